@@ -5,6 +5,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -15,10 +16,15 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"github.com/weibaohui/opendeepwiki/backend/internal/handler"
 	"github.com/weibaohui/opendeepwiki/backend/internal/mcp"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/database"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/forge"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/sandbox"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/secret"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/router"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
@@ -28,6 +34,9 @@ import (
 )
 
 func main() {
+	// 以沙箱子进程启动时执行命令后退出，必须位于其他初始化之前
+	sandbox.Init()
+
 	// 初始化 klog
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -37,6 +46,14 @@ func main() {
 
 	cfg := config.GetConfig()
 
+	if cfg.Sandbox.Enabled && !sandbox.ReadOnlySupported() {
+		if cfg.Sandbox.WeakReadOnly {
+			klog.Warningf("内核不支持 Landlock，命令沙箱只能禁止写入文件内容，无法阻止创建或删除文件")
+		} else {
+			klog.Warningf("内核不支持 Landlock，命令沙箱无法限制文件写入，run_terminal_command 将拒绝执行；可设置 sandbox.weak_read_only=true 退化执行")
+		}
+	}
+
 	if err := os.MkdirAll(cfg.Data.Dir, 0755); err != nil {
 		log.Fatalf("Failed to create data directory: %v", err)
 	}
@@ -44,6 +61,11 @@ func main() {
 		log.Fatalf("Failed to create repo directory: %v", err)
 	}
 
+	// 自建代码托管平台的域名映射，用于解析仓库地址与生成代码链接
+	if err := forge.SetHosts(cfg.Forge.Hosts); err != nil {
+		log.Fatalf("Invalid forge config: %v", err)
+	}
+
 	// 释放内嵌的默认 agents 文件（如果不存在）
 	if err := assets.ExtractAgents(cfg.Agent.Dir); err != nil {
 		log.Fatalf("Failed to extract embedded agents: %v", err)
@@ -77,14 +99,75 @@ func main() {
 	chatSessionRepo := repository.NewChatSessionRepository(db)
 	chatMessageRepo := repository.NewChatMessageRepository(db)
 	chatToolCallRepo := repository.NewChatToolCallRepository(db)
+	taskJobRepo := repository.NewTaskJobRepository(db)
+	taskDependencyRepo := repository.NewTaskDependencyRepository(db)
+	taskTraceRepo := repository.NewTaskTraceRepository(db)
+	embeddingProviderRepo := repository.NewEmbeddingProviderRepository(db)
+	embeddingChunkRepo := repository.NewEmbeddingChunkRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	userSessionRepo := repository.NewUserSessionRepository(db)
 
 	// 初始化 Service
 	docService := service.NewDocumentService(cfg, docRepo, repoRepo, ratingRepo, nil)
 	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
 	taskUsageService := service.NewTaskUsageService(taskUsageRepo)
+	taskTraceService := service.NewTaskTraceService(taskTraceRepo)
 	userRequestService := service.NewUserRequestService(userRequestRepo, repoRepo)
 	agentService := service.NewAgentService(agentVersionRepo, cfg.Agent.Dir)
 	chatService := service.NewChatService(chatSessionRepo, chatMessageRepo, chatToolCallRepo)
+	embeddingService := service.NewEmbeddingService(embeddingProviderRepo, embeddingChunkRepo, docRepo, repoRepo)
+	authService := service.NewAuthService(userRepo, apiTokenRepo, userSessionRepo, cfg.Auth.SessionTTL)
+	workspaceRepo := repository.NewWorkspaceRepository(db)
+	gitCredentialRepo := repository.NewGitCredentialRepository(db)
+	repositoryRefRepo := repository.NewRepositoryRefRepository(db)
+	repositoryWebhookRepo := repository.NewRepositoryWebhookRepository(db)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo, repoRepo, taskRepo, docRepo, userRequestRepo)
+
+	// Git 凭证加密：优先使用配置的口令，否则使用密钥文件（不存在时自动生成）
+	credentialKey := cfg.Credential.EncryptionKey
+	if credentialKey == "" {
+		if credentialKey, err = secret.LoadOrCreateKey(cfg.Credential.KeyFile); err != nil {
+			log.Fatalf("Failed to load credential key: %v", err)
+		}
+	}
+	credentialBox, err := secret.NewBox(credentialKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize credential encryption: %v", err)
+	}
+	gitCredentialService := service.NewGitCredentialService(gitCredentialRepo, credentialBox)
+
+	// 按分支、标签维护的引用文档集
+	repositoryRefService := service.NewRepositoryRefService(cfg, repoRepo, repositoryRefRepo, docRepo, taskRepo)
+	repositoryRefService.SetGitCredentialService(gitCredentialService)
+	docService.SetRepositoryRefRepository(repositoryRefRepo)
+
+	// 审计日志：注册各目标的快照函数，用于记录变更前后差异
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db), time.Duration(cfg.Audit.RetentionDays)*24*time.Hour)
+	auditService.RegisterSnapshot(service.AuditTargetRepository, service.SnapshotByID(repoRepo.GetBasic))
+	auditService.RegisterSnapshot(service.AuditTargetTask, service.SnapshotByID(func(_ context.Context, id uint) (*model.Task, error) { return taskRepo.Get(id) }))
+	auditService.RegisterSnapshot(service.AuditTargetDocument, service.SnapshotByID(func(_ context.Context, id uint) (*model.Document, error) { return docRepo.Get(id) }))
+	auditService.RegisterSnapshot(service.AuditTargetUserRequest, service.SnapshotByID(func(_ context.Context, id uint) (*model.UserRequest, error) { return userRequestRepo.GetByID(id) }))
+	auditService.RegisterSnapshot(service.AuditTargetAPIKey, service.SnapshotByID(apiKeyRepo.GetByID))
+	auditService.RegisterSnapshot(service.AuditTargetGitCredential, service.SnapshotByID(gitCredentialRepo.GetByID))
+	auditService.RegisterSnapshot(service.AuditTargetEmbeddingProvider, service.SnapshotByID(embeddingProviderRepo.GetByID))
+	auditService.RegisterSnapshot(service.AuditTargetUser, service.SnapshotByID(userRepo.GetByID))
+	auditService.RegisterSnapshot(service.AuditTargetWorkspace, service.SnapshotByID(workspaceRepo.GetByID))
+	auditService.RegisterSnapshot(service.AuditTargetAgent, func(ctx context.Context, filename string) (interface{}, error) {
+		return agentService.GetAgent(ctx, filename)
+	})
+	auditService.StartRetention(context.Background(), 24*time.Hour)
+	if cfg.Auth.Enabled {
+		adminPasswordFile := filepath.Join(cfg.Data.Dir, "initial_admin_password")
+		if err := authService.EnsureBootstrapAdmin(context.Background(), cfg.Auth.AdminUsername, cfg.Auth.AdminPassword, adminPasswordFile); err != nil {
+			log.Fatalf("Failed to create bootstrap admin: %v", err)
+		}
+		if removed, err := authService.CleanupExpiredSessions(context.Background()); err != nil {
+			klog.Warningf("清理过期会话失败: %v", err)
+		} else if removed > 0 {
+			klog.V(6).Infof("启动时清理了 %d 个过期会话", removed)
+		}
+	}
 
 	//初始化系列Writer
 	titleRewriter, err := writers.NewTitleRewriter(cfg, docRepo, taskRepo)
@@ -126,6 +209,9 @@ func main() {
 	//初始化系列Writer结束
 
 	taskService := service.NewTaskService(cfg, taskRepo, repoRepo, docService)
+	taskService.SetDependencyRepository(taskDependencyRepo)
+	taskService.SetTraceService(taskTraceService)
+	taskService.SetRepositoryRefRepository(repositoryRefRepo)
 	taskService.AddWriters(userRequestWriter)
 	taskService.AddWriters(defaultWriter)
 	taskService.AddWriters(dbModelWriter)
@@ -136,11 +222,38 @@ func main() {
 	taskService.AddWriters(incrementalWriter)
 	tocWriter.SetTaskService(taskService)
 	incrementalWriter.SetTaskService(taskService)
+	incrementalWriter.SetGitCredentialService(gitCredentialService)
+
+	// 加载 YAML 自定义写入器，并随文件变更热加载
+	customWriters, err := writers.NewCustomWriterRegistry(cfg, hintRepo, taskService)
+	if err != nil {
+		log.Fatalf("Failed to initialize custom writers: %v", err)
+	}
+	if err := customWriters.Start(); err != nil {
+		klog.Warningf("Failed to start custom writer watcher: %v", err)
+	}
+	defer customWriters.Stop()
 
 	// 初始化全局任务编排器
-	// maxWorkers=2，避免并发过多打爆CPU/LLM配额
+	// maxWorkers 默认1，避免并发过多打爆CPU/LLM配额
+	// 作业队列持久化到数据库，重启后继续分发
 	taskExecutor := &taskExecutorAdapter{taskService: taskService}
-	orchestrator.InitGlobalOrchestrator(1, taskExecutor)
+	if err := orchestrator.InitGlobalOrchestrator(cfg.Orchestrator.MaxWorkers, taskExecutor, orchestrator.NewDBJobStore(taskJobRepo)); err != nil {
+		log.Fatalf("Failed to initialize orchestrator: %v", err)
+	}
+	// 按仓库限制并发并加权轮询，避免单个仓库占满全部 worker
+	orchestrator.GetGlobalOrchestrator().SetRepoPolicy(orchestrator.RepoPolicy{
+		MaxPerRepo:  cfg.Orchestrator.MaxPerRepo,
+		RepoLimits:  cfg.Orchestrator.RepoLimits,
+		RepoWeights: cfg.Orchestrator.RepoWeights,
+	})
+	if cfg.Orchestrator.PriorityAging != "" {
+		aging, err := time.ParseDuration(cfg.Orchestrator.PriorityAging)
+		if err != nil {
+			log.Fatalf("Invalid orchestrator.priority_aging: %v", err)
+		}
+		orchestrator.GetGlobalOrchestrator().SetLaneAging(aging)
+	}
 	taskService.SetOrchestrator(orchestrator.GetGlobalOrchestrator())
 	defer orchestrator.ShutdownGlobalOrchestrator()
 
@@ -149,6 +262,10 @@ func main() {
 	subscriber.NewTaskEventSubscriber(taskService).Register(taskEventBus)
 	taskService.SetEventBus(taskEventBus)
 
+	// 初始化任务进度事件总线，供 /tasks/:id/stream 实时推送
+	taskProgressBus := eventbus.NewTaskProgressBus()
+	taskService.SetProgressBus(taskProgressBus)
+
 	// 初始化活跃度事件总线
 	activityEventBus := eventbus.NewActivityEventBus()
 	subscriber.NewActivityEventSubscriber(repoRepo, cfg).Register(activityEventBus)
@@ -163,6 +280,11 @@ func main() {
 
 	// 初始化 RepositoryService (依赖全局编排器，需要在 orchestrator 初始化之后)
 	repoService := service.NewRepositoryService(cfg, repoRepo, taskRepo, docRepo, hintRepo, incrementalHistoryRepo)
+	repoService.SetDependencyRepository(taskDependencyRepo)
+	repoService.SetGitCredentialService(gitCredentialService)
+	repoService.SetRepositoryRefRepository(repositoryRefRepo)
+	repoService.SetRepositoryWebhookRepository(repositoryWebhookRepo)
+	incrementalWriter.SetRepositoryService(repoService)
 	//注册RepoEventBus
 	repoEventBus := eventbus.NewRepositoryEventBus()
 	subscriber.NewRepositoryEventSubscriber(taskEventBus, taskService, repoService).Register(repoEventBus)
@@ -171,18 +293,48 @@ func main() {
 	// 初始化文档事件总线
 	docEventBus := eventbus.NewDocEventBus()
 	subscriber.NewDocEventSubscriber(taskEventBus, syncEventRepo).Register(docEventBus)
+	subscriber.NewDocIndexSubscriber(docService).Register(docEventBus)
+	subscriber.NewEmbeddingSubscriber(embeddingService).Register(docEventBus, repoEventBus)
+	docService.SetEventBus(docEventBus)
+	if _, err := docService.RebuildSearchIndex(); err != nil {
+		klog.Errorf("重建文档搜索索引失败: %v", err)
+	}
 
 	// 初始化 Handler
 	repoHandler := handler.NewRepositoryHandler(repoEventBus, taskEventBus, repoService, taskService)
+	repoHandler.SetRepositoryRefService(repositoryRefService)
 	taskHandler := handler.NewTaskHandler(taskService)
 	docHandler := handler.NewDocumentHandler(docEventBus, docService)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	embeddingHandler := handler.NewEmbeddingHandler(embeddingService)
 	syncService := syncservice.New(repoRepo, taskRepo, docRepo, taskUsageRepo, syncTargetRepo, syncEventRepo)
 	syncService.SetDocEventBus(docEventBus)
+	syncService.SetRemoteToken(cfg.Auth.SyncToken)
 	syncHandler := handler.NewSyncHandler(syncService)
 	userRequestHandler := handler.NewUserRequestHandler(userRequestService, taskEventBus, taskService)
 
 	agentHandler := handler.NewAgentHandler(agentService)
+	authHandler := handler.NewAuthHandler(authService, cfg.Auth.Enabled, cfg.Auth.CookieSecure)
+	if cfg.Auth.OIDC.Enabled {
+		authHandler.SetOIDCService(service.NewOIDCService(cfg.Auth.OIDC, authService, userRepo, nil))
+		klog.V(6).Infof("OIDC 单点登录已启用: issuer=%s", cfg.Auth.OIDC.IssuerURL)
+	}
+
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	auditHandler := handler.NewAuditHandler(auditService, cfg.Audit.Enabled)
+	gitCredentialHandler := handler.NewGitCredentialHandler(gitCredentialService)
+	repositoryRefHandler := handler.NewRepositoryRefHandler(repositoryRefService)
+
+	// 推送 Webhook：防抖后触发增量分析，密钥与 Git 凭证共用加密
+	webhookService := service.NewWebhookService(cfg, repoRepo, repositoryWebhookRepo, credentialBox, taskEventBus)
+	defer webhookService.Stop()
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	// Agent 评测：以固定版本的 Agent 配置运行 Writer，对样例仓库的输出打分
+	evalService := service.NewEvalService(cfg, repository.NewEvalRunRepository(db), agentService)
+	evalService.AddWriters(defaultWriter, tocWriter)
+	evalService.RecoverInterrupted(context.Background())
+	evalHandler := handler.NewEvalHandler(evalService)
 
 	// 初始化 OpenAPIHandler（AI 友好 API 端点）
 	// 提供 /.well-known/openapi.yaml 端点，供 AI 工具使用
@@ -198,6 +350,13 @@ func main() {
 		log.Fatalf("Failed to create enhanced model provider: %v", err)
 	}
 	manager.SetEnhancedModelProvider(enhancedModelProvider)
+	evalService.SetJudge(adkagents.NewProxyChatModel(enhancedModelProvider, cfg.Eval.JudgeModels))
+	adkagents.SetSemanticSearcher(embeddingService)
+	if cfg.Audit.Enabled {
+		adkagents.SetCommandAuditor(auditService)
+	}
+	// 工作空间可覆盖 agent/skill 目录，同名定义优先于全局目录
+	adkagents.SetWorkspaceDirResolver(workspaceService.Dirs)
 
 	// 创建 AgentFactory（必须在 Manager 设置 EnhancedModelProvider 之后）
 	agentFactory, err := adkagents.NewAgentFactory(cfg)
@@ -218,16 +377,20 @@ func main() {
 	// Streamable HTTP 是 MCP 协议的传输层实现，支持同步 HTTP 响应和流式事件
 	streamableServer := server.NewStreamableHTTPServer(mcpServer.GetServer())
 
-	// 启动时清理卡住的任务（超过 10 分钟的运行中任务）
+	// 启动时恢复持久化队列中的任务并清理卡住的任务（超过 10 分钟的运行中任务），完成后再开始分发
 	cleanupStuckTasks(taskService)
+	orchestrator.StartGlobalOrchestrator()
 	taskService.StartPendingTaskScheduler(context.Background(), 10*time.Second)
 
 	// 设置路由
-	r := router.Setup(cfg, repoHandler, taskHandler, docHandler, apiKeyHandler, syncHandler, userRequestHandler, openAPIHandler, activityHandler, agentHandler, chatHandler)
+	r := router.Setup(cfg, repoHandler, taskHandler, docHandler, apiKeyHandler, syncHandler, userRequestHandler, openAPIHandler, activityHandler, agentHandler, chatHandler, embeddingHandler, authHandler, workspaceHandler, auditHandler, gitCredentialHandler, repositoryRefHandler, webhookHandler, evalHandler)
 
 	// 添加 MCP Streamable HTTP 端点
 	// Streamable HTTP 使用单个端点处理 GET（流式事件）和 POST（JSON-RPC 请求）
-	r.Any("/mcp/streamable", func(c *gin.Context) {
+	// 启用认证时 MCP 客户端需携带 viewer 及以上角色的 API Token，可通过 X-Workspace-ID 头选择工作空间
+	mcpAuth := middleware.Auth(cfg.Auth.Enabled, authService, func(method, path string) string { return model.RoleViewer })
+	mcpWorkspace := middleware.Workspace(workspaceService, func(string) string { return "" })
+	r.Any("/mcp/streamable", mcpAuth, mcpWorkspace, func(c *gin.Context) {
 		streamableServer.ServeHTTP(c.Writer, c.Request)
 	})
 	klog.V(6).Info("MCP 端点已注册: /mcp/streamable")
@@ -235,6 +398,8 @@ func main() {
 	//eino callbacks注册
 	callbacks := adkagents.NewEinoCallbacks(true, 8)
 	callbacks.AppendGlobalHandlers(callbacks.Handler())
+	adkagents.SetTaskProgressBus(taskProgressBus)
+	adkagents.SetTaskTraceRecorder(taskTraceService)
 
 	log.Printf("Server starting on port %s...", cfg.Server.Port)
 	if err := r.Run(":" + cfg.Server.Port); err != nil {
@@ -244,6 +409,14 @@ func main() {
 
 // cleanupStuckTasks 清理启动前卡住的任务
 func cleanupStuckTasks(taskService *service.TaskService) {
+	// 先恢复仍在持久化队列中的中断任务，避免被当作卡住任务标记失败
+	recovered, err := taskService.RecoverInterruptedTasksOnStartup()
+	if err != nil {
+		klog.V(6).Infof("恢复中断任务失败: %v", err)
+	} else if recovered > 0 {
+		klog.V(6).Infof("启动时恢复了 %d 个中断任务", recovered)
+	}
+
 	timeout := 10 * time.Minute
 
 	affected, err := taskService.CleanupStuckTasks(timeout)
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,7 +17,19 @@ type Config struct {
 	Data     DataConfig     `yaml:"data"`
 	Agent    AgentConfig    `yaml:"agent"`
 	Skill    SkillConfig    `yaml:"skill"`
+	Writer   WriterConfig   `yaml:"writer"`
 	Activity ActivityConfig `yaml:"activity"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Audit    AuditConfig    `yaml:"audit"`
+
+	Credential CredentialConfig `yaml:"credential"`
+	Import     ImportConfig     `yaml:"import"`
+	Forge      ForgeConfig      `yaml:"forge"`
+	Webhook    WebhookConfig    `yaml:"webhook"`
+	Sandbox    SandboxConfig    `yaml:"sandbox"`
+	Eval       EvalConfig       `yaml:"eval"`
+
+	Orchestrator OrchestratorConfig `yaml:"orchestrator"`
 }
 
 type ServerConfig struct {
@@ -40,6 +54,12 @@ type SkillConfig struct {
 	Dir string
 }
 
+// WriterConfig YAML 自定义写入器配置
+type WriterConfig struct {
+	Dir            string
+	ReloadInterval time.Duration
+}
+
 type ActivityConfig struct {
 	Enabled         bool          `yaml:"enabled"`          // 是否启用活跃度功能
 	DefaultInterval time.Duration `yaml:"default_interval"` // 默认更新间隔
@@ -48,6 +68,102 @@ type ActivityConfig struct {
 	ResetHour       int           `yaml:"reset_hour"`       // 每日重置小时（0-23）
 }
 
+// AuthConfig 认证与权限配置
+type AuthConfig struct {
+	Enabled      bool          `yaml:"enabled"`       // 是否启用认证，关闭时所有接口无需登录
+	SessionTTL   time.Duration `yaml:"session_ttl"`   // 登录会话有效期
+	CookieSecure bool          `yaml:"cookie_secure"` // 会话 Cookie 是否仅通过 HTTPS 发送
+	// 首次启动且没有任何用户时创建的管理员账号；密码为空时随机生成并写入 <data.dir>/initial_admin_password（权限 0600）
+	AdminUsername string `yaml:"admin_username"`
+	AdminPassword string `yaml:"admin_password"`
+	// 向其他实例推送或拉取同步数据时携带的 API Token，目标实例启用认证时需要配置
+	SyncToken string     `yaml:"sync_token"`
+	OIDC      OIDCConfig `yaml:"oidc"`
+}
+
+// OIDCConfig OIDC 单点登录配置（授权码流程）
+type OIDCConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	IssuerURL     string   `yaml:"issuer_url"` // 身份提供方地址，通过 /.well-known/openid-configuration 发现端点
+	ClientID      string   `yaml:"client_id"`
+	ClientSecret  string   `yaml:"client_secret"`
+	RedirectURL   string   `yaml:"redirect_url"`   // 回调地址，形如 https://wiki.example.com/api/auth/oidc/callback
+	Scopes        []string `yaml:"scopes"`         // 额外申请的 scope，openid 总是包含
+	UsernameClaim string   `yaml:"username_claim"` // 作为用户名的 claim，默认 preferred_username
+	GroupsClaim   string   `yaml:"groups_claim"`   // 用户组 claim，默认 groups
+	// 用户组到角色的映射，取匹配到的最高角色
+	RoleMapping map[string]string `yaml:"role_mapping"`
+	// 未匹配任何用户组时的角色，设为 none 时拒绝登录
+	DefaultRole string `yaml:"default_role"`
+}
+
+// CredentialConfig Git 凭证加密配置
+type CredentialConfig struct {
+	EncryptionKey string `yaml:"encryption_key"` // 加密口令，为空时使用密钥文件中的随机密钥
+	KeyFile       string `yaml:"key_file"`       // 密钥文件，默认 <data.dir>/credential.key，不存在时自动生成
+}
+
+// ImportConfig 本地目录与压缩包导入配置
+type ImportConfig struct {
+	// 允许导入的服务器本地目录，导入路径必须位于其中之一；为空时禁止导入本地目录
+	LocalRoots     []string `yaml:"local_roots"`
+	MaxArchiveMB   int64    `yaml:"max_archive_mb"`   // 上传压缩包大小上限
+	MaxExtractedMB int64    `yaml:"max_extracted_mb"` // 解压或复制后的总大小上限
+	MaxFiles       int      `yaml:"max_files"`        // 解压或复制后的文件数上限
+}
+
+// ForgeConfig 代码托管平台配置
+type ForgeConfig struct {
+	// 自建实例域名到平台类型的映射，类型为 github、gitlab、gitea、bitbucket、gitee；
+	// 配置为 gitlab 的域名支持多级群组路径，代码链接按对应平台的格式生成
+	Hosts map[string]string `yaml:"hosts"`
+}
+
+// WebhookConfig 推送 Webhook 配置
+type WebhookConfig struct {
+	// 防抖时长：同一仓库在该时长内的多次推送合并为一次增量分析
+	Debounce time.Duration `yaml:"debounce"`
+}
+
+// SandboxConfig 智能体 run_terminal_command 的执行沙箱配置，命令白名单与黑名单在智能体 YAML 中配置
+type SandboxConfig struct {
+	// 是否在隔离环境中执行命令（Linux 用户与网络命名空间、Landlock 只读文件系统）；
+	// 无法隔离时命令执行失败，关闭后命令以服务进程权限直接执行
+	Enabled     bool          `yaml:"enabled"`
+	Network     bool          `yaml:"network"`       // 是否允许命令访问网络
+	Timeout     time.Duration `yaml:"timeout"`       // 单条命令的最长执行时间
+	CPUSeconds  int           `yaml:"cpu_seconds"`   // CPU 时间上限（秒）
+	MemoryMB    int           `yaml:"memory_mb"`     // 地址空间上限（MB）
+	MaxOutputKB int           `yaml:"max_output_kb"` // stdout 与 stderr 各自返回给模型的上限（KB）
+	// 内核不支持 Landlock 时是否退化为只禁止写入文件内容（仍可创建、删除文件）；关闭时命令执行失败
+	WeakReadOnly bool `yaml:"weak_read_only"`
+}
+
+// EvalConfig Agent 评测配置
+type EvalConfig struct {
+	// 样例仓库目录：每个 <name>.yaml 描述一个样例（标题、规则检查、评审标准），仓库代码位于同名子目录
+	SamplesDir string `yaml:"samples_dir"`
+	// 单个样例的最长生成时间
+	Timeout time.Duration `yaml:"timeout"`
+	// LLM 评审使用的模型（API Key 名称），为空时按优先级自动选择
+	JudgeModels []string `yaml:"judge_models"`
+}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	Enabled       bool `yaml:"enabled"`        // 是否记录写操作审计日志
+	RetentionDays int  `yaml:"retention_days"` // 保留天数，超期日志每天清理一次，0 表示永久保留
+}
+
+type OrchestratorConfig struct {
+	MaxWorkers  int          `yaml:"max_workers"`  // 全局并发 worker 数
+	MaxPerRepo  int          `yaml:"max_per_repo"` // 单仓库并发上限，0 表示不限制
+	RepoLimits  map[uint]int `yaml:"repo_limits"`  // 指定仓库ID的并发上限
+	RepoWeights map[uint]int `yaml:"repo_weights"` // 指定仓库ID的调度权重，默认 1
+	// PriorityAging 低优先级作业每等待该时长提升一个优先级通道，防止饿死；为空使用默认值 10m
+	PriorityAging string `yaml:"priority_aging"`
+}
+
 var (
 	cfg  *Config
 	once sync.Once
@@ -81,6 +197,10 @@ func loadConfig() *Config {
 		Skill: SkillConfig{
 			Dir: "./skills",
 		},
+		Writer: WriterConfig{
+			Dir:            "./writers",
+			ReloadInterval: 5 * time.Second,
+		},
 		Activity: ActivityConfig{
 			Enabled:         true,
 			DefaultInterval: 7 * 24 * time.Hour, // 7天
@@ -88,6 +208,43 @@ func loadConfig() *Config {
 			CheckInterval:   1 * time.Hour,      // 每小时检查一次
 			ResetHour:       0,                  // 每天凌晨0点重置
 		},
+		Auth: AuthConfig{
+			SessionTTL:    7 * 24 * time.Hour,
+			AdminUsername: "admin",
+			OIDC: OIDCConfig{
+				Scopes:        []string{"profile", "email"},
+				UsernameClaim: "preferred_username",
+				GroupsClaim:   "groups",
+				DefaultRole:   "viewer",
+			},
+		},
+		Audit: AuditConfig{
+			Enabled:       true,
+			RetentionDays: 180,
+		},
+		Import: ImportConfig{
+			MaxArchiveMB:   200,
+			MaxExtractedMB: 1024,
+			MaxFiles:       100000,
+		},
+		Webhook: WebhookConfig{
+			Debounce: 2 * time.Minute,
+		},
+		Sandbox: SandboxConfig{
+			Enabled:     true,
+			Timeout:     30 * time.Second,
+			CPUSeconds:  30,
+			MemoryMB:    2048,
+			MaxOutputKB: 64,
+		},
+		Eval: EvalConfig{
+			SamplesDir: "./eval/samples",
+			Timeout:    30 * time.Minute,
+		},
+		Orchestrator: OrchestratorConfig{
+			MaxWorkers: 1,
+			MaxPerRepo: 1,
+		},
 	}
 
 	configPath := os.Getenv("CONFIG_PATH")
@@ -120,12 +277,86 @@ func loadConfig() *Config {
 		config.Data.RepoDir = filepath.Join(config.Data.Dir, "repos")
 	}
 
+	if encryptionKey := os.Getenv("CREDENTIAL_ENCRYPTION_KEY"); encryptionKey != "" {
+		config.Credential.EncryptionKey = encryptionKey
+	}
+	if config.Credential.KeyFile == "" {
+		config.Credential.KeyFile = filepath.Join(config.Data.Dir, "credential.key")
+	}
+
+	// 允许导入的本地目录，多个目录用系统路径分隔符分隔
+	if localRoots := os.Getenv("IMPORT_LOCAL_ROOTS"); localRoots != "" {
+		config.Import.LocalRoots = filepath.SplitList(localRoots)
+	}
+
+	// 自建代码托管平台，格式为 host=type，多个用逗号分隔，例如 git.example.com=gitlab
+	if forgeHosts := os.Getenv("FORGE_HOSTS"); forgeHosts != "" {
+		if config.Forge.Hosts == nil {
+			config.Forge.Hosts = make(map[string]string)
+		}
+		for _, pair := range strings.Split(forgeHosts, ",") {
+			if host, kind, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				config.Forge.Hosts[strings.TrimSpace(host)] = strings.TrimSpace(kind)
+			}
+		}
+	}
+
 	if agentDir := os.Getenv("AGENT_DIR"); agentDir != "" {
 		config.Agent.Dir = agentDir
 	}
 	if skillDir := os.Getenv("SKILL_DIR"); skillDir != "" {
 		config.Skill.Dir = skillDir
 	}
+	if writerDir := os.Getenv("WRITER_DIR"); writerDir != "" {
+		config.Writer.Dir = writerDir
+	}
+
+	// 认证环境变量
+	if authEnabled := os.Getenv("AUTH_ENABLED"); authEnabled != "" {
+		config.Auth.Enabled = authEnabled == "true" || authEnabled == "1"
+	}
+	if adminUsername := os.Getenv("AUTH_ADMIN_USERNAME"); adminUsername != "" {
+		config.Auth.AdminUsername = adminUsername
+	}
+	if adminPassword := os.Getenv("AUTH_ADMIN_PASSWORD"); adminPassword != "" {
+		config.Auth.AdminPassword = adminPassword
+	}
+	if syncToken := os.Getenv("AUTH_SYNC_TOKEN"); syncToken != "" {
+		config.Auth.SyncToken = syncToken
+	}
+	if issuerURL := os.Getenv("OIDC_ISSUER_URL"); issuerURL != "" {
+		config.Auth.OIDC.IssuerURL = issuerURL
+		config.Auth.OIDC.Enabled = true
+	}
+	if clientID := os.Getenv("OIDC_CLIENT_ID"); clientID != "" {
+		config.Auth.OIDC.ClientID = clientID
+	}
+	if clientSecret := os.Getenv("OIDC_CLIENT_SECRET"); clientSecret != "" {
+		config.Auth.OIDC.ClientSecret = clientSecret
+	}
+	if redirectURL := os.Getenv("OIDC_REDIRECT_URL"); redirectURL != "" {
+		config.Auth.OIDC.RedirectURL = redirectURL
+	}
+
+	// 审计日志环境变量
+	if auditEnabled := os.Getenv("AUDIT_ENABLED"); auditEnabled != "" {
+		config.Audit.Enabled = auditEnabled == "true" || auditEnabled == "1"
+	}
+	if retention := os.Getenv("AUDIT_RETENTION_DAYS"); retention != "" {
+		if days, err := strconv.Atoi(retention); err == nil {
+			config.Audit.RetentionDays = days
+		}
+	}
+
+	// 命令沙箱环境变量
+	if sandboxEnabled := os.Getenv("SANDBOX_ENABLED"); sandboxEnabled != "" {
+		config.Sandbox.Enabled = sandboxEnabled == "true" || sandboxEnabled == "1"
+	}
+
+	// Agent 评测环境变量
+	if samplesDir := os.Getenv("EVAL_SAMPLES_DIR"); samplesDir != "" {
+		config.Eval.SamplesDir = samplesDir
+	}
 
 	return config
 }
@@ -0,0 +1,34 @@
+from flask import Flask, abort, jsonify, request
+
+from todo.store import TodoStore
+
+app = Flask(__name__)
+store = TodoStore()
+
+
+@app.get("/todos")
+def list_todos():
+    done = request.args.get("done")
+    items = store.list(done=None if done is None else done == "true")
+    return jsonify([item.to_dict() for item in items])
+
+
+@app.post("/todos")
+def create_todo():
+    payload = request.get_json(silent=True) or {}
+    title = (payload.get("title") or "").strip()
+    if not title:
+        abort(400, "title is required")
+    return jsonify(store.add(title).to_dict()), 201
+
+
+@app.post("/todos/<int:todo_id>/complete")
+def complete_todo(todo_id):
+    item = store.complete(todo_id)
+    if item is None:
+        abort(404)
+    return jsonify(item.to_dict())
+
+
+if __name__ == "__main__":
+    app.run(port=8000)
@@ -0,0 +1,44 @@
+from dataclasses import asdict, dataclass, field
+from datetime import datetime, timezone
+from threading import Lock
+
+
+@dataclass
+class Todo:
+    id: int
+    title: str
+    done: bool = False
+    created_at: str = field(default_factory=lambda: datetime.now(timezone.utc).isoformat())
+
+    def to_dict(self):
+        return asdict(self)
+
+
+class TodoStore:
+    """In-memory todo storage guarded by a lock."""
+
+    def __init__(self):
+        self._items = {}
+        self._next_id = 1
+        self._lock = Lock()
+
+    def add(self, title):
+        with self._lock:
+            item = Todo(id=self._next_id, title=title)
+            self._items[item.id] = item
+            self._next_id += 1
+            return item
+
+    def list(self, done=None):
+        with self._lock:
+            items = list(self._items.values())
+        if done is None:
+            return items
+        return [item for item in items if item.done == done]
+
+    def complete(self, todo_id):
+        with self._lock:
+            item = self._items.get(todo_id)
+            if item is not None:
+                item.done = True
+            return item
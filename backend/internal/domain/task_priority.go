@@ -0,0 +1,39 @@
+package domain
+
+// TaskPriority 任务优先级通道，编排器按通道从高到低分发
+type TaskPriority string
+
+const (
+	PriorityInteractive TaskPriority = "interactive" // 交互任务：用户需求、手动运行
+	PriorityNormal      TaskPriority = "normal"      // 普通任务：默认通道
+	PriorityBackground  TaskPriority = "background"  // 后台任务：增量更新等定时触发的任务
+)
+
+// Rank 返回优先级序号，数值越小越先分发；未知或为空时按普通任务处理
+func (p TaskPriority) Rank() int {
+	switch p {
+	case PriorityInteractive:
+		return 0
+	case PriorityBackground:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Valid 判断是否为合法的优先级
+func (p TaskPriority) Valid() bool {
+	return p == PriorityInteractive || p == PriorityNormal || p == PriorityBackground
+}
+
+// PriorityByRank 根据序号返回优先级，超出范围时取最近的通道
+func PriorityByRank(rank int) TaskPriority {
+	switch {
+	case rank <= 0:
+		return PriorityInteractive
+	case rank >= 2:
+		return PriorityBackground
+	default:
+		return PriorityNormal
+	}
+}
@@ -23,6 +23,15 @@ type Writer interface {
 	Generate(ctx context.Context, localPath string, title string, taskID uint) (string, error)
 }
 
+// Previewer 可离线评测的 Writer：只生成内容，不创建任务也不写入文档
+type Previewer interface {
+	Writer
+	// Preview 对 localPath 处的仓库生成内容，title 为文档标题（不需要标题的 Writer 忽略）
+	Preview(ctx context.Context, localPath string, title string) (string, error)
+	// Agents 生成过程中使用的 Agent 名称
+	Agents() []string
+}
+
 // 错误定义
 var (
 	ErrInvalidLocalPath         = errors.New("invalid local path")
@@ -0,0 +1,291 @@
+package writers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"github.com/weibaohui/opendeepwiki/backend/internal/utils"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// 自定义写入器的输出处理方式
+const (
+	CustomOutputDocument = "document" // Agent 输出即文档内容
+	CustomOutputHint     = "hint"     // Agent 输出 YAML 线索，保存到 TaskHint
+	CustomOutputSubtasks = "subtasks" // Agent 输出 YAML 目录，为每一项创建文档任务
+)
+
+// CustomWriterSpec YAML 定义的自定义写入器
+//
+// 示例:
+//
+//	name: SecurityReviewWriter
+//	agents: [document_generator, document_checker, markdown_checker]
+//	session_values:
+//	  review_focus: security
+//	output: document
+//	prompt: |
+//	  仓库地址: {{.LocalPath}}
+//	  文档标题: {{.Title}}
+type CustomWriterSpec struct {
+	Name          string            `yaml:"name"`
+	Description   string            `yaml:"description"`
+	Agents        []string          `yaml:"agents"`         // 按顺序执行的 Agent 链
+	Prompt        string            `yaml:"prompt"`         // text/template 格式的初始消息
+	SessionValues map[string]string `yaml:"session_values"` // 额外写入 Agent 会话的值
+	Output        string            `yaml:"output"`         // document（默认）, hint, subtasks
+	SubtaskWriter string            `yaml:"subtask_writer"` // subtasks 输出时子任务使用的写入器，默认 DefaultWriter
+}
+
+// customPromptData 提示词模板可用的变量
+type customPromptData struct {
+	LocalPath    string
+	Title        string
+	Outline      string
+	Hints        string
+	TaskID       uint
+	RepositoryID uint
+}
+
+// customHintResult hint 输出的 YAML 结构
+type customHintResult struct {
+	Hints []domain.DirMakerHintSpec `yaml:"hints"`
+}
+
+// LoadCustomWriterSpec 从 YAML 文件读取并校验自定义写入器定义
+func LoadCustomWriterSpec(path string) (*CustomWriterSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取写入器定义失败: %w", err)
+	}
+	return ParseCustomWriterSpec(data)
+}
+
+// ParseCustomWriterSpec 解析并校验自定义写入器定义
+func ParseCustomWriterSpec(data []byte) (*CustomWriterSpec, error) {
+	var spec CustomWriterSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrYAMLParseFailed, err)
+	}
+
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return nil, fmt.Errorf("写入器名称不能为空")
+	}
+	if len(spec.Agents) == 0 {
+		return nil, fmt.Errorf("写入器 %s 未配置 agents", spec.Name)
+	}
+	if strings.TrimSpace(spec.Prompt) == "" {
+		return nil, fmt.Errorf("写入器 %s 未配置 prompt", spec.Name)
+	}
+	switch spec.Output {
+	case "":
+		spec.Output = CustomOutputDocument
+	case CustomOutputDocument, CustomOutputHint, CustomOutputSubtasks:
+	default:
+		return nil, fmt.Errorf("写入器 %s 的 output 无效: %s", spec.Name, spec.Output)
+	}
+	if spec.SubtaskWriter == "" {
+		spec.SubtaskWriter = string(domain.DefaultWriter)
+	}
+	if _, err := template.New(spec.Name).Parse(spec.Prompt); err != nil {
+		return nil, fmt.Errorf("写入器 %s 的 prompt 模板无效: %w", spec.Name, err)
+	}
+	return &spec, nil
+}
+
+// customWriter 由 YAML 定义驱动的写入器
+type customWriter struct {
+	spec        *CustomWriterSpec
+	prompt      *template.Template
+	factory     *adkagents.AgentFactory
+	hintRepo    repository.HintRepository
+	taskService *service.TaskService
+}
+
+func newCustomWriter(spec *CustomWriterSpec, factory *adkagents.AgentFactory, hintRepo repository.HintRepository, taskService *service.TaskService) (*customWriter, error) {
+	prompt, err := template.New(spec.Name).Parse(spec.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("解析 prompt 模板失败: %w", err)
+	}
+	return &customWriter{
+		spec:        spec,
+		prompt:      prompt,
+		factory:     factory,
+		hintRepo:    hintRepo,
+		taskService: taskService,
+	}, nil
+}
+
+func (s *customWriter) Name() domain.WriterName {
+	return domain.WriterName(s.spec.Name)
+}
+
+// Generate 按定义执行 Agent 链，并按 output 处理输出
+func (s *customWriter) Generate(ctx context.Context, localPath string, title string, taskID uint) (string, error) {
+	if localPath == "" {
+		return "", fmt.Errorf("%w: local path is empty", domain.ErrInvalidLocalPath)
+	}
+	if title == "" {
+		return "", fmt.Errorf("%w: title is empty", domain.ErrInvalidLocalPath)
+	}
+
+	task, err := s.taskService.Get(taskID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrTaskNotFound, err)
+	}
+
+	klog.V(6).Infof("[%s] 开始生成: 仓库路径=%s, 标题=%s, 任务ID=%d, output=%s", s.Name(), localPath, title, taskID, s.spec.Output)
+
+	adk.AddSessionValue(ctx, "local_path", localPath)
+	adk.AddSessionValue(ctx, "document_title", title)
+	adk.AddSessionValue(ctx, "task_id", taskID)
+	for key, value := range s.spec.SessionValues {
+		adk.AddSessionValue(ctx, key, value)
+	}
+
+	initialMessage, err := s.renderPrompt(customPromptData{
+		LocalPath:    localPath,
+		Title:        title,
+		Outline:      task.Outline,
+		Hints:        buildTaskHintPrompt(s.hintRepo, taskID),
+		TaskID:       taskID,
+		RepositoryID: task.RepositoryID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	agent, err := adkagents.BuildSequentialAgent(
+		ctx,
+		s.factory,
+		s.spec.Name+"_sequential_agent",
+		s.spec.Description,
+		s.spec.Agents...,
+	)
+	if err != nil {
+		return "", fmt.Errorf("create agent failed: %w", err)
+	}
+
+	lastContent, err := adkagents.RunAgentToLastContent(ctx, agent, []adk.Message{
+		{
+			Role:    schema.User,
+			Content: initialMessage,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrAgentExecutionFailed, err)
+	}
+	if lastContent == "" {
+		return "", domain.ErrNoAgentOutput
+	}
+	klog.V(8).Infof("[%s] Agent 输出内容: \n%s\n", s.Name(), lastContent)
+
+	switch s.spec.Output {
+	case CustomOutputHint:
+		return s.saveHints(task, lastContent)
+	case CustomOutputSubtasks:
+		return s.createSubtasks(ctx, task, lastContent)
+	default:
+		return lastContent, nil
+	}
+}
+
+func (s *customWriter) renderPrompt(data customPromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := s.prompt.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染 prompt 模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// saveHints 解析 Agent 输出的线索并保存，返回线索汇总作为文档内容
+func (s *customWriter) saveHints(task *model.Task, content string) (string, error) {
+	yamlStr := utils.ExtractYAML(content)
+	if yamlStr == "" {
+		return "", fmt.Errorf("%w: 提取 YAML 失败", domain.ErrYAMLParseFailed)
+	}
+	var result customHintResult
+	if err := yaml.Unmarshal([]byte(yamlStr), &result); err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrYAMLParseFailed, err)
+	}
+
+	hints := make([]model.TaskHint, 0, len(result.Hints))
+	builder := &strings.Builder{}
+	builder.WriteString(fmt.Sprintf("# %s\n\n", task.Title))
+	for _, item := range result.Hints {
+		hints = append(hints, model.TaskHint{
+			RepositoryID: task.RepositoryID,
+			TaskID:       task.ID,
+			Title:        task.Title,
+			Aspect:       item.Aspect,
+			Source:       item.Source,
+			Detail:       item.Detail,
+		})
+		builder.WriteString(fmt.Sprintf("- **%s**（%s）: %s\n", safe(item.Aspect), safe(item.Source), safe(item.Detail)))
+	}
+	if s.hintRepo != nil && len(hints) > 0 {
+		if err := s.hintRepo.CreateBatch(hints); err != nil {
+			return "", fmt.Errorf("保存线索失败: %w", err)
+		}
+	}
+	klog.V(6).Infof("[%s] 已保存线索: taskID=%d, count=%d", s.Name(), task.ID, len(hints))
+	return builder.String(), nil
+}
+
+// createSubtasks 解析 Agent 输出的目录并创建文档任务，返回子任务列表作为文档内容
+func (s *customWriter) createSubtasks(ctx context.Context, task *model.Task, content string) (string, error) {
+	result, err := parseDirList(content)
+	if err != nil {
+		return "", err
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString(fmt.Sprintf("# %s\n\n", task.Title))
+	if result.AnalysisSummary != "" {
+		builder.WriteString(result.AnalysisSummary)
+		builder.WriteString("\n\n")
+	}
+	created := 0
+	for _, dir := range result.Dirs {
+		subtask, err := s.taskService.CreateDocWriteTask(ctx, task.RepositoryID, dir.Title, dir.Outline, dir.SortOrder, domain.WriterName(s.spec.SubtaskWriter))
+		if err != nil {
+			klog.Errorf("[%s] 创建子任务失败: repoID=%d, title=%s, error=%v", s.Name(), task.RepositoryID, dir.Title, err)
+			continue
+		}
+		created++
+		builder.WriteString(fmt.Sprintf("- %s\n", dir.Title))
+
+		if s.hintRepo == nil || len(dir.Hint) == 0 {
+			continue
+		}
+		hints := make([]model.TaskHint, 0, len(dir.Hint))
+		for _, item := range dir.Hint {
+			hints = append(hints, model.TaskHint{
+				RepositoryID: task.RepositoryID,
+				TaskID:       subtask.ID,
+				Title:        dir.Title,
+				Aspect:       item.Aspect,
+				Source:       item.Source,
+				Detail:       item.Detail,
+			})
+		}
+		if err := s.hintRepo.CreateBatch(hints); err != nil {
+			klog.Errorf("[%s] 保存子任务线索失败: taskID=%d, error=%v", s.Name(), subtask.ID, err)
+		}
+	}
+	klog.V(6).Infof("[%s] 已创建子任务: taskID=%d, count=%d", s.Name(), task.ID, created)
+	return builder.String(), nil
+}
@@ -0,0 +1,127 @@
+package writers
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// CustomWriterRegistry 从 Writer.Dir 加载 YAML 自定义写入器，注册到 TaskService 并热加载
+type CustomWriterRegistry struct {
+	dir         string
+	factory     *adkagents.AgentFactory
+	hintRepo    repository.HintRepository
+	taskService *service.TaskService
+	watcher     *adkagents.FileWatcher
+
+	// 文件路径 -> 已注册的写入器名称
+	loaded map[string]domain.WriterName
+	mutex  sync.Mutex
+}
+
+// NewCustomWriterRegistry 创建自定义写入器注册器
+func NewCustomWriterRegistry(cfg *config.Config, hintRepo repository.HintRepository, taskService *service.TaskService) (*CustomWriterRegistry, error) {
+	factory, err := adkagents.NewAgentFactory(cfg)
+	if err != nil {
+		klog.Errorf("[CustomWriterRegistry] 创建 AgentFactory 失败: %v", err)
+		return nil, fmt.Errorf("create AgentFactory failed: %w", err)
+	}
+
+	r := &CustomWriterRegistry{
+		dir:         cfg.Writer.Dir,
+		factory:     factory,
+		hintRepo:    hintRepo,
+		taskService: taskService,
+		loaded:      make(map[string]domain.WriterName),
+	}
+	r.watcher = adkagents.NewFileWatcher(cfg.Writer.Dir, cfg.Writer.ReloadInterval, r.handleEvent)
+	return r, nil
+}
+
+// Start 加载目录下已有的写入器定义并开始监听变更
+func (r *CustomWriterRegistry) Start() error {
+	// 首次扫描时所有文件都会以 create 事件回调，完成初始加载
+	return r.watcher.Start()
+}
+
+// Stop 停止监听
+func (r *CustomWriterRegistry) Stop() {
+	r.watcher.Stop()
+}
+
+func (r *CustomWriterRegistry) handleEvent(event adkagents.FileEvent) {
+	switch event.Type {
+	case "create", "modify":
+		if err := r.load(event.Path); err != nil {
+			klog.Errorf("[CustomWriterRegistry] 加载写入器失败: path=%s, error=%v", event.Path, err)
+			return
+		}
+		klog.V(6).Infof("[CustomWriterRegistry] 已加载写入器: path=%s", event.Path)
+	case "delete":
+		r.unload(event.Path)
+	}
+}
+
+// load 解析定义文件并注册；解析失败时保留之前加载的版本
+func (r *CustomWriterRegistry) load(path string) error {
+	spec, err := LoadCustomWriterSpec(path)
+	if err != nil {
+		return err
+	}
+	writer, err := newCustomWriter(spec, r.factory, r.hintRepo, r.taskService)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	name := writer.Name()
+	for otherPath, otherName := range r.loaded {
+		if otherName == name && otherPath != path {
+			return fmt.Errorf("写入器 %s 已由 %s 定义", name, otherPath)
+		}
+	}
+	previous, reloading := r.loaded[path]
+	if !r.ownsLocked(name) {
+		if _, err := r.taskService.GetWriter(name); err == nil {
+			return fmt.Errorf("写入器 %s 与内置写入器重名", name)
+		}
+	}
+
+	if reloading {
+		r.taskService.RemoveWriter(previous)
+	}
+	r.taskService.AddWriters(writer)
+	r.loaded[path] = name
+	return nil
+}
+
+// unload 注销文件对应的写入器
+func (r *CustomWriterRegistry) unload(path string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	name, ok := r.loaded[path]
+	if !ok {
+		return
+	}
+	r.taskService.RemoveWriter(name)
+	delete(r.loaded, path)
+	klog.V(6).Infof("[CustomWriterRegistry] 已注销写入器: name=%s, path=%s", name, path)
+}
+
+func (r *CustomWriterRegistry) ownsLocked(name domain.WriterName) bool {
+	for _, loadedName := range r.loaded {
+		if loadedName == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package writers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+const testWriterYAML = `name: %s
+agents: [document_generator]
+prompt: |
+  仓库: {{.LocalPath}} 标题: {{.Title}}{{if .Outline}} 大纲: {{.Outline}}{{end}}
+`
+
+func writeWriterSpec(t *testing.T, path string, name string) {
+	t.Helper()
+	content := strings.Replace(testWriterYAML, "%s", name, 1)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write spec error: %v", err)
+	}
+}
+
+func TestParseCustomWriterSpec(t *testing.T) {
+	spec, err := ParseCustomWriterSpec([]byte(strings.Replace(testWriterYAML, "%s", "SecurityReviewWriter", 1)))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if spec.Output != CustomOutputDocument || spec.SubtaskWriter != string(domain.DefaultWriter) {
+		t.Fatalf("unexpected defaults: output=%s subtaskWriter=%s", spec.Output, spec.SubtaskWriter)
+	}
+
+	writer, err := newCustomWriter(spec, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("new writer error: %v", err)
+	}
+	prompt, err := writer.renderPrompt(customPromptData{LocalPath: "/repo", Title: "安全评审", Outline: "认证"})
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	if !strings.Contains(prompt, "/repo") || !strings.Contains(prompt, "大纲: 认证") {
+		t.Fatalf("unexpected prompt: %s", prompt)
+	}
+
+	invalid := []string{
+		"agents: [a]\nprompt: x\n",
+		"name: X\nprompt: x\n",
+		"name: X\nagents: [a]\n",
+		"name: X\nagents: [a]\nprompt: x\noutput: pdf\n",
+		"name: X\nagents: [a]\nprompt: '{{.Title'\n",
+	}
+	for _, data := range invalid {
+		if _, err := ParseCustomWriterSpec([]byte(data)); err == nil {
+			t.Fatalf("expected error for spec: %q", data)
+		}
+	}
+}
+
+func TestCustomWriterRegistryHotReload(t *testing.T) {
+	dir := t.TempDir()
+	taskService := service.NewTaskService(nil, nil, nil, nil)
+	taskService.AddWriters(&customWriter{spec: &CustomWriterSpec{Name: string(domain.DefaultWriter)}})
+	r := &CustomWriterRegistry{
+		dir:         dir,
+		taskService: taskService,
+		loaded:      make(map[string]domain.WriterName),
+	}
+
+	path := filepath.Join(dir, "security.yaml")
+	writeWriterSpec(t, path, "SecurityReviewWriter")
+	r.handleEvent(adkagents.FileEvent{Type: "create", Path: path})
+	if _, err := taskService.GetWriter("SecurityReviewWriter"); err != nil {
+		t.Fatalf("expected writer registered: %v", err)
+	}
+
+	// 修改名称后旧写入器被替换
+	writeWriterSpec(t, path, "SecurityAuditWriter")
+	r.handleEvent(adkagents.FileEvent{Type: "modify", Path: path})
+	if _, err := taskService.GetWriter("SecurityReviewWriter"); err == nil {
+		t.Fatalf("expected old writer removed")
+	}
+	if _, err := taskService.GetWriter("SecurityAuditWriter"); err != nil {
+		t.Fatalf("expected renamed writer registered: %v", err)
+	}
+
+	// 无效修改保留上一个版本
+	if err := os.WriteFile(path, []byte("name: [broken"), 0644); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	r.handleEvent(adkagents.FileEvent{Type: "modify", Path: path})
+	if _, err := taskService.GetWriter("SecurityAuditWriter"); err != nil {
+		t.Fatalf("expected previous writer kept: %v", err)
+	}
+
+	// 不允许覆盖内置写入器
+	builtin := filepath.Join(dir, "builtin.yaml")
+	writeWriterSpec(t, builtin, string(domain.DefaultWriter))
+	r.handleEvent(adkagents.FileEvent{Type: "create", Path: builtin})
+	if _, ok := r.loaded[builtin]; ok {
+		t.Fatalf("expected builtin name rejected")
+	}
+
+	r.handleEvent(adkagents.FileEvent{Type: "delete", Path: path})
+	if _, err := taskService.GetWriter("SecurityAuditWriter"); err == nil {
+		t.Fatalf("expected writer unloaded")
+	}
+	if _, err := taskService.GetWriter(domain.DefaultWriter); err != nil {
+		t.Fatalf("expected builtin writer untouched: %v", err)
+	}
+}
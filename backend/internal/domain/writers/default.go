@@ -61,6 +61,15 @@ func (s *defaultWriter) Generate(ctx context.Context, localPath string, title st
 	return markdown, nil
 }
 
+// Preview 不关联任务生成文档，用于评测
+func (s *defaultWriter) Preview(ctx context.Context, localPath string, title string) (string, error) {
+	return s.Generate(ctx, localPath, title, 0)
+}
+
+func (s *defaultWriter) Agents() []string {
+	return []string{domain.AgentGen, domain.AgentCheck, domain.AgentDocCheck}
+}
+
 func (s *defaultWriter) genDocument(ctx context.Context, localPath string, title string, taskID uint) (string, error) {
 	adk.AddSessionValue(ctx, "local_path", localPath)
 	adk.AddSessionValue(ctx, "document_title", title)
@@ -71,9 +80,7 @@ func (s *defaultWriter) genDocument(ctx context.Context, localPath string, title
 		s.factory,
 		"document_generator_sequential_agent",
 		"document generator sequential agent - analyze code and generate documentation",
-		domain.AgentGen,
-		domain.AgentCheck,
-		domain.AgentDocCheck,
+		s.Agents()...,
 	)
 
 	if err != nil {
@@ -120,10 +127,15 @@ func (s *defaultWriter) genDocument(ctx context.Context, localPath string, title
 }
 
 func (s *defaultWriter) buildHintPrompt(taskID uint) string {
-	if s.hintRepo == nil || taskID == 0 {
+	return buildTaskHintPrompt(s.hintRepo, taskID)
+}
+
+// buildTaskHintPrompt 将任务关联的线索拼接为提示词片段
+func buildTaskHintPrompt(hintRepo repository.HintRepository, taskID uint) string {
+	if hintRepo == nil || taskID == 0 {
 		return ""
 	}
-	hints, err := s.hintRepo.GetByTaskID(taskID)
+	hints, err := hintRepo.GetByTaskID(taskID)
 	if err != nil {
 		klog.V(6).Infof("[dgen.buildHintPrompt] 读取任务证据失败: taskID=%d, error=%v", taskID, err)
 		return ""
@@ -62,7 +62,7 @@ func (s *docRewriter) Generate(ctx context.Context, localPath string, title stri
 		return "", fmt.Errorf("rewrite guide is empty")
 	}
 
-	agent, err := s.factory.Manager.CreateAgent(domain.AgentDocRewriter)
+	agent, err := s.factory.Manager.CreateAgentFor(ctx, domain.AgentDocRewriter)
 	if err != nil {
 		klog.Errorf("[%s] 创建 Agent '%s' 失败: %v", s.Name(), domain.AgentDocRewriter, err)
 		return "", fmt.Errorf("create agent failed: %w", err)
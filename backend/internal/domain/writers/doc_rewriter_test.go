@@ -26,6 +26,10 @@ func (m *mockDocRepo) GetByRepository(repoID uint) ([]model.Document, error) {
 	return nil, nil
 }
 
+func (m *mockDocRepo) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	return nil, nil
+}
+
 func (m *mockDocRepo) GetAllDocumentsTitleAndID(repoID uint) ([]model.Document, error) {
 	return nil, nil
 }
@@ -42,6 +46,10 @@ func (m *mockDocRepo) Get(id uint) (*model.Document, error) {
 	return doc, nil
 }
 
+func (m *mockDocRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return m.Get(id)
+}
+
 func (m *mockDocRepo) Save(doc *model.Document) error {
 	if m.docs == nil {
 		m.docs = make(map[uint]*model.Document)
@@ -63,6 +71,10 @@ func (m *mockDocRepo) DeleteByRepositoryID(repoID uint) error {
 	return nil
 }
 
+func (m *mockDocRepo) DeleteByRepositoryRef(repoID uint, ref string) error {
+	return nil
+}
+
 func (m *mockDocRepo) UpdateTaskID(docID uint, taskID uint) error {
 	return nil
 }
@@ -123,6 +135,10 @@ func (m *mockTaskRepo) Get(id uint) (*model.Task, error) {
 	return task, nil
 }
 
+func (m *mockTaskRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Task, error) {
+	return m.Get(id)
+}
+
 func (m *mockTaskRepo) Save(task *model.Task) error {
 	return nil
 }
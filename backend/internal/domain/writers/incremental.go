@@ -13,6 +13,7 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
@@ -30,6 +31,8 @@ type incrementalWriter struct {
 	taskService  *service.TaskService
 	repoBus      *eventbus.RepositoryEventBus
 	historyRepo  repository.IncrementalUpdateHistoryRepository
+	credentials  *service.GitCredentialService
+	repoService  *service.RepositoryService
 }
 
 func NewIncrementalWriter(cfg *config.Config, repoRepo repository.RepoRepository, taskRepo repository.TaskRepository, taskHintRepo repository.HintRepository, docRepo repository.DocumentRepository, historyRepo repository.IncrementalUpdateHistoryRepository) (*incrementalWriter, error) {
@@ -55,6 +58,16 @@ func (s *incrementalWriter) Name() domain.WriterName {
 	return domain.IncrementalWriter
 }
 
+// SetGitCredentialService 设置 Git 凭证服务，拉取私有仓库时使用
+func (s *incrementalWriter) SetGitCredentialService(credentials *service.GitCredentialService) {
+	s.credentials = credentials
+}
+
+// SetRepositoryService 设置仓库服务，增量更新本地目录与压缩包导入的仓库时用于同步代码
+func (s *incrementalWriter) SetRepositoryService(repoService *service.RepositoryService) {
+	s.repoService = repoService
+}
+
 func (s *incrementalWriter) SetTaskService(taskService *service.TaskService) {
 	s.taskService = taskService
 }
@@ -69,7 +82,7 @@ func (s *incrementalWriter) Generate(ctx context.Context, localPath string, titl
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", domain.ErrTaskNotFound, err)
 	}
-	repo, err := s.repoRepo.Get(task.RepositoryID)
+	repo, err := s.repoRepo.Get(ctx, task.RepositoryID)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", domain.ErrRepoNotFound, err)
 	}
@@ -125,12 +138,34 @@ func (s *incrementalWriter) createIncrementalPlan(ctx context.Context, repo *mod
 		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidLocalPath, err)
 	}
 
-	klog.V(6).Infof("[%s] 执行 git pull: repoID=%d, localPath=%s", s.Name(), repo.ID, repo.LocalPath)
-	pullOutput, err := git.Pull(ctx, repo.LocalPath)
-	if err != nil {
-		return nil, fmt.Errorf("git pull 失败: %w", err)
+	if service.IsImportedSource(repo) {
+		// 导入的仓库没有远端可拉取，重新同步导入来源并提交快照
+		if s.repoService == nil {
+			return nil, fmt.Errorf("仓库服务为空，无法同步导入的代码")
+		}
+		if err := s.repoService.SyncImportedSource(ctx, repo); err != nil {
+			return nil, fmt.Errorf("同步导入代码失败: %w", err)
+		}
+		klog.V(6).Infof("[%s] 导入代码已同步: repoID=%d, source=%s", s.Name(), repo.ID, repo.SourceType)
+	} else {
+		if s.credentials != nil {
+			auth, err := s.credentials.Resolve(ctx, repo)
+			if err != nil {
+				return nil, fmt.Errorf("解析 Git 凭证失败: %w", err)
+			}
+			ctx = git.WithAuth(ctx, auth)
+		}
+
+		ref := git.NewRef(repo.CloneRefType, repo.CloneBranch)
+		klog.V(6).Infof("[%s] 执行 git pull: repoID=%d, localPath=%s, ref=%s %s", s.Name(), repo.ID, repo.LocalPath, ref.Type, ref.Name)
+		pullOutput, err := git.Pull(ctx, repo.LocalPath, ref)
+		if err != nil {
+			return nil, fmt.Errorf("git pull 失败: %w", err)
+		}
+		klog.V(6).Infof("[%s] git pull 完成: repoID=%d, 输出=%s", s.Name(), repo.ID, pullOutput)
+		// 代码已更新，重建符号索引
+		codeindex.DefaultStore().Warm(repo.LocalPath)
 	}
-	klog.V(6).Infof("[%s] git pull 完成: repoID=%d, 输出=%s", s.Name(), repo.ID, pullOutput)
 
 	if err := git.EnsureBaseCommitAvailable(ctx, repo.LocalPath, repo.CloneCommit); err != nil {
 		return nil, err
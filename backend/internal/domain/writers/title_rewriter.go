@@ -64,7 +64,7 @@ func (s *titleRewriter) Generate(ctx context.Context, localPath string, title st
 	klog.V(6).Infof("[%s] 当前标题: %s", s.Name(), oldTitle)
 
 	// 2. 调用 Agent
-	agent, err := s.factory.Manager.CreateAgent(domain.AgentTitleRewriter)
+	agent, err := s.factory.Manager.CreateAgentFor(ctx, domain.AgentTitleRewriter)
 	if err != nil {
 		klog.Errorf("[%s] 创建 Agent '%s' 失败: %v", s.Name(), domain.AgentTitleRewriter, err)
 		return "", fmt.Errorf("create agent failed: %w", err)
@@ -58,12 +58,13 @@ func (s *tocWriter) Generate(ctx context.Context, localPath string, title string
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", domain.ErrTaskNotFound, err)
 	}
-	repo, err := s.repoRepo.Get(task.RepositoryID)
+	repo, err := s.repoRepo.Get(ctx, task.RepositoryID)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", domain.ErrRepoNotFound, err)
 	}
 
-	result, err := s.createDirs(ctx, repo)
+	// localPath 为任务所属文档集的检出目录，引用文档集与仓库主目录不同
+	result, err := s.createDirs(ctx, localPath)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", domain.ErrDirMakerGenerationFailed, err)
 	}
@@ -86,17 +87,34 @@ func (s *tocWriter) Generate(ctx context.Context, localPath string, title string
 	return "", nil
 }
 
+// Preview 生成目录但不创建任务，返回 YAML 格式的目录结构，用于评测
+func (s *tocWriter) Preview(ctx context.Context, localPath string, title string) (string, error) {
+	result, err := s.createDirs(ctx, localPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrDirMakerGenerationFailed, err)
+	}
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrYAMLParseFailed, err)
+	}
+	return string(data), nil
+}
+
+func (s *tocWriter) Agents() []string {
+	return []string{domain.AgentTocEditor, domain.AgentTocChecker}
+}
+
 // CreateDirs 分析仓库目录并创建目录。
-func (s *tocWriter) createDirs(ctx context.Context, repo *model.Repository) (*domain.DirMakerGenerationResult, error) {
+func (s *tocWriter) createDirs(ctx context.Context, localPath string) (*domain.DirMakerGenerationResult, error) {
 
-	if repo.LocalPath == "" {
-		return nil, fmt.Errorf("%w: repo.LocalPath 为空", domain.ErrInvalidLocalPath)
+	if localPath == "" {
+		return nil, fmt.Errorf("%w: localPath 为空", domain.ErrInvalidLocalPath)
 	}
-	if _, err := os.Stat(repo.LocalPath); err != nil {
+	if _, err := os.Stat(localPath); err != nil {
 		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidLocalPath, err)
 	}
 
-	result, err := s.genDirList(ctx, repo.LocalPath)
+	result, err := s.genDirList(ctx, localPath)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", domain.ErrAgentExecutionFailed, err)
 	}
@@ -114,8 +132,7 @@ func (s *tocWriter) genDirList(ctx context.Context, localPath string) (*domain.D
 		s.factory,
 		"toc_generator_sequential_agent",
 		"目录制定者顺序执行 Agent - 先生成目录，再校验修正",
-		domain.AgentTocEditor,
-		domain.AgentTocChecker,
+		s.Agents()...,
 	)
 
 	initialMessage := fmt.Sprintf(`请帮我分析这个代码仓库，并生成需要的技术分析任务列表。
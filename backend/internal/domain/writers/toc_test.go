@@ -0,0 +1,132 @@
+package writers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+)
+
+type noopAPIKeyService struct{}
+
+func (noopAPIKeyService) MarkUnavailable(ctx context.Context, apiKeyID uint, resetTime time.Time) error {
+	return nil
+}
+
+func (noopAPIKeyService) RecordRequest(ctx context.Context, apiKeyID uint, success bool) error {
+	return nil
+}
+
+var (
+	replayConfigOnce sync.Once
+	replayConfig     *config.Config
+)
+
+// replayTestConfig Agent Manager 是进程内单例，首次创建时的 Agent 目录与数据目录对之后的测试同样生效，因此共用一份配置
+func replayTestConfig(t *testing.T) *config.Config {
+	replayConfigOnce.Do(func() {
+		repoDir, err := os.MkdirTemp("", "opendeepwiki-writers-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayConfig = &config.Config{}
+		replayConfig.Agent.Dir = "testdata/agents"
+		replayConfig.Agent.ReloadInterval = time.Hour
+		replayConfig.Data.RepoDir = repoDir
+	})
+	return replayConfig
+}
+
+// newReplayTocWriter 创建使用 testdata/agents 精简 Agent 定义与回放模型的目录生成服务，
+// 仓库检出目录与生产环境一致，位于数据目录下且目录名带时间戳
+func newReplayTocWriter(t *testing.T, keys ...*model.APIKey) (*tocWriter, string) {
+	t.Helper()
+	cfg := replayTestConfig(t)
+
+	localPath, err := os.MkdirTemp(cfg.Data.RepoDir, fmt.Sprintf("demo-%d-", time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(localPath) })
+	files := map[string]string{
+		"README.md": "# demo\n一个命令行工具。\n",
+		"go.mod":    "module demo\n",
+		"main.go":   "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(localPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// list_dir 输出包含文件大小与修改时间，仓库只放文件（目录大小随文件系统不同）并固定修改时间，工具结果才与夹具一致
+	modTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	for name := range files {
+		if err := os.Chtimes(filepath.Join(localPath, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.APIKey{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	for _, key := range keys {
+		if err := apiKeyRepo.Create(context.Background(), key); err != nil {
+			t.Fatalf("create api key error: %v", err)
+		}
+	}
+
+	writer, err := NewTocWriter(cfg, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("new toc writer error: %v", err)
+	}
+	provider, err := adkagents.NewEnhancedModelProvider(cfg, apiKeyRepo, noopAPIKeyService{}, nil)
+	if err != nil {
+		t.Fatalf("new model provider error: %v", err)
+	}
+	writer.factory.Manager.SetEnhancedModelProvider(provider)
+	return writer, localPath
+}
+
+// TestTocWriterPreviewReplay 用 testdata/replay/toc 中录制的模型响应运行目录生成链路（目录制定 -> 校验），无需网络
+func TestTocWriterPreviewReplay(t *testing.T) {
+	writer, localPath := newReplayTocWriter(t, &model.APIKey{
+		Name:     "replay",
+		Provider: adkagents.ProviderReplay,
+		BaseURL:  "testdata/replay/toc",
+		APIKey:   "-",
+		Model:    "replay",
+		Status:   "enabled",
+	})
+
+	output, err := writer.Preview(context.Background(), localPath, "")
+	if err != nil {
+		t.Fatalf("preview error: %v", err)
+	}
+	result, err := parseDirList(output)
+	if err != nil {
+		t.Fatalf("parse preview error: %v\n%s", err, output)
+	}
+	if len(result.Dirs) != 2 || result.Dirs[0].Title != "项目概览" || result.Dirs[1].Title != "命令行入口" {
+		t.Fatalf("unexpected dirs: %s", output)
+	}
+	if !strings.Contains(result.AnalysisSummary, "命令行") {
+		t.Fatalf("unexpected analysis summary: %q", result.AnalysisSummary)
+	}
+}
@@ -0,0 +1,42 @@
+package eventbus
+
+import "time"
+
+type TaskProgressEventType string
+
+const (
+	TaskProgressStatus        TaskProgressEventType = "status"         // 任务状态变化
+	TaskProgressAgentStarted  TaskProgressEventType = "agent_started"  // 子 Agent 开始执行
+	TaskProgressAgentFinished TaskProgressEventType = "agent_finished" // 子 Agent 执行结束
+	TaskProgressModelOutput   TaskProgressEventType = "model_output"   // 模型输出（含流式片段）
+	TaskProgressToolInvoked   TaskProgressEventType = "tool_invoked"   // 工具调用及参数
+	TaskProgressToolResult    TaskProgressEventType = "tool_result"    // 工具返回结果
+	TaskProgressTokenUsage    TaskProgressEventType = "token_usage"    // 单次模型调用的 Token 用量
+	TaskProgressError         TaskProgressEventType = "error"          // 节点执行出错
+)
+
+// TaskProgressEvent 任务执行过程中的实时进度事件，按任务ID分发
+type TaskProgressEvent struct {
+	Type             TaskProgressEventType `json:"type"`
+	TaskID           uint                  `json:"task_id"`
+	Timestamp        time.Time             `json:"timestamp"`
+	Status           string                `json:"status,omitempty"`
+	Agent            string                `json:"agent,omitempty"`
+	Tool             string                `json:"tool,omitempty"`
+	Arguments        string                `json:"arguments,omitempty"`
+	Content          string                `json:"content,omitempty"`
+	Partial          bool                  `json:"partial,omitempty"` // Content 为流式片段
+	PromptTokens     int                   `json:"prompt_tokens,omitempty"`
+	CompletionTokens int                   `json:"completion_tokens,omitempty"`
+	TotalTokens      int                   `json:"total_tokens,omitempty"`
+	Error            string                `json:"error,omitempty"`
+}
+
+type TaskProgressEventHandler = Handler[TaskProgressEvent]
+
+// TaskProgressBus 以任务ID为键的进度事件总线，订阅者只接收单个任务的事件
+type TaskProgressBus = Bus[uint, TaskProgressEvent]
+
+func NewTaskProgressBus() *TaskProgressBus {
+	return NewBus[uint, TaskProgressEvent]()
+}
@@ -27,6 +27,7 @@ type TaskEvent struct {
 	WriterName   domain.WriterName
 	TaskID       uint            // 任务ID,若有
 	TaskType     domain.TaskType // 任务类型
+	Ref          string          // 引用文档集，为空表示仓库主文档集
 }
 
 type TaskEventHandler = Handler[TaskEvent]
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// AuditHandler 审计日志查询处理器
+type AuditHandler struct {
+	service *service.AuditService
+	enabled bool
+}
+
+// NewAuditHandler 创建审计日志处理器，enabled 为 false 时不记录新的审计日志，历史日志仍可查询
+func NewAuditHandler(service *service.AuditService, enabled bool) *AuditHandler {
+	return &AuditHandler{service: service, enabled: enabled}
+}
+
+// Service 返回审计服务，供审计中间件记录写操作
+func (h *AuditHandler) Service() *service.AuditService {
+	return h.service
+}
+
+// Enabled 是否记录审计日志
+func (h *AuditHandler) Enabled() bool {
+	return h.enabled
+}
+
+// RegisterRoutes 注册路由
+func (h *AuditHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/audit", h.List)
+}
+
+// List 按操作人、动作、目标与时间范围查询审计日志
+func (h *AuditHandler) List(c *gin.Context) {
+	req := &service.AuditListRequest{
+		ActorName:  c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+	for name, dest := range map[string]*uint{"workspace_id": &req.WorkspaceID, "actor_id": &req.ActorID} {
+		if raw := c.Query(name); raw != "" {
+			value, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*dest = uint(value)
+		}
+	}
+	for name, dest := range map[string]*time.Time{"since": &req.Since, "until": &req.Until} {
+		if raw := c.Query(name); raw != "" {
+			value, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be RFC3339 time"})
+				return
+			}
+			*dest = value
+		}
+	}
+	req.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	req.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	logs, total, err := h.service.List(c.Request.Context(), req)
+	if err != nil {
+		klog.Errorf("ListAuditLogs: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": logs, "total": total})
+}
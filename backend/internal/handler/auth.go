@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// oidcStateCookieName 发起 OIDC 登录的浏览器保存的 state，回调时校验
+	oidcStateCookieName = "odw_oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+// AuthHandler 登录、用户与 API Token 处理器
+type AuthHandler struct {
+	service      *service.AuthService
+	oidc         *service.OIDCService
+	enabled      bool
+	cookieSecure bool
+}
+
+// NewAuthHandler 创建认证处理器
+func NewAuthHandler(service *service.AuthService, enabled bool, cookieSecure bool) *AuthHandler {
+	return &AuthHandler{service: service, enabled: enabled, cookieSecure: cookieSecure}
+}
+
+// SetOIDCService 启用 OIDC 单点登录
+func (h *AuthHandler) SetOIDCService(oidc *service.OIDCService) {
+	h.oidc = oidc
+}
+
+// Service 返回认证服务，供认证中间件解析令牌
+func (h *AuthHandler) Service() *service.AuthService {
+	return h.service
+}
+
+// Enabled 是否启用认证
+func (h *AuthHandler) Enabled() bool {
+	return h.enabled
+}
+
+// RegisterRoutes 注册路由
+func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
+	auth := router.Group("/auth")
+	{
+		auth.POST("/login", h.Login)
+		auth.POST("/logout", h.Logout)
+		auth.GET("/status", h.Status)
+		auth.GET("/me", h.Me)
+		auth.PUT("/password", h.ChangePassword)
+		auth.GET("/tokens", h.ListTokens)
+		auth.POST("/tokens", h.CreateToken)
+		auth.DELETE("/tokens/:id", h.RevokeToken)
+		auth.GET("/oidc/login", h.OIDCLogin)
+		auth.GET("/oidc/callback", h.OIDCCallback)
+	}
+
+	users := router.Group("/users")
+	{
+		users.GET("", h.ListUsers)
+		users.POST("", h.CreateUser)
+		users.GET("/:id", h.GetUser)
+		users.PUT("/:id", h.UpdateUser)
+		users.DELETE("/:id", h.DeleteUser)
+	}
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// Login 用户名密码登录，会话令牌写入 HttpOnly Cookie 并在响应中返回
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, user, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			klog.V(6).Infof("Login: 登录失败: username=%s", req.Username)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		klog.Errorf("Login: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSessionCookie(c, token, int(h.service.SessionTTL().Seconds()))
+	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+}
+
+// Logout 退出登录
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.service.Logout(c.Request.Context(), middleware.TokenFromRequest(c)); err != nil {
+		klog.V(6).Infof("Logout: 删除会话失败: %v", err)
+	}
+	h.setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// Status 返回认证是否启用及当前登录用户，供前端决定是否展示登录页
+func (h *AuthHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.enabled,
+		"oidc":    h.oidc != nil,
+		"user":    middleware.CurrentUser(c),
+	})
+}
+
+// OIDCLogin 跳转到身份提供方登录，redirect 参数为登录完成后返回的站内路径
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "oidc login is not enabled"})
+		return
+	}
+	authURL, state, err := h.oidc.BeginLogin(c.Request.Context(), c.Query("redirect"))
+	if err != nil {
+		klog.Errorf("OIDCLogin: failed: %v", err)
+		status := http.StatusBadGateway
+		if errors.Is(err, service.ErrOIDCTooManyLogins) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	// state 绑定到当前浏览器；SameSite=Lax 允许身份提供方跳回时携带该 Cookie
+	h.setOIDCStateCookie(c, state, int(service.OIDCLoginTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 身份提供方回调，校验通过后写入会话 Cookie 并跳回站内页面
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "oidc login is not enabled"})
+		return
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errCode, "error_description": c.Query("error_description")})
+		return
+	}
+
+	boundState, _ := c.Cookie(oidcStateCookieName)
+	h.setOIDCStateCookie(c, "", -1)
+	token, _, redirect, err := h.oidc.FinishLogin(c.Request.Context(), c.Query("state"), boundState, c.Query("code"))
+	if err != nil {
+		klog.V(6).Infof("OIDCCallback: 登录失败: %v", err)
+		status := http.StatusUnauthorized
+		if errors.Is(err, service.ErrOIDCAccessDenied) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSessionCookie(c, token, int(h.service.SessionTTL().Seconds()))
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Me 返回当前登录用户
+func (h *AuthHandler) Me(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword 修改当前用户密码
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.ChangePassword(c.Request.Context(), user.ID, req.OldPassword, req.NewPassword); err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+// ListTokens 列出当前用户的 API Token
+func (h *AuthHandler) ListTokens(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	tokens, err := h.service.ListAPITokens(c.Request.Context(), user.ID)
+	if err != nil {
+		klog.Errorf("ListTokens: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tokens, "total": len(tokens)})
+}
+
+// CreateToken 为当前用户创建 API Token，明文只返回一次
+func (h *AuthHandler) CreateToken(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	var req service.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	apiToken, plaintext, err := h.service.CreateAPIToken(c.Request.Context(), user, &req)
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"token": plaintext, "data": apiToken})
+}
+
+// RevokeToken 吊销当前用户的 API Token
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.service.RevokeAPIToken(c.Request.Context(), user.ID, id); err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ListUsers 列出用户
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	users, err := h.service.ListUsers(c.Request.Context())
+	if err != nil {
+		klog.Errorf("ListUsers: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users, "total": len(users)})
+}
+
+// CreateUser 创建用户
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req service.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := h.service.CreateUser(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetUser 获取用户
+func (h *AuthHandler) GetUser(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user, err := h.service.GetUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser 更新用户资料、角色、状态或重置密码
+func (h *AuthHandler) UpdateUser(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req service.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := h.service.UpdateUser(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser 删除用户
+func (h *AuthHandler) DeleteUser(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.service.DeleteUser(c.Request.Context(), id); err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+func (h *AuthHandler) setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookieName, token, maxAge, "/", "", h.cookieSecure, true)
+}
+
+func (h *AuthHandler) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookieName, state, maxAge, oidcStateCookiePath, "", h.cookieSecure, true)
+}
+
+// authErrorStatus 将认证服务错误映射为 HTTP 状态码
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound), errors.Is(err, repository.ErrAPITokenNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrUserDuplicate), errors.Is(err, service.ErrLastAdmin):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrWeakPassword), errors.Is(err, service.ErrExternalAccount):
+		return http.StatusBadRequest
+	}
+	klog.Errorf("auth handler: %v", err)
+	return http.StatusInternalServerError
+}
@@ -14,8 +14,10 @@ import (
 	"github.com/cloudwego/eino/schema"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
 	"k8s.io/klog/v2"
 )
@@ -80,7 +82,7 @@ func (h *ChatHandler) CreateSession(c *gin.Context) {
 		return
 	}
 
-	session, err := h.chatService.CreateSession(c.Request.Context(), uint(repoID))
+	session, err := h.chatService.CreateSession(c.Request.Context(), uint(repoID), middleware.CurrentUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -297,6 +299,7 @@ func (h *ChatHandler) WebSocket(c *gin.Context) {
 		send:      make(chan []byte, 256),
 		sessionID: sessionID,
 		repoID:    uint(repoID),
+		workspace: session.WorkspaceID,
 		stopChan:  make(chan struct{}),
 	}
 
@@ -315,6 +318,7 @@ type Client struct {
 	send      chan []byte
 	sessionID string
 	repoID    uint
+	workspace uint // 会话所属工作空间，Agent 只使用该空间的 API Key
 	stopChan  chan struct{}
 	mu        sync.Mutex
 	closed    bool // 标记连接是否已关闭
@@ -486,7 +490,7 @@ func (h *ChatHandler) handleStop(client *Client) {
 // runAgent 运行Agent
 func (h *ChatHandler) runAgent(client *Client, userMsg *model.ChatMessage) {
 	// 创建可取消的上下文
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(repository.WithWorkspace(context.Background(), client.workspace))
 	defer cancel()
 
 	// 监听停止信号
@@ -502,7 +506,7 @@ func (h *ChatHandler) runAgent(client *Client, userMsg *model.ChatMessage) {
 	// 获取仓库信息
 	var repoInfo string
 	if h.repoService != nil {
-		repo, err := h.repoService.Get(client.repoID)
+		repo, err := h.repoService.Get(ctx, client.repoID)
 		if err == nil && repo != nil {
 			repoInfo = fmt.Sprintf("## 当前仓库信息\n- 仓库名称: %s\n- 仓库地址: %s\n- 本地路径: %s\n- 仓库描述: %s\n- 当前分支: %s\n- 当前Commit: %s\n",
 				repo.Name, repo.URL, repo.LocalPath, repo.Description, repo.CloneBranch, repo.CloneCommit)
@@ -522,7 +526,7 @@ func (h *ChatHandler) runAgent(client *Client, userMsg *model.ChatMessage) {
 	}
 
 	// 获取 Agent
-	agent, err := h.agentFactory.GetAgent("chat_assistant")
+	agent, err := h.agentFactory.GetAgentFor(ctx, "chat_assistant")
 	if err != nil {
 		client.sendError("AGENT_NOT_FOUND", fmt.Sprintf("无法获取Agent: %v", err))
 		return
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
 )
 
@@ -30,9 +31,10 @@ func (h *DocumentHandler) GetByRepository(c *gin.Context) {
 		return
 	}
 
-	docs, err := h.service.GetByRepository(uint(repoID))
+	// ref 为空时返回仓库主文档集
+	docs, err := h.service.GetByRepositoryRef(uint(repoID), c.Query("ref"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -89,7 +91,7 @@ func (h *DocumentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.service.Update(uint(id), req.Content)
+	doc, err := h.service.Update(uint(id), req.Content, middleware.CurrentUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -106,9 +108,9 @@ func (h *DocumentHandler) Export(c *gin.Context) {
 		return
 	}
 
-	data, filename, err := h.service.ExportAll(uint(repoID))
+	data, filename, err := h.service.ExportAll(uint(repoID), c.Query("ref"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -123,9 +125,9 @@ func (h *DocumentHandler) ExportPDF(c *gin.Context) {
 		return
 	}
 
-	data, filename, err := h.service.ExportPDF(uint(repoID))
+	data, filename, err := h.service.ExportPDF(uint(repoID), c.Query("ref"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -164,15 +166,37 @@ func (h *DocumentHandler) GetIndex(c *gin.Context) {
 		return
 	}
 
-	content, err := h.service.GetIndex(uint(repoID))
+	content, err := h.service.GetIndex(uint(repoID), c.Query("ref"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"content": content})
 }
 
+// DiffRefs 比较仓库两个引用文档集的文档，from/to 为空表示仓库主文档集
+func (h *DocumentHandler) DiffRefs(c *gin.Context) {
+	repoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository id"})
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be different refs"})
+		return
+	}
+
+	diff, err := h.service.DiffRefs(c.Request.Context(), uint(repoID), from, to)
+	if err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
 // SubmitRating 提交文档评分
 func (h *DocumentHandler) SubmitRating(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -247,3 +271,27 @@ func (h *DocumentHandler) GetTokenUsage(c *gin.Context) {
 		"data":    usage,
 	})
 }
+
+// Search 按相关度搜索文档，支持仓库、版本与标题过滤
+func (h *DocumentHandler) Search(c *gin.Context) {
+	var req service.DocumentSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	results, err := h.service.SearchDocuments(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   len(results),
+	})
+}
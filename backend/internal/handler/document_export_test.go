@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -28,6 +29,13 @@ func (m *mockExportHandlerDocRepo) GetByRepository(repoID uint) ([]model.Documen
 	return nil, nil
 }
 
+func (m *mockExportHandlerDocRepo) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	if ref == "" {
+		return m.GetByRepository(repoID)
+	}
+	return nil, nil
+}
+
 func (m *mockExportHandlerDocRepo) GetVersions(repoID uint, title string) ([]model.Document, error) {
 	return nil, nil
 }
@@ -36,6 +44,10 @@ func (m *mockExportHandlerDocRepo) Get(id uint) (*model.Document, error) {
 	return nil, nil
 }
 
+func (m *mockExportHandlerDocRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return m.Get(id)
+}
+
 func (m *mockExportHandlerDocRepo) Save(doc *model.Document) error {
 	return nil
 }
@@ -56,6 +68,10 @@ func (m *mockExportHandlerDocRepo) DeleteByRepositoryID(repoID uint) error {
 	return nil
 }
 
+func (m *mockExportHandlerDocRepo) DeleteByRepositoryRef(repoID uint, ref string) error {
+	return nil
+}
+
 func (m *mockExportHandlerDocRepo) UpdateTaskID(docID uint, taskID uint) error {
 	return nil
 }
@@ -92,30 +108,30 @@ type mockExportHandlerRepoRepo struct {
 	GetBasicFunc func(id uint) (*model.Repository, error)
 }
 
-func (m *mockExportHandlerRepoRepo) Create(repo *model.Repository) error {
+func (m *mockExportHandlerRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockExportHandlerRepoRepo) List() ([]model.Repository, error) {
+func (m *mockExportHandlerRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	return nil, nil
 }
 
-func (m *mockExportHandlerRepoRepo) Get(id uint) (*model.Repository, error) {
+func (m *mockExportHandlerRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
 	return nil, nil
 }
 
-func (m *mockExportHandlerRepoRepo) GetBasic(id uint) (*model.Repository, error) {
+func (m *mockExportHandlerRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	if m.GetBasicFunc != nil {
 		return m.GetBasicFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *mockExportHandlerRepoRepo) Save(repo *model.Repository) error {
+func (m *mockExportHandlerRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockExportHandlerRepoRepo) Delete(id uint) error {
+func (m *mockExportHandlerRepoRepo) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// EmbeddingHandler 向量模型配置与语义检索处理器
+type EmbeddingHandler struct {
+	service *service.EmbeddingService
+}
+
+// NewEmbeddingHandler 创建向量检索处理器
+func NewEmbeddingHandler(service *service.EmbeddingService) *EmbeddingHandler {
+	return &EmbeddingHandler{service: service}
+}
+
+// RegisterRoutes 注册路由
+func (h *EmbeddingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/embedding-providers", h.ListProviders)
+	router.POST("/embedding-providers", h.CreateProvider)
+	router.GET("/embedding-providers/:id", h.GetProvider)
+	router.PUT("/embedding-providers/:id", h.UpdateProvider)
+	router.DELETE("/embedding-providers/:id", h.DeleteProvider)
+	router.PATCH("/embedding-providers/:id/status", h.UpdateStatus)
+	router.GET("/embeddings/search", h.Search)
+	router.POST("/repositories/:id/embeddings/rebuild", h.Rebuild)
+}
+
+// EmbeddingProviderResponse 向量模型配置响应（脱敏）
+type EmbeddingProviderResponse struct {
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	Provider     string     `json:"provider"`
+	BaseURL      string     `json:"base_url"`
+	APIKey       string     `json:"api_key"` // 脱敏后
+	Model        string     `json:"model"`
+	Dimensions   int        `json:"dimensions"`
+	Priority     int        `json:"priority"`
+	Status       string     `json:"status"`
+	RequestCount int        `json:"request_count"`
+	ErrorCount   int        `json:"error_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CreateProvider 创建向量模型配置
+func (h *EmbeddingHandler) CreateProvider(c *gin.Context) {
+	var req service.CreateEmbeddingProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider, err := h.service.CreateProvider(c.Request.Context(), &req)
+	if err != nil {
+		klog.Errorf("CreateEmbeddingProvider: failed: %v", err)
+		c.JSON(embeddingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, toEmbeddingProviderResponse(provider))
+}
+
+// GetProvider 获取向量模型配置
+func (h *EmbeddingHandler) GetProvider(c *gin.Context) {
+	id, ok := parseEmbeddingProviderID(c)
+	if !ok {
+		return
+	}
+	provider, err := h.service.GetProvider(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toEmbeddingProviderResponse(provider))
+}
+
+// ListProviders 列出向量模型配置
+func (h *EmbeddingHandler) ListProviders(c *gin.Context) {
+	providers, err := h.service.ListProviders(c.Request.Context())
+	if err != nil {
+		klog.Errorf("ListEmbeddingProviders: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	responses := make([]*EmbeddingProviderResponse, 0, len(providers))
+	for _, provider := range providers {
+		responses = append(responses, toEmbeddingProviderResponse(provider))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  responses,
+		"total": len(responses),
+	})
+}
+
+// UpdateProvider 更新向量模型配置
+func (h *EmbeddingHandler) UpdateProvider(c *gin.Context) {
+	id, ok := parseEmbeddingProviderID(c)
+	if !ok {
+		return
+	}
+	var req service.UpdateEmbeddingProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider, err := h.service.UpdateProvider(c.Request.Context(), id, &req)
+	if err != nil {
+		klog.Errorf("UpdateEmbeddingProvider: failed: %v", err)
+		c.JSON(embeddingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toEmbeddingProviderResponse(provider))
+}
+
+// DeleteProvider 删除向量模型配置
+func (h *EmbeddingHandler) DeleteProvider(c *gin.Context) {
+	id, ok := parseEmbeddingProviderID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteProvider(c.Request.Context(), id); err != nil {
+		klog.Errorf("DeleteEmbeddingProvider: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
+}
+
+// UpdateStatus 启用或禁用向量模型配置
+func (h *EmbeddingHandler) UpdateStatus(c *gin.Context) {
+	id, ok := parseEmbeddingProviderID(c)
+	if !ok {
+		return
+	}
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.UpdateProviderStatus(c.Request.Context(), id, req.Status); err != nil {
+		c.JSON(embeddingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
+}
+
+// Search 语义检索文档与源码分块
+func (h *EmbeddingHandler) Search(c *gin.Context) {
+	var req embedding.SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	hits, err := h.service.SemanticSearch(c.Request.Context(), req)
+	if err != nil {
+		klog.Errorf("SemanticSearch: failed: %v", err)
+		c.JSON(embeddingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  hits,
+		"total": len(hits),
+	})
+}
+
+// Rebuild 在后台重建仓库文档与源码的向量分块
+func (h *EmbeddingHandler) Rebuild(c *gin.Context) {
+	var repoID uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &repoID); err != nil || repoID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository id"})
+		return
+	}
+	if err := h.service.StartRebuild(repoID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "rebuild started"})
+}
+
+func parseEmbeddingProviderID(c *gin.Context) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func embeddingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrEmbeddingProviderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrEmbeddingProviderDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNoEmbeddingProvider):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func toEmbeddingProviderResponse(provider *model.EmbeddingProvider) *EmbeddingProviderResponse {
+	return &EmbeddingProviderResponse{
+		ID:           provider.ID,
+		Name:         provider.Name,
+		Provider:     provider.Provider,
+		BaseURL:      provider.BaseURL,
+		APIKey:       provider.MaskAPIKey(),
+		Model:        provider.Model,
+		Dimensions:   provider.Dimensions,
+		Priority:     provider.Priority,
+		Status:       provider.Status,
+		RequestCount: provider.RequestCount,
+		ErrorCount:   provider.ErrorCount,
+		LastUsedAt:   provider.LastUsedAt,
+		CreatedAt:    provider.CreatedAt,
+		UpdatedAt:    provider.UpdatedAt,
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// EvalHandler Agent 评测接口
+type EvalHandler struct {
+	service *service.EvalService
+}
+
+// NewEvalHandler 创建 Agent 评测处理器
+func NewEvalHandler(service *service.EvalService) *EvalHandler {
+	return &EvalHandler{service: service}
+}
+
+// RegisterRoutes 注册路由
+func (h *EvalHandler) RegisterRoutes(router *gin.RouterGroup) {
+	agents := router.Group("/agents")
+	{
+		agents.POST("/:filename/evaluate", h.Start)
+		agents.GET("/:filename/evaluate", h.Compare)
+		agents.GET("/:filename/evaluate/:id", h.Get)
+	}
+}
+
+// Start 使用指定版本的 Agent 对样例仓库运行评测，评测在后台执行
+func (h *EvalHandler) Start(c *gin.Context) {
+	fileName, ok := parseEvalFileName(c)
+	if !ok {
+		return
+	}
+	var req service.EvalRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	run, err := h.service.Start(c.Request.Context(), fileName, &req)
+	if err != nil {
+		c.JSON(evalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": run})
+}
+
+// Compare 列出 Agent 最近的评测，并按版本汇总得分
+func (h *EvalHandler) Compare(c *gin.Context) {
+	fileName, ok := parseEvalFileName(c)
+	if !ok {
+		return
+	}
+	comparison, err := h.service.Compare(c.Request.Context(), fileName)
+	if err != nil {
+		c.JSON(evalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comparison})
+}
+
+// Get 获取评测详情，包含各样例的检查结果、评审意见与输出
+func (h *EvalHandler) Get(c *gin.Context) {
+	fileName, ok := parseEvalFileName(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid eval run id"})
+		return
+	}
+	run, err := h.service.Get(c.Request.Context(), fileName, uint(id))
+	if err != nil {
+		c.JSON(evalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+func parseEvalFileName(c *gin.Context) (string, bool) {
+	fileName := c.Param("filename")
+	// 防止目录遍历攻击
+	if fileName == "" || strings.Contains(fileName, "..") || strings.Contains(fileName, "/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid filename"})
+		return "", false
+	}
+	return fileName, true
+}
+
+func evalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, repository.ErrAgentVersionNotFound), errors.Is(err, service.ErrEvalRunNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrEvalRunning):
+		return http.StatusConflict
+	case errors.Is(err, adkagents.ErrInvalidConfig), errors.Is(err, adkagents.ErrInvalidName),
+		errors.Is(err, service.ErrEvalWriterNotFound), errors.Is(err, service.ErrEvalNoSamples):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
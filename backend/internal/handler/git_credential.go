@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// GitCredentialHandler 私有仓库 Git 凭证处理器，接口不返回密钥
+type GitCredentialHandler struct {
+	service *service.GitCredentialService
+}
+
+// NewGitCredentialHandler 创建 Git 凭证处理器
+func NewGitCredentialHandler(service *service.GitCredentialService) *GitCredentialHandler {
+	return &GitCredentialHandler{service: service}
+}
+
+// RegisterRoutes 注册路由
+func (h *GitCredentialHandler) RegisterRoutes(router *gin.RouterGroup) {
+	credentials := router.Group("/git-credentials")
+	{
+		credentials.GET("", h.List)
+		credentials.POST("", h.Create)
+		credentials.GET("/:id", h.Get)
+		credentials.PUT("/:id", h.Update)
+		credentials.DELETE("/:id", h.Delete)
+	}
+}
+
+// List 列出当前工作空间的凭证
+func (h *GitCredentialHandler) List(c *gin.Context) {
+	credentials, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(gitCredentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": credentials, "total": len(credentials)})
+}
+
+// Create 创建凭证
+func (h *GitCredentialHandler) Create(c *gin.Context) {
+	var req service.GitCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	credential, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(gitCredentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, credential)
+}
+
+// Get 获取凭证
+func (h *GitCredentialHandler) Get(c *gin.Context) {
+	id, ok := parseGitCredentialID(c)
+	if !ok {
+		return
+	}
+	credential, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(gitCredentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credential)
+}
+
+// Update 更新凭证，secret 为空时保留原密钥
+func (h *GitCredentialHandler) Update(c *gin.Context) {
+	id, ok := parseGitCredentialID(c)
+	if !ok {
+		return
+	}
+	var req service.GitCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	credential, err := h.service.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(gitCredentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credential)
+}
+
+// Delete 删除凭证
+func (h *GitCredentialHandler) Delete(c *gin.Context) {
+	id, ok := parseGitCredentialID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(gitCredentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// parseGitCredentialID 解析路径中的凭证ID，失败时直接返回 400
+func parseGitCredentialID(c *gin.Context) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// gitCredentialErrorStatus 将 Git 凭证服务错误映射为 HTTP 状态码
+func gitCredentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrGitCredentialNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrGitCredentialDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrGitCredentialNameRequired), errors.Is(err, service.ErrGitCredentialHostRequired),
+		errors.Is(err, service.ErrInvalidGitCredentialKind), errors.Is(err, service.ErrGitCredentialSecretRequired),
+		errors.Is(err, service.ErrGitCredentialUsernameRequired), errors.Is(err, service.ErrInvalidSSHKey),
+		errors.Is(err, service.ErrGitCredentialMismatch):
+		return http.StatusBadRequest
+	}
+	klog.Errorf("git credential handler: %v", err)
+	return http.StatusInternalServerError
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
 )
 
@@ -17,6 +18,9 @@ type RepositoryHandler struct {
 	taskBus     *eventbus.TaskEventBus
 	service     *service.RepositoryService
 	taskService *service.TaskService
+
+	// 引用文档集服务，可为空；为空时分析任务只能针对仓库主文档集
+	refService *service.RepositoryRefService
 }
 
 func NewRepositoryHandler(repoBus *eventbus.RepositoryEventBus, taskBus *eventbus.TaskEventBus, service *service.RepositoryService, taskService *service.TaskService) *RepositoryHandler {
@@ -28,6 +32,28 @@ func NewRepositoryHandler(repoBus *eventbus.RepositoryEventBus, taskBus *eventbu
 	}
 }
 
+// SetRepositoryRefService 设置引用文档集服务，使分析接口支持 ?ref= 指定文档集
+func (h *RepositoryHandler) SetRepositoryRefService(refService *service.RepositoryRefService) {
+	h.refService = refService
+}
+
+// queryRef 读取 ?ref= 指定的引用文档集并校验已检出，为空表示仓库主文档集；校验失败时直接返回错误响应
+func (h *RepositoryHandler) queryRef(c *gin.Context, repoID uint) (string, bool) {
+	ref := c.Query("ref")
+	if ref == "" {
+		return "", true
+	}
+	if h.refService == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": repository.ErrRepositoryRefNotFound.Error()})
+		return "", false
+	}
+	if _, err := h.refService.GetReady(c.Request.Context(), repoID, ref); err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return "", false
+	}
+	return ref, true
+}
+
 func (h *RepositoryHandler) Create(c *gin.Context) {
 	var req service.CreateRepoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,10 +61,13 @@ func (h *RepositoryHandler) Create(c *gin.Context) {
 		return
 	}
 
-	repo, err := h.service.Create(req)
+	repo, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
 		switch {
-		case errors.Is(err, service.ErrInvalidRepositoryURL):
+		case errors.Is(err, service.ErrInvalidRepositoryURL),
+			errors.Is(err, service.ErrInvalidRepositoryRef),
+			errors.Is(err, repository.ErrGitCredentialNotFound),
+			errors.Is(err, service.ErrGitCredentialMismatch):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, service.ErrRepositoryAlreadyExists):
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
@@ -58,7 +87,7 @@ func (h *RepositoryHandler) Create(c *gin.Context) {
 }
 
 func (h *RepositoryHandler) List(c *gin.Context) {
-	repos, err := h.service.List()
+	repos, err := h.service.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -74,7 +103,7 @@ func (h *RepositoryHandler) Get(c *gin.Context) {
 		return
 	}
 
-	repo, err := h.service.Get(uint(id))
+	repo, err := h.service.Get(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
 		return
@@ -125,11 +154,17 @@ func (h *RepositoryHandler) AnalyzeDirectory(c *gin.Context) {
 		return
 	}
 
+	ref, ok := h.queryRef(c, uint(id))
+	if !ok {
+		return
+	}
+
 	ctx := context.Background()
 
 	h.taskBus.Publish(ctx, eventbus.TaskEventTocWrite, eventbus.TaskEvent{
 		Type:         eventbus.TaskEventTocWrite,
 		RepositoryID: uint(id),
+		Ref:          ref,
 		Title:        "目录分析",
 		SortOrder:    10,
 		WriterName:   domain.TocWriter,
@@ -148,10 +183,16 @@ func (h *RepositoryHandler) AnalyzeDatabaseModel(c *gin.Context) {
 		return
 	}
 
+	ref, ok := h.queryRef(c, uint(id))
+	if !ok {
+		return
+	}
+
 	ctx := context.Background()
 	h.taskBus.Publish(ctx, eventbus.TaskEventDocWrite, eventbus.TaskEvent{
 		Type:         eventbus.TaskEventDocWrite,
 		RepositoryID: uint(id),
+		Ref:          ref,
 		Title:        "数据库模型分析",
 		SortOrder:    20,
 		WriterName:   domain.DBModelWriter,
@@ -170,10 +211,16 @@ func (h *RepositoryHandler) AnalyzeAPI(c *gin.Context) {
 		return
 	}
 
+	ref, ok := h.queryRef(c, uint(id))
+	if !ok {
+		return
+	}
+
 	ctx := context.Background()
 	h.taskBus.Publish(ctx, eventbus.TaskEventDocWrite, eventbus.TaskEvent{
 		Type:         eventbus.TaskEventDocWrite,
 		RepositoryID: uint(id),
+		Ref:          ref,
 		Title:        "API接口分析",
 		SortOrder:    20,
 		WriterName:   domain.APIWriter,
@@ -184,6 +231,61 @@ func (h *RepositoryHandler) AnalyzeAPI(c *gin.Context) {
 	})
 }
 
+// RunWriterRequest 使用指定写入器生成文档的请求
+type RunWriterRequest struct {
+	Title     string `json:"title"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// RunWriter 使用指定写入器（如 YAML 自定义写入器）为仓库创建文档任务。
+func (h *RepositoryHandler) RunWriter(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// 请求体可省略，标题默认使用写入器名称
+	var req RunWriterRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	writerName := domain.WriterName(c.Param("name"))
+	if _, err := h.taskService.GetWriter(writerName); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Title == "" {
+		req.Title = string(writerName)
+	}
+	if req.SortOrder == 0 {
+		req.SortOrder = 20
+	}
+
+	ref, ok := h.queryRef(c, uint(id))
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	h.taskBus.Publish(ctx, eventbus.TaskEventDocWrite, eventbus.TaskEvent{
+		Type:         eventbus.TaskEventDocWrite,
+		RepositoryID: uint(id),
+		Ref:          ref,
+		Title:        req.Title,
+		SortOrder:    req.SortOrder,
+		WriterName:   writerName,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "writer task started",
+	})
+}
+
 // IncrementalAnalysis 处理增量分析的触发请求。
 func (h *RepositoryHandler) IncrementalAnalysis(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/archive"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead 上传请求中除文件外的表单字段与分隔符预留的大小
+const multipartOverhead = 1 << 20
+
+// ImportLocal 从服务器本地目录创建仓库
+func (h *RepositoryHandler) ImportLocal(c *gin.Context) {
+	var req service.ImportLocalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	repo, err := h.service.ImportLocal(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(repositoryImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.publishAdded(repo.ID)
+	c.JSON(http.StatusCreated, repo)
+}
+
+// Upload 上传 .zip、.tar.gz 压缩包创建仓库，表单字段：file、name（可选）、git_init（可选，默认 true）
+func (h *RepositoryHandler) Upload(c *gin.Context) {
+	h.limitUpload(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	gitInit := true
+	if v := c.PostForm("git_init"); v != "" {
+		if gitInit, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid git_init"})
+			return
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	repo, err := h.service.ImportArchive(c.Request.Context(), service.ImportArchiveRequest{
+		Name:     c.PostForm("name"),
+		Filename: file.Filename,
+		GitInit:  gitInit,
+	}, f)
+	if err != nil {
+		c.JSON(repositoryImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.publishAdded(repo.ID)
+	c.JSON(http.StatusCreated, repo)
+}
+
+// Reupload 上传新的压缩包替换压缩包导入仓库的代码，之后可触发增量更新
+func (h *RepositoryHandler) Reupload(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository id"})
+		return
+	}
+	h.limitUpload(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	repo, err := h.service.ReimportArchive(c.Request.Context(), id, file.Filename, f)
+	if err != nil {
+		c.JSON(repositoryImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, repo)
+}
+
+// publishAdded 发布仓库添加事件，由订阅者异步导入代码并创建目录任务
+func (h *RepositoryHandler) publishAdded(repoID uint) {
+	h.repoBus.Publish(context.Background(), eventbus.RepositoryEventAdded, eventbus.RepositoryEvent{
+		Type:         eventbus.RepositoryEventAdded,
+		RepositoryID: repoID,
+	})
+}
+
+// limitUpload 按压缩包大小上限限制请求体，避免超大上传先落盘再被拒绝
+func (h *RepositoryHandler) limitUpload(c *gin.Context) {
+	if limit := h.service.MaxArchiveBytes(); limit > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	}
+}
+
+// uploadErrorStatus 读取上传文件失败时的状态码
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// repositoryImportErrorStatus 将导入错误映射为 HTTP 状态码
+func repositoryImportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, archive.ErrTooLarge), errors.Is(err, archive.ErrTooManyFiles):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, archive.ErrUnsupportedFormat),
+		errors.Is(err, archive.ErrUnsafePath),
+		errors.Is(err, service.ErrInvalidImportSource),
+		errors.Is(err, service.ErrNotArchiveRepository):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrLocalImportDisabled), errors.Is(err, service.ErrLocalPathNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrRepositoryAlreadyExists), errors.Is(err, service.ErrRepositoryImportBusy):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// RepositoryRefHandler 仓库引用文档集接口
+type RepositoryRefHandler struct {
+	service *service.RepositoryRefService
+}
+
+// NewRepositoryRefHandler 创建引用文档集处理器
+func NewRepositoryRefHandler(service *service.RepositoryRefService) *RepositoryRefHandler {
+	return &RepositoryRefHandler{service: service}
+}
+
+// List 列出仓库的引用文档集
+func (h *RepositoryRefHandler) List(c *gin.Context) {
+	repoID, ok := parseRepositoryRefParam(c, "id")
+	if !ok {
+		return
+	}
+	refs, err := h.service.List(c.Request.Context(), repoID)
+	if err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": refs, "total": len(refs)})
+}
+
+// Create 为仓库添加引用文档集，分支、标签、提交指定其一，检出在后台进行
+func (h *RepositoryRefHandler) Create(c *gin.Context) {
+	repoID, ok := parseRepositoryRefParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.RepositoryRefRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ref, err := h.service.Add(c.Request.Context(), repoID, req)
+	if err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": ref})
+}
+
+// Get 获取引用文档集详情，用于轮询检出状态
+func (h *RepositoryRefHandler) Get(c *gin.Context) {
+	repoID, ok := parseRepositoryRefParam(c, "id")
+	if !ok {
+		return
+	}
+	refID, ok := parseRepositoryRefParam(c, "refId")
+	if !ok {
+		return
+	}
+	ref, err := h.service.Get(c.Request.Context(), repoID, refID)
+	if err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ref})
+}
+
+// Delete 删除引用文档集及其任务、文档与本地检出
+func (h *RepositoryRefHandler) Delete(c *gin.Context) {
+	repoID, ok := parseRepositoryRefParam(c, "id")
+	if !ok {
+		return
+	}
+	refID, ok := parseRepositoryRefParam(c, "refId")
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), repoID, refID); err != nil {
+		c.JSON(repositoryRefErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// parseRepositoryRefParam 解析路径中的ID，失败时直接返回 400
+func parseRepositoryRefParam(c *gin.Context, name string) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param(name), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// repositoryRefErrorStatus 将引用文档集相关错误映射为 HTTP 状态码
+func repositoryRefErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrRepositoryRefNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRepositoryRefExists), errors.Is(err, service.ErrRepositoryRefBusy),
+		errors.Is(err, service.ErrRepositoryRefNotReady):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRepositoryRefRequired), errors.Is(err, service.ErrInvalidRepositoryRef):
+		return http.StatusBadRequest
+	}
+	klog.Errorf("repository ref handler: %v", err)
+	return http.StatusInternalServerError
+}
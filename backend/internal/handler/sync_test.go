@@ -22,7 +22,7 @@ type mockSyncRepoRepo struct {
 }
 
 // Create 创建仓库
-func (m *mockSyncRepoRepo) Create(repo *model.Repository) error {
+func (m *mockSyncRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -34,7 +34,7 @@ func (m *mockSyncRepoRepo) Create(repo *model.Repository) error {
 }
 
 // List 列出仓库
-func (m *mockSyncRepoRepo) List() ([]model.Repository, error) {
+func (m *mockSyncRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	var out []model.Repository
 	for _, repo := range m.repos {
 		out = append(out, *repo)
@@ -43,12 +43,12 @@ func (m *mockSyncRepoRepo) List() ([]model.Repository, error) {
 }
 
 // Get 获取仓库
-func (m *mockSyncRepoRepo) Get(id uint) (*model.Repository, error) {
-	return m.GetBasic(id)
+func (m *mockSyncRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
+	return m.GetBasic(ctx, id)
 }
 
 // GetBasic 获取仓库基础信息
-func (m *mockSyncRepoRepo) GetBasic(id uint) (*model.Repository, error) {
+func (m *mockSyncRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -60,7 +60,7 @@ func (m *mockSyncRepoRepo) GetBasic(id uint) (*model.Repository, error) {
 }
 
 // Save 保存仓库
-func (m *mockSyncRepoRepo) Save(repo *model.Repository) error {
+func (m *mockSyncRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -69,7 +69,7 @@ func (m *mockSyncRepoRepo) Save(repo *model.Repository) error {
 }
 
 // Delete 删除仓库
-func (m *mockSyncRepoRepo) Delete(id uint) error {
+func (m *mockSyncRepoRepo) Delete(ctx context.Context, id uint) error {
 	delete(m.repos, id)
 	return nil
 }
@@ -89,6 +89,9 @@ func (m *mockSyncTaskRepo) GetByStatus(status string) ([]model.Task, error) { re
 
 // Get 获取任务
 func (m *mockSyncTaskRepo) Get(id uint) (*model.Task, error) { return nil, domain.ErrRecordNotFound }
+func (m *mockSyncTaskRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Task, error) {
+	return m.Get(id)
+}
 
 // Save 保存任务
 func (m *mockSyncTaskRepo) Save(task *model.Task) error { return nil }
@@ -126,6 +129,11 @@ func (m *mockSyncDocRepo) Create(doc *model.Document) error { return nil }
 // GetByRepository 按仓库获取文档
 func (m *mockSyncDocRepo) GetByRepository(repoID uint) ([]model.Document, error) { return nil, nil }
 
+// GetByRepositoryRef 获取引用文档集的文档列表
+func (m *mockSyncDocRepo) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	return nil, nil
+}
+
 // GetVersions 获取版本列表
 func (m *mockSyncDocRepo) GetVersions(repoID uint, title string) ([]model.Document, error) {
 	return nil, nil
@@ -133,6 +141,9 @@ func (m *mockSyncDocRepo) GetVersions(repoID uint, title string) ([]model.Docume
 
 // Get 获取文档
 func (m *mockSyncDocRepo) Get(id uint) (*model.Document, error) { return nil, domain.ErrRecordNotFound }
+func (m *mockSyncDocRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return m.Get(id)
+}
 
 // GetTokenUsageByDocID 根据 document_id 获取 Token 用量数据
 func (m *mockSyncDocRepo) GetTokenUsageByDocID(docID uint) (*model.TaskUsage, error) {
@@ -151,6 +162,9 @@ func (m *mockSyncDocRepo) DeleteByTaskID(taskID uint) error { return nil }
 // DeleteByRepositoryID 删除仓库下文档
 func (m *mockSyncDocRepo) DeleteByRepositoryID(repoID uint) error { return nil }
 
+// DeleteByRepositoryRef 删除引用文档集的文档
+func (m *mockSyncDocRepo) DeleteByRepositoryRef(repoID uint, ref string) error { return nil }
+
 // UpdateTaskID 更新任务ID
 func (m *mockSyncDocRepo) UpdateTaskID(docID uint, taskID uint) error { return nil }
 
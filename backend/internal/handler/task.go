@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
 )
 
@@ -55,6 +57,62 @@ func (h *TaskHandler) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// ListWriters 列出已注册的写入器（含 YAML 自定义写入器）
+func (h *TaskHandler) ListWriters(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"writers": h.service.ListWriters()})
+}
+
+// GetGraph 获取仓库的任务依赖图
+func (h *TaskHandler) GetGraph(c *gin.Context) {
+	repoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository id"})
+		return
+	}
+
+	graph, err := h.service.GetTaskGraph(uint(repoID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// AddDependencies 为任务追加上游依赖
+func (h *TaskHandler) AddDependencies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req struct {
+		DependsOn []uint `json:"depends_on" binding:"required"`
+		OnFailure string `json:"on_failure"` // block（默认）, skip
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.service.Get(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	if err := h.service.AddDependencies(uint(id), req.DependsOn, req.OnFailure); err != nil {
+		status := http.StatusBadRequest
+		if !errors.Is(err, service.ErrDependencyCycle) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "task dependencies updated"})
+}
+
 // Enqueue 提交任务到队列（替代原来的Run方法）
 // 接口变更：从"立即执行"改为"提交作业"
 func (h *TaskHandler) Enqueue(c *gin.Context) {
@@ -99,6 +157,12 @@ func (h *TaskHandler) Run(c *gin.Context) {
 		return
 	}
 
+	// 手动运行视为交互请求，优先于后台任务分发
+	if err := h.service.SetPriority(uint(id), domain.PriorityInteractive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 提交任务到编排器队列
 	if err := h.service.Enqueue(uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -223,6 +287,42 @@ func (h *TaskHandler) Cancel(c *gin.Context) {
 	})
 }
 
+// SetPriority 调整任务优先级（interactive/normal/background）
+func (h *TaskHandler) SetPriority(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req struct {
+		Priority domain.TaskPriority `json:"priority" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Priority.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid priority"})
+		return
+	}
+
+	if _, err := h.service.Get(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	if err := h.service.SetPriority(uint(id), req.Priority); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "task priority updated",
+		"priority": req.Priority,
+	})
+}
+
 // CleanupStuck 清理超时的卡住任务
 func (h *TaskHandler) CleanupStuck(c *gin.Context) {
 	// 默认超时时间为 10 分钟
@@ -332,3 +432,25 @@ func (h *TaskHandler) GetTaskUsage(c *gin.Context) {
 		"data":    usage,
 	})
 }
+
+// GetTrace 获取任务执行轨迹（Agent 步骤、模型调用与工具调用）
+func (h *TaskHandler) GetTrace(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	if _, err := h.service.Get(uint(taskID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	trace, err := h.service.GetTrace(c.Request.Context(), uint(taskID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
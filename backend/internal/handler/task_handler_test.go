@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service"
 )
@@ -636,3 +637,19 @@ func requireJSONDecode(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
 
+
+// TestTaskStreamKeepsTerminalStatus 进度事件占满缓冲时，终态事件仍然送达
+func TestTaskStreamKeepsTerminalStatus(t *testing.T) {
+	stream := newTaskStream()
+	for i := 0; i < taskStreamBuffer+10; i++ {
+		stream.publish(eventbus.TaskProgressEvent{Type: eventbus.TaskProgressModelOutput, TaskID: 1})
+	}
+	stream.publish(eventbus.TaskProgressEvent{Type: eventbus.TaskProgressStatus, TaskID: 1, Status: "running"})
+	stream.publish(eventbus.TaskProgressEvent{Type: eventbus.TaskProgressStatus, TaskID: 1, Status: "succeeded"})
+
+	status := <-stream.statuses
+	assert.Equal(t, "succeeded", status.Status)
+	assert.True(t, isTerminalProgress(status))
+	assert.Len(t, stream.pending(), taskStreamBuffer)
+	assert.Empty(t, stream.pending())
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+	"k8s.io/klog/v2"
+)
+
+const (
+	taskStreamBuffer    = 256              // 单个订阅者缓冲的进度事件数，消费过慢时丢弃新的进度事件（状态事件不丢弃）
+	taskStreamHeartbeat = 15 * time.Second // 心跳间隔，防止代理断开空闲连接
+)
+
+// Stream 实时推送任务进度
+// 默认使用 SSE；请求携带 WebSocket 升级头时改用 WebSocket。任务进入终态后关闭连接
+func (h *TaskHandler) Stream(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	stream := newTaskStream()
+	unsubscribe := h.service.SubscribeProgress(uint(id), func(ctx context.Context, event eventbus.TaskProgressEvent) error {
+		stream.publish(event)
+		return nil
+	})
+	defer unsubscribe()
+
+	// 订阅后再读取当前状态，订阅前进入终态的任务也能推送终态并关闭连接
+	task, err := h.service.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	initial := eventbus.TaskProgressEvent{
+		Type:      eventbus.TaskProgressStatus,
+		TaskID:    task.ID,
+		Timestamp: time.Now(),
+		Status:    task.Status,
+		Error:     task.ErrorMsg,
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamWebSocket(c, initial, stream)
+		return
+	}
+	h.streamSSE(c, initial, stream)
+}
+
+// taskStream 单个订阅者的事件缓冲
+// 进度事件缓冲满时丢弃；状态事件单独缓冲且只保留最新一条，保证终态事件一定送达并关闭连接
+type taskStream struct {
+	events   chan eventbus.TaskProgressEvent
+	statuses chan eventbus.TaskProgressEvent
+	mu       sync.Mutex
+}
+
+func newTaskStream() *taskStream {
+	return &taskStream{
+		events:   make(chan eventbus.TaskProgressEvent, taskStreamBuffer),
+		statuses: make(chan eventbus.TaskProgressEvent, 1),
+	}
+}
+
+func (s *taskStream) publish(event eventbus.TaskProgressEvent) {
+	if event.Type != eventbus.TaskProgressStatus {
+		select {
+		case s.events <- event:
+		default:
+			klog.V(6).Infof("任务进度订阅者消费过慢，丢弃事件: taskID=%d, type=%s", event.TaskID, event.Type)
+		}
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 尚未发送的旧状态被新状态替换
+	select {
+	case <-s.statuses:
+	default:
+	}
+	s.statuses <- event
+}
+
+// pending 取出已缓冲的进度事件，发送状态事件前先发送，保持事件顺序
+func (s *taskStream) pending() []eventbus.TaskProgressEvent {
+	var events []eventbus.TaskProgressEvent
+	for {
+		select {
+		case event := <-s.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func (h *TaskHandler) streamSSE(c *gin.Context, initial eventbus.TaskProgressEvent, stream *taskStream) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent(string(initial.Type), initial)
+	c.Writer.Flush()
+	if isTerminalProgress(initial) {
+		return
+	}
+
+	heartbeat := time.NewTicker(taskStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-stream.events:
+			c.SSEvent(string(event.Type), event)
+			return true
+		case status := <-stream.statuses:
+			for _, event := range stream.pending() {
+				c.SSEvent(string(event.Type), event)
+			}
+			c.SSEvent(string(status.Type), status)
+			return !isTerminalProgress(status)
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"timestamp": time.Now()})
+			return true
+		}
+	})
+}
+
+func (h *TaskHandler) streamWebSocket(c *gin.Context, initial eventbus.TaskProgressEvent, stream *taskStream) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		klog.Errorf("任务进度 WebSocket 升级失败: taskID=%d, error=%v", initial.TaskID, err)
+		return
+	}
+	defer conn.Close()
+
+	// 读协程只用于感知客户端断开
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(v interface{}) error {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(v)
+	}
+
+	if err := write(initial); err != nil || isTerminalProgress(initial) {
+		return
+	}
+
+	heartbeat := time.NewTicker(taskStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event := <-stream.events:
+			if err := write(event); err != nil {
+				return
+			}
+		case status := <-stream.statuses:
+			for _, event := range stream.pending() {
+				if err := write(event); err != nil {
+					return
+				}
+			}
+			if err := write(status); err != nil || isTerminalProgress(status) {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		case <-heartbeat.C:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isTerminalProgress 判断是否为任务终态事件
+func isTerminalProgress(event eventbus.TaskProgressEvent) bool {
+	return event.Type == eventbus.TaskProgressStatus && statemachine.IsTerminal(statemachine.TaskStatus(event.Status))
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/webhook"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// maxWebhookBody 推送请求体上限，与 GitHub 的 25MB 载荷上限一致
+const maxWebhookBody = 25 << 20
+
+// WebhookHandler 推送 Webhook 接口：平台投递入口与仓库的 Webhook 配置
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler 创建 Webhook 处理器
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// Receive 接收 GitHub、GitLab、Gitea 的推送，签名通过后按分支过滤并防抖触发增量分析
+func (h *WebhookHandler) Receive(c *gin.Context) {
+	repoID, ok := parseWebhookRepoID(c)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	delivery, err := h.service.Receive(c.Request.Context(), repoID, c.Request.Header, body)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if delivery.Scheduled {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, gin.H{"data": delivery})
+}
+
+// Get 获取仓库的 Webhook 配置
+func (h *WebhookHandler) Get(c *gin.Context) {
+	repoID, ok := parseWebhookRepoID(c)
+	if !ok {
+		return
+	}
+	hook, err := h.service.Get(c.Request.Context(), repoID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hook, "url": webhookPath(repoID)})
+}
+
+// Update 创建或更新仓库的 Webhook 配置，自动生成的密钥只在本次响应中返回
+func (h *WebhookHandler) Update(c *gin.Context) {
+	repoID, ok := parseWebhookRepoID(c)
+	if !ok {
+		return
+	}
+	var req service.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hook, generated, err := h.service.Configure(c.Request.Context(), repoID, req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	resp := gin.H{"data": hook, "url": webhookPath(repoID)}
+	if generated != "" {
+		resp["secret"] = generated
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete 删除仓库的 Webhook 配置
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	repoID, ok := parseWebhookRepoID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), repoID); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// webhookPath 平台中填写的投递地址（相对服务根路径）
+func webhookPath(repoID uint) string {
+	return fmt.Sprintf("/api/webhooks/%d", repoID)
+}
+
+func parseWebhookRepoID(c *gin.Context) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// webhookErrorStatus 将 Webhook 错误映射为 HTTP 状态码
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, webhook.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, webhook.ErrUnknownProvider),
+		errors.Is(err, webhook.ErrInvalidPayload),
+		errors.Is(err, service.ErrInvalidWebhookBranch),
+		errors.Is(err, service.ErrWebhookUnsupportedSource):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrRepositoryWebhookNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
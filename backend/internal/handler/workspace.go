@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// WorkspaceHandler 工作空间与成员管理处理器
+type WorkspaceHandler struct {
+	service *service.WorkspaceService
+}
+
+// NewWorkspaceHandler 创建工作空间处理器
+func NewWorkspaceHandler(service *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{service: service}
+}
+
+// Service 返回工作空间服务，供工作空间中间件校验访问权限
+func (h *WorkspaceHandler) Service() *service.WorkspaceService {
+	return h.service
+}
+
+// RegisterRoutes 注册路由
+func (h *WorkspaceHandler) RegisterRoutes(router *gin.RouterGroup) {
+	workspaces := router.Group("/workspaces")
+	{
+		workspaces.GET("", h.List)
+		workspaces.POST("", h.Create)
+		workspaces.GET("/:id", h.Get)
+		workspaces.PUT("/:id", h.Update)
+		workspaces.DELETE("/:id", h.Delete)
+		workspaces.GET("/:id/members", h.ListMembers)
+		workspaces.POST("/:id/members", h.AddMember)
+		workspaces.DELETE("/:id/members/:user_id", h.RemoveMember)
+	}
+}
+
+// AddWorkspaceMemberRequest 添加成员请求
+type AddWorkspaceMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// List 列出当前用户可访问的工作空间
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	workspaces, err := h.service.List(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		klog.Errorf("ListWorkspaces: failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspaces, "total": len(workspaces)})
+}
+
+// Create 创建工作空间
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	var req service.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	workspace, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, workspace)
+}
+
+// Get 获取工作空间
+func (h *WorkspaceHandler) Get(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Authorize(c.Request.Context(), middleware.CurrentUser(c), id); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	workspace, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, workspace)
+}
+
+// Update 更新工作空间
+func (h *WorkspaceHandler) Update(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	var req service.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	workspace, err := h.service.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, workspace)
+}
+
+// Delete 删除工作空间
+func (h *WorkspaceHandler) Delete(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ListMembers 列出工作空间成员
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	members, err := h.service.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members, "total": len(members)})
+}
+
+// AddMember 添加工作空间成员
+func (h *WorkspaceHandler) AddMember(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	var req AddWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.AddMember(c.Request.Context(), id, req.UserID); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "added"})
+}
+
+// RemoveMember 移除工作空间成员
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	id, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	var userID uint
+	if _, err := fmt.Sscanf(c.Param("user_id"), "%d", &userID); err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := h.service.RemoveMember(c.Request.Context(), id, userID); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed"})
+}
+
+// parseWorkspaceID 解析路径中的工作空间ID，失败时直接返回 400
+func parseWorkspaceID(c *gin.Context) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// workspaceErrorStatus 将工作空间服务错误映射为 HTTP 状态码
+func workspaceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrWorkspaceNotFound), errors.Is(err, repository.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrWorkspaceDuplicate), errors.Is(err, service.ErrWorkspaceNotEmpty), errors.Is(err, service.ErrDefaultWorkspace):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidWorkspaceSlug), errors.Is(err, service.ErrWorkspaceNameRequired):
+		return http.StatusBadRequest
+	}
+	klog.Errorf("workspace handler: %v", err)
+	return http.StatusInternalServerError
+}
@@ -85,7 +85,7 @@ func (w *MCPServerWrapper) registerTools() {
 
 	// 3. search_documents - 搜索文档内容
 	w.server.AddTool(mcp.NewTool("search_documents",
-		mcp.WithDescription("搜索文档内容。通过关键词在文档标题、文件名、内容中进行匹配搜索。支持分页和类型过滤。"),
+		mcp.WithDescription("搜索文档内容。文档按章节分块建立索引，按相关度（BM25）排序，返回每篇文档最相关的片段。支持代码标识符（如 GetUserByID、task_id）与中文检索，可按仓库、版本、标题和类型过滤。"),
 		mcp.WithString("query",
 			mcp.Required(),
 			mcp.Description("搜索关键词"),
@@ -96,6 +96,15 @@ func (w *MCPServerWrapper) registerTools() {
 		mcp.WithNumber("limit",
 			mcp.Description("返回结果数量限制，默认 10，最大 50"),
 		),
+		mcp.WithNumber("version",
+			mcp.Description("限定文档版本号（可选，不传则只搜索最新版本）"),
+		),
+		mcp.WithString("ref",
+			mcp.Description("限定引用文档集，如分支或标签名（可选，不传则只搜索仓库主文档集）"),
+		),
+		mcp.WithString("title",
+			mcp.Description("按文档标题过滤，标题包含该字符串（可选）"),
+		),
 		mcp.WithString("doc_type",
 			mcp.Description("按文档类型过滤，可选值：api, architecture, guide, readme"),
 		),
@@ -142,7 +151,7 @@ func (w *MCPServerWrapper) handleListRepositories(ctx context.Context, request m
 
 	status := request.GetString("status", "")
 
-	repos, err := w.repoService.List()
+	repos, err := w.repoService.List(ctx)
 	if err != nil {
 		klog.Errorf("MCP: 获取仓库列表失败: %v", err)
 		return mcp.NewToolResultError(fmt.Sprintf("获取仓库列表失败: %v", err)), nil
@@ -215,7 +224,7 @@ func (w *MCPServerWrapper) handleGetRepository(ctx context.Context, request mcp.
 
 	// 优先使用 repo_id
 	if repoID > 0 {
-		repo, err = w.repoService.Get(uint(repoID))
+		repo, err = w.repoService.Get(ctx, uint(repoID))
 		if err != nil {
 			klog.Errorf("MCP: 获取仓库失败 (id=%d): %v", repoID, err)
 			return mcp.NewToolResultError(fmt.Sprintf("获取仓库失败: %v", err)), nil
@@ -225,7 +234,7 @@ func (w *MCPServerWrapper) handleGetRepository(ctx context.Context, request mcp.
 		}
 	} else if repoName != "" {
 		// 通过名称查找仓库
-		repos, listErr := w.repoService.List()
+		repos, listErr := w.repoService.List(ctx)
 		if listErr != nil {
 			klog.Errorf("MCP: 获取仓库列表失败: %v", listErr)
 			return mcp.NewToolResultError(fmt.Sprintf("获取仓库列表失败: %v", listErr)), nil
@@ -288,11 +297,10 @@ func (w *MCPServerWrapper) handleSearchDocuments(ctx context.Context, request mc
 	}
 
 	repoID, _ := request.RequireInt("repo_id")
-	var repoIDPtr *uint
-	if repoID > 0 {
-		id := uint(repoID)
-		repoIDPtr = &id
+	if repoID < 0 {
+		repoID = 0
 	}
+	version, _ := request.RequireInt("version")
 
 	// 分页参数
 	limit := 10
@@ -305,7 +313,15 @@ func (w *MCPServerWrapper) handleSearchDocuments(ctx context.Context, request mc
 
 	docType := request.GetString("doc_type", "")
 
-	results, err := w.docService.SearchDocuments(ctx, query, repoIDPtr)
+	// 类型过滤在检索之后进行，先取足够多的候选结果
+	results, err := w.docService.SearchDocuments(ctx, service.DocumentSearchRequest{
+		Query:        query,
+		RepositoryID: uint(repoID),
+		Ref:          request.GetString("ref", ""),
+		Version:      version,
+		Title:        request.GetString("title", ""),
+		Limit:        100,
+	})
 	if err != nil {
 		klog.Errorf("MCP: 搜索文档失败: %v", err)
 		return mcp.NewToolResultError(fmt.Sprintf("搜索文档失败: %v", err)), nil
@@ -349,18 +365,19 @@ func (w *MCPServerWrapper) handleReadDocument(ctx context.Context, request mcp.C
 		return mcp.NewToolResultError("doc_id 参数是必需的"), nil
 	}
 
-	doc, err := w.docService.Get(uint(docID))
+	doc, err := w.docService.GetInWorkspace(ctx, uint(docID))
 	if err != nil {
 		klog.Errorf("MCP: 获取文档失败: %v", err)
 		return mcp.NewToolResultError(fmt.Sprintf("获取文档失败: %v", err)), nil
 	}
 
-	// 获取仓库名称
-	repoName := ""
-	repo, err := w.repoService.Get(doc.RepositoryID)
-	if err == nil && repo != nil {
-		repoName = repo.Name
+	// 仓库同样按工作空间查询，查不到时不返回文档内容
+	repo, err := w.repoService.Get(ctx, doc.RepositoryID)
+	if err != nil {
+		klog.Errorf("MCP: 获取文档所属仓库失败: %v", err)
+		return mcp.NewToolResultError(fmt.Sprintf("获取文档失败: %v", err)), nil
 	}
+	repoName := repo.Name
 
 	result := map[string]interface{}{
 		"id":        doc.ID,
@@ -387,18 +404,19 @@ func (w *MCPServerWrapper) handleGetDocumentSummary(ctx context.Context, request
 		return mcp.NewToolResultError("doc_id 参数是必需的"), nil
 	}
 
-	doc, err := w.docService.Get(uint(docID))
+	doc, err := w.docService.GetInWorkspace(ctx, uint(docID))
 	if err != nil {
 		klog.Errorf("MCP: 获取文档失败: %v", err)
 		return mcp.NewToolResultError(fmt.Sprintf("获取文档失败: %v", err)), nil
 	}
 
-	// 获取仓库名称
-	repoName := ""
-	repo, err := w.repoService.Get(doc.RepositoryID)
-	if err == nil && repo != nil {
-		repoName = repo.Name
+	// 仓库同样按工作空间查询，查不到时不返回文档内容
+	repo, err := w.repoService.Get(ctx, doc.RepositoryID)
+	if err != nil {
+		klog.Errorf("MCP: 获取文档所属仓库失败: %v", err)
+		return mcp.NewToolResultError(fmt.Sprintf("获取文档失败: %v", err)), nil
 	}
+	repoName := repo.Name
 
 	// 生成摘要（前 500 字符）
 	summary := doc.Content
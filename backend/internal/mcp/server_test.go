@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// TestReadDocument_WorkspaceScope 其他工作空间的文档不能通过 MCP 读取
+func TestReadDocument_WorkspaceScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}))
+
+	repoRepo := repository.NewRepoRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+	docRepo := repository.NewDocumentRepository(db)
+	teamA := repository.WithWorkspace(context.Background(), 2)
+	teamB := repository.WithWorkspace(context.Background(), 3)
+
+	repo := &model.Repository{Name: "secret", URL: "https://github.com/org/secret"}
+	require.NoError(t, repoRepo.Create(teamA, repo))
+	doc := &model.Document{RepositoryID: repo.ID, Title: "overview", Content: "internal design"}
+	require.NoError(t, db.Create(doc).Error)
+
+	cfg := &config.Config{}
+	cfg.Data.RepoDir = t.TempDir()
+	w := NewMCPServer(
+		service.NewRepositoryService(cfg, repoRepo, taskRepo, docRepo, nil, nil),
+		service.NewDocumentService(cfg, docRepo, repoRepo, nil, nil),
+	)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"doc_id": float64(doc.ID)}
+
+	for name, handle := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"read_document":        w.handleReadDocument,
+		"get_document_summary": w.handleGetDocumentSummary,
+	} {
+		result, err := handle(teamB, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError, name)
+		assert.NotContains(t, resultText(result), "internal design", name)
+
+		result, err = handle(teamA, request)
+		require.NoError(t, err)
+		assert.False(t, result.IsError, name)
+		assert.Contains(t, resultText(result), "internal design", name)
+	}
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var text string
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			text += c.Text
+		}
+	}
+	return text
+}
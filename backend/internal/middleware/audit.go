@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+// auditBodyLimit 记录请求体与解析响应体的最大字节数，超出部分不记录
+const auditBodyLimit = 64 * 1024
+
+// AuditRecorder 读取目标快照并追加审计日志
+type AuditRecorder interface {
+	Snapshot(ctx context.Context, targetType, targetID string) string
+	Record(ctx context.Context, entry *model.AuditLog)
+}
+
+// AuditTarget 路由对应的审计目标
+type AuditTarget struct {
+	Type    string // 目标类型，为空表示该路由不记录审计日志
+	Action  string
+	IDParam string // 路由中指向目标的参数名，为空时从创建接口的响应中读取 id
+}
+
+// AuditPolicy 返回写操作路由对应的审计目标
+type AuditPolicy func(method, path string) AuditTarget
+
+// Audit 审计中间件，记录所有写操作的操作人、目标、请求体与变更前后快照
+// 需在 Auth 与 Workspace 之后使用，以便读取当前用户与工作空间
+func Audit(recorder AuditRecorder, policy AuditPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		target := policy(method, c.FullPath())
+		if target.Type == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		entry := &model.AuditLog{
+			Action:     target.Action,
+			TargetType: target.Type,
+			Method:     method,
+			Path:       c.Request.URL.Path,
+			ClientIP:   c.ClientIP(),
+			ActorName:  "anonymous",
+			Request:    readAuditBody(c),
+		}
+		if target.IDParam != "" {
+			entry.TargetID = c.Param(target.IDParam)
+		}
+		if entry.TargetID != "" {
+			entry.Before = recorder.Snapshot(ctx, target.Type, entry.TargetID)
+		}
+
+		// 创建接口的目标 ID 只能从响应中获得
+		var response *auditResponseWriter
+		if entry.TargetID == "" {
+			response = &auditResponseWriter{ResponseWriter: c.Writer}
+			c.Writer = response
+		}
+
+		c.Next()
+
+		entry.StatusCode = c.Writer.Status()
+		if user := CurrentUser(c); user != nil {
+			entry.ActorID = user.ID
+			entry.ActorName = user.Username
+		}
+		if response != nil && entry.StatusCode < http.StatusBadRequest {
+			entry.TargetID = responseID(response.body.Bytes())
+		}
+		if entry.TargetID != "" && entry.StatusCode < http.StatusBadRequest {
+			entry.After = recorder.Snapshot(ctx, target.Type, entry.TargetID)
+		}
+		recorder.Record(ctx, entry)
+	}
+}
+
+// readAuditBody 读取 JSON 请求体并放回，供处理器再次读取
+func readAuditBody(c *gin.Context) string {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return ""
+	}
+	original := c.Request.Body
+	buf, err := io.ReadAll(io.LimitReader(original, auditBodyLimit+1))
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), original), Closer: original}
+	if err != nil || len(buf) > auditBodyLimit {
+		return ""
+	}
+	return string(buf)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseID 从 {"id": 1} 或 {"data": {"id": 1}} 形式的响应中读取目标 ID
+func responseID(body []byte) string {
+	var payload struct {
+		ID   json.RawMessage `json:"id"`
+		Data struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	raw := payload.ID
+	if len(raw) == 0 {
+		raw = payload.Data.ID
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return strings.Trim(string(raw), `"`)
+}
+
+// auditResponseWriter 截取响应体开头部分，用于读取新建目标的 ID
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(data []byte) {
+	if remaining := auditBodyLimit - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+const (
+	// SessionCookieName 登录会话 Cookie 名称
+	SessionCookieName = "odw_session"
+
+	currentUserKey = "currentUser"
+)
+
+// Authenticator 根据令牌解析当前用户
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*model.User, error)
+}
+
+// RolePolicy 返回访问路由所需的最低角色，返回空字符串表示无需登录
+// path 为 gin 注册的路由模板，例如 /api/repositories/:id
+type RolePolicy func(method, path string) string
+
+// Auth 认证与鉴权中间件
+// 请求携带有效令牌时总是解析出当前用户；未启用认证时不做拦截，保持原有的开放访问行为
+func Auth(enabled bool, authenticator Authenticator, policy RolePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := TokenFromRequest(c); token != "" && authenticator != nil {
+			if user, err := authenticator.Authenticate(c.Request.Context(), token); err == nil {
+				c.Set(currentUserKey, user)
+			}
+		}
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		required := policy(c.Request.Method, path)
+		if required == "" {
+			c.Next()
+			return
+		}
+
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		if !user.HasRole(required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied", "required_role": required})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TokenFromRequest 依次从 Authorization: Bearer 头和会话 Cookie 中读取令牌
+func TokenFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if cookie, err := c.Cookie(SessionCookieName); err == nil {
+		return cookie
+	}
+	return ""
+}
+
+// CurrentUser 返回当前请求的登录用户，未登录时返回 nil
+func CurrentUser(c *gin.Context) *model.User {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil
+	}
+	user, _ := value.(*model.User)
+	return user
+}
+
+// CurrentUserID 返回当前登录用户ID，未登录时返回 0
+func CurrentUserID(c *gin.Context) uint {
+	if user := CurrentUser(c); user != nil {
+		return user.ID
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+// WorkspaceHeader 指定当前工作空间的请求头，也可使用 workspace_id 查询参数
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceAuthorizer 校验工作空间访问权限并解析资源归属
+type WorkspaceAuthorizer interface {
+	Authorize(ctx context.Context, user *model.User, workspaceID uint) error
+	ResourceWorkspace(ctx context.Context, kind string, id uint) (uint, error)
+}
+
+// ResourcePolicy 返回路由中 :id 参数指向的资源类型，返回空字符串表示无需校验归属
+type ResourcePolicy func(path string) string
+
+// Workspace 工作空间中间件，需在 Auth 之后使用
+// 未指定工作空间时使用默认工作空间；选定的工作空间写入请求 context，仓储据此限定查询范围
+func Workspace(authorizer WorkspaceAuthorizer, policy ResourcePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID := model.DefaultWorkspaceID
+		raw := c.GetHeader(WorkspaceHeader)
+		if raw == "" {
+			raw = c.Query("workspace_id")
+		}
+		if raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil || id == 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+				return
+			}
+			workspaceID = uint(id)
+		}
+
+		ctx := c.Request.Context()
+		if err := authorizer.Authorize(ctx, CurrentUser(c), workspaceID); err != nil {
+			if errors.Is(err, repository.ErrWorkspaceNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx = repository.WithWorkspace(ctx, workspaceID)
+		c.Request = c.Request.WithContext(ctx)
+
+		// 按 ID 访问的资源必须属于当前工作空间，不存在的资源交由处理器返回 404
+		if kind := policy(c.FullPath()); kind != "" {
+			if id, err := strconv.ParseUint(c.Param("id"), 10, 32); err == nil {
+				owner, err := authorizer.ResourceWorkspace(ctx, kind, uint(id))
+				if err == nil && owner != workspaceID {
+					klog.V(6).Infof("Workspace: %s %d belongs to workspace %d, not %d", kind, id, owner, workspaceID)
+					c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": kind + " not found"})
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
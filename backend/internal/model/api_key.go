@@ -8,6 +8,7 @@ import (
 // APIKey API Key 配置
 type APIKey struct {
 	ID               uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceID      uint       `json:"workspace_id" gorm:"index;default:1"` // 所属工作空间，只在该空间内可用
 	Name             string     `json:"name" gorm:"size:255;uniqueIndex;not null"`
 	Provider         string     `json:"provider" gorm:"size:50;index:idx_api_keys_provider;not null"`
 	BaseURL          string     `json:"base_url" gorm:"size:500;not null"`
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// AuditLog 写操作审计日志，只追加不修改，超过保留期后按时间批量清理
+type AuditLog struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint   `json:"workspace_id" gorm:"index"`
+	ActorID     uint   `json:"actor_id" gorm:"index"` // 0 表示未启用认证或系统调用
+	ActorName   string `json:"actor_name" gorm:"size:100"`
+	// 动作，由目标类型与操作组成，例如 repository.delete、task.force-reset、agent.versions.restore
+	Action     string `json:"action" gorm:"size:100;index"`
+	TargetType string `json:"target_type" gorm:"size:50;index:idx_audit_target"`
+	TargetID   string `json:"target_id" gorm:"size:255;index:idx_audit_target"`
+	Method     string `json:"method" gorm:"size:10"`
+	Path       string `json:"path" gorm:"size:500"`
+	StatusCode int    `json:"status_code"`
+	ClientIP   string `json:"client_ip" gorm:"size:64"`
+	// 请求体与变更前后快照均为 JSON，密钥类字段已脱敏，超长文本以长度与摘要代替
+	Request   string    `json:"request,omitempty" gorm:"type:text"`
+	Before    string    `json:"before,omitempty" gorm:"type:text"`
+	After     string    `json:"after,omitempty" gorm:"type:text"`
+	Diff      string    `json:"diff,omitempty" gorm:"type:text"` // 发生变化的字段：{"字段": {"before": 旧值, "after": 新值}}
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
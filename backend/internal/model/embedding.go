@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 向量分块的来源类型
+const (
+	EmbeddingSourceDocument = "document" // 生成的文档
+	EmbeddingSourceCode     = "code"     // 仓库源码文件
+)
+
+// EmbeddingProvider 向量模型（Embedding）配置，与 APIKey 的配置方式一致
+// 目前支持 OpenAI 兼容接口（POST {base_url}/embeddings），可指向本地部署的兼容服务
+type EmbeddingProvider struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Name         string     `json:"name" gorm:"size:255;uniqueIndex;not null"`
+	Provider     string     `json:"provider" gorm:"size:50;not null"` // openai
+	BaseURL      string     `json:"base_url" gorm:"size:500;not null"`
+	APIKey       string     `json:"api_key" gorm:"type:text"`
+	Model        string     `json:"model" gorm:"size:255;not null"`
+	Dimensions   int        `json:"dimensions" gorm:"default:0"` // 0 表示使用模型默认维度
+	Priority     int        `json:"priority" gorm:"default:0;index"`
+	Status       string     `json:"status" gorm:"size:20;default:'enabled';index"` // enabled/disabled
+	RequestCount int        `json:"request_count" gorm:"default:0"`
+	ErrorCount   int        `json:"error_count" gorm:"default:0"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (EmbeddingProvider) TableName() string {
+	return "embedding_providers"
+}
+
+// MaskAPIKey 脱敏 API Key（只显示前3位和后4位）
+func (p *EmbeddingProvider) MaskAPIKey() string {
+	if len(p.APIKey) <= 7 {
+		return "***"
+	}
+	return p.APIKey[:3] + "***" + p.APIKey[len(p.APIKey)-4:]
+}
+
+// IsAvailable 检查是否可用
+func (p *EmbeddingProvider) IsAvailable() bool {
+	return p.Status == "enabled" && p.DeletedAt == nil
+}
+
+// BeforeUpdate GORM 钩子：更新前自动设置 UpdatedAt
+func (p *EmbeddingProvider) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// EmbeddingChunk 向量化的内容分块（SQLite 平铺索引）
+// 向量以 float32 小端序存储在 Vector 中，检索时全量扫描计算余弦相似度
+type EmbeddingChunk struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RepositoryID uint      `json:"repository_id" gorm:"index:idx_embedding_source;not null"`
+	SourceType   string    `json:"source_type" gorm:"size:20;index:idx_embedding_source;not null"`
+	SourceID     uint      `json:"source_id" gorm:"index:idx_embedding_source"` // 文档ID；源码为 0
+	Path         string    `json:"path" gorm:"size:1000"`                       // 源码相对路径
+	Title        string    `json:"title" gorm:"size:255"`                       // 文档标题
+	Heading      string    `json:"heading" gorm:"size:500"`                     // 文档章节
+	StartLine    int       `json:"start_line"`
+	EndLine      int       `json:"end_line"`
+	Content      string    `json:"content" gorm:"type:text"`
+	ContentHash  string    `json:"content_hash" gorm:"size:64;index"`
+	ProviderName string    `json:"provider_name" gorm:"size:255;index"` // 生成向量的 EmbeddingProvider，切换后旧向量不参与检索
+	Dimensions   int       `json:"dimensions"`
+	Vector       []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
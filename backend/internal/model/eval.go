@@ -0,0 +1,92 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 评测运行状态
+const (
+	EvalStatusRunning   = "running"
+	EvalStatusCompleted = "completed"
+	EvalStatusFailed    = "failed"
+)
+
+// EvalRun 一次 Agent 评测：使用固定版本的 Agent 配置运行 Writer，对样例仓库的输出打分
+type EvalRun struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	AgentFile    string     `json:"agent_file" gorm:"size:255;index;not null"` // 被评测的 Agent 文件名（如 document_generator.yaml）
+	AgentVersion int        `json:"agent_version" gorm:"index"`                // 评测使用的版本号，0 表示未保存过版本的当前文件
+	Writer       string     `json:"writer" gorm:"size:50;not null"`
+	Judge        bool       `json:"judge"`                       // 是否启用 LLM 评审
+	Status       string     `json:"status" gorm:"size:20;index"` // running/completed/failed
+	Score        float64    `json:"score"`                       // 各样例得分的平均值（0-100）
+	SampleCount  int        `json:"sample_count"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Results []EvalResult `json:"results,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (EvalRun) TableName() string {
+	return "eval_runs"
+}
+
+// EvalResult 单个样例的评测结果
+type EvalResult struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RunID        uint       `json:"run_id" gorm:"index;not null"`
+	Sample       string     `json:"sample" gorm:"size:255;not null"`
+	Score        float64    `json:"score"`                 // 综合得分：规则得分与评审得分的平均值，未评审时等于规则得分
+	RuleScore    float64    `json:"rule_score"`            // 规则检查通过率（0-100）
+	JudgeScore   *float64   `json:"judge_score,omitempty"` // LLM 评审得分（0-100）
+	JudgeComment string     `json:"judge_comment,omitempty" gorm:"type:text"`
+	Checks       EvalChecks `json:"checks" gorm:"type:text"`
+	Output       string     `json:"output,omitempty" gorm:"type:text"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	DurationMs   int64      `json:"duration_ms"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (EvalResult) TableName() string {
+	return "eval_results"
+}
+
+// EvalCheck 一项规则检查的结果
+type EvalCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// EvalChecks 以 JSON 形式存储的规则检查结果
+type EvalChecks []EvalCheck
+
+// Value 实现 driver.Valuer
+func (c EvalChecks) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+// Scan 实现 sql.Scanner
+func (c *EvalChecks) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return fmt.Errorf("unsupported eval checks type %T", value)
+}
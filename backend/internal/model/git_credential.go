@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Git 凭证类型
+const (
+	GitCredentialToken  = "token"   // HTTPS 访问令牌（GitHub/GitLab/Gitea Personal Access Token 等）
+	GitCredentialBasic  = "basic"   // HTTPS 用户名与密码
+	GitCredentialSSHKey = "ssh_key" // SSH 部署密钥
+)
+
+// GitCredential 克隆私有仓库使用的凭证，按主机与组织匹配仓库
+type GitCredential struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint   `json:"workspace_id" gorm:"uniqueIndex:idx_git_credential_name;default:1"`
+	Name        string `json:"name" gorm:"size:100;uniqueIndex:idx_git_credential_name;not null"`
+	Host        string `json:"host" gorm:"size:255;index;not null"` // 小写主机名，例如 github.com
+	// 组织、用户或 GitLab 群组路径，为空时匹配该主机下的所有仓库；多个凭证匹配时取最长的组织
+	Owner    string `json:"owner" gorm:"size:255"`
+	Kind     string `json:"kind" gorm:"size:20;not null"`
+	Username string `json:"username" gorm:"size:255"`
+	// 加密后的令牌、密码或私钥，不通过接口返回
+	Secret     string    `json:"-" gorm:"type:text;not null"`
+	KnownHosts string    `json:"known_hosts" gorm:"type:text"` // SSH 主机公钥，为空时首次连接自动信任
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (GitCredential) TableName() string {
+	return "git_credentials"
+}
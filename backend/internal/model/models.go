@@ -6,13 +6,26 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 )
 
+// 仓库代码来源
+const (
+	RepositorySourceGit     = "git"     // 远端 git 仓库
+	RepositorySourceLocal   = "local"   // 服务器本地目录
+	RepositorySourceArchive = "archive" // 上传的压缩包
+)
+
 type Repository struct {
 	ID                    uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceID           uint       `json:"workspace_id" gorm:"index;default:1"` // 所属工作空间
 	Name                  string     `json:"name" gorm:"size:255;"`
 	URL                   string     `json:"url" gorm:"size:500;"`
+	SourceType            string     `json:"source_type" gorm:"size:20;default:git"` // 代码来源：git、local（服务器本地目录）、archive（上传的压缩包）
+	SourcePath            string     `json:"-" gorm:"size:500"`                      // 本地目录或保存的压缩包路径，来源为 git 时为空
+	SourceGitInit         bool       `json:"source_git_init"`                        // 导入的代码是否 git init 并按导入提交快照，用于增量分析
 	LocalPath             string     `json:"local_path" gorm:"size:500"`
 	Description           string     `json:"description" gorm:"size:1000"`
-	CloneBranch           string     `json:"clone_branch" gorm:"size:255"`
+	CredentialID          *uint      `json:"credential_id,omitempty" gorm:"index"` // 克隆使用的 Git 凭证，为空时按主机与组织自动匹配
+	CloneRefType          string     `json:"clone_ref_type" gorm:"size:20"`        // 跟随的引用类型：branch、tag、commit，为空表示默认分支
+	CloneBranch           string     `json:"clone_branch" gorm:"size:255"`         // 指定引用时为请求的分支、标签或提交，否则为克隆时的默认分支
 	CloneCommit           string     `json:"clone_commit_id" gorm:"size:100"`
 	SizeMB                float64    `json:"size_mb" gorm:"default:0"`
 	Status                string     `json:"status" gorm:"size:50;default:pending"` // pending, cloning, ready, analyzing, completed, error
@@ -27,28 +40,31 @@ type Repository struct {
 }
 
 type Task struct {
-	ID           uint              `json:"id" gorm:"primaryKey"`
-	RepositoryID uint              `json:"repository_id" gorm:"index;"`
-	DocID        uint              `json:"doc_id" gorm:"index;"` // 关联的文档ID
-	Repository   *Repository       `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
-	WriterName   domain.WriterName `json:"writer_name" gorm:"size:255;default:DefaultWriter"` // 关联的写入器名称
-	TaskType     domain.TaskType   `json:"task_type" gorm:"size:50;"`                         // 任务类型，生成文档，重写标题，生成目录
-	Title        string            `json:"title" gorm:"type:text"`                            // 不限制，标题可以为空，可以重写
-	Outline      string            `json:"outline" gorm:"type:text"`
-	Status       string            `json:"status" gorm:"size:50;default:pending"` // pending, queued, running, succeeded, failed, canceled
-	RunAfter     uint              `json:"run_after"`                             // 必须在哪个任务完成后才可以运行
-	ErrorMsg     string            `json:"error_msg" gorm:"size:1000"`
-	SortOrder    int               `json:"sort_order" gorm:"default:0"`
-	StartedAt    *time.Time        `json:"started_at" gorm:"column:started_at"`
-	CompletedAt  *time.Time        `json:"completed_at" gorm:"column:completed_at"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	RepositoryID uint                `json:"repository_id" gorm:"index;"`
+	DocID        uint                `json:"doc_id" gorm:"index;"`                 // 关联的文档ID
+	Ref          string              `json:"ref" gorm:"size:255;index;default:''"` // 所属引用文档集，为空表示仓库主文档集
+	Repository   *Repository         `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
+	WriterName   domain.WriterName   `json:"writer_name" gorm:"size:255;default:DefaultWriter"` // 关联的写入器名称
+	TaskType     domain.TaskType     `json:"task_type" gorm:"size:50;"`                         // 任务类型，生成文档，重写标题，生成目录
+	Title        string              `json:"title" gorm:"type:text"`                            // 不限制，标题可以为空，可以重写
+	Outline      string              `json:"outline" gorm:"type:text"`
+	Status       string              `json:"status" gorm:"size:50;default:pending"`  // pending, queued, running, succeeded, failed, canceled
+	RunAfter     uint                `json:"run_after"`                              // 必须在哪个任务完成后才可以运行
+	Priority     domain.TaskPriority `json:"priority" gorm:"size:20;default:normal"` // 优先级通道：interactive, normal, background
+	ErrorMsg     string              `json:"error_msg" gorm:"size:1000"`
+	SortOrder    int                 `json:"sort_order" gorm:"default:0"`
+	StartedAt    *time.Time          `json:"started_at" gorm:"column:started_at"`
+	CompletedAt  *time.Time          `json:"completed_at" gorm:"column:completed_at"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
 }
 
 type Document struct {
 	ID            uint      `json:"id" gorm:"primaryKey"`
 	RepositoryID  uint      `json:"repository_id" gorm:"index;"`
 	TaskID        uint      `json:"task_id" gorm:"index"`
+	Ref           string    `json:"ref" gorm:"size:255;index;default:''"` // 所属引用文档集，为空表示仓库主文档集
 	Title         string    `json:"title" gorm:"size:255;"`
 	Filename      string    `json:"filename" gorm:"size:255;"`
 	Content       string    `json:"content" gorm:"type:text"`
@@ -58,6 +74,7 @@ type Document struct {
 	ReplacedBy    uint      `json:"replaced_by" gorm:"index;"` //被替换为哪个DocID
 	CloneBranch   string    `json:"clone_branch" gorm:"size:255"`   // 生成文档时的分支名称
 	CloneCommitID string    `json:"clone_commit_id" gorm:"size:100"` // 生成文档时的 commit id
+	UpdatedBy     uint      `json:"updated_by" gorm:"index;default:0"` // 最后手工编辑的用户ID，0 表示由系统生成
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -99,11 +116,38 @@ type TaskUsage struct {
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// 任务执行轨迹的步骤类型
+const (
+	TaskTraceStepAgent = "agent" // 子 Agent 执行（BuildSequentialAgent 链中的一环）
+	TaskTraceStepModel = "model" // 一次模型调用
+	TaskTraceStepTool  = "tool"  // 一次工具调用
+)
+
+// TaskTrace 任务执行轨迹，记录 Agent 步骤、模型调用与工具调用，用于排查文档质量与调优提示词
+type TaskTrace struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	TaskID           uint      `json:"task_id" gorm:"index;not null"`
+	StepType         string    `json:"step_type" gorm:"size:20;index;not null"`
+	Agent            string    `json:"agent" gorm:"size:255"`
+	Name             string    `json:"name" gorm:"size:255"`      // 工具名称或模型（API Key）名称
+	LLMModel         string    `json:"llm_model" gorm:"size:255"` // 实际调用的模型
+	Arguments        string    `json:"arguments" gorm:"type:text"`
+	ResultSize       int       `json:"result_size"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	DurationMs       int64     `json:"duration_ms"`
+	Error            string    `json:"error" gorm:"type:text"`
+	StartedAt        time.Time `json:"started_at" gorm:"index"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
 type SyncTarget struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	URL       string    `json:"url" gorm:"size:500;uniqueIndex;not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"uniqueIndex:idx_sync_targets_workspace_url;default:1"`
+	URL         string    `json:"url" gorm:"size:500;uniqueIndex:idx_sync_targets_workspace_url;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type SyncEvent struct {
@@ -130,6 +174,7 @@ type IncrementalUpdateHistory struct {
 type ChatSession struct {
 	ID           uint          `json:"id" gorm:"primaryKey"`
 	SessionID    string        `json:"session_id" gorm:"size:64;uniqueIndex"`            // 唯一会话标识
+	WorkspaceID  uint          `json:"workspace_id" gorm:"index;default:1"`              // 所属工作空间
 	RepoID       uint          `json:"repo_id" gorm:"index"`                             // 关联仓库ID
 	Title        string        `json:"title" gorm:"size:255"`                            // 会话标题
 	Status       string        `json:"status" gorm:"size:20;default:'active'"`           // active, archived, deleted
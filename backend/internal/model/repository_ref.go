@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// 引用文档集状态
+const (
+	RepositoryRefPending = "pending"
+	RepositoryRefCloning = "cloning"
+	RepositoryRefReady   = "ready"
+	RepositoryRefError   = "error"
+)
+
+// RepositoryRef 仓库的附加引用文档集，例如 v1.x、v2.x 分支或 v1.0 标签
+// 每个引用有独立的本地检出，任务与文档通过 Ref 字段归属到该文档集；Ref 为空的任务与文档属于仓库主文档集
+type RepositoryRef struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RepositoryID uint      `json:"repository_id" gorm:"uniqueIndex:idx_repository_ref_name;not null"`
+	Name         string    `json:"name" gorm:"size:255;uniqueIndex:idx_repository_ref_name;not null"` // 分支名、标签名或提交
+	RefType      string    `json:"ref_type" gorm:"size:20;not null"`                                  // branch、tag、commit
+	LocalPath    string    `json:"local_path" gorm:"size:500"`
+	CommitID     string    `json:"commit_id" gorm:"size:100"` // 检出的提交
+	Status       string    `json:"status" gorm:"size:50;default:pending"`
+	ErrorMsg     string    `json:"error_msg" gorm:"size:1000"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (RepositoryRef) TableName() string {
+	return "repository_refs"
+}
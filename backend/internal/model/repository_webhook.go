@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// RepositoryWebhook 仓库的推送 Webhook 配置，接收 GitHub、GitLab、Gitea 的 push 事件触发增量分析
+type RepositoryWebhook struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RepositoryID uint       `json:"repository_id" gorm:"uniqueIndex;not null"`
+	Secret       string     `json:"-" gorm:"type:text;not null"` // 加密后的签名密钥
+	Branches     string     `json:"branches" gorm:"size:1000"`   // 触发分析的分支，逗号分隔，支持通配符；为空时使用仓库跟随的分支
+	Enabled      bool       `json:"enabled" gorm:"default:true"`
+	LastEventAt  *time.Time `json:"last_event_at,omitempty"`     // 最近一次收到推送的时间
+	LastResult   string     `json:"last_result" gorm:"size:255"` // 最近一次推送的处理结果
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (RepositoryWebhook) TableName() string {
+	return "repository_webhooks"
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// TaskDependency 任务依赖边：TaskID 需要等待 DependsOnID 成功后才可运行
+// 一个任务可以有多条依赖边，与 Task.RunAfter 一起构成仓库内的任务 DAG
+type TaskDependency struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RepositoryID uint      `json:"repository_id" gorm:"index"`
+	TaskID       uint      `json:"task_id" gorm:"uniqueIndex:idx_task_dependency;not null"`
+	DependsOnID  uint      `json:"depends_on_id" gorm:"uniqueIndex:idx_task_dependency;index;not null"`
+	OnFailure    string    `json:"on_failure" gorm:"size:20;default:block"` // block, skip
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// 上游任务失败或取消时，依赖任务的处理策略
+const (
+	DependencyOnFailureBlock = "block" // 阻塞：保持 pending，上游重试成功后继续
+	DependencyOnFailureSkip  = "skip"  // 跳过：直接取消依赖任务，并继续向下游传播
+)
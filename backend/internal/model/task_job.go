@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// TaskJob 编排器持久化队列中的作业
+// 每个任务最多对应一条作业记录，重启后按记录恢复排队、重试次数与退避时间
+type TaskJob struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	TaskID         uint      `json:"task_id" gorm:"uniqueIndex;not null"`
+	RepositoryID   uint      `json:"repository_id" gorm:"index"`
+	Status         string    `json:"status" gorm:"size:20;index;default:waiting"` // waiting, running
+	Priority       string    `json:"priority" gorm:"size:20;default:normal"`      // interactive, normal, background
+	RetryCount     int       `json:"retry_count" gorm:"default:0"`
+	MaxRetries     int       `json:"max_retries" gorm:"default:5"`
+	TimeoutSeconds int       `json:"timeout_seconds" gorm:"default:0"`
+	NextRunAt      time.Time `json:"next_run_at" gorm:"index"` // 最早可分发时间（退避截止时间）
+	EnqueuedAt     time.Time `json:"enqueued_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TaskJobStatus 作业状态枚举
+const (
+	TaskJobStatusWaiting = "waiting" // 等待分发
+	TaskJobStatusRunning = "running" // 已分发执行中
+)
@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+// 用户角色，权限依次递增
+const (
+	RoleViewer = "viewer" // 只读：浏览仓库、文档、任务，发起对话
+	RoleEditor = "editor" // 编辑：管理仓库、任务与文档
+	RoleAdmin  = "admin"  // 管理员：管理用户、API Key、Agent、同步等系统配置
+)
+
+// 用户来源
+const (
+	AuthSourceLocal = "local" // 本地用户名密码
+	AuthSourceOIDC  = "oidc"  // OIDC 单点登录
+)
+
+// 用户状态
+const (
+	UserStatusEnabled  = "enabled"
+	UserStatusDisabled = "disabled"
+)
+
+// RoleLevel 返回角色的权限等级，未知角色返回 0
+func RoleLevel(role string) int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// IsValidRole 判断角色是否合法
+func IsValidRole(role string) bool {
+	return RoleLevel(role) > 0
+}
+
+// User 用户
+type User struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Username     string     `json:"username" gorm:"size:100;uniqueIndex;not null"`
+	DisplayName  string     `json:"display_name" gorm:"size:255"`
+	Email        string     `json:"email" gorm:"size:255;index"`
+	PasswordHash string     `json:"-" gorm:"size:255"` // bcrypt 哈希，为空表示不允许密码登录
+	Role         string     `json:"role" gorm:"size:20;default:'viewer'"`
+	Status       string     `json:"status" gorm:"size:20;default:'enabled'"`
+	AuthSource   string     `json:"auth_source" gorm:"size:20;default:'local'"`
+	Subject      string     `json:"-" gorm:"size:255;index"` // OIDC 用户的 sub
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// HasRole 判断用户是否具备指定角色的权限
+func (u *User) HasRole(role string) bool {
+	return u.Status == UserStatusEnabled && RoleLevel(u.Role) >= RoleLevel(role)
+}
+
+// APIToken 供脚本调用的 API Token，仅保存哈希
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"size:255"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:20"` // 明文前缀，便于用户识别
+	Role       string     `json:"role" gorm:"size:20"`   // 为空时继承用户角色，否则不高于用户角色
+	ExpiresAt  *time.Time `json:"expires_at"`            // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// UserSession 登录会话，仅保存令牌哈希
+type UserSession struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	TokenHash string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// DefaultWorkspaceID 默认工作空间ID，升级前的数据都归属该工作空间
+const DefaultWorkspaceID uint = 1
+
+// Workspace 工作空间，隔离仓库、API Key、同步目标与对话
+type Workspace struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Slug        string `json:"slug" gorm:"size:100;uniqueIndex;not null"`
+	Description string `json:"description" gorm:"size:500"`
+	// 覆盖全局 agent/skill 目录，同名文件优先使用工作空间目录，缺失时回退到全局目录
+	AgentDir  string    `json:"agent_dir" gorm:"size:500"`
+	SkillDir  string    `json:"skill_dir" gorm:"size:500"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+// WorkspaceMember 工作空间成员，管理员无需加入即可访问所有工作空间
+type WorkspaceMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_member"`
+	UserID      uint      `json:"user_id" gorm:"uniqueIndex:idx_workspace_member;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
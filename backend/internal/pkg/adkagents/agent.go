@@ -20,7 +20,8 @@ type AgentDefinition struct {
 	MaxIterations int      `yaml:"maxIterations" json:"max_iterations"` // 最大迭代次数
 
 	// 可选配置
-	Exit ExitConfig `yaml:"exit,omitempty" json:"exit,omitempty"` // 退出条件
+	Exit    ExitConfig    `yaml:"exit,omitempty" json:"exit,omitempty"`       // 退出条件
+	Sandbox SandboxConfig `yaml:"sandbox,omitempty" json:"sandbox,omitempty"` // 命令执行策略
 
 	// 路径信息（运行时填充）
 	Path     string    `json:"path"`      // 配置文件路径
@@ -35,6 +35,11 @@ func (f *AgentFactory) GetAgent(name string) (adk.Agent, error) {
 	return f.Manager.GetAgent(name)
 }
 
+// GetAgentFor 按 context 中的工作空间获取基础 Agent
+func (f *AgentFactory) GetAgentFor(ctx context.Context, name string) (adk.Agent, error) {
+	return f.Manager.GetAgentFor(ctx, name)
+}
+
 // Stop 停止 AgentFactory，释放资源
 func (f *AgentFactory) Stop() {
 	if f.Manager != nil {
@@ -70,7 +75,7 @@ func BuildSequentialAgent(
 	for _, agentName := range agentNames {
 		// 每次都创建全新的 Agent 实例，不使用缓存
 		// 因为 ADK Agent 运行后会被冻结，不能复用
-		agent, err := factory.Manager.CreateAgent(agentName)
+		agent, err := factory.Manager.CreateAgentFor(ctx, agentName)
 		if err != nil {
 			return nil, fmt.Errorf("获取 Agent 失败: name=%s, err=%w", agentName, err)
 		}
@@ -109,7 +114,7 @@ func IsMaxIterationsError(err error) bool {
 // agent: 需要运行的 Agent
 // messages: 初始消息列表
 // 返回: lastContent（可能为空）、error（若中途出错）
-func RunAgentToLastContent(ctx context.Context, agent adk.Agent, messages []adk.Message) (string, error) {
+func RunAgentToLastContent(ctx context.Context, agent adk.Agent, messages []adk.Message) (_ string, err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -121,6 +126,9 @@ func RunAgentToLastContent(ctx context.Context, agent adk.Agent, messages []adk.
 	iter := runner.Run(ctx, messages)
 
 	var lastContent string
+	// 跟踪当前执行的子 Agent，切换时推送进度事件并记录执行轨迹
+	steps := &agentStepTracker{ctx: ctx}
+	defer func() { steps.finish(err) }()
 	for {
 		select {
 		case <-ctx.Done():
@@ -132,6 +140,9 @@ func RunAgentToLastContent(ctx context.Context, agent adk.Agent, messages []adk.
 		if !ok {
 			break
 		}
+		if event.AgentName != "" {
+			steps.switchTo(event.AgentName)
+		}
 		if event.Err != nil {
 			return lastContent, event.Err
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"k8s.io/klog/v2"
 )
 
@@ -72,6 +73,8 @@ func (ec *EinoCallbacks) onStart(ctx context.Context, info *callbacks.RunInfo, i
 		ec.logModelInput(input, info)
 	case "Tool":
 		ec.logToolInput(input, info)
+		ec.publishToolInput(ctx, input, info)
+		ctx = ec.traceToolInput(ctx, input)
 	default:
 		ec.logGenericInput(input, info)
 	}
@@ -103,8 +106,11 @@ func (ec *EinoCallbacks) onEnd(ctx context.Context, info *callbacks.RunInfo, out
 	switch info.Component {
 	case "ChatModel", "Model":
 		ec.logModelOutput(output, info)
+		ec.publishModelOutput(ctx, output, info)
 	case "Tool":
 		ec.logToolOutput(output, info)
+		ec.publishToolOutput(ctx, output, info)
+		ec.traceToolOutput(ctx, output, info)
 	default:
 		ec.logGenericOutput(output, info)
 	}
@@ -132,6 +138,14 @@ func (ec *EinoCallbacks) onError(ctx context.Context, info *callbacks.RunInfo, e
 		"name", info.Name,
 		"duration_ms", duration.Milliseconds(),
 	)
+	publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+		Type:  eventbus.TaskProgressError,
+		Agent: info.Name,
+		Error: err.Error(),
+	})
+	if info.Component == "Tool" {
+		recordToolTrace(ctx, info.Name, 0, err)
+	}
 
 	delete(ec.startTimes, nodeKey)
 	return ctx
@@ -149,7 +163,8 @@ func (ec *EinoCallbacks) onStartWithStreamInput(ctx context.Context, info *callb
 		"name", info.Name,
 	)
 
-	// 流式输入时，我们无法直接读取内容，只能记录开始事件
+	// 流式输入时，我们无法直接读取内容，只能记录开始事件；回调收到的是副本，需要关闭
+	input.Close()
 	return ctx
 }
 
@@ -165,7 +180,12 @@ func (ec *EinoCallbacks) onEndWithStreamOutput(ctx context.Context, info *callba
 		"name", info.Name,
 	)
 
-	// 流式输出时，我们无法直接读取内容，只能记录结束事件
+	// 流式输出时只记录结束事件；模型的流式片段作为进度事件推送，回调收到的副本读完后关闭
+	if info.Component == "ChatModel" || info.Component == "Model" {
+		go ec.publishModelStream(ctx, output, info)
+	} else {
+		output.Close()
+	}
 	return ctx
 }
 
@@ -364,6 +384,114 @@ func (ec *EinoCallbacks) logToolOutput(output callbacks.CallbackOutput, info *ca
 	}
 }
 
+// ========== 进度事件推送 ==========
+
+// publishToolInput 推送工具调用事件
+func (ec *EinoCallbacks) publishToolInput(ctx context.Context, input callbacks.CallbackInput, info *callbacks.RunInfo) {
+	toolInput := tool.ConvCallbackInput(input)
+	if toolInput == nil {
+		return
+	}
+	publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+		Type:      eventbus.TaskProgressToolInvoked,
+		Tool:      info.Name,
+		Arguments: toolInput.ArgumentsInJSON,
+	})
+}
+
+// publishToolOutput 推送工具结果事件
+func (ec *EinoCallbacks) publishToolOutput(ctx context.Context, output callbacks.CallbackOutput, info *callbacks.RunInfo) {
+	toolOutput := tool.ConvCallbackOutput(output)
+	if toolOutput == nil {
+		return
+	}
+	publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+		Type:    eventbus.TaskProgressToolResult,
+		Tool:    info.Name,
+		Content: toolOutput.Response,
+	})
+}
+
+// publishModelOutput 推送模型输出与 Token 用量事件
+func (ec *EinoCallbacks) publishModelOutput(ctx context.Context, output callbacks.CallbackOutput, info *callbacks.RunInfo) {
+	modelOutput := model.ConvCallbackOutput(output)
+	if modelOutput == nil {
+		return
+	}
+	if modelOutput.Message != nil && modelOutput.Message.Content != "" {
+		publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+			Type:    eventbus.TaskProgressModelOutput,
+			Agent:   info.Name,
+			Content: modelOutput.Message.Content,
+		})
+	}
+	if modelOutput.TokenUsage != nil {
+		publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+			Type:             eventbus.TaskProgressTokenUsage,
+			Agent:            info.Name,
+			PromptTokens:     modelOutput.TokenUsage.PromptTokens,
+			CompletionTokens: modelOutput.TokenUsage.CompletionTokens,
+			TotalTokens:      modelOutput.TokenUsage.TotalTokens,
+		})
+	}
+}
+
+// publishModelStream 读取模型流式输出副本，逐片推送进度事件
+func (ec *EinoCallbacks) publishModelStream(ctx context.Context, output *schema.StreamReader[callbacks.CallbackOutput], info *callbacks.RunInfo) {
+	defer output.Close()
+	var usage *model.TokenUsage
+	for {
+		chunk, err := output.Recv()
+		if err != nil {
+			break
+		}
+		modelOutput := model.ConvCallbackOutput(chunk)
+		if modelOutput == nil {
+			continue
+		}
+		if modelOutput.TokenUsage != nil {
+			usage = modelOutput.TokenUsage
+		}
+		if modelOutput.Message != nil && modelOutput.Message.Content != "" {
+			publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+				Type:    eventbus.TaskProgressModelOutput,
+				Agent:   info.Name,
+				Content: modelOutput.Message.Content,
+				Partial: true,
+			})
+		}
+	}
+	if usage != nil {
+		publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+			Type:             eventbus.TaskProgressTokenUsage,
+			Agent:            info.Name,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		})
+	}
+}
+
+// ========== 执行轨迹记录 ==========
+
+// traceToolInput 将工具调用的开始时间与参数放入上下文，供结束回调记录轨迹
+func (ec *EinoCallbacks) traceToolInput(ctx context.Context, input callbacks.CallbackInput) context.Context {
+	var arguments string
+	if toolInput := tool.ConvCallbackInput(input); toolInput != nil {
+		arguments = toolInput.ArgumentsInJSON
+	}
+	return withToolTraceStart(ctx, arguments)
+}
+
+// traceToolOutput 记录工具调用轨迹
+func (ec *EinoCallbacks) traceToolOutput(ctx context.Context, output callbacks.CallbackOutput, info *callbacks.RunInfo) {
+	var resultSize int
+	if toolOutput := tool.ConvCallbackOutput(output); toolOutput != nil {
+		resultSize = len(toolOutput.Response)
+	}
+	recordToolTrace(ctx, info.Name, resultSize, nil)
+}
+
 // ========== 通用回调详情 ==========
 
 // logGenericInput 记录通用输入详情
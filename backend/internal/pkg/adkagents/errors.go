@@ -44,6 +44,9 @@ var (
 
 	// ErrRateLimitExceeded 速率限制超出
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+	// ErrReplayFixtureNotFound 回放模式下请求没有对应的夹具
+	ErrReplayFixtureNotFound = errors.New("replay fixture not found")
 )
 
 // ModelUnavailableDetail 模型不可用详细信息
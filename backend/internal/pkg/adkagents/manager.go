@@ -12,6 +12,8 @@ import (
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents/tools"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"k8s.io/klog/v2"
 )
@@ -31,6 +33,10 @@ type Manager struct {
 
 	// 增强的模型提供者（支持多模型和自动切换）
 	enhancedModelProvider *EnhancedModelProviderImpl
+
+	// 工作空间 agent 目录的注册表，按目录缓存
+	overlays  map[string]*overlayRegistry
+	overlayMu sync.Mutex
 }
 
 var (
@@ -38,6 +44,10 @@ var (
 	managerInstanceOnce sync.Once
 	defaultDocRepoMu    sync.RWMutex
 	defaultDocRepo      repository.DocumentRepository
+	defaultSearcherMu   sync.RWMutex
+	defaultSearcher     embedding.Searcher
+	defaultAuditorMu    sync.RWMutex
+	defaultAuditor      tools.CommandAuditor
 )
 
 // GetOrCreateInstance 获取或创建 Manager 单例
@@ -87,6 +97,34 @@ func getDefaultDocRepo() repository.DocumentRepository {
 	return defaultDocRepo
 }
 
+// SetSemanticSearcher 设置语义检索服务，用于 semantic_search 工具
+func SetSemanticSearcher(searcher embedding.Searcher) {
+	defaultSearcherMu.Lock()
+	defaultSearcher = searcher
+	defaultSearcherMu.Unlock()
+}
+
+// SetCommandAuditor 设置命令审计，run_terminal_command 执行的每条命令都会记录
+func SetCommandAuditor(auditor tools.CommandAuditor) {
+	defaultAuditorMu.Lock()
+	defaultAuditor = auditor
+	defaultAuditorMu.Unlock()
+}
+
+// getCommandAuditor 获取命令审计
+func getCommandAuditor() tools.CommandAuditor {
+	defaultAuditorMu.RLock()
+	defer defaultAuditorMu.RUnlock()
+	return defaultAuditor
+}
+
+// getSemanticSearcher 获取语义检索服务
+func getSemanticSearcher() embedding.Searcher {
+	defaultSearcherMu.RLock()
+	defer defaultSearcherMu.RUnlock()
+	return defaultSearcher
+}
+
 // newManagerInternal 创建 Manager 实例（内部构造）
 func newManagerInternal(cfg *config.Config) (*Manager, error) {
 
@@ -102,6 +140,7 @@ func newManagerInternal(cfg *config.Config) (*Manager, error) {
 		parser:   parser,
 		loader:   loader,
 		docRepo:  getDefaultDocRepo(),
+		overlays: make(map[string]*overlayRegistry),
 	}
 
 	// 初始加载
@@ -202,7 +241,7 @@ func (m *Manager) GetAgent(name string) (adk.Agent, error) {
 	}
 
 	// 创建 ADK Agent
-	agent, err := m.createADKAgent(def)
+	agent, err := m.createADKAgent(def, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ADK agent: %w", err)
 	}
@@ -226,7 +265,7 @@ func (m *Manager) CreateAgent(name string) (adk.Agent, error) {
 	}
 
 	// 创建 ADK Agent（不使用缓存）
-	agent, err := m.createADKAgent(def)
+	agent, err := m.createADKAgent(def, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ADK agent: %w", err)
 	}
@@ -235,7 +274,8 @@ func (m *Manager) CreateAgent(name string) (adk.Agent, error) {
 }
 
 // createADKAgent 根据 AgentDefinition 创建 ADK Agent
-func (m *Manager) createADKAgent(def *AgentDefinition) (adk.Agent, error) {
+// workspaceSkillDir 为工作空间技能目录，为空时只使用全局技能目录
+func (m *Manager) createADKAgent(def *AgentDefinition, workspaceSkillDir string) (adk.Agent, error) {
 	ctx := context.Background()
 
 	// 获取模型（支持模型池）
@@ -248,20 +288,9 @@ func (m *Manager) createADKAgent(def *AgentDefinition) (adk.Agent, error) {
 		klog.V(6).Infof("[Manager] Using proxy model pool for agent %s: %v", def.Name, modelNames)
 		chatModel = NewProxyChatModel(m.enhancedModelProvider, modelNames)
 	} else if def.Model != "" && m.enhancedModelProvider != nil {
-		// 使用单个模型（通过 EnhancedModelProvider）
+		// 使用单个模型，同样通过代理在每次调用时按 context 解析 API Key，保证工作空间隔离
 		klog.V(6).Infof("[Manager] Using model %s for agent %s", def.Model, def.Name)
-		cm, err := m.enhancedModelProvider.GetModel(def.Model)
-		if err != nil {
-			klog.Errorf("[Manager] Failed to get model %s: %v", def.Model, err)
-			return nil, fmt.Errorf("failed to get model %s: %w", def.Model, err)
-		}
-		// 类型断言：将 ChatModel 转换为 ToolCallingChatModel
-		var ok bool
-		chatModel, ok = cm.(model.ToolCallingChatModel)
-		if !ok {
-			klog.Errorf("[Manager] Model %s does not implement ToolCallingChatModel", def.Model)
-			return nil, fmt.Errorf("model %s does not implement ToolCallingChatModel", def.Model)
-		}
+		chatModel = NewProxyChatModel(m.enhancedModelProvider, []string{def.Model})
 	} else if m.enhancedModelProvider != nil {
 		// 模型未指定，使用动态代理模型（自动从数据库选择）
 		klog.V(6).Infof("[Manager] Model not specified for agent %s, using dynamic ProxyChatModel", def.Name)
@@ -277,6 +306,14 @@ func (m *Manager) createADKAgent(def *AgentDefinition) (adk.Agent, error) {
 		BasePath: m.cfg.Data.RepoDir,
 		SkillDir: m.cfg.Skill.Dir,
 		DocRepo:  m.docRepo,
+		Searcher: getSemanticSearcher(),
+
+		WorkspaceSkillDir: workspaceSkillDir,
+
+		AgentName:      def.Name,
+		CommandPolicy:  tools.CommandPolicy{Allow: def.Sandbox.Allow, Deny: def.Sandbox.Deny},
+		Sandbox:        m.cfg.Sandbox,
+		CommandAuditor: getCommandAuditor(),
 	}
 	tools := make([]tool.BaseTool, 0, len(def.Tools))
 	for _, toolName := range def.Tools {
@@ -2,6 +2,8 @@ package adkagents
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/eino-ext/components/model/claude"
@@ -143,6 +145,8 @@ func (p *EnhancedModelProviderImpl) createChatModel(apiKey *model.APIKey) (*Mode
 	switch apiKey.Provider {
 	case "anthropic":
 		return p.createClaudeChatModel(apiKey)
+	case ProviderReplay:
+		return p.createReplayChatModel(apiKey)
 	default:
 		return p.createOpenAIChatModel(apiKey)
 	}
@@ -193,6 +197,42 @@ func (p *EnhancedModelProviderImpl) createClaudeChatModel(apiKey *model.APIKey)
 	}, nil
 }
 
+// createReplayChatModel 创建录制/回放模型，BaseURL 为夹具目录；
+// Model 为 "record:<API Key 名称>" 时通过该 API Key 对应的真实模型录制
+func (p *EnhancedModelProviderImpl) createReplayChatModel(apiKey *model.APIKey) (*ModelWithMetadata, error) {
+	var upstream einoModel.ChatModel
+	if name, ok := strings.CutPrefix(apiKey.Model, replayRecordPrefix); ok {
+		upstreamKey, err := p.apiKeyRepo.GetByName(context.Background(), name)
+		if err != nil {
+			return nil, fmt.Errorf("replay upstream %s: %w", name, ErrAPIKeyNotFound)
+		}
+		if upstreamKey.Provider == ProviderReplay {
+			return nil, fmt.Errorf("replay upstream %s cannot be a replay model", name)
+		}
+		upstreamModel, err := p.createChatModel(upstreamKey)
+		if err != nil {
+			return nil, err
+		}
+		upstream = upstreamModel.ChatModel
+	}
+
+	chatModel, err := NewReplayChatModel(apiKey.BaseURL, upstream)
+	if err != nil {
+		return nil, err
+	}
+	if p.config != nil && p.config.Data.RepoDir != "" {
+		// 仓库检出目录名带时间戳，按数据目录下的一级子目录整体替换，录制与回放时的请求才能匹配
+		chatModel.PathRoots = map[string]string{p.config.Data.RepoDir: replayRepoPlaceholder}
+	}
+
+	return &ModelWithMetadata{
+		ChatModel:  chatModel,
+		APIKeyName: apiKey.Name,
+		APIKeyID:   apiKey.ID,
+		LLMModel:   apiKey.Model,
+	}, nil
+}
+
 // MarkModelUnavailable 标记模型为不可用
 func (p *EnhancedModelProviderImpl) MarkModelUnavailable(ctx context.Context, modelName string, resetTime time.Time) error {
 	// 获取 API Key 配置
@@ -39,21 +39,27 @@ func (p *Parser) Parse(configPath string) (*AgentDefinition, error) {
 		return nil, fmt.Errorf("failed to read agent config: %w", err)
 	}
 
-	// 解析 YAML
-	agent := &AgentDefinition{}
-	if err := yaml.Unmarshal(content, agent); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	agent, err := p.ParseContent(content)
+	if err != nil {
+		return nil, err
 	}
 
 	// 设置路径信息
 	agent.Path = configPath
+	return agent, nil
+}
+
+// ParseContent 解析并校验 YAML 内容，例如数据库中保存的历史版本
+func (p *Parser) ParseContent(content []byte) (*AgentDefinition, error) {
+	agent := &AgentDefinition{}
+	if err := yaml.Unmarshal(content, agent); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
 	agent.LoadedAt = Now()
 
-	// 校验
 	if err := p.Validate(agent); err != nil {
 		return nil, err
 	}
-
 	return agent, nil
 }
 
@@ -94,6 +100,13 @@ func (p *Parser) Validate(agent *AgentDefinition) error {
 		return fmt.Errorf("%w: maxIterations cannot exceed 1000", ErrInvalidConfig)
 	}
 
+	// 校验命令策略中的程序名
+	for _, name := range append(append([]string{}, agent.Sandbox.Allow...), agent.Sandbox.Deny...) {
+		if name == "" || strings.ContainsAny(name, " \t/") {
+			return fmt.Errorf("%w: sandbox command %q must be a program name", ErrInvalidConfig, name)
+		}
+	}
+
 	return nil
 }
 
@@ -126,6 +126,17 @@ func TestParser_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "sandbox command with path",
+			agent: &AgentDefinition{
+				Name:          "SandboxAgent",
+				Description:   "An agent with an invalid sandbox allow list",
+				Instruction:   "Do something.",
+				MaxIterations: 10,
+				Sandbox:       SandboxConfig{Allow: []string{"ls", "/bin/cat"}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,51 @@
+package adkagents
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+)
+
+// 进度事件中单条内容的最大长度，避免超长工具结果塞满推送通道
+const maxProgressContentLength = 4096
+
+var taskProgressBus atomic.Pointer[eventbus.TaskProgressBus]
+
+// SetTaskProgressBus 设置任务进度事件总线
+// 设置后，带有 taskID 上下文的 Agent 运行、模型调用与工具调用都会推送进度事件
+func SetTaskProgressBus(bus *eventbus.TaskProgressBus) {
+	taskProgressBus.Store(bus)
+}
+
+// publishTaskProgress 推送任务进度事件；上下文中没有 taskID 或未设置总线时忽略
+func publishTaskProgress(ctx context.Context, event eventbus.TaskProgressEvent) {
+	bus := taskProgressBus.Load()
+	if bus == nil || ctx == nil {
+		return
+	}
+	taskID, ok := ctx.Value("taskID").(uint)
+	if !ok || taskID == 0 {
+		return
+	}
+	event.TaskID = taskID
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.Content = truncateProgressContent(event.Content)
+	event.Arguments = truncateProgressContent(event.Arguments)
+	_ = bus.Publish(ctx, taskID, event)
+}
+
+func truncateProgressContent(content string) string {
+	if len(content) <= maxProgressContentLength {
+		return content
+	}
+	cut := maxProgressContentLength
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + "...(truncated)"
+}
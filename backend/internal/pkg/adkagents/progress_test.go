@@ -0,0 +1,45 @@
+package adkagents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+)
+
+func TestPublishTaskProgress(t *testing.T) {
+	bus := eventbus.NewTaskProgressBus()
+	SetTaskProgressBus(bus)
+	defer SetTaskProgressBus(nil)
+
+	var received []eventbus.TaskProgressEvent
+	unsubscribe := bus.Subscribe(7, func(ctx context.Context, event eventbus.TaskProgressEvent) error {
+		received = append(received, event)
+		return nil
+	})
+	defer unsubscribe()
+
+	// 没有 taskID 的上下文不推送
+	publishTaskProgress(context.Background(), eventbus.TaskProgressEvent{Type: eventbus.TaskProgressToolInvoked})
+	if len(received) != 0 {
+		t.Fatalf("expected no events without taskID, got %d", len(received))
+	}
+
+	ctx := context.WithValue(context.Background(), "taskID", uint(7))
+	publishTaskProgress(ctx, eventbus.TaskProgressEvent{
+		Type:    eventbus.TaskProgressToolResult,
+		Tool:    "read_file",
+		Content: strings.Repeat("文", maxProgressContentLength),
+	})
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	event := received[0]
+	if event.TaskID != 7 || event.Tool != "read_file" || event.Timestamp.IsZero() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if len(event.Content) > maxProgressContentLength+len("...(truncated)") || !strings.HasSuffix(event.Content, "...(truncated)") {
+		t.Fatalf("expected truncated content, got length %d", len(event.Content))
+	}
+}
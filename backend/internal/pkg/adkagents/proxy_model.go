@@ -133,7 +133,9 @@ func (p *ProxyChatModel) executeWithRetry(
 		p.toolBinder.BindToModel(&model.ChatModel)
 
 		// 4. 执行请求
+		startedAt := time.Now()
 		result, err := executor(model)
+		recordModelTrace(ctx, model, startedAt, result, err)
 		if err == nil {
 			// 成功，记录用量和请求
 			if msg, ok := result.(*schema.Message); ok && msg != nil && msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
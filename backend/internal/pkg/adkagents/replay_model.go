@@ -0,0 +1,392 @@
+package adkagents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	einoModel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ProviderReplay 录制/回放模型的 provider 类型。
+	// API Key 配置中 BaseURL 为夹具目录；Model 为空或 "replay" 时回放，
+	// 为 "record:<API Key 名称>" 时通过指定的真实模型请求并录制
+	ProviderReplay = "replay"
+
+	replayRecordPrefix = "record:"
+	// replayKeyLen 夹具文件名使用的请求摘要长度（十六进制字符）
+	replayKeyLen = 24
+	// replayPathSegment PathRoots 下一级子目录的匹配规则，目录名止于路径分隔符、空白、引号、问号或括号等标点
+	replayPathSegment = "/[^/\\s\"'`?!()\\[\\]<>,;:\\\\]+"
+	// replayRepoPlaceholder 仓库检出目录在夹具中的占位符
+	replayRepoPlaceholder = "$REPO"
+)
+
+// ReplayFixture 一次模型调用的夹具，request 为参与计算摘要的规范化请求，便于审阅与比对
+type ReplayFixture struct {
+	Key      string          `json:"key"`
+	Model    string          `json:"model,omitempty"`
+	Request  replayRequest   `json:"request"`
+	Response *schema.Message `json:"response"`
+}
+
+type replayRequest struct {
+	Messages []replayMessage `json:"messages"`
+	Tools    []string        `json:"tools,omitempty"`
+}
+
+// replayMessage 只保留决定模型输出的字段，忽略 ResponseMeta、推理内容等每次调用都会变化的信息
+type replayMessage struct {
+	Role         schema.RoleType          `json:"role"`
+	Content      string                   `json:"content,omitempty"`
+	MultiContent []schema.ChatMessagePart `json:"multi_content,omitempty"`
+	Name         string                   `json:"name,omitempty"`
+	ToolCalls    []replayToolCall         `json:"tool_calls,omitempty"`
+	ToolCallID   string                   `json:"tool_call_id,omitempty"`
+	ToolName     string                   `json:"tool_name,omitempty"`
+}
+
+type replayToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ReplayChatModel 录制/回放模型，用于在没有网络的环境中确定性地测试 Agent 流程。
+// 请求按规范化后的消息与工具列表计算摘要，每个摘要对应夹具目录下的一个 JSON 文件：
+// 录制模式（upstream 非空）转发给真实模型并写入夹具，回放模式直接返回夹具中的响应
+type ReplayChatModel struct {
+	dir      string
+	upstream einoModel.ChatModel
+
+	// Placeholders 计算摘要前将请求中的文本替换为占位符，例如把临时仓库目录替换为 "$REPO"，
+	// 使录制与回放时路径不同的请求得到相同的摘要
+	Placeholders map[string]string
+	// PathRoots 计算摘要前将这些目录下的一级子目录整体替换为占位符，例如数据目录下带时间戳的检出目录
+	// "data/repos/demo-1700000000" 替换为 "$REPO"。录制的响应同样写入占位符，回放时还原为本次请求中的实际路径
+	PathRoots map[string]string
+
+	mu    sync.RWMutex
+	tools []*schema.ToolInfo
+}
+
+// NewReplayChatModel 创建录制/回放模型，upstream 为空时为回放模式
+func NewReplayChatModel(dir string, upstream einoModel.ChatModel) (*ReplayChatModel, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("replay fixture directory is required")
+	}
+	if upstream != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create replay fixture directory: %w", err)
+		}
+	}
+	return &ReplayChatModel{dir: dir, upstream: upstream}, nil
+}
+
+// Recording 是否为录制模式
+func (m *ReplayChatModel) Recording() bool {
+	return m.upstream != nil
+}
+
+// Generate 实现 model.ChatModel 接口
+func (m *ReplayChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.Message, error) {
+	fixture, norm := m.fixture(input, opts)
+	if !m.Recording() {
+		ctx = m.onStart(ctx, input)
+		msg, err := m.load(fixture.Key)
+		if err != nil {
+			callbacks.OnError(ctx, err)
+			return nil, err
+		}
+		msg = norm.rewrite(msg, norm.restore)
+		callbacks.OnEnd(ctx, &einoModel.CallbackOutput{Message: msg})
+		return msg, nil
+	}
+
+	m.bindUpstream()
+	msg, err := m.upstream.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	fixture.Response = norm.rewrite(msg, norm.normalize)
+	if err := m.save(fixture); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Stream 实现 model.ChatModel 接口，回放时以单个分片返回完整响应
+func (m *ReplayChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.StreamReader[*schema.Message], error) {
+	fixture, norm := m.fixture(input, opts)
+	if !m.Recording() {
+		ctx = m.onStart(ctx, input)
+		msg, err := m.load(fixture.Key)
+		if err != nil {
+			callbacks.OnError(ctx, err)
+			return nil, err
+		}
+		out := schema.StreamReaderFromArray([]*einoModel.CallbackOutput{{Message: norm.rewrite(msg, norm.restore)}})
+		_, stream := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderWithConvert(out,
+			func(src *einoModel.CallbackOutput) (callbacks.CallbackOutput, error) {
+				return src, nil
+			}))
+		return schema.StreamReaderWithConvert(stream, func(src callbacks.CallbackOutput) (*schema.Message, error) {
+			return src.(*einoModel.CallbackOutput).Message, nil
+		}), nil
+	}
+
+	m.bindUpstream()
+	stream, err := m.upstream.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	var chunks []*schema.Message
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("concat stream response: %w", err)
+	}
+	fixture.Response = norm.rewrite(msg, norm.normalize)
+	if err := m.save(fixture); err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray(chunks), nil
+}
+
+// onStart 回放时像真实模型组件一样触发模型回调：Agent 依据模型回调生成事件并写入后续 Agent 的上下文，
+// 录制时这些回调由 upstream 触发，回放时不补齐会导致后续请求与录制时不同
+func (m *ReplayChatModel) onStart(ctx context.Context, input []*schema.Message) context.Context {
+	ctx = callbacks.EnsureRunInfo(ctx, m.GetType(), components.ComponentOfChatModel)
+	return callbacks.OnStart(ctx, &einoModel.CallbackInput{Messages: input})
+}
+
+// GetType 组件类型名，用于回调中的 RunInfo
+func (m *ReplayChatModel) GetType() string {
+	return "Replay"
+}
+
+// BindTools 实现 model.ChatModel 接口，绑定的工具参与请求摘要
+func (m *ReplayChatModel) BindTools(tools []*schema.ToolInfo) error {
+	m.mu.Lock()
+	m.tools = tools
+	m.mu.Unlock()
+	return nil
+}
+
+// WithTools 适配 model.ToolCallingChatModel 接口
+func (m *ReplayChatModel) WithTools(tools []*schema.ToolInfo) (einoModel.ToolCallingChatModel, error) {
+	clone := &ReplayChatModel{dir: m.dir, upstream: m.upstream, Placeholders: m.Placeholders, PathRoots: m.PathRoots, tools: tools}
+	return clone, nil
+}
+
+// bindUpstream 录制时将工具同步绑定到真实模型
+func (m *ReplayChatModel) bindUpstream() {
+	m.mu.RLock()
+	tools := m.tools
+	m.mu.RUnlock()
+	if binder, ok := m.upstream.(interface {
+		BindTools(tools []*schema.ToolInfo) error
+	}); ok && len(tools) > 0 {
+		if err := binder.BindTools(tools); err != nil {
+			klog.Warningf("ReplayChatModel: bind tools to upstream failed: %v", err)
+		}
+	}
+}
+
+// fixture 规范化请求并计算摘要，返回的 normalizer 记录了本次请求中占位符对应的实际文本
+func (m *ReplayChatModel) fixture(input []*schema.Message, opts []einoModel.Option) (*ReplayFixture, *replayNormalizer) {
+	m.mu.RLock()
+	tools := m.tools
+	m.mu.RUnlock()
+	if options := einoModel.GetCommonOptions(nil, opts...); len(options.Tools) > 0 {
+		tools = options.Tools
+	}
+
+	norm := m.normalizer()
+	req := replayRequest{Messages: make([]replayMessage, 0, len(input))}
+	for _, msg := range input {
+		if msg == nil {
+			continue
+		}
+		rm := replayMessage{
+			Role:         msg.Role,
+			Content:      norm.normalize(msg.Content),
+			MultiContent: msg.MultiContent,
+			Name:         msg.Name,
+			ToolCallID:   msg.ToolCallID,
+			ToolName:     msg.ToolName,
+		}
+		for _, call := range msg.ToolCalls {
+			rm.ToolCalls = append(rm.ToolCalls, replayToolCall{ID: call.ID, Name: call.Function.Name, Arguments: norm.normalize(call.Function.Arguments)})
+		}
+		req.Messages = append(req.Messages, rm)
+	}
+	for _, tool := range tools {
+		if tool != nil {
+			req.Tools = append(req.Tools, tool.Name)
+		}
+	}
+	sort.Strings(req.Tools)
+
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return &ReplayFixture{Key: hex.EncodeToString(sum[:])[:replayKeyLen], Request: req}, norm
+}
+
+// replayNormalizer 单次请求的文本规范化
+type replayNormalizer struct {
+	replacements []replayReplacement
+	// bindings 占位符 -> 本次请求中被替换的实际文本（取首次出现）
+	bindings map[string]string
+}
+
+type replayReplacement struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}
+
+// normalizer 按 PathRoots、Placeholders 构造规范化规则，各自按长度降序，保证结果与 map 遍历顺序无关
+func (m *ReplayChatModel) normalizer() *replayNormalizer {
+	norm := &replayNormalizer{bindings: make(map[string]string)}
+	for _, root := range sortedKeys(m.PathRoots) {
+		pattern := regexp.QuoteMeta(filepath.Clean(root)) + replayPathSegment
+		norm.replacements = append(norm.replacements, replayReplacement{regexp.MustCompile(pattern), m.PathRoots[root]})
+	}
+	for _, value := range sortedKeys(m.Placeholders) {
+		norm.replacements = append(norm.replacements, replayReplacement{regexp.MustCompile(regexp.QuoteMeta(value)), m.Placeholders[value]})
+	}
+	return norm
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// normalize 将文本中的实际值替换为占位符
+func (n *replayNormalizer) normalize(text string) string {
+	if text == "" {
+		return text
+	}
+	for _, r := range n.replacements {
+		text = r.pattern.ReplaceAllStringFunc(text, func(actual string) string {
+			if _, ok := n.bindings[r.placeholder]; !ok {
+				n.bindings[r.placeholder] = actual
+			}
+			return r.placeholder
+		})
+	}
+	return text
+}
+
+// restore 将文本中的占位符还原为本次请求中的实际值
+func (n *replayNormalizer) restore(text string) string {
+	if text == "" || len(n.bindings) == 0 {
+		return text
+	}
+	// 较长的占位符优先，避免 "$REPO" 误替换 "$REPO_DIR" 的前缀
+	for _, placeholder := range sortedKeys(n.bindings) {
+		text = strings.ReplaceAll(text, placeholder, n.bindings[placeholder])
+	}
+	return text
+}
+
+// rewrite 返回替换了内容与工具调用参数的响应副本，不修改原消息
+func (n *replayNormalizer) rewrite(msg *schema.Message, replace func(string) string) *schema.Message {
+	if msg == nil {
+		return nil
+	}
+	out := *msg
+	out.Content = replace(msg.Content)
+	if len(msg.ToolCalls) > 0 {
+		out.ToolCalls = make([]schema.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			call.Function.Arguments = replace(call.Function.Arguments)
+			out.ToolCalls[i] = call
+		}
+	}
+	return &out
+}
+
+func (m *ReplayChatModel) path(key string) string {
+	return filepath.Join(m.dir, key+".json")
+}
+
+func (m *ReplayChatModel) load(key string) (*schema.Message, error) {
+	data, err := os.ReadFile(m.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: key=%s, dir=%s", ErrReplayFixtureNotFound, key, m.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read replay fixture %s: %w", key, err)
+	}
+	var fixture ReplayFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse replay fixture %s: %w", key, err)
+	}
+	if fixture.Response == nil {
+		return nil, fmt.Errorf("replay fixture %s has no response", key)
+	}
+	klog.V(6).Infof("ReplayChatModel: replayed fixture %s", key)
+	return fixture.Response, nil
+}
+
+// save 先写临时文件再重命名，避免并发录制时读到不完整的夹具
+func (m *ReplayChatModel) save(fixture *ReplayFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal replay fixture: %w", err)
+	}
+	tmp, err := os.CreateTemp(m.dir, fixture.Key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write replay fixture: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write replay fixture: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), m.path(fixture.Key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write replay fixture: %w", err)
+	}
+	klog.V(6).Infof("ReplayChatModel: recorded fixture %s", fixture.Key)
+	return nil
+}
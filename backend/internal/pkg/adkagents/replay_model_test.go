@@ -0,0 +1,146 @@
+package adkagents
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/adk"
+	einoModel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents/tools"
+)
+
+// scriptedModel 按调用顺序返回预设响应，模拟需要网络的真实模型
+type scriptedModel struct {
+	responses []*schema.Message
+	calls     int
+}
+
+func (m *scriptedModel) Generate(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.Message, error) {
+	if m.calls >= len(m.responses) {
+		return nil, errors.New("unexpected model call")
+	}
+	m.calls++
+	return m.responses[m.calls-1], nil
+}
+
+func (m *scriptedModel) Stream(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *scriptedModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+// runListDirAgent 在 repoDir 上运行一个带 list_dir 工具的 Agent，返回最终输出
+func runListDirAgent(t *testing.T, chatModel einoModel.ToolCallingChatModel, repoDir string) (string, error) {
+	t.Helper()
+	ctx := context.Background()
+	agent, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        "lister",
+		Description: "lists files",
+		Instruction: "List the repository root and summarize it.",
+		Model:       chatModel,
+		ToolsConfig: adk.ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{
+			Tools: []tool.BaseTool{tools.NewListDirTool(repoDir)},
+		}},
+		MaxIterations: 5,
+	})
+	if err != nil {
+		t.Fatalf("create agent error: %v", err)
+	}
+	return RunAgentToLastContent(ctx, agent, []adk.Message{schema.UserMessage("What is in " + repoDir + "?")})
+}
+
+func newRepoDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReplayChatModelRecordAndReplay(t *testing.T) {
+	fixtures := t.TempDir()
+	upstream := &scriptedModel{responses: []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "list_dir", Arguments: `{"dir":"."}`}}}),
+		schema.AssistantMessage("The repository contains main.go.", nil),
+	}}
+
+	recordDir := newRepoDir(t)
+	recorder, err := NewReplayChatModel(fixtures, upstream)
+	if err != nil {
+		t.Fatalf("NewReplayChatModel error: %v", err)
+	}
+	recorder.Placeholders = map[string]string{recordDir: "$REPO"}
+	recorded, err := runListDirAgent(t, recorder, recordDir)
+	if err != nil || recorded != "The repository contains main.go." {
+		t.Fatalf("record run = %q, %v", recorded, err)
+	}
+	entries, _ := os.ReadDir(fixtures)
+	if upstream.calls != 2 || len(entries) != 2 {
+		t.Fatalf("expected 2 upstream calls and 2 fixtures, got %d calls, %d fixtures", upstream.calls, len(entries))
+	}
+
+	// 回放时仓库位于另一个临时目录，占位符保证请求摘要不变，且不再访问真实模型
+	replayDir := newRepoDir(t)
+	replayer, _ := NewReplayChatModel(fixtures, nil)
+	replayer.Placeholders = map[string]string{replayDir: "$REPO"}
+	replayed, err := runListDirAgent(t, replayer, replayDir)
+	if err != nil || replayed != recorded {
+		t.Fatalf("replay run = %q, %v", replayed, err)
+	}
+	if upstream.calls != 2 {
+		t.Fatalf("replay must not call upstream, calls=%d", upstream.calls)
+	}
+
+	// 工具输出变化后请求不再匹配任何夹具
+	os.WriteFile(filepath.Join(replayDir, "extra.go"), []byte("package main\n"), 0644)
+	_, err = runListDirAgent(t, replayer, replayDir)
+	if !errors.Is(err, ErrReplayFixtureNotFound) {
+		t.Fatalf("expected fixture miss, got %v", err)
+	}
+}
+
+// TestReplayChatModelPathRoots 检出目录名每次不同，按数据目录整体替换后仍能回放，响应中的路径还原为本次目录
+func TestReplayChatModelPathRoots(t *testing.T) {
+	fixtures := t.TempDir()
+	dataDir := t.TempDir()
+	newCheckout := func(name string) string {
+		dir := filepath.Join(dataDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	recordDir := newCheckout("demo-1700000000")
+	upstream := &scriptedModel{responses: []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "list_dir", Arguments: `{"dir":"` + recordDir + `"}`}}}),
+		schema.AssistantMessage("Files under "+recordDir+": main.go", nil),
+	}}
+	recorder, _ := NewReplayChatModel(fixtures, upstream)
+	recorder.PathRoots = map[string]string{dataDir: replayRepoPlaceholder}
+	if _, err := runListDirAgent(t, recorder, recordDir); err != nil {
+		t.Fatalf("record run error: %v", err)
+	}
+
+	replayDir := newCheckout("demo-1800000000@v1.0")
+	replayer, _ := NewReplayChatModel(fixtures, nil)
+	replayer.PathRoots = map[string]string{dataDir: replayRepoPlaceholder}
+	replayed, err := runListDirAgent(t, replayer, replayDir)
+	if err != nil || replayed != "Files under "+replayDir+": main.go" {
+		t.Fatalf("replay run = %q, %v", replayed, err)
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/sandbox"
+	"k8s.io/klog/v2"
 )
 
 // RunTerminalCommandTool allows agents to execute terminal commands.
@@ -21,8 +25,35 @@ type RunTerminalCommandTool struct {
 	basePath string
 	// Timeout is the maximum duration for command execution
 	Timeout time.Duration
-	// AllowedCommands is a list of allowed command prefixes (empty = allow all)
-	AllowedCommands []string
+	// Policy restricts which programs the command may run
+	Policy CommandPolicy
+	// Sandbox runs commands in an isolated, read-only environment; nil runs them directly
+	Sandbox *sandbox.Options
+	// MaxOutputBytes caps stdout and stderr separately (0 = unlimited)
+	MaxOutputBytes int
+	// AgentName identifies the calling agent in audit records
+	AgentName string
+	// Auditor records every command; nil disables auditing
+	Auditor CommandAuditor
+}
+
+// CommandRecord describes one run_terminal_command invocation for auditing.
+type CommandRecord struct {
+	Agent       string
+	Command     string
+	WorkingDir  string
+	Denied      string // policy violation, empty when the command ran
+	ExitCode    int
+	Duration    time.Duration
+	OutputBytes int
+	Truncated   bool
+	Sandboxed   bool
+	Error       string
+}
+
+// CommandAuditor receives a record of each command executed by agents.
+type CommandAuditor interface {
+	RecordCommand(ctx context.Context, record CommandRecord)
 }
 
 // RunTerminalCommandArgs defines the arguments for run_terminal_command tool.
@@ -48,12 +79,16 @@ func (t *RunTerminalCommandTool) Info(ctx context.Context) (*schema.ToolInfo, er
 		Name: "run_terminal_command",
 		Desc: `Execute a terminal/shell command and return the output.
 Use this tool to:
-- Run git commands (git status, git diff, git commit,tree --charset utf-8 etc.)
+- Run read-only git commands (git status, git diff, git log, tree --charset utf-8 etc.)
 - Execute build commands
 - Run scripts (python3 <script>,uv run <script>)
 - Check file contents with cat, ls, etc.
 
-Returns stdout and stderr from the command execution.`,
+Commands run in a sandbox: the repository is read-only, network access is disabled,
+and only $TMPDIR is writable. Programs that modify files or access the network
+(rm, mv, curl, git commit, ...) and redirects to files are rejected.
+
+Returns stdout and stderr from the command execution (large output is truncated).`,
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"command": {
 				Type:     schema.String,
@@ -80,20 +115,6 @@ func (t *RunTerminalCommandTool) InvokableRun(ctx context.Context, argumentsInJS
 		return "", fmt.Errorf("command is required")
 	}
 
-	// Check allowed commands if configured
-	if len(t.AllowedCommands) > 0 {
-		allowed := false
-		for _, prefix := range t.AllowedCommands {
-			if strings.HasPrefix(args.Command, prefix) {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			return "", fmt.Errorf("command not allowed: %s", args.Command)
-		}
-	}
-
 	// Determine working directory
 	workingDir := t.WorkingDir
 	if args.WorkingDir != "" {
@@ -110,28 +131,66 @@ func (t *RunTerminalCommandTool) InvokableRun(ctx context.Context, argumentsInJS
 		return "", fmt.Errorf("security violation: command contains path traversal sequences")
 	}
 
+	record := CommandRecord{Agent: t.AgentName, Command: args.Command, WorkingDir: workingDir, Sandboxed: t.Sandbox != nil}
+	defer func() {
+		if t.Auditor != nil {
+			t.Auditor.RecordCommand(ctx, record)
+		}
+	}()
+
+	// Policy violations are returned to the model so it can choose another approach
+	if err := t.Policy.Check(args.Command); err != nil {
+		record.Denied = err.Error()
+		klog.V(6).Infof("[RunTerminalCommandTool] command denied: agent=%s, command=%s, reason=%v", t.AgentName, args.Command, err)
+		return fmt.Sprintf("Error: command denied: %v", err), nil
+	}
+
 	// Create command with timeout context
 	timeoutCtx, cancel := context.WithTimeout(ctx, t.Timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, "sh", "-c", args.Command)
-	if workingDir != "" {
-		cmd.Dir = workingDir
+	cmd, cleanup, err := t.command(timeoutCtx, workingDir, args.Command)
+	if err != nil {
+		record.Error = err.Error()
+		if errors.Is(err, sandbox.ErrUnavailable) {
+			return "", sandboxUnavailable(err)
+		}
+		return "", fmt.Errorf("failed to prepare command: %w", err)
 	}
+	defer cleanup()
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout := &limitedBuffer{limit: t.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: t.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	start := time.Now()
+	err = cmd.Run()
+	record.Duration = time.Since(start)
+	record.OutputBytes = stdout.total + stderr.total
+	record.Truncated = stdout.truncated() || stderr.truncated()
+	if cmd.ProcessState != nil {
+		record.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		record.Error = err.Error()
+		var exitErr *exec.ExitError
+		if t.Sandbox != nil && !errors.As(err, &exitErr) && cmd.ProcessState == nil {
+			// The sandbox could not be created (e.g. user namespaces disabled); fail closed
+			return "", sandboxUnavailable(err)
+		}
+		if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("command timed out after %s", t.Timeout)
+		}
+	}
 
 	// Build result
 	var result strings.Builder
-	if stdout.Len() > 0 {
+	if stdout.buf.Len() > 0 {
 		result.WriteString("STDOUT:\n")
 		result.WriteString(stdout.String())
 	}
-	if stderr.Len() > 0 {
+	if stderr.buf.Len() > 0 {
 		if result.Len() > 0 {
 			result.WriteString("\n")
 		}
@@ -153,5 +212,73 @@ func (t *RunTerminalCommandTool) InvokableRun(ctx context.Context, argumentsInJS
 	return result.String(), nil
 }
 
+// command builds the shell command, inside the sandbox when configured.
+// Sandboxed commands get a private scratch directory as HOME and TMPDIR, the only
+// writable location, and do not inherit the server's environment (API keys etc.).
+func (t *RunTerminalCommandTool) command(ctx context.Context, workingDir, command string) (*exec.Cmd, func(), error) {
+	if t.Sandbox == nil {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		if workingDir != "" {
+			cmd.Dir = workingDir
+		}
+		return cmd, func() {}, nil
+	}
+
+	scratch, err := os.MkdirTemp("", "opendeepwiki-cmd-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(scratch) }
+
+	opts := *t.Sandbox
+	opts.Writable = append(append([]string{}, opts.Writable...), scratch)
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + scratch,
+		"TMPDIR=" + scratch,
+		"LANG=C.UTF-8",
+	}
+	cmd, err := sandbox.Command(ctx, opts, workingDir, command, env)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return cmd, cleanup, nil
+}
+
+// sandboxUnavailable tells operators how to relax isolation when the sandbox cannot run.
+func sandboxUnavailable(err error) error {
+	return fmt.Errorf("sandbox unavailable, set sandbox.weak_read_only=true (kernel without landlock) or sandbox.enabled=false to run commands with weaker isolation: %w", err)
+}
+
+// limitedBuffer keeps at most limit bytes while counting everything written.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if b.limit <= 0 {
+		return b.buf.Write(p)
+	}
+	if remain := b.limit - b.buf.Len(); remain > 0 {
+		b.buf.Write(p[:min(len(p), remain)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) truncated() bool {
+	return b.limit > 0 && b.total > b.limit
+}
+
+func (b *limitedBuffer) String() string {
+	if !b.truncated() {
+		return b.buf.String()
+	}
+	return b.buf.String() + fmt.Sprintf("\n... (output truncated, %d of %d bytes shown)", b.buf.Len(), b.total)
+}
+
 // Ensure RunTerminalCommandTool implements tool.InvokableTool
 var _ tool.InvokableTool = (*RunTerminalCommandTool)(nil)
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type recordingAuditor struct {
+	records []CommandRecord
+}
+
+func (a *recordingAuditor) RecordCommand(ctx context.Context, record CommandRecord) {
+	a.records = append(a.records, record)
+}
+
+func TestRunTerminalCommandPolicyAndAudit(t *testing.T) {
+	auditor := &recordingAuditor{}
+	cmd := NewRunTerminalCommandTool(t.TempDir())
+	cmd.AgentName = "document-generator"
+	cmd.Auditor = auditor
+	cmd.MaxOutputBytes = 16
+
+	result, err := cmd.InvokableRun(context.Background(), `{"command":"rm -rf ."}`)
+	if err != nil || !strings.HasPrefix(result, "Error: command denied") {
+		t.Fatalf("denied command = %q, %v", result, err)
+	}
+
+	result, err = cmd.InvokableRun(context.Background(), `{"command":"seq 1 100"}`)
+	if err != nil || !strings.Contains(result, "output truncated, 16 of 292 bytes shown") {
+		t.Fatalf("truncated output = %q, %v", result, err)
+	}
+
+	if len(auditor.records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(auditor.records))
+	}
+	if denied := auditor.records[0]; denied.Denied == "" || denied.Agent != "document-generator" {
+		t.Errorf("denied record = %+v", denied)
+	}
+	if ran := auditor.records[1]; ran.Denied != "" || ran.ExitCode != 0 || ran.OutputBytes != 292 || !ran.Truncated {
+		t.Errorf("executed record = %+v", ran)
+	}
+}
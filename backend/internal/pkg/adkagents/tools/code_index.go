@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultSymbolLimit    = 50
+	defaultReferenceLimit = 100
+	maxCodeIndexLimit     = 500
+)
+
+// codeIndexBase 符号索引工具的公共部分
+type codeIndexBase struct {
+	basePath string
+	store    *codeindex.Store
+}
+
+// resolveIndex 解析路径所在仓库的索引
+// basePath 下的第一级目录视为仓库根目录；返回索引与相对仓库根目录的路径前缀（用于限定子目录）
+func (b codeIndexBase) resolveIndex(path string) (*codeindex.Index, string, error) {
+	if path == "" {
+		return nil, "", fmt.Errorf("path is required")
+	}
+	absPath, err := ValidateAndResolvePath(b.basePath, path)
+	if err != nil {
+		return nil, "", err
+	}
+	absBase, err := filepath.Abs(b.basePath)
+	if err != nil {
+		return nil, "", err
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	root, prefix := absBase, ""
+	if rel != "." {
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		root = filepath.Join(absBase, parts[0])
+		if len(parts) == 2 {
+			prefix = parts[1]
+		}
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, "", fmt.Errorf("repository directory not found: %s", path)
+	}
+
+	idx, err := b.store.Get(root)
+	if err != nil {
+		return nil, "", err
+	}
+	return idx, prefix, nil
+}
+
+func inPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+func normalizeLimit(limit, defaultLimit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxCodeIndexLimit {
+		return maxCodeIndexLimit
+	}
+	return limit
+}
+
+func formatSymbol(symbol codeindex.Symbol) string {
+	location := fmt.Sprintf("%s:%d", symbol.Path, symbol.Line)
+	if symbol.EndLine > symbol.Line {
+		location = fmt.Sprintf("%s:%d-%d", symbol.Path, symbol.Line, symbol.EndLine)
+	}
+	name := symbol.Name
+	if symbol.Container != "" {
+		name = symbol.Container + "." + symbol.Name
+	}
+	return fmt.Sprintf("%s %s %s\n    %s", symbol.Kind, name, location, symbol.Signature)
+}
+
+// FindSymbolTool 符号定义查找工具
+// 实现 Eino 的 tool.BaseTool 接口，在仓库符号索引中按名称查找函数、类型等定义
+type FindSymbolTool struct {
+	codeIndexBase
+}
+
+// NewFindSymbolTool 创建符号定义查找工具
+// basePath: 操作的基础路径；store: 符号索引缓存
+func NewFindSymbolTool(basePath string, store *codeindex.Store) *FindSymbolTool {
+	klog.V(6).Infof("[FindSymbolTool] 创建工具实例: basePath=%s", basePath)
+	return &FindSymbolTool{codeIndexBase{basePath: basePath, store: store}}
+}
+
+// Info 返回工具信息
+func (t *FindSymbolTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "find_symbol",
+		Desc: "Find definitions of functions, methods, types, classes, constants and variables by name in a repository. Returns kind, location (file:line) and signature. Supports 'Type.Method' names.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {
+				Type:     schema.String,
+				Desc:     "Repository directory (or a sub directory to restrict results)",
+				Required: true,
+			},
+			"name": {
+				Type:     schema.String,
+				Desc:     "Symbol name or 'Type.Method'",
+				Required: true,
+			},
+			"kind": {
+				Type: schema.String,
+				Desc: "Optional kind filter: function, method, type, struct, interface, class, enum, module, const, var, field",
+			},
+			"exact": {
+				Type: schema.Boolean,
+				Desc: "Exact case-sensitive match; default is case-insensitive substring match",
+			},
+			"limit": {
+				Type: schema.Integer,
+				Desc: "Maximum number of results (default 50)",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *FindSymbolTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Path  string `json:"path"`
+		Name  string `json:"name"`
+		Kind  string `json:"kind"`
+		Exact bool   `json:"exact"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Name) == "" {
+		return "Error: name is required", nil
+	}
+
+	idx, prefix, err := t.resolveIndex(args.Path)
+	if err != nil {
+		klog.Errorf("[FindSymbolTool] 获取符号索引失败: %v", err)
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	limit := normalizeLimit(args.Limit, defaultSymbolLimit)
+	var lines []string
+	for _, symbol := range idx.FindSymbols(codeindex.SymbolQuery{Name: args.Name, Kind: args.Kind, Exact: args.Exact}) {
+		if !inPrefix(symbol.Path, prefix) {
+			continue
+		}
+		if len(lines) >= limit {
+			lines = append(lines, fmt.Sprintf("... (more results truncated, limit=%d)", limit))
+			break
+		}
+		lines = append(lines, formatSymbol(symbol))
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("No symbols found matching %q.", args.Name), nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// FindReferencesTool 符号引用查找工具
+// 实现 Eino 的 tool.BaseTool 接口，查找标识符在仓库源码中的使用位置
+type FindReferencesTool struct {
+	codeIndexBase
+}
+
+// NewFindReferencesTool 创建符号引用查找工具
+func NewFindReferencesTool(basePath string, store *codeindex.Store) *FindReferencesTool {
+	klog.V(6).Infof("[FindReferencesTool] 创建工具实例: basePath=%s", basePath)
+	return &FindReferencesTool{codeIndexBase{basePath: basePath, store: store}}
+}
+
+// Info 返回工具信息
+func (t *FindReferencesTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "find_references",
+		Desc: "Find where an identifier is used in a repository's source files (whole-word match; comments and strings are ignored for Go). Definitions are marked with [def].",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {
+				Type:     schema.String,
+				Desc:     "Repository directory (or a sub directory to restrict results)",
+				Required: true,
+			},
+			"name": {
+				Type:     schema.String,
+				Desc:     "Identifier to look up",
+				Required: true,
+			},
+			"limit": {
+				Type: schema.Integer,
+				Desc: "Maximum number of results (default 100)",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *FindReferencesTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Path  string `json:"path"`
+		Name  string `json:"name"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return "Error: name is required", nil
+	}
+	// Type.Method 形式只查找方法名
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	idx, prefix, err := t.resolveIndex(args.Path)
+	if err != nil {
+		klog.Errorf("[FindReferencesTool] 获取符号索引失败: %v", err)
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	limit := normalizeLimit(args.Limit, defaultReferenceLimit)
+	refs, _ := idx.FindReferences(name, 0)
+	var lines []string
+	for _, ref := range refs {
+		if !inPrefix(ref.Path, prefix) {
+			continue
+		}
+		if len(lines) >= limit {
+			lines = append(lines, fmt.Sprintf("... (more results truncated, limit=%d)", limit))
+			break
+		}
+		marker := ""
+		if ref.Definition {
+			marker = " [def]"
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d:%d%s: %s", ref.Path, ref.Line, ref.Column, marker, ref.Text))
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("No references found for %q.", name), nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// OutlineFileTool 文件大纲工具
+// 实现 Eino 的 tool.BaseTool 接口，列出单个源码文件中定义的符号
+type OutlineFileTool struct {
+	basePath string
+}
+
+// NewOutlineFileTool 创建文件大纲工具
+func NewOutlineFileTool(basePath string) *OutlineFileTool {
+	klog.V(6).Infof("[OutlineFileTool] 创建工具实例: basePath=%s", basePath)
+	return &OutlineFileTool{basePath: basePath}
+}
+
+// Info 返回工具信息
+func (t *OutlineFileTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "outline_file",
+		Desc: "List the symbols (types, functions, methods, fields, constants) defined in a source file with their line ranges and signatures, without reading the whole file.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {
+				Type:     schema.String,
+				Desc:     "Source file path",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *OutlineFileTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Path == "" {
+		return "Error: path is required", nil
+	}
+	absPath, err := ValidateAndResolvePath(t.basePath, args.Path)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	symbols, err := codeindex.ParseFile(absPath, filepath.Base(absPath))
+	if err != nil {
+		klog.Errorf("[OutlineFileTool] 解析文件失败: path=%s, error=%v", absPath, err)
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(symbols) == 0 {
+		return "No symbols found in file.", nil
+	}
+
+	lines := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		indent := ""
+		if symbol.Container != "" && symbol.Kind != codeindex.KindMethod {
+			indent = "  "
+		}
+		location := fmt.Sprintf("L%d", symbol.Line)
+		if symbol.EndLine > symbol.Line {
+			location = fmt.Sprintf("L%d-%d", symbol.Line, symbol.EndLine)
+		}
+		name := symbol.Name
+		if symbol.Container != "" {
+			name = symbol.Container + "." + symbol.Name
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %s: %s", indent, location, symbol.Kind, name, symbol.Signature))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
+)
+
+// TestCodeIndexTools 验证符号工具按 basePath 下的第一级目录定位仓库，并支持子目录过滤
+func TestCodeIndexTools(t *testing.T) {
+	base := t.TempDir()
+	files := map[string]string{
+		"repo-a/server/handler.go": "package server\n\ntype Handler struct {\n\tName string\n}\n\nfunc (h *Handler) Serve() {}\n",
+		"repo-a/client/client.go":  "package client\n\nfunc Serve() {}\n",
+		"repo-b/other.go":          "package other\n\nfunc Serve() {}\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(base, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	store := codeindex.NewStore(0)
+	ctx := context.Background()
+
+	result, _ := NewFindSymbolTool(base, store).InvokableRun(ctx, `{"path":"repo-a","name":"Serve","exact":true}`)
+	if !strings.Contains(result, "method Handler.Serve server/handler.go:7") || !strings.Contains(result, "client/client.go") || strings.Contains(result, "other.go") {
+		t.Fatalf("unexpected find_symbol result: %s", result)
+	}
+
+	result, _ = NewFindSymbolTool(base, store).InvokableRun(ctx, `{"path":"repo-a/client","name":"Serve"}`)
+	if strings.Contains(result, "handler.go") || !strings.Contains(result, "client/client.go") {
+		t.Fatalf("expected results restricted to sub directory: %s", result)
+	}
+
+	result, _ = NewFindReferencesTool(base, store).InvokableRun(ctx, `{"path":"repo-a","name":"Handler"}`)
+	if !strings.Contains(result, "server/handler.go:3:6 [def]") || !strings.Contains(result, "server/handler.go:7:") {
+		t.Fatalf("unexpected find_references result: %s", result)
+	}
+
+	result, _ = NewOutlineFileTool(base).InvokableRun(ctx, `{"path":"repo-a/server/handler.go"}`)
+	if !strings.Contains(result, "L3-5 struct Handler") || !strings.Contains(result, "  L4 field Handler.Name: Name string") {
+		t.Fatalf("unexpected outline_file result: %s", result)
+	}
+
+	result, _ = NewFindSymbolTool(base, store).InvokableRun(ctx, `{"path":"../","name":"Serve"}`)
+	if !strings.HasPrefix(result, "Error:") {
+		t.Fatalf("expected path escape error, got: %s", result)
+	}
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultDeniedCommands 始终禁止的程序：改写或删除文件、访问网络、提权与进程控制。
+// 沙箱已将文件系统设为只读并关闭网络，这里在执行前给出明确的拒绝原因
+var DefaultDeniedCommands = []string{
+	"rm", "rmdir", "mv", "dd", "shred", "truncate", "mkfs", "chmod", "chown", "chgrp", "ln",
+	"curl", "wget", "ssh", "scp", "sftp", "rsync", "nc", "ncat", "netcat", "telnet", "ftp",
+	"sudo", "su", "doas", "mount", "umount", "kill", "pkill", "killall", "reboot", "shutdown",
+	"crontab", "docker", "kubectl", "eval", "exec", "source", ".",
+}
+
+// readOnlyGitCommands 允许的 git 子命令，其余子命令可能修改仓库或访问网络
+var readOnlyGitCommands = []string{
+	"log", "show", "diff", "blame", "status", "branch", "tag", "rev-parse", "rev-list",
+	"ls-files", "ls-tree", "cat-file", "grep", "shortlog", "describe", "name-rev", "help",
+}
+
+// wrapperCommands 执行其他程序的命令，被执行的程序同样需要检查
+var wrapperCommands = []string{"xargs", "env", "nice", "nohup", "timeout", "time", "command", "stdbuf"}
+
+var shellCommands = []string{"sh", "bash", "dash", "zsh", "ksh"}
+
+// CommandPolicy run_terminal_command 的命令策略，检查命令中每个管道段与 && 段的程序名
+type CommandPolicy struct {
+	Allow []string // 允许的程序名，为空时除禁止列表外均允许
+	Deny  []string // 在 DefaultDeniedCommands 之外额外禁止的程序名
+}
+
+// Check 检查命令是否符合策略。命令替换与写入文件的重定向一律拒绝
+func (p CommandPolicy) Check(command string) error {
+	segments, err := splitCommand(command)
+	if err != nil {
+		return err
+	}
+	for _, words := range segments {
+		if err := p.checkProgram(words); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p CommandPolicy) checkProgram(words []string) error {
+	// 跳过 FOO=bar 形式的环境变量赋值
+	for len(words) > 0 && isAssignment(words[0]) {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	name := filepath.Base(words[0])
+	if slices.Contains(DefaultDeniedCommands, name) || slices.Contains(p.Deny, name) {
+		return fmt.Errorf("command %q is denied", name)
+	}
+	if len(p.Allow) > 0 && !slices.Contains(p.Allow, name) {
+		return fmt.Errorf("command %q is not in the allow list: %s", name, strings.Join(p.Allow, ", "))
+	}
+
+	args := words[1:]
+	switch {
+	case slices.Contains(shellCommands, name) && slices.Contains(args, "-c"):
+		return fmt.Errorf("%s -c is not allowed, run the command directly", name)
+	case name == "git":
+		if sub := firstArg(args); sub != "" && !slices.Contains(readOnlyGitCommands, sub) {
+			return fmt.Errorf("git %s is not allowed, only read-only git commands can be used", sub)
+		}
+	case name == "find":
+		for i, arg := range args {
+			switch arg {
+			case "-delete", "-fprint", "-fprintf", "-fls":
+				return fmt.Errorf("find %s is not allowed", arg)
+			case "-exec", "-execdir", "-ok", "-okdir":
+				if err := p.checkProgram(args[i+1:]); err != nil {
+					return err
+				}
+			}
+		}
+	case slices.Contains(wrapperCommands, name):
+		for i, arg := range args {
+			// 跳过选项、赋值与 timeout 的时长参数
+			if strings.HasPrefix(arg, "-") || isAssignment(arg) || (name == "timeout" && isDuration(arg)) {
+				continue
+			}
+			return p.checkProgram(args[i:])
+		}
+	}
+	return nil
+}
+
+// firstArg 返回第一个非选项参数（git -C dir log 中的 log）
+func firstArg(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-C" || args[i] == "-c":
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// isDuration timeout 的时长参数，例如 30、1.5m
+func isDuration(word string) bool {
+	return word != "" && word[0] >= '0' && word[0] <= '9' && strings.TrimRight(word, "0123456789.smhd") == ""
+}
+
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitCommand 按 shell 语法把命令拆成简单命令（管道、;、&&、||、& 与括号分隔），每个简单命令为单词列表。
+// 只处理引号与转义，足以识别程序名；命令替换与写入文件的重定向直接拒绝
+func splitCommand(command string) ([][]string, error) {
+	var (
+		segments [][]string
+		words    []string
+		word     strings.Builder
+		inWord   bool
+		quote    rune
+	)
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endSegment := func() {
+		endWord()
+		if len(words) > 0 {
+			segments = append(segments, words)
+			words = nil
+		}
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		if quote == '\'' {
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+			continue
+		}
+		if c == '`' || (c == '$' && next == '(') {
+			return nil, fmt.Errorf("command substitution is not allowed")
+		}
+		if quote == '"' {
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && next != 0:
+				word.WriteRune(next)
+				i++
+			default:
+				word.WriteRune(c)
+			}
+			continue
+		}
+
+		switch {
+		case c == '\\' && next != 0:
+			word.WriteRune(next)
+			inWord = true
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '>' || c == '<':
+			if next == '(' {
+				return nil, fmt.Errorf("process substitution is not allowed")
+			}
+			// 2>/dev/null 中的文件描述符编号不是单词
+			if inWord && strings.Trim(word.String(), "0123456789") == "" {
+				word.Reset()
+				inWord = false
+			}
+			endWord()
+			j := i + 1
+			for j < len(runes) && (runes[j] == '>' || runes[j] == '|') {
+				j++
+			}
+			if c == '>' && j < len(runes) && runes[j] == '&' {
+				// >&2 复制文件描述符
+				j++
+				for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+					j++
+				}
+				i = j - 1
+				continue
+			}
+			for j < len(runes) && (runes[j] == ' ' || runes[j] == '\t') {
+				j++
+			}
+			start := j
+			for j < len(runes) && !strings.ContainsRune(" \t;&|()<>\n", runes[j]) {
+				j++
+			}
+			target := string(runes[start:j])
+			if c == '>' && target != "/dev/null" {
+				return nil, fmt.Errorf("writing to files is not allowed: %s", strings.TrimSpace(string(runes[i:j])))
+			}
+			i = j - 1
+		case strings.ContainsRune(";&|()\n", c):
+			endSegment()
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command")
+	}
+	endSegment()
+	return segments, nil
+}
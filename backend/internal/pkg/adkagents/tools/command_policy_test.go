@@ -0,0 +1,56 @@
+package tools
+
+import "testing"
+
+func TestCommandPolicyCheck(t *testing.T) {
+	policy := CommandPolicy{Deny: []string{"make"}}
+	allowed := []string{
+		"ls -la",
+		"git -C repo log --oneline -5 | head -3",
+		"grep -rn 'rm -rf' . 2>/dev/null",
+		"find . -name '*.go' -exec wc -l {} +",
+		"FOO=1 timeout 5 python3 script.py 2>&1",
+		"cat \"a file\" && echo done > /dev/null",
+	}
+	for _, command := range allowed {
+		if err := policy.Check(command); err != nil {
+			t.Errorf("Check(%q) = %v, want allowed", command, err)
+		}
+	}
+
+	denied := []string{
+		"rm -rf /",
+		"/bin/rm x",
+		"ls; curl http://example.com",
+		"make build",
+		"git commit -am x",
+		"git -C repo push",
+		"bash -c 'rm x'",
+		"find . -delete",
+		`find . -exec rm {} \;`,
+		"xargs -0 rm",
+		"timeout 5 rm x",
+		"env FOO=1 wget x",
+		"echo $(rm x)",
+		"echo `id`",
+		"echo x > out.txt",
+		"cat <(ls)",
+		"echo 'unterminated",
+	}
+	for _, command := range denied {
+		if err := policy.Check(command); err == nil {
+			t.Errorf("Check(%q) should be denied", command)
+		}
+	}
+
+	allowList := CommandPolicy{Allow: []string{"ls", "cat"}}
+	if err := allowList.Check("ls | cat"); err != nil {
+		t.Errorf("allow list rejected permitted command: %v", err)
+	}
+	if err := allowList.Check("ls | wc -l"); err == nil {
+		t.Error("allow list should reject wc")
+	}
+	if err := (CommandPolicy{Allow: []string{"rm"}}).Check("rm x"); err == nil {
+		t.Error("default denied commands cannot be allowed")
+	}
+}
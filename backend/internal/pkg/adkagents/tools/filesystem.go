@@ -26,6 +26,18 @@ type ListDirEntry struct {
 	Modified time.Time `json:"modified,omitempty"`
 }
 
+// defaultIgnoredNames 默认跳过的目录与文件名，include_config 为 true 时不跳过
+var defaultIgnoredNames = map[string]bool{
+	".git":         true,
+	".idea":        true,
+	".vscode":      true,
+	".DS_Store":    true,
+	"node_modules": true,
+	"dist":         true,
+	"build":        true,
+	"vendor":       true,
+}
+
 type ignorePattern struct {
 	raw      string
 	negate   bool
@@ -67,16 +79,7 @@ func ListDir(args json.RawMessage, basePath string) (string, error) {
 
 	var entries []ListDirEntry
 
-	ignoredNames := map[string]bool{
-		".git":         true,
-		".idea":        true,
-		".vscode":      true,
-		".DS_Store":    true,
-		"node_modules": true,
-		"dist":         true,
-		"build":        true,
-		"vendor":       true,
-	}
+	ignoredNames := defaultIgnoredNames
 	ignorePatterns := loadIgnorePatterns(basePath)
 
 	if params.Recursive {
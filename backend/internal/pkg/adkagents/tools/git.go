@@ -3,6 +3,7 @@ package tools
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -64,7 +65,11 @@ func GitClone(args json.RawMessage, basePath string) (string, error) {
 	return result, nil
 }
 
+// maxGitOutput git 命令输出返回给大模型的上限
+const maxGitOutput = 64 * 1024
+
 type GitDiffArgs struct {
+	RepoPath   string `json:"repo_path,omitempty"`
 	CommitHash string `json:"commit_hash"`
 	FilePath   string `json:"file_path,omitempty"`
 }
@@ -78,19 +83,20 @@ func GitDiff(args json.RawMessage, basePath string) (string, error) {
 	if params.CommitHash == "" {
 		return "", fmt.Errorf("commit_hash is required")
 	}
+	if err := validateRevision(params.CommitHash); err != nil {
+		return "", err
+	}
 
+	repoDir, file, err := gitRepoDir(basePath, params.RepoPath, params.FilePath)
+	if err != nil {
+		return "", err
+	}
 	cmdArgs := []string{"diff", params.CommitHash}
-	if params.FilePath != "" {
-		fullPath := filepath.Join(basePath, params.FilePath)
-		if !isPathSafe(basePath, fullPath) {
-			return "", fmt.Errorf("file_path escapes base directory: %s", params.FilePath)
-		}
-		cmdArgs = append(cmdArgs, "--", fullPath)
+	if file != "" {
+		cmdArgs = append(cmdArgs, "--", file)
 	}
 
-	cmd := exec.Command("git", cmdArgs...)
-	cmd.Dir = basePath
-	output, err := cmd.CombinedOutput()
+	output, err := runGit(repoDir, cmdArgs...)
 	if err != nil {
 		return "", fmt.Errorf("git diff failed: %w", err)
 	}
@@ -99,10 +105,11 @@ func GitDiff(args json.RawMessage, basePath string) (string, error) {
 		return "No differences found.", nil
 	}
 
-	return string(output), nil
+	return truncateGitOutput(output), nil
 }
 
 type GitLogArgs struct {
+	RepoPath string `json:"repo_path,omitempty"`
 	FilePath string `json:"file_path,omitempty"`
 	Limit    int    `json:"limit,omitempty"`
 	Since    string `json:"since,omitempty"`
@@ -121,26 +128,27 @@ func GitLog(args json.RawMessage, basePath string) (string, error) {
 		params.Limit = 50
 	}
 
-	cmdArgs := []string{"log", "--oneline", fmt.Sprintf("-%d", params.Limit)}
+	repoDir, file, err := gitRepoDir(basePath, params.RepoPath, params.FilePath)
+	if err != nil {
+		return "", err
+	}
+	cmdArgs := []string{"log", "--format=%h %ad %an %s", "--date=short", fmt.Sprintf("-%d", params.Limit)}
 
 	if params.Since != "" {
-		cmdArgs = append(cmdArgs, "--since", params.Since)
+		cmdArgs = append(cmdArgs, "--since="+params.Since)
 	}
 
-	if params.FilePath != "" {
-		fullPath := filepath.Join(basePath, params.FilePath)
-		if !isPathSafe(basePath, fullPath) {
-			return "", fmt.Errorf("file_path escapes base directory: %s", params.FilePath)
-		}
-		cmdArgs = append(cmdArgs, "--", fullPath)
+	if file != "" {
+		cmdArgs = append(cmdArgs, "--follow", "--", file)
 	}
 
-	cmd := exec.Command("git", cmdArgs...)
-	cmd.Dir = basePath
-	output, err := cmd.CombinedOutput()
+	output, err := runGit(repoDir, cmdArgs...)
 	if err != nil {
 		return "", fmt.Errorf("git log failed: %w", err)
 	}
+	if len(output) == 0 {
+		return "No commits found.", nil
+	}
 
 	return string(output), nil
 }
@@ -155,12 +163,9 @@ func GitStatus(args json.RawMessage, basePath string) (string, error) {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	repoPath := basePath
-	if params.RepoPath != "" {
-		repoPath = filepath.Join(basePath, params.RepoPath)
-		if !isPathSafe(basePath, repoPath) {
-			return "", fmt.Errorf("repo_path escapes base directory: %s", params.RepoPath)
-		}
+	repoPath, _, err := gitRepoDir(basePath, params.RepoPath, "")
+	if err != nil {
+		return "", err
 	}
 
 	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
@@ -210,12 +215,9 @@ func GitBranchList(args json.RawMessage, basePath string) (string, error) {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	repoPath := basePath
-	if params.RepoPath != "" {
-		repoPath = filepath.Join(basePath, params.RepoPath)
-		if !isPathSafe(basePath, repoPath) {
-			return "", fmt.Errorf("repo_path escapes base directory: %s", params.RepoPath)
-		}
+	repoPath, _, err := gitRepoDir(basePath, params.RepoPath, "")
+	if err != nil {
+		return "", err
 	}
 
 	cmdArgs := []string{"branch"}
@@ -223,9 +225,7 @@ func GitBranchList(args json.RawMessage, basePath string) (string, error) {
 		cmdArgs = append(cmdArgs, "-r")
 	}
 
-	cmd := exec.Command("git", cmdArgs...)
-	cmd.Dir = repoPath
-	output, err := cmd.CombinedOutput()
+	output, err := runGit(repoPath, cmdArgs...)
 	if err != nil {
 		return "", fmt.Errorf("git branch failed: %w", err)
 	}
@@ -238,6 +238,160 @@ func GitBranchList(args json.RawMessage, basePath string) (string, error) {
 	return result, nil
 }
 
+type GitBlameArgs struct {
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Rev       string `json:"rev,omitempty"`
+}
+
+func GitBlame(args json.RawMessage, basePath string) (string, error) {
+	var params GitBlameArgs
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.FilePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	if err := validateRevision(params.Rev); err != nil {
+		return "", err
+	}
+
+	repoDir, file, err := gitRepoDir(basePath, "", params.FilePath)
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", fmt.Errorf("file_path must be a file: %s", params.FilePath)
+	}
+
+	cmdArgs := []string{"blame", "--date=short", "-w"}
+	if params.StartLine > 0 {
+		end := params.EndLine
+		if end < params.StartLine {
+			end = params.StartLine
+		}
+		cmdArgs = append(cmdArgs, "-L", fmt.Sprintf("%d,%d", params.StartLine, end))
+	}
+	if params.Rev != "" {
+		cmdArgs = append(cmdArgs, params.Rev)
+	}
+	cmdArgs = append(cmdArgs, "--", file)
+
+	output, err := runGit(repoDir, cmdArgs...)
+	if err != nil {
+		return "", fmt.Errorf("git blame failed: %w", err)
+	}
+
+	return truncateGitOutput(output), nil
+}
+
+type GitShowArgs struct {
+	RepoPath string `json:"repo_path,omitempty"`
+	Rev      string `json:"rev"`
+	FilePath string `json:"file_path,omitempty"`
+	StatOnly bool   `json:"stat_only,omitempty"`
+}
+
+func GitShow(args json.RawMessage, basePath string) (string, error) {
+	var params GitShowArgs
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.Rev == "" {
+		return "", fmt.Errorf("rev is required")
+	}
+	if err := validateRevision(params.Rev); err != nil {
+		return "", err
+	}
+
+	repoDir, file, err := gitRepoDir(basePath, params.RepoPath, params.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	cmdArgs := []string{"show", "--format=fuller", "--date=iso"}
+	if params.StatOnly {
+		cmdArgs = append(cmdArgs, "--stat")
+	}
+	cmdArgs = append(cmdArgs, params.Rev)
+	if file != "" {
+		cmdArgs = append(cmdArgs, "--", file)
+	}
+
+	output, err := runGit(repoDir, cmdArgs...)
+	if err != nil {
+		return "", fmt.Errorf("git show failed: %w", err)
+	}
+
+	return truncateGitOutput(output), nil
+}
+
+// gitRepoDir 解析 git 命令的执行目录与文件路径。
+// 执行目录为 repoPath 或 filePath 所在的仓库根目录，都为空时为 basePath 所在仓库；
+// 路径与仓库根目录都必须位于 basePath 之内，避免读取上层目录中其他仓库的历史。
+// 返回的文件路径相对仓库根目录，filePath 为空或指向仓库根目录时为空
+func gitRepoDir(basePath, repoPath, filePath string) (string, string, error) {
+	target := repoPath
+	if filePath != "" {
+		target = filePath
+	}
+	absTarget, err := ValidateAndResolvePath(basePath, target)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := absTarget
+	if info, err := os.Stat(absTarget); err != nil || !info.IsDir() {
+		// 文件可能已在工作区中删除，仍可查询其历史
+		dir = filepath.Dir(absTarget)
+	}
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", fmt.Errorf("not a git repository: %s", target)
+	}
+	root := strings.TrimSpace(string(out))
+	if !isPathSafe(basePath, root) {
+		return "", "", fmt.Errorf("not a git repository: %s", target)
+	}
+
+	if filePath == "" {
+		return root, "", nil
+	}
+	rel, err := filepath.Rel(resolvePathWithSymlinks(root), resolvePathWithSymlinks(absTarget))
+	if err != nil || rel == "." {
+		return root, "", nil
+	}
+	return root, filepath.ToSlash(rel), nil
+}
+
+// validateRevision 拒绝以 - 开头的版本号，防止被 git 当作命令行选项
+func validateRevision(rev string) error {
+	if strings.HasPrefix(strings.TrimSpace(rev), "-") {
+		return fmt.Errorf("invalid revision: %s", rev)
+	}
+	return nil
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
+
+func truncateGitOutput(output []byte) string {
+	if len(output) <= maxGitOutput {
+		return string(output)
+	}
+	return string(output[:maxGitOutput]) + fmt.Sprintf("\n... (output truncated, %d bytes total)", len(output))
+}
+
 func getGitInfo(repoPath string) (branch, commit string, err error) {
 	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	branchCmd.Dir = repoPath
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// initGitRepo 在 basePath/name 下创建包含两次提交的仓库，返回第一次提交的哈希
+func initGitRepo(t *testing.T, basePath, name string) string {
+	t.Helper()
+	dir := filepath.Join(basePath, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com", "GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v error: %v, %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial commit")
+	first := git("rev-parse", "--short", "HEAD")
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\n// retry once on timeout\nfunc main() {}\n"), 0644)
+	git("commit", "-q", "-am", "add retry note")
+	return first
+}
+
+func TestGitTools(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	basePath := t.TempDir()
+	first := initGitRepo(t, basePath, "demo")
+	ctx := context.Background()
+
+	blame, _ := NewGitBlameTool(basePath).InvokableRun(ctx, `{"file_path":"demo/main.go","start_line":3}`)
+	if !strings.Contains(blame, "Alice") || !strings.Contains(blame, "retry once on timeout") || strings.Contains(blame, "package main") {
+		t.Errorf("git_blame = %s", blame)
+	}
+
+	log, _ := NewGitLogTool(basePath).InvokableRun(ctx, `{"file_path":"demo/main.go"}`)
+	if !strings.Contains(log, "add retry note") || !strings.Contains(log, "initial commit") {
+		t.Errorf("git_log = %s", log)
+	}
+
+	show, _ := NewGitShowTool(basePath).InvokableRun(ctx, `{"repo_path":"demo","rev":"HEAD"}`)
+	if !strings.Contains(show, "add retry note") || !strings.Contains(show, "+// retry once on timeout") {
+		t.Errorf("git_show = %s", show)
+	}
+
+	diff, _ := NewGitDiffTool(basePath).InvokableRun(ctx, `{"repo_path":"demo","commit_hash":"`+first+`"}`)
+	if !strings.Contains(diff, "+// retry once on timeout") {
+		t.Errorf("git_diff = %s", diff)
+	}
+
+	status, _ := NewGitStatusTool(basePath).InvokableRun(ctx, `{"repo_path":"demo"}`)
+	if !strings.Contains(status, "Clean: true") {
+		t.Errorf("git_status = %s", status)
+	}
+}
+
+func TestGitToolsSandbox(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	// basePath 位于另一个仓库内部时，不得读取上层仓库的历史
+	outer := t.TempDir()
+	initGitRepo(t, outer, ".")
+	basePath := filepath.Join(outer, "repos")
+	os.MkdirAll(filepath.Join(basePath, "plain"), 0755)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		tool tool.InvokableTool
+		args string
+	}{
+		{"escape", NewGitLogTool(basePath), `{"file_path":"../main.go"}`},
+		{"parent repo", NewGitLogTool(basePath), `{"repo_path":"plain"}`},
+		{"option rev", NewGitShowTool(basePath), `{"repo_path":"plain","rev":"--output=/tmp/x"}`},
+		{"absolute path", NewGitBlameTool(basePath), `{"file_path":"/etc/passwd"}`},
+	}
+	for _, tc := range cases {
+		result, err := tc.tool.InvokableRun(ctx, tc.args)
+		if err != nil || !strings.HasPrefix(result, "Error:") {
+			t.Errorf("%s: expected error result, got %s, %v", tc.name, result, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"k8s.io/klog/v2"
+)
+
+// gitToolBase Git 历史工具的公共部分，所有路径都限定在 basePath 内
+type gitToolBase struct {
+	basePath string
+}
+
+// invoke 调用 git.go 中的实现，错误作为字符串返回给大模型，而不是返回 error 中断节点执行
+func (b gitToolBase) invoke(name, arguments string, run func(json.RawMessage, string) (string, error)) (string, error) {
+	if !json.Valid([]byte(arguments)) {
+		return "", fmt.Errorf("invalid arguments: %s", arguments)
+	}
+	result, err := run(json.RawMessage(arguments), b.basePath)
+	if err != nil {
+		klog.Errorf("[%s] 执行失败: %v", name, err)
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return result, nil
+}
+
+var gitRepoPathParam = &schema.ParameterInfo{
+	Type: schema.String,
+	Desc: "Repository directory (defaults to the directory of file_path)",
+}
+
+// GitLogTool 提交历史工具
+type GitLogTool struct {
+	gitToolBase
+}
+
+// NewGitLogTool 创建提交历史工具
+func NewGitLogTool(basePath string) *GitLogTool {
+	klog.V(6).Infof("[GitLogTool] 创建工具实例: basePath=%s", basePath)
+	return &GitLogTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitLogTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_log",
+		Desc: "Show recent commits (hash, date, author, subject) of a repository or a single file, following renames.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"repo_path": gitRepoPathParam,
+			"file_path": {
+				Type: schema.String,
+				Desc: "Optional file to show history for",
+			},
+			"limit": {
+				Type: schema.Integer,
+				Desc: "Maximum number of commits (default 10, max 50)",
+			},
+			"since": {
+				Type: schema.String,
+				Desc: "Only commits after this date, e.g. '2024-01-01' or '2 weeks ago'",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitLogTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitLogTool", arguments, GitLog)
+}
+
+// GitDiffTool 差异对比工具
+type GitDiffTool struct {
+	gitToolBase
+}
+
+// NewGitDiffTool 创建差异对比工具
+func NewGitDiffTool(basePath string) *GitDiffTool {
+	klog.V(6).Infof("[GitDiffTool] 创建工具实例: basePath=%s", basePath)
+	return &GitDiffTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitDiffTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_diff",
+		Desc: "Show the diff between a commit (or range such as 'abc123..def456') and the working tree, optionally limited to one file.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"repo_path": gitRepoPathParam,
+			"commit_hash": {
+				Type:     schema.String,
+				Desc:     "Commit, branch, tag or range to diff against",
+				Required: true,
+			},
+			"file_path": {
+				Type: schema.String,
+				Desc: "Optional file to limit the diff to",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitDiffTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitDiffTool", arguments, GitDiff)
+}
+
+// GitStatusTool 工作区状态工具
+type GitStatusTool struct {
+	gitToolBase
+}
+
+// NewGitStatusTool 创建工作区状态工具
+func NewGitStatusTool(basePath string) *GitStatusTool {
+	klog.V(6).Infof("[GitStatusTool] 创建工具实例: basePath=%s", basePath)
+	return &GitStatusTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitStatusTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_status",
+		Desc: "Show the current branch and modified or untracked files of a repository.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"repo_path": {
+				Type:     schema.String,
+				Desc:     "Repository directory",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitStatusTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitStatusTool", arguments, GitStatus)
+}
+
+// GitBranchListTool 分支列表工具
+type GitBranchListTool struct {
+	gitToolBase
+}
+
+// NewGitBranchListTool 创建分支列表工具
+func NewGitBranchListTool(basePath string) *GitBranchListTool {
+	klog.V(6).Infof("[GitBranchListTool] 创建工具实例: basePath=%s", basePath)
+	return &GitBranchListTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitBranchListTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_branch_list",
+		Desc: "List local (or remote) branches of a repository and the current branch.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"repo_path": {
+				Type:     schema.String,
+				Desc:     "Repository directory",
+				Required: true,
+			},
+			"remote": {
+				Type: schema.Boolean,
+				Desc: "List remote-tracking branches instead",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitBranchListTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitBranchListTool", arguments, GitBranchList)
+}
+
+// GitBlameTool 逐行追溯工具
+type GitBlameTool struct {
+	gitToolBase
+}
+
+// NewGitBlameTool 创建逐行追溯工具
+func NewGitBlameTool(basePath string) *GitBlameTool {
+	klog.V(6).Infof("[GitBlameTool] 创建工具实例: basePath=%s", basePath)
+	return &GitBlameTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitBlameTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_blame",
+		Desc: "Show which commit and author last changed each line of a file. Use a line range to explain why a piece of code looks the way it does, then git_show the commit.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"file_path": {
+				Type:     schema.String,
+				Desc:     "File to blame",
+				Required: true,
+			},
+			"start_line": {
+				Type: schema.Integer,
+				Desc: "First line of the range (1-based)",
+			},
+			"end_line": {
+				Type: schema.Integer,
+				Desc: "Last line of the range (defaults to start_line)",
+			},
+			"rev": {
+				Type: schema.String,
+				Desc: "Optional commit to blame at (defaults to HEAD)",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitBlameTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitBlameTool", arguments, GitBlame)
+}
+
+// GitShowTool 提交详情工具
+type GitShowTool struct {
+	gitToolBase
+}
+
+// NewGitShowTool 创建提交详情工具
+func NewGitShowTool(basePath string) *GitShowTool {
+	klog.V(6).Infof("[GitShowTool] 创建工具实例: basePath=%s", basePath)
+	return &GitShowTool{gitToolBase{basePath: basePath}}
+}
+
+// Info 返回工具信息
+func (t *GitShowTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "git_show",
+		Desc: "Show a commit's author, date, message and changes, optionally limited to one file or to a file summary.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"repo_path": gitRepoPathParam,
+			"rev": {
+				Type:     schema.String,
+				Desc:     "Commit hash, branch or tag",
+				Required: true,
+			},
+			"file_path": {
+				Type: schema.String,
+				Desc: "Optional file to limit the changes to",
+			},
+			"stat_only": {
+				Type: schema.Boolean,
+				Desc: "Only list changed files with line counts",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *GitShowTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	return t.invoke("GitShowTool", arguments, GitShow)
+}
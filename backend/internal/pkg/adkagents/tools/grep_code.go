@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultGrepMatches = 100
+	maxGrepMatches     = 500
+	maxGrepContext     = 10
+	// maxGrepOutput 返回给大模型的结果上限，超出后截断
+	maxGrepOutput = 32 * 1024
+	// maxGrepFileSize 超过该大小的文件不搜索，多为生成文件或数据文件
+	maxGrepFileSize = 1 << 20
+	maxGrepLineLen  = 300
+)
+
+type GrepCodeArgs struct {
+	Pattern         string `json:"pattern"`
+	Path            string `json:"path,omitempty"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+	Literal         bool   `json:"literal,omitempty"`
+	Context         int    `json:"context,omitempty"`
+	Include         string `json:"include,omitempty"`
+	Exclude         string `json:"exclude,omitempty"`
+	MaxMatches      int    `json:"max_matches,omitempty"`
+}
+
+// GrepCode 按正则表达式搜索文件内容，遵循 .gitignore 规则并跳过二进制与过大的文件。
+// 结果格式与 grep -n 一致：匹配行为 path:line:text，上下文行为 path-line-text，不相邻的片段之间以 -- 分隔
+func GrepCode(args json.RawMessage, basePath string) (string, error) {
+	var params GrepCodeArgs
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.Pattern == "" {
+		return "", fmt.Errorf("pattern is required")
+	}
+	expr := params.Pattern
+	if params.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if params.CaseInsensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	if params.Path == "" {
+		params.Path = "."
+	}
+	root, err := ValidateAndResolvePath(basePath, params.Path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", fmt.Errorf("path not found: %s", params.Path)
+	}
+	absBase, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve base path: %w", err)
+	}
+
+	limit := params.MaxMatches
+	if limit <= 0 {
+		limit = defaultGrepMatches
+	}
+	limit = min(limit, maxGrepMatches)
+	g := &grepper{
+		re:       re,
+		context:  min(max(params.Context, 0), maxGrepContext),
+		include:  parseGlobList(params.Include),
+		exclude:  parseGlobList(params.Exclude),
+		limit:    limit,
+		basePath: absBase,
+	}
+
+	if !info.IsDir() {
+		g.searchFile(root)
+	} else {
+		ignoreRoot := findIgnoreRoot(absBase, root)
+		ignorePatterns := loadIgnorePatterns(ignoreRoot)
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if g.done() {
+				return filepath.SkipAll
+			}
+			if path == root {
+				return nil
+			}
+			relToIgnoreRoot, relErr := filepath.Rel(ignoreRoot, path)
+			if relErr != nil {
+				relToIgnoreRoot = d.Name()
+			}
+			relToRoot, _ := filepath.Rel(root, path)
+			if shouldIgnorePath(relToIgnoreRoot, d.Name(), d.IsDir(), false, defaultIgnoredNames, ignorePatterns) || matchGlobList(relToRoot, d.IsDir(), g.exclude) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			if len(g.include) > 0 && !matchGlobList(relToRoot, false, g.include) {
+				return nil
+			}
+			g.searchFile(path)
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("search failed: %w", err)
+		}
+	}
+
+	if g.matches == 0 {
+		return fmt.Sprintf("No matches found for %q in %s.", params.Pattern, params.Path), nil
+	}
+	result := g.out.String()
+	switch {
+	case g.truncated:
+		result += fmt.Sprintf("\n... (output truncated at %d bytes, narrow the path or pattern)", maxGrepOutput)
+	case g.matches >= g.limit:
+		result += fmt.Sprintf("\n... (stopped after %d matches, narrow the path or pattern)", g.limit)
+	}
+	return result, nil
+}
+
+// grepper 累计搜索结果并在达到匹配数或输出上限时停止
+type grepper struct {
+	re       *regexp.Regexp
+	context  int
+	include  []ignorePattern
+	exclude  []ignorePattern
+	limit    int
+	basePath string
+
+	out       strings.Builder
+	matches   int
+	truncated bool
+	// wroteAny 是否已输出过匹配，用于在不相邻的片段之间插入分隔符
+	wroteAny bool
+}
+
+func (g *grepper) done() bool {
+	return g.matches >= g.limit || g.truncated
+}
+
+func (g *grepper) searchFile(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxGrepFileSize {
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || isBinaryContent(content) {
+		return
+	}
+	name, err := filepath.Rel(g.basePath, path)
+	if err != nil {
+		name = path
+	}
+	name = filepath.ToSlash(name)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	// next 为下一个未输出的行，afterUntil 为当前匹配的后续上下文截止行
+	next, afterUntil, started := 0, -1, false
+	for i, line := range lines {
+		if !g.re.MatchString(line) {
+			if i <= afterUntil {
+				g.writeLine(name, "-", i, line)
+				next = i + 1
+			}
+			continue
+		}
+		if g.done() {
+			return
+		}
+		start := max(i-g.context, next)
+		if g.context > 0 && g.wroteAny && (!started || start > next) {
+			g.write("--\n")
+		}
+		for j := start; j < i; j++ {
+			g.writeLine(name, "-", j, lines[j])
+		}
+		g.writeLine(name, ":", i, line)
+		g.matches++
+		next, afterUntil, started, g.wroteAny = i+1, i+g.context, true, true
+	}
+}
+
+func (g *grepper) writeLine(name, sep string, index int, line string) {
+	g.write(fmt.Sprintf("%s%s%d%s%s\n", name, sep, index+1, sep, clipLine(line)))
+}
+
+func (g *grepper) write(s string) {
+	if g.truncated {
+		return
+	}
+	if g.out.Len()+len(s) > maxGrepOutput {
+		g.truncated = true
+		return
+	}
+	g.out.WriteString(s)
+}
+
+// findIgnoreRoot 从搜索目录向上查找仓库根目录，.gitignore 规则相对仓库根目录生效；找不到时使用 basePath
+func findIgnoreRoot(absBase, dir string) string {
+	for current := dir; isPathSafe(absBase, current); current = filepath.Dir(current) {
+		if FileExists(filepath.Join(current, ".git")) {
+			return current
+		}
+		if current == absBase || current == filepath.Dir(current) {
+			break
+		}
+	}
+	return absBase
+}
+
+// parseGlobList 解析逗号分隔的 glob 列表，语法与 .gitignore 相同：不含 / 时匹配文件名，支持 **
+func parseGlobList(list string) []ignorePattern {
+	var patterns []ignorePattern
+	for _, item := range strings.Split(list, ",") {
+		if pattern, ok := parseIgnoreLine(item); ok && !pattern.negate {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func matchGlobList(relPath string, isDir bool, patterns []ignorePattern) bool {
+	for _, pattern := range patterns {
+		if matchIgnorePattern(relPath, isDir, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinaryContent 与 git 的判断方式一致：前 8000 字节中含有 NUL 视为二进制文件
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+func clipLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if len(line) <= maxGrepLineLen {
+		return line
+	}
+	return line[:maxGrepLineLen] + "..."
+}
+
+// GrepCodeTool 代码内容搜索工具
+// 实现 Eino 的 tool.BaseTool 接口，按正则表达式搜索文件内容并返回匹配行及上下文
+type GrepCodeTool struct {
+	basePath string
+}
+
+// NewGrepCodeTool 创建代码内容搜索工具
+func NewGrepCodeTool(basePath string) *GrepCodeTool {
+	klog.V(6).Infof("[GrepCodeTool] 创建工具实例: basePath=%s", basePath)
+	return &GrepCodeTool{basePath: basePath}
+}
+
+// Info 返回工具信息
+func (t *GrepCodeTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "grep_code",
+		Desc: "Search file contents with a regular expression (RE2 syntax). Returns 'path:line:text' for matches and 'path-line-text' for context lines. Respects .gitignore and skips binary files; results are capped, so narrow path/include when output is truncated.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"pattern": {
+				Type:     schema.String,
+				Desc:     "Regular expression to search for, e.g. 'func\\s+NewServer' or 'TODO|FIXME'",
+				Required: true,
+			},
+			"path": {
+				Type: schema.String,
+				Desc: "Directory or file to search (default: base directory)",
+			},
+			"case_insensitive": {
+				Type: schema.Boolean,
+				Desc: "Case-insensitive match",
+			},
+			"literal": {
+				Type: schema.Boolean,
+				Desc: "Treat pattern as a plain string instead of a regular expression",
+			},
+			"context": {
+				Type: schema.Integer,
+				Desc: "Lines of context before and after each match (max 10)",
+			},
+			"include": {
+				Type: schema.String,
+				Desc: "Comma-separated globs of files to search, e.g. '*.go,*.proto' or 'internal/**/*.ts'",
+			},
+			"exclude": {
+				Type: schema.String,
+				Desc: "Comma-separated globs of files or directories to skip, e.g. '*_test.go,testdata'",
+			},
+			"max_matches": {
+				Type: schema.Integer,
+				Desc: "Maximum number of matches (default 100, max 500)",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+// 注意: 工具调用的输入输出日志由 EinoCallbacks 处理，此处仅记录业务相关日志
+func (t *GrepCodeTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	result, err := GrepCode(json.RawMessage(arguments), t.basePath)
+	if err != nil {
+		klog.Errorf("[GrepCodeTool] 搜索失败: %v", err)
+		// 将错误信息作为字符串返回给大模型，而不是返回 error 中断节点执行
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGrepFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func grep(t *testing.T, basePath string, args GrepCodeArgs) string {
+	t.Helper()
+	raw, _ := json.Marshal(args)
+	result, err := GrepCode(raw, basePath)
+	if err != nil {
+		t.Fatalf("GrepCode(%+v) error: %v", args, err)
+	}
+	return result
+}
+
+func TestGrepCode(t *testing.T) {
+	basePath := t.TempDir()
+	writeGrepFixture(t, basePath, map[string]string{
+		"demo/.git/HEAD":          "ref: refs/heads/main\n",
+		"demo/.gitignore":         "gen/\n",
+		"demo/main.go":            "package main\n\nimport \"fmt\"\n\nfunc NewServer() {\n\tfmt.Println(\"a\")\n}\n",
+		"demo/main_test.go":       "package main\n\nfunc TestNewServer() { NewServer() }\n",
+		"demo/web/app.ts":         "export function newServer() {}\n",
+		"demo/gen/server.go":      "func NewServer() {}\n",
+		"demo/node_modules/x.js":  "NewServer()\n",
+		"demo/assets/logo.bin":    "NewServer\x00\x01",
+		"demo/docs/long/notes.md": "see NewServer\n",
+	})
+
+	result := grep(t, basePath, GrepCodeArgs{Pattern: `func\s+NewServer`, Path: "demo"})
+	if !strings.Contains(result, "demo/main.go:5:func NewServer() {") {
+		t.Errorf("expected match with path relative to base, got:\n%s", result)
+	}
+	for _, skipped := range []string{"gen/server.go", "node_modules", "logo.bin"} {
+		if strings.Contains(result, skipped) {
+			t.Errorf("%s should be skipped, got:\n%s", skipped, result)
+		}
+	}
+
+	result = grep(t, basePath, GrepCodeArgs{Pattern: "newserver", Path: "demo", CaseInsensitive: true, Include: "*.go,*.ts", Exclude: "*_test.go"})
+	if !strings.Contains(result, "demo/web/app.ts:1:") || strings.Contains(result, "main_test.go") || strings.Contains(result, "notes.md") {
+		t.Errorf("include/exclude not applied, got:\n%s", result)
+	}
+
+	result = grep(t, basePath, GrepCodeArgs{Pattern: "Println", Path: "demo/main.go", Context: 1})
+	want := "demo/main.go-5-func NewServer() {\ndemo/main.go:6:\tfmt.Println(\"a\")\ndemo/main.go-7-}\n"
+	if result != want {
+		t.Errorf("context lines = %q, want %q", result, want)
+	}
+
+	result = grep(t, basePath, GrepCodeArgs{Pattern: "NewServer", Path: "demo", MaxMatches: 1})
+	if strings.Count(result, "NewServer") != 1 || !strings.Contains(result, "stopped after 1 matches") {
+		t.Errorf("max_matches not applied, got:\n%s", result)
+	}
+
+	if result := grep(t, basePath, GrepCodeArgs{Pattern: "NewServer(", Path: "demo", Literal: true, Include: "*_test.go"}); !strings.Contains(result, "main_test.go:3:") {
+		t.Errorf("literal search failed, got:\n%s", result)
+	}
+}
+
+func TestGrepCodeErrors(t *testing.T) {
+	basePath := t.TempDir()
+	for _, args := range []string{
+		`{"pattern":""}`,
+		`{"pattern":"(unclosed"}`,
+		`{"pattern":"x","path":"../"}`,
+		`{"pattern":"x","path":"missing"}`,
+	} {
+		if _, err := GrepCode(json.RawMessage(args), basePath); err == nil {
+			t.Errorf("GrepCode(%s) should fail", args)
+		}
+	}
+}
@@ -13,14 +13,14 @@ import (
 
 // ListSkillsTool 本地技能发现工具
 type ListSkillsTool struct {
-	skillDir string
+	skillDirs []string
 }
 
 // NewListSkillsTool 创建技能发现工具
-// skillDir: 技能定义所在的目录
-func NewListSkillsTool(skillDir string) *ListSkillsTool {
-	klog.V(6).Infof("[ListSkillsTool] 创建工具实例: skillDir=%s", skillDir)
-	return &ListSkillsTool{skillDir: skillDir}
+// skillDirs: 技能定义所在的目录，按优先级排列，同名技能以靠前目录为准
+func NewListSkillsTool(skillDirs ...string) *ListSkillsTool {
+	klog.V(6).Infof("[ListSkillsTool] 创建工具实例: skillDirs=%v", skillDirs)
+	return &ListSkillsTool{skillDirs: skillDirs}
 }
 
 // Info 返回工具信息
@@ -36,35 +36,45 @@ func (t *ListSkillsTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 
 // InvokableRun 执行工具调用
 func (t *ListSkillsTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
-	klog.V(6).Infof("[ListSkillsTool] 开始扫描技能目录: %s", t.skillDir)
-
-	// 使用 Eino 的 LocalBackend 扫描技能
-	sb, err := skill.NewLocalBackend(&skill.LocalBackendConfig{
-		BaseDir: t.skillDir,
-	})
-	if err != nil {
-		klog.Errorf("[ListSkillsTool] 创建 Skill Backend 失败: %v", err)
-		return "", fmt.Errorf("failed to initialize skill backend: %w", err)
-	}
-
-	skills, err := sb.List(ctx)
-	if err != nil {
-		klog.Errorf("[ListSkillsTool] 获取技能列表失败: %v", err)
-		return "", fmt.Errorf("failed to list skills: %w", err)
-	}
-
 	// 构造简化的返回结果
 	type SkillSummary struct {
 		Name        string `json:"name"`
 		Description string `json:"description"`
 	}
 
-	summaries := make([]SkillSummary, 0, len(skills))
-	for _, s := range skills {
-		summaries = append(summaries, SkillSummary{
-			Name:        s.Name,
-			Description: s.Description,
+	summaries := make([]SkillSummary, 0)
+	seen := make(map[string]bool)
+	for _, skillDir := range t.skillDirs {
+		if skillDir == "" {
+			continue
+		}
+		klog.V(6).Infof("[ListSkillsTool] 开始扫描技能目录: %s", skillDir)
+
+		// 使用 Eino 的 LocalBackend 扫描技能
+		sb, err := skill.NewLocalBackend(&skill.LocalBackendConfig{
+			BaseDir: skillDir,
 		})
+		if err != nil {
+			klog.Errorf("[ListSkillsTool] 创建 Skill Backend 失败: %v", err)
+			return "", fmt.Errorf("failed to initialize skill backend: %w", err)
+		}
+
+		skills, err := sb.List(ctx)
+		if err != nil {
+			klog.Errorf("[ListSkillsTool] 获取技能列表失败: %v", err)
+			return "", fmt.Errorf("failed to list skills: %w", err)
+		}
+
+		for _, s := range skills {
+			if seen[s.Name] {
+				continue
+			}
+			seen[s.Name] = true
+			summaries = append(summaries, SkillSummary{
+				Name:        s.Name,
+				Description: s.Description,
+			})
+		}
 	}
 
 	result, err := json.MarshalIndent(summaries, "", "  ")
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
+	"k8s.io/klog/v2"
+)
+
+// SemanticSearchTool 语义检索工具
+// 实现 Eino 的 tool.BaseTool 接口，按语义相似度检索文档与源码分块
+type SemanticSearchTool struct {
+	searcher embedding.Searcher
+}
+
+// NewSemanticSearchTool 创建语义检索工具
+// searcher: 语义检索服务
+func NewSemanticSearchTool(searcher embedding.Searcher) *SemanticSearchTool {
+	klog.V(6).Infof("[SemanticSearchTool] 创建工具实例")
+	return &SemanticSearchTool{searcher: searcher}
+}
+
+// Info 返回工具信息
+// 实现 tool.BaseTool 接口
+func (t *SemanticSearchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "semantic_search",
+		Desc: "Search generated documents and repository source code by meaning (vector similarity). Use natural language queries; returns the most relevant chunks with file paths, line ranges or document IDs.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {
+				Type:     schema.String,
+				Desc:     "Natural language description of what to find",
+				Required: true,
+			},
+			"repo_id": {
+				Type: schema.Integer,
+				Desc: "Repository ID to search in, 0 or omitted searches all repositories",
+			},
+			"source_type": {
+				Type: schema.String,
+				Desc: "Restrict results to 'document' or 'code', omitted searches both",
+				Enum: []string{"document", "code"},
+			},
+			"limit": {
+				Type: schema.Integer,
+				Desc: "Maximum number of results (default 10, max 50)",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行工具调用
+func (t *SemanticSearchTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	if t.searcher == nil {
+		return "Error: 语义检索服务未初始化", nil
+	}
+
+	var args struct {
+		Query      string `json:"query"`
+		RepoID     uint   `json:"repo_id"`
+		SourceType string `json:"source_type"`
+		Limit      int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return "Error: query 不能为空", nil
+	}
+
+	klog.V(6).Infof("[SemanticSearchTool] 语义检索: query=%q, repo_id=%d, source_type=%s", args.Query, args.RepoID, args.SourceType)
+	hits, err := t.searcher.SemanticSearch(ctx, embedding.SearchRequest{
+		Query:        args.Query,
+		RepositoryID: args.RepoID,
+		SourceType:   args.SourceType,
+		Limit:        args.Limit,
+	})
+	if err != nil {
+		klog.Errorf("[SemanticSearchTool] 语义检索失败: %v", err)
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(hits) == 0 {
+		return "No results found.", nil
+	}
+
+	var sb strings.Builder
+	for i, hit := range hits {
+		if hit.SourceType == "code" {
+			fmt.Fprintf(&sb, "[%d] code %s:%d-%d (repo_id=%d, score=%.3f)\n", i+1, hit.Path, hit.StartLine, hit.EndLine, hit.RepositoryID, hit.Score)
+		} else {
+			fmt.Fprintf(&sb, "[%d] document doc_id=%d %s", i+1, hit.DocID, hit.Title)
+			if hit.Heading != "" {
+				fmt.Fprintf(&sb, " > %s", hit.Heading)
+			}
+			fmt.Fprintf(&sb, " (repo_id=%d, score=%.3f)\n", hit.RepositoryID, hit.Score)
+		}
+		sb.WriteString(hit.Content)
+		sb.WriteString("\n\n")
+	}
+	return sb.String(), nil
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/weibaohui/opendeepwiki/backend/config"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents/tools"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/sandbox"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 )
 
@@ -12,7 +16,16 @@ import (
 type ToolProvider struct {
 	BasePath string
 	SkillDir string
-	DocRepo  repository.DocumentRepository
+	// WorkspaceSkillDir 工作空间技能目录，同名技能优先于 SkillDir
+	WorkspaceSkillDir string
+	DocRepo           repository.DocumentRepository
+	Searcher          embedding.Searcher
+
+	// run_terminal_command 配置
+	AgentName      string
+	CommandPolicy  tools.CommandPolicy
+	Sandbox        config.SandboxConfig
+	CommandAuditor tools.CommandAuditor
 }
 
 // GetTool 获取指定名称的工具
@@ -24,15 +37,40 @@ func (p *ToolProvider) GetTool(name string) (tool.BaseTool, error) {
 		return tools.NewReadFileTool(p.BasePath), nil
 	case "search_files":
 		return tools.NewSearchFilesTool(p.BasePath), nil
+	case "grep_code":
+		return tools.NewGrepCodeTool(p.BasePath), nil
+	case "find_symbol":
+		return tools.NewFindSymbolTool(p.BasePath, codeindex.DefaultStore()), nil
+	case "find_references":
+		return tools.NewFindReferencesTool(p.BasePath, codeindex.DefaultStore()), nil
+	case "outline_file":
+		return tools.NewOutlineFileTool(p.BasePath), nil
 	case "list_skills":
-		return tools.NewListSkillsTool(p.SkillDir), nil
+		return tools.NewListSkillsTool(p.WorkspaceSkillDir, p.SkillDir), nil
+	case "git_log":
+		return tools.NewGitLogTool(p.BasePath), nil
+	case "git_diff":
+		return tools.NewGitDiffTool(p.BasePath), nil
+	case "git_status":
+		return tools.NewGitStatusTool(p.BasePath), nil
+	case "git_branch_list":
+		return tools.NewGitBranchListTool(p.BasePath), nil
+	case "git_blame":
+		return tools.NewGitBlameTool(p.BasePath), nil
+	case "git_show":
+		return tools.NewGitShowTool(p.BasePath), nil
 	case "run_terminal_command":
-		return tools.NewRunTerminalCommandTool(p.BasePath), nil
+		return p.newRunTerminalCommandTool(), nil
 	case "read_doc":
 		if p.DocRepo == nil {
 			return nil, fmt.Errorf("document repository not configured")
 		}
 		return tools.NewReadDocTool(p.DocRepo), nil
+	case "semantic_search":
+		if p.Searcher == nil {
+			return nil, fmt.Errorf("semantic searcher not configured")
+		}
+		return tools.NewSemanticSearchTool(p.Searcher), nil
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
@@ -40,5 +78,26 @@ func (p *ToolProvider) GetTool(name string) (tool.BaseTool, error) {
 
 // ListTools 列出所有可用工具名称
 func (p *ToolProvider) ListTools() []string {
-	return []string{"list_dir", "read_file", "search_files", "list_skills", "run_terminal_command", "read_doc"}
+	return []string{"list_dir", "read_file", "search_files", "grep_code", "find_symbol", "find_references", "outline_file", "list_skills", "git_log", "git_diff", "git_status", "git_branch_list", "git_blame", "git_show", "run_terminal_command", "read_doc", "semantic_search"}
+}
+
+// newRunTerminalCommandTool 按智能体的命令策略与全局沙箱配置创建命令工具
+func (p *ToolProvider) newRunTerminalCommandTool() *tools.RunTerminalCommandTool {
+	t := tools.NewRunTerminalCommandTool(p.BasePath)
+	t.AgentName = p.AgentName
+	t.Policy = p.CommandPolicy
+	t.Auditor = p.CommandAuditor
+	if p.Sandbox.Timeout > 0 {
+		t.Timeout = p.Sandbox.Timeout
+	}
+	t.MaxOutputBytes = p.Sandbox.MaxOutputKB << 10
+	if p.Sandbox.Enabled {
+		t.Sandbox = &sandbox.Options{
+			Network:      p.Sandbox.Network,
+			CPUSeconds:   p.Sandbox.CPUSeconds,
+			MemoryMB:     p.Sandbox.MemoryMB,
+			WeakReadOnly: p.Sandbox.WeakReadOnly,
+		}
+	}
+	return t
 }
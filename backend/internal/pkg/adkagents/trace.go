@@ -0,0 +1,139 @@
+package adkagents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"k8s.io/klog/v2"
+)
+
+var (
+	taskTraceRecorder TaskTraceRecorder
+	taskTraceMutex    sync.RWMutex
+)
+
+// SetTaskTraceRecorder 设置任务执行轨迹记录器
+// 设置后，带有 taskID 上下文的 Agent 步骤、模型调用与工具调用都会持久化到任务轨迹
+func SetTaskTraceRecorder(recorder TaskTraceRecorder) {
+	taskTraceMutex.Lock()
+	defer taskTraceMutex.Unlock()
+	taskTraceRecorder = recorder
+}
+
+// recordTaskTrace 记录一条任务执行轨迹；上下文中没有 taskID 或未设置记录器时忽略
+func recordTaskTrace(ctx context.Context, trace *model.TaskTrace) {
+	taskTraceMutex.RLock()
+	recorder := taskTraceRecorder
+	taskTraceMutex.RUnlock()
+	if recorder == nil || ctx == nil {
+		return
+	}
+	taskID, ok := ctx.Value("taskID").(uint)
+	if !ok || taskID == 0 {
+		return
+	}
+	trace.TaskID = taskID
+	if trace.StartedAt.IsZero() {
+		trace.StartedAt = time.Now()
+	}
+	trace.Arguments = truncateProgressContent(trace.Arguments)
+	// 轨迹写入不应受任务取消影响
+	if err := recorder.RecordTrace(context.WithoutCancel(ctx), trace); err != nil {
+		klog.V(6).Infof("任务轨迹记录失败：taskID=%d, type=%s, err=%v", taskID, trace.StepType, err)
+	}
+}
+
+type toolTraceStartKey struct{}
+
+// toolTraceStart 工具调用开始时记录的信息，通过回调上下文传递到结束回调
+type toolTraceStart struct {
+	startedAt time.Time
+	arguments string
+}
+
+func withToolTraceStart(ctx context.Context, arguments string) context.Context {
+	return context.WithValue(ctx, toolTraceStartKey{}, &toolTraceStart{startedAt: time.Now(), arguments: arguments})
+}
+
+// recordToolTrace 记录一次工具调用的参数、结果大小与耗时
+func recordToolTrace(ctx context.Context, toolName string, resultSize int, err error) {
+	trace := &model.TaskTrace{
+		StepType:   model.TaskTraceStepTool,
+		Name:       toolName,
+		ResultSize: resultSize,
+	}
+	if start, ok := ctx.Value(toolTraceStartKey{}).(*toolTraceStart); ok {
+		trace.StartedAt = start.startedAt
+		trace.Arguments = start.arguments
+		trace.DurationMs = time.Since(start.startedAt).Milliseconds()
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	recordTaskTrace(ctx, trace)
+}
+
+// recordModelTrace 记录一次模型调用的模型名称、Token 用量与耗时
+func recordModelTrace(ctx context.Context, m *ModelWithMetadata, startedAt time.Time, result any, err error) {
+	trace := &model.TaskTrace{
+		StepType:   model.TaskTraceStepModel,
+		Name:       m.APIKeyName,
+		LLMModel:   m.LLMModel,
+		StartedAt:  startedAt,
+		DurationMs: time.Since(startedAt).Milliseconds(),
+	}
+	if msg, ok := result.(*schema.Message); ok && msg != nil {
+		trace.ResultSize = len(msg.Content)
+		if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+			trace.PromptTokens = msg.ResponseMeta.Usage.PromptTokens
+			trace.CompletionTokens = msg.ResponseMeta.Usage.CompletionTokens
+			trace.TotalTokens = msg.ResponseMeta.Usage.TotalTokens
+		}
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	recordTaskTrace(ctx, trace)
+}
+
+// agentStepTracker 跟踪 Agent 链中当前执行的子 Agent
+type agentStepTracker struct {
+	ctx       context.Context
+	name      string
+	startedAt time.Time
+}
+
+// switchTo 切换到新的子 Agent，结束上一个子 Agent 的步骤
+func (t *agentStepTracker) switchTo(name string) {
+	if name == t.name {
+		return
+	}
+	t.finish(nil)
+	t.name = name
+	t.startedAt = time.Now()
+	publishTaskProgress(t.ctx, eventbus.TaskProgressEvent{Type: eventbus.TaskProgressAgentStarted, Agent: name})
+}
+
+// finish 结束当前子 Agent 的步骤，推送进度事件并记录轨迹
+func (t *agentStepTracker) finish(err error) {
+	if t.name == "" {
+		return
+	}
+	publishTaskProgress(t.ctx, eventbus.TaskProgressEvent{Type: eventbus.TaskProgressAgentFinished, Agent: t.name})
+	trace := &model.TaskTrace{
+		StepType:   model.TaskTraceStepAgent,
+		Agent:      t.name,
+		Name:       t.name,
+		StartedAt:  t.startedAt,
+		DurationMs: time.Since(t.startedAt).Milliseconds(),
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	recordTaskTrace(t.ctx, trace)
+	t.name = ""
+}
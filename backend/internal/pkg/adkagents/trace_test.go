@@ -0,0 +1,53 @@
+package adkagents
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+type fakeTraceRecorder struct {
+	traces []*model.TaskTrace
+}
+
+func (f *fakeTraceRecorder) RecordTrace(ctx context.Context, trace *model.TaskTrace) error {
+	f.traces = append(f.traces, trace)
+	return nil
+}
+
+func TestRecordTaskTrace(t *testing.T) {
+	recorder := &fakeTraceRecorder{}
+	SetTaskTraceRecorder(recorder)
+	defer SetTaskTraceRecorder(nil)
+
+	// 没有 taskID 的上下文（如对话）不记录
+	recordToolTrace(context.Background(), "read_file", 10, nil)
+	if len(recorder.traces) != 0 {
+		t.Fatalf("expected no traces without taskID, got %d", len(recorder.traces))
+	}
+
+	ctx := context.WithValue(context.Background(), "taskID", uint(3))
+	steps := &agentStepTracker{ctx: ctx}
+	steps.switchTo("document_generator")
+	recordToolTrace(withToolTraceStart(ctx, `{"path":"go.mod"}`), "read_file", 42, nil)
+	steps.switchTo("document_generator")
+	steps.switchTo("document_checker")
+	steps.finish(errors.New("boom"))
+	steps.finish(nil)
+
+	if len(recorder.traces) != 3 {
+		t.Fatalf("expected 3 traces, got %d", len(recorder.traces))
+	}
+	tool := recorder.traces[0]
+	if tool.TaskID != 3 || tool.StepType != model.TaskTraceStepTool || tool.Arguments != `{"path":"go.mod"}` || tool.ResultSize != 42 {
+		t.Fatalf("unexpected tool trace: %+v", tool)
+	}
+	if recorder.traces[1].Agent != "document_generator" || recorder.traces[1].Error != "" {
+		t.Fatalf("unexpected first agent trace: %+v", recorder.traces[1])
+	}
+	if recorder.traces[2].Agent != "document_checker" || recorder.traces[2].Error != "boom" {
+		t.Fatalf("unexpected second agent trace: %+v", recorder.traces[2])
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	einoModel "github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 )
 
 // ExitConfig 退出条件配置
@@ -13,6 +14,12 @@ type ExitConfig struct {
 	Type string `yaml:"type" json:"type"` // 退出类型，如 "tool_call"
 }
 
+// SandboxConfig run_terminal_command 的命令策略，程序名按 basename 匹配
+type SandboxConfig struct {
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"` // 允许的程序，为空时除禁止列表外均允许
+	Deny  []string `yaml:"deny,omitempty" json:"deny,omitempty"`   // 额外禁止的程序
+}
+
 // LoadResult 加载结果
 type LoadResult struct {
 	Agent  *AgentDefinition
@@ -37,6 +44,11 @@ type TaskUsageService interface {
 	RecordUsage(ctx context.Context, taskID uint, apiKeyName string, usage *schema.TokenUsage) error
 }
 
+// TaskTraceRecorder 任务执行轨迹记录接口（避免循环导入）
+type TaskTraceRecorder interface {
+	RecordTrace(ctx context.Context, trace *model.TaskTrace) error
+}
+
 // ModelWithMetadata 带有元数据的模型包装器
 type ModelWithMetadata struct {
 	einoModel.ChatModel
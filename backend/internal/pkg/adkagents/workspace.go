@@ -0,0 +1,137 @@
+package adkagents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"k8s.io/klog/v2"
+)
+
+// WorkspaceDirs 工作空间级 agent/skill 目录覆盖，空值表示沿用全局目录
+type WorkspaceDirs struct {
+	AgentDir string
+	SkillDir string
+}
+
+// WorkspaceDirResolver 根据 context 中的工作空间解析目录覆盖
+type WorkspaceDirResolver func(ctx context.Context) WorkspaceDirs
+
+var (
+	workspaceDirResolverMu sync.RWMutex
+	workspaceDirResolver   WorkspaceDirResolver
+)
+
+// SetWorkspaceDirResolver 设置工作空间目录解析器
+func SetWorkspaceDirResolver(resolver WorkspaceDirResolver) {
+	workspaceDirResolverMu.Lock()
+	workspaceDirResolver = resolver
+	workspaceDirResolverMu.Unlock()
+}
+
+// resolveWorkspaceDirs 解析当前 context 的工作空间目录，未配置解析器时返回空
+func resolveWorkspaceDirs(ctx context.Context) WorkspaceDirs {
+	workspaceDirResolverMu.RLock()
+	resolver := workspaceDirResolver
+	workspaceDirResolverMu.RUnlock()
+	if resolver == nil || ctx == nil {
+		return WorkspaceDirs{}
+	}
+	return resolver(ctx)
+}
+
+// overlayRegistry 工作空间 agent 目录的注册表，按热加载间隔整体重新扫描
+type overlayRegistry struct {
+	registry *Registry
+	loadedAt time.Time
+}
+
+// overlay 获取工作空间 agent 目录对应的注册表
+func (m *Manager) overlay(dir string) *Registry {
+	m.overlayMu.Lock()
+	defer m.overlayMu.Unlock()
+
+	if o, ok := m.overlays[dir]; ok && time.Since(o.loadedAt) < m.cfg.Agent.ReloadInterval {
+		return o.registry
+	}
+
+	registry := NewRegistry()
+	results, err := NewLoader(m.parser, registry).LoadFromDir(dir)
+	if err != nil {
+		klog.Errorf("[Manager] Failed to load workspace agents from dir %s: %v", dir, err)
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			klog.Errorf("[Manager] Failed to load workspace agent: %v", r.Error)
+		}
+	}
+	m.overlays[dir] = &overlayRegistry{registry: registry, loadedAt: time.Now()}
+	return registry
+}
+
+type pinnedAgentsKey struct{}
+
+// WithPinnedAgent 在 ctx 内用指定定义替换同名 Agent，例如按历史版本评测 Agent 时固定其配置
+func WithPinnedAgent(ctx context.Context, def *AgentDefinition) context.Context {
+	pinned := map[string]*AgentDefinition{}
+	if existing, ok := ctx.Value(pinnedAgentsKey{}).(map[string]*AgentDefinition); ok {
+		for name, d := range existing {
+			pinned[name] = d
+		}
+	}
+	pinned[def.Name] = def
+	return context.WithValue(ctx, pinnedAgentsKey{}, pinned)
+}
+
+// pinnedAgent 获取 ctx 中固定的 Agent 定义
+func pinnedAgent(ctx context.Context, name string) *AgentDefinition {
+	if ctx == nil {
+		return nil
+	}
+	pinned, _ := ctx.Value(pinnedAgentsKey{}).(map[string]*AgentDefinition)
+	return pinned[name]
+}
+
+// resolveDefinition 解析 Agent 定义：ctx 中固定的定义最优先，其次是工作空间目录中的同名定义，缺失时回退到全局目录
+func (m *Manager) resolveDefinition(ctx context.Context, name string) (*AgentDefinition, WorkspaceDirs, bool, error) {
+	dirs := resolveWorkspaceDirs(ctx)
+	if def := pinnedAgent(ctx, name); def != nil {
+		return def, dirs, true, nil
+	}
+	if dirs.AgentDir != "" {
+		if def, err := m.overlay(dirs.AgentDir).Get(name); err == nil {
+			return def, dirs, true, nil
+		}
+	}
+	def, err := m.registry.Get(name)
+	return def, dirs, false, err
+}
+
+// GetAgentFor 按 context 中的工作空间获取 Agent
+// 未覆盖时复用全局缓存；工作空间覆盖的 Agent 不缓存
+func (m *Manager) GetAgentFor(ctx context.Context, name string) (adk.Agent, error) {
+	dirs := resolveWorkspaceDirs(ctx)
+	if dirs.AgentDir == "" && dirs.SkillDir == "" && pinnedAgent(ctx, name) == nil {
+		return m.GetAgent(name)
+	}
+	return m.CreateAgentFor(ctx, name)
+}
+
+// CreateAgentFor 按 context 中的工作空间创建全新的 Agent 实例
+func (m *Manager) CreateAgentFor(ctx context.Context, name string) (adk.Agent, error) {
+	def, dirs, overridden, err := m.resolveDefinition(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if overridden {
+		klog.V(6).Infof("[Manager] Using workspace agent %s from %s", name, dirs.AgentDir)
+	}
+
+	agent, err := m.createADKAgent(def, dirs.SkillDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ADK agent: %w", err)
+	}
+	return agent, nil
+}
@@ -0,0 +1,263 @@
+// Package archive 安全地解压上传的代码压缩包或复制本地目录，用于导入没有远端地址的仓库。
+// 只还原普通文件与目录，拒绝越出目标目录的路径（zip-slip），跳过符号链接与 .git 目录，并限制总大小与文件数。
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的压缩包格式
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+var (
+	// ErrUnsupportedFormat 不支持的压缩包格式
+	ErrUnsupportedFormat = errors.New("unsupported archive format, expected .zip, .tar.gz or .tgz")
+	// ErrUnsafePath 条目路径越出目标目录
+	ErrUnsafePath = errors.New("archive entry escapes target directory")
+	// ErrTooLarge 解压或复制的总大小超过上限
+	ErrTooLarge = errors.New("content exceeds size limit")
+	// ErrTooManyFiles 文件数超过上限
+	ErrTooManyFiles = errors.New("content exceeds file count limit")
+)
+
+// Limits 解压与复制的限制，零值表示不限制
+type Limits struct {
+	MaxBytes int64 // 解压后文件总大小
+	MaxFiles int   // 文件数
+}
+
+// DetectFormat 根据文件名判断压缩包格式
+func DetectFormat(filename string) (string, error) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// TrimExt 去掉压缩包扩展名，例如 demo-1.0.tar.gz -> demo-1.0
+func TrimExt(filename string) string {
+	base := filepath.Base(filename)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if len(base) > len(ext) && strings.EqualFold(base[len(base)-len(ext):], ext) {
+			return base[:len(base)-len(ext)]
+		}
+	}
+	return base
+}
+
+// Extract 将压缩包解压到 dest，dest 不存在时创建
+func Extract(src, format, dest string, limits Limits) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	w := &writer{dest: dest, limits: limits}
+	switch format {
+	case FormatZip:
+		return w.extractZip(src)
+	case FormatTarGz:
+		return w.extractTarGz(src)
+	}
+	return ErrUnsupportedFormat
+}
+
+// CopyDir 将本地目录复制到 dest，规则与解压相同
+func CopyDir(src, dest string, limits Limits) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	w := &writer{dest: dest, limits: limits}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return w.mkdir(filepath.ToSlash(rel))
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return w.writeFile(filepath.ToSlash(rel), info.Mode(), f)
+	})
+}
+
+// SingleRoot 目录中只有一个子目录且没有文件时返回该子目录，用于去掉压缩包常见的顶层目录（如 demo-main/）
+func SingleRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+type writer struct {
+	dest   string
+	limits Limits
+	bytes  int64
+	files  int
+}
+
+func (w *writer) extractZip(src string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := w.mkdir(f.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("open zip entry %s: %w", f.Name, err)
+			}
+			err = w.writeFile(f.Name, mode, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *writer) extractTarGz(src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := w.mkdir(hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := w.writeFile(hdr.Name, hdr.FileInfo().Mode(), tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// target 校验条目路径并返回目标路径，skip 为 true 表示条目位于 .git 目录内，应跳过
+func (w *writer) target(name string) (string, bool, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" {
+		return "", false, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+		}
+		// 不还原 .git 目录，避免携带 hooks 等配置在后续 git 命令中执行
+		if part == ".git" {
+			return "", true, nil
+		}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." {
+		return "", true, nil
+	}
+	target := filepath.Join(w.dest, filepath.FromSlash(cleaned))
+	if rel, err := filepath.Rel(w.dest, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return target, false, nil
+}
+
+func (w *writer) mkdir(name string) error {
+	target, skip, err := w.target(name)
+	if err != nil || skip {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+func (w *writer) writeFile(name string, mode fs.FileMode, r io.Reader) error {
+	target, skip, err := w.target(name)
+	if err != nil || skip {
+		return err
+	}
+	w.files++
+	if w.limits.MaxFiles > 0 && w.files > w.limits.MaxFiles {
+		return fmt.Errorf("%w: max %d files", ErrTooManyFiles, w.limits.MaxFiles)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if mode&0111 != 0 {
+		perm = 0755
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// 按实际写入的字节计数，不信任压缩包头中声明的大小
+	src := r
+	if w.limits.MaxBytes > 0 {
+		src = io.LimitReader(r, w.limits.MaxBytes-w.bytes+1)
+	}
+	n, err := io.Copy(out, src)
+	w.bytes += n
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if w.limits.MaxBytes > 0 && w.bytes > w.limits.MaxBytes {
+		return fmt.Errorf("%w: max %d bytes", ErrTooLarge, w.limits.MaxBytes)
+	}
+	return nil
+}
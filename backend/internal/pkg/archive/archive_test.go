@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	name    string
+	content string
+	symlink bool
+}
+
+func writeZip(t *testing.T, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "src.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("create zip error: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatalf("zip entry error: %v", err)
+		}
+		w.Write([]byte(e.content))
+	}
+	zw.Close()
+	f.Close()
+	return p
+}
+
+func writeTarGz(t *testing.T, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "src.tar.gz")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("create tar error: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.symlink {
+			hdr = &tar.Header{Name: e.name, Linkname: e.content, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("tar header error: %v", err)
+		}
+		if !e.symlink {
+			tw.Write([]byte(e.content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+	return p
+}
+
+func TestExtract(t *testing.T) {
+	entries := []entry{
+		{name: "demo-main/main.go", content: "package main"},
+		{name: "demo-main/pkg/util.go", content: "package pkg"},
+		{name: "demo-main/.git/hooks/post-commit", content: "#!/bin/sh\ntouch pwned"},
+	}
+	for format, src := range map[string]string{FormatZip: writeZip(t, entries), FormatTarGz: writeTarGz(t, entries)} {
+		dest := t.TempDir()
+		if err := Extract(src, format, dest, Limits{}); err != nil {
+			t.Fatalf("%s extract error: %v", format, err)
+		}
+		root := SingleRoot(dest)
+		if filepath.Base(root) != "demo-main" {
+			t.Fatalf("%s single root = %s", format, root)
+		}
+		if data, err := os.ReadFile(filepath.Join(root, "pkg", "util.go")); err != nil || string(data) != "package pkg" {
+			t.Fatalf("%s util.go = %q, %v", format, data, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, ".git")); !os.IsNotExist(err) {
+			t.Fatalf("%s .git directory must not be extracted", format)
+		}
+	}
+}
+
+func TestExtractRejectsUnsafeEntries(t *testing.T) {
+	cases := map[string]string{
+		"zip parent": writeZip(t, []entry{{name: "../evil.txt", content: "x"}}),
+		"zip nested": writeZip(t, []entry{{name: "a/../../evil.txt", content: "x"}}),
+		"zip abs":    writeZip(t, []entry{{name: "/tmp/evil.txt", content: "x"}}),
+		"tar parent": writeTarGz(t, []entry{{name: "../evil.txt", content: "x"}}),
+	}
+	for name, src := range cases {
+		format, _ := DetectFormat(src)
+		dest := filepath.Join(t.TempDir(), "out")
+		if err := Extract(src, format, dest, Limits{}); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("%s: error = %v, want ErrUnsafePath", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); !os.IsNotExist(err) {
+			t.Errorf("%s: file written outside target", name)
+		}
+	}
+
+	// 符号链接被跳过，不会指向目标目录之外
+	dest := t.TempDir()
+	src := writeTarGz(t, []entry{{name: "link", content: "/etc/passwd", symlink: true}, {name: "ok.txt", content: "ok"}})
+	if err := Extract(src, FormatTarGz, dest, Limits{}); err != nil {
+		t.Fatalf("extract error: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+		t.Fatalf("symlink must be skipped")
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	src := writeZip(t, []entry{{name: "a.txt", content: strings.Repeat("a", 100)}, {name: "b.txt", content: strings.Repeat("b", 100)}})
+	if err := Extract(src, FormatZip, t.TempDir(), Limits{MaxBytes: 150}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if err := Extract(src, FormatZip, t.TempDir(), Limits{MaxFiles: 1}); !errors.Is(err, ErrTooManyFiles) {
+		t.Fatalf("expected ErrTooManyFiles, got %v", err)
+	}
+	if err := Extract(src, FormatZip, t.TempDir(), Limits{MaxBytes: 200, MaxFiles: 2}); err != nil {
+		t.Fatalf("within limits: %v", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for name, want := range map[string]string{"a.zip": FormatZip, "a.TAR.GZ": FormatTarGz, "a.tgz": FormatTarGz} {
+		if got, err := DetectFormat(name); err != nil || got != want {
+			t.Errorf("DetectFormat(%s) = %s, %v", name, got, err)
+		}
+	}
+	if _, err := DetectFormat("a.rar"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("rar should be unsupported")
+	}
+	if got := TrimExt("demo-1.0.tar.gz"); got != "demo-1.0" {
+		t.Errorf("TrimExt = %s", got)
+	}
+}
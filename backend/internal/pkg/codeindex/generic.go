@@ -0,0 +1,166 @@
+package codeindex
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// symbolPattern ctags 风格的单行符号匹配规则，name 为符号名所在的捕获组
+type symbolPattern struct {
+	kind string
+	re   *regexp.Regexp
+	name int
+}
+
+func pattern(kind, expr string) symbolPattern {
+	return symbolPattern{kind: kind, re: regexp.MustCompile(expr), name: 1}
+}
+
+// 各语言的启发式规则，按顺序匹配，每行只取第一个命中的规则
+var languagePatterns = map[string][]symbolPattern{
+	"python": {
+		pattern(KindClass, `^\s*class\s+([A-Za-z_]\w*)`),
+		pattern(KindFunction, `^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`),
+	},
+	"javascript": {
+		pattern(KindClass, `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`),
+		pattern(KindInterface, `^\s*(?:export\s+)?interface\s+([A-Za-z_$][\w$]*)`),
+		pattern(KindType, `^\s*(?:export\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`),
+		pattern(KindEnum, `^\s*(?:export\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`),
+		pattern(KindFunction, `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`),
+		pattern(KindFunction, `^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`),
+		pattern(KindMethod, `^\s+(?:(?:public|private|protected|static|async|readonly|override|get|set)\s+)*([A-Za-z_$][\w$]*)\s*\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$`),
+	},
+	"java": {
+		pattern(KindClass, `^\s*(?:(?:public|private|protected|static|final|abstract|sealed|open|data|internal)\s+)*(?:class|record|object)\s+([A-Za-z_]\w*)`),
+		pattern(KindInterface, `^\s*(?:(?:public|private|protected|static|sealed|internal)\s+)*(?:interface|@interface)\s+([A-Za-z_]\w*)`),
+		pattern(KindEnum, `^\s*(?:(?:public|private|protected|static|internal)\s+)*enum\s+(?:class\s+)?([A-Za-z_]\w*)`),
+		pattern(KindFunction, `^\s*(?:(?:public|private|protected|internal|override|suspend|inline)\s+)*fun\s+(?:<[^>]+>\s*)?(?:[\w.]+\.)?([A-Za-z_]\w*)\s*\(`),
+		pattern(KindMethod, `^\s*(?:(?:public|private|protected|internal|static|final|abstract|synchronized|native|override|virtual|async|default)\s+)+(?:<[^>]+>\s+)?[\w<>\[\],.?]+\s+([A-Za-z_]\w*)\s*\(`),
+	},
+	"rust": {
+		pattern(KindStruct, `^\s*(?:pub(?:\([^)]*\))?\s+)?struct\s+([A-Za-z_]\w*)`),
+		pattern(KindEnum, `^\s*(?:pub(?:\([^)]*\))?\s+)?enum\s+([A-Za-z_]\w*)`),
+		pattern(KindInterface, `^\s*(?:pub(?:\([^)]*\))?\s+)?trait\s+([A-Za-z_]\w*)`),
+		pattern(KindType, `^\s*(?:pub(?:\([^)]*\))?\s+)?type\s+([A-Za-z_]\w*)`),
+		pattern(KindModule, `^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+([A-Za-z_]\w*)`),
+		pattern(KindFunction, `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+([A-Za-z_]\w*)`),
+	},
+	"c": {
+		pattern(KindClass, `^\s*(?:template\s*<[^>]*>\s*)?class\s+([A-Za-z_]\w*)\s*(?:final\s*)?[:{]?\s*$`),
+		pattern(KindStruct, `^\s*(?:typedef\s+)?struct\s+([A-Za-z_]\w*)\s*\{?\s*$`),
+		pattern(KindEnum, `^\s*(?:typedef\s+)?enum\s+(?:class\s+)?([A-Za-z_]\w*)`),
+		pattern(KindFunction, `^(?:[A-Za-z_][\w:<>,*&\s]*\s+[*&]*)([A-Za-z_][\w:~]*)\s*\([^;]*$`),
+	},
+	"ruby": {
+		pattern(KindClass, `^\s*class\s+([A-Z]\w*(?:::\w+)*)`),
+		pattern(KindModule, `^\s*module\s+([A-Z]\w*(?:::\w+)*)`),
+		pattern(KindMethod, `^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`),
+	},
+	"php": {
+		pattern(KindClass, `^\s*(?:(?:abstract|final|readonly)\s+)*class\s+([A-Za-z_]\w*)`),
+		pattern(KindInterface, `^\s*(?:interface|trait)\s+([A-Za-z_]\w*)`),
+		pattern(KindFunction, `^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?([A-Za-z_]\w*)`),
+	},
+}
+
+// 扩展名到语言的映射
+var languageByExt = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "javascript",
+	".tsx":   "javascript",
+	".vue":   "javascript",
+	".java":  "java",
+	".kt":    "java",
+	".scala": "java",
+	".cs":    "java",
+	".rs":    "rust",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "c",
+	".cpp":   "c",
+	".hpp":   "c",
+	".rb":    "ruby",
+	".php":   "php",
+}
+
+// 启发式规则容易误判为函数的关键字
+var keywordNames = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"function": true, "new": true, "else": true, "sizeof": true, "do": true,
+}
+
+// LanguageOf 根据扩展名判断语言，不支持时返回空字符串
+func LanguageOf(path string) string {
+	return languageByExt[strings.ToLower(filepath.Ext(path))]
+}
+
+// parseGeneric 按行匹配启发式规则提取符号
+func parseGeneric(path, language string, src []byte) []Symbol {
+	patterns := languagePatterns[language]
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var symbols []Symbol
+	for i, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		for _, p := range patterns {
+			match := p.re.FindStringSubmatch(line)
+			if match == nil || keywordNames[match[p.name]] {
+				continue
+			}
+			kind := p.kind
+			// 缩进的 def 视为类方法
+			if language == "python" && kind == KindFunction && strings.TrimLeft(line, " \t") != line {
+				kind = KindMethod
+			}
+			symbols = append(symbols, Symbol{
+				Name:      match[p.name],
+				Kind:      kind,
+				Path:      path,
+				Line:      i + 1,
+				Signature: truncateSignature(strings.TrimSuffix(trimmed, "{")),
+				Language:  language,
+			})
+			break
+		}
+	}
+	return symbols
+}
+
+// textIdentifiers 返回文本中指定标识符按单词边界出现的位置
+func textIdentifiers(src []byte, name string) [][2]int {
+	re, err := regexp.Compile(`(^|[^\w$])` + regexp.QuoteMeta(name) + `($|[^\w$])`)
+	if err != nil {
+		return nil
+	}
+	var positions [][2]int
+	for i, line := range strings.Split(string(src), "\n") {
+		if !strings.Contains(line, name) {
+			continue
+		}
+		offset := 0
+		for {
+			loc := re.FindStringSubmatchIndex(line[offset:])
+			if loc == nil {
+				break
+			}
+			start := offset + loc[3] // 前导字符之后
+			positions = append(positions, [2]int{i + 1, start + 1})
+			offset = start + len(name)
+			if offset >= len(line) {
+				break
+			}
+		}
+	}
+	return positions
+}
@@ -0,0 +1,157 @@
+package codeindex
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// maxSignatureLength 签名展示的最大字符数
+const maxSignatureLength = 200
+
+// parseGo 使用 go/ast 解析 Go 源文件中的符号
+func parseGo(path string, src []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil && file == nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	add := func(name, kind, container string, node ast.Node, signature string) {
+		if name == "" || name == "_" {
+			return
+		}
+		symbols = append(symbols, Symbol{
+			Name:      name,
+			Kind:      kind,
+			Container: container,
+			Path:      path,
+			Line:      fset.Position(node.Pos()).Line,
+			EndLine:   fset.Position(node.End()).Line,
+			Signature: truncateSignature(signature),
+			Language:  "go",
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind, container := KindFunction, ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				kind, container = KindMethod, receiverName(d.Recv.List[0].Type)
+			}
+			add(d.Name.Name, kind, container, d, funcSignature(fset, d))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := KindType
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						kind = KindStruct
+						for _, field := range t.Fields.List {
+							for _, name := range field.Names {
+								add(name.Name, KindField, s.Name.Name, field, name.Name+" "+nodeString(fset, field.Type))
+							}
+						}
+					case *ast.InterfaceType:
+						kind = KindInterface
+						for _, method := range t.Methods.List {
+							for _, name := range method.Names {
+								add(name.Name, KindMethod, s.Name.Name, method, name.Name+strings.TrimPrefix(nodeString(fset, method.Type), "func"))
+							}
+						}
+					}
+					add(s.Name.Name, kind, "", s, "type "+s.Name.Name+" "+typeSummary(fset, s.Type))
+				case *ast.ValueSpec:
+					kind := KindVar
+					if d.Tok == token.CONST {
+						kind = KindConst
+					}
+					for _, name := range s.Names {
+						signature := d.Tok.String() + " " + name.Name
+						if s.Type != nil {
+							signature += " " + nodeString(fset, s.Type)
+						}
+						add(name.Name, kind, "", s, signature)
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].Line < symbols[j].Line })
+	return symbols, nil
+}
+
+// goIdentifiers 返回 Go 源文件中指定标识符出现的位置，忽略注释与字符串
+func goIdentifiers(src []byte, name string) [][2]int {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	var positions [][2]int
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT && lit == name {
+			position := fset.Position(pos)
+			positions = append(positions, [2]int{position.Line, position.Column})
+		}
+	}
+	return positions
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	}
+	return ""
+}
+
+func funcSignature(fset *token.FileSet, decl *ast.FuncDecl) string {
+	copied := *decl
+	copied.Body = nil
+	copied.Doc = nil
+	return nodeString(fset, &copied)
+}
+
+func typeSummary(fset *token.FileSet, expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	return nodeString(fset, expr)
+}
+
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func truncateSignature(signature string) string {
+	if runes := []rune(signature); len(runes) > maxSignatureLength {
+		return string(runes[:maxSignatureLength]) + "..."
+	}
+	return signature
+}
@@ -0,0 +1,257 @@
+package codeindex
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 索引构建参数
+const (
+	MaxFileSize = 1 << 20 // 超过该大小的文件不参与索引
+	MaxFiles    = 20000   // 单个仓库最多索引的文件数
+)
+
+// 构建索引时跳过的目录
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+// Index 单个仓库的符号索引，构建后只读，并发安全
+type Index struct {
+	Root    string
+	BuiltAt time.Time
+
+	files   []string         // 已索引的源码文件（相对路径）
+	symbols []Symbol         // 按路径、行号排序
+	byName  map[string][]int // 小写符号名 -> symbols 下标
+}
+
+// Build 遍历仓库目录构建符号索引：Go 使用 go/ast 解析，其他语言使用启发式规则
+func Build(root string) (*Index, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	idx := &Index{Root: root, BuiltAt: time.Now(), byName: make(map[string][]int)}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || LanguageOf(path) == "" {
+			return nil
+		}
+		if len(idx.files) >= MaxFiles {
+			return fs.SkipAll
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		symbols, err := ParseFile(path, filepath.ToSlash(rel))
+		if err != nil {
+			return nil
+		}
+		idx.files = append(idx.files, filepath.ToSlash(rel))
+		idx.symbols = append(idx.symbols, symbols...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, symbol := range idx.symbols {
+		key := strings.ToLower(symbol.Name)
+		idx.byName[key] = append(idx.byName[key], i)
+	}
+	return idx, nil
+}
+
+// ParseFile 解析单个源码文件的符号，rel 为记录在符号中的路径
+func ParseFile(path, rel string) ([]Symbol, error) {
+	language := LanguageOf(path)
+	if language == "" {
+		return nil, fmt.Errorf("unsupported file type: %s", filepath.Ext(path))
+	}
+	src, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+	if language == "go" {
+		return parseGo(rel, src)
+	}
+	return parseGeneric(rel, language, src), nil
+}
+
+func readSource(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxFileSize {
+		return nil, fmt.Errorf("file too large: %d bytes", info.Size())
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(src, 0) >= 0 {
+		return nil, fmt.Errorf("binary file")
+	}
+	return src, nil
+}
+
+// FileCount 返回已索引的文件数
+func (idx *Index) FileCount() int {
+	return len(idx.files)
+}
+
+// SymbolCount 返回已索引的符号数
+func (idx *Index) SymbolCount() int {
+	return len(idx.symbols)
+}
+
+// SymbolQuery 符号查询条件
+type SymbolQuery struct {
+	Name  string
+	Kind  string // 为空表示不限类型
+	Exact bool   // true 时名称需完全一致（区分大小写），否则不区分大小写的子串匹配
+	Limit int
+}
+
+// FindSymbols 按名称查找符号定义，完全匹配的排在前面
+func (idx *Index) FindSymbols(query SymbolQuery) []Symbol {
+	name := strings.TrimSpace(query.Name)
+	if name == "" {
+		return nil
+	}
+	// 支持 Type.Method 形式
+	container := ""
+	if i := strings.LastIndex(name, "."); i > 0 && i < len(name)-1 {
+		container, name = name[:i], name[i+1:]
+	}
+	lower := strings.ToLower(name)
+
+	var candidates []int
+	if query.Exact {
+		candidates = idx.byName[lower]
+	} else {
+		for key, ids := range idx.byName {
+			if strings.Contains(key, lower) {
+				candidates = append(candidates, ids...)
+			}
+		}
+	}
+
+	var result []Symbol
+	for _, i := range candidates {
+		symbol := idx.symbols[i]
+		if query.Exact && symbol.Name != name {
+			continue
+		}
+		if query.Kind != "" && symbol.Kind != query.Kind {
+			continue
+		}
+		if container != "" && !strings.EqualFold(symbol.Container, container) {
+			continue
+		}
+		result = append(result, symbol)
+	}
+
+	rank := func(s Symbol) int {
+		switch {
+		case s.Name == name:
+			return 0
+		case strings.EqualFold(s.Name, name):
+			return 1
+		case strings.HasPrefix(strings.ToLower(s.Name), lower):
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := rank(result[i]), rank(result[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Line < result[j].Line
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result
+}
+
+// FindReferences 查找标识符在仓库源码中出现的位置
+// Go 文件按词法单元匹配（忽略注释与字符串），其他语言按单词边界匹配；返回结果与是否被截断
+func (idx *Index) FindReferences(name string, limit int) ([]Reference, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+	definitions := make(map[string]bool)
+	for _, i := range idx.byName[strings.ToLower(name)] {
+		symbol := idx.symbols[i]
+		if symbol.Name == name {
+			definitions[fmt.Sprintf("%s:%d", symbol.Path, symbol.Line)] = true
+		}
+	}
+
+	var refs []Reference
+	for _, rel := range idx.files {
+		src, err := readSource(filepath.Join(idx.Root, filepath.FromSlash(rel)))
+		if err != nil || !bytes.Contains(src, []byte(name)) {
+			continue
+		}
+		var positions [][2]int
+		if LanguageOf(rel) == "go" {
+			positions = goIdentifiers(src, name)
+		} else {
+			positions = textIdentifiers(src, name)
+		}
+		if len(positions) == 0 {
+			continue
+		}
+		lines := strings.Split(string(src), "\n")
+		for _, pos := range positions {
+			if limit > 0 && len(refs) >= limit {
+				return refs, true
+			}
+			text := ""
+			if pos[0]-1 < len(lines) {
+				text = truncateSignature(strings.TrimSpace(lines[pos[0]-1]))
+			}
+			refs = append(refs, Reference{
+				Path:       rel,
+				Line:       pos[0],
+				Column:     pos[1],
+				Text:       text,
+				Definition: definitions[fmt.Sprintf("%s:%d", rel, pos[0])],
+			})
+		}
+	}
+	return refs, false
+}
@@ -0,0 +1,162 @@
+package codeindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+}
+
+const goSource = `package store
+
+// Store 存储
+type Store struct {
+	Name string
+}
+
+type Reader interface {
+	Read(id int) (string, error)
+}
+
+const MaxSize = 10
+
+// Open 打开存储，"Open" 在字符串中不算引用
+func (s *Store) Open(path string) error {
+	return nil
+}
+
+func NewStore() *Store {
+	s := &Store{}
+	_ = s.Open("x")
+	return s
+}
+`
+
+// TestBuildAndFindSymbols 验证 Go 与启发式解析的符号定义
+func TestBuildAndFindSymbols(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "store/store.go", goSource)
+	writeFile(t, root, "app/main.py", "class Server:\n    def open(self):\n        pass\n\ndef open_server():\n    return Server()\n")
+	writeFile(t, root, "web/api.ts", "export interface Options {}\nexport function openApi(opts: Options) {\n}\nexport const closeApi = async () => {}\n")
+	writeFile(t, root, "node_modules/lib/index.js", "function Open() {}\n")
+
+	idx, err := Build(root)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	if idx.FileCount() != 3 {
+		t.Fatalf("expected 3 files, got %d", idx.FileCount())
+	}
+
+	symbols := idx.FindSymbols(SymbolQuery{Name: "Store.Open"})
+	if len(symbols) != 1 || symbols[0].Kind != KindMethod || symbols[0].Line != 15 || symbols[0].EndLine != 17 {
+		t.Fatalf("unexpected method symbol: %+v", symbols)
+	}
+	if symbols[0].Signature != "func (s *Store) Open(path string) error" {
+		t.Fatalf("unexpected signature: %q", symbols[0].Signature)
+	}
+
+	symbols = idx.FindSymbols(SymbolQuery{Name: "open"})
+	if len(symbols) < 4 || symbols[0].Name != "open" || symbols[0].Kind != KindMethod || symbols[0].Language != "python" {
+		t.Fatalf("expected exact-case match first: %+v", symbols)
+	}
+
+	if symbols := idx.FindSymbols(SymbolQuery{Name: "Read", Kind: KindMethod, Exact: true}); len(symbols) != 1 || symbols[0].Container != "Reader" {
+		t.Fatalf("unexpected interface method: %+v", symbols)
+	}
+	if symbols := idx.FindSymbols(SymbolQuery{Name: "closeApi", Exact: true}); len(symbols) != 1 || symbols[0].Kind != KindFunction {
+		t.Fatalf("unexpected arrow function: %+v", symbols)
+	}
+	if symbols := idx.FindSymbols(SymbolQuery{Name: "Options", Kind: KindInterface}); len(symbols) != 1 {
+		t.Fatalf("unexpected interface: %+v", symbols)
+	}
+}
+
+// TestFindReferences 验证 Go 引用忽略注释与字符串，并标记定义处
+func TestFindReferences(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "store.go", goSource)
+	writeFile(t, root, "main.py", "store.Open()\nOpenFile()\n")
+
+	idx, err := Build(root)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	refs, truncated := idx.FindReferences("Open", 0)
+	if truncated {
+		t.Fatalf("unexpected truncation")
+	}
+	// store.go: 定义处与调用处；注释和字符串中的 Open 被忽略。main.py: 仅 store.Open
+	if len(refs) != 3 {
+		t.Fatalf("expected 3 references, got %+v", refs)
+	}
+	definitions := 0
+	for _, ref := range refs {
+		if ref.Definition {
+			definitions++
+		}
+	}
+	if definitions != 1 {
+		t.Fatalf("expected 1 definition, got %d", definitions)
+	}
+	if refs, truncated := idx.FindReferences("Open", 1); len(refs) != 1 || !truncated {
+		t.Fatalf("expected truncated result, got %d, %v", len(refs), truncated)
+	}
+}
+
+// TestStoreInvalidate 验证缓存与失效
+func TestStoreInvalidate(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a\nfunc A() {}\n")
+	store := NewStore(0)
+
+	first, err := store.Get(root)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if second, _ := store.Get(root); second != first {
+		t.Fatalf("expected cached index")
+	}
+
+	writeFile(t, root, "b.go", "package a\nfunc B() {}\n")
+	store.Invalidate(root)
+	rebuilt, _ := store.Get(root)
+	if rebuilt == first || len(rebuilt.FindSymbols(SymbolQuery{Name: "B", Exact: true})) != 1 {
+		t.Fatalf("expected rebuilt index to contain B")
+	}
+}
+
+// TestStoreEvictsExpiredRoots 访问任一仓库时清理其他已过期的索引与构建锁
+func TestStoreEvictsExpiredRoots(t *testing.T) {
+	oldRoot, newRoot := t.TempDir(), t.TempDir()
+	writeFile(t, oldRoot, "a.go", "package a\nfunc A() {}\n")
+	writeFile(t, newRoot, "a.go", "package a\nfunc A() {}\n")
+	store := NewStore(time.Minute)
+
+	if _, err := store.Get(oldRoot); err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	store.indexes[filepath.Clean(oldRoot)].BuiltAt = time.Now().Add(-time.Hour)
+	if _, err := store.Get(newRoot); err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if len(store.indexes) != 1 || len(store.locks) != 1 {
+		t.Fatalf("expired root should be evicted, indexes=%d locks=%d", len(store.indexes), len(store.locks))
+	}
+
+	store.Invalidate(newRoot)
+	if len(store.indexes) != 0 || len(store.locks) != 0 {
+		t.Fatalf("invalidate should drop index and lock, indexes=%d locks=%d", len(store.indexes), len(store.locks))
+	}
+}
@@ -0,0 +1,111 @@
+package codeindex
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultMaxAge 索引的最长有效期，超过后下次访问时重建
+const DefaultMaxAge = 10 * time.Minute
+
+// Store 按仓库根目录缓存符号索引
+type Store struct {
+	mutex   sync.Mutex
+	maxAge  time.Duration
+	indexes map[string]*Index
+	locks   map[string]*sync.Mutex // 每个仓库一把构建锁，避免并发重复构建
+}
+
+// NewStore 创建索引缓存，maxAge<=0 时使用 DefaultMaxAge
+func NewStore(maxAge time.Duration) *Store {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Store{
+		maxAge:  maxAge,
+		indexes: make(map[string]*Index),
+		locks:   make(map[string]*sync.Mutex),
+	}
+}
+
+var defaultStore = NewStore(0)
+
+// DefaultStore 返回全局索引缓存，供克隆流程预热与 Agent 工具查询共用
+func DefaultStore() *Store {
+	return defaultStore
+}
+
+// Get 返回仓库的符号索引，不存在或已过期时同步构建
+// 同时清理其他已过期的索引，重新克隆或引用检出产生的旧目录不会一直占用内存
+func (s *Store) Get(root string) (*Index, error) {
+	root = filepath.Clean(root)
+
+	s.mutex.Lock()
+	s.evictExpired(root, time.Now())
+	lock, ok := s.locks[root]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[root] = lock
+	}
+	s.mutex.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	s.mutex.Lock()
+	idx := s.indexes[root]
+	s.mutex.Unlock()
+	if idx != nil && time.Since(idx.BuiltAt) < s.maxAge {
+		return idx, nil
+	}
+
+	start := time.Now()
+	idx, err := Build(root)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.indexes[root] = idx
+	s.mutex.Unlock()
+	klog.V(6).Infof("符号索引构建完成: root=%s, files=%d, symbols=%d, elapsed=%s", root, idx.FileCount(), idx.SymbolCount(), time.Since(start))
+	return idx, nil
+}
+
+// Warm 在后台构建仓库索引，用于克隆或更新完成后预热
+func (s *Store) Warm(root string) {
+	s.Invalidate(root)
+	go func() {
+		if _, err := s.Get(root); err != nil {
+			klog.V(6).Infof("符号索引预热失败: root=%s, error=%v", root, err)
+		}
+	}()
+}
+
+// Invalidate 丢弃仓库的缓存索引
+func (s *Store) Invalidate(root string) {
+	root = filepath.Clean(root)
+	s.mutex.Lock()
+	s.drop(root)
+	s.mutex.Unlock()
+}
+
+// evictExpired 丢弃除 keep 外已过期的索引，调用方需持有 s.mutex
+func (s *Store) evictExpired(keep string, now time.Time) {
+	for root, idx := range s.indexes {
+		if root != keep && now.Sub(idx.BuiltAt) >= s.maxAge {
+			s.drop(root)
+		}
+	}
+}
+
+// drop 删除索引及空闲的构建锁，正在构建的仓库保留锁，调用方需持有 s.mutex
+func (s *Store) drop(root string) {
+	delete(s.indexes, root)
+	if lock, ok := s.locks[root]; ok && lock.TryLock() {
+		delete(s.locks, root)
+		lock.Unlock()
+	}
+}
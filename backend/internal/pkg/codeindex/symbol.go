@@ -0,0 +1,37 @@
+package codeindex
+
+// 符号类型
+const (
+	KindFunction  = "function"
+	KindMethod    = "method"
+	KindType      = "type"
+	KindStruct    = "struct"
+	KindInterface = "interface"
+	KindClass     = "class"
+	KindEnum      = "enum"
+	KindModule    = "module"
+	KindConst     = "const"
+	KindVar       = "var"
+	KindField     = "field"
+)
+
+// Symbol 源码中定义的符号
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Container string `json:"container,omitempty"` // 所属类型，如方法的接收者、字段所在结构体
+	Path      string `json:"path"`                // 相对仓库根目录的路径，使用 / 分隔
+	Line      int    `json:"line"`
+	EndLine   int    `json:"end_line,omitempty"` // 0 表示未知（非 Go 语言的启发式解析）
+	Signature string `json:"signature"`
+	Language  string `json:"language"`
+}
+
+// Reference 符号名在源码中出现的位置
+type Reference struct {
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Text       string `json:"text"`       // 所在行内容
+	Definition bool   `json:"definition"` // 是否为符号定义处
+}
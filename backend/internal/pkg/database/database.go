@@ -44,12 +44,61 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}, &model.DocumentRating{}, &model.TaskHint{}, &model.TaskUsage{}, &model.SyncTarget{}, &model.SyncEvent{}, &model.IncrementalUpdateHistory{}, &model.UserRequest{}, &model.AgentVersion{}); err != nil {
+	// 同步目标地址改为按工作空间唯一，先移除旧的全局唯一索引
+	if db.Migrator().HasTable(&model.SyncTarget{}) && db.Migrator().HasIndex(&model.SyncTarget{}, "idx_sync_targets_url") {
+		if err := db.Migrator().DropIndex(&model.SyncTarget{}, "idx_sync_targets_url"); err != nil {
+			return nil, err
+		}
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}, &model.DocumentRating{}, &model.TaskHint{}, &model.TaskUsage{}, &model.TaskTrace{}, &model.SyncTarget{}, &model.SyncEvent{}, &model.IncrementalUpdateHistory{}, &model.UserRequest{}, &model.AgentVersion{}); err != nil {
 		return nil, err
 	}
 	if err := db.AutoMigrate(&model.APIKey{}); err != nil {
 		return nil, err
 	}
+	// 迁移编排器持久化队列表
+	if err := db.AutoMigrate(&model.TaskJob{}); err != nil {
+		return nil, err
+	}
+	// 迁移任务依赖表
+	if err := db.AutoMigrate(&model.TaskDependency{}); err != nil {
+		return nil, err
+	}
+	// 迁移向量检索相关表
+	if err := db.AutoMigrate(&model.EmbeddingProvider{}, &model.EmbeddingChunk{}); err != nil {
+		return nil, err
+	}
+	// 迁移用户与认证相关表
+	if err := db.AutoMigrate(&model.User{}, &model.APIToken{}, &model.UserSession{}); err != nil {
+		return nil, err
+	}
+	// 迁移工作空间相关表，并确保默认工作空间存在
+	if err := db.AutoMigrate(&model.Workspace{}, &model.WorkspaceMember{}); err != nil {
+		return nil, err
+	}
+	if err := db.FirstOrCreate(&model.Workspace{}, model.Workspace{ID: model.DefaultWorkspaceID, Name: "Default", Slug: "default"}).Error; err != nil {
+		return nil, err
+	}
+	// 迁移 Git 凭证表
+	if err := db.AutoMigrate(&model.GitCredential{}); err != nil {
+		return nil, err
+	}
+	// 迁移引用文档集表
+	if err := db.AutoMigrate(&model.RepositoryRef{}); err != nil {
+		return nil, err
+	}
+	// 迁移仓库推送 Webhook 表
+	if err := db.AutoMigrate(&model.RepositoryWebhook{}); err != nil {
+		return nil, err
+	}
+	// 迁移 Agent 评测表
+	if err := db.AutoMigrate(&model.EvalRun{}, &model.EvalResult{}); err != nil {
+		return nil, err
+	}
+	// 迁移审计日志表
+	if err := db.AutoMigrate(&model.AuditLog{}); err != nil {
+		return nil, err
+	}
 	// 迁移对话相关表
 	if err := db.AutoMigrate(&model.ChatSession{}, &model.ChatMessage{}, &model.ChatToolCall{}); err != nil {
 		return nil, err
@@ -0,0 +1,118 @@
+package docindex
+
+import (
+	"strings"
+)
+
+// DefaultChunkSize 单个分块的最大字符数（按 rune 计）
+const DefaultChunkSize = 800
+
+// Chunk 文档分块
+type Chunk struct {
+	Heading string // 所在章节的标题路径，如 "架构 > 任务编排"
+	Text    string
+}
+
+// ChunkMarkdown 按 Markdown 标题切分文档，超长章节再按段落切分
+// 代码块内的 # 不视为标题
+func ChunkMarkdown(content string, maxRunes int) []Chunk {
+	if maxRunes <= 0 {
+		maxRunes = DefaultChunkSize
+	}
+
+	var chunks []Chunk
+	var headings []string // 按级别记录的标题栈
+	var section []string
+	inFence := false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(section, "\n"))
+		section = section[:0]
+		if text == "" {
+			return
+		}
+		heading := strings.Join(nonEmpty(headings), " > ")
+		for _, part := range splitBySize(text, maxRunes) {
+			chunks = append(chunks, Chunk{Heading: heading, Text: part})
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if level, title := parseHeading(trimmed); level > 0 {
+				flush()
+				for len(headings) < level {
+					headings = append(headings, "")
+				}
+				headings = append(headings[:level-1], title)
+				continue
+			}
+		}
+		section = append(section, line)
+	}
+	flush()
+	return chunks
+}
+
+// parseHeading 解析 ATX 标题，返回级别与标题文本；非标题返回 0
+func parseHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+}
+
+// splitBySize 按空行分段合并为不超过 maxRunes 的片段，单段过长时硬切分
+func splitBySize(text string, maxRunes int) []string {
+	if len([]rune(text)) <= maxRunes {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	currentLen := 0
+	emit := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			parts = append(parts, s)
+		}
+		current.Reset()
+		currentLen = 0
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		runes := []rune(paragraph)
+		if currentLen > 0 && currentLen+len(runes) > maxRunes {
+			emit()
+		}
+		for len(runes) > maxRunes {
+			current.WriteString(string(runes[:maxRunes]))
+			emit()
+			runes = runes[maxRunes:]
+		}
+		if currentLen > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(string(runes))
+		currentLen += len(runes)
+	}
+	emit()
+	return parts
+}
+
+func nonEmpty(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,324 @@
+package docindex
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	snippetLength = 200
+)
+
+// DocMeta 被索引文档的元信息，用于过滤与展示
+type DocMeta struct {
+	DocID        uint
+	RepositoryID uint
+	Ref          string // 所属引用文档集，为空表示仓库主文档集
+	Title        string
+	Filename     string
+	Version      int
+	IsLatest     bool
+}
+
+// Query 检索条件
+type Query struct {
+	Text         string
+	RepositoryID uint   // 0 表示不限仓库
+	Ref          string // 引用文档集，为空表示仓库主文档集
+	Version      int    // 0 表示只检索各文档的最新版本，>0 检索指定版本号
+	Title        string // 标题包含该字符串（不区分大小写）
+	Limit        int    // 返回文档数上限，<=0 不限制
+}
+
+// Hit 检索命中的文档，附带得分最高的分块
+type Hit struct {
+	DocMeta
+	Score   float64
+	Heading string
+	Snippet string
+}
+
+type indexedDoc struct {
+	meta     DocMeta
+	chunkIDs []int
+}
+
+type indexedChunk struct {
+	docID   uint
+	heading string
+	text    string
+	length  int
+	terms   map[string]int
+}
+
+// Index 文档分块的 BM25 倒排索引，内存存储，并发安全
+type Index struct {
+	mutex       sync.RWMutex
+	chunkSize   int
+	docs        map[uint]*indexedDoc
+	chunks      map[int]*indexedChunk
+	postings    map[string]map[int]int // 词 -> 分块ID -> 词频
+	nextChunkID int
+	totalLength int
+}
+
+// NewIndex 创建空索引，chunkSize<=0 时使用 DefaultChunkSize
+func NewIndex(chunkSize int) *Index {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &Index{
+		chunkSize: chunkSize,
+		docs:      make(map[uint]*indexedDoc),
+		chunks:    make(map[int]*indexedChunk),
+		postings:  make(map[string]map[int]int),
+	}
+}
+
+// Upsert 索引或重新索引一篇文档
+// 文档为最新版本时，同仓库同引用同标题的其他文档被标记为历史版本
+func (idx *Index) Upsert(meta DocMeta, content string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.removeLocked(meta.DocID)
+	if meta.IsLatest {
+		for _, doc := range idx.docs {
+			if doc.meta.RepositoryID == meta.RepositoryID && doc.meta.Ref == meta.Ref && doc.meta.Title == meta.Title {
+				doc.meta.IsLatest = false
+			}
+		}
+	}
+
+	doc := &indexedDoc{meta: meta}
+	for _, chunk := range ChunkMarkdown(content, idx.chunkSize) {
+		// 标题与章节路径参与每个分块的检索
+		tokens := Tokenize(meta.Title + "\n" + chunk.Heading + "\n" + chunk.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		terms := make(map[string]int)
+		for _, token := range tokens {
+			terms[token]++
+		}
+
+		id := idx.nextChunkID
+		idx.nextChunkID++
+		idx.chunks[id] = &indexedChunk{
+			docID:   meta.DocID,
+			heading: chunk.Heading,
+			text:    chunk.Text,
+			length:  len(tokens),
+			terms:   terms,
+		}
+		for term, tf := range terms {
+			if idx.postings[term] == nil {
+				idx.postings[term] = make(map[int]int)
+			}
+			idx.postings[term][id] = tf
+		}
+		idx.totalLength += len(tokens)
+		doc.chunkIDs = append(doc.chunkIDs, id)
+	}
+	idx.docs[meta.DocID] = doc
+}
+
+// Remove 从索引中移除文档
+func (idx *Index) Remove(docID uint) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.removeLocked(docID)
+}
+
+func (idx *Index) removeLocked(docID uint) {
+	doc, ok := idx.docs[docID]
+	if !ok {
+		return
+	}
+	for _, id := range doc.chunkIDs {
+		chunk := idx.chunks[id]
+		for term := range chunk.terms {
+			postings := idx.postings[term]
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+		idx.totalLength -= chunk.length
+		delete(idx.chunks, id)
+	}
+	delete(idx.docs, docID)
+}
+
+// Len 返回已索引的文档数
+func (idx *Index) Len() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return len(idx.docs)
+}
+
+// Search 按 BM25 对分块打分，每篇文档取得分最高的分块，按得分降序返回
+func (idx *Index) Search(query Query) []Hit {
+	terms := uniqueTokens(Tokenize(query.Text))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	if len(idx.chunks) == 0 {
+		return nil
+	}
+	total := float64(len(idx.chunks))
+	avgLength := float64(idx.totalLength) / total
+	titleFilter := strings.ToLower(query.Title)
+
+	allowed := make(map[uint]bool)
+	scores := make(map[int]float64)
+	for _, term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			chunk := idx.chunks[id]
+			ok, checked := allowed[chunk.docID]
+			if !checked {
+				ok = idx.docs[chunk.docID].meta.matches(query, titleFilter)
+				allowed[chunk.docID] = ok
+			}
+			if !ok {
+				continue
+			}
+			freq := float64(tf)
+			norm := freq + bm25K1*(1-bm25B+bm25B*float64(chunk.length)/avgLength)
+			scores[id] += idf * freq * (bm25K1 + 1) / norm
+		}
+	}
+
+	best := make(map[uint]int)
+	for id, score := range scores {
+		docID := idx.chunks[id].docID
+		if current, ok := best[docID]; !ok || score > scores[current] || (score == scores[current] && id < current) {
+			best[docID] = id
+		}
+	}
+
+	hits := make([]Hit, 0, len(best))
+	for docID, id := range best {
+		chunk := idx.chunks[id]
+		hits = append(hits, Hit{
+			DocMeta: idx.docs[docID].meta,
+			Score:   scores[id],
+			Heading: chunk.heading,
+			Snippet: snippet(chunk.text, terms, snippetLength),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].DocID < hits[j].DocID
+	})
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits
+}
+
+func (m DocMeta) matches(query Query, titleFilter string) bool {
+	if query.RepositoryID != 0 && m.RepositoryID != query.RepositoryID {
+		return false
+	}
+	if m.Ref != query.Ref {
+		return false
+	}
+	if query.Version > 0 {
+		if m.Version != query.Version {
+			return false
+		}
+	} else if !m.IsLatest {
+		return false
+	}
+	if titleFilter != "" && !strings.Contains(strings.ToLower(m.Title), titleFilter) {
+		return false
+	}
+	return true
+}
+
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// snippet 截取分块中首个命中词附近的片段
+func snippet(text string, terms []string, maxLen int) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	pos := -1
+	for _, term := range terms {
+		if i := indexRunes(lower, []rune(term)); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	if pos < 0 {
+		pos = 0
+	}
+
+	start := pos - maxLen/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxLen
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	result := string(runes[start:end])
+	if start > 0 {
+		result = "..." + result
+	}
+	if end < len(runes) {
+		result += "..."
+	}
+	return result
+}
+
+func indexRunes(haystack, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,82 @@
+package docindex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := strings.Join(Tokenize("调用 GetUserByID 读取 task_id, HTTPServer v2"), " ")
+	for _, want := range []string{"调用", "getuserbyid", "get", "user", "by", "id", "读取", "task_id", "task", "httpserver", "http", "server", "v2"} {
+		if !strings.Contains(" "+tokens+" ", " "+want+" ") {
+			t.Fatalf("expected token %q in %q", want, tokens)
+		}
+	}
+}
+
+func TestChunkMarkdown(t *testing.T) {
+	content := "# 架构\n概述\n## 任务编排\n编排器负责调度\n```go\n# not a heading\n```\n## 存储\n" + strings.Repeat("数据", 50) + "\n\n" + strings.Repeat("索引", 50)
+	chunks := ChunkMarkdown(content, 120)
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[1].Heading != "架构 > 任务编排" || !strings.Contains(chunks[1].Text, "# not a heading") {
+		t.Fatalf("unexpected chunk: %+v", chunks[1])
+	}
+	if chunks[2].Heading != "架构 > 存储" || chunks[3].Heading != "架构 > 存储" {
+		t.Fatalf("expected long section split under same heading: %+v", chunks[2:])
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex(0)
+	idx.Upsert(DocMeta{DocID: 1, RepositoryID: 1, Title: "任务编排", Version: 1, IsLatest: true},
+		"# 编排器\nOrchestrator 负责任务调度，调度器按优先级出队。调度失败时重试。")
+	idx.Upsert(DocMeta{DocID: 2, RepositoryID: 1, Title: "存储", Version: 1, IsLatest: true},
+		"# 存储\n文档保存在数据库中，任务调度不在这里。")
+	idx.Upsert(DocMeta{DocID: 3, RepositoryID: 2, Title: "任务编排", Version: 1, IsLatest: true},
+		"# 编排\n另一个仓库的任务调度说明。")
+
+	hits := idx.Search(Query{Text: "任务调度", RepositoryID: 1})
+	if len(hits) != 2 || hits[0].DocID != 1 {
+		t.Fatalf("expected doc 1 ranked first in repo 1, got %+v", hits)
+	}
+	if !strings.Contains(hits[0].Snippet, "调度") || hits[0].Heading != "编排器" {
+		t.Fatalf("unexpected hit: %+v", hits[0])
+	}
+
+	if hits := idx.Search(Query{Text: "orchestrator"}); len(hits) != 1 || hits[0].DocID != 1 {
+		t.Fatalf("expected case-insensitive identifier match, got %+v", hits)
+	}
+	if hits := idx.Search(Query{Text: "任务调度", Title: "存储"}); len(hits) != 1 || hits[0].DocID != 2 {
+		t.Fatalf("expected title filter, got %+v", hits)
+	}
+
+	// 新版本替换旧版本：默认只检索最新版本，可按版本号检索历史
+	idx.Upsert(DocMeta{DocID: 4, RepositoryID: 1, Title: "任务编排", Version: 2, IsLatest: true}, "# 编排器\n使用持久化队列。")
+	if hits := idx.Search(Query{Text: "优先级"}); len(hits) != 0 {
+		t.Fatalf("expected old version excluded, got %+v", hits)
+	}
+	if hits := idx.Search(Query{Text: "优先级", Version: 1}); len(hits) != 1 || hits[0].DocID != 1 {
+		t.Fatalf("expected version filter to find old version, got %+v", hits)
+	}
+
+	// 更新内容后旧词不再命中
+	idx.Upsert(DocMeta{DocID: 2, RepositoryID: 1, Title: "存储", Version: 1, IsLatest: true}, "# 存储\nSQLite")
+	if hits := idx.Search(Query{Text: "数据库"}); len(hits) != 0 {
+		t.Fatalf("expected stale terms removed, got %+v", hits)
+	}
+	idx.Remove(2)
+	if hits := idx.Search(Query{Text: "sqlite"}); len(hits) != 0 || idx.Len() != 3 {
+		t.Fatalf("expected doc removed, got %+v len=%d", hits, idx.Len())
+	}
+
+	// 引用文档集的同名文档不替换主文档集的最新版本，且只在指定引用时检索
+	idx.Upsert(DocMeta{DocID: 5, RepositoryID: 1, Ref: "v1.0", Title: "任务编排", Version: 1, IsLatest: true}, "# 编排器\n使用持久化队列。")
+	if hits := idx.Search(Query{Text: "持久化队列", RepositoryID: 1}); len(hits) != 1 || hits[0].DocID != 4 {
+		t.Fatalf("expected main doc set to keep its latest version, got %+v", hits)
+	}
+	if hits := idx.Search(Query{Text: "持久化队列", RepositoryID: 1, Ref: "v1.0"}); len(hits) != 1 || hits[0].DocID != 5 {
+		t.Fatalf("expected ref filter, got %+v", hits)
+	}
+}
@@ -0,0 +1,109 @@
+package docindex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize 将文本切分为检索词
+//
+// 面向代码文档做了以下处理：
+//   - 英文与数字按单词切分并转为小写，丢弃单个字母
+//   - 标识符额外拆分 camelCase / snake_case，如 GetUserByID 同时产生 getuserbyid、get、user、by、id
+//   - 连续的中文字符按二元组切分（单字时保留单字），无需分词词典即可匹配中文短语
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = appendWordTokens(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			tokens = append(tokens, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+// appendWordTokens 追加单词本身及其标识符子词
+func appendWordTokens(tokens []string, word string) []string {
+	word = strings.Trim(word, "_")
+	lower := strings.ToLower(word)
+	if len([]rune(lower)) < 2 {
+		return tokens
+	}
+	tokens = append(tokens, lower)
+
+	parts := splitIdentifier(word)
+	if len(parts) < 2 {
+		return tokens
+	}
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if len([]rune(part)) >= 2 || isDigits(part) {
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+// splitIdentifier 按下划线与大小写变化拆分标识符
+func splitIdentifier(word string) []string {
+	var parts []string
+	for _, segment := range strings.Split(word, "_") {
+		runes := []rune(segment)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			boundary := unicode.IsLower(prev) && unicode.IsUpper(cur)
+			// 连续大写后接小写时，最后一个大写字母属于下一个词：HTTPServer -> HTTP, Server
+			if unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				boundary = true
+			}
+			if boundary {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,115 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	einoEmbedding "github.com/cloudwego/eino/components/embedding"
+)
+
+// ProviderOpenAI OpenAI 兼容的 Embedding 接口
+const ProviderOpenAI = "openai"
+
+// OpenAIEmbedder 调用 OpenAI 兼容的 /embeddings 接口，实现 eino 的 embedding.Embedder
+// 本地部署的兼容服务（如 Ollama、llama.cpp、vLLM）同样适用
+type OpenAIEmbedder struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	dimensions int
+	client     *http.Client
+}
+
+// NewOpenAIEmbedder 创建 OpenAI 兼容的向量模型客户端
+// dimensions 为 0 时不传维度参数，使用模型默认维度
+func NewOpenAIEmbedder(baseURL, apiKey, model string, dimensions int) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		dimensions: dimensions,
+		client:     &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+type embeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// EmbedStrings 批量生成文本向量，返回顺序与输入一致
+func (e *OpenAIEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...einoEmbedding.Option) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	model := e.model
+	if options := einoEmbedding.GetCommonOptions(nil, opts...); options.Model != nil && *options.Model != "" {
+		model = *options.Model
+	}
+
+	body, err := json.Marshal(embeddingRequest{Model: model, Input: texts, Dimensions: e.dimensions})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求向量模型失败: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取向量模型响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := string(data)
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return nil, fmt.Errorf("向量模型返回错误: status=%d, body=%s", resp.StatusCode, msg)
+	}
+
+	var result embeddingResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析向量模型响应失败: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("向量数量不匹配: 期望 %d, 实际 %d", len(texts), len(result.Data))
+	}
+	vectors := make([][]float64, len(texts))
+	for i, item := range result.Data {
+		index := item.Index
+		if index < 0 || index >= len(texts) {
+			index = i
+		}
+		vectors[index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("第 %d 条文本未返回向量", i)
+		}
+	}
+	return vectors, nil
+}
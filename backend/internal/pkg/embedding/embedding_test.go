@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpenAIEmbedder 验证请求格式与按 index 还原向量顺序
+func TestOpenAIEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "bad request", http.StatusUnauthorized)
+			return
+		}
+		var req embeddingRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "text-embedding-3-small" || req.Dimensions != 2 || len(req.Input) != 2 {
+			http.Error(w, "unexpected payload", http.StatusBadRequest)
+			return
+		}
+		// 故意乱序返回
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAIEmbedder(server.URL+"/v1/", "sk-test", "text-embedding-3-small", 2)
+	vectors, err := embedder.EmbedStrings(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed error: %v", err)
+	}
+	if vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Fatalf("unexpected vectors: %v", vectors)
+	}
+
+	_, err = NewOpenAIEmbedder(server.URL, "wrong", "m", 0).EmbedStrings(context.Background(), []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
+
+// TestVectorEncodingAndCosine 验证向量编解码与余弦相似度
+func TestVectorEncodingAndCosine(t *testing.T) {
+	decoded := DecodeVector(EncodeVector([]float64{0.5, -1.25, 3}))
+	if len(decoded) != 3 || decoded[1] != -1.25 {
+		t.Fatalf("unexpected decoded vector: %v", decoded)
+	}
+	if score := Cosine([]float64{1, 0}, []float32{1, 0}); math.Abs(score-1) > 1e-9 {
+		t.Fatalf("expected 1, got %v", score)
+	}
+	if score := Cosine([]float64{1, 0}, []float32{0, 1, 0}); score != 0 {
+		t.Fatalf("expected 0 for dimension mismatch, got %v", score)
+	}
+}
+
+// TestChunkLines 验证按行分块与重叠
+func TestChunkLines(t *testing.T) {
+	lines := make([]string, 25)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	chunks := ChunkLines(strings.Join(lines, "\n"), 10, 2)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[1].StartLine != 9 || chunks[1].EndLine != 18 || chunks[2].EndLine != 25 {
+		t.Fatalf("unexpected chunk ranges: %+v", chunks)
+	}
+}
@@ -0,0 +1,112 @@
+package embedding
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 源码分块参数
+const (
+	SourceChunkLines   = 60        // 每个分块的行数
+	SourceChunkOverlap = 10        // 相邻分块重叠的行数
+	MaxSourceFileSize  = 256 << 10 // 超过该大小的文件不参与索引
+	MaxSourceChunks    = 20000     // 单个仓库最多索引的源码分块数
+)
+
+// 遍历时跳过的目录
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	".idea":        true,
+	".vscode":      true,
+}
+
+// 参与索引的源码与文本文件扩展名
+var sourceExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true,
+	".java": true, ".kt": true, ".scala": true, ".rs": true, ".c": true, ".h": true,
+	".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".rb": true, ".php": true,
+	".swift": true, ".m": true, ".sh": true, ".sql": true, ".proto": true, ".vue": true,
+	".md": true, ".yaml": true, ".yml": true, ".toml": true,
+}
+
+// SourceChunk 源码文件的一个行区间分块
+type SourceChunk struct {
+	Path      string // 相对仓库根目录的路径，使用 / 分隔
+	StartLine int    // 起始行号，从 1 开始
+	EndLine   int    // 结束行号（包含）
+	Content   string
+}
+
+// ChunkSourceFiles 遍历仓库目录，将源码文件按行切分为带重叠的分块
+func ChunkSourceFiles(root string) ([]SourceChunk, error) {
+	var chunks []SourceChunk
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !sourceExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() == 0 || info.Size() > MaxSourceFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		for _, chunk := range ChunkLines(string(data), SourceChunkLines, SourceChunkOverlap) {
+			chunk.Path = filepath.ToSlash(rel)
+			chunks = append(chunks, chunk)
+			if len(chunks) >= MaxSourceChunks {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	return chunks, err
+}
+
+// ChunkLines 将文本按固定行数切分，相邻分块重叠 overlap 行，空白分块被忽略
+func ChunkLines(content string, size, overlap int) []SourceChunk {
+	if size <= 0 {
+		size = SourceChunkLines
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var chunks []SourceChunk
+	for start := 0; start < len(lines); start += size - overlap {
+		end := start + size
+		if end > len(lines) {
+			end = len(lines)
+		}
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, SourceChunk{StartLine: start + 1, EndLine: end, Content: text})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
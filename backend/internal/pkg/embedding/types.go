@@ -0,0 +1,30 @@
+package embedding
+
+import "context"
+
+// SearchRequest 语义检索请求
+type SearchRequest struct {
+	Query        string `json:"query" form:"q"`
+	RepositoryID uint   `json:"repository_id" form:"repo_id"`
+	SourceType   string `json:"source_type" form:"source_type"` // document / code，为空表示不限
+	Limit        int    `json:"limit" form:"limit"`
+}
+
+// SearchHit 语义检索命中的分块
+type SearchHit struct {
+	SourceType   string  `json:"source_type"`
+	RepositoryID uint    `json:"repository_id"`
+	DocID        uint    `json:"doc_id,omitempty"`
+	Path         string  `json:"path,omitempty"`
+	Title        string  `json:"title,omitempty"`
+	Heading      string  `json:"heading,omitempty"`
+	StartLine    int     `json:"start_line,omitempty"`
+	EndLine      int     `json:"end_line,omitempty"`
+	Content      string  `json:"content"`
+	Score        float64 `json:"score"`
+}
+
+// Searcher 语义检索能力，由 service 层实现，供 Agent 工具调用
+type Searcher interface {
+	SemanticSearch(ctx context.Context, req SearchRequest) ([]SearchHit, error)
+}
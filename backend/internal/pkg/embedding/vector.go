@@ -0,0 +1,42 @@
+package embedding
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// EncodeVector 将向量编码为 float32 小端字节序，用于落库
+func EncodeVector(vector []float64) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return buf
+}
+
+// DecodeVector 解码 EncodeVector 编码的向量
+func DecodeVector(data []byte) []float32 {
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector
+}
+
+// Cosine 计算余弦相似度，维度不一致或零向量时返回 0
+func Cosine(query []float64, vector []float32) float64 {
+	if len(query) != len(vector) || len(query) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i, a := range query {
+		b := float64(vector[i])
+		dot += a * b
+		normA += a * a
+		normB += b * b
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
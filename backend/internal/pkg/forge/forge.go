@@ -0,0 +1,186 @@
+// Package forge 识别仓库所在的代码托管平台（GitHub、GitLab、Gitea、Bitbucket、Gitee），
+// 解析仓库地址中的主机与路径（支持 GitLab 多级群组），并按各平台的规则生成代码文件与行号链接。
+// 自建实例的域名通过 SetHosts 映射到平台类型。
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Kind 代码托管平台类型
+type Kind string
+
+const (
+	KindGitHub    Kind = "github"
+	KindGitLab    Kind = "gitlab"
+	KindGitea     Kind = "gitea"
+	KindBitbucket Kind = "bitbucket"
+	KindGitee     Kind = "gitee"
+	// KindUnknown 未识别的主机，按 owner/repo 两级路径解析，链接按 GitHub 风格生成
+	KindUnknown Kind = ""
+)
+
+var (
+	// ErrInvalidURL 仓库地址无法解析
+	ErrInvalidURL = errors.New("invalid repository url")
+	// ErrInvalidPath 仓库路径层级不符合平台规则
+	ErrInvalidPath = errors.New("invalid repository path")
+	// ErrUnknownKind 未知的平台类型
+	ErrUnknownKind = errors.New("unknown forge kind")
+)
+
+// builtinHosts 公共托管平台的域名
+var builtinHosts = map[string]Kind{
+	"github.com":    KindGitHub,
+	"gitlab.com":    KindGitLab,
+	"gitea.com":     KindGitea,
+	"codeberg.org":  KindGitea,
+	"bitbucket.org": KindBitbucket,
+	"gitee.com":     KindGitee,
+}
+
+var (
+	hostsMu sync.RWMutex
+	hosts   = map[string]Kind{}
+)
+
+var scpLikePattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ParseKind 解析配置中的平台类型名称
+func ParseKind(name string) (Kind, error) {
+	kind := Kind(strings.ToLower(strings.TrimSpace(name)))
+	switch kind {
+	case KindGitHub, KindGitLab, KindGitea, KindBitbucket, KindGitee:
+		return kind, nil
+	}
+	return KindUnknown, fmt.Errorf("%w: %s", ErrUnknownKind, name)
+}
+
+// SetHosts 设置自建实例的域名到平台类型的映射，例如 git.example.com -> gitlab，优先于内置域名
+func SetHosts(mapping map[string]string) error {
+	parsed := make(map[string]Kind, len(mapping))
+	for host, name := range mapping {
+		kind, err := ParseKind(name)
+		if err != nil {
+			return fmt.Errorf("forge host %s: %w", host, err)
+		}
+		parsed[strings.ToLower(strings.TrimSpace(host))] = kind
+	}
+	hostsMu.Lock()
+	hosts = parsed
+	hostsMu.Unlock()
+	return nil
+}
+
+// KindForHost 返回主机对应的平台类型，主机可以带端口
+func KindForHost(host string) Kind {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	hostsMu.RLock()
+	kind, ok := hosts[host]
+	hostsMu.RUnlock()
+	if ok {
+		return kind
+	}
+	return builtinHosts[host]
+}
+
+// Repo 解析后的仓库地址
+type Repo struct {
+	Kind   Kind
+	Scheme string // http、https 或 ssh（含 git@host:path 形式）
+	Host   string // 小写，HTTP 地址保留端口
+	Path   string // 仓库完整路径，例如 owner/repo 或 group/subgroup/repo，不含 .git
+}
+
+// Parse 解析 https://host/path、ssh://git@host/path 与 git@host:path 形式的仓库地址。
+// GitLab 支持多级群组，并去掉网页地址中 /-/ 之后的部分；其余平台只接受 owner/repo 两级路径
+func Parse(raw string) (*Repo, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, fmt.Errorf("%w: empty url", ErrInvalidURL)
+	}
+
+	repo := &Repo{}
+	var path string
+	if !strings.Contains(trimmed, "://") {
+		matches := scpLikePattern.FindStringSubmatch(trimmed)
+		if len(matches) != 3 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidURL, raw)
+		}
+		repo.Scheme = "ssh"
+		repo.Host = strings.ToLower(matches[1])
+		path = matches[2]
+	} else {
+		parsed, err := url.Parse(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		repo.Scheme = strings.ToLower(parsed.Scheme)
+		switch repo.Scheme {
+		case "http", "https":
+			repo.Host = strings.ToLower(parsed.Host)
+		case "ssh":
+			// SSH 端口与网页端口无关，只保留主机名
+			repo.Host = strings.ToLower(parsed.Hostname())
+		default:
+			return nil, fmt.Errorf("%w: unsupported scheme %s", ErrInvalidURL, parsed.Scheme)
+		}
+		path = parsed.Path
+	}
+	if repo.Host == "" {
+		return nil, fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+	repo.Kind = KindForHost(repo.Host)
+
+	path = strings.Trim(path, "/")
+	if repo.Kind == KindGitLab {
+		if i := strings.Index(path, "/-/"); i >= 0 {
+			path = path[:i]
+		}
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPath, path)
+		}
+	}
+	if len(parts) < 2 || (repo.Kind != KindGitLab && len(parts) != 2) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPath, path)
+	}
+	repo.Path = path
+	return repo, nil
+}
+
+// Owner 仓库所属的用户、组织或群组（GitLab 多级群组以 / 连接）
+func (r *Repo) Owner() string {
+	return r.Path[:strings.LastIndex(r.Path, "/")]
+}
+
+// Name 仓库名
+func (r *Repo) Name() string {
+	return r.Path[strings.LastIndex(r.Path, "/")+1:]
+}
+
+// Key 仓库去重键：host/path，小写
+func (r *Repo) Key() string {
+	return strings.ToLower(r.Host + "/" + r.Path)
+}
+
+// WebURL 仓库主页地址，SSH 地址按 https 访问同名主机
+func (r *Repo) WebURL() string {
+	scheme := r.Scheme
+	if scheme == "ssh" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/" + r.Path
+}
@@ -0,0 +1,89 @@
+package forge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	if err := SetHosts(map[string]string{"git.example.com": "gitlab", "code.example.com": "gitea"}); err != nil {
+		t.Fatalf("SetHosts error: %v", err)
+	}
+	defer SetHosts(nil)
+
+	tests := []struct {
+		input string
+		kind  Kind
+		key   string
+		owner string
+		web   string
+	}{
+		{"https://github.com/Owner/Repo.git", KindGitHub, "github.com/owner/repo", "Owner", "https://github.com/Owner/Repo"},
+		{"https://gitlab.com/group/sub/project/-/tree/main", KindGitLab, "gitlab.com/group/sub/project", "group/sub", "https://gitlab.com/group/sub/project"},
+		{"git@git.example.com:platform/backend/api.git", KindGitLab, "git.example.com/platform/backend/api", "platform/backend", "https://git.example.com/platform/backend/api"},
+		{"ssh://git@code.example.com:2222/team/svc.git", KindGitea, "code.example.com/team/svc", "team", "https://code.example.com/team/svc"},
+		{"https://git.example.com:8443/a/b/c", KindGitLab, "git.example.com:8443/a/b/c", "a/b", "https://git.example.com:8443/a/b/c"},
+		{"https://bitbucket.org/ws/repo", KindBitbucket, "bitbucket.org/ws/repo", "ws", "https://bitbucket.org/ws/repo"},
+		{"https://unknown.example.org/owner/repo", KindUnknown, "unknown.example.org/owner/repo", "owner", "https://unknown.example.org/owner/repo"},
+	}
+	for _, tt := range tests {
+		repo, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%s) error: %v", tt.input, err)
+		}
+		if repo.Kind != tt.kind || repo.Key() != tt.key || repo.Owner() != tt.owner || repo.WebURL() != tt.web {
+			t.Errorf("Parse(%s) = %+v, key=%s owner=%s web=%s", tt.input, repo, repo.Key(), repo.Owner(), repo.WebURL())
+		}
+	}
+
+	for _, input := range []string{
+		"https://github.com/owner/repo/blob/main/README.md", // GitHub 只有两级
+		"https://unknown.example.org/group/sub/repo",        // 未配置的主机按两级解析
+		"https://gitlab.com/group",
+		"ftp://github.com/owner/repo",
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) should fail", input)
+		}
+	}
+	if err := SetHosts(map[string]string{"git.example.com": "svn"}); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("unknown kind should be rejected, got %v", err)
+	}
+}
+
+func TestBlobURL(t *testing.T) {
+	if err := SetHosts(map[string]string{"code.example.com": "gitea"}); err != nil {
+		t.Fatalf("SetHosts error: %v", err)
+	}
+	defer SetHosts(nil)
+
+	path, lines := SplitLineAnchor("internal/app/main.go#L32-L86")
+	if path != "internal/app/main.go" || lines != (Lines{Start: 32, End: 86}) {
+		t.Fatalf("SplitLineAnchor = %s, %+v", path, lines)
+	}
+	if _, lines := SplitLineAnchor("README.md#usage"); lines != (Lines{}) {
+		t.Fatalf("non-line anchor should be dropped: %+v", lines)
+	}
+
+	tests := []struct {
+		url   string
+		ref   Ref
+		lines Lines
+		want  string
+	}{
+		{"https://github.com/o/r", Ref{Type: RefBranch, Name: "main"}, lines, "https://github.com/o/r/blob/main/internal/app/main.go#L32-L86"},
+		{"https://gitlab.com/g/s/r", Ref{Type: RefCommit, Name: "abc1234"}, lines, "https://gitlab.com/g/s/r/-/blob/abc1234/internal/app/main.go#L32-86"},
+		{"https://code.example.com/o/r", Ref{Type: RefTag, Name: "v1.0"}, Lines{Start: 5}, "https://code.example.com/o/r/src/tag/v1.0/internal/app/main.go#L5"},
+		{"https://bitbucket.org/w/r", Ref{Name: "main"}, lines, "https://bitbucket.org/w/r/src/main/internal/app/main.go#lines-32:86"},
+		{"https://gitee.com/o/r", Ref{}, Lines{}, "https://gitee.com/o/r/blob/HEAD/internal/app/main.go"},
+	}
+	for _, tt := range tests {
+		repo, err := Parse(tt.url)
+		if err != nil {
+			t.Fatalf("Parse(%s) error: %v", tt.url, err)
+		}
+		if got := repo.BlobURL(tt.ref, path, tt.lines); got != tt.want {
+			t.Errorf("BlobURL(%s) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 链接指向的引用类型，取值与 git.RefBranch、git.RefTag、git.RefCommit 一致
+const (
+	RefBranch = "branch"
+	RefTag    = "tag"
+	RefCommit = "commit"
+)
+
+// Ref 链接指向的分支、标签或提交，Type 为空时按分支处理
+type Ref struct {
+	Type string
+	Name string
+}
+
+// Lines 行号范围，Start 为 0 表示不定位到行，End 为 0 表示只定位到单行
+type Lines struct {
+	Start int
+	End   int
+}
+
+// lineAnchorPattern 文档中的行号锚点，例如 #L32、#L32-L86、#L32-86
+var lineAnchorPattern = regexp.MustCompile(`^L(\d+)(?:-L?(\d+))?$`)
+
+// SplitLineAnchor 拆分文档链接中的文件路径与 GitHub 风格的行号锚点，无法识别的锚点直接丢弃
+func SplitLineAnchor(link string) (string, Lines) {
+	path, anchor, found := strings.Cut(link, "#")
+	if !found {
+		return path, Lines{}
+	}
+	matches := lineAnchorPattern.FindStringSubmatch(anchor)
+	if matches == nil {
+		return path, Lines{}
+	}
+	lines := Lines{}
+	lines.Start, _ = strconv.Atoi(matches[1])
+	if matches[2] != "" {
+		lines.End, _ = strconv.Atoi(matches[2])
+	}
+	if lines.End <= lines.Start {
+		lines.End = 0
+	}
+	return path, lines
+}
+
+// BlobURL 生成文件在指定引用下的网页链接，并按平台规则附加行号锚点
+//
+//	GitHub/Gitee/未知: {web}/blob/{ref}/{path}#L1-L2
+//	GitLab:           {web}/-/blob/{ref}/{path}#L1-2
+//	Gitea:            {web}/src/{branch|tag|commit}/{ref}/{path}#L1-L2
+//	Bitbucket:        {web}/src/{ref}/{path}#lines-1:2
+func (r *Repo) BlobURL(ref Ref, path string, lines Lines) string {
+	path = escapePath(strings.TrimPrefix(path, "/"))
+	name := escapePath(ref.Name)
+	if name == "" {
+		name = "HEAD"
+	}
+
+	var link string
+	switch r.Kind {
+	case KindGitLab:
+		link = fmt.Sprintf("%s/-/blob/%s/%s", r.WebURL(), name, path)
+	case KindGitea:
+		refType := ref.Type
+		if refType == "" {
+			refType = RefBranch
+		}
+		link = fmt.Sprintf("%s/src/%s/%s/%s", r.WebURL(), refType, name, path)
+	case KindBitbucket:
+		link = fmt.Sprintf("%s/src/%s/%s", r.WebURL(), name, path)
+	default:
+		link = fmt.Sprintf("%s/blob/%s/%s", r.WebURL(), name, path)
+	}
+	return link + r.lineAnchor(lines)
+}
+
+// lineAnchor 各平台的行号锚点格式
+func (r *Repo) lineAnchor(lines Lines) string {
+	if lines.Start <= 0 {
+		return ""
+	}
+	if lines.End <= lines.Start {
+		if r.Kind == KindBitbucket {
+			return fmt.Sprintf("#lines-%d", lines.Start)
+		}
+		return fmt.Sprintf("#L%d", lines.Start)
+	}
+	switch r.Kind {
+	case KindGitLab:
+		return fmt.Sprintf("#L%d-%d", lines.Start, lines.End)
+	case KindBitbucket:
+		return fmt.Sprintf("#lines-%d:%d", lines.Start, lines.End)
+	}
+	return fmt.Sprintf("#L%d-L%d", lines.Start, lines.End)
+}
+
+// escapePath 逐段转义路径，保留分隔符 /
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
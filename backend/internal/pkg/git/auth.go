@@ -0,0 +1,144 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Auth 访问私有仓库的凭证。
+// HTTPS 凭证通过 GIT_ASKPASS 提供，SSH 私钥通过 GIT_SSH_COMMAND 指定的临时文件提供，
+// 凭证不会写入仓库 URL、.git/config 或命令行参数，命令结束后临时文件随即删除。
+type Auth struct {
+	Username   string // HTTPS 用户名
+	Password   string // HTTPS 密码或访问令牌
+	SSHKey     string // SSH 私钥
+	KnownHosts string // SSH 主机公钥，为空时首次连接自动信任
+}
+
+type authContextKey struct{}
+
+// WithAuth 返回携带凭证的 context，Pull、EnsureBaseCommitAvailable 等访问远端的操作使用该凭证。
+func WithAuth(ctx context.Context, auth *Auth) context.Context {
+	if auth == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, authContextKey{}, auth)
+}
+
+func authFromContext(ctx context.Context) *Auth {
+	if ctx == nil {
+		return nil
+	}
+	auth, _ := ctx.Value(authContextKey{}).(*Auth)
+	return auth
+}
+
+// askPassScript 根据 git 的提示返回用户名或密码，凭证只存在于 git 子进程的环境变量中
+const askPassScript = `#!/bin/sh
+case "$1" in
+  Username*) printf '%s\n' "$OPENDEEPWIKI_GIT_USERNAME" ;;
+  *) printf '%s\n' "$OPENDEEPWIKI_GIT_PASSWORD" ;;
+esac
+`
+
+// commandEnv 返回执行 git 命令的环境变量，以及删除临时凭证文件的清理函数。
+func (a *Auth) commandEnv() ([]string, func(), error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if a == nil || (a.Password == "" && a.SSHKey == "") {
+		return env, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "opendeepwiki-git-auth-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create credential dir failed: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	if a.SSHKey != "" {
+		keyFile := filepath.Join(dir, "id_key")
+		key := strings.TrimSpace(a.SSHKey) + "\n"
+		if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("write ssh key failed: %w", err)
+		}
+		sshCommand := "ssh -i " + shellQuote(keyFile) + " -o IdentitiesOnly=yes -o BatchMode=yes"
+		if a.KnownHosts != "" {
+			knownHostsFile := filepath.Join(dir, "known_hosts")
+			if err := os.WriteFile(knownHostsFile, []byte(strings.TrimSpace(a.KnownHosts)+"\n"), 0600); err != nil {
+				cleanup()
+				return nil, nil, fmt.Errorf("write known hosts failed: %w", err)
+			}
+			sshCommand += " -o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + shellQuote(knownHostsFile)
+		} else {
+			sshCommand += " -o StrictHostKeyChecking=accept-new"
+		}
+		env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+	}
+
+	if a.Password != "" {
+		script := filepath.Join(dir, "askpass.sh")
+		if err := os.WriteFile(script, []byte(askPassScript), 0700); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("write askpass script failed: %w", err)
+		}
+		env = append(env,
+			"GIT_ASKPASS="+script,
+			"OPENDEEPWIKI_GIT_USERNAME="+a.Username,
+			"OPENDEEPWIKI_GIT_PASSWORD="+a.Password,
+		)
+	}
+	return env, cleanup, nil
+}
+
+// commandArgs 使用凭证时禁用已配置的 credential helper，避免凭证被持久化到本机凭证存储。
+func (a *Auth) commandArgs(args ...string) []string {
+	if a == nil {
+		return args
+	}
+	return append([]string{"-c", "credential.helper="}, args...)
+}
+
+// redact 从命令输出中去除密码与令牌。
+func (a *Auth) redact(output string) string {
+	if a == nil || a.Password == "" {
+		return output
+	}
+	return strings.ReplaceAll(output, a.Password, "***")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var scpLikeURLPattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ParseRemote 解析仓库 URL 的主机与仓库路径（不含 .git 后缀），ssh 表示是否通过 SSH 访问。
+// 支持 https://host/owner/repo、ssh://git@host/owner/repo 与 git@host:owner/repo 三种形式。
+func ParseRemote(raw string) (host string, path string, ssh bool, err error) {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.Contains(trimmed, "://") {
+		matches := scpLikeURLPattern.FindStringSubmatch(trimmed)
+		if len(matches) != 3 {
+			return "", "", false, fmt.Errorf("invalid remote url")
+		}
+		host, path = matches[1], matches[2]
+		ssh = true
+	} else {
+		parsed, parseErr := url.Parse(trimmed)
+		if parseErr != nil {
+			return "", "", false, fmt.Errorf("invalid remote url: %w", parseErr)
+		}
+		host, path = parsed.Hostname(), parsed.Path
+		ssh = parsed.Scheme == "ssh"
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", "", false, fmt.Errorf("invalid remote url")
+	}
+	return strings.ToLower(host), path, ssh, nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,6 +13,8 @@ import (
 	"time"
 
 	"k8s.io/klog/v2"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/forge"
 )
 
 // CloneOptions 定义克隆仓库的参数。
@@ -21,6 +22,8 @@ type CloneOptions struct {
 	URL       string
 	TargetDir string
 	Timeout   time.Duration
+	Auth      *Auth // 私有仓库凭证，可为空
+	Ref       Ref   // 克隆的分支、标签或提交，为空时克隆默认分支
 }
 
 // FileChange 描述单个文件的变更信息。
@@ -39,7 +42,11 @@ type LineRange struct {
 }
 
 // Clone 克隆远端仓库到指定目录。
+// 分支与标签按深度 1 克隆；提交需要完整历史才能定位，先完整克隆再检出该提交。
 func Clone(opts CloneOptions) error {
+	if err := opts.Ref.Validate(); err != nil {
+		return err
+	}
 	if opts.Timeout == 0 {
 		opts.Timeout = 10 * time.Minute
 	}
@@ -53,12 +60,33 @@ func Clone(opts CloneOptions) error {
 		return fmt.Errorf("failed to create parent directory: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", url, opts.TargetDir)
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	env, cleanup, err := opts.Auth.commandEnv()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	args := []string{"clone", "--depth", "1"}
+	switch opts.Ref.Type {
+	case RefBranch, RefTag:
+		args = append(args, "--branch", opts.Ref.Name)
+	case RefCommit:
+		args = []string{"clone", "--no-checkout"}
+	}
+	args = append(args, "--", url, opts.TargetDir)
+
+	cmd := exec.CommandContext(ctx, "git", opts.Auth.commandArgs(args...)...)
+	cmd.Env = env
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("git clone failed: %s, output: %s", err, string(output))
+		return fmt.Errorf("git clone failed: %s, output: %s", err, opts.Auth.redact(string(output)))
+	}
+
+	if opts.Ref.Type == RefCommit {
+		if err := checkoutCommit(ctx, opts.TargetDir, opts.Ref.Name); err != nil {
+			return fmt.Errorf("checkout commit %s failed: %w", opts.Ref.Name, err)
+		}
 	}
 
 	return nil
@@ -96,52 +124,25 @@ func IsValidGitURL(url string) bool {
 }
 
 // NormalizeRepoURL 归一化仓库 URL 并返回去重键。
+// 路径层级按托管平台校验：GitLab（含配置为 gitlab 的自建域名）支持多级群组，其余平台为 owner/repo。
 func NormalizeRepoURL(raw string) (string, string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
 		return "", "", fmt.Errorf("empty url")
 	}
-
-	if strings.HasPrefix(trimmed, "git@") {
-		re := regexp.MustCompile(`^git@([^:]+):([^/]+)/([^/]+?)(?:\.git)?/?$`)
-		matches := re.FindStringSubmatch(trimmed)
-		if len(matches) != 4 {
-			return "", "", fmt.Errorf("invalid ssh url")
-		}
-		host := strings.ToLower(matches[1])
-		owner := strings.ToLower(matches[2])
-		repo := strings.ToLower(matches[3])
-		normalized := fmt.Sprintf("git@%s:%s/%s.git", host, owner, repo)
-		key := fmt.Sprintf("%s/%s/%s", host, owner, repo)
-		return normalized, key, nil
-	}
-
-	parsed, err := url.Parse(trimmed)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid url: %w", err)
-	}
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+	if !strings.HasPrefix(trimmed, "git@") && !strings.HasPrefix(trimmed, "http://") && !strings.HasPrefix(trimmed, "https://") {
 		return "", "", fmt.Errorf("unsupported scheme")
 	}
-	if parsed.Host == "" {
-		return "", "", fmt.Errorf("missing host")
-	}
 
-	path := strings.Trim(parsed.Path, "/")
-	path = strings.TrimSuffix(path, ".git")
-	if path == "" {
-		return "", "", fmt.Errorf("missing repo path")
+	repo, err := forge.Parse(trimmed)
+	if err != nil {
+		return "", "", err
 	}
-	parts := strings.Split(path, "/")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid repo path")
+	key := repo.Key()
+	if repo.Scheme == "ssh" {
+		return fmt.Sprintf("git@%s.git", strings.Replace(key, "/", ":", 1)), key, nil
 	}
-	host := strings.ToLower(parsed.Host)
-	owner := strings.ToLower(parts[0])
-	repo := strings.ToLower(parts[1])
-	normalized := fmt.Sprintf("%s://%s/%s/%s", strings.ToLower(parsed.Scheme), host, owner, repo)
-	key := fmt.Sprintf("%s/%s/%s", host, owner, repo)
-	return normalized, key, nil
+	return repo.Scheme + "://" + key, key, nil
 }
 
 // GetIncrementalChanges 获取指定提交到最新提交之间的文件变更与变更说明。
@@ -358,12 +359,24 @@ func FormatIncrementalChangesForAI(repoPath string, baseCommit string) (string,
 	return builder.String(), nil
 }
 
-// Pull 执行 git pull 并返回输出。
-func Pull(ctx context.Context, repoPath string) (string, error) {
+// Pull 按克隆时的引用拉取最新代码并返回输出，使用 WithAuth 设置的凭证。
+// 指定分支时从远端拉取该分支；标签与提交是固定快照，不拉取。
+func Pull(ctx context.Context, repoPath string, ref Ref) (string, error) {
+	if ref.IsFixed() {
+		klog.V(6).Infof("引用为固定的%s，跳过拉取: repoPath=%s, ref=%s", ref.Type, repoPath, ref.Name)
+		return "", nil
+	}
+	if ref.Type == RefBranch {
+		if err := ref.Validate(); err != nil {
+			return "", err
+		}
+		return runGitCommand(ctx, repoPath, "pull", "origin", ref.Name)
+	}
 	return runGitCommand(ctx, repoPath, "pull")
 }
 
 // EnsureBaseCommitAvailable 确保基线提交在仓库历史中可用，必要时补全历史记录。
+// 补全历史需要访问远端，使用 WithAuth 设置的凭证。
 func EnsureBaseCommitAvailable(ctx context.Context, repoPath string, baseCommit string) error {
 	baseCommit = strings.TrimSpace(baseCommit)
 	if baseCommit == "" {
@@ -551,7 +564,7 @@ func parseIntWithDefault(value string, fallback int) int {
 	return parsed
 }
 
-// GetBranchAndCommit 获取当前分支名与最新提交号。
+// GetBranchAndCommit 获取当前分支名与最新提交号，HEAD 处于分离状态（检出标签或提交）时分支名为空。
 func GetBranchAndCommit(repoPath string) (string, string, error) {
 	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	branchCmd.Dir = repoPath
@@ -567,7 +580,11 @@ func GetBranchAndCommit(repoPath string) (string, string, error) {
 		return "", "", fmt.Errorf("git commit failed: %w, output: %s", err, string(commitBytes))
 	}
 
-	return strings.TrimSpace(string(branchBytes)), strings.TrimSpace(string(commitBytes)), nil
+	branch := strings.TrimSpace(string(branchBytes))
+	if branch == "HEAD" {
+		branch = ""
+	}
+	return branch, strings.TrimSpace(string(commitBytes)), nil
 }
 
 func verifyCommit(ctx context.Context, repoPath string, commit string) error {
@@ -599,12 +616,19 @@ func ensureAllBranches(ctx context.Context, repoPath string) error {
 }
 
 func runGitCommand(ctx context.Context, repoPath string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", args...)
+	auth := authFromContext(ctx)
+	env, cleanup, err := auth.commandEnv()
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	cmd := exec.CommandContext(ctx, "git", auth.commandArgs(args...)...)
 	cmd.Dir = repoPath
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = env
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("git %s 失败: %w, 输出: %s", strings.Join(args, " "), err, string(output))
+		return "", fmt.Errorf("git %s 失败: %w, 输出: %s", strings.Join(args, " "), err, auth.redact(string(output)))
 	}
 	return strings.TrimSpace(string(output)), nil
 }
@@ -99,6 +99,13 @@ func TestNormalizeRepoURL(t *testing.T) {
 			wantKey:   "gitlab.com/team/project",
 			expectErr: false,
 		},
+		{
+			name:      "gitlab nested group",
+			input:     "https://gitlab.com/Group/Sub/Project.git",
+			wantURL:   "https://gitlab.com/group/sub/project",
+			wantKey:   "gitlab.com/group/sub/project",
+			expectErr: false,
+		},
 		{
 			name:      "ssh url",
 			input:     "git@github.com:Owner/Repo.git",
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// 克隆跟随的引用类型
+const (
+	RefBranch = "branch"
+	RefTag    = "tag"
+	RefCommit = "commit"
+)
+
+// ErrInvalidRef 引用类型或名称不合法
+var ErrInvalidRef = errors.New("invalid git ref")
+
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// Ref 克隆与拉取跟随的引用，Type 为空表示远端默认分支，此时忽略 Name。
+// 分支会随拉取前进，标签与提交是固定快照，拉取时跳过。
+type Ref struct {
+	Type string
+	Name string
+}
+
+// NewRef 由仓库记录的引用类型与名称构造引用，类型为空时忽略名称（此时名称是克隆后记录的默认分支）
+func NewRef(refType, name string) Ref {
+	if refType == "" {
+		return Ref{}
+	}
+	return Ref{Type: refType, Name: name}
+}
+
+// IsFixed 是否为标签或提交等不会前进的引用
+func (r Ref) IsFixed() bool {
+	return r.Type == RefTag || r.Type == RefCommit
+}
+
+// Validate 校验引用名称，规则参考 git check-ref-format，并拒绝以 - 开头的名称以免被当作命令行参数
+func (r Ref) Validate() error {
+	switch r.Type {
+	case "":
+		return nil
+	case RefCommit:
+		if !commitPattern.MatchString(r.Name) {
+			return ErrInvalidRef
+		}
+		return nil
+	case RefBranch, RefTag:
+	default:
+		return ErrInvalidRef
+	}
+	name := r.Name
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+		strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock") || name == "@" ||
+		strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") ||
+		strings.ContainsAny(name, " ~^:?*[\\") {
+		return ErrInvalidRef
+	}
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f {
+			return ErrInvalidRef
+		}
+	}
+	return nil
+}
+
+// checkoutCommit 在未检出的完整克隆中切换到指定提交，HEAD 处于分离状态
+func checkoutCommit(ctx context.Context, repoPath, commit string) error {
+	_, err := runGitCommand(ctx, repoPath, "checkout", "-q", "--detach", commit)
+	return err
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newRefTestRemote 创建包含 main、release/1.x 分支与 v1.0 标签的裸仓库，返回 file:// 地址与首个提交
+func newRefTestRemote(t *testing.T) (string, string, string) {
+	t.Helper()
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+	commit := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+		runGit(t, work, "add", ".")
+		runGit(t, work, "commit", "-q", "-m", name)
+	}
+	runGit(t, work, "init", "-q")
+	runGit(t, work, "checkout", "-q", "-b", "main")
+	runGit(t, work, "config", "user.email", "test@example.com")
+	runGit(t, work, "config", "user.name", "test")
+	commit("first.txt", "1")
+	first := getHeadCommit(t, work)
+	runGit(t, work, "tag", "v1.0")
+	runGit(t, work, "checkout", "-q", "-b", "release/1.x")
+	commit("release.txt", "r")
+	runGit(t, work, "checkout", "-q", "main")
+	commit("second.txt", "2")
+	runGit(t, root, "clone", "-q", "--bare", work, filepath.Join(root, "remote.git"))
+	return "file://" + filepath.Join(root, "remote.git"), work, first
+}
+
+func TestCloneAndPullFollowRef(t *testing.T) {
+	remote, work, first := newRefTestRemote(t)
+	exists := func(dir, name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	branchDir := filepath.Join(t.TempDir(), "branch")
+	branchRef := Ref{Type: RefBranch, Name: "release/1.x"}
+	if err := Clone(CloneOptions{URL: remote, TargetDir: branchDir, Ref: branchRef}); err != nil {
+		t.Fatalf("clone branch error: %v", err)
+	}
+	if branch, _, err := GetBranchAndCommit(branchDir); err != nil || branch != "release/1.x" {
+		t.Fatalf("branch clone should be on release/1.x, got %q %v", branch, err)
+	}
+	if !exists(branchDir, "release.txt") || exists(branchDir, "second.txt") {
+		t.Fatalf("branch clone has wrong content")
+	}
+
+	tagDir := filepath.Join(t.TempDir(), "tag")
+	tagRef := Ref{Type: RefTag, Name: "v1.0"}
+	if err := Clone(CloneOptions{URL: remote, TargetDir: tagDir, Ref: tagRef}); err != nil {
+		t.Fatalf("clone tag error: %v", err)
+	}
+	if branch, _, err := GetBranchAndCommit(tagDir); err != nil || branch != "" {
+		t.Fatalf("tag clone should be detached, got %q %v", branch, err)
+	}
+
+	commitDir := filepath.Join(t.TempDir(), "commit")
+	if err := Clone(CloneOptions{URL: remote, TargetDir: commitDir, Ref: Ref{Type: RefCommit, Name: first[:10]}}); err != nil {
+		t.Fatalf("clone commit error: %v", err)
+	}
+	if head := getHeadCommit(t, commitDir); head != first {
+		t.Fatalf("commit clone HEAD = %s, want %s", head, first)
+	}
+
+	// 远端分支前进后，分支克隆拉取到新提交，标签克隆保持不变
+	runGit(t, work, "checkout", "-q", "release/1.x")
+	if err := os.WriteFile(filepath.Join(work, "patch.txt"), []byte("p"), 0644); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "-q", "-m", "patch")
+	runGit(t, work, "push", "-q", filepath.Join(filepath.Dir(work), "remote.git"), "release/1.x")
+
+	ctx := context.Background()
+	if _, err := Pull(ctx, branchDir, branchRef); err != nil {
+		t.Fatalf("pull branch error: %v", err)
+	}
+	if !exists(branchDir, "patch.txt") {
+		t.Fatalf("branch pull should fetch new commit")
+	}
+	if _, err := Pull(ctx, tagDir, tagRef); err != nil {
+		t.Fatalf("pull tag should be skipped, got %v", err)
+	}
+	if exists(tagDir, "patch.txt") {
+		t.Fatalf("tag clone must stay on the tagged commit")
+	}
+}
+
+func TestRefValidate(t *testing.T) {
+	valid := []Ref{
+		{},
+		{Type: RefBranch, Name: "release/1.x"},
+		{Type: RefTag, Name: "v1.2.3"},
+		{Type: RefCommit, Name: "a1b2c3d"},
+	}
+	for _, ref := range valid {
+		if err := ref.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error: %v", ref, err)
+		}
+	}
+	invalid := []Ref{
+		{Type: "head", Name: "main"},
+		{Type: RefBranch},
+		{Type: RefBranch, Name: "--upload-pack=touch"},
+		{Type: RefBranch, Name: "a..b"},
+		{Type: RefTag, Name: "v1 .0"},
+		{Type: RefCommit, Name: "main"},
+		{Type: RefCommit, Name: "abc"},
+	}
+	for _, ref := range invalid {
+		if err := ref.Validate(); !errors.Is(err, ErrInvalidRef) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidRef", ref, err)
+		}
+	}
+}
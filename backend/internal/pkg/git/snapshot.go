@@ -0,0 +1,50 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// snapshotIdentity 导入快照提交使用的作者，避免依赖服务器的 git 全局配置
+var snapshotIdentity = []string{"-c", "user.name=openDeepWiki", "-c", "user.email=opendeepwiki@localhost", "-c", "core.hooksPath=/dev/null"}
+
+// IsRepository 目录是否为 git 仓库的工作区
+func IsRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Snapshot 将目录当前内容提交为一个快照并返回提交号，目录还不是 git 仓库时先初始化。
+// 用于本地目录与上传压缩包导入的仓库：每次重新导入提交一个快照，GetIncrementalChanges 即可比较两次导入的差异。
+func Snapshot(ctx context.Context, dir, message string) (string, error) {
+	if !IsRepository(dir) {
+		if _, err := runGitCommand(ctx, dir, "init", "-q"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := runGitCommand(ctx, dir, append(snapshotIdentity, "add", "-A")...); err != nil {
+		return "", err
+	}
+	if _, err := runGitCommand(ctx, dir, append(snapshotIdentity, "commit", "-q", "--allow-empty", "--no-verify", "-m", message)...); err != nil {
+		return "", err
+	}
+	return runGitCommand(ctx, dir, "rev-parse", "--short", "HEAD")
+}
+
+// ClearWorkTree 删除工作区中除 .git 以外的所有内容，重新导入前调用，使删除的文件也能体现在下一次快照中
+func ClearWorkTree(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
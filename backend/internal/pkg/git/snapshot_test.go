@@ -0,0 +1,51 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotTracksReimport(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	write("keep.go", "package a\n")
+	write("old.go", "package old\n\nfunc Old() {}\n")
+
+	base, err := Snapshot(ctx, dir, "import 1")
+	if err != nil || base == "" {
+		t.Fatalf("first snapshot = %q, %v", base, err)
+	}
+
+	// 重新导入：清空工作区后写入新内容
+	if err := ClearWorkTree(dir); err != nil {
+		t.Fatalf("clear error: %v", err)
+	}
+	if !IsRepository(dir) {
+		t.Fatalf(".git must be kept when clearing work tree")
+	}
+	write("keep.go", "package a\n\nfunc A() {}\n")
+	write("new.go", "package a\n\nvar New = 1\n")
+	head, err := Snapshot(ctx, dir, "import 2")
+	if err != nil || head == base {
+		t.Fatalf("second snapshot = %q, %v", head, err)
+	}
+
+	_, changes, err := GetIncrementalChanges(dir, base)
+	if err != nil {
+		t.Fatalf("GetIncrementalChanges error: %v", err)
+	}
+	types := make(map[string]string)
+	for _, change := range changes {
+		types[change.Path] = change.ChangeType
+	}
+	if len(types) != 3 || types["keep.go"] == "" || types["new.go"] == "" || types["old.go"] == "" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
@@ -0,0 +1,122 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/oidc"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/oidc/oidctest"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	issuer.SetUser(map[string]interface{}{"sub": "u-42", "groups": []string{"dev", "ops"}})
+
+	ctx := context.Background()
+	client := oidc.NewClient(oidc.Config{
+		IssuerURL:   issuer.URL(),
+		ClientID:    "wiki",
+		RedirectURL: "http://wiki.local/api/auth/oidc/callback",
+		Scopes:      []string{"profile"},
+	}, nil)
+
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL error: %v", err)
+	}
+	if !strings.Contains(authURL, "code_challenge_method=S256") || !strings.Contains(authURL, "scope=openid+profile") {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize error: %v", err)
+	}
+	resp.Body.Close()
+	location, _ := url.Parse(resp.Header.Get("Location"))
+	if location.Query().Get("state") != "state-1" {
+		t.Fatalf("state not echoed: %s", location)
+	}
+	code := location.Query().Get("code")
+
+	// PKCE verifier 不匹配时换取失败
+	if _, err := client.Exchange(ctx, code, "wrong-verifier"); err == nil {
+		t.Fatalf("expected exchange with wrong verifier to fail")
+	}
+
+	resp, _ = noRedirect.Get(authURL)
+	resp.Body.Close()
+	location, _ = url.Parse(resp.Header.Get("Location"))
+	tokens, err := client.Exchange(ctx, location.Query().Get("code"), "verifier-1")
+	if err != nil {
+		t.Fatalf("exchange error: %v", err)
+	}
+	idToken, err := client.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify error: %v", err)
+	}
+	if idToken.Subject != "u-42" || len(idToken.StringsClaim("groups")) != 2 {
+		t.Fatalf("unexpected id token: %+v", idToken)
+	}
+
+	if _, err := client.VerifyIDToken(ctx, tokens.IDToken, "other-nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("expected nonce mismatch, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	ctx := context.Background()
+	client := oidc.NewClient(oidc.Config{IssuerURL: issuer.URL(), ClientID: "wiki"}, nil)
+	now := time.Now()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": issuer.URL(),
+			"sub": "u-1",
+			"aud": "wiki",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+
+	if _, err := client.VerifyIDToken(ctx, issuer.SignToken(valid()), ""); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	cases := map[string]func(map[string]interface{}){
+		"wrong audience": func(c map[string]interface{}) { c["aud"] = "other" },
+		"wrong issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"missing sub":    func(c map[string]interface{}) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := valid()
+		mutate(claims)
+		if _, err := client.VerifyIDToken(ctx, issuer.SignToken(claims), ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Errorf("%s: expected invalid token, got %v", name, err)
+		}
+	}
+
+	// 篡改载荷后签名失效
+	parts := strings.Split(issuer.SignToken(valid()), ".")
+	tampered := issuer.SignToken(map[string]interface{}{"iss": issuer.URL(), "sub": "admin", "aud": "wiki", "exp": now.Add(time.Hour).Unix()})
+	forged := parts[0] + "." + strings.Split(tampered, ".")[1] + "." + parts[2]
+	if _, err := client.VerifyIDToken(ctx, forged, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("expected forged token to be rejected, got %v", err)
+	}
+
+	// alg=none 的令牌被拒绝
+	unsigned := "eyJhbGciOiJub25lIn0." + parts[1] + "."
+	if _, err := client.VerifyIDToken(ctx, unsigned, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("expected unsigned token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package oidctest 提供本地模拟的 OIDC 身份提供方，用于测试授权码登录流程
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/oidc"
+)
+
+const keyID = "oidctest-key"
+
+type pendingCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// Issuer 模拟身份提供方
+// /authorize 不展示登录页，直接以当前设置的用户身份签发授权码并跳回 redirect_uri
+type Issuer struct {
+	Server *httptest.Server
+
+	key *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	claims map[string]interface{}
+	codes  map[string]pendingCode
+}
+
+// NewIssuer 启动模拟身份提供方，使用完毕后调用 Close
+func NewIssuer() *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer := &Issuer{
+		key:    key,
+		claims: map[string]interface{}{"sub": "user-1", "preferred_username": "alice"},
+		codes:  make(map[string]pendingCode),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+// URL 返回 issuer 地址
+func (i *Issuer) URL() string {
+	return i.Server.URL
+}
+
+// Close 关闭模拟服务
+func (i *Issuer) Close() {
+	i.Server.Close()
+}
+
+// SetUser 设置后续登录使用的用户 claims，必须包含 sub
+func (i *Issuer) SetUser(claims map[string]interface{}) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.claims = claims
+}
+
+// SignToken 用签名私钥签发任意 claims，用于构造异常 ID Token
+func (i *Issuer) SignToken(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, oidc.Provider{
+		Issuer:                i.URL(),
+		AuthorizationEndpoint: i.URL() + "/authorize",
+		TokenEndpoint:         i.URL() + "/token",
+		JWKSURI:               i.URL() + "/jwks",
+		SigningAlgs:           []string{"RS256"},
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := i.key.PublicKey
+	writeJSON(w, http.StatusOK, oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{{
+		Kty: "RSA",
+		Kid: keyID,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	target, err := url.Parse(redirectURI)
+	if err != nil || redirectURI == "" || query.Get("response_type") != "code" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code, _ := oidc.RandomString(16)
+
+	i.mutex.Lock()
+	i.codes[code] = pendingCode{
+		clientID:      query.Get("client_id"),
+		redirectURI:   redirectURI,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		claims:        i.claims,
+	}
+	i.mutex.Unlock()
+
+	values := target.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	target.RawQuery = values.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	code := r.PostForm.Get("code")
+
+	i.mutex.Lock()
+	pending, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mutex.Unlock()
+
+	clientID := r.PostForm.Get("client_id")
+	if user, _, hasBasic := r.BasicAuth(); hasBasic {
+		clientID, _ = url.QueryUnescape(user)
+	}
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok, r.PostForm.Get("grant_type") != "authorization_code":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case clientID != pending.clientID, r.PostForm.Get("redirect_uri") != pending.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	case pending.codeChallenge != base64.RawURLEncoding.EncodeToString(verifier[:]):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "pkce verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   i.URL(),
+		"aud":   pending.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": pending.nonce,
+	}
+	for k, v := range pending.claims {
+		claims[k] = v
+	}
+	writeJSON(w, http.StatusOK, oidc.TokenResponse{
+		AccessToken: "access-" + code,
+		TokenType:   "Bearer",
+		IDToken:     i.SignToken(claims),
+		ExpiresIn:   3600,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,125 @@
+package sandbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock 系统调用号在各架构上相同
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+
+	prSetNoNewPrivs = 38
+	oPath           = 0x200000
+)
+
+// 文件系统访问权限位，见 include/uapi/linux/landlock.h
+const (
+	accessFSWriteFile  = 1 << 1
+	accessFSRemoveDir  = 1 << 4
+	accessFSRemoveFile = 1 << 5
+	accessFSMakeChar   = 1 << 6
+	accessFSMakeDir    = 1 << 7
+	accessFSMakeReg    = 1 << 8
+	accessFSMakeSock   = 1 << 9
+	accessFSMakeFifo   = 1 << 10
+	accessFSMakeBlock  = 1 << 11
+	accessFSMakeSym    = 1 << 12
+	accessFSRefer      = 1 << 13 // ABI 2
+	accessFSTruncate   = 1 << 14 // ABI 3
+
+	// accessFSFile 可以授予单个文件的写权限
+	accessFSFile = accessFSWriteFile | accessFSTruncate
+)
+
+// landlockABI 返回内核支持的 Landlock ABI 版本，不支持时返回 0
+func landlockABI() int {
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// ReadOnlySupported 内核是否支持用 Landlock 限制文件写入
+func ReadOnlySupported() bool {
+	return landlockABI() > 0
+}
+
+// restrictWrites 禁止当前线程及其 exec 后的进程写入文件系统，writable 中的路径及其子路径除外；
+// 读取与执行不受限制。/dev/null 始终可写，便于命令丢弃输出
+func restrictWrites(writable []string) error {
+	abi := landlockABI()
+	if abi == 0 {
+		return ErrUnavailable
+	}
+	handled := uint64(accessFSWriteFile | accessFSRemoveDir | accessFSRemoveFile | accessFSMakeChar |
+		accessFSMakeDir | accessFSMakeReg | accessFSMakeSock | accessFSMakeFifo | accessFSMakeBlock | accessFSMakeSym)
+	if abi >= 2 {
+		handled |= accessFSRefer
+	}
+	if abi >= 3 {
+		handled |= accessFSTruncate
+	}
+
+	// struct landlock_ruleset_attr 的第一个字段
+	attr := handled
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("landlock create ruleset: %w", errno)
+	}
+	defer syscall.Close(int(fd))
+
+	for _, path := range append([]string{os.DevNull}, writable...) {
+		if err := addPathRule(int(fd), path, handled); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("set no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("landlock restrict self: %w", errno)
+	}
+	return nil
+}
+
+// addPathRule 授予 path 之下的写权限；文件只能授予文件相关的权限
+func addPathRule(rulesetFD int, path string, handled uint64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	allowed := handled
+	if !info.IsDir() {
+		allowed &= accessFSFile
+	}
+
+	pathFD, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer syscall.Close(pathFD)
+
+	// struct landlock_path_beneath_attr 为 packed 结构：u64 allowed_access + s32 parent_fd
+	var rule [12]byte
+	binary.NativeEndian.PutUint64(rule[0:8], allowed)
+	binary.NativeEndian.PutUint32(rule[8:12], uint32(int32(pathFD)))
+	if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(rulesetFD), landlockRulePathBeneath, uintptr(unsafe.Pointer(&rule[0])), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("landlock add rule %s: %w", path, errno)
+	}
+	return nil
+}
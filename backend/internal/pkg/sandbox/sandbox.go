@@ -0,0 +1,88 @@
+// Package sandbox 在隔离环境中执行智能体的 shell 命令。
+//
+// 命令不直接由服务进程执行，而是重新启动当前可执行文件作为沙箱子进程：
+// 子进程在独立的用户与网络命名空间中启动（不允许网络时没有可用网卡），
+// 设置 CPU 与内存上限、用 Landlock 将文件系统限制为只读（仅指定目录可写），
+// 然后 exec 为 /bin/sh -c。因此 main 函数需要在最开始调用 Init。
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable 当前平台或内核不支持所需的隔离
+var ErrUnavailable = errors.New("sandbox isolation unavailable")
+
+const (
+	// helperArg 沙箱子进程的命令行标记
+	helperArg = "__opendeepwiki_sandbox_exec"
+	// envOptions 传递给沙箱子进程的限制参数
+	envOptions = "OPENDEEPWIKI_SANDBOX"
+)
+
+// Options 沙箱限制
+type Options struct {
+	Network    bool     `json:"network"`     // 是否允许访问网络
+	CPUSeconds int      `json:"cpu_seconds"` // CPU 时间上限，0 表示不限制
+	MemoryMB   int      `json:"memory_mb"`   // 地址空间上限，0 表示不限制
+	Writable   []string `json:"writable"`    // 可写目录或文件，其余路径只读
+	// WeakReadOnly 内核不支持 Landlock 时退化为 RLIMIT_FSIZE=0：只禁止写入文件内容，
+	// 仍可创建、删除、重命名文件；未开启时拒绝执行命令
+	WeakReadOnly bool `json:"weak_read_only"`
+}
+
+// Command 构造在沙箱中执行 shell 命令的 exec.Cmd，调用方负责设置输出与运行。
+// env 为命令的完整环境变量，dir 为工作目录
+// 无法将文件系统限制为只读且未开启 WeakReadOnly 时返回 ErrUnavailable
+func Command(ctx context.Context, opts Options, dir, command string, env []string) (*exec.Cmd, error) {
+	if !opts.WeakReadOnly && !ReadOnlySupported() {
+		return nil, fmt.Errorf("%w: landlock is not supported by the kernel", ErrUnavailable)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate executable: %w", err)
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, self, helperArg, command)
+	cmd.Dir = dir
+	cmd.Env = append(env, envOptions+"="+string(data))
+	if err := isolate(cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Init 沙箱子进程入口：以沙箱子进程启动时应用限制并 exec 为 shell，不会返回；
+// 普通启动时直接返回。必须在 main 函数开头、启动其他 goroutine 之前调用
+func Init() {
+	if len(os.Args) != 3 || os.Args[1] != helperArg {
+		return
+	}
+	var opts Options
+	if err := json.Unmarshal([]byte(os.Getenv(envOptions)), &opts); err != nil {
+		fail(fmt.Errorf("invalid sandbox options: %w", err))
+	}
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envOptions+"=") {
+			env = append(env, kv)
+		}
+	}
+	// 只有 exec 失败时才会返回
+	fail(restrictAndExec(opts, os.Args[2], env))
+}
+
+// fail 沙箱子进程无法应用限制时退出，126 与 shell 的“无法执行”一致
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(126)
+}
@@ -0,0 +1,61 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// isolate 让沙箱子进程在新的用户命名空间中启动（普通用户也可创建），
+// 不允许网络时同时创建网络命名空间；命令超时后结束整个进程组
+func isolate(cmd *exec.Cmd, opts Options) error {
+	attr := &syscall.SysProcAttr{
+		Setpgid:     true,
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	if !opts.Network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr = attr
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return nil
+}
+
+// restrictAndExec 在沙箱子进程中设置资源上限与只读文件系统，然后 exec 为 shell
+func restrictAndExec(opts Options, command string, env []string) error {
+	// Landlock 与 no_new_privs 作用于当前线程，必须在同一线程上 exec
+	runtime.LockOSThread()
+
+	if opts.CPUSeconds > 0 {
+		if err := setrlimit(syscall.RLIMIT_CPU, uint64(opts.CPUSeconds)); err != nil {
+			return err
+		}
+	}
+	if opts.MemoryMB > 0 {
+		if err := setrlimit(syscall.RLIMIT_AS, uint64(opts.MemoryMB)<<20); err != nil {
+			return err
+		}
+	}
+	if err := restrictWrites(opts.Writable); err != nil {
+		if !errors.Is(err, ErrUnavailable) || !opts.WeakReadOnly {
+			return err
+		}
+		// 显式开启后，内核未启用 Landlock 时退化为禁止写入普通文件内容
+		if err := setrlimit(syscall.RLIMIT_FSIZE, 0); err != nil {
+			return err
+		}
+	}
+	return syscall.Exec("/bin/sh", []string{"sh", "-c", command}, env)
+}
+
+func setrlimit(resource int, value uint64) error {
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+}
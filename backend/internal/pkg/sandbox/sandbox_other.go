@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+// isolate 非 Linux 平台没有可用的命名空间隔离
+func isolate(cmd *exec.Cmd, opts Options) error {
+	return ErrUnavailable
+}
+
+func restrictAndExec(opts Options, command string, env []string) error {
+	return ErrUnavailable
+}
+
+// ReadOnlySupported 非 Linux 平台不支持只读文件系统限制
+func ReadOnlySupported() bool {
+	return false
+}
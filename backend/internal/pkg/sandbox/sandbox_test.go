@@ -0,0 +1,110 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+// run 在沙箱中执行命令，环境不支持隔离时跳过测试
+func run(t *testing.T, opts Options, dir, command string) (string, error) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox requires linux")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd, err := Command(ctx, opts, dir, command, []string{"PATH=" + os.Getenv("PATH")})
+	if errors.Is(err, ErrUnavailable) {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	if err != nil && cmd.ProcessState == nil {
+		t.Skipf("user namespaces unavailable: %v", err)
+	}
+	return out.String(), err
+}
+
+func TestCommandIsolatesNetwork(t *testing.T) {
+	out, err := run(t, Options{}, t.TempDir(), "cat /proc/net/dev")
+	if err != nil {
+		t.Fatalf("run error: %v, output=%s", err, out)
+	}
+	for _, line := range strings.Split(out, "\n")[2:] {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && name != "lo" {
+			t.Errorf("unexpected network interface %q in sandbox", name)
+		}
+	}
+}
+
+func TestCommandReadOnly(t *testing.T) {
+	if !ReadOnlySupported() {
+		t.Skip("landlock unavailable")
+	}
+	dir := t.TempDir()
+	scratch := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Writable: []string{scratch}}
+
+	if out, err := run(t, opts, dir, "cat README.md && echo ok > "+filepath.Join(scratch, "out.txt")+" && ls missing 2>/dev/null; true"); err != nil || !strings.Contains(out, "hello") {
+		t.Fatalf("read and scratch write should succeed: %v, %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(scratch, "out.txt")); err != nil {
+		t.Fatalf("scratch file not written: %v", err)
+	}
+	for _, command := range []string{"echo x > new.txt", "rm README.md", "mkdir sub", "echo x >> README.md"} {
+		if _, err := run(t, opts, dir, command); err == nil {
+			t.Errorf("%q should fail in read-only sandbox", command)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "hello" {
+		t.Errorf("README.md modified: %q", data)
+	}
+}
+
+func TestCommandMemoryLimit(t *testing.T) {
+	out, err := run(t, Options{MemoryMB: 64}, t.TempDir(), "ulimit -v")
+	if err != nil {
+		t.Fatalf("run error: %v, output=%s", err, out)
+	}
+	if strings.TrimSpace(out) != "65536" {
+		t.Errorf("ulimit -v = %q, want 65536", out)
+	}
+}
+
+// TestCommandRequiresReadOnly 无法限制文件系统时默认拒绝执行，显式开启 WeakReadOnly 才退化执行
+func TestCommandRequiresReadOnly(t *testing.T) {
+	if ReadOnlySupported() {
+		t.Skip("landlock available")
+	}
+	if _, err := Command(context.Background(), Options{}, t.TempDir(), "true", nil); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	dir := t.TempDir()
+	out, err := run(t, Options{WeakReadOnly: true}, dir, "echo hi > out.txt")
+	if err == nil {
+		t.Fatalf("write should fail with RLIMIT_FSIZE=0, output=%s", out)
+	}
+}
@@ -0,0 +1,108 @@
+// Package secret 使用 AES-256-GCM 加密落库的敏感配置，例如 Git 凭证。
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// version 密文前缀，便于日后更换算法
+const version = "v1:"
+
+// ErrDecrypt 密文损坏或密钥不匹配
+var ErrDecrypt = errors.New("secret: decrypt failed")
+
+// Box 对称加解密器
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox 创建加解密器，key 为任意长度的口令，经 SHA-256 派生为 AES-256 密钥
+func NewBox(key string) (*Box, error) {
+	if key == "" {
+		return nil, errors.New("secret: empty key")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Encrypt 加密明文，每次使用随机 nonce，相同明文得到不同密文
+func (b *Box) Encrypt(plain string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return version + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文
+func (b *Box) Decrypt(encoded string) (string, error) {
+	if !strings.HasPrefix(encoded, version) {
+		return "", ErrDecrypt
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, version))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// LoadOrCreateKey 读取密钥文件，不存在时生成随机密钥并以 0600 权限写入
+// 密钥文件与数据库分开存放，仅拿到数据库无法解密凭证
+func LoadOrCreateKey(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", fmt.Errorf("secret: key file %s is empty", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	key := base64.StdEncoding.EncodeToString(raw)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	// O_EXCL 避免并发启动时互相覆盖
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return LoadOrCreateKey(path)
+		}
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(key + "\n"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
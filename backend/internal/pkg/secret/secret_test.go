@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBoxRoundTrip(t *testing.T) {
+	box, err := NewBox("passphrase")
+	if err != nil {
+		t.Fatalf("NewBox error: %v", err)
+	}
+	first, err := box.Encrypt("ghp_token")
+	if err != nil {
+		t.Fatalf("Encrypt error: %v", err)
+	}
+	second, _ := box.Encrypt("ghp_token")
+	if first == second || strings.Contains(first, "ghp_token") {
+		t.Fatalf("ciphertext must be randomized and opaque: %s %s", first, second)
+	}
+	plain, err := box.Decrypt(first)
+	if err != nil || plain != "ghp_token" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	other, _ := NewBox("other")
+	if _, err := other.Decrypt(first); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected decrypt error with wrong key, got %v", err)
+	}
+	tampered := first[:len(first)-2] + "AA"
+	if _, err := box.Decrypt(tampered); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected decrypt error for tampered ciphertext, got %v", err)
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "credential.key")
+	key, err := LoadOrCreateKey(path)
+	if err != nil || key == "" {
+		t.Fatalf("LoadOrCreateKey error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("key file should be 0600: %v %v", info, err)
+	}
+	again, err := LoadOrCreateKey(path)
+	if err != nil || again != key {
+		t.Fatalf("key should be stable across loads: %q %q %v", key, again, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package webhook 解析 GitHub、GitLab、Gitea 的推送 Webhook 请求并校验签名。
+//
+//	GitHub: X-GitHub-Event，X-Hub-Signature-256 为 sha256=HMAC-SHA256(secret, body)
+//	Gitea:  X-Gitea-Event，X-Gitea-Signature 为 HMAC-SHA256(secret, body)；
+//	        为兼容 GitHub 同时发送 X-GitHub-Event，因此先于 GitHub 识别
+//	GitLab: X-Gitlab-Event，X-Gitlab-Token 直接携带密钥
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 推送来源
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+var (
+	// ErrUnknownProvider 请求头中没有可识别的平台事件
+	ErrUnknownProvider = errors.New("unknown webhook provider")
+	// ErrInvalidSignature 签名缺失或与密钥不匹配
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrInvalidPayload 请求体不是合法的推送事件
+	ErrInvalidPayload = errors.New("invalid webhook payload")
+)
+
+// zeroCommit 删除分支时 after 为全零提交
+const zeroCommit = "0000000000000000000000000000000000000000"
+
+// Event 解析后的 Webhook 事件
+type Event struct {
+	Provider string
+	Name     string // 平台原始事件名，例如 push、Push Hook、ping
+	Push     bool   // 是否为分支推送，标签推送与其他事件为 false
+	Branch   string // 推送的分支名
+	After    string // 推送后的提交
+	Deleted  bool   // 分支被删除
+}
+
+// pushPayload 三个平台推送事件的公共字段
+type pushPayload struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+}
+
+// Parse 识别平台、用 secret 校验签名并解析推送事件；签名校验先于解析请求体
+func Parse(header http.Header, body []byte, secret string) (*Event, error) {
+	event := &Event{}
+	switch {
+	case header.Get("X-Gitea-Event") != "":
+		event.Provider, event.Name = ProviderGitea, header.Get("X-Gitea-Event")
+		if !verifyHMAC(body, secret, header.Get("X-Gitea-Signature")) {
+			return nil, ErrInvalidSignature
+		}
+	case header.Get("X-GitHub-Event") != "":
+		event.Provider, event.Name = ProviderGitHub, header.Get("X-GitHub-Event")
+		if !verifyHMAC(body, secret, strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")) {
+			return nil, ErrInvalidSignature
+		}
+	case header.Get("X-Gitlab-Event") != "":
+		event.Provider, event.Name = ProviderGitLab, header.Get("X-Gitlab-Event")
+		token := header.Get("X-Gitlab-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return nil, ErrInvalidSignature
+		}
+	default:
+		return nil, ErrUnknownProvider
+	}
+
+	if event.Name != "push" && event.Name != "Push Hook" {
+		return event, nil
+	}
+	var payload pushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	branch, ok := strings.CutPrefix(payload.Ref, "refs/heads/")
+	if !ok {
+		// 标签推送等非分支引用
+		return event, nil
+	}
+	event.Push = true
+	event.Branch = branch
+	event.After = payload.After
+	event.Deleted = payload.Deleted || payload.After == zeroCommit
+	return event, nil
+}
+
+// Sign 计算 GitHub、Gitea 使用的 HMAC-SHA256 十六进制签名
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyHMAC(body []byte, secret, signature string) bool {
+	if signature == "" || secret == "" {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
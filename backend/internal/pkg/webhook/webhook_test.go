@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"ref":"refs/heads/main","after":"abc123"}`)
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	cases := []struct {
+		name     string
+		header   http.Header
+		provider string
+	}{
+		{"github", header("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+Sign(body, secret)), ProviderGitHub},
+		{"gitea", header("X-Gitea-Event", "push", "X-Gitea-Signature", Sign(body, secret)), ProviderGitea},
+		// Gitea 实际发送的请求头同时包含 GitHub、Gogs 兼容头
+		{"gitea compat headers", header(
+			"X-GitHub-Event", "push", "X-GitHub-Event-Type", "push", "X-Gogs-Event", "push",
+			"X-Gitea-Event", "push", "X-Gitea-Event-Type", "push", "X-Gitea-Delivery", "f6266f16-1bf3-46a5-9ea4-602e06ead473",
+			"X-Gitea-Signature", Sign(body, secret), "X-Gogs-Signature", Sign(body, secret),
+			"X-Hub-Signature", "sha1=invalid", "X-Hub-Signature-256", "sha256="+Sign(body, secret),
+		), ProviderGitea},
+		{"gitlab", header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", secret), ProviderGitLab},
+	}
+	for _, tc := range cases {
+		event, err := Parse(tc.header, body, secret)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", tc.name, err)
+		}
+		if event.Provider != tc.provider || !event.Push || event.Branch != "main" || event.After != "abc123" || event.Deleted {
+			t.Errorf("%s: unexpected event %+v", tc.name, event)
+		}
+	}
+
+	invalid := []http.Header{
+		header("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+Sign(body, "other")),
+		header("X-GitHub-Event", "push"),
+		header("X-Gitea-Event", "push", "X-Gitea-Signature", "not-hex"),
+		// Gitea 请求按 X-Gitea-Signature 校验，不接受仅 GitHub 签名有效的请求
+		header("X-GitHub-Event", "push", "X-Gitea-Event", "push", "X-Hub-Signature-256", "sha256="+Sign(body, secret)),
+		header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", "other"),
+	}
+	for _, h := range invalid {
+		if _, err := Parse(h, body, secret); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("headers %v: error = %v, want ErrInvalidSignature", h, err)
+		}
+	}
+	if _, err := Parse(http.Header{}, body, secret); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("missing event header: %v", err)
+	}
+
+	// 标签推送、ping 与删除分支
+	tag := []byte(`{"ref":"refs/tags/v1.0","after":"abc123"}`)
+	if event, err := Parse(header("X-Gitlab-Event", "Tag Push Hook", "X-Gitlab-Token", secret), tag, secret); err != nil || event.Push {
+		t.Errorf("tag push = %+v, %v", event, err)
+	}
+	if event, err := Parse(header("X-GitHub-Event", "ping", "X-Hub-Signature-256", "sha256="+Sign([]byte(`{}`), secret)), []byte(`{}`), secret); err != nil || event.Push || event.Name != "ping" {
+		t.Errorf("ping = %+v, %v", event, err)
+	}
+	deleted := []byte(`{"ref":"refs/heads/feature","after":"0000000000000000000000000000000000000000"}`)
+	if event, err := Parse(header("X-Gitea-Event", "push", "X-Gitea-Signature", Sign(deleted, secret)), deleted, secret); err != nil || !event.Deleted {
+		t.Errorf("deleted branch = %+v, %v", event, err)
+	}
+}
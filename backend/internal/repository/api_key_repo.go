@@ -67,23 +67,27 @@ func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
 
 // Create 创建 API Key 配置
 func (r *apiKeyRepository) Create(ctx context.Context, apiKey *model.APIKey) error {
+	apiKey.WorkspaceID = workspaceIDForCreate(ctx, apiKey.WorkspaceID)
 	return r.db.WithContext(ctx).Create(apiKey).Error
 }
 
 // Update 更新 API Key 配置
 func (r *apiKeyRepository) Update(ctx context.Context, apiKey *model.APIKey) error {
+	if workspaceID, ok := WorkspaceFromContext(ctx); ok && apiKey.WorkspaceID != workspaceID {
+		return ErrAPIKeyNotFound
+	}
 	return r.db.WithContext(ctx).Save(apiKey).Error
 }
 
 // Delete 软删除 API Key 配置
 func (r *apiKeyRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.APIKey{}, id).Error
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Delete(&model.APIKey{}, id).Error
 }
 
 // GetByID 根据 ID 获取
 func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
 	var apiKey model.APIKey
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&apiKey).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Where("id = ?", id).First(&apiKey).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrAPIKeyNotFound
@@ -96,7 +100,7 @@ func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey,
 // GetByName 根据名称获取
 func (r *apiKeyRepository) GetByName(ctx context.Context, name string) (*model.APIKey, error) {
 	var apiKey model.APIKey
-	err := r.db.WithContext(ctx).Where("name = ? AND deleted_at IS NULL", name).First(&apiKey).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Where("name = ? AND deleted_at IS NULL", name).First(&apiKey).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrAPIKeyNotFound
@@ -112,7 +116,7 @@ func (r *apiKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
 	r.releaseExpiredRateLimits(ctx)
 
 	var apiKeys []*model.APIKey
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("deleted_at IS NULL").
 		Order("priority ASC, id ASC").
 		Find(&apiKeys).Error
@@ -122,7 +126,7 @@ func (r *apiKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
 // ListByProvider 按提供商列出配置
 func (r *apiKeyRepository) ListByProvider(ctx context.Context, provider string) ([]*model.APIKey, error) {
 	var apiKeys []*model.APIKey
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("provider = ? AND deleted_at IS NULL", provider).
 		Order("priority ASC, id ASC").
 		Find(&apiKeys).Error
@@ -135,7 +139,7 @@ func (r *apiKeyRepository) ListByNames(ctx context.Context, names []string) ([]*
 		return []*model.APIKey{}, nil
 	}
 	var apiKeys []*model.APIKey
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("name IN ? AND status = ? AND deleted_at IS NULL", names, "enabled").
 		Where("rate_limit_reset_at IS NULL OR rate_limit_reset_at < ?", time.Now()).
 		Order("priority ASC, id ASC").
@@ -146,7 +150,7 @@ func (r *apiKeyRepository) ListByNames(ctx context.Context, names []string) ([]*
 // GetHighestPriority 获取优先级最高的可用配置
 func (r *apiKeyRepository) GetHighestPriority(ctx context.Context) (*model.APIKey, error) {
 	var apiKey model.APIKey
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("status = ? AND deleted_at IS NULL", "enabled").
 		Where("rate_limit_reset_at IS NULL OR rate_limit_reset_at < ?", time.Now()).
 		Order("priority ASC, id ASC").
@@ -162,7 +166,7 @@ func (r *apiKeyRepository) GetHighestPriority(ctx context.Context) (*model.APIKe
 
 // UpdateStatus 更新状态
 func (r *apiKeyRepository) UpdateStatus(ctx context.Context, id uint, status string) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.APIKey{}).
 		Where("id = ?", id).
 		Update("status", status).Error
@@ -170,7 +174,7 @@ func (r *apiKeyRepository) UpdateStatus(ctx context.Context, id uint, status str
 
 // IncrementStats 增加统计信息
 func (r *apiKeyRepository) IncrementStats(ctx context.Context, id uint, requestCount int, errorCount int) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.APIKey{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
@@ -181,7 +185,7 @@ func (r *apiKeyRepository) IncrementStats(ctx context.Context, id uint, requestC
 
 // UpdateLastUsedAt 更新最后使用时间
 func (r *apiKeyRepository) UpdateLastUsedAt(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.APIKey{}).
 		Where("id = ?", id).
 		Update("last_used_at", time.Now()).Error
@@ -189,7 +193,7 @@ func (r *apiKeyRepository) UpdateLastUsedAt(ctx context.Context, id uint) error
 
 // SetRateLimitReset 设置速率限制重置时间
 func (r *apiKeyRepository) SetRateLimitReset(ctx context.Context, id uint, resetTime time.Time) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.APIKey{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
@@ -209,7 +213,7 @@ func (r *apiKeyRepository) GetStats(ctx context.Context) (map[string]interface{}
 	}
 
 	var result StatsResult
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.APIKey{}).
 		Select(`
 			COUNT(*) as total_count,
@@ -33,13 +33,14 @@ func NewChatSessionRepository(db *gorm.DB) ChatSessionRepository {
 
 // Create 创建会话
 func (r *chatSessionRepository) Create(ctx context.Context, session *model.ChatSession) error {
+	session.WorkspaceID = workspaceIDForCreate(ctx, session.WorkspaceID)
 	return r.db.WithContext(ctx).Create(session).Error
 }
 
 // GetBySessionID 根据sessionID获取会话
 func (r *chatSessionRepository) GetBySessionID(ctx context.Context, sessionID string) (*model.ChatSession, error) {
 	var session model.ChatSession
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("session_id = ?", sessionID).
 		First(&session).Error
 	if err != nil {
@@ -54,7 +55,7 @@ func (r *chatSessionRepository) ListByRepoID(ctx context.Context, repoID uint, p
 	var total int64
 
 	// 查询总数
-	if err := r.db.WithContext(ctx).
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("repo_id = ? AND status != 'deleted'", repoID).
 		Count(&total).Error; err != nil {
@@ -63,7 +64,7 @@ func (r *chatSessionRepository) ListByRepoID(ctx context.Context, repoID uint, p
 
 	// 查询列表
 	offset := (page - 1) * pageSize
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("repo_id = ? AND status != 'deleted'", repoID).
 		Order("updated_at DESC").
 		Offset(offset).
@@ -78,12 +79,15 @@ func (r *chatSessionRepository) ListByRepoID(ctx context.Context, repoID uint, p
 
 // Update 更新会话
 func (r *chatSessionRepository) Update(ctx context.Context, session *model.ChatSession) error {
+	if workspaceID, ok := WorkspaceFromContext(ctx); ok && session.WorkspaceID != workspaceID {
+		return gorm.ErrRecordNotFound
+	}
 	return r.db.WithContext(ctx).Save(session).Error
 }
 
 // Delete 删除会话（软删除）
 func (r *chatSessionRepository) Delete(ctx context.Context, sessionID string) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("session_id = ?", sessionID).
 		Update("status", "deleted").Error
@@ -91,7 +95,7 @@ func (r *chatSessionRepository) Delete(ctx context.Context, sessionID string) er
 
 // UpdateTitle 更新会话标题
 func (r *chatSessionRepository) UpdateTitle(ctx context.Context, sessionID, title string) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("session_id = ?", sessionID).
 		Updates(map[string]interface{}{
@@ -106,7 +110,7 @@ func (r *chatSessionRepository) ListPublicByRepoID(ctx context.Context, repoID u
 	var total int64
 
 	// 查询总数
-	if err := r.db.WithContext(ctx).
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("repo_id = ? AND status != 'deleted' AND visibility = 'public'", repoID).
 		Count(&total).Error; err != nil {
@@ -115,7 +119,7 @@ func (r *chatSessionRepository) ListPublicByRepoID(ctx context.Context, repoID u
 
 	// 查询列表
 	offset := (page - 1) * pageSize
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Where("repo_id = ? AND status != 'deleted' AND visibility = 'public'", repoID).
 		Order("updated_at DESC").
 		Offset(offset).
@@ -130,7 +134,7 @@ func (r *chatSessionRepository) ListPublicByRepoID(ctx context.Context, repoID u
 
 // UpdateVisibility 更新会话可见性
 func (r *chatSessionRepository) UpdateVisibility(ctx context.Context, sessionID, visibility string) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("session_id = ?", sessionID).
 		Update("visibility", visibility).Error
@@ -138,7 +142,7 @@ func (r *chatSessionRepository) UpdateVisibility(ctx context.Context, sessionID,
 
 // UpdateMessageCount 更新消息数量
 func (r *chatSessionRepository) UpdateMessageCount(ctx context.Context, sessionID string, count int) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).
 		Model(&model.ChatSession{}).
 		Where("session_id = ?", sessionID).
 		Update("message_count", count).Error
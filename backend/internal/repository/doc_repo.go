@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"time"
@@ -18,13 +19,25 @@ func NewDocumentRepository(db *gorm.DB) DocumentRepository {
 }
 
 func (r *documentRepository) Create(doc *model.Document) error {
-	// 从关联的 Repository 获取 clone_branch 和 clone_commit_id
+	fillCloneInfo(r.db, doc)
+	return r.db.Create(doc).Error
+}
+
+// fillCloneInfo 从文档所属的仓库或引用文档集获取 clone_branch 和 clone_commit_id
+func fillCloneInfo(db *gorm.DB, doc *model.Document) {
+	if doc.Ref != "" {
+		var ref model.RepositoryRef
+		if err := db.Where("repository_id = ? AND name = ?", doc.RepositoryID, doc.Ref).First(&ref).Error; err == nil {
+			doc.CloneBranch = ref.Name
+			doc.CloneCommitID = ref.CommitID
+		}
+		return
+	}
 	var repo model.Repository
-	if err := r.db.First(&repo, doc.RepositoryID).Error; err == nil {
+	if err := db.First(&repo, doc.RepositoryID).Error; err == nil {
 		doc.CloneBranch = repo.CloneBranch
 		doc.CloneCommitID = repo.CloneCommit
 	}
-	return r.db.Create(doc).Error
 }
 
 func (r *documentRepository) GetVersions(repoID uint, title string) ([]model.Document, error) {
@@ -35,19 +48,24 @@ func (r *documentRepository) GetVersions(repoID uint, title string) ([]model.Doc
 	return docs, err
 }
 
-// GetAllDocumentsTitleAndID 获取指定仓库的所有文档标题与ID。
+// GetAllDocumentsTitleAndID 获取指定仓库主文档集的所有文档标题与ID。
 func (r *documentRepository) GetAllDocumentsTitleAndID(repoID uint) ([]model.Document, error) {
 	var docs []model.Document
-	err := r.db.Where("repository_id = ?", repoID).
+	err := r.db.Where("repository_id = ? AND ref = ?", repoID, "").
 		Select("title, id").
 		Find(&docs).Error
 	return docs, err
 }
 
-// GetByRepository 获取指定仓库的所有最新文档。
+// GetByRepository 获取指定仓库主文档集的所有最新文档。
 func (r *documentRepository) GetByRepository(repoID uint) ([]model.Document, error) {
+	return r.GetByRepositoryRef(repoID, "")
+}
+
+// GetByRepositoryRef 获取指定仓库某个引用文档集的所有最新文档，ref 为空表示主文档集。
+func (r *documentRepository) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
 	var docs []model.Document
-	err := r.db.Where("repository_id = ? AND is_latest = ?", repoID, true).
+	err := r.db.Where("repository_id = ? AND ref = ? AND is_latest = ?", repoID, ref, true).
 		Order("sort_order").
 		Find(&docs).Error
 	return docs, err
@@ -62,6 +80,15 @@ func (r *documentRepository) Get(id uint) (*model.Document, error) {
 	return &doc, nil
 }
 
+func (r *documentRepository) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	var doc model.Document
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspaceByRepo(ctx)).First(&doc, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
 func (r *documentRepository) Save(doc *model.Document) error {
 	return r.db.Save(doc).Error
 }
@@ -78,6 +105,11 @@ func (r *documentRepository) DeleteByRepositoryID(repoID uint) error {
 	return r.db.Where("repository_id = ?", repoID).Delete(&model.Document{}).Error
 }
 
+// DeleteByRepositoryRef 删除仓库某个引用文档集的所有文档。
+func (r *documentRepository) DeleteByRepositoryRef(repoID uint, ref string) error {
+	return r.db.Where("repository_id = ? AND ref = ?", repoID, ref).Delete(&model.Document{}).Error
+}
+
 func (r *documentRepository) CreateVersioned(doc *model.Document) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		var maxVersion sql.NullInt64
@@ -102,12 +134,7 @@ func (r *documentRepository) CreateVersioned(doc *model.Document) error {
 			return err
 		}
 
-		// 从关联的 Repository 获取 clone_branch 和 clone_commit_id
-		var repo model.Repository
-		if err := tx.First(&repo, doc.RepositoryID).Error; err == nil {
-			doc.CloneBranch = repo.CloneBranch
-			doc.CloneCommitID = repo.CloneCommit
-		}
+		fillCloneInfo(tx, doc)
 
 		doc.Version = nextVersion
 		doc.IsLatest = true
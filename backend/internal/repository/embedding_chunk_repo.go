@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// 扫描向量时每批读取的分块数
+const embeddingScanBatchSize = 500
+
+type embeddingChunkRepository struct {
+	db *gorm.DB
+}
+
+// NewEmbeddingChunkRepository 创建向量分块仓储
+func NewEmbeddingChunkRepository(db *gorm.DB) EmbeddingChunkRepository {
+	return &embeddingChunkRepository{db: db}
+}
+
+func (r *embeddingChunkRepository) sourceScope(db *gorm.DB, repoID uint, sourceType string, sourceID uint) *gorm.DB {
+	db = db.Where("repository_id = ? AND source_type = ?", repoID, sourceType)
+	if sourceID != 0 {
+		db = db.Where("source_id = ?", sourceID)
+	}
+	return db
+}
+
+// ReplaceChunks 在事务中删除来源的旧分块并写入新分块
+func (r *embeddingChunkRepository) ReplaceChunks(ctx context.Context, repoID uint, sourceType string, sourceID uint, chunks []model.EmbeddingChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.sourceScope(tx, repoID, sourceType, sourceID).Delete(&model.EmbeddingChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&chunks, 200).Error
+	})
+}
+
+// ListChunks 查询来源的分块
+func (r *embeddingChunkRepository) ListChunks(ctx context.Context, repoID uint, sourceType string, sourceID uint) ([]model.EmbeddingChunk, error) {
+	var chunks []model.EmbeddingChunk
+	err := r.sourceScope(r.db.WithContext(ctx), repoID, sourceType, sourceID).
+		Order("id ASC").
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// ScanVectors 分批扫描分块向量，不读取正文
+func (r *embeddingChunkRepository) ScanVectors(ctx context.Context, providerName string, repoID uint, sourceType string, fn func(chunks []model.EmbeddingChunk) error) error {
+	query := r.db.WithContext(ctx).
+		Model(&model.EmbeddingChunk{}).
+		Select("id", "repository_id", "source_type", "source_id", "dimensions", "vector").
+		Where("provider_name = ?", providerName)
+	if repoID != 0 {
+		query = query.Where("repository_id = ?", repoID)
+	}
+	if sourceType != "" {
+		query = query.Where("source_type = ?", sourceType)
+	}
+
+	var batch []model.EmbeddingChunk
+	return query.FindInBatches(&batch, embeddingScanBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// GetByIDs 根据 ID 列表获取分块（含正文）
+func (r *embeddingChunkRepository) GetByIDs(ctx context.Context, ids []uint) ([]model.EmbeddingChunk, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var chunks []model.EmbeddingChunk
+	err := r.db.WithContext(ctx).
+		Omit("vector").
+		Where("id IN ?", ids).
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// CountByRepository 统计仓库下某类型的分块数
+func (r *embeddingChunkRepository) CountByRepository(ctx context.Context, repoID uint, sourceType string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.EmbeddingChunk{}).
+		Where("repository_id = ? AND source_type = ?", repoID, sourceType).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteDocumentVersions 删除同仓库同标题的其他文档分块
+func (r *embeddingChunkRepository) DeleteDocumentVersions(ctx context.Context, repoID uint, title string, keepDocID uint) error {
+	return r.db.WithContext(ctx).
+		Where("repository_id = ? AND source_type = ? AND title = ? AND source_id <> ?", repoID, model.EmbeddingSourceDocument, title, keepDocID).
+		Delete(&model.EmbeddingChunk{}).Error
+}
+
+// DeleteBySource 删除来源的全部分块
+func (r *embeddingChunkRepository) DeleteBySource(ctx context.Context, repoID uint, sourceType string, sourceID uint) error {
+	return r.sourceScope(r.db.WithContext(ctx), repoID, sourceType, sourceID).Delete(&model.EmbeddingChunk{}).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrEmbeddingProviderNotFound 向量模型配置不存在错误
+var ErrEmbeddingProviderNotFound = errors.New("embedding provider not found")
+
+// ErrEmbeddingProviderDuplicate 向量模型配置名称重复错误
+var ErrEmbeddingProviderDuplicate = errors.New("embedding provider name already exists")
+
+type embeddingProviderRepository struct {
+	db *gorm.DB
+}
+
+// NewEmbeddingProviderRepository 创建向量模型配置仓储
+func NewEmbeddingProviderRepository(db *gorm.DB) EmbeddingProviderRepository {
+	return &embeddingProviderRepository{db: db}
+}
+
+// Create 创建配置
+func (r *embeddingProviderRepository) Create(ctx context.Context, provider *model.EmbeddingProvider) error {
+	return r.db.WithContext(ctx).Create(provider).Error
+}
+
+// Update 更新配置
+func (r *embeddingProviderRepository) Update(ctx context.Context, provider *model.EmbeddingProvider) error {
+	return r.db.WithContext(ctx).Save(provider).Error
+}
+
+// Delete 删除配置
+func (r *embeddingProviderRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.EmbeddingProvider{}, id).Error
+}
+
+// GetByID 根据 ID 获取
+func (r *embeddingProviderRepository) GetByID(ctx context.Context, id uint) (*model.EmbeddingProvider, error) {
+	var provider model.EmbeddingProvider
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&provider).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmbeddingProviderNotFound
+		}
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// GetByName 根据名称获取
+func (r *embeddingProviderRepository) GetByName(ctx context.Context, name string) (*model.EmbeddingProvider, error) {
+	var provider model.EmbeddingProvider
+	err := r.db.WithContext(ctx).Where("name = ? AND deleted_at IS NULL", name).First(&provider).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmbeddingProviderNotFound
+		}
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// List 列出所有配置（按优先级排序，包含已禁用）
+func (r *embeddingProviderRepository) List(ctx context.Context) ([]*model.EmbeddingProvider, error) {
+	var providers []*model.EmbeddingProvider
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL").
+		Order("priority ASC, id ASC").
+		Find(&providers).Error
+	return providers, err
+}
+
+// IncrementStats 增加统计信息并更新最后使用时间
+func (r *embeddingProviderRepository) IncrementStats(ctx context.Context, id uint, requestCount int, errorCount int) error {
+	return r.db.WithContext(ctx).
+		Model(&model.EmbeddingProvider{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"request_count": gorm.Expr("request_count + ?", requestCount),
+			"error_count":   gorm.Expr("error_count + ?", errorCount),
+			"last_used_at":  gorm.Expr("CURRENT_TIMESTAMP"),
+		}).Error
+}
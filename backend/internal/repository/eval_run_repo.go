@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrEvalRunNotFound 评测记录不存在
+var ErrEvalRunNotFound = errors.New("eval run not found")
+
+type evalRunRepository struct {
+	db *gorm.DB
+}
+
+// NewEvalRunRepository 创建 Agent 评测记录仓储
+func NewEvalRunRepository(db *gorm.DB) EvalRunRepository {
+	return &evalRunRepository{db: db}
+}
+
+func (r *evalRunRepository) Create(ctx context.Context, run *model.EvalRun) error {
+	return r.db.WithContext(ctx).Omit("Results").Create(run).Error
+}
+
+func (r *evalRunRepository) Save(ctx context.Context, run *model.EvalRun) error {
+	return r.db.WithContext(ctx).Omit("Results").Save(run).Error
+}
+
+func (r *evalRunRepository) AddResult(ctx context.Context, result *model.EvalResult) error {
+	return r.db.WithContext(ctx).Create(result).Error
+}
+
+func (r *evalRunRepository) Get(ctx context.Context, id uint) (*model.EvalRun, error) {
+	var run model.EvalRun
+	err := r.db.WithContext(ctx).Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&run, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEvalRunNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *evalRunRepository) ListByAgent(ctx context.Context, agentFile string, limit int) ([]model.EvalRun, error) {
+	var runs []model.EvalRun
+	err := r.db.WithContext(ctx).Where("agent_file = ?", agentFile).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+func (r *evalRunRepository) MarkInterrupted(ctx context.Context) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.EvalRun{}).Where("status = ?", model.EvalStatusRunning).
+		Updates(map[string]interface{}{"status": model.EvalStatusFailed, "error": "interrupted by server restart", "finished_at": &now})
+	return result.RowsAffected, result.Error
+}
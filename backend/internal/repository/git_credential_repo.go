@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrGitCredentialNotFound Git 凭证不存在错误
+var ErrGitCredentialNotFound = errors.New("git credential not found")
+
+type gitCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewGitCredentialRepository 创建 Git 凭证仓储
+func NewGitCredentialRepository(db *gorm.DB) GitCredentialRepository {
+	return &gitCredentialRepository{db: db}
+}
+
+func (r *gitCredentialRepository) Create(ctx context.Context, credential *model.GitCredential) error {
+	credential.WorkspaceID = workspaceIDForCreate(ctx, credential.WorkspaceID)
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *gitCredentialRepository) Save(ctx context.Context, credential *model.GitCredential) error {
+	if workspaceID, ok := WorkspaceFromContext(ctx); ok && credential.WorkspaceID != workspaceID {
+		return ErrGitCredentialNotFound
+	}
+	return r.db.WithContext(ctx).Save(credential).Error
+}
+
+func (r *gitCredentialRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Delete(&model.GitCredential{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGitCredentialNotFound
+	}
+	return nil
+}
+
+func (r *gitCredentialRepository) GetByID(ctx context.Context, id uint) (*model.GitCredential, error) {
+	var credential model.GitCredential
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).First(&credential, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGitCredentialNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *gitCredentialRepository) GetByName(ctx context.Context, name string) (*model.GitCredential, error) {
+	var credential model.GitCredential
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Where("name = ?", name).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGitCredentialNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *gitCredentialRepository) List(ctx context.Context) ([]model.GitCredential, error) {
+	var credentials []model.GitCredential
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Order("host asc, owner asc, id asc").Find(&credentials).Error
+	return credentials, err
+}
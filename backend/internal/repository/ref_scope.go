@@ -0,0 +1,22 @@
+package repository
+
+import "context"
+
+type docRefContextKey struct{}
+
+// WithDocRef 返回携带引用文档集的 context，在该 context 中创建的任务与文档归属该文档集
+func WithDocRef(ctx context.Context, ref string) context.Context {
+	if ref == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, docRefContextKey{}, ref)
+}
+
+// DocRefFromContext 读取 context 中的引用文档集，未设置时为仓库主文档集
+func DocRefFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ref, _ := ctx.Value(docRefContextKey{}).(string)
+	return ref
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"gorm.io/gorm"
 )
@@ -13,38 +15,42 @@ func NewRepoRepository(db *gorm.DB) RepoRepository {
 	return &repoRepository{db: db}
 }
 
-func (r *repoRepository) Create(repo *model.Repository) error {
-	return r.db.Create(repo).Error
+func (r *repoRepository) Create(ctx context.Context, repo *model.Repository) error {
+	repo.WorkspaceID = workspaceIDForCreate(ctx, repo.WorkspaceID)
+	return r.db.WithContext(ctx).Create(repo).Error
 }
 
-func (r *repoRepository) List() ([]model.Repository, error) {
+func (r *repoRepository) List(ctx context.Context) ([]model.Repository, error) {
 	var repos []model.Repository
-	err := r.db.Order("created_at desc").Find(&repos).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Order("created_at desc").Find(&repos).Error
 	return repos, err
 }
 
-func (r *repoRepository) Get(id uint) (*model.Repository, error) {
+func (r *repoRepository) Get(ctx context.Context, id uint) (*model.Repository, error) {
 	var repo model.Repository
-	err := r.db.Preload("Tasks").Preload("Documents", "is_latest = ?", true).First(&repo, id).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Preload("Tasks").Preload("Documents", "is_latest = ?", true).First(&repo, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &repo, nil
 }
 
-func (r *repoRepository) GetBasic(id uint) (*model.Repository, error) {
+func (r *repoRepository) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	var repo model.Repository
-	err := r.db.First(&repo, id).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).First(&repo, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &repo, nil
 }
 
-func (r *repoRepository) Save(repo *model.Repository) error {
-	return r.db.Save(repo).Error
+func (r *repoRepository) Save(ctx context.Context, repo *model.Repository) error {
+	if workspaceID, ok := WorkspaceFromContext(ctx); ok && repo.WorkspaceID != workspaceID {
+		return gorm.ErrRecordNotFound
+	}
+	return r.db.WithContext(ctx).Save(repo).Error
 }
 
-func (r *repoRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Repository{}, id).Error
+func (r *repoRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Delete(&model.Repository{}, id).Error
 }
@@ -7,13 +7,14 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 )
 
+// RepoRepository 仓库仓储，context 携带工作空间时只访问该空间内的仓库
 type RepoRepository interface {
-	Create(repo *model.Repository) error
-	List() ([]model.Repository, error)
-	Get(id uint) (*model.Repository, error)
-	GetBasic(id uint) (*model.Repository, error)
-	Save(repo *model.Repository) error
-	Delete(id uint) error
+	Create(ctx context.Context, repo *model.Repository) error
+	List(ctx context.Context) ([]model.Repository, error)
+	Get(ctx context.Context, id uint) (*model.Repository, error)
+	GetBasic(ctx context.Context, id uint) (*model.Repository, error)
+	Save(ctx context.Context, repo *model.Repository) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type TaskRepository interface {
@@ -21,6 +22,8 @@ type TaskRepository interface {
 	GetByRepository(repoID uint) ([]model.Task, error)
 	GetByStatus(status string) ([]model.Task, error)
 	Get(id uint) (*model.Task, error)
+	// GetInWorkspace 按 context 中的工作空间查询任务，供不经过 Workspace 中间件资源校验的入口（如 MCP）使用
+	GetInWorkspace(ctx context.Context, id uint) (*model.Task, error)
 	Save(task *model.Task) error
 	CleanupStuckTasks(timeout time.Duration) (int64, error)
 	GetStuckTasks(timeout time.Duration) ([]model.Task, error)
@@ -31,17 +34,45 @@ type TaskRepository interface {
 	GetRecentTasks(limit int) ([]model.Task, error)
 }
 
+// TaskJobRepository 编排器持久化队列仓储
+type TaskJobRepository interface {
+	Upsert(job *model.TaskJob) error
+	ListReady(now time.Time, limit int, excludeRepos []uint) ([]model.TaskJob, error)
+	Claim(id uint) (bool, error)
+	Requeue(job *model.TaskJob) error
+	UpdatePriority(taskID uint, priority string) error
+	DeleteByTaskID(taskID uint) error
+	ExistsByTaskID(taskID uint) (bool, error)
+	ResetRunning() (int64, error)
+	CountByStatus(status string) (int64, error)
+	CountWaitingByRepository() (map[uint]int64, error)
+}
+
+// TaskDependencyRepository 任务依赖（DAG 边）仓储
+type TaskDependencyRepository interface {
+	CreateBatch(deps []model.TaskDependency) error
+	GetByTaskID(taskID uint) ([]model.TaskDependency, error)
+	GetDependents(taskID uint) ([]model.TaskDependency, error)
+	GetByRepository(repoID uint) ([]model.TaskDependency, error)
+	DeleteByTaskID(taskID uint) error
+	DeleteByRepositoryID(repoID uint) error
+}
+
 type DocumentRepository interface {
 	Create(doc *model.Document) error
 	GetByRepository(repoID uint) ([]model.Document, error)
+	GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error)
 	GetAllDocumentsTitleAndID(repoID uint) ([]model.Document, error)
 	GetAllLatest() ([]model.Document, error)
 	GetVersions(repoID uint, title string) ([]model.Document, error)
 	Get(id uint) (*model.Document, error)
+	// GetInWorkspace 按 context 中的工作空间查询文档，供不经过 Workspace 中间件资源校验的入口（如 MCP）使用
+	GetInWorkspace(ctx context.Context, id uint) (*model.Document, error)
 	Save(doc *model.Document) error
 	Delete(id uint) error
 	DeleteByTaskID(taskID uint) error
 	DeleteByRepositoryID(repoID uint) error
+	DeleteByRepositoryRef(repoID uint, ref string) error
 	UpdateTaskID(docID uint, taskID uint) error
 	TransferLatest(oldDocID uint, newDocID uint) error
 
@@ -72,6 +103,147 @@ type TaskUsageRepository interface {
 	UpsertMany(ctx context.Context, usages []model.TaskUsage) error
 }
 
+type TaskTraceRepository interface {
+	Create(ctx context.Context, trace *model.TaskTrace) error
+	GetByTaskID(ctx context.Context, taskID uint) ([]model.TaskTrace, error)
+	DeleteByTaskID(ctx context.Context, taskID uint) error
+}
+
+type EmbeddingProviderRepository interface {
+	Create(ctx context.Context, provider *model.EmbeddingProvider) error
+	Update(ctx context.Context, provider *model.EmbeddingProvider) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.EmbeddingProvider, error)
+	GetByName(ctx context.Context, name string) (*model.EmbeddingProvider, error)
+	List(ctx context.Context) ([]*model.EmbeddingProvider, error)
+	IncrementStats(ctx context.Context, id uint, requestCount int, errorCount int) error
+}
+
+type EmbeddingChunkRepository interface {
+	// ReplaceChunks 替换来源的全部分块；sourceID 为 0 时替换仓库下该类型的全部分块
+	ReplaceChunks(ctx context.Context, repoID uint, sourceType string, sourceID uint, chunks []model.EmbeddingChunk) error
+	// ListChunks 查询来源的分块（含向量），用于复用未变化内容的向量
+	ListChunks(ctx context.Context, repoID uint, sourceType string, sourceID uint) ([]model.EmbeddingChunk, error)
+	// ScanVectors 分批扫描指定向量模型生成的分块（不含正文），repoID 为 0 不限仓库，sourceType 为空不限类型
+	ScanVectors(ctx context.Context, providerName string, repoID uint, sourceType string, fn func(chunks []model.EmbeddingChunk) error) error
+	GetByIDs(ctx context.Context, ids []uint) ([]model.EmbeddingChunk, error)
+	CountByRepository(ctx context.Context, repoID uint, sourceType string) (int64, error)
+	// DeleteDocumentVersions 删除同仓库同标题的其他文档分块（文档出现新版本时）
+	DeleteDocumentVersions(ctx context.Context, repoID uint, title string, keepDocID uint) error
+	DeleteBySource(ctx context.Context, repoID uint, sourceType string, sourceID uint) error
+}
+
+// UserRepository 用户仓储
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	Save(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetBySubject(ctx context.Context, authSource, subject string) (*model.User, error)
+	List(ctx context.Context) ([]model.User, error)
+	Count(ctx context.Context) (int64, error)
+	CountByRole(ctx context.Context, role string) (int64, error)
+}
+
+// APITokenRepository API Token 仓储
+type APITokenRepository interface {
+	Create(ctx context.Context, token *model.APIToken) error
+	GetByHash(ctx context.Context, hash string) (*model.APIToken, error)
+	ListByUser(ctx context.Context, userID uint) ([]model.APIToken, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	DeleteByUser(ctx context.Context, userID uint) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+// WorkspaceRepository 工作空间仓储
+type WorkspaceRepository interface {
+	Create(ctx context.Context, workspace *model.Workspace) error
+	Save(ctx context.Context, workspace *model.Workspace) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.Workspace, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Workspace, error)
+	List(ctx context.Context) ([]model.Workspace, error)
+	ListByUser(ctx context.Context, userID uint) ([]model.Workspace, error)
+	AddMember(ctx context.Context, workspaceID, userID uint) error
+	RemoveMember(ctx context.Context, workspaceID, userID uint) error
+	ListMembers(ctx context.Context, workspaceID uint) ([]model.WorkspaceMember, error)
+	IsMember(ctx context.Context, workspaceID, userID uint) (bool, error)
+	CountResources(ctx context.Context, workspaceID uint) (int64, error)
+}
+
+// GitCredentialRepository Git 凭证仓储，按 context 中的工作空间隔离
+type GitCredentialRepository interface {
+	Create(ctx context.Context, credential *model.GitCredential) error
+	Save(ctx context.Context, credential *model.GitCredential) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.GitCredential, error)
+	GetByName(ctx context.Context, name string) (*model.GitCredential, error)
+	List(ctx context.Context) ([]model.GitCredential, error)
+}
+
+// RepositoryRefRepository 仓库引用文档集仓储，仓库的工作空间归属由调用方先行校验
+type RepositoryRefRepository interface {
+	Create(ctx context.Context, ref *model.RepositoryRef) error
+	Save(ctx context.Context, ref *model.RepositoryRef) error
+	Delete(ctx context.Context, id uint) error
+	Get(ctx context.Context, id uint) (*model.RepositoryRef, error)
+	GetByName(ctx context.Context, repoID uint, name string) (*model.RepositoryRef, error)
+	ListByRepository(ctx context.Context, repoID uint) ([]model.RepositoryRef, error)
+	DeleteByRepositoryID(ctx context.Context, repoID uint) error
+}
+
+// RepositoryWebhookRepository 仓库 Webhook 配置仓储，仓库的工作空间归属由调用方先行校验
+type RepositoryWebhookRepository interface {
+	Save(ctx context.Context, hook *model.RepositoryWebhook) error
+	GetByRepository(ctx context.Context, repoID uint) (*model.RepositoryWebhook, error)
+	DeleteByRepository(ctx context.Context, repoID uint) error
+}
+
+// EvalRunRepository Agent 评测记录
+type EvalRunRepository interface {
+	Create(ctx context.Context, run *model.EvalRun) error
+	// Save 更新评测运行本身，不处理结果
+	Save(ctx context.Context, run *model.EvalRun) error
+	AddResult(ctx context.Context, result *model.EvalResult) error
+	// Get 获取评测运行及各样例结果
+	Get(ctx context.Context, id uint) (*model.EvalRun, error)
+	// ListByAgent 按创建时间倒序列出 Agent 的评测运行，不含样例结果
+	ListByAgent(ctx context.Context, agentFile string, limit int) ([]model.EvalRun, error)
+	// MarkInterrupted 将未完成的评测标记为失败，服务重启时调用
+	MarkInterrupted(ctx context.Context) (int64, error)
+}
+
+// AuditLogFilter 审计日志查询条件，零值字段不参与过滤
+type AuditLogFilter struct {
+	WorkspaceID uint
+	ActorID     uint
+	ActorName   string
+	Action      string // 前缀匹配，task. 可查询所有任务相关操作
+	TargetType  string
+	TargetID    string
+	Since       time.Time
+	Until       time.Time
+	Page        int
+	PageSize    int
+}
+
+// AuditLogRepository 审计日志仓储，只追加不修改
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+	List(ctx context.Context, filter AuditLogFilter) ([]model.AuditLog, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserSessionRepository 登录会话仓储
+type UserSessionRepository interface {
+	Create(ctx context.Context, session *model.UserSession) error
+	GetByHash(ctx context.Context, hash string) (*model.UserSession, error)
+	DeleteByHash(ctx context.Context, hash string) error
+	DeleteByUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
 type SyncTargetRepository interface {
 	List(ctx context.Context) ([]model.SyncTarget, error)
 	Upsert(ctx context.Context, url string) (*model.SyncTarget, error)
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrRepositoryRefNotFound 引用文档集不存在错误
+var ErrRepositoryRefNotFound = errors.New("repository ref not found")
+
+type repositoryRefRepository struct {
+	db *gorm.DB
+}
+
+// NewRepositoryRefRepository 创建仓库引用文档集仓储
+func NewRepositoryRefRepository(db *gorm.DB) RepositoryRefRepository {
+	return &repositoryRefRepository{db: db}
+}
+
+func (r *repositoryRefRepository) Create(ctx context.Context, ref *model.RepositoryRef) error {
+	return r.db.WithContext(ctx).Create(ref).Error
+}
+
+func (r *repositoryRefRepository) Save(ctx context.Context, ref *model.RepositoryRef) error {
+	return r.db.WithContext(ctx).Save(ref).Error
+}
+
+func (r *repositoryRefRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&model.RepositoryRef{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRepositoryRefNotFound
+	}
+	return nil
+}
+
+func (r *repositoryRefRepository) Get(ctx context.Context, id uint) (*model.RepositoryRef, error) {
+	var ref model.RepositoryRef
+	if err := r.db.WithContext(ctx).First(&ref, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepositoryRefNotFound
+		}
+		return nil, err
+	}
+	return &ref, nil
+}
+
+func (r *repositoryRefRepository) GetByName(ctx context.Context, repoID uint, name string) (*model.RepositoryRef, error) {
+	var ref model.RepositoryRef
+	if err := r.db.WithContext(ctx).Where("repository_id = ? AND name = ?", repoID, name).First(&ref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepositoryRefNotFound
+		}
+		return nil, err
+	}
+	return &ref, nil
+}
+
+func (r *repositoryRefRepository) ListByRepository(ctx context.Context, repoID uint) ([]model.RepositoryRef, error) {
+	var refs []model.RepositoryRef
+	err := r.db.WithContext(ctx).Where("repository_id = ?", repoID).Order("id").Find(&refs).Error
+	return refs, err
+}
+
+func (r *repositoryRefRepository) DeleteByRepositoryID(ctx context.Context, repoID uint) error {
+	return r.db.WithContext(ctx).Where("repository_id = ?", repoID).Delete(&model.RepositoryRef{}).Error
+}
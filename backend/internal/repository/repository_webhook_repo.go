@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrRepositoryWebhookNotFound 仓库未配置 Webhook
+var ErrRepositoryWebhookNotFound = errors.New("repository webhook not found")
+
+type repositoryWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewRepositoryWebhookRepository 创建仓库 Webhook 配置仓储
+func NewRepositoryWebhookRepository(db *gorm.DB) RepositoryWebhookRepository {
+	return &repositoryWebhookRepository{db: db}
+}
+
+func (r *repositoryWebhookRepository) Save(ctx context.Context, hook *model.RepositoryWebhook) error {
+	return r.db.WithContext(ctx).Save(hook).Error
+}
+
+func (r *repositoryWebhookRepository) GetByRepository(ctx context.Context, repoID uint) (*model.RepositoryWebhook, error) {
+	var hook model.RepositoryWebhook
+	if err := r.db.WithContext(ctx).Where("repository_id = ?", repoID).First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepositoryWebhookNotFound
+		}
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *repositoryWebhookRepository) DeleteByRepository(ctx context.Context, repoID uint) error {
+	result := r.db.WithContext(ctx).Where("repository_id = ?", repoID).Delete(&model.RepositoryWebhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRepositoryWebhookNotFound
+	}
+	return nil
+}
@@ -19,13 +19,15 @@ func NewSyncTargetRepository(db *gorm.DB) SyncTargetRepository {
 
 func (r *syncTargetRepository) List(ctx context.Context) ([]model.SyncTarget, error) {
 	var targets []model.SyncTarget
-	err := r.db.WithContext(ctx).Order("updated_at desc, id desc").Find(&targets).Error
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Order("updated_at desc, id desc").Find(&targets).Error
 	return targets, err
 }
 
 func (r *syncTargetRepository) Upsert(ctx context.Context, url string) (*model.SyncTarget, error) {
+	// 同一地址在不同工作空间内各自独立
+	workspaceID := workspaceIDForCreate(ctx, 0)
 	var target model.SyncTarget
-	err := r.db.WithContext(ctx).Where("url = ?", url).First(&target).Error
+	err := r.db.WithContext(ctx).Where("workspace_id = ? AND url = ?", workspaceID, url).First(&target).Error
 	if err == nil {
 		target.UpdatedAt = time.Now()
 		if err := r.db.WithContext(ctx).Save(&target).Error; err != nil {
@@ -37,7 +39,8 @@ func (r *syncTargetRepository) Upsert(ctx context.Context, url string) (*model.S
 		return nil, err
 	}
 	target = model.SyncTarget{
-		URL: url,
+		WorkspaceID: workspaceID,
+		URL:         url,
 	}
 	if err := r.db.WithContext(ctx).Create(&target).Error; err != nil {
 		return nil, err
@@ -46,7 +49,7 @@ func (r *syncTargetRepository) Upsert(ctx context.Context, url string) (*model.S
 }
 
 func (r *syncTargetRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.SyncTarget{}, id).Error
+	return r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Delete(&model.SyncTarget{}, id).Error
 }
 
 func (r *syncTargetRepository) TrimExcess(ctx context.Context, max int) error {
@@ -54,7 +57,7 @@ func (r *syncTargetRepository) TrimExcess(ctx context.Context, max int) error {
 		return nil
 	}
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&model.SyncTarget{}).Count(&count).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Model(&model.SyncTarget{}).Count(&count).Error; err != nil {
 		return err
 	}
 	if int(count) <= max {
@@ -62,7 +65,7 @@ func (r *syncTargetRepository) TrimExcess(ctx context.Context, max int) error {
 	}
 	trim := int(count) - max
 	var targets []model.SyncTarget
-	if err := r.db.WithContext(ctx).Order("updated_at asc, id asc").Limit(trim).Find(&targets).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(scopeWorkspace(ctx)).Order("updated_at asc, id asc").Limit(trim).Find(&targets).Error; err != nil {
 		return err
 	}
 	if len(targets) == 0 {
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type taskDependencyRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskDependencyRepository 创建任务依赖仓储
+func NewTaskDependencyRepository(db *gorm.DB) TaskDependencyRepository {
+	return &taskDependencyRepository{db: db}
+}
+
+// CreateBatch 批量写入依赖边，在同一事务中完成
+func (r *taskDependencyRepository) CreateBatch(deps []model.TaskDependency) error {
+	if len(deps) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&deps).Error
+	})
+}
+
+// GetByTaskID 获取任务的所有上游依赖
+func (r *taskDependencyRepository) GetByTaskID(taskID uint) ([]model.TaskDependency, error) {
+	var deps []model.TaskDependency
+	err := r.db.Where("task_id = ?", taskID).Order("id").Find(&deps).Error
+	return deps, err
+}
+
+// GetDependents 获取依赖该任务的所有下游依赖边
+func (r *taskDependencyRepository) GetDependents(taskID uint) ([]model.TaskDependency, error) {
+	var deps []model.TaskDependency
+	err := r.db.Where("depends_on_id = ?", taskID).Order("id").Find(&deps).Error
+	return deps, err
+}
+
+// GetByRepository 获取仓库内的所有依赖边
+func (r *taskDependencyRepository) GetByRepository(repoID uint) ([]model.TaskDependency, error) {
+	var deps []model.TaskDependency
+	err := r.db.Where("repository_id = ?", repoID).Order("id").Find(&deps).Error
+	return deps, err
+}
+
+// DeleteByTaskID 删除与任务相关的依赖边（作为上游或下游）
+func (r *taskDependencyRepository) DeleteByTaskID(taskID uint) error {
+	return r.db.Where("task_id = ? OR depends_on_id = ?", taskID, taskID).Delete(&model.TaskDependency{}).Error
+}
+
+func (r *taskDependencyRepository) DeleteByRepositoryID(repoID uint) error {
+	return r.db.Where("repository_id = ?", repoID).Delete(&model.TaskDependency{}).Error
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type taskJobRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskJobRepository 创建编排器持久化队列仓储
+func NewTaskJobRepository(db *gorm.DB) TaskJobRepository {
+	return &taskJobRepository{db: db}
+}
+
+// Upsert 按 task_id 写入作业
+// 已存在等待中的作业时覆盖其重试信息；执行中的作业不做修改，避免重复分发
+func (r *taskJobRepository) Upsert(job *model.TaskJob) error {
+	var existing model.TaskJob
+	err := r.db.Where("task_id = ?", job.TaskID).First(&existing).Error
+	if err == nil {
+		if existing.Status == model.TaskJobStatusRunning {
+			*job = existing
+			return nil
+		}
+		job.ID = existing.ID
+		job.CreatedAt = existing.CreatedAt
+		return r.db.Save(job).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.db.Create(job).Error
+}
+
+// ListReady 获取已到期的等待作业，excludeRepos 中仓库的作业不返回
+// 每个优先级通道分别按 next_run_at、id 排序并各取 limit 条，避免大量低优先级积压占满扫描窗口
+func (r *taskJobRepository) ListReady(now time.Time, limit int, excludeRepos []uint) ([]model.TaskJob, error) {
+	ready := func() *gorm.DB {
+		tx := r.db.Model(&model.TaskJob{}).Where("status = ? AND next_run_at <= ?", model.TaskJobStatusWaiting, now)
+		if len(excludeRepos) > 0 {
+			tx = tx.Where("repository_id NOT IN ?", excludeRepos)
+		}
+		return tx
+	}
+	var priorities []string
+	if err := ready().Distinct("priority").Pluck("priority", &priorities).Error; err != nil {
+		return nil, err
+	}
+	var jobs []model.TaskJob
+	for _, priority := range priorities {
+		var lane []model.TaskJob
+		tx := ready().Where("priority = ?", priority).Order("next_run_at, id")
+		if limit > 0 {
+			tx = tx.Limit(limit)
+		}
+		if err := tx.Find(&lane).Error; err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, lane...)
+	}
+	return jobs, nil
+}
+
+// Claim 将等待作业标记为执行中，仅当状态仍为 waiting 时成功
+func (r *taskJobRepository) Claim(id uint) (bool, error) {
+	result := r.db.Model(&model.TaskJob{}).
+		Where("id = ? AND status = ?", id, model.TaskJobStatusWaiting).
+		Update("status", model.TaskJobStatusRunning)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Requeue 将作业放回等待状态，并更新重试次数与下次分发时间
+func (r *taskJobRepository) Requeue(job *model.TaskJob) error {
+	job.Status = model.TaskJobStatusWaiting
+	return r.db.Model(&model.TaskJob{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"retry_count": job.RetryCount,
+			"max_retries": job.MaxRetries,
+			"next_run_at": job.NextRunAt,
+		}).Error
+}
+
+// UpdatePriority 修改等待中作业的优先级，执行中的作业不受影响
+func (r *taskJobRepository) UpdatePriority(taskID uint, priority string) error {
+	return r.db.Model(&model.TaskJob{}).
+		Where("task_id = ? AND status = ?", taskID, model.TaskJobStatusWaiting).
+		Update("priority", priority).Error
+}
+
+func (r *taskJobRepository) DeleteByTaskID(taskID uint) error {
+	return r.db.Where("task_id = ?", taskID).Delete(&model.TaskJob{}).Error
+}
+
+func (r *taskJobRepository) ExistsByTaskID(taskID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.TaskJob{}).Where("task_id = ?", taskID).Count(&count).Error
+	return count > 0, err
+}
+
+// ResetRunning 将执行中的作业恢复为等待状态（用于进程重启后恢复）
+func (r *taskJobRepository) ResetRunning() (int64, error) {
+	result := r.db.Model(&model.TaskJob{}).
+		Where("status = ?", model.TaskJobStatusRunning).
+		Update("status", model.TaskJobStatusWaiting)
+	return result.RowsAffected, result.Error
+}
+
+func (r *taskJobRepository) CountByStatus(status string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TaskJob{}).Where("status = ?", status).Count(&count).Error
+	return count, err
+}
+
+// CountWaitingByRepository 按仓库统计等待中的作业数量
+func (r *taskJobRepository) CountWaitingByRepository() (map[uint]int64, error) {
+	var results []struct {
+		RepositoryID uint
+		Count        int64
+	}
+	err := r.db.Model(&model.TaskJob{}).
+		Where("status = ?", model.TaskJobStatusWaiting).
+		Group("repository_id").
+		Select("repository_id, count(*) as count").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(results))
+	for _, r := range results {
+		counts[r.RepositoryID] = r.Count
+	}
+	return counts, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -42,6 +43,15 @@ func (r *taskRepository) Get(id uint) (*model.Task, error) {
 	return &task, nil
 }
 
+func (r *taskRepository) GetInWorkspace(ctx context.Context, id uint) (*model.Task, error) {
+	var task model.Task
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspaceByRepo(ctx)).First(&task, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 func (r *taskRepository) Save(task *model.Task) error {
 	return r.db.Save(task).Error
 }
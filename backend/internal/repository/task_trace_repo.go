@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type taskTraceRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskTraceRepository 创建 TaskTrace 仓储
+func NewTaskTraceRepository(db *gorm.DB) TaskTraceRepository {
+	return &taskTraceRepository{db: db}
+}
+
+// Create 新增一条执行轨迹
+func (r *taskTraceRepository) Create(ctx context.Context, trace *model.TaskTrace) error {
+	return r.db.WithContext(ctx).Create(trace).Error
+}
+
+// GetByTaskID 按开始时间顺序查询任务的执行轨迹
+func (r *taskTraceRepository) GetByTaskID(ctx context.Context, taskID uint) ([]model.TaskTrace, error) {
+	var traces []model.TaskTrace
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspaceByTask(ctx)).
+		Where("task_id = ?", taskID).
+		Order("started_at ASC, id ASC").
+		Find(&traces).Error
+	if err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// DeleteByTaskID 删除任务的全部执行轨迹
+func (r *taskTraceRepository) DeleteByTaskID(ctx context.Context, taskID uint) error {
+	return r.db.WithContext(ctx).Scopes(scopeWorkspaceByTask(ctx)).Where("task_id = ?", taskID).Delete(&model.TaskTrace{}).Error
+}
@@ -26,7 +26,7 @@ func (r *taskUsageRepository) Create(ctx context.Context, usage *model.TaskUsage
 // 返回最新的记录（如果有多条）
 func (r *taskUsageRepository) GetByTaskID(ctx context.Context, taskID uint) (*model.TaskUsage, error) {
 	var usage model.TaskUsage
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspaceByTask(ctx)).
 		Where("task_id = ?", taskID).
 		Order("id DESC").
 		First(&usage).Error
@@ -42,7 +42,7 @@ func (r *taskUsageRepository) GetByTaskID(ctx context.Context, taskID uint) (*mo
 // GetByTaskIDList 根据 task_id 查询任务用量记录列表
 func (r *taskUsageRepository) GetByTaskIDList(ctx context.Context, taskID uint) ([]model.TaskUsage, error) {
 	var usages []model.TaskUsage
-	err := r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).Scopes(scopeWorkspaceByTask(ctx)).
 		Where("task_id = ?", taskID).
 		Order("id ASC").
 		Find(&usages).Error
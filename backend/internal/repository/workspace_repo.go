@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrWorkspaceNotFound 工作空间不存在错误
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// ErrWorkspaceDuplicate 工作空间标识重复错误
+var ErrWorkspaceDuplicate = errors.New("workspace slug already exists")
+
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository 创建工作空间仓储
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+func (r *workspaceRepository) Create(ctx context.Context, workspace *model.Workspace) error {
+	return r.db.WithContext(ctx).Create(workspace).Error
+}
+
+func (r *workspaceRepository) Save(ctx context.Context, workspace *model.Workspace) error {
+	return r.db.WithContext(ctx).Save(workspace).Error
+}
+
+func (r *workspaceRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ?", id).Delete(&model.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Workspace{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWorkspaceNotFound
+		}
+		return nil
+	})
+}
+
+func (r *workspaceRepository) GetByID(ctx context.Context, id uint) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := r.db.WithContext(ctx).First(&workspace, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *workspaceRepository) GetBySlug(ctx context.Context, slug string) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *workspaceRepository) List(ctx context.Context) ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	err := r.db.WithContext(ctx).Order("id asc").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// ListByUser 列出用户可访问的工作空间：默认工作空间与已加入的工作空间
+func (r *workspaceRepository) ListByUser(ctx context.Context, userID uint) ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	err := r.db.WithContext(ctx).
+		Where("id = ? OR id IN (?)", model.DefaultWorkspaceID,
+			r.db.Model(&model.WorkspaceMember{}).Select("workspace_id").Where("user_id = ?", userID)).
+		Order("id asc").
+		Find(&workspaces).Error
+	return workspaces, err
+}
+
+func (r *workspaceRepository) AddMember(ctx context.Context, workspaceID, userID uint) error {
+	member := model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+}
+
+func (r *workspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Delete(&model.WorkspaceMember{}).Error
+}
+
+func (r *workspaceRepository) ListMembers(ctx context.Context, workspaceID uint) ([]model.WorkspaceMember, error) {
+	var members []model.WorkspaceMember
+	err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("id asc").Find(&members).Error
+	return members, err
+}
+
+func (r *workspaceRepository) IsMember(ctx context.Context, workspaceID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountResources 统计工作空间下的仓库、API Key、Git 凭证、同步目标与对话数量，用于删除前检查
+func (r *workspaceRepository) CountResources(ctx context.Context, workspaceID uint) (int64, error) {
+	var total int64
+	for _, m := range []interface{}{&model.Repository{}, &model.APIKey{}, &model.GitCredential{}, &model.SyncTarget{}, &model.ChatSession{}} {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(m).Where("workspace_id = ?", workspaceID).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type workspaceContextKey struct{}
+
+// WithWorkspace 返回携带工作空间的 context，仓储据此限定查询范围
+func WithWorkspace(ctx context.Context, workspaceID uint) context.Context {
+	if workspaceID == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, workspaceContextKey{}, workspaceID)
+}
+
+// WithoutWorkspace 返回不限定工作空间的 context，用于存在性校验等系统级查询
+func WithoutWorkspace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, uint(0))
+}
+
+// WorkspaceFromContext 读取 context 中的工作空间
+// 未设置时表示系统调用（后台任务、启动恢复等），不限定工作空间
+func WorkspaceFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	workspaceID, ok := ctx.Value(workspaceContextKey{}).(uint)
+	return workspaceID, ok && workspaceID != 0
+}
+
+// workspaceIDForCreate 新建记录所属的工作空间，未指定时归属默认工作空间
+func workspaceIDForCreate(ctx context.Context, current uint) uint {
+	if current != 0 {
+		return current
+	}
+	if workspaceID, ok := WorkspaceFromContext(ctx); ok {
+		return workspaceID
+	}
+	return model.DefaultWorkspaceID
+}
+
+// scopeWorkspace 按 context 中的工作空间过滤 workspace_id 列
+func scopeWorkspace(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if workspaceID, ok := WorkspaceFromContext(ctx); ok {
+			return db.Where("workspace_id = ?", workspaceID)
+		}
+		return db
+	}
+}
+
+// scopeWorkspaceByRepo 按所属仓库的工作空间过滤 repository_id 列，用于任务、文档等按仓库存储的记录
+// 任务、文档与提示词仓储的大部分方法不接收 context，HTTP 接口按 ID 访问时由 Workspace 中间件校验资源归属（见 router.WorkspaceResource），
+// 不经过该校验的入口需使用 GetInWorkspace
+func scopeWorkspaceByRepo(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if workspaceID, ok := WorkspaceFromContext(ctx); ok {
+			return db.Where("repository_id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+				Table("repositories").Select("id").Where("workspace_id = ?", workspaceID))
+		}
+		return db
+	}
+}
+
+// scopeWorkspaceByTask 按任务所属仓库的工作空间过滤 task_id 列，用于任务用量、执行轨迹等按任务存储的记录
+func scopeWorkspaceByTask(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if workspaceID, ok := WorkspaceFromContext(ctx); ok {
+			return db.Where("task_id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+				Table("tasks").Select("tasks.id").
+				Joins("JOIN repositories ON repositories.id = tasks.repository_id").
+				Where("repositories.workspace_id = ?", workspaceID))
+		}
+		return db
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+func setupWorkspaceTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}, &model.APIKey{}, &model.SyncTarget{}, &model.TaskUsage{}, &model.TaskTrace{}))
+	return db
+}
+
+// TestRepoRepository_WorkspaceScope 仓库查询按 context 中的工作空间隔离
+func TestRepoRepository_WorkspaceScope(t *testing.T) {
+	repo := NewRepoRepository(setupWorkspaceTestDB(t))
+	teamA := WithWorkspace(context.Background(), 2)
+	teamB := WithWorkspace(context.Background(), 3)
+
+	a := &model.Repository{Name: "a", URL: "https://github.com/org/a"}
+	require.NoError(t, repo.Create(teamA, a))
+	assert.Equal(t, uint(2), a.WorkspaceID)
+
+	legacy := &model.Repository{Name: "legacy", URL: "https://github.com/org/legacy"}
+	require.NoError(t, repo.Create(context.Background(), legacy))
+	assert.Equal(t, model.DefaultWorkspaceID, legacy.WorkspaceID)
+
+	list, err := repo.List(teamA)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, a.ID, list[0].ID)
+
+	_, err = repo.GetBasic(teamB, a.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.Save(teamB, a), gorm.ErrRecordNotFound)
+
+	// 其他工作空间的删除不生效
+	require.NoError(t, repo.Delete(teamB, a.ID))
+	_, err = repo.GetBasic(teamA, a.ID)
+	assert.NoError(t, err)
+
+	// 系统调用不限定工作空间
+	all, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	_, err = repo.GetBasic(WithoutWorkspace(teamB), a.ID)
+	assert.NoError(t, err)
+}
+
+// TestAPIKeyRepository_WorkspaceScope API Key 只在所属工作空间内可用
+func TestAPIKeyRepository_WorkspaceScope(t *testing.T) {
+	repo := NewAPIKeyRepository(setupWorkspaceTestDB(t))
+	teamA := WithWorkspace(context.Background(), 2)
+	teamB := WithWorkspace(context.Background(), 3)
+
+	key := &model.APIKey{Name: "team-a-key", Provider: "openai", BaseURL: "https://api.openai.com/v1", APIKey: "sk-a", Model: "gpt-4", Status: "enabled"}
+	require.NoError(t, repo.Create(teamA, key))
+
+	_, err := repo.GetHighestPriority(teamB)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	_, err = repo.GetByName(teamB, "team-a-key")
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	keys, err := repo.ListByNames(teamB, []string{"team-a-key"})
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.ErrorIs(t, repo.Update(teamB, key), ErrAPIKeyNotFound)
+
+	found, err := repo.GetHighestPriority(teamA)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+}
+
+// TestSyncTargetRepository_WorkspaceScope 同一同步地址可在不同工作空间分别保存
+func TestSyncTargetRepository_WorkspaceScope(t *testing.T) {
+	repo := NewSyncTargetRepository(setupWorkspaceTestDB(t))
+	teamA := WithWorkspace(context.Background(), 2)
+	teamB := WithWorkspace(context.Background(), 3)
+
+	_, err := repo.Upsert(teamA, "http://remote:8080")
+	require.NoError(t, err)
+	_, err = repo.Upsert(teamB, "http://remote:8080")
+	require.NoError(t, err)
+	_, err = repo.Upsert(teamA, "http://remote:8080")
+	require.NoError(t, err)
+
+	targets, err := repo.List(teamA)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, uint(2), targets[0].WorkspaceID)
+
+	all, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+// TestTaskRecords_WorkspaceScope 任务用量与执行轨迹按任务所属仓库的工作空间隔离
+func TestTaskRecords_WorkspaceScope(t *testing.T) {
+	db := setupWorkspaceTestDB(t)
+	teamA := WithWorkspace(context.Background(), 2)
+	teamB := WithWorkspace(context.Background(), 3)
+
+	repo := &model.Repository{Name: "a", URL: "https://github.com/org/a"}
+	require.NoError(t, NewRepoRepository(db).Create(teamA, repo))
+	task := &model.Task{RepositoryID: repo.ID, Title: "overview"}
+	require.NoError(t, db.Create(task).Error)
+
+	usages := NewTaskUsageRepository(db)
+	require.NoError(t, usages.Create(context.Background(), &model.TaskUsage{TaskID: task.ID, APIKeyName: "default", TotalTokens: 10}))
+	traces := NewTaskTraceRepository(db)
+	require.NoError(t, traces.Create(context.Background(), &model.TaskTrace{TaskID: task.ID, StepType: "tool"}))
+
+	usage, err := usages.GetByTaskID(teamB, task.ID)
+	require.NoError(t, err)
+	assert.Nil(t, usage)
+	list, err := traces.GetByTaskID(teamB, task.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	// 其他工作空间的删除不生效
+	require.NoError(t, traces.DeleteByTaskID(teamB, task.ID))
+	usage, err = usages.GetByTaskID(teamA, task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, usage)
+	list, err = traces.GetByTaskID(teamA, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+// TestTaskAndDocument_GetInWorkspace 按 ID 查询任务与文档时按所属仓库的工作空间隔离
+func TestTaskAndDocument_GetInWorkspace(t *testing.T) {
+	db := setupWorkspaceTestDB(t)
+	teamA := WithWorkspace(context.Background(), 2)
+	teamB := WithWorkspace(context.Background(), 3)
+
+	repo := &model.Repository{Name: "a", URL: "https://github.com/org/a"}
+	require.NoError(t, NewRepoRepository(db).Create(teamA, repo))
+	task := &model.Task{RepositoryID: repo.ID, Title: "overview"}
+	require.NoError(t, db.Create(task).Error)
+	doc := &model.Document{RepositoryID: repo.ID, TaskID: task.ID, Title: "overview"}
+	require.NoError(t, db.Create(doc).Error)
+
+	tasks := NewTaskRepository(db)
+	docs := NewDocumentRepository(db)
+
+	_, err := tasks.GetInWorkspace(teamB, task.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = docs.GetInWorkspace(teamB, doc.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	gotTask, err := tasks.GetInWorkspace(teamA, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, task.ID, gotTask.ID)
+	gotDoc, err := docs.GetInWorkspace(context.Background(), doc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, doc.ID, gotDoc.ID)
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// auditTargetPrefixes 路由前缀与审计目标的对应关系，按顺序匹配，先匹配更具体的前缀
+// targetType 为空的前缀不记录审计日志
+var auditTargetPrefixes = []struct {
+	prefix     string
+	targetType string
+	idParam    string
+}{
+	{"/api/repositories/:id/chat", "", ""}, // 对话属于阅读行为
+	{"/api/repositories/:id/user-requests", service.AuditTargetUserRequest, ""},
+	{"/api/repositories", service.AuditTargetRepository, "id"},
+	{"/api/tasks", service.AuditTargetTask, "id"},
+	{"/api/documents", service.AuditTargetDocument, "id"},
+	{"/api/user-requests", service.AuditTargetUserRequest, "id"},
+	{"/api/api-keys", service.AuditTargetAPIKey, "id"},
+	{"/api/git-credentials", service.AuditTargetGitCredential, "id"},
+	{"/api/agents", service.AuditTargetAgent, "filename"},
+	{"/api/sync", service.AuditTargetSync, ""},
+	{"/api/activity", service.AuditTargetActivity, ""},
+	{"/api/embedding-providers", service.AuditTargetEmbeddingProvider, "id"},
+	{"/api/users", service.AuditTargetUser, "id"},
+	{"/api/workspaces", service.AuditTargetWorkspace, "id"},
+}
+
+// AuditAction 返回写操作路由的审计目标与动作名
+// 动作名由目标类型与前缀之后的静态路径段组成：
+// POST /api/tasks/:id/force-reset -> task.force-reset，DELETE /api/agents/:filename/versions/:version -> agent.versions.delete；
+// 没有静态路径段时按方法命名：POST -> create，PUT/PATCH -> update，DELETE -> delete
+func AuditAction(method, path string) middleware.AuditTarget {
+	for _, t := range auditTargetPrefixes {
+		if !hasPathPrefix(path, t.prefix) {
+			continue
+		}
+		if t.targetType == "" {
+			return middleware.AuditTarget{}
+		}
+
+		var segments []string
+		for _, segment := range strings.Split(strings.TrimPrefix(path, t.prefix), "/") {
+			if segment != "" && !strings.HasPrefix(segment, ":") {
+				segments = append(segments, segment)
+			}
+		}
+		lastIsParam := strings.Contains(path[strings.LastIndex(path, "/")+1:], ":")
+		if len(segments) == 0 || lastIsParam || method == http.MethodDelete {
+			segments = append(segments, auditMethodVerb(method))
+		}
+		return middleware.AuditTarget{
+			Type:    t.targetType,
+			Action:  t.targetType + "." + strings.Join(segments, "."),
+			IDParam: t.idParam,
+		}
+	}
+	return middleware.AuditTarget{}
+}
+
+func auditMethodVerb(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "update"
+	}
+}
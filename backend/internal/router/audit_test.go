@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// fakeAuditRecorder 以 "类型/ID" 为键保存目标状态，记录所有审计日志
+type fakeAuditRecorder struct {
+	state   map[string]string
+	entries []*model.AuditLog
+}
+
+func (f *fakeAuditRecorder) Snapshot(ctx context.Context, targetType, targetID string) string {
+	return f.state[targetType+"/"+targetID]
+}
+
+func (f *fakeAuditRecorder) Record(ctx context.Context, entry *model.AuditLog) {
+	f.entries = append(f.entries, entry)
+}
+
+func TestAuditAction(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         middleware.AuditTarget
+	}{
+		{"POST", "/api/repositories", middleware.AuditTarget{Type: service.AuditTargetRepository, Action: "repository.create", IDParam: "id"}},
+		{"DELETE", "/api/repositories/:id", middleware.AuditTarget{Type: service.AuditTargetRepository, Action: "repository.delete", IDParam: "id"}},
+		{"DELETE", "/api/repositories/:id/refs/:refId", middleware.AuditTarget{Type: service.AuditTargetRepository, Action: "repository.refs.delete", IDParam: "id"}},
+		{"PUT", "/api/repositories/:id/webhook", middleware.AuditTarget{Type: service.AuditTargetRepository, Action: "repository.webhook", IDParam: "id"}},
+		{"POST", "/api/webhooks/:id", middleware.AuditTarget{}},
+		{"POST", "/api/tasks/:id/force-reset", middleware.AuditTarget{Type: service.AuditTargetTask, Action: "task.force-reset", IDParam: "id"}},
+		{"POST", "/api/agents/:filename/versions/:version/restore", middleware.AuditTarget{Type: service.AuditTargetAgent, Action: "agent.versions.restore", IDParam: "filename"}},
+		{"DELETE", "/api/agents/:filename/versions", middleware.AuditTarget{Type: service.AuditTargetAgent, Action: "agent.versions.delete", IDParam: "filename"}},
+		{"POST", "/api/agents/:filename/evaluate", middleware.AuditTarget{Type: service.AuditTargetAgent, Action: "agent.evaluate", IDParam: "filename"}},
+		{"PATCH", "/api/api-keys/:id/status", middleware.AuditTarget{Type: service.AuditTargetAPIKey, Action: "api_key.status", IDParam: "id"}},
+		{"PUT", "/api/api-keys/:id", middleware.AuditTarget{Type: service.AuditTargetAPIKey, Action: "api_key.update", IDParam: "id"}},
+		{"POST", "/api/repositories/:id/user-requests", middleware.AuditTarget{Type: service.AuditTargetUserRequest, Action: "user_request.create"}},
+		{"POST", "/api/sync/target-save", middleware.AuditTarget{Type: service.AuditTargetSync, Action: "sync.target-save"}},
+		{"PUT", "/api/activity/config", middleware.AuditTarget{Type: service.AuditTargetActivity, Action: "activity.config"}},
+		{"POST", "/api/repositories/:id/chat/sessions", middleware.AuditTarget{}},
+		{"POST", "/api/auth/login", middleware.AuditTarget{}},
+	}
+	for _, tc := range cases {
+		if got := AuditAction(tc.method, tc.path); got != tc.want {
+			t.Errorf("AuditAction(%s %s) = %+v, want %+v", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := fakeAuthenticator{"editor": {ID: 5, Username: "alice", Role: model.RoleEditor, Status: model.UserStatusEnabled}}
+	recorder := &fakeAuditRecorder{state: map[string]string{"task/1": `{"status":"failed"}`}}
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(middleware.Auth(true, users, RequiredRole))
+	api.Use(middleware.Audit(recorder, AuditAction))
+	api.POST("/tasks/:id/reset", func(c *gin.Context) {
+		recorder.state["task/1"] = `{"status":"pending"}`
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	api.POST("/repositories", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		recorder.state["repository/42"] = string(body)
+		c.JSON(http.StatusCreated, gin.H{"id": 42})
+	})
+	api.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer editor")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	send(http.MethodPost, "/api/tasks/1/reset", "")
+	// 处理器仍能读取被审计中间件读取过的请求体
+	send(http.MethodPost, "/api/repositories", `{"url":"https://github.com/a/b"}`)
+	send(http.MethodGet, "/api/tasks/1", "")
+
+	if len(recorder.entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(recorder.entries))
+	}
+	reset := recorder.entries[0]
+	if reset.Action != "task.reset" || reset.TargetID != "1" || reset.ActorID != 5 || reset.ActorName != "alice" ||
+		reset.Before != `{"status":"failed"}` || reset.After != `{"status":"pending"}` || reset.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected reset entry: %+v", reset)
+	}
+	create := recorder.entries[1]
+	if create.Action != "repository.create" || create.TargetID != "42" || create.Before != "" ||
+		create.After != `{"url":"https://github.com/a/b"}` || create.Request != `{"url":"https://github.com/a/b"}` {
+		t.Fatalf("unexpected create entry: %+v", create)
+	}
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+// publicRoutes 无需登录即可访问的路由
+var publicRoutes = map[string]bool{
+	"POST /api/auth/login":  true,
+	"GET /api/auth/status":  true,
+	"POST /api/auth/logout": true,
+
+	"GET /api/auth/oidc/login":    true,
+	"GET /api/auth/oidc/callback": true,
+
+	// 推送 Webhook 通过签名校验身份
+	"POST /api/webhooks/:id": true,
+}
+
+// routeRoles 单独指定最低角色的路由，优先级高于前缀规则
+var routeRoles = map[string]string{
+	// 仓库删除与本地目录清理不可恢复，仅管理员可操作
+	"DELETE /api/repositories/:id":           model.RoleAdmin,
+	"POST /api/repositories/:id/purge-local": model.RoleAdmin,
+	"POST /api/tasks/cleanup":                model.RoleAdmin,
+	"PUT /api/activity/config":               model.RoleAdmin,
+
+	// 导入服务器本地目录会读取服务器文件，仅管理员可操作
+	"POST /api/repositories/import-local": model.RoleAdmin,
+
+	// Webhook 密钥仅管理员可设置
+	"PUT /api/repositories/:id/webhook":    model.RoleAdmin,
+	"DELETE /api/repositories/:id/webhook": model.RoleAdmin,
+
+	// 成员列表仅管理员可查看
+	"GET /api/workspaces/:id/members": model.RoleAdmin,
+
+	// 文档评分属于阅读行为，只读用户也可提交
+	"POST /api/documents/:id/ratings": model.RoleViewer,
+}
+
+// adminPrefixes 整体仅管理员可访问的路由前缀（含读取，避免泄露密钥等配置）
+var adminPrefixes = []string{
+	"/api/users",
+	"/api/api-keys",
+	"/api/git-credentials",
+	"/api/embedding-providers",
+	"/api/sync",
+	"/api/audit",
+}
+
+// viewerPrefixes 只读用户也可执行写操作的路由前缀
+var viewerPrefixes = []string{
+	"/api/auth/",                 // 修改自己的密码、管理自己的 API Token
+	"/api/repositories/:id/chat", // 发起与管理对话
+}
+
+// RequiredRole 返回访问路由所需的最低角色
+// 默认读取需要 viewer，写操作需要 editor；Agent 定义与工作空间的修改需要 admin
+func RequiredRole(method, path string) string {
+	key := method + " " + path
+	if publicRoutes[key] {
+		return ""
+	}
+	if role, ok := routeRoles[key]; ok {
+		return role
+	}
+	for _, prefix := range adminPrefixes {
+		if hasPathPrefix(path, prefix) {
+			return model.RoleAdmin
+		}
+	}
+
+	readOnly := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	if readOnly {
+		return model.RoleViewer
+	}
+	for _, prefix := range viewerPrefixes {
+		if hasPathPrefix(path, prefix) {
+			return model.RoleViewer
+		}
+	}
+	if hasPathPrefix(path, "/api/agents") || hasPathPrefix(path, "/api/workspaces") {
+		return model.RoleAdmin
+	}
+	return model.RoleEditor
+}
+
+// hasPathPrefix 按路径段匹配前缀，/api/sync 不匹配 /api/synced
+func hasPathPrefix(path, prefix string) bool {
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,131 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+func TestRequiredRole(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodPost, "/api/auth/login", ""},
+		{http.MethodGet, "/api/auth/status", ""},
+		{http.MethodGet, "/api/repositories", model.RoleViewer},
+		{http.MethodPost, "/api/repositories", model.RoleEditor},
+		{http.MethodDelete, "/api/repositories/:id", model.RoleAdmin},
+		{http.MethodPost, "/api/repositories/:id/purge-local", model.RoleAdmin},
+		{http.MethodPost, "/api/repositories/import-local", model.RoleAdmin},
+		{http.MethodPost, "/api/repositories/upload", model.RoleEditor},
+		{http.MethodPost, "/api/tasks/:id/run", model.RoleEditor},
+		{http.MethodPut, "/api/documents/:id", model.RoleEditor},
+		{http.MethodPost, "/api/documents/:id/ratings", model.RoleViewer},
+		{http.MethodPost, "/api/repositories/:id/chat/sessions", model.RoleViewer},
+		{http.MethodGet, "/api/agents/:filename", model.RoleViewer},
+		{http.MethodPut, "/api/agents/:filename", model.RoleAdmin},
+		{http.MethodPost, "/api/agents/:filename/evaluate", model.RoleAdmin},
+		{http.MethodGet, "/api/agents/:filename/evaluate/:id", model.RoleViewer},
+		{http.MethodGet, "/api/api-keys", model.RoleAdmin},
+		{http.MethodPost, "/api/sync/repository-clear", model.RoleAdmin},
+		{http.MethodGet, "/api/users", model.RoleAdmin},
+		{http.MethodPost, "/api/auth/tokens", model.RoleViewer},
+		{http.MethodPut, "/api/activity/config", model.RoleAdmin},
+		{http.MethodGet, "/api/workspaces", model.RoleViewer},
+		{http.MethodPost, "/api/workspaces", model.RoleAdmin},
+		{http.MethodGet, "/api/workspaces/:id/members", model.RoleAdmin},
+		{http.MethodGet, "/api/audit", model.RoleAdmin},
+		{http.MethodGet, "/api/git-credentials", model.RoleAdmin},
+		{http.MethodPost, "/api/webhooks/:id", ""},
+		{http.MethodGet, "/api/repositories/:id/webhook", model.RoleViewer},
+		{http.MethodPut, "/api/repositories/:id/webhook", model.RoleAdmin},
+	}
+	for _, tc := range cases {
+		if got := RequiredRole(tc.method, tc.path); got != tc.want {
+			t.Errorf("RequiredRole(%s %s) = %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+type fakeAuthenticator map[string]*model.User
+
+func (f fakeAuthenticator) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	if user, ok := f[token]; ok {
+		return user, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := fakeAuthenticator{
+		"viewer": {ID: 1, Role: model.RoleViewer, Status: model.UserStatusEnabled},
+		"editor": {ID: 2, Role: model.RoleEditor, Status: model.UserStatusEnabled},
+		"admin":  {ID: 3, Role: model.RoleAdmin, Status: model.UserStatusEnabled},
+	}
+
+	newEngine := func(enabled bool) *gin.Engine {
+		r := gin.New()
+		api := r.Group("/api")
+		api.Use(middleware.Auth(enabled, users, RequiredRole))
+		ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user_id": middleware.CurrentUserID(c)}) }
+		api.GET("/auth/status", ok)
+		api.GET("/repositories", ok)
+		api.POST("/repositories", ok)
+		api.DELETE("/repositories/:id", ok)
+		return r
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{http.MethodGet, "/api/auth/status", "", http.StatusOK},
+		{http.MethodGet, "/api/repositories", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/repositories", "bogus", http.StatusUnauthorized},
+		{http.MethodGet, "/api/repositories", "viewer", http.StatusOK},
+		{http.MethodPost, "/api/repositories", "viewer", http.StatusForbidden},
+		{http.MethodPost, "/api/repositories", "editor", http.StatusOK},
+		{http.MethodDelete, "/api/repositories/1", "editor", http.StatusForbidden},
+		{http.MethodDelete, "/api/repositories/1", "admin", http.StatusOK},
+	}
+	engine := newEngine(true)
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s token=%q: status %d, want %d", tc.method, tc.path, tc.token, w.Code, tc.want)
+		}
+	}
+
+	// 会话 Cookie 同样可用于认证
+	req := httptest.NewRequest(http.MethodPost, "/api/repositories", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "editor"})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("cookie auth: status %d, want 200", w.Code)
+	}
+
+	// 未启用认证时不拦截
+	req = httptest.NewRequest(http.MethodDelete, "/api/repositories/1", nil)
+	w = httptest.NewRecorder()
+	newEngine(false).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("auth disabled: status %d, want 200", w.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/config"
 	"github.com/weibaohui/opendeepwiki/backend/internal/embed"
 	"github.com/weibaohui/opendeepwiki/backend/internal/handler"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
 )
 
 func Setup(
@@ -20,6 +21,14 @@ func Setup(
 	activityHandler *handler.ActivityHandler,
 	agentHandler *handler.AgentHandler,
 	chatHandler *handler.ChatHandler,
+	embeddingHandler *handler.EmbeddingHandler,
+	authHandler *handler.AuthHandler,
+	workspaceHandler *handler.WorkspaceHandler,
+	auditHandler *handler.AuditHandler,
+	gitCredentialHandler *handler.GitCredentialHandler,
+	repositoryRefHandler *handler.RepositoryRefHandler,
+	webhookHandler *handler.WebhookHandler,
+	evalHandler *handler.EvalHandler,
 ) *gin.Engine {
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -30,38 +39,91 @@ func Setup(
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.WorkspaceHeader},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
 
 	api := r.Group("/api")
+	if authHandler != nil {
+		// 认证与鉴权，路由所需角色见 RequiredRole
+		api.Use(middleware.Auth(authHandler.Enabled(), authHandler.Service(), RequiredRole))
+	}
+	if workspaceHandler != nil {
+		// 选定工作空间并校验按 ID 访问的资源归属，需在认证之后执行
+		api.Use(middleware.Workspace(workspaceHandler.Service(), WorkspaceResource))
+	}
+	if auditHandler != nil && auditHandler.Enabled() {
+		// 记录写操作审计日志，需在认证与工作空间之后执行以获取操作人与工作空间
+		api.Use(middleware.Audit(auditHandler.Service(), AuditAction))
+	}
 	{
+		// 登录与用户管理
+		if authHandler != nil {
+			authHandler.RegisterRoutes(api)
+		}
+
+		// 工作空间管理
+		if workspaceHandler != nil {
+			workspaceHandler.RegisterRoutes(api)
+		}
+
+		// 审计日志查询
+		if auditHandler != nil {
+			auditHandler.RegisterRoutes(api)
+		}
+
 		api.GET("/doc/:id/redirect", docHandler.Redirect)
 
+		// 代码托管平台的推送投递入口，通过签名校验，无需登录
+		if webhookHandler != nil {
+			api.POST("/webhooks/:id", webhookHandler.Receive)
+		}
+
 		repos := api.Group("/repositories")
 		{
 			repos.POST("", repoHandler.Create)
+			repos.POST("/import-local", repoHandler.ImportLocal) // 导入服务器本地目录
+			repos.POST("/upload", repoHandler.Upload)            // 上传压缩包导入
 			repos.GET("", repoHandler.List)
 			repos.GET("/:id", repoHandler.Get)
 			repos.DELETE("/:id", repoHandler.Delete)
 			repos.POST("/:id/run-all", repoHandler.RunAllTasks)
 			repos.POST("/:id/clone", repoHandler.Clone)
 			repos.POST("/:id/purge-local", repoHandler.PurgeLocal)
+			repos.POST("/:id/upload", repoHandler.Reupload) // 重新上传压缩包
 			repos.POST("/:id/directory-analyze", repoHandler.AnalyzeDirectory)
 			repos.POST("/:id/db-model-analyze", repoHandler.AnalyzeDatabaseModel)
 			repos.POST("/:id/api-analyze", repoHandler.AnalyzeAPI)
 			repos.POST("/:id/incremental-analysis", repoHandler.IncrementalAnalysis)
+			repos.POST("/:id/writers/:name/run", repoHandler.RunWriter) // 使用指定写入器生成文档
 			repos.POST("/:id/user-requests", userRequestHandler.CreateUserRequest)
 			repos.GET("/:id/user-requests", userRequestHandler.ListUserRequests)
 			repos.POST("/:id/set-ready", repoHandler.SetReady)
 			repos.GET("/:id/incremental-history", repoHandler.GetIncrementalHistory)
 			repos.GET("/:id/tasks", taskHandler.GetByRepository)
 			repos.GET("/:id/tasks/stats", taskHandler.GetStats) // 新增：任务统计
+			repos.GET("/:id/tasks/graph", taskHandler.GetGraph) // 任务依赖图
 			repos.GET("/:id/documents", docHandler.GetByRepository)
 			repos.GET("/:id/documents/index", docHandler.GetIndex)
 			repos.GET("/:id/documents/export", docHandler.Export)
 			repos.GET("/:id/export-pdf", docHandler.ExportPDF)
+			repos.GET("/:id/documents/diff", docHandler.DiffRefs) // 比较两个引用文档集
+
+			// 推送 Webhook 配置
+			if webhookHandler != nil {
+				repos.GET("/:id/webhook", webhookHandler.Get)
+				repos.PUT("/:id/webhook", webhookHandler.Update)
+				repos.DELETE("/:id/webhook", webhookHandler.Delete)
+			}
+
+			// 按分支、标签维护的引用文档集
+			if repositoryRefHandler != nil {
+				repos.GET("/:id/refs", repositoryRefHandler.List)
+				repos.POST("/:id/refs", repositoryRefHandler.Create)
+				repos.GET("/:id/refs/:refId", repositoryRefHandler.Get)
+				repos.DELETE("/:id/refs/:refId", repositoryRefHandler.Delete)
+			}
 		}
 
 		tasks := api.Group("/tasks")
@@ -71,18 +133,25 @@ func Setup(
 			tasks.GET("/stuck", taskHandler.GetStuck)               // 获取卡住的任务
 			tasks.POST("/cleanup", taskHandler.CleanupStuck)        // 清理卡住的任务
 			tasks.GET("/:id", taskHandler.Get)
+			tasks.GET("/:id/stream", taskHandler.Stream)  // 实时推送任务进度（SSE/WebSocket）
+			tasks.GET("/:id/trace", taskHandler.GetTrace) // 获取任务执行轨迹
 			tasks.POST("/:id/run", taskHandler.Run)
-			tasks.POST("/:id/enqueue", taskHandler.Enqueue)      // 新增：提交任务到队列
-			tasks.POST("/:id/retry", taskHandler.Retry)          // 新增：重试任务
-			tasks.POST("/:id/regen", taskHandler.ReGenByNewTask) // 新增：重新生成任务
-			tasks.POST("/:id/cancel", taskHandler.Cancel)        // 新增：取消任务
+			tasks.POST("/:id/enqueue", taskHandler.Enqueue)              // 新增：提交任务到队列
+			tasks.POST("/:id/retry", taskHandler.Retry)                  // 新增：重试任务
+			tasks.POST("/:id/regen", taskHandler.ReGenByNewTask)         // 新增：重新生成任务
+			tasks.POST("/:id/cancel", taskHandler.Cancel)                // 新增：取消任务
+			tasks.POST("/:id/priority", taskHandler.SetPriority)         // 调整任务优先级
+			tasks.POST("/:id/dependencies", taskHandler.AddDependencies) // 追加任务依赖
 			tasks.POST("/:id/reset", taskHandler.Reset)
 			tasks.POST("/:id/force-reset", taskHandler.ForceReset) // 强制重置
 			tasks.DELETE("/:id", taskHandler.Delete)               // 删除任务（新增）
 		}
 
+		api.GET("/writers", taskHandler.ListWriters) // 已注册的写入器
+
 		docs := api.Group("/documents")
 		{
+			docs.GET("/search", docHandler.Search) // 按相关度搜索文档
 			docs.GET("/:id", docHandler.Get)
 			docs.GET("/:id/versions", docHandler.GetVersions)
 			docs.PUT("/:id", docHandler.Update)
@@ -94,6 +163,16 @@ func Setup(
 		// API Key 管理
 		apiKeyHandler.RegisterRoutes(api)
 
+		// 私有仓库 Git 凭证管理
+		if gitCredentialHandler != nil {
+			gitCredentialHandler.RegisterRoutes(api)
+		}
+
+		// 向量模型配置与语义检索
+		if embeddingHandler != nil {
+			embeddingHandler.RegisterRoutes(api)
+		}
+
 		// 数据同步
 		syncHandler.RegisterRoutes(api)
 
@@ -102,6 +181,9 @@ func Setup(
 
 		// Agent 管理
 		agentHandler.RegisterRoutes(api)
+		if evalHandler != nil {
+			evalHandler.RegisterRoutes(api)
+		}
 
 		// 对话管理
 		if chatHandler != nil {
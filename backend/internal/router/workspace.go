@@ -0,0 +1,31 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// workspaceResourcePrefixes 路由前缀与 :id 所指资源类型的对应关系
+// 任务、文档、提示词与用户需求的仓储按 ID 查询时默认不限定工作空间，HTTP 接口依赖这里的校验，
+// 新增按这些资源 ID 访问的路由时必须落在以下前缀内；不经过该校验的入口（如 MCP）需使用 GetInWorkspace
+var workspaceResourcePrefixes = []struct {
+	prefix string
+	kind   string
+}{
+	{"/api/repositories/:id", service.WorkspaceResourceRepository},
+	{"/api/tasks/:id", service.WorkspaceResourceTask},
+	{"/api/documents/:id", service.WorkspaceResourceDocument},
+	{"/api/doc/:id", service.WorkspaceResourceDocument},
+	{"/api/user-requests/:id", service.WorkspaceResourceUserRequest},
+}
+
+// WorkspaceResource 返回路由中 :id 指向的资源类型，用于校验资源是否属于当前工作空间
+func WorkspaceResource(path string) string {
+	for _, r := range workspaceResourcePrefixes {
+		if path == r.prefix || strings.HasPrefix(path, r.prefix+"/") {
+			return r.kind
+		}
+	}
+	return ""
+}
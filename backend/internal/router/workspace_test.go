@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/opendeepwiki/backend/internal/middleware"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+)
+
+// fakeWorkspaces 工作空间 2 只有用户 1 是成员；仓库 10 属于工作空间 2
+type fakeWorkspaces struct{}
+
+func (fakeWorkspaces) Authorize(ctx context.Context, user *model.User, workspaceID uint) error {
+	switch {
+	case workspaceID > 2:
+		return repository.ErrWorkspaceNotFound
+	case workspaceID == 2 && user != nil && user.ID != 1:
+		return service.ErrWorkspaceForbidden
+	}
+	return nil
+}
+
+func (fakeWorkspaces) ResourceWorkspace(ctx context.Context, kind string, id uint) (uint, error) {
+	if id == 10 {
+		return 2, nil
+	}
+	return model.DefaultWorkspaceID, nil
+}
+
+func TestWorkspaceResource(t *testing.T) {
+	cases := map[string]string{
+		"/api/repositories/:id":               service.WorkspaceResourceRepository,
+		"/api/repositories/:id/chat/sessions": service.WorkspaceResourceRepository,
+		"/api/tasks/:id/run":                  service.WorkspaceResourceTask,
+		"/api/tasks/:id/trace":                service.WorkspaceResourceTask,
+		"/api/documents/:id/token-usage":      service.WorkspaceResourceDocument,
+		"/api/doc/:id/redirect":               service.WorkspaceResourceDocument,
+		"/api/user-requests/:id/status":       service.WorkspaceResourceUserRequest,
+		"/api/repositories":                   "",
+		"/api/tasks/status":                   "",
+	}
+	for path, want := range cases {
+		if got := WorkspaceResource(path); got != want {
+			t.Errorf("WorkspaceResource(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWorkspaceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := fakeAuthenticator{
+		"member":   {ID: 1, Role: model.RoleViewer, Status: model.UserStatusEnabled},
+		"outsider": {ID: 2, Role: model.RoleViewer, Status: model.UserStatusEnabled},
+	}
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(middleware.Auth(true, users, RequiredRole))
+	api.Use(middleware.Workspace(fakeWorkspaces{}, WorkspaceResource))
+	workspace := func(c *gin.Context) {
+		id, _ := repository.WorkspaceFromContext(c.Request.Context())
+		c.String(http.StatusOK, strconv.Itoa(int(id)))
+	}
+	api.GET("/repositories", workspace)
+	api.GET("/repositories/:id", workspace)
+	api.GET("/tasks/:id/trace", workspace)
+
+	cases := []struct {
+		path      string
+		token     string
+		workspace string
+		want      int
+		body      string
+	}{
+		{"/api/repositories", "outsider", "", http.StatusOK, "1"},
+		{"/api/repositories", "member", "2", http.StatusOK, "2"},
+		{"/api/repositories", "outsider", "2", http.StatusForbidden, ""},
+		{"/api/repositories", "member", "9", http.StatusNotFound, ""},
+		{"/api/repositories", "member", "abc", http.StatusBadRequest, ""},
+		{"/api/repositories/10", "member", "2", http.StatusOK, "2"},
+		// 资源属于其他工作空间时按不存在处理
+		{"/api/repositories/10", "member", "", http.StatusNotFound, ""},
+		{"/api/repositories/11", "member", "2", http.StatusNotFound, ""},
+		// 任务、文档等仓储不接收 context，只能依靠中间件按所属仓库校验
+		{"/api/tasks/10/trace", "member", "", http.StatusNotFound, ""},
+		{"/api/tasks/10/trace", "member", "2", http.StatusOK, "2"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		if tc.workspace != "" {
+			req.Header.Set(middleware.WorkspaceHeader, tc.workspace)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want || (tc.body != "" && w.Body.String() != tc.body) {
+			t.Errorf("GET %s token=%s workspace=%q: status %d body %q, want %d %q", tc.path, tc.token, tc.workspace, w.Code, w.Body.String(), tc.want, tc.body)
+		}
+	}
+}
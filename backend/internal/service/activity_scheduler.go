@@ -81,7 +81,7 @@ func (s *ActivityScheduler) checkAndTriggerUpdates(ctx context.Context) {
 	klog.V(6).Info("开始检查仓库更新时间...")
 
 	// 获取所有仓库
-	repos, err := s.repoRepo.List()
+	repos, err := s.repoRepo.List(ctx)
 	if err != nil {
 		klog.Errorf("获取仓库列表失败: %v", err)
 		return
@@ -124,7 +124,7 @@ func (s *ActivityScheduler) triggerIncrementalAnalysis(ctx context.Context, repo
 
 // UpdateNextUpdateTime 更新仓库的下一次更新时间
 func (s *ActivityScheduler) UpdateNextUpdateTime(repoID uint, activityPoints int) error {
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return err
 	}
@@ -142,7 +142,7 @@ func (s *ActivityScheduler) UpdateNextUpdateTime(repoID uint, activityPoints int
 	newNextUpdateTime := now.Add(newInterval)
 	repo.NextUpdateTime = &newNextUpdateTime
 
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		return err
 	}
 
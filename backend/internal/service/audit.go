@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents/tools"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+// 审计目标类型
+const (
+	AuditTargetRepository        = "repository"
+	AuditTargetTask              = "task"
+	AuditTargetDocument          = "document"
+	AuditTargetAPIKey            = "api_key"
+	AuditTargetGitCredential     = "git_credential"
+	AuditTargetAgent             = "agent"
+	AuditTargetSync              = "sync"
+	AuditTargetActivity          = "activity"
+	AuditTargetUserRequest       = "user_request"
+	AuditTargetUser              = "user"
+	AuditTargetWorkspace         = "workspace"
+	AuditTargetEmbeddingProvider = "embedding_provider"
+)
+
+const (
+	// auditMaxTextLength 快照中超过该长度的文本以长度与摘要代替，避免文档正文撑大审计表
+	auditMaxTextLength = 2000
+	auditRedacted      = "***"
+	auditMaxPageSize   = 200
+)
+
+// AuditSnapshotFunc 读取审计目标的当前状态，返回值序列化为 JSON 作为变更前后快照
+type AuditSnapshotFunc func(ctx context.Context, id string) (interface{}, error)
+
+// SnapshotByID 将按数字 ID 读取的仓储方法适配为快照函数
+func SnapshotByID[T any](get func(ctx context.Context, id uint) (T, error)) AuditSnapshotFunc {
+	return func(ctx context.Context, id string) (interface{}, error) {
+		var n uint
+		if _, err := fmt.Sscanf(id, "%d", &n); err != nil {
+			return nil, err
+		}
+		return get(ctx, n)
+	}
+}
+
+// AuditListRequest 审计日志查询参数
+type AuditListRequest struct {
+	WorkspaceID uint
+	ActorID     uint
+	ActorName   string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Since       time.Time
+	Until       time.Time
+	Page        int
+	PageSize    int
+}
+
+// AuditService 审计日志记录、查询与保留期清理
+type AuditService struct {
+	repo      repository.AuditLogRepository
+	retention time.Duration
+
+	mu        sync.RWMutex
+	snapshots map[string]AuditSnapshotFunc
+
+	retentionOnce sync.Once
+}
+
+// NewAuditService 创建审计服务，retention 为 0 表示永久保留
+func NewAuditService(repo repository.AuditLogRepository, retention time.Duration) *AuditService {
+	return &AuditService{
+		repo:      repo,
+		retention: retention,
+		snapshots: make(map[string]AuditSnapshotFunc),
+	}
+}
+
+// RegisterSnapshot 注册目标类型的快照函数，未注册的类型只记录请求体
+func (s *AuditService) RegisterSnapshot(targetType string, fn AuditSnapshotFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[targetType] = fn
+}
+
+// Snapshot 返回目标当前状态的 JSON，目标不存在或未注册快照函数时返回空字符串
+func (s *AuditService) Snapshot(ctx context.Context, targetType, targetID string) string {
+	s.mu.RLock()
+	fn, ok := s.snapshots[targetType]
+	s.mu.RUnlock()
+	if !ok || targetID == "" {
+		return ""
+	}
+	value, err := fn(ctx, targetID)
+	if err != nil || value == nil {
+		klog.V(6).Infof("AuditService: snapshot %s %s unavailable: %v", targetType, targetID, err)
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		klog.Warningf("AuditService: marshal snapshot %s %s failed: %v", targetType, targetID, err)
+		return ""
+	}
+	return sanitizeAuditJSON(string(data))
+}
+
+// Record 追加一条审计日志，计算前后快照差异；写入失败只记录日志，不影响业务请求
+func (s *AuditService) Record(ctx context.Context, entry *model.AuditLog) {
+	entry.Request = sanitizeAuditJSON(entry.Request)
+	if entry.StatusCode < 400 {
+		entry.Diff = diffAuditJSON(entry.Before, entry.After)
+	}
+	if entry.WorkspaceID == 0 {
+		entry.WorkspaceID = model.DefaultWorkspaceID
+		if workspaceID, ok := repository.WorkspaceFromContext(ctx); ok {
+			entry.WorkspaceID = workspaceID
+		}
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		klog.Errorf("AuditService: record %s on %s %s failed: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// RecordCommand 记录智能体通过 run_terminal_command 执行的命令，实现 tools.CommandAuditor。
+// 被策略拒绝的命令状态码为 403，执行失败或非零退出为 500
+func (s *AuditService) RecordCommand(ctx context.Context, record tools.CommandRecord) {
+	request, _ := json.Marshal(map[string]interface{}{
+		"command":     record.Command,
+		"working_dir": record.WorkingDir,
+	})
+	result := map[string]interface{}{
+		"exit_code":    record.ExitCode,
+		"duration_ms":  record.Duration.Milliseconds(),
+		"output_bytes": record.OutputBytes,
+		"truncated":    record.Truncated,
+		"sandboxed":    record.Sandboxed,
+	}
+	status := 200
+	switch {
+	case record.Denied != "":
+		status = 403
+		result = map[string]interface{}{"denied": record.Denied}
+	case record.Error != "":
+		status = 500
+		result["error"] = record.Error
+	}
+	after, _ := json.Marshal(result)
+	s.Record(ctx, &model.AuditLog{
+		ActorName:  "agent",
+		Action:     "agent.command",
+		TargetType: AuditTargetAgent,
+		TargetID:   record.Agent,
+		Method:     "EXEC",
+		StatusCode: status,
+		Request:    string(request),
+		After:      string(after),
+	})
+}
+
+// List 分页查询审计日志
+func (s *AuditService) List(ctx context.Context, req *AuditListRequest) ([]model.AuditLog, int64, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > auditMaxPageSize {
+		pageSize = auditMaxPageSize
+	}
+	return s.repo.List(ctx, repository.AuditLogFilter{
+		WorkspaceID: req.WorkspaceID,
+		ActorID:     req.ActorID,
+		ActorName:   req.ActorName,
+		Action:      req.Action,
+		TargetType:  req.TargetType,
+		TargetID:    req.TargetID,
+		Since:       req.Since,
+		Until:       req.Until,
+		Page:        page,
+		PageSize:    pageSize,
+	})
+}
+
+// Cleanup 删除超过保留期的审计日志
+func (s *AuditService) Cleanup(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// StartRetention 启动保留期清理，立即执行一次后按 interval 周期执行
+func (s *AuditService) StartRetention(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 || interval <= 0 {
+		return
+	}
+	s.retentionOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if removed, err := s.Cleanup(ctx); err != nil {
+					klog.Warningf("AuditService: cleanup failed: %v", err)
+				} else if removed > 0 {
+					klog.V(6).Infof("AuditService: removed %d audit logs older than %v", removed, s.retention)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// sanitizeAuditJSON 脱敏密钥类字段并摘要超长文本，非 JSON 内容返回空字符串
+func sanitizeAuditJSON(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return ""
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(sanitizeAuditValue("", value)); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func sanitizeAuditValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = sanitizeAuditValue(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeAuditValue(key, item)
+		}
+		return v
+	case string:
+		if v != "" && isSensitiveAuditKey(key) {
+			return auditRedacted
+		}
+		if len(v) > auditMaxTextLength {
+			sum := sha256.Sum256([]byte(v))
+			return fmt.Sprintf("<%d bytes sha256:%s>", len(v), hex.EncodeToString(sum[:])[:16])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSensitiveAuditKey 判断字段是否为密码、密钥或令牌
+func isSensitiveAuditKey(key string) bool {
+	key = strings.ToLower(key)
+	return key == "api_key" || key == "token" ||
+		strings.Contains(key, "password") || strings.Contains(key, "secret") ||
+		strings.HasSuffix(key, "_token") || strings.HasSuffix(key, "_hash")
+}
+
+// diffAuditJSON 比较两个 JSON 对象的顶层字段，返回发生变化的字段
+// 创建时 before 为空、删除时 after 为空，差异中缺失的一侧为 null
+func diffAuditJSON(before, after string) string {
+	if before == "" && after == "" {
+		return ""
+	}
+	var beforeFields, afterFields map[string]interface{}
+	if before != "" {
+		if err := json.Unmarshal([]byte(before), &beforeFields); err != nil {
+			return ""
+		}
+	}
+	if after != "" {
+		if err := json.Unmarshal([]byte(after), &afterFields); err != nil {
+			return ""
+		}
+	}
+
+	diff := make(map[string]map[string]interface{})
+	compare := func(key string) {
+		if key == "updated_at" {
+			return
+		}
+		if _, done := diff[key]; done {
+			return
+		}
+		oldValue, newValue := beforeFields[key], afterFields[key]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			diff[key] = map[string]interface{}{"before": oldValue, "after": newValue}
+		}
+	}
+	for key := range beforeFields {
+		compare(key)
+	}
+	for key := range afterFields {
+		compare(key)
+	}
+	if len(diff) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
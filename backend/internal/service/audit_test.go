@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents/tools"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newTestAuditService(t *testing.T, retention time.Duration) (*AuditService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.AuditLog{}, &model.APIKey{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	return NewAuditService(repository.NewAuditLogRepository(db), retention), db
+}
+
+func TestAuditServiceSnapshotDiffAndRedaction(t *testing.T) {
+	svc, db := newTestAuditService(t, 0)
+	ctx := repository.WithWorkspace(context.Background(), 3)
+	apiKeys := repository.NewAPIKeyRepository(db)
+	svc.RegisterSnapshot(AuditTargetAPIKey, SnapshotByID(apiKeys.GetByID))
+
+	key := &model.APIKey{Name: "primary", Provider: "openai", BaseURL: "https://api.example.com", APIKey: "sk-secret", Model: "gpt", Priority: 1, Status: "enabled"}
+	if err := apiKeys.Create(ctx, key); err != nil {
+		t.Fatalf("create api key error: %v", err)
+	}
+	id := "1"
+	before := svc.Snapshot(ctx, AuditTargetAPIKey, id)
+	if before == "" || strings.Contains(before, "sk-secret") {
+		t.Fatalf("snapshot must exist and hide the api key: %s", before)
+	}
+	key.Priority = 5
+	if err := apiKeys.Update(ctx, key); err != nil {
+		t.Fatalf("update api key error: %v", err)
+	}
+	after := svc.Snapshot(ctx, AuditTargetAPIKey, id)
+
+	svc.Record(ctx, &model.AuditLog{
+		Action:     "api_key.update",
+		TargetType: AuditTargetAPIKey,
+		TargetID:   id,
+		StatusCode: 200,
+		Request:    `{"priority": 5, "api_key": "sk-new", "description": "` + strings.Repeat("x", auditMaxTextLength+1) + `"}`,
+		Before:     before,
+		After:      after,
+	})
+
+	logs, total, err := svc.List(context.Background(), &AuditListRequest{Action: "api_key."})
+	if err != nil || total != 1 {
+		t.Fatalf("list error: %v total=%d", err, total)
+	}
+	entry := logs[0]
+	if entry.WorkspaceID != 3 {
+		t.Fatalf("workspace should come from context, got %d", entry.WorkspaceID)
+	}
+	if strings.Contains(entry.Request, "sk-new") || !strings.Contains(entry.Request, "sha256:") {
+		t.Fatalf("request body not sanitized: %s", entry.Request)
+	}
+	var diff map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(entry.Diff), &diff); err != nil {
+		t.Fatalf("invalid diff %q: %v", entry.Diff, err)
+	}
+	if len(diff) != 1 || diff["priority"]["before"] != float64(1) || diff["priority"]["after"] != float64(5) {
+		t.Fatalf("unexpected diff: %s", entry.Diff)
+	}
+
+	// 不存在的目标与未注册的类型没有快照
+	if got := svc.Snapshot(ctx, AuditTargetAPIKey, "99"); got != "" {
+		t.Fatalf("missing target should have no snapshot, got %s", got)
+	}
+	if got := svc.Snapshot(ctx, AuditTargetSync, "1"); got != "" {
+		t.Fatalf("unregistered target should have no snapshot, got %s", got)
+	}
+}
+
+func TestAuditServiceFailedRequestHasNoDiff(t *testing.T) {
+	svc, _ := newTestAuditService(t, 0)
+	ctx := context.Background()
+	svc.Record(ctx, &model.AuditLog{Action: "repository.delete", TargetType: AuditTargetRepository, TargetID: "7", StatusCode: 403, Before: `{"id":7}`})
+
+	logs, _, err := svc.List(ctx, &AuditListRequest{TargetType: AuditTargetRepository, TargetID: "7"})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("list error: %v len=%d", err, len(logs))
+	}
+	if logs[0].Diff != "" || logs[0].WorkspaceID != model.DefaultWorkspaceID {
+		t.Fatalf("unexpected entry: %+v", logs[0])
+	}
+}
+
+func TestAuditServiceRecordCommand(t *testing.T) {
+	svc, _ := newTestAuditService(t, 0)
+	ctx := context.Background()
+	svc.RecordCommand(ctx, tools.CommandRecord{Agent: "document-generator", Command: "rm -rf .", Denied: `command "rm" is denied`})
+	svc.RecordCommand(ctx, tools.CommandRecord{Agent: "document-generator", Command: "ls", WorkingDir: "/data/repos", Duration: 1500 * time.Millisecond, OutputBytes: 42, Sandboxed: true})
+
+	logs, total, err := svc.List(ctx, &AuditListRequest{Action: "agent.command", TargetID: "document-generator"})
+	if err != nil || total != 2 {
+		t.Fatalf("list error: %v total=%d", err, total)
+	}
+	statuses := map[string]int{}
+	for _, entry := range logs {
+		var request map[string]string
+		json.Unmarshal([]byte(entry.Request), &request)
+		statuses[request["command"]] = entry.StatusCode
+	}
+	if statuses["rm -rf ."] != 403 || statuses["ls"] != 200 {
+		t.Fatalf("unexpected statuses: %v", statuses)
+	}
+}
+
+func TestAuditServiceCleanup(t *testing.T) {
+	svc, db := newTestAuditService(t, 24*time.Hour)
+	ctx := context.Background()
+	old := &model.AuditLog{Action: "task.reset", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	recent := &model.AuditLog{Action: "task.run"}
+	if err := db.Create(old).Error; err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	if err := db.Create(recent).Error; err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	removed, err := svc.Cleanup(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("cleanup removed=%d err=%v", removed, err)
+	}
+	logs, total, _ := svc.List(ctx, &AuditListRequest{})
+	if total != 1 || logs[0].Action != "task.run" {
+		t.Fatalf("unexpected remaining logs: %+v", logs)
+	}
+
+	// 保留期为 0 时永久保留
+	forever, _ := newTestAuditService(t, 0)
+	if removed, err := forever.Cleanup(ctx); err != nil || removed != 0 {
+		t.Fatalf("retention 0 should keep logs, removed=%d err=%v", removed, err)
+	}
+}
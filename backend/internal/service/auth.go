@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// APITokenPrefix API Token 的明文前缀，用于与会话令牌区分
+	APITokenPrefix = "odw_"
+
+	minPasswordLength = 8
+	defaultSessionTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUnauthenticated 未登录或令牌无效
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrInvalidRole 无效的角色
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrWeakPassword 密码强度不足
+	ErrWeakPassword = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	// ErrLastAdmin 不能删除或降级最后一个管理员
+	ErrLastAdmin = errors.New("cannot remove the last admin")
+	// ErrExternalAccount 外部身份提供方管理的账号不能设置本地密码
+	ErrExternalAccount = errors.New("password is managed by the identity provider")
+)
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+}
+
+// UpdateUserRequest 更新用户请求，空字段不修改
+type UpdateUserRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+	Password    string `json:"password"`
+}
+
+// CreateAPITokenRequest 创建 API Token 请求
+type CreateAPITokenRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Role          string `json:"role"`            // 为空时继承用户角色
+	ExpiresInDays int    `json:"expires_in_days"` // 0 表示永不过期
+}
+
+// AuthService 用户、登录会话与 API Token 管理
+type AuthService struct {
+	userRepo    repository.UserRepository
+	tokenRepo   repository.APITokenRepository
+	sessionRepo repository.UserSessionRepository
+	sessionTTL  time.Duration
+}
+
+// NewAuthService 创建认证服务
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.APITokenRepository, sessionRepo repository.UserSessionRepository, sessionTTL time.Duration) *AuthService {
+	if sessionTTL <= 0 {
+		sessionTTL = defaultSessionTTL
+	}
+	return &AuthService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		sessionTTL:  sessionTTL,
+	}
+}
+
+// SessionTTL 返回登录会话有效期
+func (s *AuthService) SessionTTL() time.Duration {
+	return s.sessionTTL
+}
+
+// EnsureBootstrapAdmin 没有任何用户时创建初始管理员
+// 密码为空时随机生成，写入权限为 0600 的 passwordFile，日志中只记录文件位置，避免密码进入日志系统
+func (s *AuthService) EnsureBootstrapAdmin(ctx context.Context, username, password, passwordFile string) error {
+	count, err := s.userRepo.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if username == "" {
+		username = "admin"
+	}
+	generated := password == ""
+	if generated {
+		if passwordFile == "" {
+			return fmt.Errorf("admin password is required when no password file is configured")
+		}
+		if password, err = randomToken(12); err != nil {
+			return err
+		}
+		if err := writeSecretFile(passwordFile, password+"\n"); err != nil {
+			return fmt.Errorf("write bootstrap admin password: %w", err)
+		}
+	}
+	if _, err := s.CreateUser(ctx, &CreateUserRequest{Username: username, Password: password, Role: model.RoleAdmin}); err != nil {
+		if generated {
+			os.Remove(passwordFile)
+		}
+		return err
+	}
+	if generated {
+		klog.Warningf("已创建初始管理员账号: username=%s，随机密码已写入 %s（请登录后立即修改密码并删除该文件）", username, passwordFile)
+	} else {
+		klog.Infof("已创建初始管理员账号: username=%s", username)
+	}
+	return nil
+}
+
+// writeSecretFile 以 0600 权限写入敏感内容，已存在的文件同样收紧权限
+func writeSecretFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// CreateUser 创建用户，未指定角色时为 viewer
+func (s *AuthService) CreateUser(ctx context.Context, req *CreateUserRequest) (*model.User, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	role := req.Role
+	if role == "" {
+		role = model.RoleViewer
+	}
+	if !model.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.userRepo.GetByUsername(ctx, username); err == nil {
+		return nil, repository.ErrUserDuplicate
+	}
+
+	user := &model.User{
+		Username:    username,
+		DisplayName: req.DisplayName,
+		Email:       req.Email,
+		Role:        role,
+		Status:      model.UserStatusEnabled,
+		AuthSource:  model.AuthSourceLocal,
+	}
+	if req.Password != "" {
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("CreateUser: username=%s, role=%s, id=%d", user.Username, user.Role, user.ID)
+	return user, nil
+}
+
+// UpdateUser 更新用户资料、角色、状态或重置密码
+func (s *AuthService) UpdateUser(ctx context.Context, id uint, req *UpdateUserRequest) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role != "" && !model.IsValidRole(req.Role) {
+		return nil, ErrInvalidRole
+	}
+	if req.Password != "" && user.AuthSource == model.AuthSourceOIDC {
+		return nil, ErrExternalAccount
+	}
+	if req.Status != "" && req.Status != model.UserStatusEnabled && req.Status != model.UserStatusDisabled {
+		return nil, ErrInvalidStatus
+	}
+	losingAdmin := user.Role == model.RoleAdmin && user.Status == model.UserStatusEnabled &&
+		((req.Role != "" && req.Role != model.RoleAdmin) || req.Status == model.UserStatusDisabled)
+	if losingAdmin {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.DisplayName != "" {
+		user.DisplayName = req.DisplayName
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Role != "" {
+		user.Role = req.Role
+	}
+	if req.Status != "" {
+		user.Status = req.Status
+	}
+	if req.Password != "" {
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	// 禁用或重置密码后，已有会话失效
+	if req.Status == model.UserStatusDisabled || req.Password != "" {
+		_ = s.sessionRepo.DeleteByUser(ctx, user.ID)
+	}
+	return user, nil
+}
+
+// DeleteUser 删除用户及其会话与 API Token
+func (s *AuthService) DeleteUser(ctx context.Context, id uint) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == model.RoleAdmin && user.Status == model.UserStatusEnabled {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	_ = s.sessionRepo.DeleteByUser(ctx, id)
+	_ = s.tokenRepo.DeleteByUser(ctx, id)
+	return nil
+}
+
+func (s *AuthService) ensureOtherAdmin(ctx context.Context) error {
+	count, err := s.userRepo.CountByRole(ctx, model.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// GetUser 获取用户
+func (s *AuthService) GetUser(ctx context.Context, id uint) (*model.User, error) {
+	return s.userRepo.GetByID(ctx, id)
+}
+
+// ListUsers 列出用户
+func (s *AuthService) ListUsers(ctx context.Context) ([]model.User, error) {
+	return s.userRepo.List(ctx)
+}
+
+// ChangePassword 用户修改自己的密码，需校验旧密码
+func (s *AuthService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.AuthSource == model.AuthSourceOIDC {
+		return ErrExternalAccount
+	}
+	if user.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return s.userRepo.Save(ctx, user)
+}
+
+// Login 校验用户名密码并创建登录会话，返回会话令牌
+func (s *AuthService) Login(ctx context.Context, username, password string) (string, *model.User, error) {
+	user, err := s.userRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return "", nil, ErrInvalidCredentials
+		}
+		return "", nil, err
+	}
+	if user.Status != model.UserStatusEnabled || user.PasswordHash == "" ||
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return "", nil, ErrInvalidCredentials
+	}
+
+	token, err := s.CreateSession(ctx, user)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// CreateSession 为已认证的用户创建登录会话，返回会话令牌
+func (s *AuthService) CreateSession(ctx context.Context, user *model.User) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if err := s.sessionRepo.Create(ctx, &model.UserSession{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.sessionTTL),
+	}); err != nil {
+		return "", err
+	}
+	user.LastLoginAt = &now
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		klog.V(6).Infof("更新最后登录时间失败: userID=%d, error=%v", user.ID, err)
+	}
+	return token, nil
+}
+
+// Logout 删除登录会话
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.sessionRepo.DeleteByHash(ctx, hashToken(token))
+}
+
+// Authenticate 根据会话令牌或 API Token 解析当前用户
+// API Token 指定了角色时，返回的用户角色被降为该角色
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	now := time.Now()
+	hash := hashToken(token)
+
+	var userID uint
+	var tokenRole string
+	if strings.HasPrefix(token, APITokenPrefix) {
+		apiToken, err := s.tokenRepo.GetByHash(ctx, hash)
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+		if apiToken.ExpiresAt != nil && now.After(*apiToken.ExpiresAt) {
+			return nil, ErrUnauthenticated
+		}
+		// 每分钟最多记录一次使用时间，避免每个请求都写库
+		if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > time.Minute {
+			_ = s.tokenRepo.TouchLastUsed(ctx, apiToken.ID, now)
+		}
+		userID, tokenRole = apiToken.UserID, apiToken.Role
+	} else {
+		session, err := s.sessionRepo.GetByHash(ctx, hash)
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+		if now.After(session.ExpiresAt) {
+			_ = s.sessionRepo.DeleteByHash(ctx, hash)
+			return nil, ErrUnauthenticated
+		}
+		userID = session.UserID
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.Status != model.UserStatusEnabled {
+		return nil, ErrUnauthenticated
+	}
+	if tokenRole != "" && model.RoleLevel(tokenRole) < model.RoleLevel(user.Role) {
+		user.Role = tokenRole
+	}
+	return user, nil
+}
+
+// CreateAPIToken 为用户创建 API Token，明文只在创建时返回一次
+func (s *AuthService) CreateAPIToken(ctx context.Context, user *model.User, req *CreateAPITokenRequest) (*model.APIToken, string, error) {
+	if req.Role != "" {
+		if !model.IsValidRole(req.Role) {
+			return nil, "", ErrInvalidRole
+		}
+		if model.RoleLevel(req.Role) > model.RoleLevel(user.Role) {
+			return nil, "", fmt.Errorf("%w: token role %s exceeds user role %s", ErrInvalidRole, req.Role, user.Role)
+		}
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := APITokenPrefix + secret
+	token := &model.APIToken{
+		UserID:    user.ID,
+		Name:      req.Name,
+		TokenHash: hashToken(plain),
+		Prefix:    plain[:len(APITokenPrefix)+6],
+		Role:      req.Role,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+	klog.V(6).Infof("CreateAPIToken: userID=%d, tokenID=%d, name=%s", user.ID, token.ID, token.Name)
+	return token, plain, nil
+}
+
+// ListAPITokens 列出用户的 API Token
+func (s *AuthService) ListAPITokens(ctx context.Context, userID uint) ([]model.APIToken, error) {
+	return s.tokenRepo.ListByUser(ctx, userID)
+}
+
+// RevokeAPIToken 撤销用户的 API Token
+func (s *AuthService) RevokeAPIToken(ctx context.Context, userID uint, tokenID uint) error {
+	return s.tokenRepo.Delete(ctx, userID, tokenID)
+}
+
+// CleanupExpiredSessions 清理过期的登录会话
+func (s *AuthService) CleanupExpiredSessions(ctx context.Context) (int64, error) {
+	return s.sessionRepo.DeleteExpired(ctx, time.Now())
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// hashToken 令牌只以 SHA-256 哈希落库
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.APIToken{}, &model.UserSession{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	return NewAuthService(repository.NewUserRepository(db), repository.NewAPITokenRepository(db), repository.NewUserSessionRepository(db), time.Hour)
+}
+
+func TestAuthServiceLoginAndSession(t *testing.T) {
+	svc := newTestAuthService(t)
+	ctx := context.Background()
+
+	if err := svc.EnsureBootstrapAdmin(ctx, "admin", "admin-password", ""); err != nil {
+		t.Fatalf("bootstrap error: %v", err)
+	}
+	// 已有用户时不重复创建
+	if err := svc.EnsureBootstrapAdmin(ctx, "other", "other-password", ""); err != nil {
+		t.Fatalf("bootstrap again error: %v", err)
+	}
+	users, _ := svc.ListUsers(ctx)
+	if len(users) != 1 || users[0].Role != model.RoleAdmin {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	if _, _, err := svc.Login(ctx, "admin", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	token, user, err := svc.Login(ctx, "admin", "admin-password")
+	if err != nil {
+		t.Fatalf("login error: %v", err)
+	}
+	if user.LastLoginAt == nil {
+		t.Fatalf("expected last login time")
+	}
+
+	current, err := svc.Authenticate(ctx, token)
+	if err != nil || current.Username != "admin" {
+		t.Fatalf("authenticate error: %v, user=%+v", err, current)
+	}
+
+	if err := svc.Logout(ctx, token); err != nil {
+		t.Fatalf("logout error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, token); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected unauthenticated after logout, got %v", err)
+	}
+}
+
+func TestAuthServiceDisabledUserLosesSession(t *testing.T) {
+	svc := newTestAuthService(t)
+	ctx := context.Background()
+
+	user, err := svc.CreateUser(ctx, &CreateUserRequest{Username: "bob", Password: "bob-password", Role: model.RoleEditor})
+	if err != nil {
+		t.Fatalf("create user error: %v", err)
+	}
+	token, _, err := svc.Login(ctx, "bob", "bob-password")
+	if err != nil {
+		t.Fatalf("login error: %v", err)
+	}
+	if _, err := svc.UpdateUser(ctx, user.ID, &UpdateUserRequest{Status: model.UserStatusDisabled}); err != nil {
+		t.Fatalf("disable user error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, token); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected unauthenticated for disabled user, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "bob", "bob-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected disabled user login to fail, got %v", err)
+	}
+}
+
+func TestAuthServiceAPITokenRole(t *testing.T) {
+	svc := newTestAuthService(t)
+	ctx := context.Background()
+
+	admin, err := svc.CreateUser(ctx, &CreateUserRequest{Username: "root", Password: "root-password", Role: model.RoleAdmin})
+	if err != nil {
+		t.Fatalf("create user error: %v", err)
+	}
+
+	apiToken, plaintext, err := svc.CreateAPIToken(ctx, admin, &CreateAPITokenRequest{Name: "ci", Role: model.RoleViewer})
+	if err != nil {
+		t.Fatalf("create token error: %v", err)
+	}
+	if !strings.HasPrefix(plaintext, APITokenPrefix) || apiToken.TokenHash == plaintext {
+		t.Fatalf("unexpected token: %s", plaintext)
+	}
+
+	// Token 指定的角色低于用户角色时按 Token 角色鉴权
+	current, err := svc.Authenticate(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("authenticate error: %v", err)
+	}
+	if current.Role != model.RoleViewer || current.HasRole(model.RoleEditor) {
+		t.Fatalf("expected viewer role, got %s", current.Role)
+	}
+
+	// 普通用户不能创建高于自身角色的 Token
+	viewer, _ := svc.CreateUser(ctx, &CreateUserRequest{Username: "guest", Password: "guest-password"})
+	if _, _, err := svc.CreateAPIToken(ctx, viewer, &CreateAPITokenRequest{Name: "x", Role: model.RoleAdmin}); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("expected invalid role, got %v", err)
+	}
+
+	if err := svc.RevokeAPIToken(ctx, admin.ID, apiToken.ID); err != nil {
+		t.Fatalf("revoke error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, plaintext); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected unauthenticated after revoke, got %v", err)
+	}
+}
+
+func TestAuthServiceLastAdminProtection(t *testing.T) {
+	svc := newTestAuthService(t)
+	ctx := context.Background()
+
+	admin, err := svc.CreateUser(ctx, &CreateUserRequest{Username: "admin", Password: "admin-password", Role: model.RoleAdmin})
+	if err != nil {
+		t.Fatalf("create user error: %v", err)
+	}
+	if err := svc.DeleteUser(ctx, admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("expected last admin error, got %v", err)
+	}
+	if _, err := svc.UpdateUser(ctx, admin.ID, &UpdateUserRequest{Role: model.RoleViewer}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("expected last admin error, got %v", err)
+	}
+
+	if _, err := svc.CreateUser(ctx, &CreateUserRequest{Username: "admin2", Password: "admin-password", Role: model.RoleAdmin}); err != nil {
+		t.Fatalf("create second admin error: %v", err)
+	}
+	if err := svc.DeleteUser(ctx, admin.ID); err != nil {
+		t.Fatalf("delete admin error: %v", err)
+	}
+	if _, err := svc.CreateUser(ctx, &CreateUserRequest{Username: "weak", Password: "short"}); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("expected weak password error, got %v", err)
+	}
+}
+
+// TestEnsureBootstrapAdminGeneratedPassword 随机密码写入 0600 文件，不出现在日志中
+func TestEnsureBootstrapAdminGeneratedPassword(t *testing.T) {
+	svc := newTestAuthService(t)
+	ctx := context.Background()
+
+	if err := svc.EnsureBootstrapAdmin(ctx, "admin", "", ""); err == nil {
+		t.Fatalf("expected error without password file")
+	}
+
+	passwordFile := filepath.Join(t.TempDir(), "data", "initial_admin_password")
+	if err := svc.EnsureBootstrapAdmin(ctx, "admin", "", passwordFile); err != nil {
+		t.Fatalf("bootstrap error: %v", err)
+	}
+	info, err := os.Stat(passwordFile)
+	if err != nil {
+		t.Fatalf("password file error: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("password file mode = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(passwordFile)
+	if _, _, err := svc.Login(ctx, "admin", strings.TrimSpace(string(data))); err != nil {
+		t.Fatalf("login with generated password error: %v", err)
+	}
+}
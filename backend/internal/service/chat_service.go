@@ -12,7 +12,7 @@ import (
 // ChatService 对话服务接口
 type ChatService interface {
 	// 会话管理
-	CreateSession(ctx context.Context, repoID uint) (*model.ChatSession, error)
+	CreateSession(ctx context.Context, repoID uint, createdBy uint) (*model.ChatSession, error)
 	GetSession(ctx context.Context, sessionID string) (*model.ChatSession, error)
 	ListSessions(ctx context.Context, repoID uint, page, pageSize int) ([]*model.ChatSession, int64, error)
 	ListPublicSessions(ctx context.Context, repoID uint, page, pageSize int) ([]*model.ChatSession, int64, error)
@@ -61,11 +61,12 @@ func generateID(prefix string) string {
 	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
 }
 
-// CreateSession 创建会话
-func (s *chatService) CreateSession(ctx context.Context, repoID uint) (*model.ChatSession, error) {
+// CreateSession 创建会话，createdBy 为创建者用户ID，未登录时为 0
+func (s *chatService) CreateSession(ctx context.Context, repoID uint, createdBy uint) (*model.ChatSession, error) {
 	session := &model.ChatSession{
 		SessionID: generateID("sess"),
 		RepoID:    repoID,
+		CreatedBy: createdBy,
 		Title:     "新对话",
 		Status:    "active",
 		CreatedAt: time.Now(),
@@ -12,6 +12,8 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/config"
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/docindex"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/forge"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"k8s.io/klog/v2"
 )
@@ -23,6 +25,11 @@ type DocumentService struct {
 	ratingRepo repository.DocumentRatingRepository
 	pdfService *PDFService
 	bus        *eventbus.DocEventBus
+
+	// 引用文档集仓储，可为空；为空时只能访问仓库主文档集
+	refRepo repository.RepositoryRefRepository
+
+	searchIndex *docindex.Index
 }
 
 // NewDocumentService 创建文档服务
@@ -34,11 +41,14 @@ func NewDocumentService(cfg *config.Config, docRepo repository.DocumentRepositor
 		ratingRepo: ratingRepo,
 		pdfService: NewPDFService(),
 		bus:        bus,
+
+		searchIndex: docindex.NewIndex(0),
 	}
 }
 
 type CreateDocumentRequest struct {
 	RepositoryID uint   `json:"repository_id"`
+	Ref          string `json:"ref"` // 引用文档集，为空表示仓库主文档集
 	TaskID       uint   `json:"task_id"`
 	Title        string `json:"title"`
 	Filename     string `json:"filename"`
@@ -56,6 +66,7 @@ func (s *DocumentService) TransferLatest(oldDocID uint, newDocID uint) error {
 func (s *DocumentService) Create(req CreateDocumentRequest) (*model.Document, error) {
 	doc := &model.Document{
 		RepositoryID: req.RepositoryID,
+		Ref:          req.Ref,
 		TaskID:       req.TaskID,
 		Title:        req.Title,
 		Filename:     req.Filename,
@@ -87,25 +98,79 @@ func (s *DocumentService) GetByRepository(repoID uint) ([]model.Document, error)
 	return s.docRepo.GetByRepository(repoID)
 }
 
+// SetRepositoryRefRepository 设置引用文档集仓储，用于按分支或标签切换文档版本
+func (s *DocumentService) SetRepositoryRefRepository(refRepo repository.RepositoryRefRepository) {
+	s.refRepo = refRepo
+}
+
+// GetByRepositoryRef 获取仓库某个引用文档集的最新文档，ref 为空表示仓库主文档集
+func (s *DocumentService) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	if err := s.checkRef(repoID, ref); err != nil {
+		return nil, err
+	}
+	return s.docRepo.GetByRepositoryRef(repoID, ref)
+}
+
+// checkRef 校验引用文档集存在，ref 为空表示仓库主文档集
+func (s *DocumentService) checkRef(repoID uint, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	if s.refRepo == nil {
+		return repository.ErrRepositoryRefNotFound
+	}
+	_, err := s.refRepo.GetByName(context.Background(), repoID, ref)
+	return err
+}
+
+// refSuffix 导出文件名中的引用后缀，例如 demo@v1.0-docs.zip
+func refSuffix(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return "@" + refPathName(ref)
+}
+
 func (s *DocumentService) Get(id uint) (*model.Document, error) {
 	return s.docRepo.Get(id)
 }
 
+// GetInWorkspace 按 context 中的工作空间获取文档，其他工作空间的文档视为不存在
+func (s *DocumentService) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return s.docRepo.GetInWorkspace(ctx, id)
+}
+
 func (s *DocumentService) GetVersions(docID uint) ([]model.Document, error) {
 	doc, err := s.docRepo.Get(docID)
 	if err != nil {
 		return nil, err
 	}
-	return s.docRepo.GetVersions(doc.RepositoryID, doc.Title)
+	versions, err := s.docRepo.GetVersions(doc.RepositoryID, doc.Title)
+	if err != nil {
+		return nil, err
+	}
+	return filterDocsByRef(versions, doc.Ref), nil
+}
+
+// filterDocsByRef 只保留属于指定引用文档集的文档，同名文档在不同引用下各自有版本序列
+func filterDocsByRef(docs []model.Document, ref string) []model.Document {
+	filtered := docs[:0]
+	for _, doc := range docs {
+		if doc.Ref == ref {
+			filtered = append(filtered, doc)
+		}
+	}
+	return filtered
 }
 
-func (s *DocumentService) Update(docID uint, content string) (*model.Document, error) {
+func (s *DocumentService) Update(docID uint, content string, updatedBy uint) (*model.Document, error) {
 	doc, err := s.docRepo.Get(docID)
 	if err != nil {
 		return nil, err
 	}
 
 	doc.Content = content
+	doc.UpdatedBy = updatedBy
 	doc.UpdatedAt = time.Now()
 	if err := s.docRepo.Save(doc); err != nil {
 		return nil, err
@@ -133,13 +198,14 @@ func (s *DocumentService) DeleteByTaskID(taskID uint) error {
 	return s.docRepo.DeleteByTaskID(taskID)
 }
 
-func (s *DocumentService) ExportAll(repoID uint) ([]byte, string, error) {
-	repo, err := s.repoRepo.GetBasic(repoID)
+// ExportAll 导出仓库某个引用文档集的所有文档为 zip，ref 为空表示仓库主文档集
+func (s *DocumentService) ExportAll(repoID uint, ref string) ([]byte, string, error) {
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	docs, err := s.docRepo.GetByRepository(repoID)
+	docs, err := s.GetByRepositoryRef(repoID, ref)
 	if err != nil {
 		return nil, "", err
 	}
@@ -170,18 +236,18 @@ func (s *DocumentService) ExportAll(repoID uint) ([]byte, string, error) {
 		return nil, "", err
 	}
 
-	filename := fmt.Sprintf("%s-docs.zip", repo.Name)
+	filename := fmt.Sprintf("%s%s-docs.zip", repo.Name, refSuffix(ref))
 	return buf.Bytes(), filename, nil
 }
 
-// ExportPDF 导出仓库下所有文档为PDF
-func (s *DocumentService) ExportPDF(repoID uint) ([]byte, string, error) {
-	repo, err := s.repoRepo.GetBasic(repoID)
+// ExportPDF 导出仓库某个引用文档集的所有文档为PDF，ref 为空表示仓库主文档集
+func (s *DocumentService) ExportPDF(repoID uint, ref string) ([]byte, string, error) {
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	docs, err := s.docRepo.GetByRepository(repoID)
+	docs, err := s.GetByRepositoryRef(repoID, ref)
 	if err != nil {
 		return nil, "", err
 	}
@@ -199,7 +265,7 @@ func (s *DocumentService) ExportPDF(repoID uint) ([]byte, string, error) {
 		return nil, "", err
 	}
 
-	filename := fmt.Sprintf("%s-docs.pdf", repo.Name)
+	filename := fmt.Sprintf("%s%s-docs.pdf", repo.Name, refSuffix(ref))
 	klog.V(6).Infof("导出PDF完成: repoID=%d, 文件大小=%d", repoID, len(data))
 	return data, filename, nil
 }
@@ -215,13 +281,13 @@ func (s *DocumentService) generateIndex(repoName string, docs []model.Document)
 	return content
 }
 
-func (s *DocumentService) GetIndex(repoID uint) (string, error) {
-	repo, err := s.repoRepo.GetBasic(repoID)
+func (s *DocumentService) GetIndex(repoID uint, ref string) (string, error) {
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return "", err
 	}
 
-	docs, err := s.docRepo.GetByRepository(repoID)
+	docs, err := s.GetByRepositoryRef(repoID, ref)
 	if err != nil {
 		return "", err
 	}
@@ -236,29 +302,43 @@ func (s *DocumentService) GetRedirectURL(docID uint, filePath string) (string, e
 		return "", err
 	}
 
-	repo, err := s.repoRepo.GetBasic(doc.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), doc.RepositoryID)
 	if err != nil {
 		return "", err
 	}
 
-	// 处理仓库 URL
-	repoURL := repo.URL
-	if before, ok := strings.CutSuffix(repoURL, ".git"); ok {
-		repoURL = before
+	// 本地目录与压缩包导入的仓库没有可跳转的远端
+	if IsImportedSource(repo) {
+		return "", ErrRepositoryHasNoRemote
 	}
 
-	branch := repo.CloneBranch
-	if branch == "" {
-		branch = "main" // 默认回退
+	remote, err := forge.Parse(repo.URL)
+	if err != nil {
+		return "", err
 	}
 
-	// 清理文件路径 (移除开头的 /)
-	filePath = strings.TrimPrefix(filePath, "/")
+	// 指定了分支、标签或提交时 CloneBranch 为请求的引用；未记录时按克隆的提交定位，再回退到远端默认分支
+	ref := forge.Ref{Type: repo.CloneRefType, Name: repo.CloneBranch}
+	if doc.Ref != "" {
+		// 引用文档集的文档跳转到该引用下的代码
+		ref = forge.Ref{Name: doc.Ref}
+		if s.refRepo != nil {
+			if docRef, err := s.refRepo.GetByName(context.Background(), repo.ID, doc.Ref); err == nil {
+				ref.Type = docRef.RefType
+			}
+		}
+	}
+	if ref.Name == "" && repo.CloneCommit != "" {
+		ref = forge.Ref{Type: forge.RefCommit, Name: repo.CloneCommit}
+	}
 
-	// 构造 URL
-	// 假设是 GitHub/GitLab 风格: base/blob/branch/path
-	// TODO 以后要兼容各种类型
-	return fmt.Sprintf("%s/blob/%s/%s", repoURL, branch, filePath), nil
+	// 文档中的行号是按生成时的代码写的，带行号的链接固定到生成文档时的提交，避免分支前进后行号错位
+	path, lines := forge.SplitLineAnchor(filePath)
+	if lines.Start > 0 && doc.CloneCommitID != "" {
+		ref = forge.Ref{Type: forge.RefCommit, Name: doc.CloneCommitID}
+	}
+
+	return remote.BlobURL(ref, path, lines), nil
 }
 
 // SubmitRating 提交文档评分并返回统计信息
@@ -317,138 +397,140 @@ func (s *DocumentService) GetTokenUsage(docID uint) (*model.TaskUsage, error) {
 
 // DocumentSearchResult 文档搜索结果
 type DocumentSearchResult struct {
-	DocID    uint   `json:"doc_id"`
-	RepoID   uint   `json:"repo_id"`
-	RepoName string `json:"repo_name"`
-	Title    string `json:"title"`
-	Filename string `json:"filename"`
-	Snippet  string `json:"snippet"`
-}
-
-// SearchDocuments 搜索文档内容（关键词匹配）
-// query: 搜索关键词
-// repoID: 限定仓库ID（可选，nil 表示搜索所有仓库）
-func (s *DocumentService) SearchDocuments(ctx context.Context, query string, repoID *uint) ([]DocumentSearchResult, error) {
-	// 获取所有最新文档
-	var docs []model.Document
-	if repoID != nil {
-		// 搜索指定仓库
-		var err error
-		docs, err = s.docRepo.GetByRepository(*repoID)
-		if err != nil {
-			return nil, fmt.Errorf("获取仓库文档失败: %w", err)
-		}
-	} else {
-		// 搜索所有仓库，只获取最新文档
-		allDocs, err := s.docRepo.GetAllLatest()
-		if err != nil {
-			return nil, fmt.Errorf("获取所有文档失败: %w", err)
-		}
-		docs = allDocs
-	}
+	DocID    uint    `json:"doc_id"`
+	RepoID   uint    `json:"repo_id"`
+	RepoName string  `json:"repo_name"`
+	Ref      string  `json:"ref,omitempty"`
+	Title    string  `json:"title"`
+	Filename string  `json:"filename"`
+	Version  int     `json:"version"`
+	Heading  string  `json:"heading,omitempty"` // 命中分块所在章节
+	Score    float64 `json:"score"`
+	Snippet  string  `json:"snippet"`
+}
 
-	// 关键词搜索（不区分大小写）
-	queryLower := strings.ToLower(query)
-	var matchedDocs []model.Document
-	repoIDs := make(map[uint]struct{})
+// DocumentSearchRequest 文档搜索条件
+type DocumentSearchRequest struct {
+	Query        string `json:"query" form:"q"`
+	RepositoryID uint   `json:"repository_id" form:"repo_id"` // 0 表示搜索所有仓库
+	Ref          string `json:"ref" form:"ref"`               // 为空表示只搜索仓库主文档集
+	Version      int    `json:"version" form:"version"`       // 0 表示只搜索最新版本
+	Title        string `json:"title" form:"title"`           // 标题包含该字符串
+	Limit        int    `json:"limit" form:"limit"`           // 默认 20，最大 100
+}
 
-	for _, doc := range docs {
-		// 在标题、文件名、内容中搜索
-		titleLower := strings.ToLower(doc.Title)
-		filenameLower := strings.ToLower(doc.Filename)
-		contentLower := strings.ToLower(doc.Content)
-
-		if strings.Contains(titleLower, queryLower) ||
-			strings.Contains(filenameLower, queryLower) ||
-			strings.Contains(contentLower, queryLower) {
-			matchedDocs = append(matchedDocs, doc)
-			repoIDs[doc.RepositoryID] = struct{}{}
-		}
-	}
+// SetEventBus 设置文档事件总线
+func (s *DocumentService) SetEventBus(bus *eventbus.DocEventBus) {
+	s.bus = bus
+}
 
-	// 批量获取仓库信息，避免 N+1 查询
-	repoMap := make(map[uint]string)
-	for id := range repoIDs {
-		repo, err := s.repoRepo.GetBasic(id)
-		if err == nil && repo != nil {
-			repoMap[id] = repo.Name
-		}
+// IndexDocument 将文档（重新）写入搜索索引，文档已删除时从索引移除
+func (s *DocumentService) IndexDocument(docID uint) error {
+	doc, err := s.docRepo.Get(docID)
+	if err != nil {
+		s.searchIndex.Remove(docID)
+		return err
 	}
+	s.indexDocument(doc)
+	return nil
+}
 
-	// 构建结果
-	var results []DocumentSearchResult
-	for _, doc := range matchedDocs {
-		repoName := repoMap[doc.RepositoryID]
-		// 生成上下文片段
-		snippet := generateSnippet(doc.Content, query, 200)
-
-		results = append(results, DocumentSearchResult{
-			DocID:    doc.ID,
-			RepoID:   doc.RepositoryID,
-			RepoName: repoName,
-			Title:    doc.Title,
-			Filename: doc.Filename,
-			Snippet:  snippet,
-		})
-	}
+func (s *DocumentService) indexDocument(doc *model.Document) {
+	s.searchIndex.Upsert(docindex.DocMeta{
+		DocID:        doc.ID,
+		RepositoryID: doc.RepositoryID,
+		Ref:          doc.Ref,
+		Title:        doc.Title,
+		Filename:     doc.Filename,
+		Version:      doc.Version,
+		IsLatest:     doc.IsLatest,
+	}, doc.Content)
+}
 
-	// 限制返回数量
-	if len(results) > 20 {
-		results = results[:20]
+// RebuildSearchIndex 从数据库重建搜索索引（包含各文档的历史版本），返回索引的文档数
+func (s *DocumentService) RebuildSearchIndex() (int, error) {
+	latest, err := s.docRepo.GetAllLatest()
+	if err != nil {
+		return 0, fmt.Errorf("获取所有文档失败: %w", err)
 	}
 
-	return results, nil
-}
-
-// generateSnippet 生成搜索结果片段
-// 使用 rune 处理 UTF-8 字符，避免字节索引导致的中文字符截断问题
-func generateSnippet(content, query string, maxLen int) string {
-	// 将内容转换为 rune 切片处理 UTF-8 字符
-	contentRunes := []rune(content)
-	queryRunes := []rune(strings.ToLower(query))
-	contentLower := []rune(strings.ToLower(content))
-
-	// 查找关键词位置（不区分大小写）
-	idx := -1
-	for i := 0; i <= len(contentLower)-len(queryRunes); i++ {
-		match := true
-		for j := 0; j < len(queryRunes); j++ {
-			if contentLower[i+j] != queryRunes[j] {
-				match = false
-				break
-			}
+	count := 0
+	for i := range latest {
+		versions, err := s.docRepo.GetVersions(latest[i].RepositoryID, latest[i].Title)
+		versions = filterDocsByRef(versions, latest[i].Ref)
+		if err != nil || len(versions) == 0 {
+			versions = latest[i : i+1]
 		}
-		if match {
-			idx = i
-			break
+		for j := range versions {
+			s.indexDocument(&versions[j])
+			count++
 		}
 	}
+	klog.V(6).Infof("文档搜索索引重建完成: 文档数=%d", count)
+	return count, nil
+}
 
-	if idx == -1 {
-		// 未找到关键词，返回内容开头
-		if len(contentRunes) > maxLen {
-			return string(contentRunes[:maxLen]) + "..."
-		}
-		return content
+// SearchDocuments 按相关度（BM25）搜索文档分块，每篇文档返回得分最高的片段
+func (s *DocumentService) SearchDocuments(ctx context.Context, req DocumentSearchRequest) ([]DocumentSearchResult, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, fmt.Errorf("搜索关键词不能为空")
 	}
-
-	// 计算片段起始位置，让关键词出现在片段中间位置
-	start := idx - 50
-	if start < 0 {
-		start = 0
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 20
 	}
-	end := idx + len(queryRunes) + 150
-	if end > len(contentRunes) {
-		end = len(contentRunes)
+	if limit > 100 {
+		limit = 100
 	}
 
-	snippet := string(contentRunes[start:end])
-	if start > 0 {
-		snippet = "..." + snippet
-	}
-	if end < len(contentRunes) {
-		snippet = snippet + "..."
+	hits := s.searchIndex.Search(docindex.Query{
+		Text:         req.Query,
+		RepositoryID: req.RepositoryID,
+		Ref:          req.Ref,
+		Version:      req.Version,
+		Title:        req.Title,
+	})
+
+	results := make([]DocumentSearchResult, 0, limit)
+	repoNames := make(map[uint]string)
+	hiddenRepos := make(map[uint]bool)
+	_, scoped := repository.WorkspaceFromContext(ctx)
+	for _, hit := range hits {
+		if len(results) >= limit {
+			break
+		}
+		if hiddenRepos[hit.RepositoryID] {
+			continue
+		}
+		// 文档删除没有事件通知，命中时校验并清理失效条目
+		if _, err := s.docRepo.Get(hit.DocID); err != nil {
+			s.searchIndex.Remove(hit.DocID)
+			continue
+		}
+		repoName, ok := repoNames[hit.RepositoryID]
+		if !ok {
+			repo, err := s.repoRepo.GetBasic(ctx, hit.RepositoryID)
+			if err == nil && repo != nil {
+				repoName = repo.Name
+			} else if scoped {
+				// 仓库不在当前工作空间，其文档不返回
+				hiddenRepos[hit.RepositoryID] = true
+				continue
+			}
+			repoNames[hit.RepositoryID] = repoName
+		}
+		results = append(results, DocumentSearchResult{
+			DocID:    hit.DocID,
+			RepoID:   hit.RepositoryID,
+			RepoName: repoName,
+			Ref:      hit.Ref,
+			Title:    hit.Title,
+			Filename: hit.Filename,
+			Version:  hit.Version,
+			Heading:  hit.Heading,
+			Score:    hit.Score,
+			Snippet:  hit.Snippet,
+		})
 	}
-
-	return snippet
+	return results, nil
 }
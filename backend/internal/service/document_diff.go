@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+)
+
+// 文档差异状态
+const (
+	DocDiffAdded     = "added"
+	DocDiffRemoved   = "removed"
+	DocDiffModified  = "modified"
+	DocDiffUnchanged = "unchanged"
+)
+
+// DocumentDiff 两个引用文档集中同一标题文档的差异
+type DocumentDiff struct {
+	Title     string `json:"title"`
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	FromDocID uint   `json:"from_doc_id,omitempty"`
+	ToDocID   uint   `json:"to_doc_id,omitempty"`
+	Diff      string `json:"diff,omitempty"` // unified diff，未变化的文档为空
+}
+
+// DocumentRefDiff 两个引用文档集之间的差异，引用为空表示仓库主文档集
+type DocumentRefDiff struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Added     int            `json:"added"`
+	Removed   int            `json:"removed"`
+	Modified  int            `json:"modified"`
+	Unchanged int            `json:"unchanged"`
+	Documents []DocumentDiff `json:"documents"`
+}
+
+// DiffRefs 比较仓库两个引用文档集的最新文档，按标题对应，
+// 结果按 to 文档集的顺序排列，仅存在于 from 的文档排在最后
+func (s *DocumentService) DiffRefs(ctx context.Context, repoID uint, from, to string) (*DocumentRefDiff, error) {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return nil, err
+	}
+	fromDocs, err := s.GetByRepositoryRef(repoID, from)
+	if err != nil {
+		return nil, err
+	}
+	toDocs, err := s.GetByRepositoryRef(repoID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromByTitle := make(map[string]*model.Document, len(fromDocs))
+	for i := range fromDocs {
+		fromByTitle[fromDocs[i].Title] = &fromDocs[i]
+	}
+
+	result := &DocumentRefDiff{From: from, To: to, Documents: make([]DocumentDiff, 0, len(toDocs))}
+	matched := make(map[string]bool, len(toDocs))
+	for i := range toDocs {
+		toDoc := &toDocs[i]
+		matched[toDoc.Title] = true
+		item := DocumentDiff{Title: toDoc.Title, Filename: toDoc.Filename, ToDocID: toDoc.ID}
+		fromDoc, ok := fromByTitle[toDoc.Title]
+		switch {
+		case !ok:
+			item.Status = DocDiffAdded
+			result.Added++
+			item.Diff, err = unifiedDiff("", toDoc.Content, from, to)
+		case fromDoc.Content == toDoc.Content:
+			item.Status = DocDiffUnchanged
+			item.FromDocID = fromDoc.ID
+			result.Unchanged++
+		default:
+			item.Status = DocDiffModified
+			item.FromDocID = fromDoc.ID
+			result.Modified++
+			item.Diff, err = unifiedDiff(fromDoc.Content, toDoc.Content, from, to)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("生成文档差异失败: title=%s, error=%w", toDoc.Title, err)
+		}
+		result.Documents = append(result.Documents, item)
+	}
+	for i := range fromDocs {
+		fromDoc := &fromDocs[i]
+		if matched[fromDoc.Title] {
+			continue
+		}
+		diff, err := unifiedDiff(fromDoc.Content, "", from, to)
+		if err != nil {
+			return nil, fmt.Errorf("生成文档差异失败: title=%s, error=%w", fromDoc.Title, err)
+		}
+		result.Removed++
+		result.Documents = append(result.Documents, DocumentDiff{
+			Title:     fromDoc.Title,
+			Filename:  fromDoc.Filename,
+			Status:    DocDiffRemoved,
+			FromDocID: fromDoc.ID,
+			Diff:      diff,
+		})
+	}
+	return result, nil
+}
+
+// unifiedDiff 生成 unified diff，引用为空时以 HEAD 标识仓库主文档集
+func unifiedDiff(a, b, from, to string) (string, error) {
+	label := func(ref string) string {
+		if ref == "" {
+			return "HEAD"
+		}
+		return ref
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: label(from),
+		ToFile:   label(to),
+		Context:  3,
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -30,6 +31,13 @@ func (m *mockExportDocRepo) GetByRepository(repoID uint) ([]model.Document, erro
 	return nil, nil
 }
 
+func (m *mockExportDocRepo) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	if ref == "" {
+		return m.GetByRepository(repoID)
+	}
+	return nil, nil
+}
+
 func (m *mockExportDocRepo) GetVersions(repoID uint, title string) ([]model.Document, error) {
 	return nil, nil
 }
@@ -38,6 +46,10 @@ func (m *mockExportDocRepo) Get(id uint) (*model.Document, error) {
 	return nil, nil
 }
 
+func (m *mockExportDocRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return m.Get(id)
+}
+
 func (m *mockExportDocRepo) Save(doc *model.Document) error {
 	return nil
 }
@@ -54,6 +66,10 @@ func (m *mockExportDocRepo) DeleteByRepositoryID(repoID uint) error {
 	return nil
 }
 
+func (m *mockExportDocRepo) DeleteByRepositoryRef(repoID uint, ref string) error {
+	return nil
+}
+
 func (m *mockExportDocRepo) UpdateTaskID(docID uint, taskID uint) error {
 	return nil
 }
@@ -93,33 +109,33 @@ type mockExportRepoRepo struct {
 	GetBasicFunc func(id uint) (*model.Repository, error)
 }
 
-func (m *mockExportRepoRepo) Create(repo *model.Repository) error {
+func (m *mockExportRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockExportRepoRepo) List() ([]model.Repository, error) {
+func (m *mockExportRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	return nil, nil
 }
 
-func (m *mockExportRepoRepo) Get(id uint) (*model.Repository, error) {
+func (m *mockExportRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
 	return nil, nil
 }
 func (m *mockExportRepoRepo) GetAllDocumentsTitleAndID(repoID uint) ([]model.Document, error) {
 	return nil, nil
 }
 
-func (m *mockExportRepoRepo) GetBasic(id uint) (*model.Repository, error) {
+func (m *mockExportRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	if m.GetBasicFunc != nil {
 		return m.GetBasicFunc(id)
 	}
 	return nil, errors.New("not found")
 }
 
-func (m *mockExportRepoRepo) Save(repo *model.Repository) error {
+func (m *mockExportRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockExportRepoRepo) Delete(id uint) error {
+func (m *mockExportRepoRepo) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
@@ -139,7 +155,7 @@ func TestDocumentServiceExportPDF(t *testing.T) {
 	}
 	service := NewDocumentService(&config.Config{}, docRepo, repoRepo, nil, eventbus.NewDocEventBus())
 
-	data, filename, err := service.ExportPDF(1)
+	data, filename, err := service.ExportPDF(1, "")
 	if err != nil {
 		t.Fatalf("ExportPDF error: %v", err)
 	}
@@ -167,7 +183,7 @@ func TestDocumentServiceExportPDFNoDocs(t *testing.T) {
 	}
 	service := NewDocumentService(&config.Config{}, docRepo, repoRepo, nil, eventbus.NewDocEventBus())
 
-	_, _, err := service.ExportPDF(2)
+	_, _, err := service.ExportPDF(2, "")
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
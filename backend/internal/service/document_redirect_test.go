@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/forge"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+)
+
+func TestDocumentServiceRedirectURL(t *testing.T) {
+	env := newRefTestEnv(t)
+	ctx := context.Background()
+	if err := forge.SetHosts(map[string]string{"git.example.com": "gitlab"}); err != nil {
+		t.Fatalf("SetHosts error: %v", err)
+	}
+	defer forge.SetHosts(nil)
+
+	env.repo.URL = "https://git.example.com/platform/backend/api"
+	if err := env.docService.repoRepo.Save(ctx, env.repo); err != nil {
+		t.Fatalf("save repo error: %v", err)
+	}
+	if err := env.refRepo.Create(ctx, &model.RepositoryRef{RepositoryID: env.repo.ID, Name: "v1.0", RefType: git.RefTag, Status: model.RepositoryRefReady}); err != nil {
+		t.Fatalf("create ref error: %v", err)
+	}
+	doc, err := env.docService.Create(CreateDocumentRequest{RepositoryID: env.repo.ID, TaskID: 1, Title: "概览", Filename: "overview.md", Content: "x"})
+	if err != nil {
+		t.Fatalf("create doc error: %v", err)
+	}
+	refDoc, err := env.docService.Create(CreateDocumentRequest{RepositoryID: env.repo.ID, Ref: "v1.0", TaskID: 2, Title: "概览", Filename: "overview.md", Content: "x"})
+	if err != nil {
+		t.Fatalf("create ref doc error: %v", err)
+	}
+
+	tests := []struct {
+		docID uint
+		path  string
+		want  string
+	}{
+		{doc.ID, "/cmd/main.go", "https://git.example.com/platform/backend/api/-/blob/main/cmd/main.go"},
+		{refDoc.ID, "cmd/main.go", "https://git.example.com/platform/backend/api/-/blob/v1.0/cmd/main.go"},
+	}
+	for _, tt := range tests {
+		got, err := env.docService.GetRedirectURL(tt.docID, tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("GetRedirectURL(%d, %s) = %s, %v, want %s", tt.docID, tt.path, got, err, tt.want)
+		}
+	}
+
+	// 带行号的链接固定到生成文档时的提交
+	doc.CloneCommitID = "abc1234"
+	if err := env.docRepo.Save(doc); err != nil {
+		t.Fatalf("save doc error: %v", err)
+	}
+	got, err := env.docService.GetRedirectURL(doc.ID, "cmd/main.go#L10-L20")
+	if want := "https://git.example.com/platform/backend/api/-/blob/abc1234/cmd/main.go#L10-20"; err != nil || got != want {
+		t.Errorf("line link = %s, %v, want %s", got, err, want)
+	}
+}
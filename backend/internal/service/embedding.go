@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	einoEmbedding "github.com/cloudwego/eino/components/embedding"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/docindex"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+const (
+	embeddingBatchSize     = 32 // 单次请求向量模型的文本数
+	defaultSemanticLimit   = 10
+	maxSemanticLimit       = 50
+	maxEmbeddingTextLength = 8000 // 单个分块送入向量模型的最大字符数
+)
+
+// ErrNoEmbeddingProvider 没有可用的向量模型配置
+var ErrNoEmbeddingProvider = errors.New("no enabled embedding provider")
+
+// CreateEmbeddingProviderRequest 创建向量模型配置请求
+type CreateEmbeddingProviderRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Provider   string `json:"provider"`
+	BaseURL    string `json:"base_url" binding:"required"`
+	APIKey     string `json:"api_key"`
+	Model      string `json:"model" binding:"required"`
+	Dimensions int    `json:"dimensions"`
+	Priority   int    `json:"priority"`
+}
+
+// UpdateEmbeddingProviderRequest 更新向量模型配置请求
+type UpdateEmbeddingProviderRequest struct {
+	Name       string `json:"name"`
+	Provider   string `json:"provider"`
+	BaseURL    string `json:"base_url"`
+	APIKey     string `json:"api_key"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Priority   int    `json:"priority"`
+}
+
+// EmbeddingService 向量检索服务：管理向量模型配置，对文档与源码分块生成向量并检索
+type EmbeddingService struct {
+	providerRepo repository.EmbeddingProviderRepository
+	chunkRepo    repository.EmbeddingChunkRepository
+	docRepo      repository.DocumentRepository
+	repoRepo     repository.RepoRepository
+
+	// newEmbedder 根据配置创建向量模型客户端，测试时可替换
+	newEmbedder func(provider *model.EmbeddingProvider) einoEmbedding.Embedder
+}
+
+// NewEmbeddingService 创建向量检索服务
+func NewEmbeddingService(providerRepo repository.EmbeddingProviderRepository, chunkRepo repository.EmbeddingChunkRepository, docRepo repository.DocumentRepository, repoRepo repository.RepoRepository) *EmbeddingService {
+	return &EmbeddingService{
+		providerRepo: providerRepo,
+		chunkRepo:    chunkRepo,
+		docRepo:      docRepo,
+		repoRepo:     repoRepo,
+		newEmbedder: func(provider *model.EmbeddingProvider) einoEmbedding.Embedder {
+			return embedding.NewOpenAIEmbedder(provider.BaseURL, provider.APIKey, provider.Model, provider.Dimensions)
+		},
+	}
+}
+
+// CreateProvider 创建向量模型配置
+func (s *EmbeddingService) CreateProvider(ctx context.Context, req *CreateEmbeddingProviderRequest) (*model.EmbeddingProvider, error) {
+	if existing, err := s.providerRepo.GetByName(ctx, req.Name); err == nil && existing != nil {
+		return nil, repository.ErrEmbeddingProviderDuplicate
+	}
+	providerType := req.Provider
+	if providerType == "" {
+		providerType = embedding.ProviderOpenAI
+	}
+	provider := &model.EmbeddingProvider{
+		Name:       req.Name,
+		Provider:   providerType,
+		BaseURL:    req.BaseURL,
+		APIKey:     req.APIKey,
+		Model:      req.Model,
+		Dimensions: req.Dimensions,
+		Priority:   req.Priority,
+		Status:     "enabled",
+	}
+	if err := s.providerRepo.Create(ctx, provider); err != nil {
+		klog.Errorf("CreateEmbeddingProvider: failed to create provider: %v", err)
+		return nil, err
+	}
+	klog.V(6).Infof("CreateEmbeddingProvider: successfully created provider id=%d", provider.ID)
+	return provider, nil
+}
+
+// UpdateProvider 更新向量模型配置
+func (s *EmbeddingService) UpdateProvider(ctx context.Context, id uint, req *UpdateEmbeddingProviderRequest) (*model.EmbeddingProvider, error) {
+	provider, err := s.providerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" && req.Name != provider.Name {
+		if existing, err := s.providerRepo.GetByName(ctx, req.Name); err == nil && existing != nil && existing.ID != id {
+			return nil, repository.ErrEmbeddingProviderDuplicate
+		}
+		provider.Name = req.Name
+	}
+	if req.Provider != "" {
+		provider.Provider = req.Provider
+	}
+	if req.BaseURL != "" {
+		provider.BaseURL = req.BaseURL
+	}
+	if req.APIKey != "" {
+		provider.APIKey = req.APIKey
+	}
+	if req.Model != "" {
+		provider.Model = req.Model
+	}
+	if req.Dimensions > 0 {
+		provider.Dimensions = req.Dimensions
+	}
+	if req.Priority > 0 {
+		provider.Priority = req.Priority
+	}
+	if err := s.providerRepo.Update(ctx, provider); err != nil {
+		klog.Errorf("UpdateEmbeddingProvider: failed to update provider: %v", err)
+		return nil, err
+	}
+	return provider, nil
+}
+
+// DeleteProvider 删除向量模型配置
+func (s *EmbeddingService) DeleteProvider(ctx context.Context, id uint) error {
+	return s.providerRepo.Delete(ctx, id)
+}
+
+// GetProvider 获取向量模型配置
+func (s *EmbeddingService) GetProvider(ctx context.Context, id uint) (*model.EmbeddingProvider, error) {
+	return s.providerRepo.GetByID(ctx, id)
+}
+
+// ListProviders 列出向量模型配置
+func (s *EmbeddingService) ListProviders(ctx context.Context) ([]*model.EmbeddingProvider, error) {
+	return s.providerRepo.List(ctx)
+}
+
+// UpdateProviderStatus 启用或禁用向量模型配置
+func (s *EmbeddingService) UpdateProviderStatus(ctx context.Context, id uint, status string) error {
+	if status != "enabled" && status != "disabled" {
+		return ErrInvalidStatus
+	}
+	provider, err := s.providerRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	provider.Status = status
+	return s.providerRepo.Update(ctx, provider)
+}
+
+// availableProviders 按优先级返回启用的向量模型配置
+func (s *EmbeddingService) availableProviders(ctx context.Context) ([]*model.EmbeddingProvider, error) {
+	providers, err := s.providerRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var available []*model.EmbeddingProvider
+	for _, provider := range providers {
+		if provider.IsAvailable() {
+			available = append(available, provider)
+		}
+	}
+	if len(available) == 0 {
+		return nil, ErrNoEmbeddingProvider
+	}
+	return available, nil
+}
+
+// embedTexts 使用可用的向量模型生成向量，失败时按优先级切换到下一个配置
+func (s *EmbeddingService) embedTexts(ctx context.Context, texts []string) (*model.EmbeddingProvider, [][]float64, error) {
+	providers, err := s.availableProviders(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var lastErr error
+	for _, provider := range providers {
+		vectors, err := s.embedWith(ctx, provider, texts)
+		if err == nil {
+			return provider, vectors, nil
+		}
+		klog.Warningf("向量模型调用失败，尝试下一个配置: provider=%s, error=%v", provider.Name, err)
+		lastErr = err
+	}
+	return nil, nil, lastErr
+}
+
+// embedWith 使用指定配置分批生成向量并记录调用统计
+func (s *EmbeddingService) embedWith(ctx context.Context, provider *model.EmbeddingProvider, texts []string) ([][]float64, error) {
+	embedder := s.newEmbedder(provider)
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embedder.EmbedStrings(ctx, texts[start:end])
+		if statErr := s.providerRepo.IncrementStats(ctx, provider.ID, 1, boolToInt(err != nil)); statErr != nil {
+			klog.V(6).Infof("记录向量模型调用统计失败: provider=%s, error=%v", provider.Name, statErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// IndexDocument 为文档生成向量分块；文档非最新版本或已删除时清理其分块
+func (s *EmbeddingService) IndexDocument(ctx context.Context, docID uint) error {
+	doc, err := s.docRepo.Get(docID)
+	if err != nil {
+		return err
+	}
+	if !doc.IsLatest {
+		return s.chunkRepo.DeleteBySource(ctx, doc.RepositoryID, model.EmbeddingSourceDocument, doc.ID)
+	}
+
+	var chunks []model.EmbeddingChunk
+	for _, chunk := range docindex.ChunkMarkdown(doc.Content, docindex.DefaultChunkSize) {
+		chunks = append(chunks, model.EmbeddingChunk{
+			RepositoryID: doc.RepositoryID,
+			SourceType:   model.EmbeddingSourceDocument,
+			SourceID:     doc.ID,
+			Title:        doc.Title,
+			Heading:      chunk.Heading,
+			Content:      chunk.Text,
+		})
+	}
+	if err := s.embedAndReplace(ctx, doc.RepositoryID, model.EmbeddingSourceDocument, doc.ID, chunks); err != nil {
+		return err
+	}
+	// 新版本生成后，旧版本的分块不再参与检索
+	return s.chunkRepo.DeleteDocumentVersions(ctx, doc.RepositoryID, doc.Title, doc.ID)
+}
+
+// IndexRepositorySources 为仓库源码文件生成向量分块
+func (s *EmbeddingService) IndexRepositorySources(ctx context.Context, repoID uint) (int, error) {
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		return 0, err
+	}
+	if repo.LocalPath == "" {
+		return 0, fmt.Errorf("仓库尚未克隆: repoID=%d", repoID)
+	}
+	sources, err := embedding.ChunkSourceFiles(repo.LocalPath)
+	if err != nil {
+		return 0, fmt.Errorf("读取仓库源码失败: %w", err)
+	}
+
+	chunks := make([]model.EmbeddingChunk, 0, len(sources))
+	for _, source := range sources {
+		chunks = append(chunks, model.EmbeddingChunk{
+			RepositoryID: repoID,
+			SourceType:   model.EmbeddingSourceCode,
+			Path:         source.Path,
+			StartLine:    source.StartLine,
+			EndLine:      source.EndLine,
+			Content:      source.Content,
+		})
+	}
+	if err := s.embedAndReplace(ctx, repoID, model.EmbeddingSourceCode, 0, chunks); err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// HasSourceIndex 判断仓库源码是否已生成向量分块
+func (s *EmbeddingService) HasSourceIndex(ctx context.Context, repoID uint) (bool, error) {
+	count, err := s.chunkRepo.CountByRepository(ctx, repoID, model.EmbeddingSourceCode)
+	return count > 0, err
+}
+
+// RebuildRepository 重新生成仓库全部最新文档与源码的向量分块
+func (s *EmbeddingService) RebuildRepository(ctx context.Context, repoID uint) (int, error) {
+	docs, err := s.docRepo.GetByRepository(repoID)
+	if err != nil {
+		return 0, fmt.Errorf("获取仓库文档失败: %w", err)
+	}
+	if err := s.chunkRepo.DeleteBySource(ctx, repoID, model.EmbeddingSourceDocument, 0); err != nil {
+		return 0, err
+	}
+	for _, doc := range docs {
+		if err := s.IndexDocument(ctx, doc.ID); err != nil {
+			return 0, fmt.Errorf("文档向量生成失败: docID=%d, %w", doc.ID, err)
+		}
+	}
+	count, err := s.IndexRepositorySources(ctx, repoID)
+	if err != nil {
+		return 0, err
+	}
+	return len(docs) + count, nil
+}
+
+// StartRebuild 校验仓库存在后在后台重建其向量分块
+func (s *EmbeddingService) StartRebuild(repoID uint) error {
+	if _, err := s.repoRepo.GetBasic(context.Background(), repoID); err != nil {
+		return err
+	}
+	go func() {
+		count, err := s.RebuildRepository(context.Background(), repoID)
+		if err != nil {
+			klog.Errorf("重建仓库向量失败: repoID=%d, error=%v", repoID, err)
+			return
+		}
+		klog.V(6).Infof("重建仓库向量完成: repoID=%d, sources=%d", repoID, count)
+	}()
+	return nil
+}
+
+// embedAndReplace 生成分块向量并替换来源的旧分块，内容未变化的分块复用已有向量
+func (s *EmbeddingService) embedAndReplace(ctx context.Context, repoID uint, sourceType string, sourceID uint, chunks []model.EmbeddingChunk) error {
+	if len(chunks) == 0 {
+		return s.chunkRepo.DeleteBySource(ctx, repoID, sourceType, sourceID)
+	}
+
+	for i := range chunks {
+		chunks[i].ContentHash = hashEmbeddingText(embeddingText(&chunks[i]))
+	}
+
+	providers, err := s.availableProviders(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := s.chunkRepo.ListChunks(ctx, repoID, sourceType, sourceID)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		reused := make(map[string][]byte)
+		for _, chunk := range existing {
+			if chunk.ProviderName == provider.Name && len(chunk.Vector) > 0 {
+				reused[chunk.ContentHash] = chunk.Vector
+			}
+		}
+
+		var pending []int
+		var texts []string
+		for i := range chunks {
+			if vector, ok := reused[chunks[i].ContentHash]; ok {
+				chunks[i].Vector = vector
+				chunks[i].Dimensions = len(vector) / 4
+				continue
+			}
+			pending = append(pending, i)
+			texts = append(texts, embeddingText(&chunks[i]))
+		}
+
+		vectors, err := s.embedWith(ctx, provider, texts)
+		if err != nil {
+			klog.Warningf("向量模型调用失败，尝试下一个配置: provider=%s, error=%v", provider.Name, err)
+			lastErr = err
+			continue
+		}
+		for j, i := range pending {
+			chunks[i].Vector = embedding.EncodeVector(vectors[j])
+			chunks[i].Dimensions = len(vectors[j])
+		}
+		for i := range chunks {
+			chunks[i].ProviderName = provider.Name
+		}
+		klog.V(6).Infof("向量分块生成完成: repoID=%d, source=%s/%d, chunks=%d, embedded=%d, provider=%s",
+			repoID, sourceType, sourceID, len(chunks), len(pending), provider.Name)
+		return s.chunkRepo.ReplaceChunks(ctx, repoID, sourceType, sourceID, chunks)
+	}
+	return lastErr
+}
+
+// embeddingText 生成送入向量模型的文本，附带标题或路径以保留上下文
+func embeddingText(chunk *model.EmbeddingChunk) string {
+	var prefix string
+	if chunk.SourceType == model.EmbeddingSourceCode {
+		prefix = chunk.Path
+	} else {
+		prefix = strings.TrimSpace(chunk.Title + "\n" + chunk.Heading)
+	}
+	text := prefix + "\n" + chunk.Content
+	if runes := []rune(text); len(runes) > maxEmbeddingTextLength {
+		text = string(runes[:maxEmbeddingTextLength])
+	}
+	return text
+}
+
+func hashEmbeddingText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// SemanticSearch 语义检索：对查询生成向量后全量扫描余弦相似度，返回 top-k 分块
+func (s *EmbeddingService) SemanticSearch(ctx context.Context, req embedding.SearchRequest) ([]embedding.SearchHit, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if req.SourceType != "" && req.SourceType != model.EmbeddingSourceDocument && req.SourceType != model.EmbeddingSourceCode {
+		return nil, fmt.Errorf("invalid source_type: %s", req.SourceType)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSemanticLimit
+	}
+	if limit > maxSemanticLimit {
+		limit = maxSemanticLimit
+	}
+
+	start := time.Now()
+	provider, vectors, err := s.embedTexts(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	queryVector := vectors[0]
+
+	type scored struct {
+		id    uint
+		score float64
+	}
+	// 多取一些候选，过滤掉已删除的文档或仓库后仍能凑满 limit
+	candidates := limit * 2
+	var top []scored
+	err = s.chunkRepo.ScanVectors(ctx, provider.Name, req.RepositoryID, req.SourceType, func(chunks []model.EmbeddingChunk) error {
+		for _, chunk := range chunks {
+			score := embedding.Cosine(queryVector, embedding.DecodeVector(chunk.Vector))
+			if score <= 0 {
+				continue
+			}
+			if len(top) < candidates || score > top[len(top)-1].score {
+				top = append(top, scored{id: chunk.ID, score: score})
+				sort.Slice(top, func(i, j int) bool { return top[i].score > top[j].score })
+				if len(top) > candidates {
+					top = top[:candidates]
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描向量失败: %w", err)
+	}
+	if len(top) == 0 {
+		return []embedding.SearchHit{}, nil
+	}
+
+	ids := make([]uint, len(top))
+	for i, item := range top {
+		ids[i] = item.id
+	}
+	chunks, err := s.chunkRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.EmbeddingChunk, len(chunks))
+	for i := range chunks {
+		byID[chunks[i].ID] = &chunks[i]
+	}
+
+	hits := make([]embedding.SearchHit, 0, limit)
+	validRepos := make(map[uint]bool)
+	validDocs := make(map[uint]bool)
+	for _, item := range top {
+		chunk, ok := byID[item.id]
+		if !ok || !s.chunkSourceExists(ctx, chunk, validRepos, validDocs) {
+			continue
+		}
+		hit := embedding.SearchHit{
+			SourceType:   chunk.SourceType,
+			RepositoryID: chunk.RepositoryID,
+			Path:         chunk.Path,
+			Title:        chunk.Title,
+			Heading:      chunk.Heading,
+			StartLine:    chunk.StartLine,
+			EndLine:      chunk.EndLine,
+			Content:      chunk.Content,
+			Score:        item.score,
+		}
+		if chunk.SourceType == model.EmbeddingSourceDocument {
+			hit.DocID = chunk.SourceID
+		}
+		hits = append(hits, hit)
+		if len(hits) >= limit {
+			break
+		}
+	}
+	klog.V(6).Infof("语义检索完成: query=%q, repoID=%d, provider=%s, hits=%d, elapsed=%s", query, req.RepositoryID, provider.Name, len(hits), time.Since(start))
+	return hits, nil
+}
+
+// chunkSourceExists 校验分块来源仍然存在，已删除的仓库或文档的分块被清理
+func (s *EmbeddingService) chunkSourceExists(ctx context.Context, chunk *model.EmbeddingChunk, validRepos map[uint]bool, validDocs map[uint]bool) bool {
+	valid, checked := validRepos[chunk.RepositoryID]
+	if !checked {
+		// 不限定工作空间校验仓库是否存在，避免误删其他工作空间的分块
+		repo, err := s.repoRepo.GetBasic(repository.WithoutWorkspace(ctx), chunk.RepositoryID)
+		valid = err == nil
+		if !valid {
+			_ = s.chunkRepo.DeleteBySource(ctx, chunk.RepositoryID, model.EmbeddingSourceDocument, 0)
+			_ = s.chunkRepo.DeleteBySource(ctx, chunk.RepositoryID, model.EmbeddingSourceCode, 0)
+		} else if workspaceID, ok := repository.WorkspaceFromContext(ctx); ok && repo.WorkspaceID != workspaceID {
+			// 其他工作空间的仓库不参与检索
+			valid = false
+		}
+		validRepos[chunk.RepositoryID] = valid
+	}
+	if !valid {
+		return false
+	}
+	if chunk.SourceType != model.EmbeddingSourceDocument {
+		return true
+	}
+
+	valid, checked = validDocs[chunk.SourceID]
+	if !checked {
+		doc, err := s.docRepo.Get(chunk.SourceID)
+		valid = err == nil && doc.IsLatest
+		validDocs[chunk.SourceID] = valid
+		if !valid {
+			_ = s.chunkRepo.DeleteBySource(ctx, chunk.RepositoryID, model.EmbeddingSourceDocument, chunk.SourceID)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/embedding"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 模拟 OpenAI 兼容的向量接口：按固定词表生成词袋向量
+var fakeEmbeddingVocabulary = []string{"database", "connection", "http", "router", "cache"}
+
+func newFakeEmbeddingServer(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}
+		data := make([]item, 0, len(req.Input))
+		for i, text := range req.Input {
+			vector := make([]float64, len(fakeEmbeddingVocabulary)+1)
+			lower := strings.ToLower(text)
+			for j, word := range fakeEmbeddingVocabulary {
+				vector[j] = float64(strings.Count(lower, word))
+			}
+			vector[len(vector)-1] = 0.01
+			data = append(data, item{Index: i, Embedding: vector})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+// TestEmbeddingServiceSemanticSearch 验证文档与源码分块的向量生成、检索、向量复用与失效清理
+func TestEmbeddingServiceSemanticSearch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Document{}, &model.EmbeddingProvider{}, &model.EmbeddingChunk{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	ctx := context.Background()
+
+	var requests int32
+	server := newFakeEmbeddingServer(t, &requests)
+	defer server.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "db.go"), []byte("package store\n\n// open database connection\nfunc Open() {}\n"), 0644); err != nil {
+		t.Fatalf("write file error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "logo.png"), []byte("database\x00"), 0644); err != nil {
+		t.Fatalf("write file error: %v", err)
+	}
+
+	repoRepo := repository.NewRepoRepository(db)
+	docRepo := repository.NewDocumentRepository(db)
+	repo := &model.Repository{Name: "demo", LocalPath: repoDir}
+	if err := repoRepo.Create(context.Background(), repo); err != nil {
+		t.Fatalf("create repo error: %v", err)
+	}
+	doc := &model.Document{RepositoryID: repo.ID, TaskID: 1, Title: "路由设计", Content: "# HTTP\n\nhttp router and cache layer"}
+	if err := docRepo.CreateVersioned(doc); err != nil {
+		t.Fatalf("create doc error: %v", err)
+	}
+
+	svc := NewEmbeddingService(repository.NewEmbeddingProviderRepository(db), repository.NewEmbeddingChunkRepository(db), docRepo, repoRepo)
+	if _, err := svc.SemanticSearch(ctx, embedding.SearchRequest{Query: "database"}); err != ErrNoEmbeddingProvider {
+		t.Fatalf("expected ErrNoEmbeddingProvider, got %v", err)
+	}
+	// 优先级更高的配置不可用时切换到下一个配置
+	if _, err := svc.CreateProvider(ctx, &CreateEmbeddingProviderRequest{Name: "broken", BaseURL: broken.URL, Model: "m", Priority: 1}); err != nil {
+		t.Fatalf("create provider error: %v", err)
+	}
+	if _, err := svc.CreateProvider(ctx, &CreateEmbeddingProviderRequest{Name: "local", BaseURL: server.URL, Model: "m", Priority: 2}); err != nil {
+		t.Fatalf("create provider error: %v", err)
+	}
+
+	if err := svc.IndexDocument(ctx, doc.ID); err != nil {
+		t.Fatalf("index doc error: %v", err)
+	}
+	count, err := svc.IndexRepositorySources(ctx, repo.ID)
+	if err != nil || count != 1 {
+		t.Fatalf("index sources: count=%d, err=%v", count, err)
+	}
+
+	hits, err := svc.SemanticSearch(ctx, embedding.SearchRequest{Query: "database connection", RepositoryID: repo.ID})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(hits) == 0 || hits[0].SourceType != model.EmbeddingSourceCode || hits[0].Path != "db.go" || hits[0].StartLine != 1 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+
+	hits, err = svc.SemanticSearch(ctx, embedding.SearchRequest{Query: "router", SourceType: model.EmbeddingSourceDocument})
+	if err != nil || len(hits) != 1 || hits[0].DocID != doc.ID || hits[0].Heading != "HTTP" {
+		t.Fatalf("unexpected document hits: %+v, err=%v", hits, err)
+	}
+
+	// 内容未变化时复用已有向量，不再请求向量模型
+	before := atomic.LoadInt32(&requests)
+	if err := svc.IndexDocument(ctx, doc.ID); err != nil {
+		t.Fatalf("reindex doc error: %v", err)
+	}
+	if after := atomic.LoadInt32(&requests); after != before {
+		t.Fatalf("expected vectors to be reused, requests %d -> %d", before, after)
+	}
+
+	// 文档删除后，检索时清理失效分块
+	if err := docRepo.Delete(doc.ID); err != nil {
+		t.Fatalf("delete doc error: %v", err)
+	}
+	hits, err = svc.SemanticSearch(ctx, embedding.SearchRequest{Query: "router", SourceType: model.EmbeddingSourceDocument})
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("expected stale document to be dropped: %+v, err=%v", hits, err)
+	}
+	if n, _ := repository.NewEmbeddingChunkRepository(db).CountByRepository(ctx, repo.ID, model.EmbeddingSourceDocument); n != 0 {
+		t.Fatalf("expected stale chunks removed, got %d", n)
+	}
+
+	providers, _ := svc.ListProviders(ctx)
+	for _, provider := range providers {
+		if provider.Name == "broken" && provider.ErrorCount == 0 {
+			t.Fatalf("expected broken provider error count to be recorded")
+		}
+	}
+}
@@ -0,0 +1,497 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	einoModel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/archive"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrEvalRunning 同一时间只允许一个评测运行，评测会占用模型与较长时间
+	ErrEvalRunning = errors.New("an evaluation is already running")
+	// ErrEvalNoSamples 没有可用的样例仓库
+	ErrEvalNoSamples = errors.New("no evaluation samples available")
+	// ErrEvalWriterNotFound 没有可评测的 Writer 使用该 Agent
+	ErrEvalWriterNotFound = errors.New("no evaluable writer uses this agent")
+	// ErrEvalRunNotFound 评测记录不存在
+	ErrEvalRunNotFound = repository.ErrEvalRunNotFound
+)
+
+const (
+	// evalMaxOutput 保存到评测结果中的输出上限
+	evalMaxOutput = 64 * 1024
+	// evalJudgeMaxInput 提交给评审模型的输出上限
+	evalJudgeMaxInput = 30 * 1024
+	evalMaxListRuns   = 100
+	// evalWorkDir 样例仓库在仓库目录下的临时副本目录，Agent 工具只能访问仓库目录内的文件
+	evalWorkDir = ".eval"
+)
+
+// EvalSample 样例仓库，定义在样例目录下的 <name>.yaml 中
+type EvalSample struct {
+	Name    string           `yaml:"-" json:"name"`
+	Path    string           `yaml:"path" json:"-"`                    // 仓库目录，相对样例目录，默认为与文件同名的子目录
+	Title   string           `yaml:"title" json:"title,omitempty"`     // 文档标题，DefaultWriter 等需要
+	Writers []string         `yaml:"writers" json:"writers,omitempty"` // 适用的 Writer，为空时适用全部
+	Checks  EvalSampleChecks `yaml:"checks" json:"checks"`             // 规则检查
+	Rubric  string           `yaml:"rubric" json:"rubric,omitempty"`   // LLM 评审标准，为空时使用默认标准
+	dir     string
+}
+
+// EvalSampleChecks 样例的规则检查，零值的检查项不执行
+type EvalSampleChecks struct {
+	MinLength      int      `yaml:"min_length" json:"min_length,omitempty"`             // 最少字符数
+	MaxLength      int      `yaml:"max_length" json:"max_length,omitempty"`             // 最多字符数
+	MustContain    []string `yaml:"must_contain" json:"must_contain,omitempty"`         // 必须出现的内容（不区分大小写）
+	MustNotContain []string `yaml:"must_not_contain" json:"must_not_contain,omitempty"` // 不得出现的内容（不区分大小写）
+	Headings       []string `yaml:"headings" json:"headings,omitempty"`                 // 必须出现的 Markdown 标题
+	MinDirs        int      `yaml:"min_dirs" json:"min_dirs,omitempty"`                 // TocWriter 最少目录数
+	CheckPaths     bool     `yaml:"check_paths" json:"check_paths,omitempty"`           // 反引号中引用的文件路径必须存在于仓库中
+}
+
+// EvalRequest 评测请求
+type EvalRequest struct {
+	Writer  string   `json:"writer"`  // 为空时选择使用该 Agent 的 Writer
+	Version int      `json:"version"` // Agent 版本号，0 表示当前版本
+	Samples []string `json:"samples"` // 为空时使用全部适用的样例
+	Judge   bool     `json:"judge"`   // 是否启用 LLM 评审
+}
+
+// EvalVersionSummary 某个 Agent 版本在某个 Writer 下的评测汇总
+type EvalVersionSummary struct {
+	Version      int                `json:"version"`
+	Writer       string             `json:"writer"`
+	Runs         int                `json:"runs"`
+	BestScore    float64            `json:"best_score"`
+	LatestScore  float64            `json:"latest_score"`
+	LatestRunID  uint               `json:"latest_run_id"`
+	SampleScores map[string]float64 `json:"sample_scores"` // 最近一次评测中各样例的得分
+}
+
+// EvalComparison Agent 各版本的评测对比
+type EvalComparison struct {
+	AgentFile string               `json:"agent_file"`
+	Versions  []EvalVersionSummary `json:"versions"` // 按版本号降序
+	Runs      []model.EvalRun      `json:"runs"`     // 最近的评测运行
+}
+
+// EvalService 使用固定版本的 Agent 配置运行 Writer，对样例仓库的输出做规则检查与可选的 LLM 评审，并按版本对比得分
+type EvalService struct {
+	cfg          *config.Config
+	runRepo      repository.EvalRunRepository
+	agentService AgentServiceAgentService
+	parser       *adkagents.Parser
+
+	mu      sync.RWMutex
+	writers map[domain.WriterName]domain.Previewer
+	judge   einoModel.BaseChatModel
+
+	running atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// NewEvalService 创建评测服务
+func NewEvalService(cfg *config.Config, runRepo repository.EvalRunRepository, agentService AgentServiceAgentService) *EvalService {
+	return &EvalService{
+		cfg:          cfg,
+		runRepo:      runRepo,
+		agentService: agentService,
+		parser:       adkagents.NewParser(),
+		writers:      make(map[domain.WriterName]domain.Previewer),
+	}
+}
+
+// AddWriters 注册可评测的 Writer，未实现 domain.Previewer 的 Writer 会被忽略
+func (s *EvalService) AddWriters(writers ...domain.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range writers {
+		if p, ok := w.(domain.Previewer); ok {
+			s.writers[w.Name()] = p
+		}
+	}
+}
+
+// SetJudge 设置 LLM 评审模型，未设置时请求中的 judge 被忽略
+func (s *EvalService) SetJudge(judge einoModel.BaseChatModel) {
+	s.mu.Lock()
+	s.judge = judge
+	s.mu.Unlock()
+}
+
+// RecoverInterrupted 将服务重启前未完成的评测标记为失败
+func (s *EvalService) RecoverInterrupted(ctx context.Context) {
+	if n, err := s.runRepo.MarkInterrupted(ctx); err != nil {
+		klog.Warningf("EvalService: mark interrupted runs failed: %v", err)
+	} else if n > 0 {
+		klog.V(6).Infof("EvalService: marked %d interrupted eval runs as failed", n)
+	}
+}
+
+// Samples 读取样例目录中的全部样例
+func (s *EvalService) Samples() ([]*EvalSample, error) {
+	dir := s.cfg.Eval.SamplesDir
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var samples []*EvalSample
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sample := &EvalSample{}
+		if err := yaml.Unmarshal(data, sample); err != nil {
+			return nil, fmt.Errorf("parse eval sample %s: %w", entry.Name(), err)
+		}
+		sample.Name = name
+		if sample.Path == "" {
+			sample.Path = name
+		}
+		sample.dir = filepath.Join(dir, sample.Path)
+		if info, err := os.Stat(sample.dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("eval sample %s: repository directory %s not found", name, sample.dir)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// Start 按请求固定 Agent 版本并在后台运行评测，返回运行中的评测记录
+func (s *EvalService) Start(ctx context.Context, agentFile string, req *EvalRequest) (*model.EvalRun, error) {
+	content, version, err := s.agentContent(ctx, agentFile, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	def, err := s.parser.ParseContent([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	writer, err := s.selectWriter(def.Name, req.Writer)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.selectSamples(writer.Name(), req.Samples)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	judge := req.Judge && s.judge != nil
+	s.mu.RUnlock()
+
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrEvalRunning
+	}
+	run := &model.EvalRun{
+		AgentFile:    agentFile,
+		AgentVersion: version,
+		Writer:       string(writer.Name()),
+		Judge:        judge,
+		Status:       model.EvalStatusRunning,
+		SampleCount:  len(samples),
+		StartedAt:    time.Now(),
+	}
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		s.running.Store(false)
+		return nil, err
+	}
+
+	// 评测在请求结束后继续运行，保留工作空间并固定 Agent 定义
+	runCtx := context.Background()
+	if workspaceID, ok := repository.WorkspaceFromContext(ctx); ok {
+		runCtx = repository.WithWorkspace(runCtx, workspaceID)
+	}
+	runCtx = adkagents.WithPinnedAgent(runCtx, def)
+
+	result := *run
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Store(false)
+		s.execute(runCtx, run, writer, samples)
+	}()
+	return &result, nil
+}
+
+// Wait 等待后台评测结束
+func (s *EvalService) Wait() {
+	s.wg.Wait()
+}
+
+// Get 获取评测记录及各样例结果
+func (s *EvalService) Get(ctx context.Context, agentFile string, id uint) (*model.EvalRun, error) {
+	run, err := s.runRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.AgentFile != agentFile {
+		return nil, ErrEvalRunNotFound
+	}
+	return run, nil
+}
+
+// Compare 汇总 Agent 各版本已完成评测的得分
+func (s *EvalService) Compare(ctx context.Context, agentFile string) (*EvalComparison, error) {
+	runs, err := s.runRepo.ListByAgent(ctx, agentFile, evalMaxListRuns)
+	if err != nil {
+		return nil, err
+	}
+	comparison := &EvalComparison{AgentFile: agentFile, Runs: runs, Versions: []EvalVersionSummary{}}
+	index := map[string]int{}
+	// runs 按时间倒序，每组第一个已完成的评测即为最近一次
+	for _, run := range runs {
+		if run.Status != model.EvalStatusCompleted {
+			continue
+		}
+		key := fmt.Sprintf("%d/%s", run.AgentVersion, run.Writer)
+		i, ok := index[key]
+		if !ok {
+			latest, err := s.runRepo.Get(ctx, run.ID)
+			if err != nil {
+				return nil, err
+			}
+			scores := make(map[string]float64, len(latest.Results))
+			for _, r := range latest.Results {
+				scores[r.Sample] = r.Score
+			}
+			comparison.Versions = append(comparison.Versions, EvalVersionSummary{
+				Version:      run.AgentVersion,
+				Writer:       run.Writer,
+				LatestScore:  run.Score,
+				LatestRunID:  run.ID,
+				SampleScores: scores,
+			})
+			i = len(comparison.Versions) - 1
+			index[key] = i
+		}
+		summary := &comparison.Versions[i]
+		summary.Runs++
+		summary.BestScore = max(summary.BestScore, run.Score)
+	}
+	sort.SliceStable(comparison.Versions, func(a, b int) bool {
+		return comparison.Versions[a].Version > comparison.Versions[b].Version
+	})
+	return comparison, nil
+}
+
+// agentContent 获取指定版本的 Agent 内容，version 为 0 时使用当前文件
+func (s *EvalService) agentContent(ctx context.Context, agentFile string, version int) (string, int, error) {
+	if version > 0 {
+		v, err := s.agentService.GetVersionContent(ctx, agentFile, version)
+		if err != nil {
+			return "", 0, err
+		}
+		return v.Content, v.Version, nil
+	}
+	agent, err := s.agentService.GetAgent(ctx, agentFile)
+	if err != nil {
+		return "", 0, err
+	}
+	return agent.Content, agent.CurrentVersion, nil
+}
+
+// selectWriter 选择使用该 Agent 的 Writer，未指定时按名称选择第一个
+func (s *EvalService) selectWriter(agentName, writerName string) (domain.Previewer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if writerName != "" {
+		w, ok := s.writers[domain.WriterName(writerName)]
+		if !ok || !slices.Contains(w.Agents(), agentName) {
+			return nil, fmt.Errorf("%w: writer %s, agent %s", ErrEvalWriterNotFound, writerName, agentName)
+		}
+		return w, nil
+	}
+	names := make([]string, 0, len(s.writers))
+	for name := range s.writers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if w := s.writers[domain.WriterName(name)]; slices.Contains(w.Agents(), agentName) {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: agent %s", ErrEvalWriterNotFound, agentName)
+}
+
+// selectSamples 按名称与适用的 Writer 筛选样例
+func (s *EvalService) selectSamples(writer domain.WriterName, names []string) ([]*EvalSample, error) {
+	all, err := s.Samples()
+	if err != nil {
+		return nil, err
+	}
+	var samples []*EvalSample
+	for _, sample := range all {
+		if len(names) > 0 && !slices.Contains(names, sample.Name) {
+			continue
+		}
+		if len(sample.Writers) > 0 && !slices.Contains(sample.Writers, string(writer)) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%w: writer %s, samples dir %s", ErrEvalNoSamples, writer, s.cfg.Eval.SamplesDir)
+	}
+	return samples, nil
+}
+
+// execute 依次评测各样例，单个样例失败记为 0 分，不影响其他样例
+func (s *EvalService) execute(ctx context.Context, run *model.EvalRun, writer domain.Previewer, samples []*EvalSample) {
+	workDir := filepath.Join(s.cfg.Data.RepoDir, evalWorkDir, fmt.Sprintf("run-%d", run.ID))
+	defer os.RemoveAll(workDir)
+
+	var total float64
+	for _, sample := range samples {
+		result := s.evaluateSample(ctx, run, writer, sample, filepath.Join(workDir, sample.Name))
+		total += result.Score
+		if err := s.runRepo.AddResult(ctx, result); err != nil {
+			klog.Errorf("EvalService: save result of sample %s in run %d failed: %v", sample.Name, run.ID, err)
+		}
+	}
+
+	now := time.Now()
+	run.Score = total / float64(len(samples))
+	run.Status = model.EvalStatusCompleted
+	run.FinishedAt = &now
+	if err := s.runRepo.Save(ctx, run); err != nil {
+		klog.Errorf("EvalService: save run %d failed: %v", run.ID, err)
+	}
+	klog.V(6).Infof("EvalService: run %d of %s v%d finished, score=%.1f", run.ID, run.AgentFile, run.AgentVersion, run.Score)
+}
+
+func (s *EvalService) evaluateSample(ctx context.Context, run *model.EvalRun, writer domain.Previewer, sample *EvalSample, repoDir string) *model.EvalResult {
+	result := &model.EvalResult{RunID: run.ID, Sample: sample.Name}
+	startedAt := time.Now()
+	defer func() { result.DurationMs = time.Since(startedAt).Milliseconds() }()
+
+	// 每次评测使用样例的全新副本，Agent 工具只能访问仓库目录内的文件
+	limits := archive.Limits{MaxBytes: s.cfg.Import.MaxExtractedMB << 20, MaxFiles: s.cfg.Import.MaxFiles}
+	if err := archive.CopyDir(sample.dir, repoDir, limits); err != nil {
+		result.Error = fmt.Sprintf("copy sample: %v", err)
+		return result
+	}
+
+	sampleCtx := ctx
+	if s.cfg.Eval.Timeout > 0 {
+		var cancel context.CancelFunc
+		sampleCtx, cancel = context.WithTimeout(ctx, s.cfg.Eval.Timeout)
+		defer cancel()
+	}
+	output, err := writer.Preview(sampleCtx, repoDir, sample.Title)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = truncateEvalText(output, evalMaxOutput)
+	result.Checks = runEvalChecks(writer.Name(), output, repoDir, sample.Checks)
+	result.RuleScore = ruleScore(result.Checks)
+	result.Score = result.RuleScore
+
+	if run.Judge {
+		score, comment, err := s.judgeOutput(ctx, writer.Name(), sample, output)
+		if err != nil {
+			result.JudgeComment = "judge failed: " + err.Error()
+			return result
+		}
+		result.JudgeScore = &score
+		result.JudgeComment = comment
+		result.Score = (result.RuleScore + score) / 2
+	}
+	return result
+}
+
+// judgeOutput 请评审模型按标准打 0-10 分，换算为 0-100
+func (s *EvalService) judgeOutput(ctx context.Context, writer domain.WriterName, sample *EvalSample, output string) (float64, string, error) {
+	s.mu.RLock()
+	judge := s.judge
+	s.mu.RUnlock()
+	if judge == nil {
+		return 0, "", errors.New("judge model not configured")
+	}
+	rubric := sample.Rubric
+	if rubric == "" {
+		rubric = defaultEvalRubric(writer)
+	}
+	prompt := fmt.Sprintf(`你是技术文档评审专家，请按评审标准为待评审内容打分（0-10 分，可以有小数）。
+
+评审标准:
+%s
+
+待评审内容:
+<content>
+%s
+</content>
+
+只输出 JSON，不要输出其他内容：{"score": 分数, "comment": "一两句评分理由"}`, rubric, truncateEvalText(output, evalJudgeMaxInput))
+
+	msg, err := judge.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return 0, "", err
+	}
+	return parseJudgeResponse(msg.Content)
+}
+
+func defaultEvalRubric(writer domain.WriterName) string {
+	if writer == domain.TocWriter {
+		return "目录是否覆盖项目的核心模块与关键流程；各目录标题是否准确、互不重复；写作提纲是否具体可执行。"
+	}
+	return "内容是否准确反映仓库代码（不编造不存在的文件、函数与配置）；结构是否清晰、重点是否突出；是否引用了具体的代码位置与示例。"
+}
+
+// parseJudgeResponse 解析评审模型输出中的 JSON，容忍前后多余的文字或代码块标记
+func parseJudgeResponse(content string) (float64, string, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return 0, "", fmt.Errorf("judge response is not JSON: %s", truncateEvalText(content, 200))
+	}
+	var verdict struct {
+		Score   float64 `json:"score"`
+		Comment string  `json:"comment"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &verdict); err != nil {
+		return 0, "", fmt.Errorf("parse judge response: %w", err)
+	}
+	return min(max(verdict.Score, 0), 10) * 10, verdict.Comment, nil
+}
+
+func truncateEvalText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	// 避免截断到多字节字符中间
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit] + "\n... (truncated)"
+}
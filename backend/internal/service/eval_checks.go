@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// evalPathPattern 反引号中形如文件路径的引用，例如 `internal/server.go` 或 `main.go:12-30`
+var evalPathPattern = regexp.MustCompile("`((?:[\\w.-]+/)*[\\w-]+\\.[A-Za-z0-9]+)(?::\\d+(?:-\\d+)?)?`")
+
+// runEvalChecks 执行规则检查：通用的非空与长度检查，TocWriter 的目录结构检查，文档类 Writer 的 Markdown 检查，以及样例自定义的检查项
+func runEvalChecks(writer domain.WriterName, output, repoDir string, checks EvalSampleChecks) model.EvalChecks {
+	var results model.EvalChecks
+	add := func(name string, passed bool, detail string) {
+		results = append(results, model.EvalCheck{Name: name, Passed: passed, Detail: detail})
+	}
+
+	trimmed := strings.TrimSpace(output)
+	add("non_empty", trimmed != "", "")
+	length := utf8.RuneCountInString(trimmed)
+	if checks.MinLength > 0 {
+		add("min_length", length >= checks.MinLength, fmt.Sprintf("%d/%d", length, checks.MinLength))
+	}
+	if checks.MaxLength > 0 {
+		add("max_length", length <= checks.MaxLength, fmt.Sprintf("%d/%d", length, checks.MaxLength))
+	}
+
+	if writer == domain.TocWriter {
+		checkToc(output, checks, add)
+	} else {
+		checkMarkdown(output, checks, add)
+	}
+
+	lower := strings.ToLower(output)
+	for _, term := range checks.MustContain {
+		add("contains:"+term, strings.Contains(lower, strings.ToLower(term)), "")
+	}
+	for _, term := range checks.MustNotContain {
+		add("not_contains:"+term, !strings.Contains(lower, strings.ToLower(term)), "")
+	}
+	if checks.CheckPaths {
+		missing := missingPaths(output, repoDir)
+		add("paths_exist", len(missing) == 0, strings.Join(missing, ", "))
+	}
+	return results
+}
+
+// checkToc 输出必须是包含 dirs 的 YAML，每个目录都有标题与写作提纲
+func checkToc(output string, checks EvalSampleChecks, add func(string, bool, string)) {
+	var result domain.DirMakerGenerationResult
+	if err := yaml.Unmarshal([]byte(output), &result); err != nil {
+		add("valid_toc", false, err.Error())
+		return
+	}
+	add("valid_toc", len(result.Dirs) > 0, fmt.Sprintf("%d dirs", len(result.Dirs)))
+	if checks.MinDirs > 0 {
+		add("min_dirs", len(result.Dirs) >= checks.MinDirs, fmt.Sprintf("%d/%d", len(result.Dirs), checks.MinDirs))
+	}
+	var incomplete []string
+	titles := map[string]bool{}
+	duplicated := false
+	for i, dir := range result.Dirs {
+		if strings.TrimSpace(dir.Title) == "" || strings.TrimSpace(dir.Outline) == "" {
+			incomplete = append(incomplete, fmt.Sprintf("#%d %s", i+1, dir.Title))
+		}
+		duplicated = duplicated || titles[dir.Title]
+		titles[dir.Title] = true
+	}
+	add("dirs_have_outline", len(incomplete) == 0, strings.Join(incomplete, ", "))
+	add("unique_titles", !duplicated, "")
+}
+
+// checkMarkdown 代码块需要标注语言（与 markdown_checker 的要求一致），并包含样例要求的标题
+func checkMarkdown(output string, checks EvalSampleChecks, add func(string, bool, string)) {
+	var headings []string
+	unlabeled, inFence := 0, false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			if !inFence && strings.TrimSpace(strings.TrimLeft(line, "`")) == "" {
+				unlabeled++
+			}
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(line, "#") {
+			headings = append(headings, strings.TrimSpace(strings.TrimLeft(line, "#")))
+		}
+	}
+	add("has_headings", len(headings) > 0, fmt.Sprintf("%d headings", len(headings)))
+	add("code_block_language", unlabeled == 0, fmt.Sprintf("%d unlabeled code blocks", unlabeled))
+
+	for _, want := range checks.Headings {
+		found := false
+		for _, heading := range headings {
+			if strings.Contains(strings.ToLower(heading), strings.ToLower(want)) {
+				found = true
+				break
+			}
+		}
+		add("heading:"+want, found, "")
+	}
+}
+
+// missingPaths 返回输出中引用但仓库中不存在的文件路径，用于发现编造的文件
+func missingPaths(output, repoDir string) []string {
+	var missing []string
+	seen := map[string]bool{}
+	for _, match := range evalPathPattern.FindAllStringSubmatch(output, -1) {
+		path := match[1]
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if _, err := os.Stat(filepath.Join(repoDir, filepath.FromSlash(path))); err == nil {
+			continue
+		}
+		// 只有文件名的引用可能位于任意子目录
+		if !strings.Contains(path, "/") && findFileByName(repoDir, path) {
+			continue
+		}
+		missing = append(missing, path)
+	}
+	return missing
+}
+
+func findFileByName(root, name string) bool {
+	found := false
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && d.Name() == name {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// ruleScore 规则检查通过率（0-100）
+func ruleScore(checks model.EvalChecks) float64 {
+	if len(checks) == 0 {
+		return 0
+	}
+	passed := 0
+	for _, check := range checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	return float64(passed) * 100 / float64(len(checks))
+}
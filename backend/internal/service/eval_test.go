@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	einoModel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// fakePreviewer 返回预设输出，模拟需要模型的 Writer
+type fakePreviewer struct {
+	output  string
+	repoDir string
+	block   chan struct{}
+}
+
+func (w *fakePreviewer) Name() domain.WriterName { return domain.DefaultWriter }
+
+func (w *fakePreviewer) Generate(ctx context.Context, localPath string, title string, taskID uint) (string, error) {
+	return w.Preview(ctx, localPath, title)
+}
+
+func (w *fakePreviewer) Preview(ctx context.Context, localPath string, title string) (string, error) {
+	if w.block != nil {
+		<-w.block
+	}
+	w.repoDir = localPath
+	if _, err := os.Stat(filepath.Join(localPath, "main.go")); err != nil {
+		return "", err
+	}
+	return w.output, nil
+}
+
+func (w *fakePreviewer) Agents() []string { return []string{domain.AgentGen} }
+
+type fakeJudge struct{ reply string }
+
+func (j *fakeJudge) Generate(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(j.reply, nil), nil
+}
+
+func (j *fakeJudge) Stream(ctx context.Context, input []*schema.Message, opts ...einoModel.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not implemented")
+}
+
+const testEvalAgent = `name: document_generator
+description: test agent
+instruction: %s
+maxIterations: 5
+`
+
+func newTestEvalService(t *testing.T) (*EvalService, AgentServiceAgentService, *fakePreviewer) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.AgentVersion{}, &model.EvalRun{}, &model.EvalResult{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+
+	samplesDir := t.TempDir()
+	os.MkdirAll(filepath.Join(samplesDir, "hello"), 0755)
+	os.WriteFile(filepath.Join(samplesDir, "hello", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(samplesDir, "hello.yaml"), []byte(`title: 项目概览
+checks:
+  must_contain: ["main.go"]
+  headings: ["Overview"]
+  check_paths: true
+`), 0644)
+
+	cfg := &config.Config{}
+	cfg.Data.RepoDir = t.TempDir()
+	cfg.Eval.SamplesDir = samplesDir
+
+	agentService := NewAgentService(repository.NewAgentVersionRepository(db), t.TempDir())
+	writer := &fakePreviewer{}
+	svc := NewEvalService(cfg, repository.NewEvalRunRepository(db), agentService)
+	svc.AddWriters(writer)
+	return svc, agentService, writer
+}
+
+func TestEvalServiceCompareVersions(t *testing.T) {
+	svc, agentService, writer := newTestEvalService(t)
+	ctx := context.Background()
+	for _, instruction := range []string{"v1", "v2"} {
+		if _, err := agentService.SaveAgent(ctx, "document_generator.yaml", strings.Replace(testEvalAgent, "%s", instruction, 1), "api", nil); err != nil {
+			t.Fatalf("SaveAgent error: %v", err)
+		}
+	}
+
+	writer.output = "# Overview\n\n入口位于 `main.go`。\n"
+	run, err := svc.Start(ctx, "document_generator.yaml", &EvalRequest{Version: 1})
+	if err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	svc.Wait()
+	if run.Status != model.EvalStatusRunning || run.AgentVersion != 1 || run.Writer != string(domain.DefaultWriter) {
+		t.Fatalf("unexpected started run: %+v", run)
+	}
+	if !strings.HasPrefix(writer.repoDir, filepath.Join(svc.cfg.Data.RepoDir, evalWorkDir)) {
+		t.Fatalf("sample should be copied into repo dir, got %s", writer.repoDir)
+	}
+	got, err := svc.Get(ctx, "document_generator.yaml", run.ID)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if got.Status != model.EvalStatusCompleted || got.Score != 100 || len(got.Results) != 1 {
+		t.Fatalf("unexpected v1 run: %+v", got)
+	}
+
+	// v2 编造了不存在的文件且缺少要求的标题；启用评审后综合得分为规则得分与评审得分的平均值
+	svc.SetJudge(&fakeJudge{reply: "```json\n{\"score\": 6, \"comment\": \"fabricated file\"}\n```"})
+	writer.output = "# Intro\n\n入口位于 `main.go` 与 `server.go`。\n"
+	run, err = svc.Start(ctx, "document_generator.yaml", &EvalRequest{Judge: true})
+	if err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	svc.Wait()
+	got, _ = svc.Get(ctx, "document_generator.yaml", run.ID)
+	result := got.Results[0]
+	if got.AgentVersion != 2 || result.JudgeScore == nil || *result.JudgeScore != 60 || result.JudgeComment != "fabricated file" {
+		t.Fatalf("unexpected v2 result: %+v", result)
+	}
+	if result.RuleScore >= 100 || result.Score != (result.RuleScore+60)/2 {
+		t.Fatalf("unexpected v2 scores: rule=%v score=%v", result.RuleScore, result.Score)
+	}
+	for _, check := range result.Checks {
+		if check.Name == "paths_exist" && (check.Passed || check.Detail != "server.go") {
+			t.Fatalf("paths_exist should report server.go, got %+v", check)
+		}
+	}
+
+	comparison, err := svc.Compare(ctx, "document_generator.yaml")
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+	if len(comparison.Versions) != 2 || comparison.Versions[0].Version != 2 || comparison.Versions[1].LatestScore != 100 {
+		t.Fatalf("unexpected comparison: %+v", comparison.Versions)
+	}
+	if comparison.Versions[0].SampleScores["hello"] != result.Score {
+		t.Fatalf("unexpected sample scores: %+v", comparison.Versions[0].SampleScores)
+	}
+
+	if _, err := svc.Get(ctx, "toc_editor.yaml", run.ID); !errors.Is(err, ErrEvalRunNotFound) {
+		t.Fatalf("expected ErrEvalRunNotFound for another agent, got %v", err)
+	}
+}
+
+func TestEvalServiceStartErrors(t *testing.T) {
+	svc, agentService, writer := newTestEvalService(t)
+	ctx := context.Background()
+	agentService.SaveAgent(ctx, "document_generator.yaml", strings.Replace(testEvalAgent, "%s", "v1", 1), "api", nil)
+	agentService.SaveAgent(ctx, "api_explorer.yaml", strings.Replace(strings.Replace(testEvalAgent, "%s", "v1", 1), "document_generator", "api_explorer", 1), "api", nil)
+
+	if _, err := svc.Start(ctx, "api_explorer.yaml", &EvalRequest{}); !errors.Is(err, ErrEvalWriterNotFound) {
+		t.Fatalf("expected ErrEvalWriterNotFound, got %v", err)
+	}
+	if _, err := svc.Start(ctx, "document_generator.yaml", &EvalRequest{Samples: []string{"missing"}}); !errors.Is(err, ErrEvalNoSamples) {
+		t.Fatalf("expected ErrEvalNoSamples, got %v", err)
+	}
+	if _, err := svc.Start(ctx, "document_generator.yaml", &EvalRequest{Version: 9}); !errors.Is(err, repository.ErrAgentVersionNotFound) {
+		t.Fatalf("expected ErrAgentVersionNotFound, got %v", err)
+	}
+
+	writer.block = make(chan struct{})
+	if _, err := svc.Start(ctx, "document_generator.yaml", &EvalRequest{}); err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	if _, err := svc.Start(ctx, "document_generator.yaml", &EvalRequest{}); !errors.Is(err, ErrEvalRunning) {
+		t.Fatalf("expected ErrEvalRunning, got %v", err)
+	}
+	close(writer.block)
+	svc.Wait()
+}
+
+func TestRunEvalChecksToc(t *testing.T) {
+	output := `dirs:
+  - title: 架构
+    outline: 模块划分
+  - title: 架构
+    outline: ""
+`
+	checks := runEvalChecks(domain.TocWriter, output, t.TempDir(), EvalSampleChecks{MinDirs: 3})
+	want := map[string]bool{"non_empty": true, "valid_toc": true, "min_dirs": false, "dirs_have_outline": false, "unique_titles": false}
+	if len(checks) != len(want) {
+		t.Fatalf("unexpected checks: %+v", checks)
+	}
+	for _, check := range checks {
+		if passed, ok := want[check.Name]; !ok || passed != check.Passed {
+			t.Errorf("check %s passed=%v, want %v", check.Name, check.Passed, passed)
+		}
+	}
+	if score := ruleScore(checks); score != 40 {
+		t.Fatalf("ruleScore = %v, want 40", score)
+	}
+
+	if checks := runEvalChecks(domain.TocWriter, "not: [yaml", t.TempDir(), EvalSampleChecks{}); ruleScore(checks) != 50 {
+		t.Fatalf("invalid toc should fail valid_toc: %+v", checks)
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/secret"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrGitCredentialNameRequired 凭证名称不能为空
+	ErrGitCredentialNameRequired = errors.New("git credential name is required")
+	// ErrGitCredentialHostRequired 凭证主机不能为空
+	ErrGitCredentialHostRequired = errors.New("git credential host is required")
+	// ErrInvalidGitCredentialKind 凭证类型不合法
+	ErrInvalidGitCredentialKind = errors.New("git credential kind must be token, basic or ssh_key")
+	// ErrGitCredentialSecretRequired 凭证密钥不能为空
+	ErrGitCredentialSecretRequired = errors.New("git credential secret is required")
+	// ErrGitCredentialUsernameRequired basic 凭证需要用户名
+	ErrGitCredentialUsernameRequired = errors.New("git credential username is required for basic auth")
+	// ErrInvalidSSHKey SSH 凭证不是私钥
+	ErrInvalidSSHKey = errors.New("ssh_key secret must be a private key")
+	// ErrGitCredentialDuplicate 凭证名称重复
+	ErrGitCredentialDuplicate = errors.New("git credential name already exists")
+	// ErrGitCredentialMismatch 凭证与仓库地址的主机或协议不匹配
+	ErrGitCredentialMismatch = errors.New("git credential does not match repository url")
+)
+
+// GitCredentialRequest 创建或更新 Git 凭证请求，更新时空字段不修改
+type GitCredentialRequest struct {
+	Name       string `json:"name"`
+	Host       string `json:"host"`
+	Owner      string `json:"owner"`
+	Kind       string `json:"kind"`
+	Username   string `json:"username"`
+	Secret     string `json:"secret"`
+	KnownHosts string `json:"known_hosts"`
+}
+
+// GitCredentialService 管理私有仓库凭证，密钥加密存储，克隆与拉取时解析为 git.Auth
+type GitCredentialService struct {
+	repo repository.GitCredentialRepository
+	box  *secret.Box
+}
+
+// NewGitCredentialService 创建 Git 凭证服务
+func NewGitCredentialService(repo repository.GitCredentialRepository, box *secret.Box) *GitCredentialService {
+	return &GitCredentialService{repo: repo, box: box}
+}
+
+// List 列出当前工作空间的凭证，不含密钥
+func (s *GitCredentialService) List(ctx context.Context) ([]model.GitCredential, error) {
+	return s.repo.List(ctx)
+}
+
+// Get 获取凭证，不含密钥
+func (s *GitCredentialService) Get(ctx context.Context, id uint) (*model.GitCredential, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 创建凭证
+func (s *GitCredentialService) Create(ctx context.Context, req *GitCredentialRequest) (*model.GitCredential, error) {
+	credential := &model.GitCredential{
+		Name:       strings.TrimSpace(req.Name),
+		Host:       normalizeCredentialHost(req.Host),
+		Owner:      normalizeCredentialOwner(req.Owner),
+		Kind:       req.Kind,
+		Username:   strings.TrimSpace(req.Username),
+		KnownHosts: strings.TrimSpace(req.KnownHosts),
+	}
+	if req.Secret == "" {
+		return nil, ErrGitCredentialSecretRequired
+	}
+	if err := validateGitCredential(credential, req.Secret); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByName(ctx, credential.Name); err == nil {
+		return nil, ErrGitCredentialDuplicate
+	}
+	encrypted, err := s.box.Encrypt(req.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("加密凭证失败: %w", err)
+	}
+	credential.Secret = encrypted
+	if err := s.repo.Create(ctx, credential); err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("GitCredentialService: created credential id=%d, host=%s, owner=%s, kind=%s", credential.ID, credential.Host, credential.Owner, credential.Kind)
+	return credential, nil
+}
+
+// Update 更新凭证，secret 为空时保留原密钥；切换类型时必须提供新密钥
+func (s *GitCredentialService) Update(ctx context.Context, id uint, req *GitCredentialRequest) (*model.GitCredential, error) {
+	credential, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(req.Name); name != "" && name != credential.Name {
+		if _, err := s.repo.GetByName(ctx, name); err == nil {
+			return nil, ErrGitCredentialDuplicate
+		}
+		credential.Name = name
+	}
+	if req.Host != "" {
+		credential.Host = normalizeCredentialHost(req.Host)
+	}
+	if req.Owner != "" {
+		credential.Owner = normalizeCredentialOwner(req.Owner)
+	}
+	if req.Kind != "" && req.Kind != credential.Kind {
+		if req.Secret == "" {
+			return nil, ErrGitCredentialSecretRequired
+		}
+		credential.Kind = req.Kind
+	}
+	if req.Username != "" {
+		credential.Username = strings.TrimSpace(req.Username)
+	}
+	if req.KnownHosts != "" {
+		credential.KnownHosts = strings.TrimSpace(req.KnownHosts)
+	}
+
+	plain := req.Secret
+	if plain == "" {
+		if plain, err = s.box.Decrypt(credential.Secret); err != nil {
+			return nil, fmt.Errorf("解密凭证失败: %w", err)
+		}
+	}
+	if err := validateGitCredential(credential, plain); err != nil {
+		return nil, err
+	}
+	if req.Secret != "" {
+		encrypted, err := s.box.Encrypt(req.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("加密凭证失败: %w", err)
+		}
+		credential.Secret = encrypted
+	}
+	if err := s.repo.Save(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// Delete 删除凭证，指定了该凭证的仓库之后克隆与拉取会失败，需重新指定凭证
+func (s *GitCredentialService) Delete(ctx context.Context, id uint) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Validate 校验凭证存在且与仓库地址匹配，用于创建仓库时指定凭证
+func (s *GitCredentialService) Validate(ctx context.Context, id uint, repoURL string) error {
+	credential, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	host, path, ssh, err := git.ParseRemote(repoURL)
+	if err != nil {
+		return ErrInvalidRepositoryURL
+	}
+	if !credentialMatches(credential, host, path, ssh) {
+		return ErrGitCredentialMismatch
+	}
+	return nil
+}
+
+// Resolve 返回克隆或拉取仓库使用的凭证，没有可用凭证时返回 nil
+// 仓库指定了凭证时只使用该凭证，否则在仓库所属工作空间中按主机与组织匹配，组织路径最长者优先
+func (s *GitCredentialService) Resolve(ctx context.Context, repo *model.Repository) (*git.Auth, error) {
+	host, path, ssh, err := git.ParseRemote(repo.URL)
+	if err != nil {
+		return nil, nil
+	}
+	ctx = repository.WithWorkspace(ctx, repo.WorkspaceID)
+
+	var matched *model.GitCredential
+	if repo.CredentialID != nil {
+		credential, err := s.repo.GetByID(ctx, *repo.CredentialID)
+		if err != nil {
+			return nil, fmt.Errorf("仓库指定的 Git 凭证不可用: %w", err)
+		}
+		if !credentialMatches(credential, host, path, ssh) {
+			return nil, ErrGitCredentialMismatch
+		}
+		matched = credential
+	} else {
+		credentials, err := s.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range credentials {
+			credential := &credentials[i]
+			if credentialMatches(credential, host, path, ssh) && (matched == nil || len(credential.Owner) > len(matched.Owner)) {
+				matched = credential
+			}
+		}
+	}
+	if matched == nil {
+		return nil, nil
+	}
+
+	plain, err := s.box.Decrypt(matched.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("解密 Git 凭证 %s 失败: %w", matched.Name, err)
+	}
+	klog.V(6).Infof("GitCredentialService: repo %d uses credential %s (%s)", repo.ID, matched.Name, matched.Kind)
+	switch matched.Kind {
+	case model.GitCredentialSSHKey:
+		return &git.Auth{SSHKey: plain, KnownHosts: matched.KnownHosts}, nil
+	case model.GitCredentialToken:
+		username := matched.Username
+		if username == "" {
+			username = defaultTokenUsername(host)
+		}
+		return &git.Auth{Username: username, Password: plain}, nil
+	default:
+		return &git.Auth{Username: matched.Username, Password: plain}, nil
+	}
+}
+
+func validateGitCredential(credential *model.GitCredential, plainSecret string) error {
+	switch {
+	case credential.Name == "":
+		return ErrGitCredentialNameRequired
+	case credential.Host == "":
+		return ErrGitCredentialHostRequired
+	}
+	switch credential.Kind {
+	case model.GitCredentialToken:
+	case model.GitCredentialBasic:
+		if credential.Username == "" {
+			return ErrGitCredentialUsernameRequired
+		}
+	case model.GitCredentialSSHKey:
+		if !strings.Contains(plainSecret, "PRIVATE KEY-----") {
+			return ErrInvalidSSHKey
+		}
+	default:
+		return ErrInvalidGitCredentialKind
+	}
+	return nil
+}
+
+// credentialMatches 主机相同、组织为仓库路径前缀，且凭证类型与访问协议一致
+func credentialMatches(credential *model.GitCredential, host, path string, ssh bool) bool {
+	if credential.Host != host {
+		return false
+	}
+	if (credential.Kind == model.GitCredentialSSHKey) != ssh {
+		return false
+	}
+	if credential.Owner == "" {
+		return true
+	}
+	path = strings.ToLower(path)
+	return path == credential.Owner || strings.HasPrefix(path, credential.Owner+"/")
+}
+
+// normalizeCredentialHost 接受 github.com、https://github.com/ 或 git@github.com 等写法
+func normalizeCredentialHost(raw string) string {
+	host := strings.TrimSpace(raw)
+	if strings.Contains(host, "://") {
+		if parsed, err := url.Parse(host); err == nil {
+			host = parsed.Hostname()
+		}
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.IndexAny(host, ":/"); i >= 0 {
+		host = host[:i]
+	}
+	return strings.ToLower(host)
+}
+
+func normalizeCredentialOwner(raw string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(raw), "/"))
+}
+
+// defaultTokenUsername 令牌认证的默认用户名，GitHub 使用 x-access-token，GitLab 等使用 oauth2
+func defaultTokenUsername(host string) string {
+	if host == "github.com" {
+		return "x-access-token"
+	}
+	return "oauth2"
+}
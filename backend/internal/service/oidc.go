@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/oidc"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+// OIDCLoginTTL 从跳转身份提供方到回调完成的最长时间
+const OIDCLoginTTL = 10 * time.Minute
+
+// oidcMaxPendingLogins 同时进行中的登录数上限，登录入口无需认证，避免匿名请求无限占用内存
+const oidcMaxPendingLogins = 10000
+
+// oidcRoleNone default_role 取该值时，未匹配用户组的账号拒绝登录
+const oidcRoleNone = "none"
+
+var (
+	// ErrOIDCInvalidState 登录状态不存在或已过期，可能是重放或跨站请求
+	ErrOIDCInvalidState = errors.New("invalid or expired oidc login state")
+	// ErrOIDCTooManyLogins 进行中的登录数已达上限
+	ErrOIDCTooManyLogins = errors.New("too many pending oidc logins")
+	// ErrOIDCAccessDenied 账号未映射到任何角色
+	ErrOIDCAccessDenied = errors.New("no role is granted to this account")
+)
+
+type oidcLoginState struct {
+	nonce        string
+	codeVerifier string
+	redirect     string
+	expiresAt    time.Time
+}
+
+// OIDCService OIDC 授权码登录：跳转身份提供方、校验回调、同步用户与角色并创建会话
+// 登录状态保存在进程内存中：服务重启后进行中的登录失效，多副本部署时需要会话保持，
+// 保证回调请求与发起登录的请求落在同一实例
+type OIDCService struct {
+	config   config.OIDCConfig
+	client   *oidc.Client
+	auth     *AuthService
+	userRepo repository.UserRepository
+
+	mutex   sync.Mutex
+	pending map[string]oidcLoginState // state -> 登录状态
+}
+
+// NewOIDCService 创建 OIDC 登录服务，httpClient 为空时使用默认客户端
+func NewOIDCService(cfg config.OIDCConfig, auth *AuthService, userRepo repository.UserRepository, httpClient *http.Client) *OIDCService {
+	return &OIDCService{
+		config: cfg,
+		client: oidc.NewClient(oidc.Config{
+			IssuerURL:    cfg.IssuerURL,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		}, httpClient),
+		auth:     auth,
+		userRepo: userRepo,
+		pending:  make(map[string]oidcLoginState),
+	}
+}
+
+// BeginLogin 生成 state、nonce 与 PKCE verifier，返回身份提供方授权地址与 state
+// redirect 为登录完成后返回的站内路径；调用方需将 state 绑定到发起登录的浏览器（如 HttpOnly Cookie），
+// 回调时传给 FinishLogin 校验，防止登录 CSRF
+func (s *OIDCService) BeginLogin(ctx context.Context, redirect string) (string, string, error) {
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.RandomString(48)
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err := s.client.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// 只在达到上限时清理过期状态，避免每次登录都遍历全部状态
+	if len(s.pending) >= oidcMaxPendingLogins {
+		for key, pending := range s.pending {
+			if now.After(pending.expiresAt) {
+				delete(s.pending, key)
+			}
+		}
+		if len(s.pending) >= oidcMaxPendingLogins {
+			return "", "", ErrOIDCTooManyLogins
+		}
+	}
+	s.pending[state] = oidcLoginState{
+		nonce:        nonce,
+		codeVerifier: verifier,
+		redirect:     SafeRedirect(redirect),
+		expiresAt:    now.Add(OIDCLoginTTL),
+	}
+	return authURL, state, nil
+}
+
+// FinishLogin 处理身份提供方回调：换取并校验 ID Token，同步用户后创建会话
+// boundState 为发起登录的浏览器保存的 state，必须与回调的 state 一致，
+// 否则可能是攻击者诱导受害者打开自己的回调地址（登录 CSRF）
+// 返回会话令牌、用户与登录前的站内路径
+func (s *OIDCService) FinishLogin(ctx context.Context, state, boundState, code string) (string, *model.User, string, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(boundState)) != 1 {
+		return "", nil, "", ErrOIDCInvalidState
+	}
+	s.mutex.Lock()
+	pending, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mutex.Unlock()
+	if !ok || state == "" || time.Now().After(pending.expiresAt) {
+		return "", nil, "", ErrOIDCInvalidState
+	}
+
+	tokens, err := s.client.Exchange(ctx, code, pending.codeVerifier)
+	if err != nil {
+		return "", nil, "", err
+	}
+	idToken, err := s.client.VerifyIDToken(ctx, tokens.IDToken, pending.nonce)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	user, err := s.syncUser(ctx, idToken)
+	if err != nil {
+		return "", nil, "", err
+	}
+	token, err := s.auth.CreateSession(ctx, user)
+	if err != nil {
+		return "", nil, "", err
+	}
+	klog.V(6).Infof("OIDC 登录成功: username=%s, role=%s", user.Username, user.Role)
+	return token, user, pending.redirect, nil
+}
+
+// syncUser 按 sub 查找或创建用户，每次登录以身份提供方的资料和用户组为准
+func (s *OIDCService) syncUser(ctx context.Context, idToken *oidc.IDToken) (*model.User, error) {
+	role, err := s.mapRole(idToken.StringsClaim(s.config.GroupsClaim))
+	if err != nil {
+		return nil, err
+	}
+	displayName := idToken.StringClaim("name")
+	email := idToken.StringClaim("email")
+
+	user, err := s.userRepo.GetBySubject(ctx, model.AuthSourceOIDC, idToken.Subject)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+	if user != nil {
+		if user.Status != model.UserStatusEnabled {
+			return nil, ErrUnauthenticated
+		}
+		user.Role = role
+		if displayName != "" {
+			user.DisplayName = displayName
+		}
+		if email != "" {
+			user.Email = email
+		}
+		if err := s.userRepo.Save(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	username, err := s.uniqueUsername(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	user = &model.User{
+		Username:    username,
+		DisplayName: displayName,
+		Email:       email,
+		Role:        role,
+		Status:      model.UserStatusEnabled,
+		AuthSource:  model.AuthSourceOIDC,
+		Subject:     idToken.Subject,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	klog.Infof("OIDC 首次登录，已创建用户: username=%s, role=%s", user.Username, user.Role)
+	return user, nil
+}
+
+// mapRole 取用户组映射到的最高角色，未匹配时使用默认角色
+func (s *OIDCService) mapRole(groups []string) (string, error) {
+	role := ""
+	for _, group := range groups {
+		mapped := s.config.RoleMapping[group]
+		if model.RoleLevel(mapped) > model.RoleLevel(role) {
+			role = mapped
+		}
+	}
+	if role != "" {
+		return role, nil
+	}
+	if s.config.DefaultRole == oidcRoleNone || !model.IsValidRole(s.config.DefaultRole) {
+		return "", ErrOIDCAccessDenied
+	}
+	return s.config.DefaultRole, nil
+}
+
+// uniqueUsername 优先使用配置的用户名 claim，与已有用户重名时追加 sub 摘要
+func (s *OIDCService) uniqueUsername(ctx context.Context, idToken *oidc.IDToken) (string, error) {
+	username := strings.TrimSpace(idToken.StringClaim(s.config.UsernameClaim))
+	if username == "" {
+		username = strings.TrimSpace(idToken.StringClaim("email"))
+	}
+	if username == "" {
+		username = idToken.Subject
+	}
+	if _, err := s.userRepo.GetByUsername(ctx, username); errors.Is(err, repository.ErrUserNotFound) {
+		return username, nil
+	} else if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(idToken.Subject))
+	return fmt.Sprintf("%s-%s", username, hex.EncodeToString(sum[:])[:6]), nil
+}
+
+// SafeRedirect 只允许站内相对路径，防止登录后被带到外部站点
+func SafeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/oidc/oidctest"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newTestOIDCService(t *testing.T, issuerURL string, defaultRole string) (*OIDCService, *AuthService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.APIToken{}, &model.UserSession{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	userRepo := repository.NewUserRepository(db)
+	auth := NewAuthService(userRepo, repository.NewAPITokenRepository(db), repository.NewUserSessionRepository(db), 0)
+	svc := NewOIDCService(config.OIDCConfig{
+		IssuerURL:     issuerURL,
+		ClientID:      "wiki",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://wiki.local/api/auth/oidc/callback",
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		RoleMapping:   map[string]string{"wiki-admins": model.RoleAdmin, "developers": model.RoleEditor},
+		DefaultRole:   defaultRole,
+	}, auth, userRepo, nil)
+	return svc, auth
+}
+
+// authorize 模拟浏览器访问授权地址，返回回调中的 state 与 code
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize error: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("bad redirect: %v", err)
+	}
+	return location.Query().Get("state"), location.Query().Get("code")
+}
+
+func TestOIDCServiceLoginSyncsUserAndRole(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	svc, auth := newTestOIDCService(t, issuer.URL(), model.RoleViewer)
+	ctx := context.Background()
+
+	issuer.SetUser(map[string]interface{}{
+		"sub":                "abc-123",
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"developers", "everyone"},
+	})
+	authURL, _, err := svc.BeginLogin(ctx, "/repo/1")
+	if err != nil {
+		t.Fatalf("begin login error: %v", err)
+	}
+	state, code := authorize(t, authURL)
+
+	// 浏览器未持有该 state（攻击者诱导受害者打开自己的回调地址）时拒绝，且不消耗登录状态
+	if _, _, _, err := svc.FinishLogin(ctx, state, "", code); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("expected invalid state without browser binding, got %v", err)
+	}
+
+	token, user, redirect, err := svc.FinishLogin(ctx, state, state, code)
+	if err != nil {
+		t.Fatalf("finish login error: %v", err)
+	}
+	if redirect != "/repo/1" {
+		t.Fatalf("unexpected redirect: %s", redirect)
+	}
+	if user.Username != "alice" || user.Role != model.RoleEditor || user.AuthSource != model.AuthSourceOIDC || user.Email != "alice@example.com" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	current, err := auth.Authenticate(ctx, token)
+	if err != nil || current.ID != user.ID {
+		t.Fatalf("session not usable: %v", err)
+	}
+
+	// state 只能使用一次
+	if _, _, _, err := svc.FinishLogin(ctx, state, state, code); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("expected invalid state on replay, got %v", err)
+	}
+
+	// 再次登录时按用户组更新角色，不重复创建用户
+	issuer.SetUser(map[string]interface{}{"sub": "abc-123", "preferred_username": "alice", "groups": []string{"wiki-admins"}})
+	authURL, _, _ = svc.BeginLogin(ctx, "https://evil.example.com")
+	state, code = authorize(t, authURL)
+	_, user2, redirect, err := svc.FinishLogin(ctx, state, state, code)
+	if err != nil {
+		t.Fatalf("second login error: %v", err)
+	}
+	if user2.ID != user.ID || user2.Role != model.RoleAdmin {
+		t.Fatalf("expected role update on same user, got %+v", user2)
+	}
+	if redirect != "/" {
+		t.Fatalf("external redirect should be rejected, got %s", redirect)
+	}
+	if err := auth.ChangePassword(ctx, user.ID, "", "new-password"); !errors.Is(err, ErrExternalAccount) {
+		t.Fatalf("expected external account error, got %v", err)
+	}
+}
+
+func TestOIDCServiceUsernameConflictAndDeniedRole(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	svc, auth := newTestOIDCService(t, issuer.URL(), "none")
+	ctx := context.Background()
+
+	if _, err := auth.CreateUser(ctx, &CreateUserRequest{Username: "bob", Password: "local-password"}); err != nil {
+		t.Fatalf("create local user error: %v", err)
+	}
+
+	// 本地已有同名用户时，OIDC 用户名追加后缀，不会接管本地账号
+	issuer.SetUser(map[string]interface{}{"sub": "sub-bob", "preferred_username": "bob", "groups": []string{"developers"}})
+	authURL, _, _ := svc.BeginLogin(ctx, "")
+	state, code := authorize(t, authURL)
+	_, user, _, err := svc.FinishLogin(ctx, state, state, code)
+	if err != nil {
+		t.Fatalf("finish login error: %v", err)
+	}
+	if user.Username == "bob" {
+		t.Fatalf("oidc user must not reuse local username")
+	}
+
+	// 未映射任何用户组且默认角色为 none 时拒绝登录
+	issuer.SetUser(map[string]interface{}{"sub": "sub-eve", "preferred_username": "eve", "groups": []string{"guests"}})
+	authURL, _, _ = svc.BeginLogin(ctx, "")
+	state, code = authorize(t, authURL)
+	if _, _, _, err := svc.FinishLogin(ctx, state, state, code); !errors.Is(err, ErrOIDCAccessDenied) {
+		t.Fatalf("expected access denied, got %v", err)
+	}
+}
+
+func TestOIDCServicePendingLoginLimit(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	svc, _ := newTestOIDCService(t, issuer.URL(), model.RoleViewer)
+	ctx := context.Background()
+
+	expired := time.Now().Add(-time.Minute)
+	for i := 0; i < oidcMaxPendingLogins; i++ {
+		svc.pending[fmt.Sprintf("state-%d", i)] = oidcLoginState{expiresAt: expired}
+	}
+	// 达到上限时先清理过期状态
+	if _, _, err := svc.BeginLogin(ctx, ""); err != nil {
+		t.Fatalf("begin login should succeed after purging expired states: %v", err)
+	}
+	if len(svc.pending) != 1 {
+		t.Fatalf("expired states should be purged, got %d", len(svc.pending))
+	}
+
+	for i := 0; i < oidcMaxPendingLogins; i++ {
+		svc.pending[fmt.Sprintf("state-%d", i)] = oidcLoginState{expiresAt: time.Now().Add(OIDCLoginTTL)}
+	}
+	if _, _, err := svc.BeginLogin(ctx, ""); !errors.Is(err, ErrOIDCTooManyLogins) {
+		t.Fatalf("expected ErrOIDCTooManyLogins, got %v", err)
+	}
+}
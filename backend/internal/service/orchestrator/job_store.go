@@ -0,0 +1,191 @@
+package orchestrator
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+)
+
+// JobStore 定义编排器的作业队列存储
+// 作业先写入存储，再由分发循环按 NextRunAt 取出；同一任务在存储中最多存在一个作业
+type JobStore interface {
+	// Push 写入待分发作业；同一任务已有等待中的作业时覆盖，执行中的作业保持不变
+	Push(job *Job) error
+	// Ready 按 NextRunAt、入队顺序返回已到期的等待作业，每个优先级通道最多 limit 个，跳过 excludeRepos 中仓库的作业
+	Ready(now time.Time, limit int, excludeRepos []uint) ([]*Job, error)
+	// Claim 将等待作业标记为执行中，返回 false 表示已被其他分发者领取
+	Claim(job *Job) (bool, error)
+	// Requeue 将作业放回等待状态，并保存新的重试次数与 NextRunAt
+	Requeue(job *Job) error
+	// SetPriority 修改等待中作业的优先级
+	SetPriority(taskID uint, priority domain.TaskPriority) error
+	// Remove 删除任务对应的作业
+	Remove(taskID uint) error
+	// Has 判断任务是否仍有作业（等待或执行中）
+	Has(taskID uint) (bool, error)
+	// Recover 将上次进程遗留的执行中作业恢复为等待状态，返回恢复数量
+	Recover() (int64, error)
+	// Len 返回等待中的作业数量
+	Len() int
+	// QueuedByRepository 按仓库统计等待中的作业数量
+	QueuedByRepository() (map[uint]int, error)
+}
+
+// memoryJobStore 内存作业存储，进程重启后丢失，仅用于测试或未配置持久化存储时
+type memoryJobStore struct {
+	maxSize int
+	seq     uint64
+	entries map[uint]*memoryJobEntry
+	mutex   sync.Mutex
+}
+
+type memoryJobEntry struct {
+	job     Job
+	seq     uint64
+	running bool
+}
+
+func newMemoryJobStore(maxSize int) *memoryJobStore {
+	return &memoryJobStore{
+		maxSize: maxSize,
+		entries: make(map[uint]*memoryJobEntry),
+	}
+}
+
+func (s *memoryJobStore) Push(job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if entry, ok := s.entries[job.TaskID]; ok {
+		if !entry.running {
+			entry.job = *job
+		}
+		return nil
+	}
+	if s.maxSize > 0 && s.waitingLocked() >= s.maxSize {
+		return ErrQueueFull
+	}
+	s.seq++
+	s.entries[job.TaskID] = &memoryJobEntry{job: *job, seq: s.seq}
+	return nil
+}
+
+func (s *memoryJobStore) Ready(now time.Time, limit int, excludeRepos []uint) ([]*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ready := make([]*memoryJobEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if entry.running || entry.job.NextRunAt.After(now) || slices.Contains(excludeRepos, entry.job.RepositoryID) {
+			continue
+		}
+		ready = append(ready, entry)
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if !ready[i].job.NextRunAt.Equal(ready[j].job.NextRunAt) {
+			return ready[i].job.NextRunAt.Before(ready[j].job.NextRunAt)
+		}
+		return ready[i].seq < ready[j].seq
+	})
+	jobs := make([]*Job, 0, len(ready))
+	lanes := make(map[domain.TaskPriority]int)
+	for _, entry := range ready {
+		if limit > 0 && lanes[entry.job.Priority] >= limit {
+			continue
+		}
+		lanes[entry.job.Priority]++
+		job := entry.job
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (s *memoryJobStore) Claim(job *Job) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[job.TaskID]
+	if !ok || entry.running {
+		return false, nil
+	}
+	entry.running = true
+	return true, nil
+}
+
+func (s *memoryJobStore) Requeue(job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[job.TaskID]
+	if !ok {
+		s.seq++
+		entry = &memoryJobEntry{seq: s.seq}
+		s.entries[job.TaskID] = entry
+	}
+	entry.job = *job
+	entry.running = false
+	return nil
+}
+
+func (s *memoryJobStore) SetPriority(taskID uint, priority domain.TaskPriority) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if entry, ok := s.entries[taskID]; ok && !entry.running {
+		entry.job.Priority = priority
+	}
+	return nil
+}
+
+func (s *memoryJobStore) Remove(taskID uint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, taskID)
+	return nil
+}
+
+func (s *memoryJobStore) Has(taskID uint) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.entries[taskID]
+	return ok, nil
+}
+
+func (s *memoryJobStore) Recover() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var recovered int64
+	for _, entry := range s.entries {
+		if entry.running {
+			entry.running = false
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+func (s *memoryJobStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.waitingLocked()
+}
+
+func (s *memoryJobStore) QueuedByRepository() (map[uint]int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	counts := make(map[uint]int)
+	for _, entry := range s.entries {
+		if !entry.running {
+			counts[entry.job.RepositoryID]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *memoryJobStore) waitingLocked() int {
+	count := 0
+	for _, entry := range s.entries {
+		if !entry.running {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+// dbJobStore 基于数据库的作业存储，排队作业、重试次数与退避截止时间在重启后保留
+type dbJobStore struct {
+	repo repository.TaskJobRepository
+}
+
+// NewDBJobStore 创建基于 TaskJobRepository 的持久化作业存储
+func NewDBJobStore(repo repository.TaskJobRepository) JobStore {
+	return &dbJobStore{repo: repo}
+}
+
+func (s *dbJobStore) Push(job *Job) error {
+	record := jobToRecord(job)
+	record.Status = model.TaskJobStatusWaiting
+	if err := s.repo.Upsert(record); err != nil {
+		return err
+	}
+	job.storeID = record.ID
+	return nil
+}
+
+func (s *dbJobStore) Ready(now time.Time, limit int, excludeRepos []uint) ([]*Job, error) {
+	records, err := s.repo.ListReady(now, limit, excludeRepos)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(records))
+	for i := range records {
+		jobs = append(jobs, recordToJob(&records[i]))
+	}
+	return jobs, nil
+}
+
+func (s *dbJobStore) Claim(job *Job) (bool, error) {
+	if job.storeID == 0 {
+		return false, nil
+	}
+	return s.repo.Claim(job.storeID)
+}
+
+func (s *dbJobStore) Requeue(job *Job) error {
+	record := jobToRecord(job)
+	record.Status = model.TaskJobStatusWaiting
+	if job.storeID == 0 {
+		if err := s.repo.Upsert(record); err != nil {
+			return err
+		}
+		job.storeID = record.ID
+		return nil
+	}
+	return s.repo.Requeue(record)
+}
+
+func (s *dbJobStore) SetPriority(taskID uint, priority domain.TaskPriority) error {
+	return s.repo.UpdatePriority(taskID, string(priority))
+}
+
+func (s *dbJobStore) Remove(taskID uint) error {
+	return s.repo.DeleteByTaskID(taskID)
+}
+
+func (s *dbJobStore) Has(taskID uint) (bool, error) {
+	return s.repo.ExistsByTaskID(taskID)
+}
+
+func (s *dbJobStore) Recover() (int64, error) {
+	return s.repo.ResetRunning()
+}
+
+func (s *dbJobStore) Len() int {
+	count, err := s.repo.CountByStatus(model.TaskJobStatusWaiting)
+	if err != nil {
+		klog.Errorf("统计等待作业数量失败: %v", err)
+		return 0
+	}
+	return int(count)
+}
+
+func (s *dbJobStore) QueuedByRepository() (map[uint]int, error) {
+	counts, err := s.repo.CountWaitingByRepository()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]int, len(counts))
+	for repoID, count := range counts {
+		result[repoID] = int(count)
+	}
+	return result, nil
+}
+
+func jobToRecord(job *Job) *model.TaskJob {
+	return &model.TaskJob{
+		ID:             job.storeID,
+		TaskID:         job.TaskID,
+		RepositoryID:   job.RepositoryID,
+		Priority:       string(job.Priority),
+		RetryCount:     job.RetryCount,
+		MaxRetries:     job.MaxRetries,
+		TimeoutSeconds: int(job.Timeout / time.Second),
+		NextRunAt:      job.NextRunAt,
+		EnqueuedAt:     job.EnqueuedAt,
+	}
+}
+
+func recordToJob(record *model.TaskJob) *Job {
+	return &Job{
+		TaskID:       record.TaskID,
+		RepositoryID: record.RepositoryID,
+		Priority:     domain.TaskPriority(record.Priority),
+		EnqueuedAt:   record.EnqueuedAt,
+		NextRunAt:    record.NextRunAt,
+		RetryCount:   record.RetryCount,
+		MaxRetries:   record.MaxRetries,
+		Timeout:      time.Duration(record.TimeoutSeconds) * time.Second,
+		storeID:      record.ID,
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/panjf2000/ants/v2"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"gorm.io/gorm"
 	"k8s.io/klog/v2"
 )
@@ -16,11 +18,16 @@ import (
 // Job 定义
 // -----------------------------
 type Job struct {
-	TaskID     uint
-	EnqueuedAt time.Time
-	RetryCount int
-	MaxRetries int
-	Timeout    time.Duration
+	TaskID       uint
+	RepositoryID uint
+	Priority     domain.TaskPriority
+	EnqueuedAt   time.Time
+	NextRunAt    time.Time // 最早可分发时间，重试退避与依赖等待都通过它延后
+	RetryCount   int
+	MaxRetries   int
+	Timeout      time.Duration
+
+	storeID uint // 持久化存储中的记录ID
 }
 
 // -----------------------------
@@ -31,27 +38,45 @@ type TaskExecutor interface {
 }
 
 // TaskDependencyChecker 定义任务依赖检查接口
+// 上游任务已失败或取消、依赖无法自行满足时，CheckDependenciesSatisfied 返回 ErrDependencyFailed，
+// 编排器将作业移出队列并调用 BlockTask，由实现将任务移出排队状态
 type TaskDependencyChecker interface {
-	CheckRunAfterSatisfied(taskID uint) (bool, uint, string, error)
+	CheckDependenciesSatisfied(taskID uint) (bool, uint, string, error)
+	BlockTask(taskID uint, dependsOn uint) error
 }
 
+const (
+	// dispatchInterval 分发循环轮询存储的间隔
+	dispatchInterval = 500 * time.Millisecond
+	// dependencyRetryDelay、dependencyRetryMaxDelay 依赖未满足时作业延后分发的最短与最长时间
+	dependencyRetryDelay    = 500 * time.Millisecond
+	dependencyRetryMaxDelay = 10 * time.Second
+	// readyScanLimit 每次公平选择时每个优先级通道读取的到期作业上限
+	readyScanLimit = 500
+	// defaultLaneAging 作业在队列中每等待该时长，优先级提升一个通道，防止低优先级作业饿死
+	defaultLaneAging = 10 * time.Minute
+)
+
 // -----------------------------
 // Orchestrator
 // -----------------------------
 type Orchestrator struct {
-	jobQueue    *jobQueue
-	retryQueue  *jobQueue
-	retryTicker *time.Ticker
+	store  JobStore
+	notify chan struct{}
 
-	pool *ants.Pool
+	pool       *ants.Pool
+	maxWorkers int
+	inflight   int32 // 已提交到协程池且尚未结束的作业数
+	repos      *repoScheduler
 
 	executor TaskExecutor
 
 	dependencyChecker TaskDependencyChecker
 
-	ctx      context.Context
-	cancel   context.CancelFunc
-	stopOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	startOnce sync.Once
+	stopOnce  sync.Once
 
 	activeCancellations map[uint]context.CancelFunc
 	cancelMutex         sync.Mutex
@@ -64,6 +89,8 @@ var (
 	ErrOrchestratorStopped = errors.New("orchestrator is stopped")
 	ErrQueueFull           = errors.New("job queue is full")
 	ErrRepoLocked          = errors.New("repository is locked by another task")
+	// ErrDependencyFailed 上游任务已失败或取消，依赖在上游重试前无法满足
+	ErrDependencyFailed = errors.New("task dependency failed")
 )
 
 // NewTaskJob
@@ -71,23 +98,35 @@ var (
 // 参数：taskID 任务ID；repositoryID 仓库ID
 // 返回：*Job 初始化后的任务对象
 func NewTaskJob(taskID, repositoryID uint) *Job {
+	now := time.Now()
 	return &Job{
-		TaskID:     taskID,
-		EnqueuedAt: time.Now(),
-		RetryCount: 0,
-		MaxRetries: 5,
-		Timeout:    30 * time.Minute,
+		TaskID:       taskID,
+		RepositoryID: repositoryID,
+		Priority:     domain.PriorityNormal,
+		EnqueuedAt:   now,
+		NextRunAt:    now,
+		RetryCount:   0,
+		MaxRetries:   5,
+		Timeout:      30 * time.Minute,
 	}
 }
 
 // -----------------------------
 // 构造函数
 // -----------------------------
-func NewOrchestrator(maxWorkers int, executor TaskExecutor) (*Orchestrator, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	jobQ := newJobQueue(120)
-	retryQ := newJobQueue(120)
+// NewOrchestrator 创建编排器
+// store 为空时使用内存存储（重启后丢失，仅用于测试）；
+// 创建时会把上次进程遗留的执行中作业恢复为等待状态
+func NewOrchestrator(maxWorkers int, executor TaskExecutor, store JobStore) (*Orchestrator, error) {
+	if store == nil {
+		store = newMemoryJobStore(120)
+	}
+	if recovered, err := store.Recover(); err != nil {
+		klog.Errorf("恢复遗留作业失败: %v", err)
+		return nil, err
+	} else if recovered > 0 {
+		klog.V(6).Infof("恢复重启前执行中的作业: count=%d", recovered)
+	}
 
 	pool, err := ants.NewPool(maxWorkers,
 		ants.WithNonblocking(false),
@@ -99,11 +138,13 @@ func NewOrchestrator(maxWorkers int, executor TaskExecutor) (*Orchestrator, erro
 		return nil, err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Orchestrator{
-		jobQueue:            jobQ,
-		retryQueue:          retryQ,
-		retryTicker:         time.NewTicker(500 * time.Millisecond),
+		store:               store,
+		notify:              make(chan struct{}, 1),
 		pool:                pool,
+		maxWorkers:          maxWorkers,
+		repos:               newRepoScheduler(defaultLaneAging),
 		activeCancellations: make(map[uint]context.CancelFunc),
 		executor:            executor,
 		ctx:                 ctx,
@@ -115,8 +156,9 @@ func NewOrchestrator(maxWorkers int, executor TaskExecutor) (*Orchestrator, erro
 // 启动
 // -----------------------------
 func (o *Orchestrator) Start() {
-	go o.dispatchLoop()
-	go o.processRetryQueue()
+	o.startOnce.Do(func() {
+		go o.dispatchLoop()
+	})
 }
 
 // -----------------------------
@@ -126,33 +168,23 @@ func (o *Orchestrator) Stop() {
 	o.stopOnce.Do(func() {
 		klog.V(6).Infof("Orchestrator stopping...")
 
-		// 1. 停止接收新任务，关闭队列
+		// 1. 停止分发，队列中的作业已持久化，下次启动后继续分发
 		o.cancel()
-		o.jobQueue.Close()
-		o.retryQueue.Close()
-
-		// 2. 等待队列中待执行的任务全部分发完毕（原有逻辑保留）
-		for {
-			if o.jobQueue.Len() == 0 && o.retryQueue.Len() == 0 {
-				break
-			}
-			time.Sleep(100 * time.Millisecond)
-			klog.V(6).Infof("Waiting for queues to empty: main=%d, retry=%d", o.jobQueue.Len(), o.retryQueue.Len())
-		}
+		klog.V(6).Infof("Dispatch stopped, waiting jobs kept in store: %d", o.store.Len())
 
-		// 3. 等待正在执行的长任务完成（核心适配 ants/v2）
-		// 3.1 先打印当前运行中的任务数，便于排查
+		// 2. 等待正在执行的长任务完成（核心适配 ants/v2）
+		// 2.1 先打印当前运行中的任务数，便于排查
 		runningTasks := o.pool.Running()
 		if runningTasks > 0 {
 			klog.V(6).Infof("Waiting for %d running tasks to complete (timeout: 35min)", runningTasks)
 		}
 
-		// 3.2 使用 ReleaseTimeout 等待35分钟（覆盖30分钟任务超时）
+		// 2.2 使用 ReleaseTimeout 等待35分钟（覆盖30分钟任务超时）
 		// 该方法会阻塞，直到：1. 所有任务完成；2. 超时；3. 被中断
 		timeout := 35 * time.Minute
 		rErr := o.pool.ReleaseTimeout(timeout)
 
-		// 3.3 打印等待结果日志
+		// 2.3 打印等待结果日志
 		if rErr == nil {
 			klog.V(6).Infof("All running tasks completed before timeout")
 		} else {
@@ -163,6 +195,26 @@ func (o *Orchestrator) Stop() {
 	})
 }
 
+// SetRepoPolicy 设置仓库级并发上限与调度权重
+func (o *Orchestrator) SetRepoPolicy(policy RepoPolicy) {
+	o.repos.setPolicy(policy)
+	o.wakeup()
+}
+
+// SetLaneAging 设置优先级老化时长，<=0 表示严格按优先级分发
+func (o *Orchestrator) SetLaneAging(aging time.Duration) {
+	o.repos.setLaneAging(aging)
+}
+
+// UpdatePriority 调整排队中作业的优先级，作业不存在或已在执行时不做修改
+func (o *Orchestrator) UpdatePriority(taskID uint, priority domain.TaskPriority) error {
+	if err := o.store.SetPriority(taskID, priority); err != nil {
+		return err
+	}
+	o.wakeup()
+	return nil
+}
+
 // SetDependencyChecker 设置任务依赖检查器
 func (o *Orchestrator) SetDependencyChecker(checker TaskDependencyChecker) {
 	o.dependencyChecker = checker
@@ -178,12 +230,16 @@ func (o *Orchestrator) EnqueueJob(job *Job) error {
 	default:
 	}
 
-	if err := o.jobQueue.Enqueue(job); err != nil {
+	if job.NextRunAt.IsZero() {
+		job.NextRunAt = time.Now()
+	}
+	if err := o.store.Push(job); err != nil {
 		if errors.Is(err, ErrQueueFull) {
 			klog.Warningf("Job queue full: taskID=%d", job.TaskID)
 		}
 		return err
 	}
+	o.wakeup()
 	klog.V(6).Infof("Job enqueued: taskID=%d", job.TaskID)
 	return nil
 }
@@ -202,6 +258,29 @@ func (o *Orchestrator) EnqueueBatch(jobs []*Job) error {
 	return nil
 }
 
+// RemoveJob 从队列中移除任务对应的作业（用于取消、重置排队中的任务）
+func (o *Orchestrator) RemoveJob(taskID uint) error {
+	return o.store.Remove(taskID)
+}
+
+// HasJob 判断任务是否仍在队列中（等待或执行中）
+func (o *Orchestrator) HasJob(taskID uint) bool {
+	ok, err := o.store.Has(taskID)
+	if err != nil {
+		klog.Errorf("查询作业失败: taskID=%d, err=%v", taskID, err)
+		return false
+	}
+	return ok
+}
+
+// wakeup 通知分发循环立即检查队列
+func (o *Orchestrator) wakeup() {
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
 // -----------------------------
 // 取消任务
 // -----------------------------
@@ -240,118 +319,143 @@ func (o *Orchestrator) CancelTask(taskID uint) bool {
 // -----------------------------
 // Dispatch Loop
 // -----------------------------
+// dispatchLoop 周期性地从存储中取出到期作业并分发，入队时会被立即唤醒
 func (o *Orchestrator) dispatchLoop() {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
 	for {
+		o.dispatchReady()
 		select {
 		case <-o.ctx.Done():
 			return
-		default:
-			job, ok := o.jobQueue.Dequeue()
-			if !ok {
-				continue
-			}
-			// TODO 获取TASK，检查其RunAfter 的任务是否已完成，若未完成，则不入队
-			// job.TaskID
-			o.tryDispatch(job)
+		case <-o.notify:
+		case <-ticker.C:
 		}
 	}
 }
 
-// -----------------------------
-// Retry Queue Loop
-// -----------------------------
-func (o *Orchestrator) processRetryQueue() {
-	defer o.retryTicker.Stop()
-	// 增加协程级Panic防护，避免协程退出
+// dispatchReady 在有空闲 worker 时持续领取到期作业
+// 每次先选最高优先级通道，再在通道内按仓库加权轮询选择作业，已达到并发上限的仓库暂时跳过
+func (o *Orchestrator) dispatchReady() {
+	// 增加Panic防护，避免分发循环退出
 	defer func() {
 		if r := recover(); r != nil {
-			klog.Errorf("Retry queue loop panic recovered: %v", r)
+			klog.Errorf("Dispatch loop panic recovered: %v", r)
 		}
 	}()
-	for {
+	for o.hasFreeWorker() {
 		select {
 		case <-o.ctx.Done():
 			return
-		case <-o.retryTicker.C:
-			for range 10 {
-				job, ok := o.retryQueue.Dequeue()
-				if !ok {
-					break
-				}
-				// 单个任务Panic不影响整个循环
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							klog.Errorf("Retry dispatch panic: taskID=%d, err=%v",
-								job.TaskID, r)
-						}
-					}()
-					o.tryDispatch(job)
-				}()
-			}
+		default:
 		}
+		// 已达并发上限的仓库在查询中排除，避免其积压作业占满扫描窗口导致其他仓库饿死
+		now := time.Now()
+		jobs, err := o.store.Ready(now, readyScanLimit, o.repos.cappedRepos())
+		if err != nil {
+			klog.Errorf("读取待分发作业失败: %v", err)
+			return
+		}
+		job := o.repos.pick(jobs, now)
+		if job == nil {
+			return
+		}
+		claimed, err := o.store.Claim(job)
+		if err != nil {
+			klog.Errorf("领取作业失败: taskID=%d, err=%v", job.TaskID, err)
+			return
+		}
+		if !claimed {
+			continue
+		}
+		o.tryDispatch(job)
 	}
 }
 
+func (o *Orchestrator) hasFreeWorker() bool {
+	if o.maxWorkers <= 0 {
+		return true
+	}
+	return int(atomic.LoadInt32(&o.inflight)) < o.maxWorkers
+}
+
 // -----------------------------
 // Try Dispatch
 // -----------------------------
 // tryDispatch
-// 说明：尝试分发任务到协程池执行；当仓库锁被占用或池提交失败时，按重试上限与计数进行重试入队
+// 说明：尝试分发已领取的作业到协程池执行；依赖未满足时延后放回队列，池提交失败时计入重试
 // 参数：job 待执行的任务
-// 行为：当达到重试上限时直接放弃，并打印中文日志
-// tryDispatch 精简为只负责分发，不操作 RetryCount
+// 行为：当达到重试上限时从队列移除，并打印中文日志
 func (o *Orchestrator) tryDispatch(job *Job) {
 	if o.dependencyChecker != nil {
-		allowed, runAfterID, runAfterStatus, err := o.dependencyChecker.CheckRunAfterSatisfied(job.TaskID)
+		allowed, dependsOn, dependsOnStatus, err := o.dependencyChecker.CheckDependenciesSatisfied(job.TaskID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				klog.V(6).Infof("任务已不存在，丢弃队列任务: taskID=%d, runAfter=%d", job.TaskID, runAfterID)
+				klog.V(6).Infof("任务已不存在，丢弃队列任务: taskID=%d, dependsOn=%d", job.TaskID, dependsOn)
+				o.removeJob(job)
 				return
 			}
-			klog.V(6).Infof("任务依赖检查失败，暂不分发: taskID=%d, runAfter=%d, error=%v", job.TaskID, runAfterID, err)
-			if enqueueErr := o.retryQueue.Enqueue(job); enqueueErr != nil {
-				klog.Errorf("任务依赖检查失败后重试入队失败: taskID=%d, error=%v", job.TaskID, enqueueErr)
+			if errors.Is(err, ErrDependencyFailed) {
+				// 不再按固定间隔反复检查，作业移出队列，上游重试成功后由任务服务重新入队
+				klog.V(6).Infof("上游任务已失败或取消，作业移出队列: taskID=%d, dependsOn=%d, status=%s", job.TaskID, dependsOn, dependsOnStatus)
+				o.removeJob(job)
+				if err := o.dependencyChecker.BlockTask(job.TaskID, dependsOn); err != nil {
+					klog.Warningf("阻塞任务失败: taskID=%d, dependsOn=%d, error=%v", job.TaskID, dependsOn, err)
+				}
+				return
 			}
+			klog.V(6).Infof("任务依赖检查失败，暂不分发: taskID=%d, dependsOn=%d, error=%v", job.TaskID, dependsOn, err)
+			o.delayJob(job, dependencyBackoff(job, time.Now()))
 			return
 		}
 		if !allowed {
-			klog.V(6).Infof("任务依赖未满足，暂不分发: taskID=%d, runAfter=%d, status=%s", job.TaskID, runAfterID, runAfterStatus)
-			if enqueueErr := o.retryQueue.Enqueue(job); enqueueErr != nil {
-				klog.Errorf("任务依赖未满足后重试入队失败: taskID=%d, error=%v", job.TaskID, enqueueErr)
-			}
+			klog.V(6).Infof("任务依赖未满足，暂不分发: taskID=%d, dependsOn=%d, status=%s", job.TaskID, dependsOn, dependsOnStatus)
+			o.delayJob(job, dependencyBackoff(job, time.Now()))
 			return
 		}
 	}
 
 	if job.MaxRetries <= 0 || job.RetryCount >= job.MaxRetries {
 		klog.Warningf("任务重试已达上限，放弃入队: taskID=%d, retry=%d/%d", job.TaskID, job.RetryCount, job.MaxRetries)
+		o.removeJob(job)
 		return
 	}
-	if err := o.pool.Submit(func() {
+
+	if err := o.repos.acquire(job.RepositoryID); err != nil {
+		klog.V(6).Infof("仓库并发已达上限，暂不分发: taskID=%d, repoID=%d", job.TaskID, job.RepositoryID)
+		o.delayJob(job, 0)
+		return
+	}
+	atomic.AddInt32(&o.inflight, 1)
+	err := o.pool.Submit(func() {
+		defer atomic.AddInt32(&o.inflight, -1)
+		defer o.repos.release(job.RepositoryID)
 		o.executeJob(job)
-	}); err == nil {
+	})
+	if err == nil {
 		return
-	} else {
-		klog.Errorf("提交任务到协程池失败: taskID=%d, err=%v", job.TaskID, err)
 	}
+	atomic.AddInt32(&o.inflight, -1)
+	o.repos.release(job.RepositoryID)
+	klog.Errorf("提交任务到协程池失败: taskID=%d, err=%v", job.TaskID, err)
 
-	if job.MaxRetries <= 0 || job.RetryCount >= job.MaxRetries {
+	job.RetryCount++
+	if job.RetryCount >= job.MaxRetries {
 		klog.Warningf("任务重试已达上限，放弃入队: taskID=%d, retry=%d/%d", job.TaskID, job.RetryCount, job.MaxRetries)
+		o.removeJob(job)
 		return
 	}
-	job.RetryCount++
-	if err := o.retryQueue.Enqueue(job); err != nil {
-		klog.Errorf("任务重试入队失败: taskID=%d, err=%v", job.TaskID, err)
-	}
+	o.delayJob(job, retryBackoff(job.RetryCount))
 }
 
-// executeJob 统一控制重试
+// executeJob 执行一次作业，失败时按退避时间放回队列，由分发循环再次调度
+// 作业上下文不继承编排器上下文，停止编排器时等待运行中的作业自然结束
 func (o *Orchestrator) executeJob(job *Job) {
 	defer func() {
 		if r := recover(); r != nil {
 			klog.Errorf("Task panic recovered: taskID=%d, err=%v", job.TaskID, r)
 			o.unregisterCancel(job.TaskID)
+			o.removeJob(job)
 		}
 	}()
 
@@ -359,7 +463,7 @@ func (o *Orchestrator) executeJob(job *Job) {
 	if timeout <= 0 {
 		timeout = 30 * time.Minute
 	}
-	ctx, cancel := context.WithTimeout(o.ctx, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	runCtx, manualCancel := context.WithCancel(ctx)
 	defer manualCancel()
@@ -367,110 +471,89 @@ func (o *Orchestrator) executeJob(job *Job) {
 	o.registerCancel(job.TaskID, manualCancel)
 	defer o.unregisterCancel(job.TaskID)
 
-	for i := job.RetryCount; i < job.MaxRetries; i++ {
-		job.RetryCount = i // 每次尝试前更新 RetryCount
-
-		err := o.executor.ExecuteTask(runCtx, job.TaskID)
-		if err == nil {
-			klog.V(6).Infof("Task completed: taskID=%d", job.TaskID)
-			return
-		}
-
-		backoff := time.Second << i
-		if backoff > 20*time.Minute {
-			backoff = 20 * time.Minute
-		}
-
-		klog.Warningf("任务重试失败: taskID=%d, retry=%d/%d, err=%v, backoff=%v",
-			job.TaskID, i+1, job.MaxRetries, err, backoff)
+	err := o.executor.ExecuteTask(runCtx, job.TaskID)
+	if err == nil {
+		klog.V(6).Infof("Task completed: taskID=%d", job.TaskID)
+		o.removeJob(job)
+		return
+	}
 
-		select {
-		case <-runCtx.Done():
-			klog.Warningf("任务被取消或超时: taskID=%d", job.TaskID)
-			return
-		case <-time.After(backoff):
-		}
+	if runCtx.Err() != nil {
+		klog.Warningf("任务被取消或超时: taskID=%d", job.TaskID)
+		o.removeJob(job)
+		return
 	}
 
-	klog.Errorf("任务执行失败且超过重试上限: taskID=%d", job.TaskID)
-}
+	job.RetryCount++
+	if job.RetryCount >= job.MaxRetries {
+		klog.Errorf("任务执行失败且超过重试上限: taskID=%d", job.TaskID)
+		o.removeJob(job)
+		return
+	}
 
-// -----------------------------
-// Queue Status
-// -----------------------------
-type QueueStatus struct {
-	QueueLength   int `json:"queue_length"`
-	ActiveWorkers int `json:"active_workers"`
-	ActiveRepos   int `json:"active_repos"`
+	backoff := retryBackoff(job.RetryCount)
+	klog.Warningf("任务重试失败: taskID=%d, retry=%d/%d, err=%v, backoff=%v",
+		job.TaskID, job.RetryCount, job.MaxRetries, err, backoff)
+	o.delayJob(job, backoff)
 }
 
-func (o *Orchestrator) GetQueueStatus() *QueueStatus {
-	return &QueueStatus{
-		QueueLength:   o.jobQueue.Len(),
-		ActiveWorkers: o.pool.Running(),
+// retryBackoff 第 n 次重试前的退避时间：1s、2s、4s……最长20分钟
+func retryBackoff(retry int) time.Duration {
+	if retry <= 0 {
+		return 0
 	}
+	if retry > 11 {
+		return 20 * time.Minute
+	}
+	backoff := time.Second << (retry - 1)
+	if backoff > 20*time.Minute {
+		backoff = 20 * time.Minute
+	}
+	return backoff
 }
 
-// -----------------------------
-// JobQueue (Ring Buffer) + Reject New
-// -----------------------------
-type jobQueue struct {
-	maxSize int
-	items   []*Job
-	mutex   sync.Mutex
-	cond    *sync.Cond
-	closed  bool
-}
-
-func newJobQueue(maxSize int) *jobQueue {
-	q := &jobQueue{
-		maxSize: maxSize,
-		items:   make([]*Job, 0, maxSize),
-	}
-	q.cond = sync.NewCond(&q.mutex)
-	return q
+// dependencyBackoff 依赖未满足时的延后时间，取已排队时长的一半：
+// 上游执行越久检查间隔越长（按 1.5 倍递增），避免长时间运行的上游导致下游作业被频繁领取与放回
+func dependencyBackoff(job *Job, now time.Time) time.Duration {
+	delay := now.Sub(job.EnqueuedAt) / 2
+	return min(max(delay, dependencyRetryDelay), dependencyRetryMaxDelay)
 }
 
-func (q *jobQueue) Enqueue(job *Job) error {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	if q.closed {
-		return ErrOrchestratorStopped
+// delayJob 将作业放回队列，delay 后才可再次分发
+func (o *Orchestrator) delayJob(job *Job, delay time.Duration) {
+	job.NextRunAt = time.Now().Add(delay)
+	if err := o.store.Requeue(job); err != nil {
+		klog.Errorf("作业重新入队失败: taskID=%d, err=%v", job.TaskID, err)
 	}
-	if q.maxSize > 0 && len(q.items) >= q.maxSize {
-		return ErrQueueFull // Reject New
-	}
-	q.items = append(q.items, job)
-	q.cond.Signal()
-	return nil
 }
 
-func (q *jobQueue) Dequeue() (*Job, bool) {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	for len(q.items) == 0 && !q.closed {
-		q.cond.Wait()
+func (o *Orchestrator) removeJob(job *Job) {
+	if err := o.store.Remove(job.TaskID); err != nil {
+		klog.Errorf("移除作业失败: taskID=%d, err=%v", job.TaskID, err)
 	}
-	if len(q.items) == 0 {
-		return nil, false
-	}
-	job := q.items[0]
-	q.items[0] = nil
-	q.items = q.items[1:]
-	return job, true
 }
 
-func (q *jobQueue) Len() int {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	return len(q.items)
+// -----------------------------
+// Queue Status
+// -----------------------------
+type QueueStatus struct {
+	QueueLength   int               `json:"queue_length"`
+	ActiveWorkers int               `json:"active_workers"`
+	ActiveRepos   int               `json:"active_repos"`
+	Repos         []RepoQueueStatus `json:"repos"`
 }
 
-func (q *jobQueue) Close() {
-	q.mutex.Lock()
-	q.closed = true
-	q.cond.Broadcast()
-	q.mutex.Unlock()
+func (o *Orchestrator) GetQueueStatus() *QueueStatus {
+	queued, err := o.store.QueuedByRepository()
+	if err != nil {
+		klog.Errorf("统计仓库排队作业失败: %v", err)
+	}
+	return &QueueStatus{
+		QueueLength:   o.store.Len(),
+		ActiveWorkers: o.pool.Running(),
+		ActiveRepos:   o.repos.activeRepos(),
+		Repos:         o.repos.snapshot(queued),
+	}
 }
 
 // -------------------- Global Orchestrator --------------------
@@ -479,21 +562,30 @@ var (
 	orchestratorOnce   sync.Once
 )
 
-func InitGlobalOrchestrator(maxWorkers int, executor TaskExecutor) error {
+// InitGlobalOrchestrator 初始化全局编排器，需调用 StartGlobalOrchestrator 后才开始分发
+// 两步启动便于在分发前完成重启遗留任务的状态恢复
+func InitGlobalOrchestrator(maxWorkers int, executor TaskExecutor, store JobStore) error {
 	var initErr error
 	orchestratorOnce.Do(func() {
-		orch, err := NewOrchestrator(maxWorkers, executor)
+		orch, err := NewOrchestrator(maxWorkers, executor, store)
 		if err != nil {
 			initErr = err
 			return
 		}
 		globalOrchestrator = orch
-		globalOrchestrator.Start()
 		klog.V(6).Infof("Global orchestrator initialized: maxWorkers=%d", maxWorkers)
 	})
 	return initErr
 }
 
+// StartGlobalOrchestrator 启动全局编排器的分发循环
+func StartGlobalOrchestrator() {
+	if globalOrchestrator != nil {
+		globalOrchestrator.Start()
+		klog.V(6).Infof("Global orchestrator started")
+	}
+}
+
 func GetGlobalOrchestrator() *Orchestrator {
 	return globalOrchestrator
 }
@@ -2,10 +2,15 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"gorm.io/gorm"
 )
 
@@ -24,16 +29,21 @@ type fakeDependencyChecker struct {
 	runAfterID     uint
 	runAfterStatus string
 	err            error
+	blocked        []uint
 }
 
-func (f *fakeDependencyChecker) CheckRunAfterSatisfied(taskID uint) (bool, uint, string, error) {
+func (f *fakeDependencyChecker) CheckDependenciesSatisfied(taskID uint) (bool, uint, string, error) {
 	return f.allowed, f.runAfterID, f.runAfterStatus, f.err
 }
 
+func (f *fakeDependencyChecker) BlockTask(taskID uint, dependsOn uint) error {
+	f.blocked = append(f.blocked, taskID)
+	return nil
+}
+
 func TestTryDispatchRepoLockedMaxRetries(t *testing.T) {
 	executor := &fakeExecutor{}
-	o, _ := NewOrchestrator(1, executor)
-	o.retryTicker.Stop()
+	o, _ := NewOrchestrator(1, executor, nil)
 	defer o.pool.Release()
 
 	job := &Job{
@@ -43,10 +53,11 @@ func TestTryDispatchRepoLockedMaxRetries(t *testing.T) {
 		Timeout:    10 * time.Millisecond,
 	}
 
+	_ = o.store.Push(job)
 	o.tryDispatch(job)
 
-	if got := o.retryQueue.Len(); got != 0 {
-		t.Fatalf("retry queue should be empty, got %d", got)
+	if ok, _ := o.store.Has(job.TaskID); ok {
+		t.Fatalf("job should be removed from store")
 	}
 	if atomic.LoadInt32(&executor.calls) != 0 {
 		t.Fatalf("executor should not be called, got %d", executor.calls)
@@ -58,8 +69,7 @@ func TestTryDispatchRepoLockedMaxRetries(t *testing.T) {
 
 func TestTryDispatchRepoLockedEnqueueRetry(t *testing.T) {
 	executor := &fakeExecutor{}
-	o, _ := NewOrchestrator(1, executor)
-	o.retryTicker.Stop()
+	o, _ := NewOrchestrator(1, executor, nil)
 	defer o.pool.Release()
 
 	job := &Job{
@@ -78,7 +88,7 @@ func TestTryDispatchRepoLockedEnqueueRetry(t *testing.T) {
 		}
 		time.Sleep(5 * time.Millisecond)
 	}
-	if got := o.retryQueue.Len(); got != 0 {
+	if got := o.store.Len(); got != 0 {
 		t.Fatalf("retry queue should be empty, got %d", got)
 	}
 	if atomic.LoadInt32(&executor.calls) != 1 {
@@ -88,8 +98,7 @@ func TestTryDispatchRepoLockedEnqueueRetry(t *testing.T) {
 
 func TestExecuteJobStopsOnTimeout(t *testing.T) {
 	executor := &fakeExecutor{err: context.DeadlineExceeded}
-	o, _ := NewOrchestrator(1, executor)
-	o.retryTicker.Stop()
+	o, _ := NewOrchestrator(1, executor, nil)
 	defer o.pool.Release()
 
 	job := &Job{
@@ -114,8 +123,7 @@ func TestExecuteJobStopsOnTimeout(t *testing.T) {
 // TestTryDispatchWithRunAfterNotSatisfied 验证依赖未满足时不会分发任务
 func TestTryDispatchWithRunAfterNotSatisfied(t *testing.T) {
 	executor := &fakeExecutor{}
-	o, _ := NewOrchestrator(1, executor)
-	o.retryTicker.Stop()
+	o, _ := NewOrchestrator(1, executor, nil)
 	defer o.pool.Release()
 
 	o.SetDependencyChecker(&fakeDependencyChecker{
@@ -133,7 +141,7 @@ func TestTryDispatchWithRunAfterNotSatisfied(t *testing.T) {
 
 	o.tryDispatch(job)
 
-	if got := o.retryQueue.Len(); got != 1 {
+	if got := o.store.Len(); got != 1 {
 		t.Fatalf("retry queue should be 1, got %d", got)
 	}
 	if atomic.LoadInt32(&executor.calls) != 0 {
@@ -141,10 +149,27 @@ func TestTryDispatchWithRunAfterNotSatisfied(t *testing.T) {
 	}
 }
 
+// TestDependencyBackoff 验证依赖等待的检查间隔随排队时长增长并有上下限
+func TestDependencyBackoff(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		waited time.Duration
+		want   time.Duration
+	}{
+		{0, dependencyRetryDelay},
+		{10 * time.Second, 5 * time.Second},
+		{time.Hour, dependencyRetryMaxDelay},
+	}
+	for _, tc := range cases {
+		if got := dependencyBackoff(&Job{EnqueuedAt: now.Add(-tc.waited)}, now); got != tc.want {
+			t.Errorf("dependencyBackoff(waited=%s) = %s, want %s", tc.waited, got, tc.want)
+		}
+	}
+}
+
 func TestTryDispatchWithRunAfterTaskNotFound(t *testing.T) {
 	executor := &fakeExecutor{}
-	o, _ := NewOrchestrator(1, executor)
-	o.retryTicker.Stop()
+	o, _ := NewOrchestrator(1, executor, nil)
 	defer o.pool.Release()
 
 	o.SetDependencyChecker(&fakeDependencyChecker{
@@ -163,10 +188,205 @@ func TestTryDispatchWithRunAfterTaskNotFound(t *testing.T) {
 
 	o.tryDispatch(job)
 
-	if got := o.retryQueue.Len(); got != 0 {
+	if got := o.store.Len(); got != 0 {
 		t.Fatalf("retry queue should be empty, got %d", got)
 	}
 	if atomic.LoadInt32(&executor.calls) != 0 {
 		t.Fatalf("executor should not be called, got %d", executor.calls)
 	}
 }
+
+// TestExecuteJobFailureRequeuesWithBackoff 验证执行失败后作业带退避时间放回队列
+func TestExecuteJobFailureRequeuesWithBackoff(t *testing.T) {
+	executor := &fakeExecutor{err: errors.New("boom")}
+	o, _ := NewOrchestrator(1, executor, nil)
+	defer o.pool.Release()
+
+	job := NewTaskJob(6, 1)
+	_ = o.store.Push(job)
+	if ok, _ := o.store.Claim(job); !ok {
+		t.Fatalf("claim should succeed")
+	}
+
+	o.executeJob(job)
+
+	if job.RetryCount != 1 {
+		t.Fatalf("retry count should be 1, got %d", job.RetryCount)
+	}
+	if !job.NextRunAt.After(time.Now()) {
+		t.Fatalf("next run should be delayed, got %v", job.NextRunAt)
+	}
+	if got := o.store.Len(); got != 1 {
+		t.Fatalf("job should be waiting in store, got %d", got)
+	}
+	ready, _ := o.store.Ready(time.Now(), 0, nil)
+	if len(ready) != 0 {
+		t.Fatalf("job should not be ready before backoff, got %d", len(ready))
+	}
+}
+
+// TestDBJobStoreSurvivesRestart 验证持久化队列在编排器重建后恢复作业与重试信息
+func TestDBJobStoreSurvivesRestart(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TaskJob{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	store := NewDBJobStore(repository.NewTaskJobRepository(db))
+
+	first, _ := NewOrchestrator(1, &fakeExecutor{}, store)
+	if err := first.EnqueueJob(NewTaskJob(7, 1)); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	if err := first.EnqueueJob(NewTaskJob(8, 1)); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	ready, _ := store.Ready(time.Now(), 1, nil)
+	if len(ready) != 1 || ready[0].TaskID != 7 {
+		t.Fatalf("expected task 7 first, got %+v", ready)
+	}
+	if ok, _ := store.Claim(ready[0]); !ok {
+		t.Fatalf("claim should succeed")
+	}
+	ready[0].RetryCount = 2
+	first.delayJob(ready[0], time.Hour)
+	if ok, _ := store.Claim(ready[0]); !ok {
+		t.Fatalf("claim should succeed")
+	}
+	first.pool.Release()
+
+	// 模拟进程重启：执行中的作业恢复为等待，重试次数与退避时间保留
+	second, err := NewOrchestrator(1, &fakeExecutor{}, store)
+	if err != nil {
+		t.Fatalf("new orchestrator error: %v", err)
+	}
+	defer second.pool.Release()
+
+	if got := second.GetQueueStatus().QueueLength; got != 2 {
+		t.Fatalf("queue length should be 2, got %d", got)
+	}
+	ready, _ = store.Ready(time.Now(), 0, nil)
+	if len(ready) != 1 || ready[0].TaskID != 8 {
+		t.Fatalf("only task 8 should be ready, got %+v", ready)
+	}
+	delayed, _ := store.Ready(time.Now().Add(2*time.Hour), 0, nil)
+	for _, job := range delayed {
+		if job.TaskID == 7 && job.RetryCount != 2 {
+			t.Fatalf("retry count should survive restart, got %d", job.RetryCount)
+		}
+	}
+	if !second.HasJob(7) {
+		t.Fatalf("task 7 should still have a job")
+	}
+}
+
+// TestDispatchSkipsCappedRepoBacklog 验证已达并发上限的仓库积压超过扫描窗口时，其他仓库的作业仍能分发
+func TestDispatchSkipsCappedRepoBacklog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TaskJob{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	executor := &fakeExecutor{}
+	o, _ := NewOrchestrator(4, executor, NewDBJobStore(repository.NewTaskJobRepository(db)))
+	defer o.pool.Release()
+	o.SetRepoPolicy(RepoPolicy{MaxPerRepo: 1})
+
+	jobs := make([]*Job, 0, readyScanLimit+1)
+	for i := 1; i <= readyScanLimit+1; i++ {
+		jobs = append(jobs, NewTaskJob(uint(i), 1))
+	}
+	if err := o.EnqueueBatch(jobs); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	if err := o.EnqueueJob(NewTaskJob(9999, 2)); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	// 仓库 1 已有一个作业在运行
+	if err := o.repos.acquire(1); err != nil {
+		t.Fatalf("acquire error: %v", err)
+	}
+
+	o.dispatchReady()
+	deadline := time.Now().Add(time.Second)
+	for o.HasJob(9999) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if o.HasJob(9999) {
+		t.Fatalf("job of repository 2 should be dispatched")
+	}
+	if got := atomic.LoadInt32(&executor.calls); got != 1 {
+		t.Fatalf("only the job of repository 2 should run, got %d calls", got)
+	}
+}
+
+// TestReadyScanIncludesInteractiveBehindBacklog 验证后台作业积压超过扫描上限时，新入队的交互作业仍能被优先选中
+func TestReadyScanIncludesInteractiveBehindBacklog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TaskJob{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	o, _ := NewOrchestrator(1, &fakeExecutor{}, NewDBJobStore(repository.NewTaskJobRepository(db)))
+	defer o.pool.Release()
+
+	enqueuedAt := time.Now().Add(-time.Minute)
+	jobs := make([]*Job, 0, readyScanLimit+10)
+	for i := 1; i <= readyScanLimit+10; i++ {
+		job := NewTaskJob(uint(i), uint(i))
+		job.Priority = domain.PriorityBackground
+		job.EnqueuedAt, job.NextRunAt = enqueuedAt, enqueuedAt
+		jobs = append(jobs, job)
+	}
+	if err := o.EnqueueBatch(jobs); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	interactive := NewTaskJob(9999, 9999)
+	interactive.Priority = domain.PriorityInteractive
+	if err := o.EnqueueJob(interactive); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+
+	now := time.Now()
+	ready, err := o.store.Ready(now, readyScanLimit, nil)
+	if err != nil {
+		t.Fatalf("ready error: %v", err)
+	}
+	if job := o.repos.pick(ready, now); job == nil || job.TaskID != 9999 {
+		t.Fatalf("interactive job should be picked first, got %+v", job)
+	}
+}
+
+// TestTryDispatchWithDependencyFailed 验证上游永久失败时作业移出队列并交由任务服务阻塞，而不是反复延后
+func TestTryDispatchWithDependencyFailed(t *testing.T) {
+	executor := &fakeExecutor{}
+	o, _ := NewOrchestrator(1, executor, nil)
+	defer o.pool.Release()
+
+	checker := &fakeDependencyChecker{
+		runAfterID:     10,
+		runAfterStatus: "failed",
+		err:            ErrDependencyFailed,
+	}
+	o.SetDependencyChecker(checker)
+
+	job := NewTaskJob(6, 1)
+	_ = o.store.Push(job)
+	o.tryDispatch(job)
+
+	if got := o.store.Len(); got != 0 {
+		t.Fatalf("job should be removed from queue, got %d", got)
+	}
+	if len(checker.blocked) != 1 || checker.blocked[0] != 6 {
+		t.Fatalf("task 6 should be blocked, got %v", checker.blocked)
+	}
+	if atomic.LoadInt32(&executor.calls) != 0 {
+		t.Fatalf("executor should not be called, got %d", executor.calls)
+	}
+}
@@ -0,0 +1,217 @@
+package orchestrator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+)
+
+// RepoPolicy 仓库级调度策略
+type RepoPolicy struct {
+	MaxPerRepo  int          // 默认单仓库并发上限，<=0 表示不限制
+	RepoLimits  map[uint]int // 指定仓库的并发上限，覆盖 MaxPerRepo
+	RepoWeights map[uint]int // 指定仓库的调度权重，默认 1
+}
+
+// RepoQueueStatus 单个仓库的队列状态
+type RepoQueueStatus struct {
+	RepositoryID uint `json:"repository_id"`
+	Active       int  `json:"active"`
+	Queued       int  `json:"queued"`
+	Limit        int  `json:"limit"`
+	Weight       int  `json:"weight"`
+}
+
+// repoScheduler 按优先级通道与仓库选择下一个作业
+// 先取最高优先级通道（等待越久通道越高，防止饿死），通道内使用平滑加权轮询：
+// 同一时刻有多个仓库排队时，按权重交替分发，避免单个仓库占满全部 worker
+type repoScheduler struct {
+	policy    RepoPolicy
+	laneAging time.Duration
+	active    map[uint]int
+	current   map[uint]int
+	mutex     sync.Mutex
+}
+
+func newRepoScheduler(laneAging time.Duration) *repoScheduler {
+	return &repoScheduler{
+		laneAging: laneAging,
+		active:    make(map[uint]int),
+		current:   make(map[uint]int),
+	}
+}
+
+func (s *repoScheduler) setLaneAging(aging time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.laneAging = aging
+}
+
+// laneLocked 计算作业当前所在通道：每等待 laneAging 提升一个通道
+func (s *repoScheduler) laneLocked(job *Job, now time.Time) int {
+	lane := job.Priority.Rank()
+	if s.laneAging > 0 && !job.EnqueuedAt.IsZero() {
+		lane -= int(now.Sub(job.EnqueuedAt) / s.laneAging)
+	}
+	if lane < domain.PriorityInteractive.Rank() {
+		lane = domain.PriorityInteractive.Rank()
+	}
+	return lane
+}
+
+func (s *repoScheduler) setPolicy(policy RepoPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.policy = policy
+}
+
+func (s *repoScheduler) limitLocked(repoID uint) int {
+	if limit, ok := s.policy.RepoLimits[repoID]; ok {
+		return limit
+	}
+	return s.policy.MaxPerRepo
+}
+
+func (s *repoScheduler) weightLocked(repoID uint) int {
+	if weight, ok := s.policy.RepoWeights[repoID]; ok && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+func (s *repoScheduler) availableLocked(repoID uint) bool {
+	limit := s.limitLocked(repoID)
+	return limit <= 0 || s.active[repoID] < limit
+}
+
+// cappedRepos 返回已达到并发上限的仓库
+func (s *repoScheduler) cappedRepos() []uint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var capped []uint
+	for repoID := range s.active {
+		if !s.availableLocked(repoID) {
+			capped = append(capped, repoID)
+		}
+	}
+	return capped
+}
+
+// pick 从到期作业中选出下一个要分发的作业
+// jobs 需按入队先后排序；返回 nil 表示所有排队仓库都已达到并发上限
+func (s *repoScheduler) pick(jobs []*Job, now time.Time) *Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 只在可分发仓库的作业中选出最高通道
+	bestLane := -1
+	candidates := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		if !s.availableLocked(job.RepositoryID) {
+			continue
+		}
+		lane := s.laneLocked(job, now)
+		if bestLane < 0 || lane < bestLane {
+			bestLane = lane
+			candidates = candidates[:0]
+		}
+		if lane == bestLane {
+			candidates = append(candidates, job)
+		}
+	}
+
+	// 每个仓库只取最早的作业，仓库顺序按其最早作业排列
+	heads := make(map[uint]*Job)
+	order := make([]uint, 0)
+	for _, job := range candidates {
+		if _, ok := heads[job.RepositoryID]; ok {
+			continue
+		}
+		heads[job.RepositoryID] = job
+		order = append(order, job.RepositoryID)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	// 不再排队的仓库清除累积权重，避免空闲后重新出现时一次性抢占
+	for repoID := range s.current {
+		if _, ok := heads[repoID]; !ok {
+			delete(s.current, repoID)
+		}
+	}
+
+	total := 0
+	var selected uint
+	selectedWeight := 0
+	for i, repoID := range order {
+		weight := s.weightLocked(repoID)
+		total += weight
+		s.current[repoID] += weight
+		if i == 0 || s.current[repoID] > selectedWeight {
+			selected = repoID
+			selectedWeight = s.current[repoID]
+		}
+	}
+	s.current[selected] -= total
+	return heads[selected]
+}
+
+// acquire 占用仓库的一个并发名额，已达上限时返回 ErrRepoLocked
+func (s *repoScheduler) acquire(repoID uint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.availableLocked(repoID) {
+		return ErrRepoLocked
+	}
+	s.active[repoID]++
+	return nil
+}
+
+// release 释放仓库的并发名额
+func (s *repoScheduler) release(repoID uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.active[repoID] <= 1 {
+		delete(s.active, repoID)
+		return
+	}
+	s.active[repoID]--
+}
+
+// snapshot 合并运行中与排队中的数量，生成按仓库ID排序的状态列表
+func (s *repoScheduler) snapshot(queued map[uint]int) []RepoQueueStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	repoIDs := make(map[uint]struct{})
+	for repoID := range s.active {
+		repoIDs[repoID] = struct{}{}
+	}
+	for repoID := range queued {
+		repoIDs[repoID] = struct{}{}
+	}
+
+	statuses := make([]RepoQueueStatus, 0, len(repoIDs))
+	for repoID := range repoIDs {
+		statuses = append(statuses, RepoQueueStatus{
+			RepositoryID: repoID,
+			Active:       s.active[repoID],
+			Queued:       queued[repoID],
+			Limit:        s.limitLocked(repoID),
+			Weight:       s.weightLocked(repoID),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].RepositoryID < statuses[j].RepositoryID
+	})
+	return statuses
+}
+
+func (s *repoScheduler) activeRepos() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.active)
+}
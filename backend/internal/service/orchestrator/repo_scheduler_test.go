@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+)
+
+func jobsOf(repoIDs ...uint) []*Job {
+	jobs := make([]*Job, 0, len(repoIDs))
+	for i, repoID := range repoIDs {
+		jobs = append(jobs, &Job{TaskID: uint(i + 1), RepositoryID: repoID})
+	}
+	return jobs
+}
+
+// TestRepoSchedulerRoundRobin 验证多个仓库排队时轮流分发
+func TestRepoSchedulerRoundRobin(t *testing.T) {
+	s := newRepoScheduler(0)
+
+	var picked []uint
+	queue := jobsOf(1, 1, 1, 1, 2)
+	for len(queue) > 0 {
+		job := s.pick(queue, time.Now())
+		picked = append(picked, job.RepositoryID)
+		for i := range queue {
+			if queue[i] == job {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if picked[0] != 1 || picked[1] != 2 {
+		t.Fatalf("repo 2 should be served second, got %v", picked)
+	}
+}
+
+// TestRepoSchedulerWeights 验证权重高的仓库获得更多分发机会
+func TestRepoSchedulerWeights(t *testing.T) {
+	s := newRepoScheduler(0)
+	s.setPolicy(RepoPolicy{RepoWeights: map[uint]int{1: 3}})
+
+	counts := map[uint]int{}
+	jobs := jobsOf(1, 2)
+	for range 8 {
+		counts[s.pick(jobs, time.Now()).RepositoryID]++
+	}
+
+	if counts[1] != 6 || counts[2] != 2 {
+		t.Fatalf("expected 6:2 split, got %v", counts)
+	}
+}
+
+// TestRepoSchedulerLimit 验证仓库达到并发上限后被跳过
+func TestRepoSchedulerLimit(t *testing.T) {
+	s := newRepoScheduler(0)
+	s.setPolicy(RepoPolicy{MaxPerRepo: 1, RepoLimits: map[uint]int{2: 2}})
+
+	if err := s.acquire(1); err != nil {
+		t.Fatalf("acquire repo 1 error: %v", err)
+	}
+	if err := s.acquire(1); !errors.Is(err, ErrRepoLocked) {
+		t.Fatalf("expected ErrRepoLocked, got %v", err)
+	}
+	if job := s.pick(jobsOf(1, 1), time.Now()); job != nil {
+		t.Fatalf("repo 1 is at limit, got job %+v", job)
+	}
+	if job := s.pick(jobsOf(1, 2), time.Now()); job == nil || job.RepositoryID != 2 {
+		t.Fatalf("repo 2 should be picked, got %+v", job)
+	}
+
+	_ = s.acquire(2)
+	status := s.snapshot(map[uint]int{1: 3, 3: 1})
+	if len(status) != 3 {
+		t.Fatalf("expected 3 repos in snapshot, got %d", len(status))
+	}
+	if status[0].Active != 1 || status[0].Queued != 3 || status[0].Limit != 1 {
+		t.Fatalf("unexpected repo 1 status: %+v", status[0])
+	}
+	if status[1].Limit != 2 {
+		t.Fatalf("repo 2 limit should be 2, got %d", status[1].Limit)
+	}
+
+	s.release(1)
+	if got := s.activeRepos(); got != 1 {
+		t.Fatalf("expected 1 active repo, got %d", got)
+	}
+}
+
+// TestRepoSchedulerPriorityLanes 验证交互任务优先分发，后台任务等待足够久后提升通道
+func TestRepoSchedulerPriorityLanes(t *testing.T) {
+	s := newRepoScheduler(10 * time.Minute)
+	now := time.Now()
+
+	jobs := []*Job{
+		{TaskID: 1, RepositoryID: 1, Priority: domain.PriorityBackground, EnqueuedAt: now},
+		{TaskID: 2, RepositoryID: 1, Priority: domain.PriorityNormal, EnqueuedAt: now},
+		{TaskID: 3, RepositoryID: 2, Priority: domain.PriorityInteractive, EnqueuedAt: now},
+	}
+	if job := s.pick(jobs, now); job.TaskID != 3 {
+		t.Fatalf("interactive job should be picked first, got %d", job.TaskID)
+	}
+	if job := s.pick(jobs[:2], now); job.TaskID != 2 {
+		t.Fatalf("normal job should be picked before background, got %d", job.TaskID)
+	}
+
+	// 后台任务等待 20 分钟后提升两个通道，与刚入队的交互任务同通道，按入队顺序先分发
+	starving := []*Job{
+		{TaskID: 4, RepositoryID: 1, Priority: domain.PriorityBackground, EnqueuedAt: now.Add(-20 * time.Minute)},
+		{TaskID: 5, RepositoryID: 1, Priority: domain.PriorityInteractive, EnqueuedAt: now},
+	}
+	if job := s.pick(starving, now); job.TaskID != 4 {
+		t.Fatalf("aged background job should not starve, got %d", job.TaskID)
+	}
+}
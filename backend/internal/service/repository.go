@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
 
 	"github.com/weibaohui/opendeepwiki/backend/config"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service/orchestrator"
@@ -24,6 +27,7 @@ type RepositoryService struct {
 	docRepo               repository.DocumentRepository
 	taskHintRepo          repository.HintRepository
 	incrementalHistoryRepo repository.IncrementalUpdateHistoryRepository
+	dependencyRepo        repository.TaskDependencyRepository
 
 	// 状态机
 	repoStateMachine *statemachine.RepositoryStateMachine
@@ -31,6 +35,18 @@ type RepositoryService struct {
 
 	// 编排器
 	orchestrator *orchestrator.Orchestrator
+
+	// 私有仓库凭证，可为空
+	credentials *GitCredentialService
+
+	// 引用文档集仓储，可为空
+	refRepo repository.RepositoryRefRepository
+
+	// Webhook 配置仓储，可为空
+	webhookRepo repository.RepositoryWebhookRepository
+
+	// 正在重新导入代码的仓库，避免并发上传同时改写仓库目录
+	reimporting sync.Map
 }
 
 // NewRepositoryService 创建仓库服务实例。
@@ -51,23 +67,65 @@ func NewRepositoryService(cfg *config.Config, repoRepo repository.RepoRepository
 
 type CreateRepoRequest struct {
 	URL string `json:"url" binding:"required"`
+	// CredentialID 克隆使用的 Git 凭证，为空时按主机与组织自动匹配
+	CredentialID *uint `json:"credential_id"`
+	// Branch、Tag、Commit 至多指定一个，都为空时克隆远端默认分支
+	Branch string `json:"branch"`
+	Tag    string `json:"tag"`
+	Commit string `json:"commit"`
+}
+
+// ref 返回请求指定的克隆引用
+func (r CreateRepoRequest) ref() (git.Ref, error) {
+	return parseGitRef(r.Branch, r.Tag, r.Commit)
+}
+
+// parseGitRef 解析至多指定一个的分支、标签或提交，都为空时返回默认分支
+func parseGitRef(branch, tag, commit string) (git.Ref, error) {
+	var refs []git.Ref
+	for _, ref := range []git.Ref{
+		{Type: git.RefBranch, Name: strings.TrimSpace(branch)},
+		{Type: git.RefTag, Name: strings.TrimSpace(tag)},
+		{Type: git.RefCommit, Name: strings.TrimSpace(commit)},
+	} {
+		if ref.Name != "" {
+			refs = append(refs, ref)
+		}
+	}
+	switch len(refs) {
+	case 0:
+		return git.Ref{}, nil
+	case 1:
+		if err := refs[0].Validate(); err != nil {
+			return git.Ref{}, fmt.Errorf("%w: %s %q", ErrInvalidRepositoryRef, refs[0].Type, refs[0].Name)
+		}
+		return refs[0], nil
+	default:
+		return git.Ref{}, fmt.Errorf("%w: branch, tag and commit are mutually exclusive", ErrInvalidRepositoryRef)
+	}
 }
 
 var (
 	ErrInvalidRepositoryURL          = errors.New("invalid repository url")
+	ErrInvalidRepositoryRef          = errors.New("invalid repository ref")
 	ErrRepositoryAlreadyExists       = errors.New("repository already exists")
 	ErrCannotDeleteRepoInvalidStatus = errors.New("无法删除仓库：已完成或正在分析中的仓库不能删除")
 )
 
 // Create 创建仓库并初始化任务
-func (s *RepositoryService) Create(req CreateRepoRequest) (*model.Repository, error) {
+// 同一工作空间内按仓库地址与引用去重，同一仓库的不同分支或标签可以分别添加，不同工作空间可以添加同一仓库
+func (s *RepositoryService) Create(ctx context.Context, req CreateRepoRequest) (*model.Repository, error) {
 	normalizedURL, repoKey, err := git.NormalizeRepoURL(req.URL)
 	if err != nil {
 		klog.V(6).Infof("仓库URL校验失败: url=%s, error=%v", req.URL, err)
 		return nil, ErrInvalidRepositoryURL
 	}
+	ref, err := req.ref()
+	if err != nil {
+		return nil, err
+	}
 
-	existingRepos, err := s.repoRepo.List()
+	existingRepos, err := s.repoRepo.List(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("获取仓库列表失败: %w", err)
 	}
@@ -77,25 +135,49 @@ func (s *RepositoryService) Create(req CreateRepoRequest) (*model.Repository, er
 			klog.V(6).Infof("已有仓库URL无法解析，跳过去重: repoID=%d, url=%s, error=%v", existing.ID, existing.URL, parseErr)
 			continue
 		}
-		if existingKey == repoKey {
-			klog.V(6).Infof("仓库已存在，拒绝重复添加: repoID=%d, url=%s", existing.ID, normalizedURL)
+		if existingKey == repoKey && git.NewRef(existing.CloneRefType, existing.CloneBranch) == ref {
+			klog.V(6).Infof("仓库已存在，拒绝重复添加: repoID=%d, url=%s, ref=%s %s", existing.ID, normalizedURL, ref.Type, ref.Name)
 			return nil, ErrRepositoryAlreadyExists
 		}
 	}
 
+	if req.CredentialID != nil {
+		if s.credentials == nil {
+			return nil, repository.ErrGitCredentialNotFound
+		}
+		if err := s.credentials.Validate(ctx, *req.CredentialID, normalizedURL); err != nil {
+			return nil, err
+		}
+	}
+
 	// 生成仓库名称和本地路径
 	repoName := git.ParseRepoName(normalizedURL)
-	localPath := filepath.Join(s.cfg.Data.RepoDir, repoName+"-"+fmt.Sprintf("%d", time.Now().Unix()))
+	localPath := s.newLocalPath(repoName)
 
 	// 创建仓库（初始状态为pending）
 	repo := &model.Repository{
-		Name:      repoName,
-		URL:       normalizedURL,
-		LocalPath: localPath,
-		Status:    string(statemachine.RepoStatusPending),
+		Name:         repoName,
+		URL:          normalizedURL,
+		LocalPath:    localPath,
+		Status:       string(statemachine.RepoStatusPending),
+		CredentialID: req.CredentialID,
+		CloneRefType: ref.Type,
+		CloneBranch:  ref.Name,
+	}
+
+	s.initActivity(repo)
+
+	if err := s.repoRepo.Create(ctx, repo); err != nil {
+		return nil, fmt.Errorf("创建仓库失败: %w", err)
 	}
 
-	// 初始化活跃度信息
+	klog.V(6).Infof("仓库创建成功: repoID=%d, name=%s, url=%s, ref=%s %s", repo.ID, repo.Name, repo.URL, ref.Type, ref.Name)
+
+	return repo, nil
+}
+
+// initActivity 初始化活跃度信息
+func (s *RepositoryService) initActivity(repo *model.Repository) {
 	if s.cfg.Activity.Enabled {
 		now := time.Now()
 		nextUpdateTime := now.Add(s.cfg.Activity.DefaultInterval)
@@ -103,30 +185,22 @@ func (s *RepositoryService) Create(req CreateRepoRequest) (*model.Repository, er
 		repo.TodayActivityCount = 0
 		repo.LastActivityResetDate = &now
 	}
-
-	if err := s.repoRepo.Create(repo); err != nil {
-		return nil, fmt.Errorf("创建仓库失败: %w", err)
-	}
-
-	klog.V(6).Infof("仓库创建成功: repoID=%d, name=%s, url=%s", repo.ID, repo.Name, repo.URL)
-
-	return repo, nil
 }
 
-// List 获取所有仓库
-func (s *RepositoryService) List() ([]model.Repository, error) {
-	return s.repoRepo.List()
+// List 获取当前工作空间的所有仓库
+func (s *RepositoryService) List(ctx context.Context) ([]model.Repository, error) {
+	return s.repoRepo.List(ctx)
 }
 
 // Get 获取单个仓库（包含任务和文档）
-func (s *RepositoryService) Get(id uint) (*model.Repository, error) {
-	return s.repoRepo.Get(id)
+func (s *RepositoryService) Get(ctx context.Context, id uint) (*model.Repository, error) {
+	return s.repoRepo.Get(ctx, id)
 }
 
 // Delete 删除仓库
 func (s *RepositoryService) Delete(id uint) error {
 	// 获取仓库基本信息
-	repo, err := s.repoRepo.GetBasic(id)
+	repo, err := s.repoRepo.GetBasic(context.Background(), id)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -144,16 +218,49 @@ func (s *RepositoryService) Delete(id uint) error {
 		if err := git.RemoveRepo(repo.LocalPath); err != nil {
 			klog.Warningf("删除本地仓库失败: repoID=%d, error=%v", id, err)
 		}
+		codeindex.DefaultStore().Invalidate(repo.LocalPath)
+	}
+
+	// 删除上传时保存的压缩包
+	if repo.SourceType == model.RepositorySourceArchive && repo.SourcePath != "" {
+		if err := os.Remove(repo.SourcePath); err != nil && !os.IsNotExist(err) {
+			klog.Warningf("删除仓库压缩包失败: repoID=%d, error=%v", id, err)
+		}
+	}
+
+	// 删除引用文档集的本地检出与记录，其文档与任务随仓库一并删除
+	if s.refRepo != nil {
+		refs, err := s.refRepo.ListByRepository(context.Background(), id)
+		if err != nil {
+			return fmt.Errorf("获取引用文档集失败: %w", err)
+		}
+		for i := range refs {
+			removeRefCheckout(&refs[i])
+		}
+		if err := s.refRepo.DeleteByRepositoryID(context.Background(), id); err != nil {
+			return fmt.Errorf("删除引用文档集失败: %w", err)
+		}
+	}
+
+	if s.webhookRepo != nil {
+		if err := s.webhookRepo.DeleteByRepository(context.Background(), id); err != nil && !errors.Is(err, repository.ErrRepositoryWebhookNotFound) {
+			return fmt.Errorf("删除 Webhook 配置失败: %w", err)
+		}
 	}
 
 	// TODO 删除数据库记录（使用事务）
 	if err := s.docRepo.DeleteByRepositoryID(id); err != nil {
 		return fmt.Errorf("删除文档失败: %w", err)
 	}
+	if s.dependencyRepo != nil {
+		if err := s.dependencyRepo.DeleteByRepositoryID(id); err != nil {
+			return fmt.Errorf("删除任务依赖失败: %w", err)
+		}
+	}
 	if err := s.taskRepo.DeleteByRepositoryID(id); err != nil {
 		return fmt.Errorf("删除任务失败: %w", err)
 	}
-	if err := s.repoRepo.Delete(id); err != nil {
+	if err := s.repoRepo.Delete(context.Background(), id); err != nil {
 		return fmt.Errorf("删除仓库失败: %w", err)
 	}
 
@@ -162,7 +269,7 @@ func (s *RepositoryService) Delete(id uint) error {
 }
 
 func (s *RepositoryService) PurgeLocalDir(id uint) error {
-	repo, err := s.repoRepo.GetBasic(id)
+	repo, err := s.repoRepo.GetBasic(context.Background(), id)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -178,8 +285,9 @@ func (s *RepositoryService) PurgeLocalDir(id uint) error {
 			klog.Warningf("删除本地仓库目录失败: repoID=%d, error=%v", id, err)
 			return fmt.Errorf("删除本地仓库目录失败: %w", err)
 		}
+		codeindex.DefaultStore().Invalidate(repo.LocalPath)
 		repo.LocalPath = ""
-		if err := s.repoRepo.Save(repo); err != nil {
+		if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 			klog.Errorf("更新仓库记录失败: repoID=%d, error=%v", id, err)
 			return fmt.Errorf("更新仓库记录失败: %w", err)
 		}
@@ -191,12 +299,40 @@ func (s *RepositoryService) PurgeLocalDir(id uint) error {
 	return nil
 }
 
+// SetDependencyRepository 设置任务依赖仓储，用于入队前检查多上游依赖
+func (s *RepositoryService) SetDependencyRepository(repo repository.TaskDependencyRepository) {
+	s.dependencyRepo = repo
+}
+
+// SetGitCredentialService 设置 Git 凭证服务，用于克隆私有仓库
+func (s *RepositoryService) SetGitCredentialService(credentials *GitCredentialService) {
+	s.credentials = credentials
+}
+
+// SetRepositoryRefRepository 设置引用文档集仓储，删除仓库时一并清理各引用的检出
+func (s *RepositoryService) SetRepositoryRefRepository(refRepo repository.RepositoryRefRepository) {
+	s.refRepo = refRepo
+}
+
+// SetRepositoryWebhookRepository 设置 Webhook 配置仓储，删除仓库时一并删除其 Webhook
+func (s *RepositoryService) SetRepositoryWebhookRepository(webhookRepo repository.RepositoryWebhookRepository) {
+	s.webhookRepo = webhookRepo
+}
+
+// resolveGitAuth 解析仓库使用的 Git 凭证，未配置凭证服务时返回 nil
+func (s *RepositoryService) resolveGitAuth(ctx context.Context, repo *model.Repository) (*git.Auth, error) {
+	if s.credentials == nil {
+		return nil, nil
+	}
+	return s.credentials.Resolve(ctx, repo)
+}
+
 // SetReady 将仓库状态设置为就绪（用于调试或特殊场景）
 func (s *RepositoryService) SetReady(repoID uint) error {
 	klog.V(6).Infof("准备将仓库状态设置为就绪: repoID=%d", repoID)
 
 	// 获取仓库
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -206,7 +342,7 @@ func (s *RepositoryService) SetReady(repoID uint) error {
 	repo.Status = string(statemachine.RepoStatusReady)
 	repo.ErrorMsg = ""
 
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
 		return fmt.Errorf("更新仓库状态失败: %w", err)
 	}
@@ -217,18 +353,19 @@ func (s *RepositoryService) SetReady(repoID uint) error {
 
 // UpdateRepositoryCloneInfo 更新仓库记录中的分支与提交信息。
 func (s *RepositoryService) UpdateRepositoryCloneInfo(ctx context.Context, repoID uint, branch string, commit string) error {
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
 	if commit == "" {
 		return fmt.Errorf("仓库最新提交为空")
 	}
-	if branch != "" {
+	// 指定了引用的仓库保留请求的分支、标签或提交名
+	if branch != "" && repo.CloneRefType == "" {
 		repo.CloneBranch = branch
 	}
 	repo.CloneCommit = commit
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
 		klog.V(6).Infof("更新仓库提交信息失败: repoID=%d, branch=%s, commit=%s, error=%v", repoID, branch, commit, err)
 		return fmt.Errorf("更新仓库提交信息失败: %w", err)
 	}
@@ -3,18 +3,17 @@ package service
 import (
 	"context"
 	"fmt"
-	"path/filepath"
-	"time"
 
 	"k8s.io/klog/v2"
 
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
 	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
 )
 
 // CloneRepository 手动触发克隆仓库（用于克隆失败的仓库）
 func (s *RepositoryService) CloneRepository(ctx context.Context, repoID uint) error {
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -27,18 +26,26 @@ func (s *RepositoryService) CloneRepository(ctx context.Context, repoID uint) er
 	// 先删除已存在的本地目录（如果有）
 	if repo.LocalPath != "" {
 		_ = git.RemoveRepo(repo.LocalPath)
+		codeindex.DefaultStore().Invalidate(repo.LocalPath)
 	}
 
-	// 重新生成路径
-	repoName := git.ParseRepoName(repo.URL)
-	repo.LocalPath = filepath.Join(s.cfg.Data.RepoDir, repoName+"-"+fmt.Sprintf("%d", time.Now().Unix()))
+	// 重新生成路径，导入的仓库没有远端地址，使用仓库名称
+	repoName := repo.Name
+	if !IsImportedSource(repo) {
+		repoName = git.ParseRepoName(repo.URL)
+	}
+	repo.LocalPath = s.newLocalPath(repoName)
 
 	// 保存新路径
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
 		return fmt.Errorf("更新仓库路径失败: %w", err)
 	}
 
-	// 异步克隆
+	// 异步克隆，本地目录与压缩包改为复制或解压
+	if IsImportedSource(repo) {
+		go s.importRepository(repoID)
+		return nil
+	}
 	go s.cloneRepository(repoID)
 
 	return nil
@@ -50,7 +57,7 @@ func (s *RepositoryService) cloneRepository(repoID uint) {
 	klog.V(6).Infof("开始克隆仓库: repoID=%d", repoID)
 
 	// 获取仓库
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		klog.Errorf("获取仓库失败: repoID=%d, error=%v", repoID, err)
 		return
@@ -68,25 +75,30 @@ func (s *RepositoryService) cloneRepository(repoID uint) {
 
 	// 更新数据库状态
 	repo.Status = string(newStatus)
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
 		return
 	}
 
 	klog.V(6).Infof("仓库状态已更新为 cloning: repoID=%d", repoID)
 
-	// 执行克隆
-	err = git.Clone(git.CloneOptions{
-		URL:       repo.URL,
-		TargetDir: repo.LocalPath,
-	})
+	// 执行克隆，私有仓库的凭证通过环境变量传给 git，不写入 URL 与本地目录
+	auth, err := s.resolveGitAuth(context.Background(), repo)
+	if err == nil {
+		err = git.Clone(git.CloneOptions{
+			URL:       repo.URL,
+			TargetDir: repo.LocalPath,
+			Auth:      auth,
+			Ref:       git.NewRef(repo.CloneRefType, repo.CloneBranch),
+		})
+	}
 
 	if err != nil {
 		// 克隆失败，状态迁移: cloning -> error
 		repo.Status = string(statemachine.RepoStatusError)
 		repo.ErrorMsg = fmt.Sprintf("克隆失败: %v", err)
 
-		if err := s.repoRepo.Save(repo); err != nil {
+		if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 			klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
 		}
 
@@ -106,7 +118,10 @@ func (s *RepositoryService) cloneRepository(repoID uint) {
 	if err != nil {
 		klog.Errorf("获取仓库分支与提交信息失败: repoID=%d, error=%v", repoID, err)
 	} else {
-		repo.CloneBranch = branch
+		// 指定了引用时保留请求的名称，检出标签或提交时 HEAD 处于分离状态，没有分支名
+		if repo.CloneRefType == "" {
+			repo.CloneBranch = branch
+		}
 		repo.CloneCommit = commit
 		klog.V(6).Infof("仓库分支与提交信息已记录: repoID=%d, branch=%s, commit=%s", repoID, branch, commit)
 	}
@@ -115,10 +130,13 @@ func (s *RepositoryService) cloneRepository(repoID uint) {
 	repo.Status = string(statemachine.RepoStatusReady)
 	repo.ErrorMsg = ""
 
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
 		return
 	}
 
 	klog.V(6).Infof("仓库克隆成功，状态已更新为 ready: repoID=%d, localPath=%s", repoID, repo.LocalPath)
+
+	// 预热符号索引，供 find_symbol 等工具使用
+	codeindex.DefaultStore().Warm(repo.LocalPath)
 }
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/archive"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+)
+
+var (
+	ErrLocalImportDisabled    = errors.New("local directory import is disabled")
+	ErrLocalPathNotAllowed    = errors.New("local path is not under an allowed import root")
+	ErrInvalidImportSource    = errors.New("invalid import source")
+	ErrNotArchiveRepository   = errors.New("repository was not imported from an archive")
+	ErrRepositoryImportBusy   = errors.New("repository is cloning or analyzing")
+	ErrImportSnapshotDisabled = errors.New("imported repository has no git snapshot, incremental update is unavailable")
+	ErrRepositoryHasNoRemote  = errors.New("repository has no remote source")
+)
+
+// importNamePattern 导入仓库名称中允许的字符，其余字符替换为 -
+var importNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ImportLocalRequest 导入服务器本地目录
+type ImportLocalRequest struct {
+	Path string `json:"path" binding:"required"`
+	// Name 仓库名称，为空时使用目录名
+	Name string `json:"name"`
+	// GitInit 是否 git init 并按导入提交快照，默认开启，重新导入后可做增量分析
+	GitInit *bool `json:"git_init"`
+}
+
+// ImportArchiveRequest 导入上传的压缩包
+type ImportArchiveRequest struct {
+	Name     string // 仓库名称，为空时使用压缩包文件名
+	Filename string // 上传的文件名，用于判断格式
+	GitInit  bool
+}
+
+// IsImportedSource 仓库代码是否来自本地目录或压缩包导入，而非远端 git 仓库
+func IsImportedSource(repo *model.Repository) bool {
+	return repo.SourceType == model.RepositorySourceLocal || repo.SourceType == model.RepositorySourceArchive
+}
+
+// ImportLocal 从服务器本地目录创建仓库，目录必须位于配置的 import.local_roots 之内
+func (s *RepositoryService) ImportLocal(ctx context.Context, req ImportLocalRequest) (*model.Repository, error) {
+	sourcePath, err := s.resolveLocalSource(req.Path)
+	if err != nil {
+		klog.V(6).Infof("本地目录校验失败: path=%s, error=%v", req.Path, err)
+		return nil, err
+	}
+
+	existingRepos, err := s.repoRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库列表失败: %w", err)
+	}
+	for _, existing := range existingRepos {
+		if existing.SourceType == model.RepositorySourceLocal && existing.SourcePath == sourcePath {
+			klog.V(6).Infof("本地目录已导入，拒绝重复添加: repoID=%d, path=%s", existing.ID, sourcePath)
+			return nil, ErrRepositoryAlreadyExists
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = filepath.Base(sourcePath)
+	}
+	gitInit := req.GitInit == nil || *req.GitInit
+	repo, err := s.createImported(ctx, model.RepositorySourceLocal, name, "local://"+sourcePath, sourcePath, gitInit)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("本地目录导入仓库创建成功: repoID=%d, name=%s, path=%s", repo.ID, repo.Name, sourcePath)
+	return repo, nil
+}
+
+// ImportArchive 保存上传的压缩包并创建仓库，解压在克隆阶段异步进行
+func (s *RepositoryService) ImportArchive(ctx context.Context, req ImportArchiveRequest, r io.Reader) (*model.Repository, error) {
+	if _, err := archive.DetectFormat(req.Filename); err != nil {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = archive.TrimExt(req.Filename)
+	}
+	stored, err := s.saveArchive(req.Filename, r)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := s.createImported(ctx, model.RepositorySourceArchive, name, "archive://"+filepath.Base(req.Filename), stored, req.GitInit)
+	if err != nil {
+		_ = os.Remove(stored)
+		return nil, err
+	}
+	klog.V(6).Infof("压缩包导入仓库创建成功: repoID=%d, name=%s, archive=%s", repo.ID, repo.Name, stored)
+	return repo, nil
+}
+
+// ReimportArchive 用新上传的压缩包替换仓库代码。
+// 启用了 git 快照时新内容提交为一个快照，CloneCommit 仍指向上次分析的基线，随后的增量更新即可比较两次上传的差异
+func (s *RepositoryService) ReimportArchive(ctx context.Context, repoID uint, filename string, r io.Reader) (*model.Repository, error) {
+	if _, loaded := s.reimporting.LoadOrStore(repoID, struct{}{}); loaded {
+		return nil, ErrRepositoryImportBusy
+	}
+	defer s.reimporting.Delete(repoID)
+
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库失败: %w", err)
+	}
+	if repo.SourceType != model.RepositorySourceArchive {
+		return nil, ErrNotArchiveRepository
+	}
+	if err := s.checkRepositoryIdle(repo); err != nil {
+		return nil, err
+	}
+	if _, err := archive.DetectFormat(filename); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.saveArchive(filename, r)
+	if err != nil {
+		return nil, err
+	}
+	previous := repo.SourcePath
+	repo.SourcePath = stored
+	repo.URL = "archive://" + filepath.Base(filename)
+
+	if err := s.refreshImported(ctx, repo, "reimport "+filepath.Base(filename)); err != nil {
+		_ = os.Remove(stored)
+		return nil, err
+	}
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
+		return nil, fmt.Errorf("更新仓库失败: %w", err)
+	}
+	if previous != "" && previous != stored {
+		_ = os.Remove(previous)
+	}
+	klog.V(6).Infof("压缩包重新导入完成: repoID=%d, archive=%s", repoID, stored)
+	return repo, nil
+}
+
+// checkRepositoryIdle 与重新克隆相同，克隆或分析中的仓库不允许改写代码；
+// 仓库状态在任务入队后才聚合更新，因此同时检查排队或执行中的任务
+func (s *RepositoryService) checkRepositoryIdle(repo *model.Repository) error {
+	currentStatus := statemachine.RepositoryStatus(repo.Status)
+	if currentStatus == statemachine.RepoStatusCloning || currentStatus == statemachine.RepoStatusAnalyzing {
+		return ErrRepositoryImportBusy
+	}
+	tasks, err := s.taskRepo.GetByRepository(repo.ID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	for _, task := range tasks {
+		status := statemachine.TaskStatus(task.Status)
+		if status == statemachine.TaskStatusQueued || status == statemachine.TaskStatusRunning {
+			return ErrRepositoryImportBusy
+		}
+	}
+	return nil
+}
+
+// SyncImportedSource 增量更新前同步导入仓库的代码：本地目录重新复制并提交快照，压缩包在重新上传时已提交快照。
+// 导入时未启用 git 快照的仓库没有可比较的基线，返回 ErrImportSnapshotDisabled
+func (s *RepositoryService) SyncImportedSource(ctx context.Context, repo *model.Repository) error {
+	if !repo.SourceGitInit {
+		return ErrImportSnapshotDisabled
+	}
+	if repo.SourceType != model.RepositorySourceLocal {
+		return nil
+	}
+	return s.refreshImported(ctx, repo, "reimport "+time.Now().Format(time.RFC3339))
+}
+
+// MaxArchiveBytes 上传压缩包的大小上限，0 表示不限制，供接口层限制请求体大小
+func (s *RepositoryService) MaxArchiveBytes() int64 {
+	return s.cfg.Import.MaxArchiveMB << 20
+}
+
+// createImported 创建导入来源的仓库记录（初始状态为 pending）
+func (s *RepositoryService) createImported(ctx context.Context, sourceType, name, url, sourcePath string, gitInit bool) (*model.Repository, error) {
+	name = strings.Trim(importNamePattern.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty repository name", ErrInvalidImportSource)
+	}
+	repo := &model.Repository{
+		Name:          name,
+		URL:           url,
+		SourceType:    sourceType,
+		SourcePath:    sourcePath,
+		SourceGitInit: gitInit,
+		LocalPath:     s.newLocalPath(name),
+		Status:        string(statemachine.RepoStatusPending),
+	}
+	// 有快照的本地目录可随时重新同步，与远端仓库一样参与按活跃度的自动更新；压缩包只能重新上传
+	if sourceType == model.RepositorySourceLocal && gitInit {
+		s.initActivity(repo)
+	}
+	if err := s.repoRepo.Create(ctx, repo); err != nil {
+		return nil, fmt.Errorf("创建仓库失败: %w", err)
+	}
+	return repo, nil
+}
+
+// importRepository 将本地目录或压缩包内容填充到仓库目录，对应远端仓库的 cloneRepository
+// 状态迁移: pending -> cloning -> ready/error
+func (s *RepositoryService) importRepository(repoID uint) {
+	klog.V(6).Infof("开始导入仓库: repoID=%d", repoID)
+	ctx := context.Background()
+
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		klog.Errorf("获取仓库失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+
+	oldStatus := statemachine.RepositoryStatus(repo.Status)
+	if err := s.repoStateMachine.Transition(oldStatus, statemachine.RepoStatusCloning, repoID); err != nil {
+		klog.Errorf("仓库状态迁移失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+	repo.Status = string(statemachine.RepoStatusCloning)
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
+		klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+
+	err = s.populateImported(repo)
+	if err == nil && repo.SourceGitInit {
+		_, err = git.Snapshot(ctx, repo.LocalPath, "import "+repo.Name)
+		if err == nil {
+			var branch, commit string
+			branch, commit, err = git.GetBranchAndCommit(repo.LocalPath)
+			repo.CloneBranch = branch
+			repo.CloneCommit = commit
+		}
+	}
+	if err != nil {
+		repo.Status = string(statemachine.RepoStatusError)
+		repo.ErrorMsg = fmt.Sprintf("导入失败: %v", err)
+		if err := s.repoRepo.Save(ctx, repo); err != nil {
+			klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
+		}
+		klog.Errorf("仓库导入失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+
+	if sizeMB, err := git.DirSizeMB(repo.LocalPath); err != nil {
+		klog.Errorf("计算仓库大小失败: repoID=%d, error=%v", repoID, err)
+	} else {
+		repo.SizeMB = sizeMB
+	}
+
+	repo.Status = string(statemachine.RepoStatusReady)
+	repo.ErrorMsg = ""
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
+		klog.Errorf("更新仓库状态失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+	klog.V(6).Infof("仓库导入成功，状态已更新为 ready: repoID=%d, localPath=%s, commit=%s", repoID, repo.LocalPath, repo.CloneCommit)
+
+	codeindex.DefaultStore().Warm(repo.LocalPath)
+}
+
+// refreshImported 清空仓库目录后重新填充代码，启用了 git 快照时提交一个新快照
+func (s *RepositoryService) refreshImported(ctx context.Context, repo *model.Repository, message string) error {
+	if repo.SourceGitInit && git.IsRepository(repo.LocalPath) {
+		if err := git.ClearWorkTree(repo.LocalPath); err != nil {
+			return fmt.Errorf("清空仓库目录失败: %w", err)
+		}
+	} else if err := os.RemoveAll(repo.LocalPath); err != nil {
+		return fmt.Errorf("清空仓库目录失败: %w", err)
+	}
+	if err := s.populateImported(repo); err != nil {
+		return err
+	}
+	if repo.SourceGitInit {
+		if _, err := git.Snapshot(ctx, repo.LocalPath, message); err != nil {
+			return fmt.Errorf("提交导入快照失败: %w", err)
+		}
+	}
+	if sizeMB, err := git.DirSizeMB(repo.LocalPath); err == nil {
+		repo.SizeMB = sizeMB
+	}
+	codeindex.DefaultStore().Invalidate(repo.LocalPath)
+	codeindex.DefaultStore().Warm(repo.LocalPath)
+	return nil
+}
+
+// populateImported 将导入来源的内容写入仓库目录
+func (s *RepositoryService) populateImported(repo *model.Repository) error {
+	limits := archive.Limits{MaxBytes: s.cfg.Import.MaxExtractedMB << 20, MaxFiles: s.cfg.Import.MaxFiles}
+	switch repo.SourceType {
+	case model.RepositorySourceLocal:
+		// 配置可能已收紧，每次复制前重新校验目录
+		if _, err := s.resolveLocalSource(repo.SourcePath); err != nil {
+			return err
+		}
+		return archive.CopyDir(repo.SourcePath, repo.LocalPath, limits)
+	case model.RepositorySourceArchive:
+		format, err := archive.DetectFormat(repo.SourcePath)
+		if err != nil {
+			return err
+		}
+		// 解压到仓库目录旁的临时目录，去掉压缩包的顶层目录后移动到仓库目录
+		if err := os.MkdirAll(s.cfg.Data.RepoDir, 0755); err != nil {
+			return err
+		}
+		tmp, err := os.MkdirTemp(s.cfg.Data.RepoDir, ".extract-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		if err := archive.Extract(repo.SourcePath, format, tmp, limits); err != nil {
+			return err
+		}
+		root := archive.SingleRoot(tmp)
+		if err := os.MkdirAll(repo.LocalPath, 0755); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := os.Rename(filepath.Join(root, entry.Name()), filepath.Join(repo.LocalPath, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: source type %q", ErrInvalidImportSource, repo.SourceType)
+}
+
+// resolveLocalSource 解析本地目录的真实路径，并校验其位于允许导入的目录之内
+func (s *RepositoryService) resolveLocalSource(path string) (string, error) {
+	if len(s.cfg.Import.LocalRoots) == 0 {
+		return "", ErrLocalImportDisabled
+	}
+	resolved, err := realPath(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidImportSource, err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidImportSource, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w: %s is not a directory", ErrInvalidImportSource, path)
+	}
+	for _, root := range s.cfg.Import.LocalRoots {
+		rootPath, err := realPath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(rootPath, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", ErrLocalPathNotAllowed
+}
+
+// realPath 返回解析符号链接后的绝对路径，防止通过链接绕过允许目录的校验
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// saveArchive 将上传的压缩包保存到数据目录，超过 import.max_archive_mb 时返回 archive.ErrTooLarge
+func (s *RepositoryService) saveArchive(filename string, r io.Reader) (string, error) {
+	dir := filepath.Join(s.cfg.Data.Dir, "archives")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建压缩包目录失败: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "upload-*-"+importNamePattern.ReplaceAllString(filepath.Base(filename), "-"))
+	if err != nil {
+		return "", fmt.Errorf("保存压缩包失败: %w", err)
+	}
+	defer f.Close()
+
+	maxBytes := s.MaxArchiveBytes()
+	src := r
+	if maxBytes > 0 {
+		src = io.LimitReader(r, maxBytes+1)
+	}
+	n, err := io.Copy(f, src)
+	if err == nil && maxBytes > 0 && n > maxBytes {
+		err = fmt.Errorf("%w: max %d MB", archive.ErrTooLarge, s.cfg.Import.MaxArchiveMB)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// newLocalPath 生成仓库的本地目录
+func (s *RepositoryService) newLocalPath(name string) string {
+	return filepath.Join(s.cfg.Data.RepoDir, name+"-"+fmt.Sprintf("%d", time.Now().Unix()))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/archive"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newImportTestService(t *testing.T) (*RepositoryService, *config.Config) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Data.Dir = t.TempDir()
+	cfg.Data.RepoDir = filepath.Join(cfg.Data.Dir, "repos")
+	cfg.Import.MaxArchiveMB = 1
+	cfg.Import.MaxExtractedMB = 1
+	cfg.Import.MaxFiles = 100
+	svc := NewRepositoryService(cfg, repository.NewRepoRepository(db), repository.NewTaskRepository(db), repository.NewDocumentRepository(db), nil, nil)
+	return svc, cfg
+}
+
+func zipBytes(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip entry error: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestRepositoryImportLocalRoots(t *testing.T) {
+	svc, cfg := newImportTestService(t)
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "demo")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+
+	if _, err := svc.ImportLocal(ctx, ImportLocalRequest{Path: src}); !errors.Is(err, ErrLocalImportDisabled) {
+		t.Fatalf("import without roots: %v", err)
+	}
+	cfg.Import.LocalRoots = []string{root}
+	if _, err := svc.ImportLocal(ctx, ImportLocalRequest{Path: t.TempDir()}); !errors.Is(err, ErrLocalPathNotAllowed) {
+		t.Fatalf("import outside roots: %v", err)
+	}
+	// 通过符号链接指向允许目录之外同样被拒绝
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatalf("symlink error: %v", err)
+	}
+	if _, err := svc.ImportLocal(ctx, ImportLocalRequest{Path: filepath.Join(root, "link")}); !errors.Is(err, ErrLocalPathNotAllowed) {
+		t.Fatalf("import through symlink: %v", err)
+	}
+
+	repo, err := svc.ImportLocal(ctx, ImportLocalRequest{Path: filepath.Join(root, "..", filepath.Base(root), "demo")})
+	if err != nil {
+		t.Fatalf("ImportLocal error: %v", err)
+	}
+	if repo.Name != "demo" || repo.SourceType != model.RepositorySourceLocal || !repo.SourceGitInit {
+		t.Fatalf("unexpected repo: %+v", repo)
+	}
+	if _, err := svc.ImportLocal(ctx, ImportLocalRequest{Path: src, Name: "other"}); !errors.Is(err, ErrRepositoryAlreadyExists) {
+		t.Fatalf("duplicate import: %v", err)
+	}
+}
+
+func TestRepositoryArchiveReimport(t *testing.T) {
+	svc, _ := newImportTestService(t)
+	ctx := context.Background()
+
+	repo, err := svc.ImportArchive(ctx, ImportArchiveRequest{Filename: "demo-1.0.zip", GitInit: true}, zipBytes(t, map[string]string{
+		"demo-1.0/main.go": "package main\n",
+		"demo-1.0/old.go":  "package main\n\nfunc Old() {}\n",
+	}))
+	if err != nil {
+		t.Fatalf("ImportArchive error: %v", err)
+	}
+	if repo.Name != "demo-1.0" {
+		t.Fatalf("name = %s", repo.Name)
+	}
+	svc.importRepository(repo.ID)
+
+	imported, err := svc.Get(ctx, repo.ID)
+	if err != nil || imported.Status != "ready" || imported.CloneCommit == "" {
+		t.Fatalf("imported repo = %+v, %v", imported, err)
+	}
+	if _, err := os.Stat(filepath.Join(imported.LocalPath, "main.go")); err != nil {
+		t.Fatalf("top-level archive directory should be stripped: %v", err)
+	}
+
+	// 分析中、有排队任务或已有上传进行中时拒绝改写代码
+	newZip := func() *bytes.Reader { return zipBytes(t, map[string]string{"demo-1.1/main.go": "package main\n"}) }
+	busy := *imported
+	busy.Status = "analyzing"
+	if err := svc.repoRepo.Save(ctx, &busy); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if _, err := svc.ReimportArchive(ctx, repo.ID, "demo-1.1.zip", newZip()); !errors.Is(err, ErrRepositoryImportBusy) {
+		t.Fatalf("reupload while analyzing: %v", err)
+	}
+	busy.Status = "ready"
+	if err := svc.repoRepo.Save(ctx, &busy); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	task := &model.Task{RepositoryID: repo.ID, Title: "overview", Status: "queued"}
+	if err := svc.taskRepo.Create(task); err != nil {
+		t.Fatalf("create task error: %v", err)
+	}
+	if _, err := svc.ReimportArchive(ctx, repo.ID, "demo-1.1.zip", newZip()); !errors.Is(err, ErrRepositoryImportBusy) {
+		t.Fatalf("reupload with queued task: %v", err)
+	}
+	if err := svc.taskRepo.Delete(task.ID); err != nil {
+		t.Fatalf("delete task error: %v", err)
+	}
+	svc.reimporting.Store(repo.ID, struct{}{})
+	if _, err := svc.ReimportArchive(ctx, repo.ID, "demo-1.1.zip", newZip()); !errors.Is(err, ErrRepositoryImportBusy) {
+		t.Fatalf("concurrent reupload: %v", err)
+	}
+	svc.reimporting.Delete(repo.ID)
+
+	if _, err := svc.ReimportArchive(ctx, repo.ID, "demo-1.1.rar", bytes.NewReader(nil)); !errors.Is(err, archive.ErrUnsupportedFormat) {
+		t.Fatalf("unsupported reupload: %v", err)
+	}
+	if _, err := svc.ReimportArchive(ctx, repo.ID, "demo-1.1.zip", zipBytes(t, map[string]string{
+		"demo-1.1/main.go": "package main\n\nfunc main() {}\n",
+	})); err != nil {
+		t.Fatalf("ReimportArchive error: %v", err)
+	}
+
+	// 基线提交不变，增量分析比较两次上传的差异
+	_, changes, err := git.GetIncrementalChanges(imported.LocalPath, imported.CloneCommit)
+	if err != nil {
+		t.Fatalf("GetIncrementalChanges error: %v", err)
+	}
+	types := make(map[string]string)
+	for _, change := range changes {
+		types[change.Path] = change.ChangeType
+	}
+	if len(types) != 2 || types["main.go"] == "" || types["old.go"] == "" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	big := make([]byte, 2<<20)
+	if _, err := svc.ImportArchive(ctx, ImportArchiveRequest{Filename: "big.zip"}, bytes.NewReader(big)); !errors.Is(err, archive.ErrTooLarge) {
+		t.Fatalf("oversized upload: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"os/exec"
 	"strings"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/config"
 	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
 )
 
 type mockRepoRepo struct {
@@ -16,7 +18,7 @@ type mockRepoRepo struct {
 	err   error
 }
 
-func (m *mockRepoRepo) Create(repo *model.Repository) error {
+func (m *mockRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -27,7 +29,7 @@ func (m *mockRepoRepo) Create(repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockRepoRepo) List() ([]model.Repository, error) {
+func (m *mockRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -38,11 +40,11 @@ func (m *mockRepoRepo) List() ([]model.Repository, error) {
 	return out, nil
 }
 
-func (m *mockRepoRepo) Get(id uint) (*model.Repository, error) {
-	return m.GetBasic(id)
+func (m *mockRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
+	return m.GetBasic(ctx, id)
 }
 
-func (m *mockRepoRepo) GetBasic(id uint) (*model.Repository, error) {
+func (m *mockRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -53,7 +55,7 @@ func (m *mockRepoRepo) GetBasic(id uint) (*model.Repository, error) {
 	return repo, nil
 }
 
-func (m *mockRepoRepo) Save(repo *model.Repository) error {
+func (m *mockRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -61,7 +63,7 @@ func (m *mockRepoRepo) Save(repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockRepoRepo) Delete(id uint) error {
+func (m *mockRepoRepo) Delete(ctx context.Context, id uint) error {
 	delete(m.repos, id)
 	return nil
 }
@@ -106,3 +108,56 @@ func TestUpdateRepositoryCloneInfoEmptyCommit(t *testing.T) {
 		t.Fatalf("expected error, got nil")
 	}
 }
+
+// TestUpdateRepositoryCloneInfoKeepsRequestedRef 验证指定了标签的仓库更新提交时保留请求的引用名。
+func TestUpdateRepositoryCloneInfoKeepsRequestedRef(t *testing.T) {
+	repoRepo := &mockRepoRepo{repos: map[uint]*model.Repository{
+		1: {ID: 1, CloneRefType: git.RefTag, CloneBranch: "v1.0", CloneCommit: "abc"},
+	}}
+	svc := NewRepositoryService(&config.Config{}, repoRepo, nil, nil, nil, nil)
+
+	if err := svc.UpdateRepositoryCloneInfo(context.Background(), 1, "main", "def"); err != nil {
+		t.Fatalf("UpdateRepositoryCloneInfo error: %v", err)
+	}
+	if repo := repoRepo.repos[1]; repo.CloneBranch != "v1.0" || repo.CloneCommit != "def" {
+		t.Fatalf("unexpected repo clone info: %+v", repo)
+	}
+}
+
+// TestCreateRepositoryWithRef 验证创建仓库时指定引用，并按地址与引用去重。
+func TestCreateRepositoryWithRef(t *testing.T) {
+	repoRepo := &mockRepoRepo{repos: map[uint]*model.Repository{
+		1: {ID: 1, URL: "https://github.com/acme/widgets", CloneBranch: "main", CloneCommit: "abc"},
+	}}
+	svc := NewRepositoryService(&config.Config{}, repoRepo, nil, nil, nil, nil)
+	ctx := context.Background()
+	url := "https://github.com/acme/widgets.git"
+
+	if _, err := svc.Create(ctx, CreateRepoRequest{URL: url}); !errors.Is(err, ErrRepositoryAlreadyExists) {
+		t.Fatalf("default branch should be duplicate, got %v", err)
+	}
+	repo, err := svc.Create(ctx, CreateRepoRequest{URL: url, Branch: " release/1.x "})
+	if err != nil {
+		t.Fatalf("create branch error: %v", err)
+	}
+	if repo.CloneRefType != git.RefBranch || repo.CloneBranch != "release/1.x" {
+		t.Fatalf("unexpected ref: %+v", repo)
+	}
+	if _, err := svc.Create(ctx, CreateRepoRequest{URL: url, Branch: "release/1.x"}); !errors.Is(err, ErrRepositoryAlreadyExists) {
+		t.Fatalf("same branch should be duplicate, got %v", err)
+	}
+	if _, err := svc.Create(ctx, CreateRepoRequest{URL: url, Tag: "release/1.x"}); err != nil {
+		t.Fatalf("tag with same name is a different ref: %v", err)
+	}
+
+	invalid := []CreateRepoRequest{
+		{URL: url, Branch: "main", Tag: "v1.0"},
+		{URL: url, Branch: "-u"},
+		{URL: url, Commit: "HEAD~1"},
+	}
+	for _, req := range invalid {
+		if _, err := svc.Create(ctx, req); !errors.Is(err, ErrInvalidRepositoryRef) {
+			t.Errorf("Create(%+v) error = %v, want ErrInvalidRepositoryRef", req, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/codeindex"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+)
+
+var (
+	// ErrRepositoryRefRequired 添加引用文档集时必须指定分支、标签或提交
+	ErrRepositoryRefRequired = errors.New("one of branch, tag or commit is required")
+	// ErrRepositoryRefExists 引用文档集已存在，或与仓库主文档集是同一引用
+	ErrRepositoryRefExists = errors.New("repository ref already exists")
+	// ErrRepositoryRefBusy 引用文档集还有排队或执行中的任务
+	ErrRepositoryRefBusy = errors.New("repository ref has queued or running tasks")
+	// ErrRepositoryRefNotReady 引用尚在检出或检出失败，不能创建任务
+	ErrRepositoryRefNotReady = errors.New("repository ref is not ready")
+)
+
+// RepositoryRefRequest 添加引用文档集请求，分支、标签、提交指定其一
+type RepositoryRefRequest struct {
+	Branch string `json:"branch"`
+	Tag    string `json:"tag"`
+	Commit string `json:"commit"`
+}
+
+// RepositoryRefService 管理仓库的附加引用文档集，每个引用独立检出，
+// 其任务与文档通过 Ref 字段与仓库主文档集区分
+type RepositoryRefService struct {
+	cfg      *config.Config
+	repoRepo repository.RepoRepository
+	refRepo  repository.RepositoryRefRepository
+	docRepo  repository.DocumentRepository
+	taskRepo repository.TaskRepository
+
+	// 私有仓库凭证，可为空
+	credentials *GitCredentialService
+}
+
+// NewRepositoryRefService 创建引用文档集服务
+func NewRepositoryRefService(cfg *config.Config, repoRepo repository.RepoRepository, refRepo repository.RepositoryRefRepository, docRepo repository.DocumentRepository, taskRepo repository.TaskRepository) *RepositoryRefService {
+	return &RepositoryRefService{
+		cfg:      cfg,
+		repoRepo: repoRepo,
+		refRepo:  refRepo,
+		docRepo:  docRepo,
+		taskRepo: taskRepo,
+	}
+}
+
+// SetGitCredentialService 设置 Git 凭证服务，用于检出私有仓库的引用
+func (s *RepositoryRefService) SetGitCredentialService(credentials *GitCredentialService) {
+	s.credentials = credentials
+}
+
+// List 列出仓库的引用文档集，不含仓库主文档集
+func (s *RepositoryRefService) List(ctx context.Context, repoID uint) ([]model.RepositoryRef, error) {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return nil, err
+	}
+	return s.refRepo.ListByRepository(ctx, repoID)
+}
+
+// Get 获取仓库的引用文档集
+func (s *RepositoryRefService) Get(ctx context.Context, repoID, refID uint) (*model.RepositoryRef, error) {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return nil, err
+	}
+	ref, err := s.refRepo.Get(ctx, refID)
+	if err != nil {
+		return nil, err
+	}
+	if ref.RepositoryID != repoID {
+		return nil, repository.ErrRepositoryRefNotFound
+	}
+	return ref, nil
+}
+
+// GetReady 按名称获取已检出的引用文档集，用于为该文档集创建任务
+func (s *RepositoryRefService) GetReady(ctx context.Context, repoID uint, name string) (*model.RepositoryRef, error) {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return nil, err
+	}
+	ref, err := s.refRepo.GetByName(ctx, repoID, name)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Status != model.RepositoryRefReady {
+		return nil, fmt.Errorf("%w: %s status=%s", ErrRepositoryRefNotReady, ref.Name, ref.Status)
+	}
+	return ref, nil
+}
+
+// Add 为仓库添加引用文档集并异步检出，检出完成后可按 ref 触发目录分析等任务
+func (s *RepositoryRefService) Add(ctx context.Context, repoID uint, req RepositoryRefRequest) (*model.RepositoryRef, error) {
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := parseGitRef(req.Branch, req.Tag, req.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Type == "" {
+		return nil, ErrRepositoryRefRequired
+	}
+	primary := git.NewRef(repo.CloneRefType, repo.CloneBranch)
+	if ref == primary || (primary.Type == "" && ref.Type == git.RefBranch && ref.Name == repo.CloneBranch) {
+		return nil, fmt.Errorf("%w: %s 是仓库主文档集", ErrRepositoryRefExists, ref.Name)
+	}
+	if _, err := s.refRepo.GetByName(ctx, repoID, ref.Name); err == nil {
+		return nil, ErrRepositoryRefExists
+	} else if !errors.Is(err, repository.ErrRepositoryRefNotFound) {
+		return nil, err
+	}
+
+	record := &model.RepositoryRef{
+		RepositoryID: repoID,
+		Name:         ref.Name,
+		RefType:      ref.Type,
+		LocalPath:    filepath.Join(s.cfg.Data.RepoDir, fmt.Sprintf("%s-%d@%s", repo.Name, time.Now().Unix(), refPathName(ref.Name))),
+		Status:       model.RepositoryRefPending,
+	}
+	if err := s.refRepo.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("创建引用文档集失败: %w", err)
+	}
+	klog.V(6).Infof("引用文档集已创建: repoID=%d, ref=%s %s, localPath=%s", repoID, ref.Type, ref.Name, record.LocalPath)
+
+	go s.cloneRef(*repo, record.ID)
+	return record, nil
+}
+
+// Delete 删除引用文档集及其任务、文档与本地检出
+func (s *RepositoryRefService) Delete(ctx context.Context, repoID, refID uint) error {
+	ref, err := s.Get(ctx, repoID, refID)
+	if err != nil {
+		return err
+	}
+	if ref.Status == model.RepositoryRefCloning {
+		return ErrRepositoryRefBusy
+	}
+
+	tasks, err := s.taskRepo.GetByRepository(repoID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	var refTasks []model.Task
+	for _, task := range tasks {
+		if task.Ref != ref.Name {
+			continue
+		}
+		status := statemachine.TaskStatus(task.Status)
+		if status == statemachine.TaskStatusQueued || status == statemachine.TaskStatusRunning {
+			return ErrRepositoryRefBusy
+		}
+		refTasks = append(refTasks, task)
+	}
+	for _, task := range refTasks {
+		if err := s.taskRepo.Delete(task.ID); err != nil {
+			return fmt.Errorf("删除任务失败: %w", err)
+		}
+	}
+	if err := s.docRepo.DeleteByRepositoryRef(repoID, ref.Name); err != nil {
+		return fmt.Errorf("删除文档失败: %w", err)
+	}
+	removeRefCheckout(ref)
+	if err := s.refRepo.Delete(ctx, ref.ID); err != nil {
+		return err
+	}
+	klog.V(6).Infof("引用文档集已删除: repoID=%d, ref=%s, tasks=%d", repoID, ref.Name, len(refTasks))
+	return nil
+}
+
+// cloneRef 检出引用，状态迁移: pending -> cloning -> ready/error
+func (s *RepositoryRefService) cloneRef(repo model.Repository, refID uint) {
+	ctx := context.Background()
+	ref, err := s.refRepo.Get(ctx, refID)
+	if err != nil {
+		klog.Errorf("获取引用文档集失败: refID=%d, error=%v", refID, err)
+		return
+	}
+	ref.Status = model.RepositoryRefCloning
+	if err := s.refRepo.Save(ctx, ref); err != nil {
+		klog.Errorf("更新引用文档集状态失败: refID=%d, error=%v", refID, err)
+		return
+	}
+
+	var auth *git.Auth
+	if s.credentials != nil {
+		auth, err = s.credentials.Resolve(ctx, &repo)
+	}
+	if err == nil {
+		err = git.Clone(git.CloneOptions{
+			URL:       repo.URL,
+			TargetDir: ref.LocalPath,
+			Auth:      auth,
+			Ref:       git.NewRef(ref.RefType, ref.Name),
+		})
+	}
+	if err == nil {
+		_, ref.CommitID, err = git.GetBranchAndCommit(ref.LocalPath)
+	}
+	if err != nil {
+		ref.Status = model.RepositoryRefError
+		ref.ErrorMsg = fmt.Sprintf("检出失败: %v", err)
+		klog.Errorf("引用文档集检出失败: repoID=%d, ref=%s, error=%v", repo.ID, ref.Name, err)
+	} else {
+		ref.Status = model.RepositoryRefReady
+		ref.ErrorMsg = ""
+		klog.V(6).Infof("引用文档集检出完成: repoID=%d, ref=%s, commit=%s", repo.ID, ref.Name, ref.CommitID)
+	}
+	if err := s.refRepo.Save(ctx, ref); err != nil {
+		klog.Errorf("更新引用文档集状态失败: refID=%d, error=%v", refID, err)
+		return
+	}
+	if ref.Status == model.RepositoryRefReady {
+		codeindex.DefaultStore().Warm(ref.LocalPath)
+	}
+}
+
+// removeRefCheckout 删除引用的本地检出
+func removeRefCheckout(ref *model.RepositoryRef) {
+	if ref.LocalPath == "" {
+		return
+	}
+	if err := git.RemoveRepo(ref.LocalPath); err != nil {
+		klog.Warningf("删除引用文档集本地目录失败: ref=%s, error=%v", ref.Name, err)
+	}
+	codeindex.DefaultStore().Invalidate(ref.LocalPath)
+}
+
+// refPathName 将引用名转换为可用于目录与文件名的形式，例如 release/1.x -> release_1.x
+func refPathName(ref string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(ref)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+type refTestEnv struct {
+	refService *RepositoryRefService
+	docService *DocumentService
+	refRepo    repository.RepositoryRefRepository
+	docRepo    repository.DocumentRepository
+	repo       *model.Repository
+}
+
+func newRefTestEnv(t *testing.T) *refTestEnv {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.Document{}, &model.RepositoryRef{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	repoRepo := repository.NewRepoRepository(db)
+	refRepo := repository.NewRepositoryRefRepository(db)
+	docRepo := repository.NewDocumentRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+
+	repo := &model.Repository{Name: "demo", URL: "https://github.com/acme/demo", CloneRefType: git.RefBranch, CloneBranch: "main"}
+	if err := repoRepo.Create(context.Background(), repo); err != nil {
+		t.Fatalf("create repo error: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Data.RepoDir = t.TempDir()
+	docService := NewDocumentService(cfg, docRepo, repoRepo, nil, nil)
+	docService.SetRepositoryRefRepository(refRepo)
+	return &refTestEnv{
+		refService: NewRepositoryRefService(cfg, repoRepo, refRepo, docRepo, taskRepo),
+		docService: docService,
+		refRepo:    refRepo,
+		docRepo:    docRepo,
+		repo:       repo,
+	}
+}
+
+func TestRepositoryRefAddValidation(t *testing.T) {
+	env := newRefTestEnv(t)
+	ctx := context.Background()
+	if err := env.refRepo.Create(ctx, &model.RepositoryRef{RepositoryID: env.repo.ID, Name: "v1.0", RefType: git.RefTag, Status: model.RepositoryRefReady}); err != nil {
+		t.Fatalf("create ref error: %v", err)
+	}
+
+	cases := []struct {
+		req  RepositoryRefRequest
+		want error
+	}{
+		{RepositoryRefRequest{}, ErrRepositoryRefRequired},
+		{RepositoryRefRequest{Branch: "main"}, ErrRepositoryRefExists}, // 与主文档集相同
+		{RepositoryRefRequest{Tag: "v1.0"}, ErrRepositoryRefExists},
+		{RepositoryRefRequest{Branch: "a..b"}, ErrInvalidRepositoryRef},
+		{RepositoryRefRequest{Branch: "dev", Tag: "v2.0"}, ErrInvalidRepositoryRef},
+	}
+	for _, tc := range cases {
+		if _, err := env.refService.Add(ctx, env.repo.ID, tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("Add(%+v) error = %v, want %v", tc.req, err, tc.want)
+		}
+	}
+
+	if _, err := env.refService.GetReady(ctx, env.repo.ID, "v1.0"); err != nil {
+		t.Fatalf("GetReady error: %v", err)
+	}
+	if _, err := env.refService.GetReady(ctx, env.repo.ID, "v9.9"); !errors.Is(err, repository.ErrRepositoryRefNotFound) {
+		t.Fatalf("unknown ref should be not found, got %v", err)
+	}
+}
+
+func TestDocumentServiceDiffRefs(t *testing.T) {
+	env := newRefTestEnv(t)
+	ctx := context.Background()
+	if err := env.refRepo.Create(ctx, &model.RepositoryRef{RepositoryID: env.repo.ID, Name: "v1.0", RefType: git.RefTag, CommitID: "abc1234", Status: model.RepositoryRefReady}); err != nil {
+		t.Fatalf("create ref error: %v", err)
+	}
+	create := func(ref, title, content string, taskID uint) {
+		t.Helper()
+		if _, err := env.docService.Create(CreateDocumentRequest{RepositoryID: env.repo.ID, Ref: ref, TaskID: taskID, Title: title, Filename: title + ".md", Content: content}); err != nil {
+			t.Fatalf("create doc error: %v", err)
+		}
+	}
+	create("v1.0", "概览", "hello\n", 1)
+	create("v1.0", "架构", "old line\n", 2)
+	create("v1.0", "废弃接口", "gone\n", 3)
+	create("", "概览", "hello\n", 4)
+	create("", "架构", "new line\n", 5)
+	create("", "部署", "deploy\n", 6)
+
+	docs, err := env.docService.GetByRepositoryRef(env.repo.ID, "v1.0")
+	if err != nil || len(docs) != 3 {
+		t.Fatalf("v1.0 docs = %d, %v", len(docs), err)
+	}
+	if docs[0].CloneBranch != "v1.0" || docs[0].CloneCommitID != "abc1234" {
+		t.Fatalf("ref doc should record ref clone info: %+v", docs[0])
+	}
+	if _, err := env.docService.GetByRepositoryRef(env.repo.ID, "v9.9"); !errors.Is(err, repository.ErrRepositoryRefNotFound) {
+		t.Fatalf("unknown ref should be not found, got %v", err)
+	}
+
+	diff, err := env.docService.DiffRefs(ctx, env.repo.ID, "v1.0", "")
+	if err != nil {
+		t.Fatalf("DiffRefs error: %v", err)
+	}
+	if diff.Added != 1 || diff.Removed != 1 || diff.Modified != 1 || diff.Unchanged != 1 {
+		t.Fatalf("unexpected summary: %+v", diff)
+	}
+	status := make(map[string]DocumentDiff)
+	for _, d := range diff.Documents {
+		status[d.Title] = d
+	}
+	if status["部署"].Status != DocDiffAdded || status["废弃接口"].Status != DocDiffRemoved || status["概览"].Status != DocDiffUnchanged {
+		t.Fatalf("unexpected statuses: %+v", diff.Documents)
+	}
+	modified := status["架构"]
+	if modified.Status != DocDiffModified || !strings.Contains(modified.Diff, "-old line") || !strings.Contains(modified.Diff, "+new line") {
+		t.Fatalf("unexpected modified diff: %+v", modified)
+	}
+
+	_, filename, err := env.docService.ExportAll(env.repo.ID, "v1.0")
+	if err != nil || filename != "demo@v1.0-docs.zip" {
+		t.Fatalf("ExportAll(v1.0) = %s, %v", filename, err)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"k8s.io/klog/v2"
@@ -16,7 +17,7 @@ func (s *RepositoryService) RunAllTasks(repoID uint) error {
 	klog.V(6).Infof("准备执行仓库的所有任务: repoID=%d", repoID)
 
 	// 获取仓库
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -51,17 +52,13 @@ func (s *RepositoryService) RunAllTasks(repoID uint) error {
 
 	readyTasks := make([]*model.Task, 0, len(pendingTasks))
 	for _, task := range pendingTasks {
-		if task.RunAfter == 0 {
-			readyTasks = append(readyTasks, task)
-			continue
-		}
-		runAfterTask, err := s.taskRepo.Get(task.RunAfter)
+		allowed, dependsOn, dependsOnStatus, err := checkTaskDependencies(s.taskRepo, s.dependencyRepo, task)
 		if err != nil {
-			klog.V(6).Infof("RunAfter依赖检查失败，任务暂不入队: taskID=%d, runAfter=%d, error=%v", task.ID, task.RunAfter, err)
+			klog.V(6).Infof("任务依赖检查失败，任务暂不入队: taskID=%d, dependsOn=%d, error=%v", task.ID, dependsOn, err)
 			continue
 		}
-		if runAfterTask.Status != string(statemachine.TaskStatusSucceeded) {
-			klog.V(6).Infof("RunAfter依赖未满足，任务暂不入队: taskID=%d, runAfter=%d, status=%s", task.ID, task.RunAfter, runAfterTask.Status)
+		if !allowed {
+			klog.V(6).Infof("任务依赖未满足，任务暂不入队: taskID=%d, dependsOn=%d, status=%s", task.ID, dependsOn, dependsOnStatus)
 			continue
 		}
 		readyTasks = append(readyTasks, task)
@@ -96,6 +93,9 @@ func (s *RepositoryService) RunAllTasks(repoID uint) error {
 	jobs := make([]*orchestrator.Job, 0, len(readyTasks))
 	for _, task := range readyTasks {
 		job := orchestrator.NewTaskJob(task.ID, task.RepositoryID)
+		if task.Priority != "" {
+			job.Priority = task.Priority
+		}
 		jobs = append(jobs, job)
 	}
 
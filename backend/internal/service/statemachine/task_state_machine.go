@@ -40,6 +40,8 @@ func NewTaskStateMachine() *TaskStateMachine {
 	// pending -> queued -> running -> succeeded/failed
 	// running -> failed（超时/异常）
 	// queued/running -> canceled（用户取消）
+	// pending -> canceled（上游依赖失败跳过）
+	// queued -> pending（上游依赖失败阻塞，等待上游重试）
 	// failed/succeeded/canceled -> pending（reset）
 	transitions := []TaskTransition{
 		// 正常执行流程
@@ -59,6 +61,10 @@ func NewTaskStateMachine() *TaskStateMachine {
 		// 取消流程
 		{TaskStatusQueued, TaskStatusCanceled},
 		{TaskStatusRunning, TaskStatusCanceled},
+		// 上游依赖失败时跳过尚未入队的任务
+		{TaskStatusPending, TaskStatusCanceled},
+		// 上游依赖失败时阻塞已入队的任务
+		{TaskStatusQueued, TaskStatusPending},
 	}
 
 	for _, t := range transitions {
@@ -31,7 +31,7 @@ func NewDocumentSyncService(repoRepo repository.RepoRepository, taskRepo reposit
 
 // Create 创建文档
 func (s *DocumentSyncService) Create(ctx context.Context, req syncdto.DocumentCreateRequest) (*model.Document, error) {
-	_, err := s.repoRepo.GetBasic(req.RepositoryID)
+	_, err := s.repoRepo.GetBasic(ctx, req.RepositoryID)
 	if err != nil {
 		return nil, fmt.Errorf("仓库不存在: %w", err)
 	}
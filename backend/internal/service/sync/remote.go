@@ -16,6 +16,7 @@ import (
 // RemoteClient 处理远程 HTTP 通信
 type RemoteClient struct {
 	client *http.Client
+	token  string
 }
 
 // NewRemoteClient 创建新的远程客户端
@@ -25,13 +26,26 @@ func NewRemoteClient() *RemoteClient {
 	}
 }
 
+// SetToken 设置访问目标服务器时携带的 API Token
+func (c *RemoteClient) SetToken(token string) {
+	c.token = token
+}
+
+// do 发送请求，配置了 Token 时附带 Authorization 头
+func (c *RemoteClient) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.client.Do(req)
+}
+
 // CheckTarget 检查目标服务器连通性
 func (c *RemoteClient) CheckTarget(ctx context.Context, targetServer string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetServer+"/ping", nil)
 	if err != nil {
 		return err
 	}
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -54,7 +68,7 @@ func (c *RemoteClient) PostJSON(ctx context.Context, url string, reqBody interfa
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
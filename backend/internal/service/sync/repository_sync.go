@@ -35,7 +35,7 @@ func (s *RepositorySyncService) CreateOrUpdate(ctx context.Context, req syncdto.
 	if req.RepositoryID == 0 {
 		return nil, errors.New("仓库ID不能为空")
 	}
-	repo, err := s.repoRepo.GetBasic(req.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(ctx, req.RepositoryID)
 	isNew := false
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrRecordNotFound) {
@@ -67,14 +67,14 @@ func (s *RepositorySyncService) CreateOrUpdate(ctx context.Context, req syncdto.
 	repo.UpdatedAt = updatedAt
 
 	if isNew {
-		if err := s.repoRepo.Create(repo); err != nil {
+		if err := s.repoRepo.Create(ctx, repo); err != nil {
 			return nil, err
 		}
 		klog.V(6).Infof("同步仓库信息已创建: repoID=%d", repo.ID)
 		return repo, nil
 	}
 
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(ctx, repo); err != nil {
 		return nil, err
 	}
 	klog.V(6).Infof("同步仓库信息已更新: repoID=%d", repo.ID)
@@ -86,7 +86,7 @@ func (s *RepositorySyncService) ClearData(ctx context.Context, repoID uint) erro
 	if repoID == 0 {
 		return errors.New("仓库ID不能为空")
 	}
-	if _, err := s.repoRepo.GetBasic(repoID); err != nil {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
 		return fmt.Errorf("仓库不存在: %w", err)
 	}
 	if err := s.docRepo.DeleteByRepositoryID(repoID); err != nil {
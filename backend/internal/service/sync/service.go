@@ -51,6 +51,11 @@ func New(repoRepo repository.RepoRepository, taskRepo repository.TaskRepository,
 	return s
 }
 
+// SetRemoteToken 设置访问远程实例时使用的 API Token
+func (s *Service) SetRemoteToken(token string) {
+	s.remoteClient.SetToken(token)
+}
+
 // SetDocEventBus 设置文档事件总线
 func (s *Service) SetDocEventBus(bus *eventbus.DocEventBus) {
 	s.docBus = bus
@@ -79,7 +84,7 @@ func (s *Service) Start(ctx context.Context, targetServer string, repoID uint, d
 		return nil, err
 	}
 
-	if _, err := s.repoRepo.GetBasic(repoID); err != nil {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
 		return nil, fmt.Errorf("仓库不存在: %w", err)
 	}
 
@@ -163,11 +168,11 @@ func (s *Service) ListSyncEvents(ctx context.Context, repositoryID uint, mode st
 	}
 	repoNameByID := make(map[uint]string)
 	if repositoryID > 0 {
-		if repo, err := s.repoRepo.GetBasic(repositoryID); err == nil && repo != nil {
+		if repo, err := s.repoRepo.GetBasic(ctx, repositoryID); err == nil && repo != nil {
 			repoNameByID[repo.ID] = repo.Name
 		}
 	} else {
-		repos, err := s.repoRepo.List()
+		repos, err := s.repoRepo.List(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -193,7 +198,7 @@ func (s *Service) ListSyncEvents(ctx context.Context, repositoryID uint, mode st
 
 // ListRepositories 列出仓库
 func (s *Service) ListRepositories(ctx context.Context) ([]syncdto.RepositoryListItem, error) {
-	repos, err := s.repoRepo.List()
+	repos, err := s.repoRepo.List(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +221,7 @@ func (s *Service) ListDocuments(ctx context.Context, repoID uint) ([]syncdto.Doc
 	if repoID == 0 {
 		return nil, errors.New("仓库ID不能为空")
 	}
-	if _, err := s.repoRepo.GetBasic(repoID); err != nil {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
 		return nil, fmt.Errorf("仓库不存在: %w", err)
 	}
 	docs, err := s.docRepo.GetByRepository(repoID)
@@ -250,7 +255,7 @@ func (s *Service) BuildPullExportData(ctx context.Context, repoID uint, document
 	if repoID == 0 {
 		return syncdto.PullExportData{}, errors.New("仓库ID不能为空")
 	}
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
 	if err != nil {
 		return syncdto.PullExportData{}, fmt.Errorf("仓库不存在: %w", err)
 	}
@@ -375,9 +380,22 @@ func (s *Service) UpdateTaskDocID(ctx context.Context, taskID uint, docID uint)
 	return s.taskSyncSvc.UpdateDocID(ctx, taskID, docID)
 }
 
-// CreateDocument 创建文档
+// CreateDocument 创建文档，并发布文档保存事件以更新搜索索引
 func (s *Service) CreateDocument(ctx context.Context, req syncdto.DocumentCreateRequest) (*model.Document, error) {
-	return s.docSyncSvc.Create(ctx, req)
+	doc, err := s.docSyncSvc.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if s.docBus != nil {
+		_ = s.docBus.Publish(ctx, eventbus.DocEventSaved, eventbus.DocEvent{
+			Type:         eventbus.DocEventSaved,
+			RepositoryID: doc.RepositoryID,
+			DocID:        doc.ID,
+			Title:        doc.Title,
+			Content:      doc.Content,
+		})
+	}
+	return doc, nil
 }
 
 // GetTaskUsagesByTaskID 获取任务用量
@@ -400,7 +418,7 @@ func (s *Service) runSync(ctx context.Context, status *Status) {
 		return
 	}
 
-	repo, err := s.repoRepo.GetBasic(status.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(ctx, status.RepositoryID)
 	if err != nil {
 		klog.Errorf("[sync.runSync] 获取仓库失败: syncID=%s, repoID=%d, error=%v", status.SyncID, status.RepositoryID, err)
 		s.statusMgr.Update(status.SyncID, func(s *Status) {
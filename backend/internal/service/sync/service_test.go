@@ -19,7 +19,7 @@ type mockRepoRepo struct {
 }
 
 // Create 创建仓库
-func (m *mockRepoRepo) Create(repo *model.Repository) error {
+func (m *mockRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -31,7 +31,7 @@ func (m *mockRepoRepo) Create(repo *model.Repository) error {
 }
 
 // List 列出仓库
-func (m *mockRepoRepo) List() ([]model.Repository, error) {
+func (m *mockRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -43,12 +43,12 @@ func (m *mockRepoRepo) List() ([]model.Repository, error) {
 }
 
 // Get 获取仓库
-func (m *mockRepoRepo) Get(id uint) (*model.Repository, error) {
-	return m.GetBasic(id)
+func (m *mockRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
+	return m.GetBasic(ctx, id)
 }
 
 // GetBasic 获取仓库基础信息
-func (m *mockRepoRepo) GetBasic(id uint) (*model.Repository, error) {
+func (m *mockRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -60,7 +60,7 @@ func (m *mockRepoRepo) GetBasic(id uint) (*model.Repository, error) {
 }
 
 // Save 保存仓库
-func (m *mockRepoRepo) Save(repo *model.Repository) error {
+func (m *mockRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -69,7 +69,7 @@ func (m *mockRepoRepo) Save(repo *model.Repository) error {
 }
 
 // Delete 删除仓库
-func (m *mockRepoRepo) Delete(id uint) error {
+func (m *mockRepoRepo) Delete(ctx context.Context, id uint) error {
 	delete(m.repos, id)
 	return nil
 }
@@ -136,6 +136,10 @@ func (m *mockTaskRepo) Get(id uint) (*model.Task, error) {
 	return task, nil
 }
 
+func (m *mockTaskRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Task, error) {
+	return m.Get(id)
+}
+
 // Save 保存任务
 func (m *mockTaskRepo) Save(task *model.Task) error {
 	if m.err != nil {
@@ -239,6 +243,20 @@ func (m *mockDocRepo) GetByRepository(repoID uint) ([]model.Document, error) {
 	return out, nil
 }
 
+// GetByRepositoryRef 按仓库与引用文档集获取文档
+func (m *mockDocRepo) GetByRepositoryRef(repoID uint, ref string) ([]model.Document, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var out []model.Document
+	for _, doc := range m.docs {
+		if doc.RepositoryID == repoID && doc.Ref == ref {
+			out = append(out, *doc)
+		}
+	}
+	return out, nil
+}
+
 // GetVersions 获取版本列表
 func (m *mockDocRepo) GetVersions(repoID uint, title string) ([]model.Document, error) {
 	return nil, m.err
@@ -256,6 +274,10 @@ func (m *mockDocRepo) Get(id uint) (*model.Document, error) {
 	return doc, nil
 }
 
+func (m *mockDocRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Document, error) {
+	return m.Get(id)
+}
+
 // Save 保存文档
 func (m *mockDocRepo) Save(doc *model.Document) error {
 	if m.err != nil {
@@ -308,6 +330,19 @@ func (m *mockDocRepo) DeleteByRepositoryID(repoID uint) error {
 	return nil
 }
 
+// DeleteByRepositoryRef 删除仓库引用文档集下的文档
+func (m *mockDocRepo) DeleteByRepositoryRef(repoID uint, ref string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for id, doc := range m.docs {
+		if doc.RepositoryID == repoID && doc.Ref == ref {
+			delete(m.docs, id)
+		}
+	}
+	return nil
+}
+
 // UpdateTaskID 更新文档任务ID
 func (m *mockDocRepo) UpdateTaskID(docID uint, taskID uint) error {
 	if m.err != nil {
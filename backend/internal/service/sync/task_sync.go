@@ -29,7 +29,7 @@ func NewTaskSyncService(repoRepo repository.RepoRepository, taskRepo repository.
 
 // Create 创建任务
 func (s *TaskSyncService) Create(ctx context.Context, req syncdto.TaskCreateRequest) (*model.Task, error) {
-	repo, err := s.repoRepo.GetBasic(req.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(ctx, req.RepositoryID)
 	if err != nil {
 		return nil, fmt.Errorf("仓库不存在: %w", err)
 	}
@@ -17,14 +17,13 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// ErrRunAfterNotSatisfied RunAfter依赖未满足错误
-var ErrRunAfterNotSatisfied = errors.New("runAfter依赖未满足")
-
 // TaskService 任务服务主入口，协调各子服务
 type TaskService struct {
 	cfg            *config.Config
 	taskRepo       repository.TaskRepository
 	repoRepo       repository.RepoRepository
+	dependencyRepo repository.TaskDependencyRepository
+	refRepo        repository.RepositoryRefRepository
 	docService     *DocumentService
 
 	// 子服务
@@ -36,8 +35,11 @@ type TaskService struct {
 	taskStateMachine *statemachine.TaskStateMachine
 	orchestrator     *orchestrator.Orchestrator
 	writers          []domain.Writer
+	writersMutex     sync.RWMutex
 	schedulerOnce    sync.Once
+	progressBus      *eventbus.TaskProgressBus
 	taskUsageService TaskUsageService
+	traceService     TaskTraceService
 	bus             *eventbus.TaskEventBus
 }
 
@@ -56,12 +58,15 @@ func NewTaskService(cfg *config.Config, taskRepo repository.TaskRepository, repo
 	s.queryService = NewTaskQueryService(taskRepo)
 	s.lifecycle = NewTaskLifecycleService(taskRepo, repoRepo)
 	s.cleanupService = NewTaskCleanupService(taskRepo, s.lifecycle)
+	s.cleanupService.SetFailureHandler(s.propagateDependencyFailure)
 
 	return s
 }
 
 // AddWriters 添加写入器
 func (s *TaskService) AddWriters(writers ...domain.Writer) {
+	s.writersMutex.Lock()
+	defer s.writersMutex.Unlock()
 	for _, w := range writers {
 		for _, existing := range s.writers {
 			if existing.Name() == w.Name() {
@@ -73,8 +78,23 @@ func (s *TaskService) AddWriters(writers ...domain.Writer) {
 	s.writers = append(s.writers, writers...)
 }
 
+// RemoveWriter 移除写入器（用于自定义写入器热加载），返回是否存在
+func (s *TaskService) RemoveWriter(name domain.WriterName) bool {
+	s.writersMutex.Lock()
+	defer s.writersMutex.Unlock()
+	for i, w := range s.writers {
+		if w.Name() == name {
+			s.writers = append(s.writers[:i], s.writers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // GetWriter 获取写入器
 func (s *TaskService) GetWriter(name domain.WriterName) (domain.Writer, error) {
+	s.writersMutex.RLock()
+	defer s.writersMutex.RUnlock()
 	for _, w := range s.writers {
 		if w.Name() == name {
 			return w, nil
@@ -83,11 +103,23 @@ func (s *TaskService) GetWriter(name domain.WriterName) (domain.Writer, error) {
 	return nil, fmt.Errorf("写入器 %s 不存在", name)
 }
 
+// ListWriters 列出已注册的写入器名称
+func (s *TaskService) ListWriters() []domain.WriterName {
+	s.writersMutex.RLock()
+	defer s.writersMutex.RUnlock()
+	names := make([]domain.WriterName, 0, len(s.writers))
+	for _, w := range s.writers {
+		names = append(names, w.Name())
+	}
+	return names
+}
+
 // SetOrchestrator 设置任务编排器
 func (s *TaskService) SetOrchestrator(o *orchestrator.Orchestrator) {
 	s.orchestrator = o
 	s.queryService.SetOrchestrator(o)
 	s.lifecycle.SetOrchestrator(o)
+	s.cleanupService.SetOrchestrator(o)
 	if o != nil {
 		o.SetDependencyChecker(s)
 	}
@@ -99,6 +131,51 @@ func (s *TaskService) SetEventBus(bus *eventbus.TaskEventBus) {
 	s.lifecycle.SetEventBus(bus)
 }
 
+// SetProgressBus 设置任务进度事件总线
+func (s *TaskService) SetProgressBus(bus *eventbus.TaskProgressBus) {
+	s.progressBus = bus
+}
+
+// SetTraceService 设置任务执行轨迹服务
+func (s *TaskService) SetTraceService(traceService TaskTraceService) {
+	s.traceService = traceService
+}
+
+// SetRepositoryRefRepository 设置引用文档集仓储，用于定位引用文档集任务的代码目录
+func (s *TaskService) SetRepositoryRefRepository(repo repository.RepositoryRefRepository) {
+	s.refRepo = repo
+}
+
+// GetTrace 获取任务的执行轨迹
+func (s *TaskService) GetTrace(ctx context.Context, taskID uint) (*TaskTraceResult, error) {
+	if s.traceService == nil {
+		return &TaskTraceResult{TaskID: taskID, Steps: []model.TaskTrace{}, Models: []string{}}, nil
+	}
+	return s.traceService.GetTrace(ctx, taskID)
+}
+
+// SubscribeProgress 订阅单个任务的实时进度事件，返回取消订阅函数
+func (s *TaskService) SubscribeProgress(taskID uint, handler eventbus.TaskProgressEventHandler) func() {
+	if s.progressBus == nil {
+		return func() {}
+	}
+	return s.progressBus.Subscribe(taskID, handler)
+}
+
+// publishStatus 推送任务状态变化事件
+func (s *TaskService) publishStatus(task *model.Task) {
+	if s.progressBus == nil {
+		return
+	}
+	_ = s.progressBus.Publish(context.Background(), task.ID, eventbus.TaskProgressEvent{
+		Type:      eventbus.TaskProgressStatus,
+		TaskID:    task.ID,
+		Timestamp: time.Now(),
+		Status:    task.Status,
+		Error:     task.ErrorMsg,
+	})
+}
+
 // ==================== 查询方法（委托给 TaskQueryService）====================
 
 // Get 获取单个任务
@@ -139,19 +216,29 @@ func (s *TaskService) Enqueue(taskID uint) error {
 	if err != nil {
 		return fmt.Errorf("获取任务失败: %w", err)
 	}
-	allowed, runAfterID, runAfterStatus, err := s.CheckRunAfterSatisfied(taskID)
+	allowed, dependsOn, dependsOnStatus, err := checkTaskDependencies(s.taskRepo, s.dependencyRepo, task)
 	if err != nil {
-		return fmt.Errorf("RunAfter依赖检查失败: %w", err)
+		return fmt.Errorf("任务依赖检查失败: %w", err)
 	}
 	if !allowed {
-		klog.V(6).Infof("RunAfter依赖未满足，任务暂不入队: taskID=%d, runAfter=%d, status=%s", taskID, runAfterID, runAfterStatus)
-		return ErrRunAfterNotSatisfied
+		klog.V(6).Infof("任务依赖未满足，任务暂不入队: taskID=%d, dependsOn=%d, status=%s", taskID, dependsOn, dependsOnStatus)
+		return ErrDependencyNotSatisfied
 	}
 
 	oldStatus := statemachine.TaskStatus(task.Status)
 	newStatus := statemachine.TaskStatusQueued
 
 	if oldStatus == statemachine.TaskStatusQueued {
+		// 已有作业时保留其重试次数、入队时间与下次执行时间，只同步优先级，避免重复入队重置排队进度
+		if s.orchestrator.HasJob(taskID) {
+			klog.V(6).Infof("任务已在队列中，保留现有作业: taskID=%d", taskID)
+			if task.Priority != "" {
+				if err := s.orchestrator.UpdatePriority(taskID, task.Priority); err != nil {
+					return fmt.Errorf("更新排队作业优先级失败: %w", err)
+				}
+			}
+			return nil
+		}
 		klog.V(6).Infof("任务已在队列中，重新入队: taskID=%d", taskID)
 		if err := s.taskRepo.Save(task); err != nil {
 			return fmt.Errorf("刷新任务时间失败: %w", err)
@@ -167,6 +254,9 @@ func (s *TaskService) Enqueue(taskID uint) error {
 	}
 
 	job := orchestrator.NewTaskJob(taskID, task.RepositoryID)
+	if task.Priority != "" {
+		job.Priority = task.Priority
+	}
 	if err := s.orchestrator.EnqueueJob(job); err != nil {
 		if oldStatus != statemachine.TaskStatusQueued {
 			task.Status = string(oldStatus)
@@ -179,6 +269,33 @@ func (s *TaskService) Enqueue(taskID uint) error {
 	return nil
 }
 
+// SetPriority 调整任务优先级，任务已在队列中时同步调整排队作业
+func (s *TaskService) SetPriority(taskID uint, priority domain.TaskPriority) error {
+	if !priority.Valid() {
+		return fmt.Errorf("无效的任务优先级: %s", priority)
+	}
+	task, err := s.taskRepo.Get(taskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	if task.Priority == priority {
+		return nil
+	}
+
+	klog.V(6).Infof("调整任务优先级: taskID=%d, %s -> %s", taskID, task.Priority, priority)
+	task.Priority = priority
+	if err := s.taskRepo.Save(task); err != nil {
+		return fmt.Errorf("更新任务优先级失败: %w", err)
+	}
+
+	if s.orchestrator != nil && task.Status == string(statemachine.TaskStatusQueued) {
+		if err := s.orchestrator.UpdatePriority(taskID, priority); err != nil {
+			return fmt.Errorf("更新排队作业优先级失败: %w", err)
+		}
+	}
+	return nil
+}
+
 // StartPendingTaskScheduler 启动 Pending 任务定时入队
 func (s *TaskService) StartPendingTaskScheduler(ctx context.Context, interval time.Duration) {
 	if interval <= 0 {
@@ -224,7 +341,7 @@ func (s *TaskService) enqueuePendingTasks(ctx context.Context) {
 		default:
 		}
 		if err := s.Enqueue(task.ID); err != nil {
-			if errors.Is(err, ErrRunAfterNotSatisfied) {
+			if errors.Is(err, ErrDependencyNotSatisfied) {
 				continue
 			}
 			klog.V(6).Infof("Pending任务入队失败: taskID=%d, error=%v", task.ID, err)
@@ -234,25 +351,6 @@ func (s *TaskService) enqueuePendingTasks(ctx context.Context) {
 	}
 }
 
-// CheckRunAfterSatisfied 检查任务RunAfter依赖是否满足
-func (s *TaskService) CheckRunAfterSatisfied(taskID uint) (bool, uint, string, error) {
-	task, err := s.taskRepo.Get(taskID)
-	if err != nil {
-		return false, 0, "", fmt.Errorf("获取任务失败: %w", err)
-	}
-	if task.RunAfter == 0 {
-		return true, 0, "", nil
-	}
-	runAfterTask, err := s.taskRepo.Get(task.RunAfter)
-	if err != nil {
-		return false, task.RunAfter, "", fmt.Errorf("获取RunAfter任务失败: %w", err)
-	}
-	if runAfterTask.Status == string(statemachine.TaskStatusSucceeded) {
-		return true, task.RunAfter, runAfterTask.Status, nil
-	}
-	return false, task.RunAfter, runAfterTask.Status, nil
-}
-
 // Run 执行任务（由编排器调用）
 func (s *TaskService) Run(ctx context.Context, taskID uint) error {
 	klog.V(6).Infof("开始执行任务: taskID=%d", taskID)
@@ -280,15 +378,27 @@ func (s *TaskService) Run(ctx context.Context, taskID uint) error {
 
 	klog.V(6).Infof("任务状态更新为 running: taskID=%d", taskID)
 	_ = s.UpdateRepositoryStatus(task.RepositoryID)
+	s.publishStatus(task)
+
+	// 轨迹只保留最近一次执行，重跑前清除上一次的记录
+	if s.traceService != nil {
+		if err := s.traceService.DeleteByTaskID(ctx, taskID); err != nil {
+			klog.Warningf("清除任务轨迹失败: taskID=%d, error=%v", taskID, err)
+		}
+	}
 
 	execErr := s.executeTaskLogic(ctx, task)
 
 	if execErr != nil {
-		_ = s.FailTask(task, fmt.Sprintf("任务执行失败: %v", execErr))
+		if err := s.FailTask(task, fmt.Sprintf("任务执行失败: %v", execErr)); err == nil {
+			s.publishStatus(task)
+		}
 		return execErr
 	}
 
-	_ = s.SucceedTask(task)
+	if err := s.SucceedTask(task); err == nil {
+		s.publishStatus(task)
+	}
 	return nil
 }
 
@@ -296,7 +406,7 @@ func (s *TaskService) Run(ctx context.Context, taskID uint) error {
 func (s *TaskService) executeTaskLogic(ctx context.Context, task *model.Task) error {
 	klog.V(6).Infof("任务信息: taskID=%d, title=%s", task.ID, task.Title)
 
-	repo, err := s.repoRepo.GetBasic(task.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(ctx, task.RepositoryID)
 	if err != nil {
 		klog.V(6).Infof("获取仓库失败: repoID=%d, error=%v", task.RepositoryID, err)
 		return err
@@ -309,8 +419,17 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *model.Task) er
 		return fmt.Errorf("获取写入器失败: %w", err)
 	}
 
+	localPath, err := s.taskLocalPath(ctx, repo, task)
+	if err != nil {
+		return err
+	}
+
+	// 任务在仓库所属的工作空间内执行，只使用该空间的 API Key 与 Agent 覆盖
+	ctx = repository.WithWorkspace(ctx, repo.WorkspaceID)
+	// 执行中创建的后续任务与文档归属同一引用文档集
+	ctx = repository.WithDocRef(ctx, task.Ref)
 	ctx = context.WithValue(ctx, "taskID", task.ID)
-	content, err := writer.Generate(ctx, repo.LocalPath, task.Title, task.ID)
+	content, err := writer.Generate(ctx, localPath, task.Title, task.ID)
 	if err != nil {
 		klog.Errorf("写入器生成文档失败: writerName=%s, taskTitle=%s, error=%v", task.WriterName, task.Title, err)
 		return fmt.Errorf("写入器生成文档失败: %w", err)
@@ -318,7 +437,7 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *model.Task) er
 	klog.V(6).Infof("文档生成完成: taskTitle=%s, contentLength=%d", task.Title, len(content))
 
 	if task.TaskType == domain.DocWrite {
-		_, err = s.docService.Update(task.DocID, content)
+		_, err = s.docService.Update(task.DocID, content, 0)
 		if err != nil {
 			klog.V(6).Infof("保存文档失败: error=%v", err)
 			return fmt.Errorf("保存文档失败: %w", err)
@@ -331,6 +450,7 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *model.Task) er
 		}
 		newDoc, err := s.docService.Create(CreateDocumentRequest{
 			RepositoryID: originDoc.RepositoryID,
+			Ref:          originDoc.Ref,
 			TaskID:       originDoc.TaskID,
 			Title:        originDoc.Title,
 			Filename:     originDoc.Filename,
@@ -356,6 +476,24 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *model.Task) er
 	return nil
 }
 
+// taskLocalPath 返回任务分析的代码目录，引用文档集的任务使用该引用的检出
+func (s *TaskService) taskLocalPath(ctx context.Context, repo *model.Repository, task *model.Task) (string, error) {
+	if task.Ref == "" {
+		return repo.LocalPath, nil
+	}
+	if s.refRepo == nil {
+		return "", fmt.Errorf("引用文档集 %s 不可用: %w", task.Ref, repository.ErrRepositoryRefNotFound)
+	}
+	ref, err := s.refRepo.GetByName(ctx, repo.ID, task.Ref)
+	if err != nil {
+		return "", fmt.Errorf("引用文档集 %s 不可用: %w", task.Ref, err)
+	}
+	if ref.Status != model.RepositoryRefReady {
+		return "", fmt.Errorf("引用文档集 %s 尚未就绪: status=%s", ref.Name, ref.Status)
+	}
+	return ref.LocalPath, nil
+}
+
 // ==================== 生命周期方法（委托给 TaskLifecycleService）====================
 
 // SucceedTask 任务成功完成处理
@@ -363,9 +501,13 @@ func (s *TaskService) SucceedTask(task *model.Task) error {
 	return s.lifecycle.SucceedTask(task)
 }
 
-// FailTask 任务失败处理
+// FailTask 任务失败处理，并按依赖策略处理下游任务
 func (s *TaskService) FailTask(task *model.Task, errMsg string) error {
-	return s.lifecycle.FailTask(task, errMsg)
+	if err := s.lifecycle.FailTask(task, errMsg); err != nil {
+		return err
+	}
+	s.propagateDependencyFailure(task)
+	return nil
 }
 
 // Reset 重置任务
@@ -411,14 +553,34 @@ func (s *TaskService) ReGenByNewTask(taskID uint) error {
 	return nil
 }
 
-// Cancel 取消任务
+// Cancel 取消任务，并按依赖策略处理下游任务
 func (s *TaskService) Cancel(taskID uint) error {
-	return s.lifecycle.Cancel(taskID)
+	if err := s.lifecycle.Cancel(taskID); err != nil {
+		return err
+	}
+	if task, err := s.taskRepo.Get(taskID); err == nil {
+		s.publishStatus(task)
+		s.propagateDependencyFailure(task)
+	}
+	return nil
 }
 
-// Delete 删除任务
+// Delete 删除任务及其依赖边、执行轨迹
 func (s *TaskService) Delete(taskID uint) error {
-	return s.lifecycle.Delete(taskID, s.docService)
+	if err := s.lifecycle.Delete(taskID, s.docService); err != nil {
+		return err
+	}
+	if s.dependencyRepo != nil {
+		if err := s.dependencyRepo.DeleteByTaskID(taskID); err != nil {
+			klog.Warningf("删除任务依赖失败: taskID=%d, error=%v", taskID, err)
+		}
+	}
+	if s.traceService != nil {
+		if err := s.traceService.DeleteByTaskID(context.Background(), taskID); err != nil {
+			klog.Warningf("删除任务轨迹失败: taskID=%d, error=%v", taskID, err)
+		}
+	}
+	return nil
 }
 
 // UpdateRepositoryStatus 更新仓库状态
@@ -438,6 +600,11 @@ func (s *TaskService) CleanupQueuedTasksOnStartup() (int64, error) {
 	return s.cleanupService.CleanupQueuedTasksOnStartup()
 }
 
+// RecoverInterruptedTasksOnStartup 恢复重启前被中断、仍在持久化队列中的运行中任务
+func (s *TaskService) RecoverInterruptedTasksOnStartup() (int64, error) {
+	return s.cleanupService.RecoverInterruptedTasksOnStartup()
+}
+
 // GetTaskUsageService 获取任务用量服务
 func (s *TaskService) GetTaskUsageService() TaskUsageService {
 	return s.taskUsageService
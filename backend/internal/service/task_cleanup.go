@@ -6,6 +6,7 @@ import (
 
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
 	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/orchestrator"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
 	"k8s.io/klog/v2"
 )
@@ -15,6 +16,8 @@ type TaskCleanupService struct {
 	taskRepo         repository.TaskRepository
 	taskStateMachine *statemachine.TaskStateMachine
 	lifecycle        *TaskLifecycleService
+	orchestrator     *orchestrator.Orchestrator
+	onFailed         func(task *model.Task)
 }
 
 // NewTaskCleanupService 创建新的任务清理服务
@@ -26,6 +29,21 @@ func NewTaskCleanupService(taskRepo repository.TaskRepository, lifecycle *TaskLi
 	}
 }
 
+// SetOrchestrator 设置编排器，用于判断任务是否仍在持久化队列中
+func (s *TaskCleanupService) SetOrchestrator(o *orchestrator.Orchestrator) {
+	s.orchestrator = o
+}
+
+// SetFailureHandler 设置任务被标记失败后的回调，用于按依赖策略处理下游任务
+func (s *TaskCleanupService) SetFailureHandler(handler func(task *model.Task)) {
+	s.onFailed = handler
+}
+
+// hasQueuedJob 判断任务在编排器队列中是否仍有作业
+func (s *TaskCleanupService) hasQueuedJob(taskID uint) bool {
+	return s.orchestrator != nil && s.orchestrator.HasJob(taskID)
+}
+
 // CleanupStuckTasks 清理卡住的任务（运行超过指定时间的任务）
 // 状态迁移: running -> failed (超时)
 func (s *TaskCleanupService) CleanupStuckTasks(timeout time.Duration) (int64, error) {
@@ -57,6 +75,9 @@ func (s *TaskCleanupService) CleanupStuckTasks(timeout time.Duration) (int64, er
 		affected++
 		klog.V(6).Infof("清理卡住任务: taskID=%d", task.ID)
 		_ = s.lifecycle.UpdateRepositoryStatus(task.RepositoryID)
+		if s.onFailed != nil {
+			s.onFailed(&task)
+		}
 	}
 
 	klog.V(6).Infof("清理卡住任务完成: affected=%d", affected)
@@ -64,6 +85,8 @@ func (s *TaskCleanupService) CleanupStuckTasks(timeout time.Duration) (int64, er
 }
 
 // CleanupQueuedTasksOnStartup 清理启动时遗留的排队任务
+// 持久化队列中仍有作业的任务保持 queued，由编排器继续分发；
+// 队列中没有作业的排队任务重置为 pending，由 Pending 定时入队重新提交
 func (s *TaskCleanupService) CleanupQueuedTasksOnStartup() (int64, error) {
 	klog.V(6).Info("开始清理启动时遗留的排队任务")
 
@@ -76,6 +99,10 @@ func (s *TaskCleanupService) CleanupQueuedTasksOnStartup() (int64, error) {
 	var affected int64
 	updatedRepoIDs := make(map[uint]struct{})
 	for _, task := range tasks {
+		if s.hasQueuedJob(task.ID) {
+			klog.V(6).Infof("排队任务仍在持久化队列中，保持排队: taskID=%d", task.ID)
+			continue
+		}
 		currentStatus := statemachine.TaskStatus(task.Status)
 		if err := s.taskStateMachine.Transition(currentStatus, statemachine.TaskStatusCanceled, task.ID); err != nil {
 			klog.Warningf("任务状态迁移失败（%s -> canceled）: taskID=%d, error=%v", currentStatus, task.ID, err)
@@ -109,6 +136,65 @@ func (s *TaskCleanupService) CleanupQueuedTasksOnStartup() (int64, error) {
 	return affected, nil
 }
 
+// RecoverInterruptedTasksOnStartup 恢复重启前被中断的运行中任务
+// 仅处理持久化队列中仍有作业的任务，状态迁移: running -> canceled -> pending -> queued
+// 作业保留原有重试次数，编排器启动后继续分发
+func (s *TaskCleanupService) RecoverInterruptedTasksOnStartup() (int64, error) {
+	klog.V(6).Info("开始恢复重启前中断的运行中任务")
+
+	tasks, err := s.taskRepo.GetByStatus(string(statemachine.TaskStatusRunning))
+	if err != nil {
+		klog.V(6).Infof("获取运行中任务失败: error=%v", err)
+		return 0, err
+	}
+
+	var affected int64
+	updatedRepoIDs := make(map[uint]struct{})
+	for _, task := range tasks {
+		if !s.hasQueuedJob(task.ID) {
+			continue
+		}
+		path := []statemachine.TaskStatus{
+			statemachine.TaskStatusCanceled,
+			statemachine.TaskStatusPending,
+			statemachine.TaskStatusQueued,
+		}
+		currentStatus := statemachine.TaskStatus(task.Status)
+		transitioned := true
+		for _, next := range path {
+			if err := s.taskStateMachine.Transition(currentStatus, next, task.ID); err != nil {
+				klog.Warningf("任务状态迁移失败（%s -> %s）: taskID=%d, error=%v", currentStatus, next, task.ID, err)
+				transitioned = false
+				break
+			}
+			currentStatus = next
+		}
+		if !transitioned {
+			continue
+		}
+
+		task.Status = string(statemachine.TaskStatusQueued)
+		task.ErrorMsg = ""
+		task.StartedAt = nil
+		task.CompletedAt = nil
+		if err := s.taskRepo.Save(&task); err != nil {
+			klog.Errorf("更新任务状态失败: taskID=%d, error=%v", task.ID, err)
+			continue
+		}
+
+		affected++
+		updatedRepoIDs[task.RepositoryID] = struct{}{}
+		klog.V(6).Infof("已恢复中断任务到队列: taskID=%d", task.ID)
+	}
+
+	for repoID := range updatedRepoIDs {
+		_ = s.lifecycle.UpdateRepositoryStatus(repoID)
+	}
+
+	klog.V(6).Infof("恢复中断任务完成: affected=%d", affected)
+	return affected, nil
+}
+
 // GetStuckTasks 获取卡住的任务列表
 func (s *TaskCleanupService) GetStuckTasks(timeout time.Duration) ([]model.Task, error) {
 	return s.taskRepo.GetStuckTasks(timeout)
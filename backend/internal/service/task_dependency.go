@@ -0,0 +1,354 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/orchestrator"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrDependencyNotSatisfied 任务依赖未满足错误
+	ErrDependencyNotSatisfied = errors.New("任务依赖未满足")
+	// ErrDependencyCycle 新增依赖会形成环
+	ErrDependencyCycle = errors.New("任务依赖存在环")
+)
+
+// TaskGraph 仓库任务依赖图，用于前端可视化
+type TaskGraph struct {
+	RepositoryID uint            `json:"repository_id"`
+	Nodes        []TaskGraphNode `json:"nodes"`
+	Edges        []TaskGraphEdge `json:"edges"`
+}
+
+// TaskGraphNode 依赖图节点
+type TaskGraphNode struct {
+	ID       uint                `json:"id"`
+	Title    string              `json:"title"`
+	TaskType domain.TaskType     `json:"task_type"`
+	Status   string              `json:"status"`
+	Priority domain.TaskPriority `json:"priority"`
+	Blocked  bool                `json:"blocked"` // pending 且存在失败/取消的上游
+}
+
+// TaskGraphEdge 依赖图的边：To 依赖 From
+type TaskGraphEdge struct {
+	From      uint   `json:"from"`
+	To        uint   `json:"to"`
+	OnFailure string `json:"on_failure"`
+	Source    string `json:"source"` // run_after, dependency
+}
+
+// SetDependencyRepository 设置任务依赖仓储
+func (s *TaskService) SetDependencyRepository(repo repository.TaskDependencyRepository) {
+	s.dependencyRepo = repo
+}
+
+// CheckDependenciesSatisfied 检查任务的所有上游依赖是否都已成功
+// 返回第一个未满足的上游任务ID及其状态；上游已失败或取消时返回 orchestrator.ErrDependencyFailed
+func (s *TaskService) CheckDependenciesSatisfied(taskID uint) (bool, uint, string, error) {
+	task, err := s.taskRepo.Get(taskID)
+	if err != nil {
+		return false, 0, "", fmt.Errorf("获取任务失败: %w", err)
+	}
+	allowed, dependsOn, dependsOnStatus, err := checkTaskDependencies(s.taskRepo, s.dependencyRepo, task)
+	if err == nil && !allowed && isFailedTaskStatus(dependsOnStatus) {
+		err = fmt.Errorf("%w: dependsOn=%d, status=%s", orchestrator.ErrDependencyFailed, dependsOn, dependsOnStatus)
+	}
+	return allowed, dependsOn, dependsOnStatus, err
+}
+
+// BlockTask 上游任务失败后将排队中的任务退回 pending，并移除其排队作业
+// 上游重试成功后，Pending 定时入队会重新提交该任务
+func (s *TaskService) BlockTask(taskID uint, dependsOn uint) error {
+	task, err := s.taskRepo.Get(taskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	oldStatus := statemachine.TaskStatus(task.Status)
+	if oldStatus != statemachine.TaskStatusQueued {
+		return nil
+	}
+	if err := s.taskStateMachine.Transition(oldStatus, statemachine.TaskStatusPending, taskID); err != nil {
+		return fmt.Errorf("任务状态迁移失败: %w", err)
+	}
+	if s.orchestrator != nil {
+		if err := s.orchestrator.RemoveJob(taskID); err != nil {
+			klog.Warningf("移除排队作业失败: taskID=%d, error=%v", taskID, err)
+		}
+	}
+
+	task.Status = string(statemachine.TaskStatusPending)
+	task.ErrorMsg = fmt.Sprintf("上游任务失败，等待上游重试: dependsOn=%d", dependsOn)
+	if err := s.taskRepo.Save(task); err != nil {
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+	klog.V(6).Infof("上游任务失败，排队任务已退回 pending: taskID=%d, dependsOn=%d", taskID, dependsOn)
+	s.publishStatus(task)
+	_ = s.UpdateRepositoryStatus(task.RepositoryID)
+	return nil
+}
+
+// AddDependencies 为任务追加上游依赖
+// 上游任务必须属于同一仓库；新增的边如果会形成环则整体拒绝
+func (s *TaskService) AddDependencies(taskID uint, dependsOn []uint, onFailure string) error {
+	if s.dependencyRepo == nil {
+		return fmt.Errorf("任务依赖仓储未初始化")
+	}
+	if onFailure == "" {
+		onFailure = model.DependencyOnFailureBlock
+	}
+	if onFailure != model.DependencyOnFailureBlock && onFailure != model.DependencyOnFailureSkip {
+		return fmt.Errorf("无效的失败处理策略: %s", onFailure)
+	}
+
+	task, err := s.taskRepo.Get(taskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	tasks, err := s.taskRepo.GetByRepository(task.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("获取仓库任务失败: %w", err)
+	}
+	existingEdges, err := s.dependencyRepo.GetByRepository(task.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("获取任务依赖失败: %w", err)
+	}
+
+	inRepo := make(map[uint]bool, len(tasks))
+	parents := make(map[uint][]uint)
+	for _, t := range tasks {
+		inRepo[t.ID] = true
+		if t.RunAfter != 0 {
+			parents[t.ID] = append(parents[t.ID], t.RunAfter)
+		}
+	}
+	for _, dep := range existingEdges {
+		parents[dep.TaskID] = append(parents[dep.TaskID], dep.DependsOnID)
+	}
+
+	deps := make([]model.TaskDependency, 0, len(dependsOn))
+	for _, parentID := range dependsOn {
+		if parentID == taskID {
+			return fmt.Errorf("%w: 任务不能依赖自身: taskID=%d", ErrDependencyCycle, taskID)
+		}
+		if !inRepo[parentID] {
+			return fmt.Errorf("上游任务不存在或不属于同一仓库: taskID=%d", parentID)
+		}
+		if containsTaskID(parents[taskID], parentID) {
+			continue
+		}
+		if dependencyReachable(parents, parentID, taskID) {
+			return fmt.Errorf("%w: taskID=%d, dependsOn=%d", ErrDependencyCycle, taskID, parentID)
+		}
+		parents[taskID] = append(parents[taskID], parentID)
+		deps = append(deps, model.TaskDependency{
+			RepositoryID: task.RepositoryID,
+			TaskID:       taskID,
+			DependsOnID:  parentID,
+			OnFailure:    onFailure,
+		})
+	}
+
+	if err := s.dependencyRepo.CreateBatch(deps); err != nil {
+		return fmt.Errorf("保存任务依赖失败: %w", err)
+	}
+	klog.V(6).Infof("任务依赖已更新: taskID=%d, added=%d, onFailure=%s", taskID, len(deps), onFailure)
+	return nil
+}
+
+// GetTaskGraph 获取仓库的任务依赖图
+func (s *TaskService) GetTaskGraph(repoID uint) (*TaskGraph, error) {
+	tasks, err := s.taskRepo.GetByRepository(repoID)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库任务失败: %w", err)
+	}
+
+	graph := &TaskGraph{
+		RepositoryID: repoID,
+		Nodes:        make([]TaskGraphNode, 0, len(tasks)),
+		Edges:        make([]TaskGraphEdge, 0),
+	}
+	statuses := make(map[uint]string, len(tasks))
+	for _, t := range tasks {
+		statuses[t.ID] = t.Status
+		if t.RunAfter != 0 {
+			graph.Edges = append(graph.Edges, TaskGraphEdge{
+				From:      t.RunAfter,
+				To:        t.ID,
+				OnFailure: model.DependencyOnFailureBlock,
+				Source:    "run_after",
+			})
+		}
+	}
+	if s.dependencyRepo != nil {
+		deps, err := s.dependencyRepo.GetByRepository(repoID)
+		if err != nil {
+			return nil, fmt.Errorf("获取任务依赖失败: %w", err)
+		}
+		for _, dep := range deps {
+			graph.Edges = append(graph.Edges, TaskGraphEdge{
+				From:      dep.DependsOnID,
+				To:        dep.TaskID,
+				OnFailure: dep.OnFailure,
+				Source:    "dependency",
+			})
+		}
+	}
+
+	blocked := make(map[uint]bool)
+	for _, edge := range graph.Edges {
+		if isFailedTaskStatus(statuses[edge.From]) {
+			blocked[edge.To] = true
+		}
+	}
+	for _, t := range tasks {
+		graph.Nodes = append(graph.Nodes, TaskGraphNode{
+			ID:       t.ID,
+			Title:    t.Title,
+			TaskType: t.TaskType,
+			Status:   t.Status,
+			Priority: t.Priority,
+			Blocked:  t.Status == string(statemachine.TaskStatusPending) && blocked[t.ID],
+		})
+	}
+	return graph, nil
+}
+
+// propagateDependencyFailure 上游任务失败或取消后，按依赖边的策略处理下游任务
+// skip 策略的下游任务被取消并继续向下传播；block 策略的下游任务保持（或退回）pending，等待上游重试
+func (s *TaskService) propagateDependencyFailure(task *model.Task) {
+	if s.dependencyRepo == nil {
+		return
+	}
+
+	skipped := 0
+	queue := []uint{task.ID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+
+		dependents, err := s.dependencyRepo.GetDependents(parentID)
+		if err != nil {
+			klog.Errorf("获取下游任务失败: taskID=%d, error=%v", parentID, err)
+			continue
+		}
+		for _, dep := range dependents {
+			if dep.OnFailure != model.DependencyOnFailureSkip {
+				klog.V(6).Infof("上游任务失败，下游任务被阻塞: taskID=%d, dependsOn=%d", dep.TaskID, parentID)
+				if err := s.BlockTask(dep.TaskID, parentID); err != nil {
+					klog.Warningf("阻塞下游任务失败: taskID=%d, dependsOn=%d, error=%v", dep.TaskID, parentID, err)
+				}
+				continue
+			}
+			if err := s.skipDependentTask(dep.TaskID, parentID); err != nil {
+				klog.Warningf("跳过下游任务失败: taskID=%d, dependsOn=%d, error=%v", dep.TaskID, parentID, err)
+				continue
+			}
+			skipped++
+			queue = append(queue, dep.TaskID)
+		}
+	}
+
+	if skipped > 0 {
+		klog.V(6).Infof("上游任务失败，已跳过下游任务: taskID=%d, skipped=%d", task.ID, skipped)
+		_ = s.UpdateRepositoryStatus(task.RepositoryID)
+	}
+}
+
+// skipDependentTask 取消尚未开始执行的下游任务
+func (s *TaskService) skipDependentTask(taskID uint, parentID uint) error {
+	task, err := s.taskRepo.Get(taskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	oldStatus := statemachine.TaskStatus(task.Status)
+	if oldStatus != statemachine.TaskStatusPending && oldStatus != statemachine.TaskStatusQueued {
+		return fmt.Errorf("任务已开始或已结束，不再跳过: status=%s", oldStatus)
+	}
+	if err := s.taskStateMachine.Transition(oldStatus, statemachine.TaskStatusCanceled, taskID); err != nil {
+		return fmt.Errorf("任务状态迁移失败: %w", err)
+	}
+	if oldStatus == statemachine.TaskStatusQueued && s.orchestrator != nil {
+		if err := s.orchestrator.RemoveJob(taskID); err != nil {
+			klog.Warningf("移除排队作业失败: taskID=%d, error=%v", taskID, err)
+		}
+	}
+
+	now := time.Now()
+	task.Status = string(statemachine.TaskStatusCanceled)
+	task.CompletedAt = &now
+	task.ErrorMsg = fmt.Sprintf("上游任务失败，已跳过: dependsOn=%d", parentID)
+	return s.taskRepo.Save(task)
+}
+
+// checkTaskDependencies 检查任务的 RunAfter 与依赖表中的所有上游任务是否都已成功
+func checkTaskDependencies(taskRepo repository.TaskRepository, dependencyRepo repository.TaskDependencyRepository, task *model.Task) (bool, uint, string, error) {
+	parentIDs := make([]uint, 0, 1)
+	if task.RunAfter != 0 {
+		parentIDs = append(parentIDs, task.RunAfter)
+	}
+	if dependencyRepo != nil {
+		deps, err := dependencyRepo.GetByTaskID(task.ID)
+		if err != nil {
+			return false, 0, "", fmt.Errorf("获取任务依赖失败: %w", err)
+		}
+		for _, dep := range deps {
+			if !containsTaskID(parentIDs, dep.DependsOnID) {
+				parentIDs = append(parentIDs, dep.DependsOnID)
+			}
+		}
+	}
+
+	for _, parentID := range parentIDs {
+		parent, err := taskRepo.Get(parentID)
+		if err != nil {
+			return false, parentID, "", fmt.Errorf("获取上游任务失败: %w", err)
+		}
+		if parent.Status != string(statemachine.TaskStatusSucceeded) {
+			return false, parentID, parent.Status, nil
+		}
+	}
+	return true, 0, "", nil
+}
+
+// dependencyReachable 判断沿上游边从 from 出发能否到达 target
+// 新增边 task -> parent 时，如果 task 可从 parent 到达，则会形成环
+func dependencyReachable(parents map[uint][]uint, from uint, target uint) bool {
+	visited := make(map[uint]bool)
+	stack := []uint{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == target {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, parents[current]...)
+	}
+	return false
+}
+
+func containsTaskID(ids []uint, id uint) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func isFailedTaskStatus(status string) bool {
+	return status == string(statemachine.TaskStatusFailed) || status == string(statemachine.TaskStatusCanceled)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/orchestrator"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+	"gorm.io/gorm"
+)
+
+func newDependencyTestService(t *testing.T) (*TaskService, repository.TaskRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.TaskDependency{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	taskRepo := repository.NewTaskRepository(db)
+	s := NewTaskService(nil, taskRepo, repository.NewRepoRepository(db), nil)
+	s.SetDependencyRepository(repository.NewTaskDependencyRepository(db))
+	return s, taskRepo
+}
+
+func createDependencyTestTask(t *testing.T, taskRepo repository.TaskRepository, title string) *model.Task {
+	t.Helper()
+	task := &model.Task{RepositoryID: 1, Title: title, Status: string(statemachine.TaskStatusPending)}
+	if err := taskRepo.Create(task); err != nil {
+		t.Fatalf("create task error: %v", err)
+	}
+	return task
+}
+
+func TestAddDependenciesRejectsCycle(t *testing.T) {
+	s, taskRepo := newDependencyTestService(t)
+	a := createDependencyTestTask(t, taskRepo, "A")
+	b := createDependencyTestTask(t, taskRepo, "B")
+	c := createDependencyTestTask(t, taskRepo, "C")
+
+	if err := s.AddDependencies(b.ID, []uint{a.ID}, ""); err != nil {
+		t.Fatalf("add B->A error: %v", err)
+	}
+	if err := s.AddDependencies(c.ID, []uint{b.ID}, ""); err != nil {
+		t.Fatalf("add C->B error: %v", err)
+	}
+	if err := s.AddDependencies(a.ID, []uint{c.ID}, ""); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if err := s.AddDependencies(a.ID, []uint{a.ID}, ""); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected self dependency error, got %v", err)
+	}
+}
+
+func TestCheckDependenciesWaitsForAllParents(t *testing.T) {
+	s, taskRepo := newDependencyTestService(t)
+	a := createDependencyTestTask(t, taskRepo, "A")
+	b := createDependencyTestTask(t, taskRepo, "B")
+	summary := createDependencyTestTask(t, taskRepo, "Summary")
+
+	if err := s.AddDependencies(summary.ID, []uint{a.ID, b.ID}, ""); err != nil {
+		t.Fatalf("add dependencies error: %v", err)
+	}
+
+	a.Status = string(statemachine.TaskStatusSucceeded)
+	_ = taskRepo.Save(a)
+	allowed, dependsOn, _, err := s.CheckDependenciesSatisfied(summary.ID)
+	if err != nil {
+		t.Fatalf("check error: %v", err)
+	}
+	if allowed || dependsOn != b.ID {
+		t.Fatalf("expected blocked by task %d, got allowed=%v dependsOn=%d", b.ID, allowed, dependsOn)
+	}
+
+	b.Status = string(statemachine.TaskStatusSucceeded)
+	_ = taskRepo.Save(b)
+	allowed, _, _, err = s.CheckDependenciesSatisfied(summary.ID)
+	if err != nil || !allowed {
+		t.Fatalf("expected satisfied, got allowed=%v err=%v", allowed, err)
+	}
+}
+
+func TestFailTaskPropagatesToDependents(t *testing.T) {
+	s, taskRepo := newDependencyTestService(t)
+	a := createDependencyTestTask(t, taskRepo, "A")
+	skipped := createDependencyTestTask(t, taskRepo, "Skipped")
+	transitive := createDependencyTestTask(t, taskRepo, "Transitive")
+	blocked := createDependencyTestTask(t, taskRepo, "Blocked")
+
+	if err := s.AddDependencies(skipped.ID, []uint{a.ID}, model.DependencyOnFailureSkip); err != nil {
+		t.Fatalf("add skip dependency error: %v", err)
+	}
+	if err := s.AddDependencies(transitive.ID, []uint{skipped.ID}, model.DependencyOnFailureSkip); err != nil {
+		t.Fatalf("add transitive dependency error: %v", err)
+	}
+	if err := s.AddDependencies(blocked.ID, []uint{a.ID}, model.DependencyOnFailureBlock); err != nil {
+		t.Fatalf("add block dependency error: %v", err)
+	}
+
+	a.Status = string(statemachine.TaskStatusRunning)
+	_ = taskRepo.Save(a)
+	if err := s.FailTask(a, "boom"); err != nil {
+		t.Fatalf("fail task error: %v", err)
+	}
+
+	for _, id := range []uint{skipped.ID, transitive.ID} {
+		got, _ := taskRepo.Get(id)
+		if got.Status != string(statemachine.TaskStatusCanceled) {
+			t.Fatalf("expected task %d canceled, got %s", id, got.Status)
+		}
+	}
+	got, _ := taskRepo.Get(blocked.ID)
+	if got.Status != string(statemachine.TaskStatusPending) {
+		t.Fatalf("expected blocked task pending, got %s", got.Status)
+	}
+
+	graph, err := s.GetTaskGraph(1)
+	if err != nil {
+		t.Fatalf("graph error: %v", err)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 3 {
+		t.Fatalf("unexpected graph size: nodes=%d edges=%d", len(graph.Nodes), len(graph.Edges))
+	}
+	for _, node := range graph.Nodes {
+		if node.ID == blocked.ID && !node.Blocked {
+			t.Fatalf("expected node %d marked blocked", node.ID)
+		}
+	}
+}
+
+func TestQueuedTaskBlockedByFailedDependency(t *testing.T) {
+	s, taskRepo := newDependencyTestService(t)
+	a := createDependencyTestTask(t, taskRepo, "A")
+	queued := createDependencyTestTask(t, taskRepo, "Queued")
+	if err := s.AddDependencies(queued.ID, []uint{a.ID}, model.DependencyOnFailureBlock); err != nil {
+		t.Fatalf("add dependency error: %v", err)
+	}
+
+	a.Status = string(statemachine.TaskStatusFailed)
+	_ = taskRepo.Save(a)
+	queued.Status = string(statemachine.TaskStatusQueued)
+	_ = taskRepo.Save(queued)
+
+	if _, dependsOn, _, err := s.CheckDependenciesSatisfied(queued.ID); !errors.Is(err, orchestrator.ErrDependencyFailed) || dependsOn != a.ID {
+		t.Fatalf("expected ErrDependencyFailed on task %d, got dependsOn=%d err=%v", a.ID, dependsOn, err)
+	}
+	if err := s.BlockTask(queued.ID, a.ID); err != nil {
+		t.Fatalf("block task error: %v", err)
+	}
+	got, _ := taskRepo.Get(queued.ID)
+	if got.Status != string(statemachine.TaskStatusPending) || got.ErrorMsg == "" {
+		t.Fatalf("expected blocked task pending with reason, got %s %q", got.Status, got.ErrorMsg)
+	}
+}
+
+// TestCleanupStuckTasksPropagatesToDependents 验证超时清理标记失败的任务同样按依赖策略处理下游任务
+func TestCleanupStuckTasksPropagatesToDependents(t *testing.T) {
+	s, taskRepo := newDependencyTestService(t)
+	a := createDependencyTestTask(t, taskRepo, "A")
+	skipped := createDependencyTestTask(t, taskRepo, "Skipped")
+	if err := s.AddDependencies(skipped.ID, []uint{a.ID}, model.DependencyOnFailureSkip); err != nil {
+		t.Fatalf("add dependency error: %v", err)
+	}
+
+	startedAt := time.Now().Add(-time.Hour)
+	a.Status = string(statemachine.TaskStatusRunning)
+	a.StartedAt = &startedAt
+	_ = taskRepo.Save(a)
+
+	if affected, err := s.CleanupStuckTasks(10 * time.Minute); err != nil || affected != 1 {
+		t.Fatalf("cleanup affected=%d err=%v", affected, err)
+	}
+	got, _ := taskRepo.Get(skipped.ID)
+	if got.Status != string(statemachine.TaskStatusCanceled) {
+		t.Fatalf("expected dependent canceled, got %s", got.Status)
+	}
+}
+
+// TestEnqueueKeepsQueuedJob 验证已排队任务再次入队时保留持久化作业的重试与排队进度
+func TestEnqueueKeepsQueuedJob(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.Task{}, &model.TaskDependency{}, &model.TaskJob{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	taskRepo := repository.NewTaskRepository(db)
+	s := NewTaskService(nil, taskRepo, repository.NewRepoRepository(db), nil)
+	s.SetDependencyRepository(repository.NewTaskDependencyRepository(db))
+	o, err := orchestrator.NewOrchestrator(1, nil, orchestrator.NewDBJobStore(repository.NewTaskJobRepository(db)))
+	if err != nil {
+		t.Fatalf("new orchestrator error: %v", err)
+	}
+	defer o.Stop()
+	s.SetOrchestrator(o)
+
+	task := createDependencyTestTask(t, taskRepo, "A")
+	if err := s.Enqueue(task.ID); err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	var job model.TaskJob
+	db.First(&job, "task_id = ?", task.ID)
+	enqueuedAt := job.EnqueuedAt
+	db.Model(&job).Update("retry_count", 3)
+
+	if err := s.SetPriority(task.ID, domain.PriorityInteractive); err != nil {
+		t.Fatalf("set priority error: %v", err)
+	}
+	if err := s.Enqueue(task.ID); err != nil {
+		t.Fatalf("re-enqueue error: %v", err)
+	}
+	db.First(&job, "task_id = ?", task.ID)
+	if job.RetryCount != 3 || !job.EnqueuedAt.Equal(enqueuedAt) || job.Priority != string(domain.PriorityInteractive) {
+		t.Fatalf("queued job should be kept, got %+v", job)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
 	"k8s.io/klog/v2"
 )
@@ -22,6 +23,7 @@ func (s *TaskService) CreateContentRewriteTask(ctx context.Context, repoID uint,
 	task := &model.Task{
 		RepositoryID: repoID,
 		DocID:        docID,
+		Ref:          repository.DocRefFromContext(ctx),
 		Title:        title,
 		Outline:      fmt.Sprintf(guide, content, replace),
 		WriterName:   domain.DocRewriter,
@@ -36,7 +38,20 @@ func (s *TaskService) CreateContentRewriteTask(ctx context.Context, repoID uint,
 	return task, nil
 }
 
-// CreateDocWriteTask 创建文档和任务，并建立双向关联
+type taskPriorityKey struct{}
+
+// withTaskPriority 指定在 ctx 内创建的任务所在的优先级通道
+func withTaskPriority(ctx context.Context, priority domain.TaskPriority) context.Context {
+	return context.WithValue(ctx, taskPriorityKey{}, priority)
+}
+
+// taskPriorityFromContext 获取 ctx 指定的任务优先级，未指定时为空（按普通任务处理）
+func taskPriorityFromContext(ctx context.Context) domain.TaskPriority {
+	priority, _ := ctx.Value(taskPriorityKey{}).(domain.TaskPriority)
+	return priority
+}
+
+// CreateDocWriteTask 创建文档和任务，并建立双向关联，文档与任务归属 context 中的引用文档集
 // 1. 创建文档
 // 2. 创建任务
 // 3. 更新文档关联的任务ID
@@ -45,8 +60,10 @@ func (s *TaskService) CreateDocWriteTask(ctx context.Context, repoID uint, title
 	if len([]rune(docTitle)) > 20 {
 		docTitle = string([]rune(docTitle)[:20])
 	}
+	ref := repository.DocRefFromContext(ctx)
 	doc, err := s.docService.Create(CreateDocumentRequest{
 		RepositoryID: repoID,
+		Ref:          ref,
 		Title:        docTitle, //文章标题，限制长度
 		Filename:     docTitle + ".md",
 		Content:      fmt.Sprintf("%s\n%s", title, outline), //文档内容，初始为空，后续会被填充
@@ -59,11 +76,13 @@ func (s *TaskService) CreateDocWriteTask(ctx context.Context, repoID uint, title
 	task := &model.Task{
 		RepositoryID: repoID,
 		DocID:        doc.ID,
+		Ref:          ref,
 		Title:        title, //任务标题，不限制长度，prompt会提取文档标题作为提示词一部分
 		Outline:      outline,
 		WriterName:   domain.DefaultWriter,
 		TaskType:     domain.DocWrite,
 		Status:       string(statemachine.TaskStatusPending),
+		Priority:     taskPriorityFromContext(ctx),
 		SortOrder:    sortOrder,
 	}
 
@@ -84,11 +103,12 @@ func (s *TaskService) CreateDocWriteTask(ctx context.Context, repoID uint, title
 	return task, nil
 }
 
-// CreateTocWriteTask 创建目录任务，无需创建文档
+// CreateTocWriteTask 创建目录任务，无需创建文档；context 指定引用文档集时分析该引用的检出
 func (s *TaskService) CreateTocWriteTask(ctx context.Context, repoID uint, title string, sortOrder int) (*model.Task, error) {
 	// 创建目录任务，无需创建文档
 	task := &model.Task{
 		RepositoryID: repoID,
+		Ref:          repository.DocRefFromContext(ctx),
 		Title:        title,
 		WriterName:   domain.TocWriter,
 		TaskType:     domain.TocWrite,
@@ -104,7 +124,7 @@ func (s *TaskService) CreateTocWriteTask(ctx context.Context, repoID uint, title
 
 func (s *TaskService) CreateIncrementalWriteTask(ctx context.Context, repoID uint, title string, sortOrder int) (*model.Task, error) {
 
-	repo, err := s.repoRepo.Get(repoID)
+	repo, err := s.repoRepo.Get(ctx, repoID)
 	if err != nil {
 		return nil, fmt.Errorf("[CreateIncrementalWriteTask] 获取仓库失败: %w", err)
 	}
@@ -118,6 +138,7 @@ func (s *TaskService) CreateIncrementalWriteTask(ctx context.Context, repoID uin
 		WriterName:   domain.IncrementalWriter,
 		TaskType:     domain.IncrementalWrite,
 		Status:       string(statemachine.TaskStatusPending),
+		Priority:     domain.PriorityBackground, // 增量更新多由定时调度触发，走后台通道
 		SortOrder:    sortOrder,
 	}
 	if err := s.taskRepo.Create(task); err != nil {
@@ -132,12 +153,14 @@ func (s *TaskService) CreateTitleRewriteTask(ctx context.Context, repoID uint, t
 	// 创建标题重写任务
 	task := &model.Task{
 		RepositoryID: repoID,
+		Ref:          repository.DocRefFromContext(ctx),
 		Title:        title,
 		DocID:        docId,
 		WriterName:   domain.TitleRewriter,
 		TaskType:     domain.TitleRewrite,
 		RunAfter:     runAfter,
 		Status:       string(statemachine.TaskStatusPending),
+		Priority:     taskPriorityFromContext(ctx),
 		SortOrder:    sortOrder,
 	}
 	if err := s.taskRepo.Create(task); err != nil {
@@ -155,6 +178,9 @@ func (s *TaskService) CreateUserRequestTask(ctx context.Context, repoID uint, co
 	// 首先创建一个分析任务，分析任务的结果会被用于创建文档
 	// 创建一个titleRewrite 任务，将标题进行重写
 
+	// 用户主动提交的需求走交互通道，优先于后台任务执行；创建时即写入优先级，避免先以普通通道入队
+	ctx = withTaskPriority(ctx, domain.PriorityInteractive)
+
 	task1, err := s.CreateDocWriteTask(ctx, repoID, content, content, sortOrder)
 	if err != nil {
 		return nil, fmt.Errorf("[CreateUserRequestTask] 创建任务失败: %w", err)
@@ -19,7 +19,7 @@ type mockTaskHelperRepoRepo struct {
 	repos map[uint]*model.Repository
 }
 
-func (m *mockTaskHelperRepoRepo) Create(repo *model.Repository) error {
+func (m *mockTaskHelperRepoRepo) Create(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -30,7 +30,7 @@ func (m *mockTaskHelperRepoRepo) Create(repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockTaskHelperRepoRepo) List() ([]model.Repository, error) {
+func (m *mockTaskHelperRepoRepo) List(ctx context.Context) ([]model.Repository, error) {
 	var out []model.Repository
 	for _, repo := range m.repos {
 		out = append(out, *repo)
@@ -38,7 +38,7 @@ func (m *mockTaskHelperRepoRepo) List() ([]model.Repository, error) {
 	return out, nil
 }
 
-func (m *mockTaskHelperRepoRepo) Get(id uint) (*model.Repository, error) {
+func (m *mockTaskHelperRepoRepo) Get(ctx context.Context, id uint) (*model.Repository, error) {
 	repo, ok := m.repos[id]
 	if !ok {
 		return nil, domain.ErrRecordNotFound
@@ -46,11 +46,11 @@ func (m *mockTaskHelperRepoRepo) Get(id uint) (*model.Repository, error) {
 	return repo, nil
 }
 
-func (m *mockTaskHelperRepoRepo) GetBasic(id uint) (*model.Repository, error) {
-	return m.Get(id)
+func (m *mockTaskHelperRepoRepo) GetBasic(ctx context.Context, id uint) (*model.Repository, error) {
+	return m.Get(ctx, id)
 }
 
-func (m *mockTaskHelperRepoRepo) Save(repo *model.Repository) error {
+func (m *mockTaskHelperRepoRepo) Save(ctx context.Context, repo *model.Repository) error {
 	if m.repos == nil {
 		m.repos = make(map[uint]*model.Repository)
 	}
@@ -58,7 +58,7 @@ func (m *mockTaskHelperRepoRepo) Save(repo *model.Repository) error {
 	return nil
 }
 
-func (m *mockTaskHelperRepoRepo) Delete(id uint) error {
+func (m *mockTaskHelperRepoRepo) Delete(ctx context.Context, id uint) error {
 	delete(m.repos, id)
 	return nil
 }
@@ -83,6 +83,10 @@ func (m *mockTaskRepo) Get(id uint) (*model.Task, error) {
 	return nil, nil
 }
 
+func (m *mockTaskRepo) GetInWorkspace(ctx context.Context, id uint) (*model.Task, error) {
+	return m.Get(id)
+}
+
 func (m *mockTaskRepo) Save(task *model.Task) error {
 	return nil
 }
@@ -150,3 +154,24 @@ func TestCreateIncrementalWriteTask(t *testing.T) {
 		t.Fatalf("task not created in repository")
 	}
 }
+
+func TestCreateTitleRewriteTaskPriorityFromContext(t *testing.T) {
+	repo := &mockTaskRepo{}
+	svc := &TaskService{taskRepo: repo}
+
+	ctx := withTaskPriority(context.Background(), domain.PriorityInteractive)
+	if _, err := svc.CreateTitleRewriteTask(ctx, 12, "标题", 3, 4, 1); err != nil {
+		t.Fatalf("CreateTitleRewriteTask error: %v", err)
+	}
+	// 优先级需在创建时写入，而不是创建后再调整
+	if repo.lastCreated == nil || repo.lastCreated.Priority != domain.PriorityInteractive {
+		t.Fatalf("task should be created in interactive lane, got %+v", repo.lastCreated)
+	}
+
+	if _, err := svc.CreateTitleRewriteTask(context.Background(), 12, "标题", 3, 4, 1); err != nil {
+		t.Fatalf("CreateTitleRewriteTask error: %v", err)
+	}
+	if repo.lastCreated.Priority != "" {
+		t.Fatalf("priority should default to empty, got %s", repo.lastCreated.Priority)
+	}
+}
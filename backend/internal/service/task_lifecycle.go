@@ -80,6 +80,7 @@ func (s *TaskLifecycleService) SucceedTask(task *model.Task) error {
 			WriterName:   task.WriterName,
 			TaskID:       task.ID,
 			TaskType:     task.TaskType,
+			Ref:          task.Ref,
 		})
 	}
 
@@ -119,6 +120,7 @@ func (s *TaskLifecycleService) FailTask(task *model.Task, errMsg string) error {
 			WriterName:   task.WriterName,
 			TaskID:       task.ID,
 			TaskType:     task.TaskType,
+			Ref:          task.Ref,
 		})
 	}
 
@@ -173,6 +175,9 @@ func (s *TaskLifecycleService) ForceReset(taskID uint) error {
 	newStatus := statemachine.TaskStatusPending
 
 	currentStatus := oldStatus
+	if currentStatus == statemachine.TaskStatusQueued {
+		s.removeQueuedJob(taskID)
+	}
 	if currentStatus == statemachine.TaskStatusRunning || currentStatus == statemachine.TaskStatusQueued {
 		if err := s.taskStateMachine.Transition(currentStatus, statemachine.TaskStatusCanceled, taskID); err != nil {
 			klog.Warningf("任务状态迁移失败（%s -> canceled）: taskID=%d, error=%v，继续强制重置", currentStatus, taskID, err)
@@ -219,6 +224,10 @@ func (s *TaskLifecycleService) Cancel(taskID uint) error {
 		return nil
 	}
 
+	if oldStatus == statemachine.TaskStatusQueued {
+		s.removeQueuedJob(taskID)
+	}
+
 	if oldStatus == statemachine.TaskStatusRunning {
 		if s.orchestrator != nil && s.orchestrator.CancelTask(taskID) {
 			klog.V(6).Infof("已触发运行中任务的取消: taskID=%d", taskID)
@@ -277,9 +286,19 @@ func (s *TaskLifecycleService) Delete(taskID uint, docService *DocumentService)
 	return nil
 }
 
+// removeQueuedJob 从编排器持久化队列中移除排队任务的作业
+func (s *TaskLifecycleService) removeQueuedJob(taskID uint) {
+	if s.orchestrator == nil {
+		return
+	}
+	if err := s.orchestrator.RemoveJob(taskID); err != nil {
+		klog.Warningf("移除排队作业失败: taskID=%d, error=%v", taskID, err)
+	}
+}
+
 // UpdateRepositoryStatus 更新仓库状态（使用状态机聚合器）
 func (s *TaskLifecycleService) UpdateRepositoryStatus(repoID uint) error {
-	repo, err := s.repoRepo.GetBasic(repoID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		return fmt.Errorf("获取仓库失败: %w", err)
 	}
@@ -308,7 +327,7 @@ func (s *TaskLifecycleService) UpdateRepositoryStatus(repoID uint) error {
 	}
 
 	repo.Status = string(newStatus)
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		return fmt.Errorf("更新仓库状态失败: %w", err)
 	}
 
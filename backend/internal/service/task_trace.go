@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+// TaskTraceService 任务执行轨迹服务接口
+type TaskTraceService interface {
+	RecordTrace(ctx context.Context, trace *model.TaskTrace) error
+	GetTrace(ctx context.Context, taskID uint) (*TaskTraceResult, error)
+	DeleteByTaskID(ctx context.Context, taskID uint) error
+}
+
+// TaskTraceResult 任务执行轨迹及汇总
+type TaskTraceResult struct {
+	TaskID      uint              `json:"task_id"`
+	Steps       []model.TaskTrace `json:"steps"`
+	AgentSteps  int               `json:"agent_steps"`
+	ModelCalls  int               `json:"model_calls"`
+	ToolCalls   int               `json:"tool_calls"`
+	Models      []string          `json:"models"`       // 实际使用过的模型，按首次出现顺序
+	TotalTokens int               `json:"total_tokens"` // 各次模型调用的 Token 合计
+	Errors      int               `json:"errors"`
+}
+
+type taskTraceService struct {
+	repo repository.TaskTraceRepository
+}
+
+// NewTaskTraceService 创建任务执行轨迹服务
+func NewTaskTraceService(repo repository.TaskTraceRepository) TaskTraceService {
+	return &taskTraceService{repo: repo}
+}
+
+// RecordTrace 记录一条执行轨迹
+func (s *taskTraceService) RecordTrace(ctx context.Context, trace *model.TaskTrace) error {
+	if trace == nil {
+		return nil
+	}
+	if trace.TaskID == 0 {
+		return fmt.Errorf("taskID 为空")
+	}
+	if err := s.repo.Create(ctx, trace); err != nil {
+		klog.V(6).Infof("任务轨迹记录失败：taskID=%d, type=%s, name=%s, err=%v", trace.TaskID, trace.StepType, trace.Name, err)
+		return err
+	}
+	return nil
+}
+
+// GetTrace 获取任务的执行轨迹，并汇总调用次数与模型使用情况
+func (s *taskTraceService) GetTrace(ctx context.Context, taskID uint) (*TaskTraceResult, error) {
+	steps, err := s.repo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TaskTraceResult{
+		TaskID: taskID,
+		Steps:  steps,
+		Models: []string{},
+	}
+	seenModels := make(map[string]bool)
+	for _, step := range steps {
+		switch step.StepType {
+		case model.TaskTraceStepAgent:
+			result.AgentSteps++
+		case model.TaskTraceStepModel:
+			result.ModelCalls++
+			result.TotalTokens += step.TotalTokens
+			name := step.LLMModel
+			if name == "" {
+				name = step.Name
+			}
+			if name != "" && !seenModels[name] {
+				seenModels[name] = true
+				result.Models = append(result.Models, name)
+			}
+		case model.TaskTraceStepTool:
+			result.ToolCalls++
+		}
+		if step.Error != "" {
+			result.Errors++
+		}
+	}
+	return result, nil
+}
+
+// DeleteByTaskID 删除任务的执行轨迹
+func (s *taskTraceService) DeleteByTaskID(ctx context.Context, taskID uint) error {
+	return s.repo.DeleteByTaskID(ctx, taskID)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// TestTaskTraceServiceGetTrace 验证轨迹按开始时间排序并汇总调用情况
+func TestTaskTraceServiceGetTrace(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TaskTrace{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	svc := NewTaskTraceService(repository.NewTaskTraceRepository(db))
+	ctx := context.Background()
+	base := time.Now()
+
+	// 子 Agent 步骤在结束时才写入，但开始时间早于其内部的调用
+	traces := []*model.TaskTrace{
+		{TaskID: 1, StepType: model.TaskTraceStepModel, Name: "primary", LLMModel: "gpt-4o", TotalTokens: 100, StartedAt: base.Add(time.Second)},
+		{TaskID: 1, StepType: model.TaskTraceStepTool, Name: "read_file", Arguments: `{"path":"main.go"}`, ResultSize: 512, StartedAt: base.Add(2 * time.Second)},
+		{TaskID: 1, StepType: model.TaskTraceStepModel, Name: "backup", LLMModel: "gpt-4o", TotalTokens: 50, Error: "timeout", StartedAt: base.Add(3 * time.Second)},
+		{TaskID: 1, StepType: model.TaskTraceStepAgent, Agent: "document_generator", Name: "document_generator", StartedAt: base},
+		{TaskID: 2, StepType: model.TaskTraceStepTool, Name: "list_dir", StartedAt: base},
+	}
+	for _, trace := range traces {
+		if err := svc.RecordTrace(ctx, trace); err != nil {
+			t.Fatalf("record error: %v", err)
+		}
+	}
+	if err := svc.RecordTrace(ctx, &model.TaskTrace{StepType: model.TaskTraceStepTool}); err == nil {
+		t.Fatalf("expected error for empty taskID")
+	}
+
+	result, err := svc.GetTrace(ctx, 1)
+	if err != nil {
+		t.Fatalf("get trace error: %v", err)
+	}
+	if len(result.Steps) != 4 || result.Steps[0].StepType != model.TaskTraceStepAgent {
+		t.Fatalf("unexpected steps order: %+v", result.Steps)
+	}
+	if result.AgentSteps != 1 || result.ModelCalls != 2 || result.ToolCalls != 1 || result.Errors != 1 {
+		t.Fatalf("unexpected summary: %+v", result)
+	}
+	if result.TotalTokens != 150 || len(result.Models) != 1 || result.Models[0] != "gpt-4o" {
+		t.Fatalf("unexpected model summary: tokens=%d models=%v", result.TotalTokens, result.Models)
+	}
+
+	if err := svc.DeleteByTaskID(ctx, 1); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+	result, _ = svc.GetTrace(ctx, 1)
+	if len(result.Steps) != 0 {
+		t.Fatalf("expected traces deleted, got %d", len(result.Steps))
+	}
+}
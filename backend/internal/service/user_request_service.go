@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -56,7 +57,7 @@ func (s *userRequestService) CreateRequest(repoID uint, content string) (*model.
 	}
 
 	// 验证仓库是否存在
-	_, err := s.repoRepo.GetBasic(repoID)
+	_, err := s.repoRepo.GetBasic(context.Background(), repoID)
 	if err != nil {
 		klog.Errorf("[service] 创建用户需求失败: 仓库不存在, repoID=%d, error=%v", repoID, err)
 		return nil, fmt.Errorf("仓库不存在: %w", err)
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/git"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/secret"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/webhook"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service/statemachine"
+)
+
+var (
+	// ErrInvalidWebhookBranch 分支过滤规则不是合法的通配符
+	ErrInvalidWebhookBranch = errors.New("invalid webhook branch pattern")
+	// ErrWebhookUnsupportedSource 本地目录与压缩包导入的仓库没有远端推送
+	ErrWebhookUnsupportedSource = errors.New("webhook is only available for git repositories")
+)
+
+// WebhookRequest 配置仓库 Webhook，更新时空字段不修改
+type WebhookRequest struct {
+	// Secret 签名密钥，首次配置时为空则自动生成
+	Secret string `json:"secret"`
+	// Branches 触发分析的分支，支持 release/* 等通配符；为空时使用仓库跟随的分支
+	Branches []string `json:"branches"`
+	Enabled  *bool    `json:"enabled"`
+}
+
+// WebhookDelivery 一次推送的处理结果
+type WebhookDelivery struct {
+	Provider  string `json:"provider"`
+	Event     string `json:"event"`
+	Branch    string `json:"branch,omitempty"`
+	Scheduled bool   `json:"scheduled"` // 是否已安排增量分析
+	Message   string `json:"message"`
+}
+
+// WebhookService 管理仓库的推送 Webhook：校验签名、按分支过滤，
+// 并对同一仓库的连续推送防抖，到期后发布增量写入事件
+type WebhookService struct {
+	cfg      *config.Config
+	repoRepo repository.RepoRepository
+	hookRepo repository.RepositoryWebhookRepository
+	box      *secret.Box
+	taskBus  *eventbus.TaskEventBus
+
+	mu      sync.Mutex
+	pending map[uint]*pendingPush
+}
+
+// webhookRetryInterval 仓库正在处理时推迟分析的最短间隔
+const webhookRetryInterval = time.Minute
+
+// pendingPush 等待防抖到期的推送
+type pendingPush struct {
+	timer *time.Timer
+}
+
+// NewWebhookService 创建 Webhook 服务
+func NewWebhookService(cfg *config.Config, repoRepo repository.RepoRepository, hookRepo repository.RepositoryWebhookRepository, box *secret.Box, taskBus *eventbus.TaskEventBus) *WebhookService {
+	return &WebhookService{
+		cfg:      cfg,
+		repoRepo: repoRepo,
+		hookRepo: hookRepo,
+		box:      box,
+		taskBus:  taskBus,
+		pending:  make(map[uint]*pendingPush),
+	}
+}
+
+// Get 获取仓库的 Webhook 配置，不含密钥
+func (s *WebhookService) Get(ctx context.Context, repoID uint) (*model.RepositoryWebhook, error) {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return nil, err
+	}
+	return s.hookRepo.GetByRepository(ctx, repoID)
+}
+
+// Configure 创建或更新仓库的 Webhook 配置，自动生成密钥时返回明文密钥，之后不再可见
+func (s *WebhookService) Configure(ctx context.Context, repoID uint, req WebhookRequest) (*model.RepositoryWebhook, string, error) {
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		return nil, "", err
+	}
+	if IsImportedSource(repo) {
+		return nil, "", ErrWebhookUnsupportedSource
+	}
+
+	hook, err := s.hookRepo.GetByRepository(ctx, repoID)
+	if errors.Is(err, repository.ErrRepositoryWebhookNotFound) {
+		hook = &model.RepositoryWebhook{RepositoryID: repoID, Enabled: true}
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	if req.Branches != nil {
+		branches := make([]string, 0, len(req.Branches))
+		for _, branch := range req.Branches {
+			branch = strings.TrimSpace(branch)
+			if branch == "" {
+				continue
+			}
+			if _, err := path.Match(branch, ""); err != nil || strings.Contains(branch, ",") {
+				return nil, "", fmt.Errorf("%w: %s", ErrInvalidWebhookBranch, branch)
+			}
+			branches = append(branches, branch)
+		}
+		hook.Branches = strings.Join(branches, ",")
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+
+	var generated string
+	plain := req.Secret
+	if plain == "" && hook.Secret == "" {
+		if plain, err = randomToken(24); err != nil {
+			return nil, "", fmt.Errorf("生成 Webhook 密钥失败: %w", err)
+		}
+		generated = plain
+	}
+	if plain != "" {
+		if hook.Secret, err = s.box.Encrypt(plain); err != nil {
+			return nil, "", fmt.Errorf("加密 Webhook 密钥失败: %w", err)
+		}
+	}
+
+	if err := s.hookRepo.Save(ctx, hook); err != nil {
+		return nil, "", err
+	}
+	klog.V(6).Infof("WebhookService: configured webhook repoID=%d, branches=%s, enabled=%v", repoID, hook.Branches, hook.Enabled)
+	return hook, generated, nil
+}
+
+// Delete 删除仓库的 Webhook 配置，并取消尚未触发的分析
+func (s *WebhookService) Delete(ctx context.Context, repoID uint) error {
+	if _, err := s.repoRepo.GetBasic(ctx, repoID); err != nil {
+		return err
+	}
+	s.cancel(repoID)
+	return s.hookRepo.DeleteByRepository(ctx, repoID)
+}
+
+// Receive 处理平台推送的 Webhook 请求。
+// 请求不携带工作空间，按仓库 ID 直接查找配置，签名校验通过后才读取仓库信息
+func (s *WebhookService) Receive(ctx context.Context, repoID uint, header http.Header, body []byte) (*WebhookDelivery, error) {
+	ctx = repository.WithoutWorkspace(ctx)
+	hook, err := s.hookRepo.GetByRepository(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := s.box.Decrypt(hook.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("解密 Webhook 密钥失败: %w", err)
+	}
+	event, err := webhook.Parse(header, body, plain)
+	if err != nil {
+		klog.V(6).Infof("WebhookService: rejected delivery repoID=%d, error=%v", repoID, err)
+		return nil, err
+	}
+
+	delivery := &WebhookDelivery{Provider: event.Provider, Event: event.Name, Branch: event.Branch}
+	switch {
+	case !hook.Enabled:
+		delivery.Message = "webhook is disabled"
+	case !event.Push:
+		delivery.Message = "event ignored"
+	case event.Deleted:
+		delivery.Message = "branch deletion ignored"
+	default:
+		repo, err := s.repoRepo.GetBasic(ctx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		if !branchTracked(hook, repo, event.Branch) {
+			delivery.Message = "branch not tracked"
+			break
+		}
+		s.schedule(repoID, s.debounce())
+		delivery.Scheduled = true
+		delivery.Message = fmt.Sprintf("incremental analysis scheduled in %s", s.debounce())
+	}
+
+	// ping 等事件只用于平台测试连通性，不覆盖最近一次推送的记录
+	if event.Push {
+		now := time.Now()
+		hook.LastEventAt = &now
+		hook.LastResult = fmt.Sprintf("%s %s: %s", event.Provider, event.Branch, delivery.Message)
+		if err := s.hookRepo.Save(ctx, hook); err != nil {
+			klog.Warningf("WebhookService: save delivery result failed: repoID=%d, error=%v", repoID, err)
+		}
+	}
+	klog.V(6).Infof("WebhookService: delivery repoID=%d, provider=%s, event=%s, branch=%s, result=%s", repoID, event.Provider, event.Name, event.Branch, delivery.Message)
+	return delivery, nil
+}
+
+// Stop 取消所有等待中的分析，服务关闭时调用
+func (s *WebhookService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for repoID, p := range s.pending {
+		p.timer.Stop()
+		delete(s.pending, repoID)
+	}
+}
+
+// branchTracked 推送的分支是否需要触发分析：配置了分支规则时按规则匹配，否则只跟随仓库克隆的分支
+func branchTracked(hook *model.RepositoryWebhook, repo *model.Repository, branch string) bool {
+	if hook.Branches != "" {
+		for _, pattern := range strings.Split(hook.Branches, ",") {
+			if ok, _ := path.Match(pattern, branch); ok {
+				return true
+			}
+		}
+		return false
+	}
+	// 跟随标签或提交的仓库不会随推送变化
+	if git.NewRef(repo.CloneRefType, repo.CloneBranch).IsFixed() {
+		return false
+	}
+	return repo.CloneBranch != "" && repo.CloneBranch == branch
+}
+
+// debounce 防抖时长，未配置时立即触发
+func (s *WebhookService) debounce() time.Duration {
+	return max(s.cfg.Webhook.Debounce, 0)
+}
+
+// schedule 安排一次增量分析，防抖期内的新推送会重新计时
+func (s *WebhookService) schedule(repoID uint, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pending[repoID]; ok {
+		p.timer.Stop()
+	}
+	p := &pendingPush{}
+	p.timer = time.AfterFunc(delay, func() { s.fire(repoID, p) })
+	s.pending[repoID] = p
+}
+
+func (s *WebhookService) cancel(repoID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pending[repoID]; ok {
+		p.timer.Stop()
+		delete(s.pending, repoID)
+	}
+}
+
+// fire 防抖到期后发布增量写入事件；仓库正在克隆或分析时推迟到下一个防抖周期
+func (s *WebhookService) fire(repoID uint, p *pendingPush) {
+	s.mu.Lock()
+	if s.pending[repoID] != p {
+		// 已被新的推送取代或已取消
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, repoID)
+	s.mu.Unlock()
+
+	ctx := repository.WithoutWorkspace(context.Background())
+	repo, err := s.repoRepo.GetBasic(ctx, repoID)
+	if err != nil {
+		klog.Errorf("WebhookService: 获取仓库失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+	switch statemachine.RepositoryStatus(repo.Status) {
+	case statemachine.RepoStatusCompleted:
+	case statemachine.RepoStatusCloning, statemachine.RepoStatusAnalyzing:
+		klog.V(6).Infof("WebhookService: 仓库正在处理中，推迟增量分析: repoID=%d, status=%s", repoID, repo.Status)
+		s.schedule(repoID, max(s.debounce(), webhookRetryInterval))
+		return
+	default:
+		// 与活跃度调度一致，只有已完成的仓库才会触发增量更新
+		klog.V(6).Infof("WebhookService: 仓库状态不为已完成，跳过增量分析: repoID=%d, status=%s", repoID, repo.Status)
+		return
+	}
+
+	if err := s.taskBus.Publish(ctx, eventbus.TaskEventIncrementalWrite, eventbus.TaskEvent{
+		Type:         eventbus.TaskEventIncrementalWrite,
+		RepositoryID: repoID,
+		Title:        "增量分析",
+		SortOrder:    20,
+		WriterName:   domain.IncrementalWriter,
+	}); err != nil {
+		klog.Errorf("WebhookService: 发布增量写入事件失败: repoID=%d, error=%v", repoID, err)
+		return
+	}
+	klog.V(6).Infof("WebhookService: 推送触发增量分析: repoID=%d", repoID)
+}
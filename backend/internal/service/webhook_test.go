@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/config"
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/secret"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/webhook"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newTestWebhookService(t *testing.T) (*WebhookService, *gorm.DB, *atomic.Int32) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Repository{}, &model.RepositoryWebhook{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	box, err := secret.NewBox("test-key")
+	if err != nil {
+		t.Fatalf("new box error: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Webhook.Debounce = 50 * time.Millisecond
+
+	var published atomic.Int32
+	bus := eventbus.NewTaskEventBus()
+	bus.Subscribe(eventbus.TaskEventIncrementalWrite, func(ctx context.Context, event eventbus.TaskEvent) error {
+		published.Add(1)
+		return nil
+	})
+	svc := NewWebhookService(cfg, repository.NewRepoRepository(db), repository.NewRepositoryWebhookRepository(db), box, bus)
+	t.Cleanup(svc.Stop)
+	return svc, db, &published
+}
+
+func githubPush(body, secret string) http.Header {
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "push")
+	header.Set("X-Hub-Signature-256", "sha256="+webhook.Sign([]byte(body), secret))
+	return header
+}
+
+func TestWebhookServiceDebouncesPushes(t *testing.T) {
+	svc, db, published := newTestWebhookService(t)
+	ctx := context.Background()
+	repo := &model.Repository{Name: "demo", URL: "https://github.com/acme/demo", Status: "completed", CloneBranch: "main"}
+	if err := db.Create(repo).Error; err != nil {
+		t.Fatalf("create repo error: %v", err)
+	}
+
+	hook, secret, err := svc.Configure(ctx, repo.ID, WebhookRequest{})
+	if err != nil || secret == "" {
+		t.Fatalf("configure error: %v, secret=%q", err, secret)
+	}
+	if strings.Contains(hook.Secret, secret) {
+		t.Fatalf("secret must be encrypted at rest")
+	}
+
+	body := `{"ref":"refs/heads/main","after":"abc123"}`
+	if _, err := svc.Receive(ctx, repo.ID, githubPush(body, "wrong"), []byte(body)); !errors.Is(err, webhook.ErrInvalidSignature) {
+		t.Fatalf("bad signature: %v", err)
+	}
+	// 未跟随的分支不触发
+	other := `{"ref":"refs/heads/feature","after":"abc123"}`
+	if delivery, err := svc.Receive(ctx, repo.ID, githubPush(other, secret), []byte(other)); err != nil || delivery.Scheduled {
+		t.Fatalf("untracked branch = %+v, %v", delivery, err)
+	}
+
+	// 防抖期内的连续推送只触发一次
+	for range 3 {
+		delivery, err := svc.Receive(ctx, repo.ID, githubPush(body, secret), []byte(body))
+		if err != nil || !delivery.Scheduled {
+			t.Fatalf("push = %+v, %v", delivery, err)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := published.Load(); got != 1 {
+		t.Fatalf("published %d incremental events, want 1", got)
+	}
+
+	stored, err := svc.Get(ctx, repo.ID)
+	if err != nil || stored.LastEventAt == nil {
+		t.Fatalf("delivery should be recorded: %+v, %v", stored, err)
+	}
+}
+
+func TestWebhookServiceBranchPatterns(t *testing.T) {
+	svc, db, published := newTestWebhookService(t)
+	ctx := context.Background()
+	repo := &model.Repository{Name: "demo", URL: "https://github.com/acme/demo", Status: "completed", CloneBranch: "main"}
+	if err := db.Create(repo).Error; err != nil {
+		t.Fatalf("create repo error: %v", err)
+	}
+
+	if _, _, err := svc.Configure(ctx, repo.ID, WebhookRequest{Branches: []string{"release/["}}); !errors.Is(err, ErrInvalidWebhookBranch) {
+		t.Fatalf("invalid pattern: %v", err)
+	}
+	if _, _, err := svc.Configure(ctx, repo.ID, WebhookRequest{Secret: "s3cret", Branches: []string{"release/*"}}); err != nil {
+		t.Fatalf("configure error: %v", err)
+	}
+
+	main := `{"ref":"refs/heads/main","after":"abc123"}`
+	if delivery, err := svc.Receive(ctx, repo.ID, githubPush(main, "s3cret"), []byte(main)); err != nil || delivery.Scheduled {
+		t.Fatalf("main should not match release/* = %+v, %v", delivery, err)
+	}
+	release := `{"ref":"refs/heads/release/1.2","after":"abc123"}`
+	if delivery, err := svc.Receive(ctx, repo.ID, githubPush(release, "s3cret"), []byte(release)); err != nil || !delivery.Scheduled {
+		t.Fatalf("release branch = %+v, %v", delivery, err)
+	}
+
+	// 删除配置会取消等待中的分析
+	if err := svc.Delete(ctx, repo.ID); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := published.Load(); got != 0 {
+		t.Fatalf("published %d events after delete, want 0", got)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/pkg/adkagents"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrWorkspaceNameRequired 工作空间名称不能为空
+	ErrWorkspaceNameRequired = errors.New("workspace name is required")
+	// ErrInvalidWorkspaceSlug 工作空间标识不合法
+	ErrInvalidWorkspaceSlug = errors.New("workspace slug must match [a-z0-9][a-z0-9-]*")
+	// ErrDefaultWorkspace 默认工作空间不能删除
+	ErrDefaultWorkspace = errors.New("default workspace cannot be deleted")
+	// ErrWorkspaceNotEmpty 工作空间仍有资源，不能删除
+	ErrWorkspaceNotEmpty = errors.New("workspace still has repositories, api keys, git credentials, sync targets or chat sessions")
+	// ErrWorkspaceForbidden 用户不是工作空间成员
+	ErrWorkspaceForbidden = errors.New("not a member of this workspace")
+)
+
+// 路由中可按 ID 定位、需要校验所属工作空间的资源类型
+const (
+	WorkspaceResourceRepository  = "repository"
+	WorkspaceResourceTask        = "task"
+	WorkspaceResourceDocument    = "document"
+	WorkspaceResourceUserRequest = "user_request"
+)
+
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,99}$`)
+
+// WorkspaceRequest 创建或更新工作空间请求，更新时空字段不修改
+type WorkspaceRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	AgentDir    string `json:"agent_dir"`
+	SkillDir    string `json:"skill_dir"`
+}
+
+// WorkspaceService 工作空间与成员管理
+type WorkspaceService struct {
+	repo            repository.WorkspaceRepository
+	userRepo        repository.UserRepository
+	repoRepo        repository.RepoRepository
+	taskRepo        repository.TaskRepository
+	docRepo         repository.DocumentRepository
+	userRequestRepo repository.UserRequestRepository
+}
+
+// NewWorkspaceService 创建工作空间服务
+func NewWorkspaceService(
+	repo repository.WorkspaceRepository,
+	userRepo repository.UserRepository,
+	repoRepo repository.RepoRepository,
+	taskRepo repository.TaskRepository,
+	docRepo repository.DocumentRepository,
+	userRequestRepo repository.UserRequestRepository,
+) *WorkspaceService {
+	return &WorkspaceService{
+		repo:            repo,
+		userRepo:        userRepo,
+		repoRepo:        repoRepo,
+		taskRepo:        taskRepo,
+		docRepo:         docRepo,
+		userRequestRepo: userRequestRepo,
+	}
+}
+
+// List 列出用户可访问的工作空间，管理员可见全部
+func (s *WorkspaceService) List(ctx context.Context, user *model.User) ([]model.Workspace, error) {
+	if user == nil || user.Role == model.RoleAdmin {
+		return s.repo.List(ctx)
+	}
+	return s.repo.ListByUser(ctx, user.ID)
+}
+
+// Get 获取工作空间
+func (s *WorkspaceService) Get(ctx context.Context, id uint) (*model.Workspace, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 创建工作空间
+func (s *WorkspaceService) Create(ctx context.Context, req *WorkspaceRequest) (*model.Workspace, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrWorkspaceNameRequired
+	}
+	slug := strings.TrimSpace(req.Slug)
+	if !workspaceSlugPattern.MatchString(slug) {
+		return nil, ErrInvalidWorkspaceSlug
+	}
+	if _, err := s.repo.GetBySlug(ctx, slug); err == nil {
+		return nil, repository.ErrWorkspaceDuplicate
+	}
+
+	workspace := &model.Workspace{
+		Name:        name,
+		Slug:        slug,
+		Description: req.Description,
+		AgentDir:    strings.TrimSpace(req.AgentDir),
+		SkillDir:    strings.TrimSpace(req.SkillDir),
+	}
+	if err := s.repo.Create(ctx, workspace); err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("CreateWorkspace: id=%d, slug=%s", workspace.ID, workspace.Slug)
+	return workspace, nil
+}
+
+// Update 更新工作空间，目录传入空字符串不修改，传入 "-" 清除覆盖
+func (s *WorkspaceService) Update(ctx context.Context, id uint, req *WorkspaceRequest) (*model.Workspace, error) {
+	workspace, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		workspace.Name = name
+	}
+	if slug := strings.TrimSpace(req.Slug); slug != "" && slug != workspace.Slug {
+		if !workspaceSlugPattern.MatchString(slug) {
+			return nil, ErrInvalidWorkspaceSlug
+		}
+		if _, err := s.repo.GetBySlug(ctx, slug); err == nil {
+			return nil, repository.ErrWorkspaceDuplicate
+		}
+		workspace.Slug = slug
+	}
+	if req.Description != "" {
+		workspace.Description = req.Description
+	}
+	workspace.AgentDir = updateDir(workspace.AgentDir, req.AgentDir)
+	workspace.SkillDir = updateDir(workspace.SkillDir, req.SkillDir)
+	if err := s.repo.Save(ctx, workspace); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// updateDir 目录字段更新规则：空不修改，"-" 清除
+func updateDir(current, value string) string {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "":
+		return current
+	case "-":
+		return ""
+	}
+	return value
+}
+
+// Delete 删除工作空间，默认工作空间与仍有资源的工作空间不能删除
+func (s *WorkspaceService) Delete(ctx context.Context, id uint) error {
+	if id == model.DefaultWorkspaceID {
+		return ErrDefaultWorkspace
+	}
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repo.CountResources(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrWorkspaceNotEmpty
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// ListMembers 列出工作空间成员
+func (s *WorkspaceService) ListMembers(ctx context.Context, id uint) ([]model.WorkspaceMember, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, id)
+}
+
+// AddMember 添加工作空间成员
+func (s *WorkspaceService) AddMember(ctx context.Context, id, userID uint) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	return s.repo.AddMember(ctx, id, userID)
+}
+
+// RemoveMember 移除工作空间成员
+func (s *WorkspaceService) RemoveMember(ctx context.Context, id, userID uint) error {
+	return s.repo.RemoveMember(ctx, id, userID)
+}
+
+// Authorize 校验用户能否访问工作空间
+// 默认工作空间对所有用户开放；管理员或未启用认证（user 为 nil）时可访问任意工作空间
+func (s *WorkspaceService) Authorize(ctx context.Context, user *model.User, id uint) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if id == model.DefaultWorkspaceID || user == nil || user.Role == model.RoleAdmin {
+		return nil
+	}
+	ok, err := s.repo.IsMember(ctx, id, user.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWorkspaceForbidden
+	}
+	return nil
+}
+
+// ResourceWorkspace 返回资源所属的工作空间，任务、文档与用户需求按所属仓库判断
+func (s *WorkspaceService) ResourceWorkspace(ctx context.Context, kind string, id uint) (uint, error) {
+	repoID := id
+	switch kind {
+	case WorkspaceResourceRepository:
+	case WorkspaceResourceTask:
+		task, err := s.taskRepo.Get(id)
+		if err != nil {
+			return 0, err
+		}
+		repoID = task.RepositoryID
+	case WorkspaceResourceDocument:
+		doc, err := s.docRepo.Get(id)
+		if err != nil {
+			return 0, err
+		}
+		repoID = doc.RepositoryID
+	case WorkspaceResourceUserRequest:
+		request, err := s.userRequestRepo.GetByID(id)
+		if err != nil {
+			return 0, err
+		}
+		repoID = request.RepositoryID
+	default:
+		return 0, fmt.Errorf("unknown workspace resource: %s", kind)
+	}
+	// 不带工作空间查询，由调用方比较归属
+	repo, err := s.repoRepo.GetBasic(context.Background(), repoID)
+	if err != nil {
+		return 0, err
+	}
+	return repo.WorkspaceID, nil
+}
+
+// Dirs 解析 context 中工作空间的 agent/skill 目录覆盖，供 Agent 管理器使用
+func (s *WorkspaceService) Dirs(ctx context.Context) adkagents.WorkspaceDirs {
+	id, ok := repository.WorkspaceFromContext(ctx)
+	if !ok {
+		return adkagents.WorkspaceDirs{}
+	}
+	workspace, err := s.repo.GetByID(context.Background(), id)
+	if err != nil {
+		klog.Warningf("WorkspaceService.Dirs: workspace %d not found: %v", id, err)
+		return adkagents.WorkspaceDirs{}
+	}
+	return adkagents.WorkspaceDirs{AgentDir: workspace.AgentDir, SkillDir: workspace.SkillDir}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func newTestWorkspaceService(t *testing.T) (*WorkspaceService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db error: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Workspace{}, &model.WorkspaceMember{}, &model.User{}, &model.Repository{}, &model.Task{}, &model.Document{}, &model.UserRequest{}, &model.APIKey{}, &model.GitCredential{}, &model.SyncTarget{}, &model.ChatSession{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	if err := db.Create(&model.Workspace{ID: model.DefaultWorkspaceID, Name: "Default", Slug: "default"}).Error; err != nil {
+		t.Fatalf("create default workspace error: %v", err)
+	}
+	svc := NewWorkspaceService(
+		repository.NewWorkspaceRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewRepoRepository(db),
+		repository.NewTaskRepository(db),
+		repository.NewDocumentRepository(db),
+		repository.NewUserRequestRepository(db),
+	)
+	return svc, db
+}
+
+func TestWorkspaceServiceAuthorize(t *testing.T) {
+	svc, db := newTestWorkspaceService(t)
+	ctx := context.Background()
+
+	alice := &model.User{Username: "alice", Role: model.RoleEditor, Status: model.UserStatusEnabled}
+	bob := &model.User{Username: "bob", Role: model.RoleEditor, Status: model.UserStatusEnabled}
+	admin := &model.User{Username: "root", Role: model.RoleAdmin, Status: model.UserStatusEnabled}
+	for _, u := range []*model.User{alice, bob, admin} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user error: %v", err)
+		}
+	}
+
+	team, err := svc.Create(ctx, &WorkspaceRequest{Name: "Team A", Slug: "team-a", AgentDir: "/data/team-a/agents"})
+	if err != nil {
+		t.Fatalf("create workspace error: %v", err)
+	}
+	if _, err := svc.Create(ctx, &WorkspaceRequest{Name: "Dup", Slug: "team-a"}); !errors.Is(err, repository.ErrWorkspaceDuplicate) {
+		t.Fatalf("expected duplicate slug error, got %v", err)
+	}
+	if _, err := svc.Create(ctx, &WorkspaceRequest{Name: "Bad", Slug: "Team A"}); !errors.Is(err, ErrInvalidWorkspaceSlug) {
+		t.Fatalf("expected invalid slug error, got %v", err)
+	}
+	if err := svc.AddMember(ctx, team.ID, alice.ID); err != nil {
+		t.Fatalf("add member error: %v", err)
+	}
+
+	if err := svc.Authorize(ctx, alice, team.ID); err != nil {
+		t.Fatalf("member should access workspace: %v", err)
+	}
+	if err := svc.Authorize(ctx, bob, team.ID); !errors.Is(err, ErrWorkspaceForbidden) {
+		t.Fatalf("expected forbidden for non-member, got %v", err)
+	}
+	if err := svc.Authorize(ctx, bob, model.DefaultWorkspaceID); err != nil {
+		t.Fatalf("default workspace should be open: %v", err)
+	}
+	if err := svc.Authorize(ctx, admin, team.ID); err != nil {
+		t.Fatalf("admin should access any workspace: %v", err)
+	}
+	if err := svc.Authorize(ctx, admin, 99); !errors.Is(err, repository.ErrWorkspaceNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	visible, err := svc.List(ctx, bob)
+	if err != nil || len(visible) != 1 {
+		t.Fatalf("non-member should only see default workspace, got %d (%v)", len(visible), err)
+	}
+	visible, _ = svc.List(ctx, alice)
+	if len(visible) != 2 {
+		t.Fatalf("member should see joined workspace, got %d", len(visible))
+	}
+
+	dirs := svc.Dirs(repository.WithWorkspace(ctx, team.ID))
+	if dirs.AgentDir != "/data/team-a/agents" || dirs.SkillDir != "" {
+		t.Fatalf("unexpected workspace dirs: %+v", dirs)
+	}
+	if dirs := svc.Dirs(ctx); dirs.AgentDir != "" {
+		t.Fatalf("system context should not resolve workspace dirs: %+v", dirs)
+	}
+}
+
+func TestWorkspaceServiceResourceAndDelete(t *testing.T) {
+	svc, db := newTestWorkspaceService(t)
+	ctx := context.Background()
+
+	team, err := svc.Create(ctx, &WorkspaceRequest{Name: "Team B", Slug: "team-b"})
+	if err != nil {
+		t.Fatalf("create workspace error: %v", err)
+	}
+	repo := &model.Repository{Name: "svc", URL: "https://github.com/org/svc"}
+	if err := repository.NewRepoRepository(db).Create(repository.WithWorkspace(ctx, team.ID), repo); err != nil {
+		t.Fatalf("create repo error: %v", err)
+	}
+	task := &model.Task{RepositoryID: repo.ID, Title: "overview"}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("create task error: %v", err)
+	}
+	doc := &model.Document{RepositoryID: repo.ID, Title: "overview", IsLatest: true}
+	if err := db.Create(doc).Error; err != nil {
+		t.Fatalf("create doc error: %v", err)
+	}
+
+	for kind, id := range map[string]uint{
+		WorkspaceResourceRepository: repo.ID,
+		WorkspaceResourceTask:       task.ID,
+		WorkspaceResourceDocument:   doc.ID,
+	} {
+		owner, err := svc.ResourceWorkspace(repository.WithWorkspace(ctx, model.DefaultWorkspaceID), kind, id)
+		if err != nil || owner != team.ID {
+			t.Fatalf("%s: expected workspace %d, got %d (%v)", kind, team.ID, owner, err)
+		}
+	}
+
+	if err := svc.Delete(ctx, team.ID); !errors.Is(err, ErrWorkspaceNotEmpty) {
+		t.Fatalf("expected not empty error, got %v", err)
+	}
+	if err := svc.Delete(ctx, model.DefaultWorkspaceID); !errors.Is(err, ErrDefaultWorkspace) {
+		t.Fatalf("expected default workspace error, got %v", err)
+	}
+	if err := db.Delete(&model.Repository{}, repo.ID).Error; err != nil {
+		t.Fatalf("delete repo error: %v", err)
+	}
+	if err := svc.Delete(ctx, team.ID); err != nil {
+		t.Fatalf("delete workspace error: %v", err)
+	}
+}
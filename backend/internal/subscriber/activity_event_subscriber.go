@@ -1,6 +1,7 @@
 package subscriber
 
 import (
+	"context"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -31,7 +32,7 @@ func (s *ActivityEventSubscriber) OnBrowseActivity(event eventbus.BrowseActivity
 	}
 
 	// 获取仓库基本信息
-	repo, err := s.repoRepo.GetBasic(event.RepositoryID)
+	repo, err := s.repoRepo.GetBasic(context.Background(), event.RepositoryID)
 	if err != nil {
 		klog.V(6).Infof("获取仓库基本信息失败: repoID=%d, error=%v", event.RepositoryID, err)
 		return
@@ -77,7 +78,7 @@ func (s *ActivityEventSubscriber) OnBrowseActivity(event eventbus.BrowseActivity
 	repo.NextUpdateTime = &newNextUpdateTime
 
 	// 保存更新
-	if err := s.repoRepo.Save(repo); err != nil {
+	if err := s.repoRepo.Save(context.Background(), repo); err != nil {
 		klog.Errorf("更新仓库活跃度信息失败: repoID=%d, error=%v", event.RepositoryID, err)
 		return
 	}
@@ -0,0 +1,36 @@
+package subscriber
+
+import (
+	"context"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"github.com/weibaohui/opendeepwiki/backend/internal/service"
+	"k8s.io/klog/v2"
+)
+
+// DocIndexSubscriber 监听文档保存/更新事件，增量维护文档搜索索引
+type DocIndexSubscriber struct {
+	docService *service.DocumentService
+}
+
+func NewDocIndexSubscriber(docService *service.DocumentService) *DocIndexSubscriber {
+	return &DocIndexSubscriber{docService: docService}
+}
+
+func (s *DocIndexSubscriber) Register(bus *eventbus.DocEventBus) {
+	if bus == nil {
+		return
+	}
+	bus.Subscribe(eventbus.DocEventSaved, s.handleDocChanged)
+	bus.Subscribe(eventbus.DocEventUpdated, s.handleDocChanged)
+}
+
+// handleDocChanged 重新索引发生变化的文档
+func (s *DocIndexSubscriber) handleDocChanged(ctx context.Context, event eventbus.DocEvent) error {
+	if err := s.docService.IndexDocument(event.DocID); err != nil {
+		klog.V(6).Infof("文档索引更新失败: type=%s, repositoryID=%d, docID=%d, error=%v", event.Type, event.RepositoryID, event.DocID, err)
+		return nil
+	}
+	klog.V(6).Infof("文档索引更新成功: type=%s, repositoryID=%d, docID=%d", event.Type, event.RepositoryID, event.DocID)
+	return nil
+}
@@ -0,0 +1,98 @@
+package subscriber
+
+import (
+	"context"
+	"sync"
+
+	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
+	"k8s.io/klog/v2"
+)
+
+// 同时进行的向量生成任务数，避免大量文档同时保存时压垮向量模型
+const embeddingConcurrency = 2
+
+type embeddingIndexService interface {
+	IndexDocument(ctx context.Context, docID uint) error
+	IndexRepositorySources(ctx context.Context, repoID uint) (int, error)
+	HasSourceIndex(ctx context.Context, repoID uint) (bool, error)
+}
+
+// EmbeddingSubscriber 监听文档与仓库事件，异步生成向量分块
+type EmbeddingSubscriber struct {
+	service   embeddingIndexService
+	semaphore chan struct{}
+
+	mutex   sync.Mutex
+	pending map[uint]bool // 正在生成源码向量的仓库，避免重复触发
+}
+
+func NewEmbeddingSubscriber(service embeddingIndexService) *EmbeddingSubscriber {
+	return &EmbeddingSubscriber{
+		service:   service,
+		semaphore: make(chan struct{}, embeddingConcurrency),
+		pending:   make(map[uint]bool),
+	}
+}
+
+func (s *EmbeddingSubscriber) Register(docBus *eventbus.DocEventBus, repoBus *eventbus.RepositoryEventBus) {
+	if docBus != nil {
+		docBus.Subscribe(eventbus.DocEventSaved, s.handleDocChanged)
+		docBus.Subscribe(eventbus.DocEventUpdated, s.handleDocChanged)
+	}
+	if repoBus != nil {
+		repoBus.Subscribe(eventbus.RepositoryEventIncrementalUpdated, s.handleRepoUpdated)
+	}
+}
+
+// handleDocChanged 异步为文档生成向量；仓库源码尚未索引时一并生成
+func (s *EmbeddingSubscriber) handleDocChanged(ctx context.Context, event eventbus.DocEvent) error {
+	go s.run(func(ctx context.Context) {
+		if err := s.service.IndexDocument(ctx, event.DocID); err != nil {
+			klog.V(6).Infof("文档向量生成失败: type=%s, repositoryID=%d, docID=%d, error=%v", event.Type, event.RepositoryID, event.DocID, err)
+			return
+		}
+		klog.V(6).Infof("文档向量生成成功: type=%s, repositoryID=%d, docID=%d", event.Type, event.RepositoryID, event.DocID)
+
+		if indexed, err := s.service.HasSourceIndex(ctx, event.RepositoryID); err == nil && !indexed {
+			s.indexSources(ctx, event.RepositoryID)
+		}
+	})
+	return nil
+}
+
+// handleRepoUpdated 仓库增量更新后重新生成源码向量
+func (s *EmbeddingSubscriber) handleRepoUpdated(ctx context.Context, event eventbus.RepositoryEvent) error {
+	go s.run(func(ctx context.Context) {
+		s.indexSources(ctx, event.RepositoryID)
+	})
+	return nil
+}
+
+func (s *EmbeddingSubscriber) indexSources(ctx context.Context, repoID uint) {
+	s.mutex.Lock()
+	if s.pending[repoID] {
+		s.mutex.Unlock()
+		return
+	}
+	s.pending[repoID] = true
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.pending, repoID)
+		s.mutex.Unlock()
+	}()
+
+	count, err := s.service.IndexRepositorySources(ctx, repoID)
+	if err != nil {
+		klog.V(6).Infof("源码向量生成失败: repositoryID=%d, error=%v", repoID, err)
+		return
+	}
+	klog.V(6).Infof("源码向量生成成功: repositoryID=%d, chunks=%d", repoID, count)
+}
+
+// run 在并发配额内执行向量生成
+func (s *EmbeddingSubscriber) run(fn func(ctx context.Context)) {
+	s.semaphore <- struct{}{}
+	defer func() { <-s.semaphore }()
+	fn(context.Background())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/weibaohui/opendeepwiki/backend/internal/domain"
 	"github.com/weibaohui/opendeepwiki/backend/internal/eventbus"
 	"github.com/weibaohui/opendeepwiki/backend/internal/model"
+	"github.com/weibaohui/opendeepwiki/backend/internal/repository"
 	"k8s.io/klog/v2"
 )
 
@@ -54,6 +55,7 @@ func (s *TaskEventSubscriber) handleDocWrite(ctx context.Context, event eventbus
 	if event.RepositoryID == 0 {
 		return fmt.Errorf("仓库ID为空")
 	}
+	ctx = eventContext(ctx, event)
 	var taskErr error
 	var taskID uint
 	if event.WriterName != "" {
@@ -83,6 +85,7 @@ func (s *TaskEventSubscriber) handleTocWrite(ctx context.Context, event eventbus
 	if event.RepositoryID == 0 {
 		return fmt.Errorf("仓库ID为空")
 	}
+	ctx = eventContext(ctx, event)
 	task, err := s.taskService.CreateTocWriteTask(ctx, event.RepositoryID, event.Title, event.SortOrder)
 	if err != nil {
 		klog.Errorf("任务事件处理失败: type=%s, repoID=%d, error=%v", event.Type, event.RepositoryID, err)
@@ -99,6 +102,7 @@ func (s *TaskEventSubscriber) handleTitleRewrite(ctx context.Context, event even
 	if event.DocID == 0 {
 		return fmt.Errorf("文档ID为空")
 	}
+	ctx = eventContext(ctx, event)
 	task, err := s.taskService.CreateTitleRewriteTask(ctx, event.RepositoryID, event.Title, event.RunAfter, event.DocID, event.SortOrder)
 	if err != nil {
 		klog.Errorf("任务事件处理失败: type=%s, repoID=%d, error=%v", event.Type, event.RepositoryID, err)
@@ -121,3 +125,12 @@ func (s *TaskEventSubscriber) handleUserRequest(ctx context.Context, event event
 	klog.V(6).Infof("任务事件处理成功: type=%s, repoID=%d, taskID=%d", event.Type, event.RepositoryID, task.ID)
 	return nil
 }
+
+// eventContext 事件指定了引用文档集时，创建的任务归属该文档集；
+// 未指定时沿用上下文中的引用，使引用文档集的目录任务派生的文档任务仍属于同一文档集
+func eventContext(ctx context.Context, event eventbus.TaskEvent) context.Context {
+	if event.Ref == "" {
+		return ctx
+	}
+	return repository.WithDocRef(ctx, event.Ref)
+}
@@ -24,6 +24,7 @@ export default function APIKeyList() {
         { value: 'openai', label: 'OpenAI' },
         { value: 'anthropic', label: 'Anthropic' },
         { value: 'deepseek', label: 'DeepSeek' },
+        { value: 'replay', label: 'Replay' },
         { value: 'other', label: 'Other' }
     ];
     const [loading, setLoading] = useState(true);
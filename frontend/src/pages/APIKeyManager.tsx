@@ -299,6 +299,7 @@ export default function APIKeyManager() {
                                         <Option value="openai">OpenAI</Option>
                                         <Option value="anthropic">Anthropic</Option>
                                         <Option value="deepseek">DeepSeek</Option>
+                                        <Option value="replay">Replay</Option>
                                         <Option value="other">Other</Option>
                                     </Select>
                                 </Form.Item>